@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/aneeshsunganahalli/Gopher/examples/handlers"
+	"github.com/aneeshsunganahalli/Gopher/internal/bridge"
+	"github.com/aneeshsunganahalli/Gopher/internal/config"
+	"github.com/aneeshsunganahalli/Gopher/internal/job"
+	_ "github.com/aneeshsunganahalli/Gopher/internal/kafka" // Client the bridge consumes with
+	"github.com/aneeshsunganahalli/Gopher/internal/queue"
+	"go.uber.org/zap"
+)
+
+// The bridge consumes the Kafka topics in BRIDGE_TOPICS and enqueues each
+// record as a job of the topic's mapped type, so Kafka consumers can be moved
+// to Gopher handlers one topic at a time.
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	logger, err := initLogger(cfg.Log)
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
+	if cfg.Queue.Backend != config.QueueBackendRedis {
+		logger.Fatal("The bridge only supports the redis queue backend")
+	}
+
+	// Sign enqueued jobs and verify dequeued ones when signing keys are configured
+	signer, err := cfg.Signing.Signer()
+	if err != nil {
+		logger.Fatal("Failed to load signing keys", zap.Error(err))
+	}
+	if signer != nil {
+		logger.Info("Job signing enabled",
+			zap.String("algorithm", signer.Algorithm()),
+			zap.Bool("verify_only", !signer.CanSign()),
+		)
+	}
+
+	routes, err := cfg.Bridge.Routes()
+	if err != nil {
+		logger.Fatal("Failed to load bridge topics", zap.Error(err))
+	}
+	if len(routes) == 0 {
+		logger.Fatal("Set BRIDGE_TOPICS to topic:job_type pairs")
+	}
+	if len(cfg.Kafka.Brokers) == 0 {
+		logger.Fatal("Set KAFKA_BROKERS")
+	}
+
+	jobQueue, err := queue.NewRedisQueue(queue.RedisOptions{
+		URL:                   cfg.Redis.URL,
+		Password:              cfg.Redis.Password,
+		DB:                    cfg.Redis.DB,
+		ConnectTimeout:        cfg.Redis.Timeout,
+		CommandTimeout:        cfg.Redis.Timeout,
+		ClusterAddrs:          cfg.Redis.ClusterAddrs,
+		KeyPrefix:             cfg.Redis.KeyPrefix,
+		Compression:           cfg.Redis.Compression,
+		CompressionThreshold:  cfg.Redis.CompressionThreshold,
+		Format:                cfg.Redis.Format,
+		SentinelMaster:        cfg.Redis.SentinelMaster,
+		SentinelAddrs:         cfg.Redis.SentinelAddrs,
+		SentinelPassword:      cfg.Redis.SentinelPassword,
+		TLSCACert:             cfg.Redis.TLSCACert,
+		TLSCert:               cfg.Redis.TLSCert,
+		TLSKey:                cfg.Redis.TLSKey,
+		TLSInsecureSkipVerify: cfg.Redis.TLSInsecureSkipVerify,
+		Signing:               queue.Signing{Signer: signer, AllowUnsigned: cfg.Signing.AllowUnsigned},
+	})
+	if err != nil {
+		logger.Fatal("Failed to initialize Redis queue", zap.Error(err))
+	}
+	defer jobQueue.Close()
+
+	keyring, err := cfg.Encryption.Keyring()
+	if err != nil {
+		logger.Fatal("Failed to load encryption keys", zap.Error(err))
+	}
+	if keyring != nil {
+		jobQueue.SetKeyring(keyring)
+	}
+
+	var bridgeQueue queue.Queue = jobQueue
+	if cfg.Server.QueueAlias != "" {
+		bridgeQueue = queue.NewAliasQueue(jobQueue, cfg.Server.QueueAlias)
+	}
+
+	registry := job.NewRegistry(logger)
+	if err := registerJobHandlers(registry, logger); err != nil {
+		logger.Fatal("Failed to register job handlers", zap.Error(err))
+	}
+
+	topics := make([]string, 0, len(routes))
+	for topic := range routes {
+		topics = append(topics, topic)
+	}
+
+	dialCtx, cancelDial := context.WithTimeout(context.Background(), cfg.Redis.Timeout)
+	client, err := queue.DialKafka(dialCtx, queue.KafkaOptions{
+		Brokers: cfg.Kafka.Brokers,
+		Topics:  topics,
+		Group:   cfg.Bridge.Group,
+	})
+	cancelDial()
+	if err != nil {
+		logger.Fatal("Failed to connect to Kafka", zap.Error(err))
+	}
+	defer client.Close()
+
+	b, err := bridge.New(client, routes, bridgeQueue, registry, cfg.Worker.MaxRetries, logger)
+	if err != nil {
+		logger.Fatal("Failed to configure bridge", zap.Error(err))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.Run(ctx)
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logger.Info("Shutting down bridge...")
+	cancel()
+	<-done
+	logger.Info("Bridge shutdown complete")
+}
+
+func initLogger(cfg config.LogConfig) (*zap.Logger, error) {
+	var zapConfig zap.Config
+
+	if cfg.Format == "console" {
+		zapConfig = zap.NewDevelopmentConfig()
+	} else {
+		zapConfig = zap.NewProductionConfig()
+	}
+
+	// Set log level
+	switch cfg.Level {
+	case "debug":
+		zapConfig.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
+	case "info":
+		zapConfig.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
+	case "warn":
+		zapConfig.Level = zap.NewAtomicLevelAt(zap.WarnLevel)
+	case "error":
+		zapConfig.Level = zap.NewAtomicLevelAt(zap.ErrorLevel)
+	default:
+		zapConfig.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
+	}
+
+	return zapConfig.Build()
+}
+
+func registerJobHandlers(registry *job.Registry, logger *zap.Logger) error {
+	// Register email handler
+	emailHandler := handlers.NewEmailJobHandler(logger)
+	if err := registry.Register(emailHandler); err != nil {
+		return err
+	}
+
+	// Register image handler
+	imageHandler := handlers.NewImageJobHandler(logger)
+	if err := registry.Register(imageHandler); err != nil {
+		return err
+	}
+
+	// Register math handler
+	mathHandler := handlers.NewMathJobHandler(logger)
+	if err := registry.Register(mathHandler); err != nil {
+		return err
+	}
+
+	logger.Info("All job handlers registered successfully")
+	return nil
+}
@@ -1,17 +1,34 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
 	"time"
 
+	"github.com/aneeshsunganahalli/Gopher/internal/api"
 	"github.com/aneeshsunganahalli/Gopher/internal/config"
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/aneeshsunganahalli/Gopher/internal/limiter"
 	"github.com/aneeshsunganahalli/Gopher/internal/queue"
 	"github.com/aneeshsunganahalli/Gopher/pkg/types"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
 )
 
 var rootCmd = &cobra.Command{
@@ -21,11 +38,110 @@ var rootCmd = &cobra.Command{
 Complete documentation is available at https://github.com/aneeshsunganahalli/Gopher`,
 }
 
+// displayTimezone is the IANA zone used when printing timestamps
+var displayTimezone string
+
+// Output formats accepted by --output
+const (
+	outputTable = "table"
+	outputWide  = "wide" // table with extra columns
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// outputFormat is how listing commands render their results
+var outputFormat string
+
+// Exit codes, documented for scripts and cron jobs
+const (
+	exitOK         = 0
+	exitError      = 1 // Invalid arguments, configuration or any other failure
+	exitNotFound   = 2 // The job, schedule or job type doesn't exist
+	exitConnection = 3 // Redis, the database or the server couldn't be reached
+	exitPartial    = 4 // Some of the jobs of a batch were processed before a failure
+)
+
+// exitCode is what the CLI exits with once the command returns
+var exitCode = exitOK
+
+// quiet suppresses everything but errors, leaving the exit code to tell the outcome
+var quiet bool
+
+// quietOutput is the real stdout under --quiet, for the few values scripts capture
+var quietOutput *os.File
+
+// remote is the server the CLI goes through instead of Redis when --server is set
+var remote apiOptions
+
+// directOnlyAnnotation marks commands the server's API has no equivalent for
+const directOnlyAnnotation = "direct-only"
+
+// activeProfile is the connection profile in use, empty for none
+var activeProfile string
+
+// logLevel is raised to errors only by --quiet
+var logLevel = zap.NewAtomicLevelAt(zap.DebugLevel)
+
+// setExitCode records the exit code of a failure; the first one wins
+func setExitCode(code int) {
+	if exitCode == exitOK {
+		exitCode = code
+	}
+}
+
+// errUnknownJobType is returned when the server has no handler for a job type
+var errUnknownJobType = errors.New("unknown job type")
+
+// exitCodeFor classifies a logged error
+func exitCodeFor(err error) int {
+	var netErr net.Error
+	var apiErr *apiError
+	switch {
+	case errors.As(err, &netErr), errors.Is(err, context.DeadlineExceeded), errors.Is(err, io.EOF):
+		return exitConnection
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound,
+		errors.Is(err, queue.ErrScheduleNotFound), errors.Is(err, queue.ErrRecordNotFound), errors.Is(err, errUnknownJobType):
+		return exitNotFound
+	}
+	return exitError
+}
+
+// exitCodeCore sets the exit code from the errors commands log, so every
+// failure path exits non-zero with a code matching what went wrong
+type exitCodeCore struct {
+	zapcore.Core
+}
+
+func (c exitCodeCore) With(fields []zapcore.Field) zapcore.Core {
+	return exitCodeCore{c.Core.With(fields)}
+}
+
+func (c exitCodeCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c exitCodeCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if entry.Level >= zapcore.ErrorLevel {
+		code := exitError
+		for _, field := range fields {
+			if err, ok := field.Interface.(error); ok && field.Type == zapcore.ErrorType {
+				code = exitCodeFor(err)
+			}
+		}
+		setExitCode(code)
+	}
+	return c.Core.Write(entry, fields)
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		os.Exit(exitError)
 	}
+	os.Exit(exitCode)
 }
 
 func main() {
@@ -33,62 +149,187 @@ func main() {
 }
 
 func init() {
-	// Initialize logger
-	logger, _ := zap.NewDevelopment()
+	// Initialize logger; logged errors set the exit code
+	logConfig := zap.NewDevelopmentConfig()
+	logConfig.Level = logLevel
+	logger, _ := logConfig.Build(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return exitCodeCore{core}
+	}))
 	defer logger.Sync()
 
+	// The profile has to be applied before the config reads the environment
+	profile, err := applyProfile(logger, os.Args[1:])
+	if err != nil {
+		logger.Fatal("Failed to load profile", zap.Error(err))
+	}
+	activeProfile = profile
+
 	// Load config
 	cfg, err := config.Load()
 	if err != nil {
 		logger.Fatal("Failed to load config", zap.Error(err))
 	}
 
+	// Jobs the CLI enqueues are signed like the server's
+	var signing queue.Signing
+	signer, err := cfg.Signing.Signer()
+	if err != nil {
+		logger.Warn("Ignoring invalid signing keys, enqueued jobs will be unsigned", zap.Error(err))
+	} else {
+		signing = queue.Signing{Signer: signer, AllowUnsigned: cfg.Signing.AllowUnsigned}
+	}
+
 	// Initialize Redis connection
 	redisOpts := queue.RedisOptions{
-		URL:            cfg.Redis.URL,
-		Password:       cfg.Redis.Password,
-		DB:             cfg.Redis.DB,
-		ConnectTimeout: cfg.Redis.Timeout,
-		CommandTimeout: cfg.Redis.Timeout,
+		URL:                   cfg.Redis.URL,
+		Password:              cfg.Redis.Password,
+		DB:                    cfg.Redis.DB,
+		ConnectTimeout:        cfg.Redis.Timeout,
+		CommandTimeout:        cfg.Redis.Timeout,
+		ClusterAddrs:          cfg.Redis.ClusterAddrs,
+		KeyPrefix:             cfg.Redis.KeyPrefix,
+		Compression:           cfg.Redis.Compression,
+		CompressionThreshold:  cfg.Redis.CompressionThreshold,
+		Format:                cfg.Redis.Format,
+		SentinelMaster:        cfg.Redis.SentinelMaster,
+		SentinelAddrs:         cfg.Redis.SentinelAddrs,
+		SentinelPassword:      cfg.Redis.SentinelPassword,
+		TLSCACert:             cfg.Redis.TLSCACert,
+		TLSCert:               cfg.Redis.TLSCert,
+		TLSKey:                cfg.Redis.TLSKey,
+		TLSInsecureSkipVerify: cfg.Redis.TLSInsecureSkipVerify,
+		Signing:               signing,
 	}
 
 	// Setup commands
-	setupCommands(redisOpts, logger)
+	setupCommands(cfg, redisOpts, logger)
 }
 
-func setupCommands(redisOpts queue.RedisOptions, logger *zap.Logger) {
+func setupCommands(cfg *config.Config, redisOpts queue.RedisOptions, logger *zap.Logger) {
+	rootCmd.PersistentFlags().StringVar(&displayTimezone, "timezone", cfg.Server.DisplayTimezone, "Timezone for displayed timestamps, e.g. Europe/Berlin or Local")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", outputTable, "Output format: table, wide, json or yaml")
+	rootCmd.PersistentFlags().BoolVar(&quiet, "quiet", false, "Print nothing but errors and report the outcome in the exit code; submit prints only job IDs")
+	rootCmd.PersistentFlags().StringVar(&remote.URL, "server", os.Getenv("GOPHER_SERVER"), "Go through this server's HTTP API instead of Redis, e.g. https://gopher.internal (default: $GOPHER_SERVER)")
+	rootCmd.PersistentFlags().StringVar(&remote.APIKey, "api-key", os.Getenv("GOPHER_API_KEY"), "API key sent as X-API-Key to the server (default: $GOPHER_API_KEY)")
+	rootCmd.PersistentFlags().DurationVar(&remote.Timeout, "request-timeout", 10*time.Second, "Timeout of each request to the server")
+	rootCmd.PersistentFlags().StringVar(&activeProfile, "profile", activeProfile, "Connection profile from ~/.gopher/config.yaml, e.g. staging (default: $GOPHER_PROFILE or the file's default)")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		switch outputFormat {
+		case outputTable, outputWide, outputJSON, outputYAML:
+		default:
+			return fmt.Errorf("invalid output format %q: must be table, wide, json or yaml", outputFormat)
+		}
+
+		if remote.URL != "" && cmd.Annotations[directOnlyAnnotation] != "" {
+			return fmt.Errorf("%s needs direct Redis access and cannot go through --server", cmd.CommandPath())
+		}
+
+		if quiet {
+			devNull, err := os.Open(os.DevNull)
+			if err != nil {
+				return err
+			}
+			quietOutput, os.Stdout = os.Stdout, devNull
+			logLevel.SetLevel(zap.ErrorLevel)
+		}
+		return nil
+	}
+
 	// Queue stats command
+	var statsWatch time.Duration
 	var statsCmd = &cobra.Command{
 		Use:   "stats",
-		Short: "Show queue statistics",
+		Short: "Show queue, retry, scheduled, DLQ and worker statistics",
+		Long: `Show pending, retrying, scheduled and dead-lettered jobs, live workers and
+throughput counters. With --watch the table refreshes until interrupted and
+adds enqueue and dequeue rates over the last minute.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			printQueueStats(cfg, redisOpts, logger, statsWatch)
+		},
+	}
+	statsCmd.Flags().DurationVarP(&statsWatch, "watch", "w", 0, "Refresh every interval, e.g. 2s, until interrupted")
+
+	// Worker listing command
+	var workersCmd = &cobra.Command{
+		Use:   "workers",
+		Short: "List live worker pools",
+		Run: func(cmd *cobra.Command, args []string) {
+			listWorkers(cfg, redisOpts, logger)
+		},
+	}
+
+	// Job inspection command
+	var inspectCmd = &cobra.Command{
+		Use:   "inspect <job-id>",
+		Short: "Show a job's status, attempts and the progress its handler reported",
+		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			printQueueStats(redisOpts, logger)
+			inspectJob(cfg, redisOpts, logger, args[0])
 		},
 	}
 
 	// Submit job command
-	var jobType, payload string
-	var maxRetries int
+	var jobType, payload, payloadFile, submitQueue string
+	var maxRetries, submitCount, submitPayloadVersion int
+	var submitValidate bool
+	var submitTimeout, submitExpiresIn, submitInterval time.Duration
 	var submitCmd = &cobra.Command{
 		Use:   "submit",
 		Short: "Submit a job to the queue",
 		Run: func(cmd *cobra.Command, args []string) {
-			submitJob(redisOpts, logger, jobType, payload, maxRetries)
+			if payloadFile != "" {
+				if cmd.Flags().Changed("payload") {
+					logger.Error("--payload and --from-file cannot be used together")
+					return
+				}
+				data, err := readPayloadFile(payloadFile)
+				if err != nil {
+					logger.Error("Failed to read payload", zap.String("file", payloadFile), zap.Error(err))
+					return
+				}
+				payload = data
+			}
+			submitJob(cfg, redisOpts, logger, jobType, payload, submitQueue, maxRetries, submitPayloadVersion, submitTimeout, submitExpiresIn, submitCount, submitInterval, submitValidate)
 		},
 	}
 	submitCmd.Flags().StringVarP(&jobType, "type", "t", "", "Job type (required)")
 	submitCmd.Flags().StringVarP(&payload, "payload", "p", "{}", "Job payload as JSON")
+	submitCmd.Flags().StringVarP(&payloadFile, "from-file", "f", "", "Read the JSON payload from a file, or - for stdin")
+	submitCmd.Flags().IntVarP(&submitCount, "count", "n", 1, "Number of copies of the job to submit")
+	submitCmd.Flags().DurationVar(&submitInterval, "interval", 0, "Pause between submissions with --count, e.g. 500ms")
 	submitCmd.Flags().IntVarP(&maxRetries, "retries", "r", 3, "Maximum number of retries")
+	submitCmd.Flags().IntVar(&submitPayloadVersion, "payload-version", 0, "Version of the payload format, checked against the versions the handler accepts (unversioned if 0)")
+	submitCmd.Flags().StringVarP(&submitQueue, "queue", "q", "", "Named queue to submit to (default queue if empty)")
+	submitCmd.Flags().DurationVar(&submitTimeout, "timeout", 0, "How long the handler may run, e.g. 2h (worker default for the type if 0)")
+	submitCmd.Flags().DurationVar(&submitExpiresIn, "expires-in", 0, "Discard the job if no worker starts it within this long, e.g. 15m (never if 0)")
+	submitCmd.Flags().BoolVar(&submitValidate, "validate", false, "Check the payload against the job type's schema from the server before enqueuing (always done with --server)")
 	submitCmd.MarkFlagRequired("type")
 
 	// List failed jobs command
+	var offset, limit int
+	var failureReason string
 	var listFailedCmd = &cobra.Command{
 		Use:   "list-failed",
 		Short: "List failed jobs in the dead letter queue",
 		Run: func(cmd *cobra.Command, args []string) {
-			listFailedJobs(redisOpts, logger)
+			listFailedJobs(cfg, redisOpts, logger, failureReason, offset, limit)
 		},
 	}
+	listFailedCmd.Flags().IntVar(&offset, "offset", 0, "Number of failed jobs to skip")
+	listFailedCmd.Flags().IntVarP(&limit, "limit", "l", 20, "Maximum number of failed jobs to show")
+	listFailedCmd.Flags().StringVar(&failureReason, "reason", "", "Only show jobs with this failure reason (handler_error, timeout, panic, expired, poison, cancelled, incompatible, dependency_failed, unknown)")
+
+	// Triage failed jobs command
+	var triageMax, triageTop int
+	var triageCmd = &cobra.Command{
+		Use:   "triage",
+		Short: "Summarize the dead letter queue by job type and error fingerprint",
+		Run: func(cmd *cobra.Command, args []string) {
+			triageFailedJobs(cfg, redisOpts, logger, triageMax, triageTop)
+		},
+	}
+	triageCmd.Flags().IntVar(&triageMax, "max", 10000, "Maximum number of failed jobs to scan, newest first")
+	triageCmd.Flags().IntVar(&triageTop, "top", 20, "Number of groups to show")
 
 	// Retry failed job command
 	var jobID string
@@ -96,7 +337,7 @@ func setupCommands(redisOpts queue.RedisOptions, logger *zap.Logger) {
 		Use:   "retry",
 		Short: "Retry a failed job from the dead letter queue",
 		Run: func(cmd *cobra.Command, args []string) {
-			retryFailedJob(redisOpts, logger, jobID)
+			retryFailedJob(cfg, redisOpts, logger, jobID)
 		},
 	}
 	retryCmd.Flags().StringVarP(&jobID, "id", "i", "", "Job ID to retry (required)")
@@ -107,136 +348,2930 @@ func setupCommands(redisOpts queue.RedisOptions, logger *zap.Logger) {
 		Use:   "retry-all",
 		Short: "Retry all failed jobs in the dead letter queue",
 		Run: func(cmd *cobra.Command, args []string) {
-			retryAllFailedJobs(redisOpts, logger)
+			retryAllFailedJobs(cfg, redisOpts, logger)
 		},
 	}
 
 	// Purge queue command
-	var queueName string
+	var purgeTargetName, purgeQueueName string
+	var purgeForce bool
 	var purgeCmd = &cobra.Command{
 		Use:   "purge",
-		Short: "Purge a queue",
+		Short: "Permanently delete every job in a queue",
+		Run: func(cmd *cobra.Command, args []string) {
+			purgeQueue(redisOpts, logger, purgeTargetName, purgeQueueName, purgeForce)
+		},
+	}
+	purgeCmd.Flags().StringVarP(&purgeTargetName, "queue", "q", "main", "Queue to purge (main, priority, scheduled, failed)")
+	purgeCmd.Flags().StringVarP(&purgeQueueName, "name", "n", "", "Physical queue to purge with --queue main (default queue if empty)")
+	purgeCmd.Flags().BoolVarP(&purgeForce, "force", "f", false, "Skip the confirmation prompt")
+
+	// Drain and ingest commands for "stop everything and review" incidents
+	var drainQueueName, drainOutput string
+	var drainCmd = &cobra.Command{
+		Use:   "drain",
+		Short: "Remove every pending job from a queue and write it to an NDJSON file without running it",
 		Run: func(cmd *cobra.Command, args []string) {
-			purgeQueue(redisOpts, logger, queueName)
+			drainQueue(redisOpts, logger, drainQueueName, drainOutput)
 		},
 	}
-	purgeCmd.Flags().StringVarP(&queueName, "queue", "q", "main", "Queue to purge (main, scheduled, failed)")
+	drainCmd.Flags().StringVarP(&drainQueueName, "queue", "q", "", "Physical queue to drain (default queue if empty)")
+	drainCmd.Flags().StringVarP(&drainOutput, "output", "o", "drained.jsonl", "Output file, or - for stdout (e.g. to pipe into aws s3 cp - s3://...)")
+	var ingestQueueName, ingestFile string
+	var ingestCmd = &cobra.Command{
+		Use:   "ingest",
+		Short: "Push jobs from a drained NDJSON file back onto a queue",
+		Run: func(cmd *cobra.Command, args []string) {
+			ingestQueue(redisOpts, logger, ingestQueueName, ingestFile)
+		},
+	}
+	ingestCmd.Flags().StringVarP(&ingestQueueName, "queue", "q", "", "Physical queue to ingest into (default queue if empty)")
+	ingestCmd.Flags().StringVarP(&ingestFile, "file", "f", "drained.jsonl", "Input file, or - for stdin")
 
 	// Health check command
+	var healthOpts probeOptions
 	var healthCmd = &cobra.Command{
 		Use:   "health",
 		Short: "Check system health",
+		Long: `Check Redis connectivity, or probe a server/worker health endpoint with --url.
+With --exit-code the command exits 1 when unhealthy, for use as a container health check.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if healthOpts.URL == "" && remote.URL != "" {
+				healthOpts.URL = remote.endpoint("/health")
+			}
+			if !checkHealth(redisOpts, logger, healthOpts) && healthOpts.ExitCode {
+				os.Exit(1)
+			}
+		},
+	}
+	healthCmd.Flags().StringVar(&healthOpts.URL, "url", "", "Health endpoint to probe instead of Redis, e.g. http://localhost:8081/health (default: /health of --server if set)")
+	healthCmd.Flags().BoolVar(&healthOpts.ExitCode, "exit-code", false, "Exit with status 1 when unhealthy")
+	healthCmd.Flags().BoolVar(&healthOpts.JSON, "json", false, "Print the result as JSON")
+	healthCmd.Flags().DurationVar(&healthOpts.Timeout, "timeout", 5*time.Second, "Probe timeout")
+
+	// Readiness probe command
+	var readyOpts probeOptions
+	var readyCmd = &cobra.Command{
+		Use:   "ready",
+		Short: "Check whether a server or worker is ready to take traffic, exiting 1 if not",
+		Run: func(cmd *cobra.Command, args []string) {
+			if readyOpts.URL == "" {
+				readyOpts.URL = fmt.Sprintf("http://%s/readyz", cfg.Server.Address())
+				if remote.URL != "" {
+					readyOpts.URL = remote.endpoint("/readyz")
+				}
+			}
+			if !probeEndpoint("ready", readyOpts) {
+				os.Exit(1)
+			}
+		},
+	}
+	readyCmd.Flags().StringVar(&readyOpts.URL, "url", "", "Readiness endpoint to probe (default: /readyz of --server or the configured server)")
+	readyCmd.Flags().BoolVar(&readyOpts.JSON, "json", false, "Print the result as JSON")
+	readyCmd.Flags().DurationVar(&readyOpts.Timeout, "timeout", 5*time.Second, "Probe timeout")
+
+	// Rotate encryption keys command
+	var rotateKeysCmd = &cobra.Command{
+		Use:   "rotate-keys",
+		Short: "Re-encrypt pending, scheduled and failed job payloads with the active key",
+		Run: func(cmd *cobra.Command, args []string) {
+			rotateKeys(cfg, redisOpts, logger)
+		},
+	}
+
+	// Data-subject erasure command
+	var eraseField, eraseValue string
+	var eraseScrub bool
+	var eraseCmd = &cobra.Command{
+		Use:   "erase",
+		Short: "Remove or scrub all queued jobs whose payload matches a data subject",
+		Run: func(cmd *cobra.Command, args []string) {
+			eraseSubject(cfg, redisOpts, logger, eraseField, eraseValue, eraseScrub)
+		},
+	}
+	eraseCmd.Flags().StringVarP(&eraseField, "field", "f", "", "Payload field path identifying the subject, e.g. user.id (required)")
+	eraseCmd.Flags().StringVarP(&eraseValue, "value", "v", "", "Subject value to match (required)")
+	eraseCmd.Flags().BoolVar(&eraseScrub, "scrub", false, "Replace matching payloads instead of deleting the jobs")
+	eraseCmd.MarkFlagRequired("field")
+	eraseCmd.MarkFlagRequired("value")
+
+	// Job recording commands
+	var recordingCmd = &cobra.Command{
+		Use:   "recording",
+		Short: "Manage the sampled job recording used for replay",
+	}
+	var exportFile string
+	var recordingExportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Write recorded jobs to a JSON lines file",
+		Run: func(cmd *cobra.Command, args []string) {
+			exportRecording(cfg, redisOpts, logger, exportFile)
+		},
+	}
+	recordingExportCmd.Flags().StringVarP(&exportFile, "output", "o", "recording.jsonl", "Output file")
+	var recordingClearCmd = &cobra.Command{
+		Use:   "clear",
+		Short: "Delete all recorded jobs",
+		Run: func(cmd *cobra.Command, args []string) {
+			clearRecording(redisOpts, logger)
+		},
+	}
+	recordingCmd.AddCommand(recordingExportCmd, recordingClearCmd)
+
+	// Replay command
+	var replayFile, replayTarget string
+	var replaySpeed float64
+	var replayCmd = &cobra.Command{
+		Use:   "replay",
+		Short: "Replay recorded jobs against a queue at a chosen speed",
+		Run: func(cmd *cobra.Command, args []string) {
+			replayJobs(cfg, redisOpts, logger, replayFile, replayTarget, replaySpeed)
+		},
+	}
+	replayCmd.Flags().StringVarP(&replayFile, "file", "f", "", "Replay from an exported file instead of the Redis recording")
+	replayCmd.Flags().StringVar(&replayTarget, "target", "", "Redis URL to replay into, e.g. a staging instance (default: REDIS_URL)")
+	replayCmd.Flags().Float64Var(&replaySpeed, "speed", 1, "Speed multiplier for the original timing; 0 replays as fast as possible")
+
+	// Queue alias commands for blue/green cutovers
+	var aliasCmd = &cobra.Command{
+		Use:   "alias",
+		Short: "Manage logical queue aliases",
+	}
+	var aliasListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List queue aliases and their target queues",
+		Run: func(cmd *cobra.Command, args []string) {
+			listAliases(redisOpts, logger)
+		},
+	}
+	var aliasSetCmd = &cobra.Command{
+		Use:   "set <alias> <queue>",
+		Short: "Atomically point an alias at a physical queue",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			switchAlias(redisOpts, logger, args[0], args[1])
+		},
+	}
+	aliasCmd.AddCommand(aliasListCmd, aliasSetCmd)
+
+	// Queue pause commands for deploys and incident response
+	var pauseReason string
+	var pauseCmd = &cobra.Command{
+		Use:   "pause <queue>",
+		Short: "Stop workers from dequeuing a queue (\"default\", a named queue or \"priority\"); enqueues continue",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			pauseQueue(redisOpts, logger, args[0], pauseReason)
+		},
+	}
+	pauseCmd.Flags().StringVarP(&pauseReason, "reason", "r", "", "Why the queue is paused, shown when listing paused queues")
+
+	var resumeCmd = &cobra.Command{
+		Use:   "resume <queue>",
+		Short: "Let workers dequeue a paused queue again",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			resumeQueue(redisOpts, logger, args[0])
+		},
+	}
+
+	var pausedCmd = &cobra.Command{
+		Use:   "paused",
+		Short: "List paused queues",
+		Run: func(cmd *cobra.Command, args []string) {
+			listPausedQueues(redisOpts, logger)
+		},
+	}
+
+	// Rate limit commands
+	var ratelimitCmd = &cobra.Command{
+		Use:   "ratelimit",
+		Short: "Inspect and change per job type rate limits at runtime",
+	}
+	var ratelimitGetCmd = &cobra.Command{
+		Use:   "get <job-type>",
+		Short: "Show the rate limit for a job type",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			getRateLimit(cfg, redisOpts, logger, args[0])
+		},
+	}
+	var limitRate float64
+	var limitBurst int
+	var ratelimitSetCmd = &cobra.Command{
+		Use:   "set <job-type>",
+		Short: "Set jobs per second for a job type (0 stops it, negative removes the limit)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			setRateLimit(cfg, redisOpts, logger, args[0], limitRate, limitBurst)
+		},
+	}
+	ratelimitSetCmd.Flags().Float64Var(&limitRate, "limit", 0, "Jobs per second (required)")
+	ratelimitSetCmd.Flags().IntVar(&limitBurst, "burst", 0, "Burst size (default: limit rounded up)")
+	ratelimitSetCmd.MarkFlagRequired("limit")
+	ratelimitCmd.AddCommand(ratelimitGetCmd, ratelimitSetCmd)
+
+	// Recurring schedule commands
+	var scheduleCmd = &cobra.Command{
+		Use:   "schedule",
+		Short: "Inspect, pause and resume recurring schedules",
+	}
+	var scheduleListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List recurring schedules",
+		Run: func(cmd *cobra.Command, args []string) {
+			listSchedules(cfg, redisOpts, logger)
+		},
+	}
+	var schedulePauseCmd = &cobra.Command{
+		Use:   "pause <schedule-id>",
+		Short: "Stop a recurring schedule from spawning jobs until resumed",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			setSchedulePaused(cfg, redisOpts, logger, args[0], true)
+		},
+	}
+	var scheduleResumeCmd = &cobra.Command{
+		Use:   "resume <schedule-id>",
+		Short: "Resume a paused recurring schedule",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			setSchedulePaused(cfg, redisOpts, logger, args[0], false)
+		},
+	}
+	var scheduleRunsLimit int
+	var scheduleRunsCmd = &cobra.Command{
+		Use:   "runs <schedule-id>",
+		Short: "List the jobs a recurring schedule has spawned, newest first",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			listScheduleRuns(cfg, redisOpts, logger, args[0], scheduleRunsLimit)
+		},
+	}
+	scheduleRunsCmd.Flags().IntVarP(&scheduleRunsLimit, "limit", "l", 20, "Maximum number of runs to show")
+	scheduleCmd.AddCommand(scheduleListCmd, schedulePauseCmd, scheduleResumeCmd, scheduleRunsCmd)
+
+	// Payload transform preview command
+	var transformType, transformPayload, transformCaller string
+	var transformCmd = &cobra.Command{
+		Use:   "transform",
+		Short: "Preview what the configured enqueue-time transforms do to a payload",
+		Long: `Apply the payload transforms from TRANSFORMS_DEFINITIONS or TRANSFORMS_FILE
+to a payload and print the result, without a server, Redis or the job type's
+handler. Enqueue hooks written in Go are not included.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			previewTransforms(cfg, logger, transformType, transformPayload, transformCaller)
+		},
+	}
+	transformCmd.Flags().StringVarP(&transformType, "type", "t", "", "Job type (required)")
+	transformCmd.Flags().StringVarP(&transformPayload, "payload", "p", "{}", "Job payload as JSON")
+	transformCmd.Flags().StringVar(&transformCaller, "caller", "", "Authenticated subject the caller source reads")
+	transformCmd.MarkFlagRequired("type")
+
+	// Connection profile listing command
+	var profilesCmd = &cobra.Command{
+		Use:   "profiles",
+		Short: "List the connection profiles in ~/.gopher/config.yaml",
+		Run: func(cmd *cobra.Command, args []string) {
+			listProfiles(logger)
+		},
+	}
+
+	// Job type documentation command
+	var typesWrite string
+	var typesCmd = &cobra.Command{
+		Use:   "types [job-type]",
+		Short: "Show the job types the server accepts with example submissions",
+		Long: `Query the server's /api/v1/jobs/types and print each job type with its
+description, version, policy and an example submit command. With a job type
+and --write, write its example payload to a file to edit and submit.`,
+		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			checkHealth(redisOpts, logger)
+			jobType := ""
+			if len(args) == 1 {
+				jobType = args[0]
+			}
+			describeJobTypes(logger, serverOptions(cfg), jobType, typesWrite)
 		},
 	}
+	typesCmd.Flags().StringVarP(&typesWrite, "write", "w", "", "Write the example payload of the given job type to this file")
 
 	// Add all commands to root
 	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(workersCmd)
+	rootCmd.AddCommand(inspectCmd)
 	rootCmd.AddCommand(submitCmd)
 	rootCmd.AddCommand(listFailedCmd)
+	rootCmd.AddCommand(triageCmd)
 	rootCmd.AddCommand(retryCmd)
 	rootCmd.AddCommand(retryAllCmd)
 	rootCmd.AddCommand(purgeCmd)
+	rootCmd.AddCommand(drainCmd)
+	rootCmd.AddCommand(ingestCmd)
 	rootCmd.AddCommand(healthCmd)
+	rootCmd.AddCommand(readyCmd)
+	rootCmd.AddCommand(rotateKeysCmd)
+	rootCmd.AddCommand(eraseCmd)
+	rootCmd.AddCommand(recordingCmd)
+	rootCmd.AddCommand(replayCmd)
+	rootCmd.AddCommand(aliasCmd)
+	rootCmd.AddCommand(pauseCmd)
+	rootCmd.AddCommand(resumeCmd)
+	rootCmd.AddCommand(pausedCmd)
+	rootCmd.AddCommand(ratelimitCmd)
+	rootCmd.AddCommand(scheduleCmd)
+	rootCmd.AddCommand(typesCmd)
+	rootCmd.AddCommand(profilesCmd)
+	rootCmd.AddCommand(transformCmd)
+
+	// These reach Redis directly; --server can't stand in for them
+	for _, cmd := range []*cobra.Command{drainCmd, ingestCmd, rotateKeysCmd, eraseCmd,
+		recordingExportCmd, recordingClearCmd, replayCmd} {
+		cmd.Annotations = map[string]string{directOnlyAnnotation: "true"}
+	}
 }
 
-func printQueueStats(redisOpts queue.RedisOptions, logger *zap.Logger) {
-	q, err := queue.NewRedisQueue(redisOpts)
+// previewTransforms applies the configured transforms to a payload and
+// prints the result
+func previewTransforms(cfg *config.Config, logger *zap.Logger, jobType, payload, caller string) {
+	transforms, err := cfg.Transforms.Parse()
 	if err != nil {
-		logger.Error("Failed to connect to Redis", zap.Error(err))
+		logger.Error("Failed to load payload transforms", zap.Error(err))
+		return
+	}
+	if !json.Valid([]byte(payload)) {
+		logger.Error("Invalid JSON payload")
 		return
 	}
-	defer q.Close()
 
-	ctx := context.Background()
-	size, err := q.Size(ctx)
-	if err != nil {
-		logger.Error("Failed to get queue size", zap.Error(err))
+	job := types.NewJob(jobType, json.RawMessage(payload), 0)
+	if err := transforms.Apply(job, types.TransformInput{Caller: caller}); err != nil {
+		logger.Error("Payload transform failed", zap.String("job_type", jobType), zap.Error(err))
+		return
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, job.Payload, "", "  "); err != nil {
+		logger.Error("Failed to format payload", zap.Error(err))
 		return
 	}
+	fmt.Println(indented.String())
+}
 
-	fmt.Printf("Queue Statistics:\n")
-	fmt.Printf("----------------\n")
-	fmt.Printf("Current queue size: %d\n", size)
+// profileFile is the operator's list of environments, by default
+// ~/.gopher/config.yaml
+type profileFile struct {
+	Default  string                `yaml:"default"` // Profile used without --profile or GOPHER_PROFILE
+	Profiles map[string]cliProfile `yaml:"profiles"`
+}
 
-	// TODO: Add more statistics
+// cliProfile is one named environment, e.g. staging
+type cliProfile struct {
+	Server string            `yaml:"server"`  // Sets GOPHER_SERVER, so commands go through the server's API
+	APIKey string            `yaml:"api_key"` // Sets GOPHER_API_KEY
+	Env    map[string]string `yaml:"env"`     // Any configuration variable, e.g. REDIS_URL or REDIS_KEY_PREFIX
 }
 
-func submitJob(redisOpts queue.RedisOptions, logger *zap.Logger, jobType, payload string, maxRetries int) {
-	q, err := queue.NewRedisQueue(redisOpts)
+// profileInfo is what gopher profiles prints for a profile, leaving out secrets
+type profileInfo struct {
+	Name     string `json:"name"`
+	Active   bool   `json:"active"`
+	Server   string `json:"server,omitempty"`
+	RedisURL string `json:"redis_url,omitempty"` // Password redacted
+}
+
+// profilePath returns GOPHER_CONFIG or ~/.gopher/config.yaml
+func profilePath() (string, error) {
+	if path := os.Getenv("GOPHER_CONFIG"); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
 	if err != nil {
-		logger.Error("Failed to connect to Redis", zap.Error(err))
-		return
+		return "", fmt.Errorf("failed to find home directory: %w", err)
 	}
-	defer q.Close()
+	return filepath.Join(home, ".gopher", "config.yaml"), nil
+}
 
-	// Parse payload
-	var rawPayload json.RawMessage
-	if err := json.Unmarshal([]byte(payload), &rawPayload); err != nil {
-		logger.Error("Invalid JSON payload", zap.Error(err))
+// loadProfiles reads the profile file; without one there are no profiles
+func loadProfiles() (*profileFile, string, error) {
+	path, err := profilePath()
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &profileFile{}, path, nil
+	}
+	if err != nil {
+		return nil, path, err
+	}
+
+	var file profileFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, path, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &file, path, nil
+}
+
+// applyProfile exports the variables of the selected profile, so the config
+// and the flag defaults pick them up, and returns its name. The profile is
+// chosen by --profile, then GOPHER_PROFILE, then the file's default, and its
+// values override the environment.
+func applyProfile(logger *zap.Logger, args []string) (string, error) {
+	name := profileFlag(args)
+	if name == "" {
+		name = os.Getenv("GOPHER_PROFILE")
+	}
+
+	file, path, err := loadProfiles()
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		name = file.Default
+	}
+	if name == "" {
+		return "", nil
+	}
+
+	profile, ok := file.Profiles[name]
+	if !ok {
+		return "", fmt.Errorf("profile %q is not defined in %s", name, path)
+	}
+
+	if profile.APIKey != "" {
+		if info, err := os.Stat(path); err == nil && info.Mode().Perm()&0077 != 0 {
+			logger.Warn("Profile file holds an API key but is readable by other users; chmod 600 it", zap.String("file", path))
+		}
+	}
+
+	variables := make(map[string]string, len(profile.Env)+2)
+	for key, value := range profile.Env {
+		variables[key] = value
+	}
+	if profile.Server != "" {
+		variables["GOPHER_SERVER"] = profile.Server
+	}
+	if profile.APIKey != "" {
+		variables["GOPHER_API_KEY"] = profile.APIKey
+	}
+	for key, value := range variables {
+		if err := os.Setenv(key, value); err != nil {
+			return "", fmt.Errorf("profile %s: failed to set %s: %w", name, key, err)
+		}
+	}
+	return name, nil
+}
+
+// profileFlag finds --profile in the arguments, since cobra parses them only
+// after the config has been loaded
+func profileFlag(args []string) string {
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return ""
+		case arg == "--profile" && i+1 < len(args):
+			return args[i+1]
+		case strings.HasPrefix(arg, "--profile="):
+			return strings.TrimPrefix(arg, "--profile=")
+		}
+	}
+	return ""
+}
+
+func listProfiles(logger *zap.Logger) {
+	file, path, err := loadProfiles()
+	if err != nil {
+		logger.Error("Failed to load profiles", zap.Error(err))
 		return
 	}
 
-	// Create job
-	job := types.NewJob(jobType, rawPayload, maxRetries)
+	names := make([]string, 0, len(file.Profiles))
+	for name := range file.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-	// Enqueue job
-	ctx := context.Background()
-	if err := q.Enqueue(ctx, job); err != nil {
-		logger.Error("Failed to enqueue job", zap.Error(err))
+	infos := make([]profileInfo, 0, len(names))
+	for _, name := range names {
+		profile := file.Profiles[name]
+		info := profileInfo{Name: name, Active: name == activeProfile, Server: profile.Server}
+		if redisURL, err := url.Parse(profile.Env["REDIS_URL"]); err == nil {
+			info.RedisURL = redisURL.Redacted()
+		}
+		infos = append(infos, info)
+	}
+
+	if printStructured(logger, infos) {
+		return
+	}
+
+	if len(infos) == 0 {
+		fmt.Printf("No profiles defined in %s\n", path)
 		return
 	}
 
-	fmt.Printf("Job enqueued successfully:\n")
-	fmt.Printf("  ID: %s\n", job.ID)
-	fmt.Printf("  Type: %s\n", job.Type)
-	fmt.Printf("  Max retries: %d\n", job.MaxRetries)
+	rows := make([][]string, 0, len(infos))
+	for _, info := range infos {
+		marker := ""
+		if info.Active {
+			marker = "*"
+		}
+		rows = append(rows, []string{marker, info.Name, info.Server, info.RedisURL})
+	}
+	printTable([]string{"", "NAME", "SERVER", "REDIS URL"}, rows)
 }
 
-func listFailedJobs(redisOpts queue.RedisOptions, logger *zap.Logger) {
-	// Implementation will depend on DLQ
-	fmt.Println("List of failed jobs:")
-	fmt.Println("-------------------")
-	// TODO: Implement when DLQ is available
+// statsRateWindow is how far back --watch looks for enqueue and dequeue rates
+const statsRateWindow = time.Minute
+
+// statsReport is what gopher stats prints
+type statsReport struct {
+	QueueSize     int                            `json:"queue_size"`
+	Retrying      int                            `json:"retrying"`            // Waiting out a retry backoff
+	Scheduled     *int                           `json:"scheduled,omitempty"` // Redis backend without --server only
+	DLQSize       int                            `json:"dlq_size"`
+	TotalEnqueued int                            `json:"total_enqueued"`
+	TotalDequeued int                            `json:"total_dequeued"`
+	Workers       *workerCounts                  `json:"workers,omitempty"` // Redis backend or a server with worker stats only
+	Rates         *statsRates                    `json:"rates,omitempty"`   // With --watch, from the second refresh on
+	Retries       *queue.RetrySummary            `json:"retries,omitempty"`
+	Priority      map[string]queue.PriorityStats `json:"priority,omitempty"`
+	SampledAt     time.Time                      `json:"sampled_at"`
+}
+
+// workerCounts sums up the live worker pools
+type workerCounts struct {
+	Pools       int `json:"pools"`
+	Draining    int `json:"draining"`
+	ActiveJobs  int `json:"active_jobs"`
+	Concurrency int `json:"concurrency"`
+}
+
+// statsRates are jobs per second between the oldest and newest sample of the window
+type statsRates struct {
+	Window   string  `json:"window"`
+	Enqueued float64 `json:"enqueued_per_second"`
+	Dequeued float64 `json:"dequeued_per_second"`
 }
 
-func retryFailedJob(redisOpts queue.RedisOptions, logger *zap.Logger, jobID string) {
-	// Implementation will depend on DLQ
-	fmt.Printf("Retrying job %s...\n", jobID)
-	// TODO: Implement when DLQ is available
+func printQueueStats(cfg *config.Config, redisOpts queue.RedisOptions, logger *zap.Logger, watch time.Duration) {
+	if watch < 0 {
+		logger.Error("Invalid watch interval", zap.Duration("watch", watch))
+		return
+	}
+
+	sample, closeSource, err := openStatsSource(cfg, redisOpts)
+	if err != nil {
+		logger.Error("Failed to open queue", zap.Error(err))
+		return
+	}
+	defer closeSource()
+
+	ctx := context.Background()
+	var window []*statsReport
+	for {
+		report, err := sample(ctx)
+		if err != nil && watch == 0 {
+			logger.Error("Failed to get queue stats", zap.Error(err))
+			return
+		}
+
+		if err != nil {
+			// Keep watching through a blip, e.g. a Redis failover
+			logger.Warn("Failed to get queue stats", zap.Error(err))
+		} else {
+			window = append(window, report)
+			for len(window) > 2 && report.SampledAt.Sub(window[1].SampledAt) >= statsRateWindow {
+				window = window[1:]
+			}
+			report.Rates = rollingRates(window)
+
+			if watch > 0 {
+				switch outputFormat {
+				case outputTable, outputWide:
+					fmt.Print("\033[H\033[2J")
+				case outputYAML:
+					fmt.Println("---")
+				}
+			}
+			if !printStructured(logger, report) {
+				printStatsTable(report, watch)
+			}
+		}
+
+		if watch == 0 {
+			return
+		}
+		time.Sleep(watch)
+	}
 }
 
-func retryAllFailedJobs(redisOpts queue.RedisOptions, logger *zap.Logger) {
-	// Implementation will depend on DLQ
-	fmt.Println("Retrying all failed jobs...")
-	// TODO: Implement when DLQ is available
+// rollingRates returns the throughput between the first and last sample
+func rollingRates(window []*statsReport) *statsRates {
+	if len(window) < 2 {
+		return nil
+	}
+	first, last := window[0], window[len(window)-1]
+	elapsed := last.SampledAt.Sub(first.SampledAt)
+	if elapsed <= 0 {
+		return nil
+	}
+	return &statsRates{
+		Window:   elapsed.Round(time.Second).String(),
+		Enqueued: float64(last.TotalEnqueued-first.TotalEnqueued) / elapsed.Seconds(),
+		Dequeued: float64(last.TotalDequeued-first.TotalDequeued) / elapsed.Seconds(),
+	}
 }
 
-func purgeQueue(redisOpts queue.RedisOptions, logger *zap.Logger, queueName string) {
-	// This would require implementing a purge method on the queue
-	fmt.Printf("Purging %s queue...\n", queueName)
-	// TODO: Implement queue purge functionality
+// printStatsTable renders a report as a one-row summary with breakdowns below
+func printStatsTable(report *statsReport, watch time.Duration) {
+	if watch > 0 {
+		fmt.Printf("Every %s, %s\n\n", watch, formatTime(report.SampledAt))
+	}
+
+	headers := []string{"PENDING", "RETRYING", "SCHEDULED", "DLQ"}
+	scheduled := "-"
+	if report.Scheduled != nil {
+		scheduled = fmt.Sprint(*report.Scheduled)
+	}
+	row := []string{fmt.Sprint(report.QueueSize), fmt.Sprint(report.Retrying), scheduled, fmt.Sprint(report.DLQSize)}
+	if report.Workers != nil {
+		headers = append(headers, "WORKERS", "BUSY")
+		row = append(row, fmt.Sprint(report.Workers.Pools), fmt.Sprintf("%d/%d", report.Workers.ActiveJobs, report.Workers.Concurrency))
+	}
+	headers = append(headers, "ENQUEUED", "DEQUEUED")
+	row = append(row, fmt.Sprint(report.TotalEnqueued), fmt.Sprint(report.TotalDequeued))
+	if watch > 0 {
+		headers = append(headers, "IN/S", "OUT/S")
+		if report.Rates != nil {
+			row = append(row, fmt.Sprintf("%.1f", report.Rates.Enqueued), fmt.Sprintf("%.1f", report.Rates.Dequeued))
+		} else {
+			row = append(row, "-", "-")
+		}
+	}
+	printTable(headers, [][]string{row})
+
+	if summary := report.Retries; summary != nil && len(summary.ByType) > 0 {
+		jobTypes := make([]string, 0, len(summary.ByType))
+		for jobType := range summary.ByType {
+			jobTypes = append(jobTypes, jobType)
+		}
+		sort.Strings(jobTypes)
+
+		headers := []string{"TYPE", "RETRYING", "NEXT DUE"}
+		if outputFormat == outputWide {
+			headers = append(headers, "LAST DUE")
+		}
+		rows := make([][]string, 0, len(jobTypes))
+		for _, jobType := range jobTypes {
+			typeSummary := summary.ByType[jobType]
+			row := []string{jobType, fmt.Sprint(typeSummary.Count), formatTime(typeSummary.NextDueAt)}
+			if outputFormat == outputWide {
+				row = append(row, formatTime(typeSummary.LastDueAt))
+			}
+			rows = append(rows, row)
+		}
+		fmt.Printf("\nRetries by type")
+		if summary.Overdue > 0 {
+			fmt.Printf(" (%d overdue)", summary.Overdue)
+		}
+		fmt.Printf(":\n")
+		printTable(headers, rows)
+	}
+
+	// A priority level whose size keeps growing while others drain is being starved
+	if report.Priority != nil {
+		rows := make([][]string, 0, 3)
+		for _, priority := range []string{queue.PriorityHigh, queue.PriorityNormal, queue.PriorityLow} {
+			level := report.Priority[priority]
+			rows = append(rows, []string{priority, fmt.Sprint(level.Size), fmt.Sprint(level.Enqueued), fmt.Sprint(level.Dequeued)})
+		}
+		fmt.Printf("\nPriority queues:\n")
+		printTable([]string{"PRIORITY", "SIZE", "ENQUEUED", "DEQUEUED"}, rows)
+	}
 }
 
-func checkHealth(redisOpts queue.RedisOptions, logger *zap.Logger) {
+// openStatsSource connects to the configured backend once and returns a
+// function taking a fresh sample of its statistics
+func openStatsSource(cfg *config.Config, redisOpts queue.RedisOptions) (func(context.Context) (*statsReport, error), func(), error) {
+	if remote.URL != "" {
+		return remoteStatsSource(remote), func() {}, nil
+	}
+
+	if cfg.Queue.Backend != config.QueueBackendRedis {
+		q, dlq, err := openQueue(cfg, redisOpts)
+		if err != nil {
+			return nil, nil, err
+		}
+		provider, ok := q.(queue.StatsProvider)
+		if !ok {
+			q.Close()
+			return nil, nil, fmt.Errorf("the %s queue backend keeps no statistics", cfg.Queue.Backend)
+		}
+
+		sample := func(ctx context.Context) (*statsReport, error) {
+			stats, err := provider.GetStats(ctx)
+			if err != nil {
+				return nil, err
+			}
+			report := &statsReport{
+				QueueSize:     stats.QueueSize,
+				Retrying:      stats.Retrying,
+				TotalEnqueued: stats.TotalEnqueued,
+				TotalDequeued: stats.TotalDequeued,
+				Priority:      stats.ByPriority,
+				SampledAt:     time.Now(),
+			}
+			if dlq != nil {
+				if report.DLQSize, err = dlq.Size(ctx); err != nil {
+					return nil, fmt.Errorf("failed to get DLQ size: %w", err)
+				}
+			}
+			return report, nil
+		}
+		return sample, func() { q.Close() }, nil
+	}
+
 	q, err := queue.NewRedisQueue(redisOpts)
 	if err != nil {
-		logger.Error("Failed to connect to Redis", zap.Error(err))
-		fmt.Println("❌ System health check failed: Redis connection error")
-		return
+		return nil, nil, err
 	}
-	defer q.Close()
+	closeAll := func() { q.Close() }
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	var pq *queue.PriorityQueue
+	if cfg.Queue.Priority {
+		if pq, err = queue.NewPriorityQueue(redisOpts); err != nil {
+			q.Close()
+			return nil, nil, err
+		}
+		closeAll = func() {
+			pq.Close()
+			q.Close()
+		}
+	}
 
-	if err := q.Health(ctx); err != nil {
-		logger.Error("Redis health check failed", zap.Error(err))
-		fmt.Println("❌ System health check failed: Redis unhealthy")
-		return
+	dlq := queue.NewRedisDLQ(q.Client(), q.Layout(), q)
+	scheduled := queue.NewScheduledQueue(q.Client(), q.Layout(), q)
+	retries := queue.NewRetryTracker(q.Client(), q.Layout())
+	heartbeats := queue.NewHeartbeatRegistry(q.Client(), q.Layout(), 3*cfg.Worker.HeartbeatInterval)
+
+	sample := func(ctx context.Context) (*statsReport, error) {
+		stats, err := q.GetStats(ctx)
+		if err != nil {
+			return nil, err
+		}
+		report := &statsReport{
+			QueueSize:     stats.QueueSize,
+			Retrying:      stats.Retrying,
+			TotalEnqueued: stats.TotalEnqueued,
+			TotalDequeued: stats.TotalDequeued,
+			SampledAt:     time.Now(),
+		}
+
+		scheduledSize, err := scheduled.Size(ctx)
+		if err != nil {
+			return nil, err
+		}
+		report.Scheduled = &scheduledSize
+		if report.DLQSize, err = dlq.Size(ctx); err != nil {
+			return nil, fmt.Errorf("failed to get DLQ size: %w", err)
+		}
+		if report.Retries, err = retries.Summary(ctx); err != nil {
+			return nil, fmt.Errorf("failed to get pending retries: %w", err)
+		}
+
+		workers, err := heartbeats.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		report.Workers = &workerCounts{Pools: len(workers)}
+		for _, worker := range workers {
+			if worker.Draining {
+				report.Workers.Draining++
+			}
+			report.Workers.ActiveJobs += worker.ActiveJobs
+			report.Workers.Concurrency += worker.Concurrency
+		}
+
+		if pq != nil {
+			if report.Priority, err = pq.StatsByPriority(ctx); err != nil {
+				return nil, fmt.Errorf("failed to get priority stats: %w", err)
+			}
+		}
+		return report, nil
+	}
+	return sample, closeAll, nil
+}
+
+// openQueue opens the configured queue and its DLQ with the encryption
+// keyring applied. The CLI reaches the redis and sqlite backends directly,
+// as well as backends registered with queue.RegisterBackend.
+func openQueue(cfg *config.Config, redisOpts queue.RedisOptions) (queue.Queue, queue.DeadLetterQueue, error) {
+	keyring, err := cfg.Encryption.Keyring()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load encryption keys: %w", err)
 	}
 
-	fmt.Println("✅ System health check passed")
-	fmt.Println("  Redis: Connected and healthy")
+	switch cfg.Queue.Backend {
+	case config.QueueBackendRedis:
+		q, err := queue.NewRedisQueue(redisOpts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+		if keyring != nil {
+			q.SetKeyring(keyring)
+		}
+		dlq := queue.NewRedisDLQ(q.Client(), q.Layout(), q)
+		dlq.SetKeyring(keyring)
+		return q, dlq, nil
+
+	case config.QueueBackendSQLite:
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Redis.Timeout)
+		defer cancel()
+
+		q, err := queue.OpenSQLite(ctx, cfg.SQLite.Driver, cfg.SQLite.Path, queue.SQLiteOptions{
+			BusyTimeout: cfg.SQLite.BusyTimeout,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		q.SetKeyring(keyring)
+		q.SetSigning(redisOpts.Signing)
+		dlq := queue.NewSQLiteDLQ(q)
+		dlq.SetKeyring(keyring)
+		dlq.SetSigning(redisOpts.Signing)
+		return q, dlq, nil
+	}
+
+	if queue.BackendRegistered(cfg.Queue.Backend) {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Redis.Timeout)
+		defer cancel()
+
+		return queue.OpenBackend(ctx, cfg.Queue.Backend, queue.BackendOptions{Keyring: keyring, Signing: redisOpts.Signing})
+	}
+
+	return nil, nil, fmt.Errorf("the CLI does not support the %s queue backend", cfg.Queue.Backend)
+}
+
+func submitJob(cfg *config.Config, redisOpts queue.RedisOptions, logger *zap.Logger, jobType, payload, queueName string, maxRetries, payloadVersion int, timeout, expiresIn time.Duration, count int, interval time.Duration, validate bool) {
+	// Parse payload
+	var rawPayload json.RawMessage
+	if err := json.Unmarshal([]byte(payload), &rawPayload); err != nil {
+		logger.Error("Invalid JSON payload", zap.Error(err))
+		return
+	}
+
+	if timeout < 0 {
+		logger.Error("Invalid timeout", zap.Duration("timeout", timeout))
+		return
+	}
+	if expiresIn < 0 {
+		logger.Error("Invalid expiry", zap.Duration("expires_in", expiresIn))
+		return
+	}
+	if count < 1 {
+		logger.Error("Invalid count", zap.Int("count", count))
+		return
+	}
+	if payloadVersion < 0 {
+		logger.Error("Invalid payload version", zap.Int("payload_version", payloadVersion))
+		return
+	}
+	if interval < 0 {
+		logger.Error("Invalid interval", zap.Duration("interval", interval))
+		return
+	}
+
+	// The server checks payloads itself with --server
+	if validate && remote.URL == "" {
+		schema, err := fetchPayloadSchema(serverOptions(cfg), jobType)
+		if err != nil {
+			logger.Error("Failed to get payload schema", zap.String("job_type", jobType), zap.Error(err))
+			return
+		}
+		if schema != nil {
+			if violations := schema.Validate(rawPayload); len(violations) > 0 {
+				logSchemaViolations(logger, violations)
+				return
+			}
+		}
+	}
+
+	enqueue, closeQueue, err := openEnqueuer(cfg, redisOpts)
+	if err != nil {
+		logger.Error("Failed to open queue", zap.Error(err))
+		return
+	}
+	defer closeQueue()
+
+	ctx := context.Background()
+	for i := 0; i < count; i++ {
+		if i > 0 && interval > 0 {
+			time.Sleep(interval)
+		}
+
+		// Create job
+		job := types.NewJob(jobType, rawPayload, maxRetries)
+		job.Queue = queueName
+		job.Timeout = timeout
+		job.PayloadVersion = payloadVersion
+		if expiresIn > 0 {
+			expiresAt := job.CreatedAt.Add(expiresIn)
+			job.ExpiresAt = &expiresAt
+		}
+
+		// Enqueue job
+		if err := enqueue(ctx, job); err != nil {
+			if i > 0 {
+				setExitCode(exitPartial)
+			}
+			var apiErr *apiError
+			if errors.As(err, &apiErr) && len(apiErr.Violations) > 0 {
+				logSchemaViolations(logger, apiErr.Violations)
+				return
+			}
+			logger.Error("Failed to enqueue job", zap.Int("submitted", i), zap.Error(err))
+			return
+		}
+
+		if quiet {
+			fmt.Fprintln(quietOutput, job.ID)
+			continue
+		}
+		if count > 1 {
+			fmt.Printf("Job %d/%d enqueued: %s\n", i+1, count, job.ID)
+			continue
+		}
+
+		fmt.Printf("Job enqueued successfully:\n")
+		fmt.Printf("  ID: %s\n", job.ID)
+		fmt.Printf("  Type: %s\n", job.Type)
+		if job.Queue != "" {
+			fmt.Printf("  Queue: %s\n", job.Queue)
+		}
+		fmt.Printf("  Max retries: %d\n", job.MaxRetries)
+		if job.PayloadVersion > 0 {
+			fmt.Printf("  Payload version: %d\n", job.PayloadVersion)
+		}
+		if job.Timeout > 0 {
+			fmt.Printf("  Timeout: %s\n", job.Timeout)
+		}
+		if job.ExpiresAt != nil {
+			fmt.Printf("  Expires at: %s\n", job.ExpiresAt.Format(time.RFC3339))
+		}
+	}
+}
+
+// openEnqueuer returns how submit enqueues jobs: onto the configured queue,
+// or through the server with --server
+func openEnqueuer(cfg *config.Config, redisOpts queue.RedisOptions) (func(context.Context, *types.Job) error, func(), error) {
+	if remote.URL != "" {
+		return submitRemote, func() {}, nil
+	}
+
+	q, _, err := openQueue(cfg, redisOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return q.Enqueue, func() { q.Close() }, nil
+}
+
+// fetchPayloadSchema returns the payload schema the server advertises for
+// jobType, or nil if it has none
+func fetchPayloadSchema(opts apiOptions, jobType string) (*types.PayloadSchema, error) {
+	infos, err := listJobTypes(opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		if info.Type != jobType {
+			continue
+		}
+		if len(info.PayloadSchema) == 0 {
+			return nil, nil
+		}
+		return types.CompileSchema(info.PayloadSchema)
+	}
+	return nil, fmt.Errorf("%w: %s", errUnknownJobType, jobType)
+}
+
+// logSchemaViolations reports every way a payload breaks its job type's schema
+func logSchemaViolations(logger *zap.Logger, violations []types.SchemaViolation) {
+	messages := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		messages = append(messages, violation.Error())
+	}
+	logger.Error("Payload does not match the job type's schema", zap.Strings("violations", messages))
+}
+
+// readPayloadFile reads a JSON payload from a file, or from stdin for "-"
+func readPayloadFile(path string) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func listFailedJobs(cfg *config.Config, redisOpts queue.RedisOptions, logger *zap.Logger, reasonFilter string, offset, limit int) {
+	var reason types.FailureReason
+	if reasonFilter != "" {
+		parsed, err := types.ParseFailureReason(reasonFilter)
+		if err != nil {
+			logger.Error("Invalid reason", zap.Error(err))
+			return
+		}
+		reason = parsed
+	}
+
+	if remote.URL != "" {
+		listFailedJobsRemote(logger, reason, offset, limit)
+		return
+	}
+
+	q, dlq, err := openQueue(cfg, redisOpts)
+	if err != nil {
+		logger.Error("Failed to open queue", zap.Error(err))
+		return
+	}
+	defer q.Close()
+	if dlq == nil {
+		logger.Error("The queue backend has no dead letter queue", zap.String("backend", cfg.Queue.Backend))
+		return
+	}
+
+	redactor, err := cfg.Redaction.Redactor()
+	if err != nil {
+		logger.Error("Failed to load redaction rules", zap.Error(err))
+		return
+	}
+
+	ctx := context.Background()
+	var jobs []*types.FailedJobInfo
+	var total int
+	if reason != "" {
+		jobs, total, err = dlq.ListByReason(ctx, reason, offset, limit)
+		if err != nil {
+			logger.Error("Failed to list failed jobs", zap.Error(err))
+			return
+		}
+	} else {
+		jobs, err = dlq.List(ctx, offset, limit)
+		if err != nil {
+			logger.Error("Failed to list failed jobs", zap.Error(err))
+			return
+		}
+
+		total, err = dlq.Size(ctx)
+		if err != nil {
+			logger.Error("Failed to get DLQ size", zap.Error(err))
+			return
+		}
+	}
+
+	report := failedJobsReport{Total: total, Jobs: make([]api.FailedJobInfo, 0, len(jobs))}
+	for _, info := range jobs {
+		if info.Job == nil {
+			continue
+		}
+		report.Jobs = append(report.Jobs, api.FailedJobInfo{
+			JobID:       info.Job.ID,
+			Type:        info.Job.Type,
+			Payload:     string(redactor.Payload(info.Job.Type, info.Job.Payload)),
+			Error:       info.Error,
+			Fingerprint: info.FingerprintOrCompute(),
+			Reason:      string(info.ReasonOrUnknown()),
+			Attempts:    info.Job.Attempts,
+			MaxRetries:  info.Job.MaxRetries,
+			FailedAt:    info.FailedAt,
+		})
+	}
+
+	printFailedJobs(logger, report)
+}
+
+// printFailedJobs renders a page of the dead letter queue
+func printFailedJobs(logger *zap.Logger, report failedJobsReport) {
+	if printStructured(logger, report) {
+		return
+	}
+
+	fmt.Printf("List of failed jobs (%d of %d):\n", len(report.Jobs), report.Total)
+	if len(report.Jobs) == 0 {
+		return
+	}
+
+	headers := []string{"ID", "TYPE", "ATTEMPTS", "FAILED AT", "REASON", "ERROR"}
+	if outputFormat == outputWide {
+		headers = append(headers, "FINGERPRINT", "PAYLOAD")
+	}
+	rows := make([][]string, 0, len(report.Jobs))
+	for _, job := range report.Jobs {
+		row := []string{job.JobID, job.Type, fmt.Sprintf("%d/%d", job.Attempts, job.MaxRetries),
+			formatTime(job.FailedAt), job.Reason, job.Error}
+		if outputFormat == outputWide {
+			row = append(row, job.Fingerprint, job.Payload)
+		}
+		rows = append(rows, row)
+	}
+	printTable(headers, rows)
+}
+
+// failedJobsReport is what gopher list-failed prints with --output json or yaml
+type failedJobsReport struct {
+	Total int                 `json:"total"`
+	Jobs  []api.FailedJobInfo `json:"jobs"`
+}
+
+func triageFailedJobs(cfg *config.Config, redisOpts queue.RedisOptions, logger *zap.Logger, maxEntries, top int) {
+	if remote.URL != "" {
+		triageFailedJobsRemote(logger, maxEntries, top)
+		return
+	}
+
+	q, dlq, err := openQueue(cfg, redisOpts)
+	if err != nil {
+		logger.Error("Failed to open queue", zap.Error(err))
+		return
+	}
+	defer q.Close()
+	if dlq == nil {
+		logger.Error("The queue backend has no dead letter queue", zap.String("backend", cfg.Queue.Backend))
+		return
+	}
+
+	report, err := queue.TriageDLQ(context.Background(), dlq, maxEntries)
+	if err != nil {
+		logger.Error("Failed to triage dead letter queue", zap.Error(err))
+		return
+	}
+
+	printTriage(logger, report, top)
+}
+
+// printTriage renders the top groups of a triage report
+func printTriage(logger *zap.Logger, report *queue.TriageReport, top int) {
+	topReport := *report
+	if len(topReport.Groups) > top {
+		topReport.Groups = topReport.Groups[:top]
+	}
+	if printStructured(logger, topReport) {
+		return
+	}
+
+	if len(report.Groups) == 0 {
+		fmt.Println("Dead letter queue is empty")
+		return
+	}
+
+	scope := fmt.Sprintf("%d failed jobs", report.Scanned)
+	if report.Truncated {
+		scope = fmt.Sprintf("newest %d failed jobs", report.Scanned)
+	}
+	fmt.Printf("%d failure groups in %s:\n", len(report.Groups), scope)
+	fmt.Println("-------------------")
+	for i, group := range report.Groups {
+		if i == top {
+			fmt.Printf("  ... %d more groups\n", len(report.Groups)-top)
+			break
+		}
+		fmt.Printf("  %s\n", group.Summary())
+		fmt.Printf("    Reason: %s  Last failed: %s  First failed: %s\n",
+			group.Reason, formatTime(group.LastFailedAt), formatTime(group.FirstFailedAt))
+		fmt.Printf("    Example: %s\n", group.Example)
+		fmt.Printf("    Jobs: %s\n", strings.Join(group.SampleJobIDs, ", "))
+	}
+}
+
+func retryFailedJob(cfg *config.Config, redisOpts queue.RedisOptions, logger *zap.Logger, jobID string) {
+	if remote.URL != "" {
+		retryFailedJobRemote(logger, jobID)
+		return
+	}
+
+	q, dlq, err := openQueue(cfg, redisOpts)
+	if err != nil {
+		logger.Error("Failed to open queue", zap.Error(err))
+		return
+	}
+	defer q.Close()
+	if dlq == nil {
+		logger.Error("The queue backend has no dead letter queue", zap.String("backend", cfg.Queue.Backend))
+		return
+	}
+
+	err = dlq.Reprocess(context.Background(), jobID)
+	if errors.Is(err, queue.ErrNotInDLQ) {
+		fmt.Printf("Job %s is not in the dead letter queue\n", jobID)
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to retry job", zap.String("job_id", jobID), zap.Error(err))
+		return
+	}
+
+	fmt.Printf("Job %s moved back to its queue\n", jobID)
+}
+
+func retryAllFailedJobs(cfg *config.Config, redisOpts queue.RedisOptions, logger *zap.Logger) {
+	if remote.URL != "" {
+		retryAllFailedJobsRemote(logger)
+		return
+	}
+
+	q, dlq, err := openQueue(cfg, redisOpts)
+	if err != nil {
+		logger.Error("Failed to open queue", zap.Error(err))
+		return
+	}
+	defer q.Close()
+	if dlq == nil {
+		logger.Error("The queue backend has no dead letter queue", zap.String("backend", cfg.Queue.Backend))
+		return
+	}
+
+	// Collect the IDs first, since every retry shifts the jobs behind it
+	ctx := context.Background()
+	var jobIDs []string
+	for offset := 0; ; offset += retryPageSize {
+		infos, err := dlq.List(ctx, offset, retryPageSize)
+		if err != nil {
+			logger.Error("Failed to list failed jobs", zap.Error(err))
+			return
+		}
+		for _, info := range infos {
+			jobIDs = append(jobIDs, info.Job.ID)
+		}
+		if len(infos) < retryPageSize {
+			break
+		}
+	}
+
+	retried := retryEach(logger, jobIDs, func(jobID string) error {
+		return dlq.Reprocess(ctx, jobID)
+	})
+	fmt.Printf("Retried %d of %d failed jobs\n", retried, len(jobIDs))
+}
+
+// retryPageSize is how many failed jobs retry-all lists per request, the
+// most the server's DLQ listing returns
+const retryPageSize = 500
+
+// retryEach retries every job, logging the ones that fail, and returns how
+// many were requeued. Jobs that left the DLQ in the meantime are skipped.
+func retryEach(logger *zap.Logger, jobIDs []string, retry func(string) error) int {
+	retried := 0
+	for _, jobID := range jobIDs {
+		err := retry(jobID)
+		if errors.Is(err, queue.ErrNotInDLQ) {
+			continue
+		}
+		if err != nil {
+			logger.Error("Failed to retry job", zap.String("job_id", jobID), zap.Error(err))
+			continue
+		}
+		retried++
+	}
+	return retried
+}
+
+// purgeTarget is a queue the purge command can empty
+type purgeTarget interface {
+	queue.Purger
+	Size(ctx context.Context) (int, error)
+}
+
+func purgeQueue(redisOpts queue.RedisOptions, logger *zap.Logger, target, queueName string, force bool) {
+	if quiet && !force {
+		logger.Error("--quiet hides the confirmation prompt; pass --force to purge")
+		return
+	}
+
+	if remote.URL != "" {
+		purgeQueueRemote(logger, target, queueName, force)
+		return
+	}
+
+	redisOpts.QueueName = queueName
+	q, err := queue.NewRedisQueue(redisOpts)
+	if err != nil {
+		logger.Error("Failed to connect to Redis", zap.Error(err))
+		return
+	}
+	defer q.Close()
+
+	var purger purgeTarget
+	label := target + " queue"
+	switch target {
+	case "main":
+		purger = q
+		label = q.Layout().QueueKey(queueName)
+	case "priority":
+		priorityQueue, err := queue.NewPriorityQueue(redisOpts)
+		if err != nil {
+			logger.Error("Failed to connect to Redis", zap.Error(err))
+			return
+		}
+		defer priorityQueue.Close()
+		purger = priorityQueue
+		label = "priority queues"
+	case "scheduled":
+		purger = queue.NewScheduledQueue(q.Client(), q.Layout(), q)
+		label = "scheduled queue (recurring schedules are kept)"
+	case "failed":
+		purger = queue.NewRedisDLQ(q.Client(), q.Layout(), q)
+		label = "dead letter queue"
+	default:
+		logger.Error("Invalid queue, must be main, priority, scheduled or failed", zap.String("queue", target))
+		return
+	}
+
+	ctx := context.Background()
+	if !force {
+		size, err := purger.Size(ctx)
+		if err != nil {
+			logger.Error("Failed to get queue size", zap.Error(err))
+			return
+		}
+		if size == 0 {
+			fmt.Printf("Nothing to purge in %s\n", label)
+			return
+		}
+
+		fmt.Printf("Permanently delete %d jobs from %s? Type 'yes' to confirm: ", size, label)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.TrimSpace(answer) != "yes" {
+			fmt.Println("Purge cancelled")
+			return
+		}
+	}
+
+	purged, err := purger.Purge(ctx)
+	if err != nil {
+		logger.Error("Failed to purge queue", zap.Error(err))
+		return
+	}
+
+	fmt.Printf("Purged %d jobs from %s\n", purged, label)
+}
+
+func drainQueue(redisOpts queue.RedisOptions, logger *zap.Logger, queueName, path string) {
+	redisOpts.QueueName = queueName
+	q, err := queue.NewRedisQueue(redisOpts)
+	if err != nil {
+		logger.Error("Failed to connect to Redis", zap.Error(err))
+		return
+	}
+	defer q.Close()
+
+	out := os.Stdout
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			logger.Error("Failed to create output file", zap.Error(err))
+			return
+		}
+		defer file.Close()
+		out = file
+	}
+
+	drained, holdingKey, err := q.DrainTo(context.Background(), out)
+	if err != nil {
+		logger.Error("Failed to drain queue", zap.Error(err))
+		if holdingKey != "" {
+			fmt.Fprintf(os.Stderr, "Drained jobs are kept in Redis list %s\n", holdingKey)
+		}
+		return
+	}
+
+	if path != "-" {
+		fmt.Printf("Drained %d jobs from %s to %s\n", drained, q.Layout().QueueKey(queueName), path)
+	}
+}
+
+func ingestQueue(redisOpts queue.RedisOptions, logger *zap.Logger, queueName, path string) {
+	in := os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			logger.Error("Failed to open input file", zap.Error(err))
+			return
+		}
+		defer file.Close()
+		in = file
+	}
+
+	redisOpts.QueueName = queueName
+	q, err := queue.NewRedisQueue(redisOpts)
+	if err != nil {
+		logger.Error("Failed to connect to Redis", zap.Error(err))
+		return
+	}
+	defer q.Close()
+
+	ingested, err := q.Ingest(context.Background(), in)
+	if err != nil {
+		if ingested > 0 {
+			setExitCode(exitPartial)
+		}
+		logger.Error("Failed to ingest jobs", zap.Int("ingested", ingested), zap.Error(err))
+		return
+	}
+
+	fmt.Printf("Ingested %d jobs into %s\n", ingested, q.Layout().QueueKey(queueName))
+}
+
+// probeOptions configures the health and ready commands
+type probeOptions struct {
+	URL      string
+	ExitCode bool
+	JSON     bool
+	Timeout  time.Duration
+}
+
+// probeResult is the machine-readable output of health and ready
+type probeResult struct {
+	Check     string `json:"check"`
+	Target    string `json:"target"`
+	Healthy   bool   `json:"healthy"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// checkHealth checks Redis, or the endpoint in opts.URL, and reports whether it is healthy
+func checkHealth(redisOpts queue.RedisOptions, logger *zap.Logger, opts probeOptions) bool {
+	if opts.URL != "" {
+		return probeEndpoint("health", opts)
+	}
+
+	start := time.Now()
+	result := probeResult{Check: "health", Target: "redis"}
+
+	q, err := queue.NewRedisQueue(redisOpts)
+	if err != nil {
+		logger.Debug("Failed to connect to Redis", zap.Error(err))
+		result.Error = "Redis connection error: " + err.Error()
+		return reportProbe(result, start, opts)
+	}
+	defer q.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	if err := q.Health(ctx); err != nil {
+		logger.Debug("Redis health check failed", zap.Error(err))
+		result.Error = "Redis unhealthy: " + err.Error()
+		return reportProbe(result, start, opts)
+	}
+
+	result.Healthy = true
+	return reportProbe(result, start, opts)
+}
+
+// probeEndpoint issues a GET against opts.URL; any 2xx response is healthy
+func probeEndpoint(check string, opts probeOptions) bool {
+	start := time.Now()
+	result := probeResult{Check: check, Target: opts.URL}
+
+	client := &http.Client{Timeout: opts.Timeout}
+	resp, err := client.Get(opts.URL)
+	if err != nil {
+		result.Error = err.Error()
+		return reportProbe(result, start, opts)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		result.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+		return reportProbe(result, start, opts)
+	}
+
+	result.Healthy = true
+	return reportProbe(result, start, opts)
+}
+
+// reportProbe prints a probe result and returns whether it was healthy
+func reportProbe(result probeResult, start time.Time, opts probeOptions) bool {
+	result.LatencyMs = time.Since(start).Milliseconds()
+
+	if opts.JSON {
+		data, _ := json.Marshal(result)
+		fmt.Println(string(data))
+		return result.Healthy
+	}
+
+	if !result.Healthy {
+		fmt.Printf("❌ %s check failed for %s: %s\n", result.Check, result.Target, result.Error)
+		return false
+	}
+
+	fmt.Printf("✅ %s check passed for %s (%dms)\n", result.Check, result.Target, result.LatencyMs)
+	return true
+}
+
+func rotateKeys(cfg *config.Config, redisOpts queue.RedisOptions, logger *zap.Logger) {
+	keyring, err := cfg.Encryption.Keyring()
+	if err != nil {
+		logger.Error("Failed to load encryption keys", zap.Error(err))
+		return
+	}
+	if keyring == nil {
+		fmt.Println("Payload encryption is not enabled (set ENCRYPTION_KEYS)")
+		return
+	}
+
+	q, err := queue.NewRedisQueue(redisOpts)
+	if err != nil {
+		logger.Error("Failed to connect to Redis", zap.Error(err))
+		return
+	}
+	defer q.Close()
+
+	fmt.Printf("Rotating payloads to key %s...\n", keyring.ActiveKeyID())
+
+	report, err := queue.RotateEncryptionKeys(context.Background(), q.Client(), q.Layout(), keyring)
+	if err != nil {
+		logger.Error("Key rotation failed", zap.Error(err))
+	}
+	if report == nil {
+		return
+	}
+
+	fmt.Printf("  Pending jobs re-encrypted:     %d\n", report.Pending)
+	fmt.Printf("  Running jobs re-encrypted:     %d\n", report.Processing)
+	fmt.Printf("  Scheduled jobs re-encrypted:   %d\n", report.Scheduled)
+	fmt.Printf("  Failed jobs re-encrypted:      %d\n", report.DLQ)
+	fmt.Printf("  Recorded jobs re-encrypted:    %d\n", report.Recordings)
+	fmt.Printf("  History records re-encrypted:  %d\n", report.History)
+	fmt.Printf("  Held jobs re-encrypted:        %d\n", report.Dependencies)
+	fmt.Printf("  Workflow nodes re-encrypted:   %d\n", report.Workflows)
+	fmt.Printf("  Quarantined jobs re-encrypted: %d\n", report.Quarantine)
+	fmt.Printf("  Skipped (unreadable):          %d\n", report.Skipped)
+}
+
+func eraseSubject(cfg *config.Config, redisOpts queue.RedisOptions, logger *zap.Logger, field, value string, scrub bool) {
+	keyring, err := cfg.Encryption.Keyring()
+	if err != nil {
+		logger.Error("Failed to load encryption keys", zap.Error(err))
+		return
+	}
+
+	q, err := queue.NewRedisQueue(redisOpts)
+	if err != nil {
+		logger.Error("Failed to connect to Redis", zap.Error(err))
+		return
+	}
+	defer q.Close()
+
+	mode := queue.ErasureDelete
+	if scrub {
+		mode = queue.ErasureScrub
+	}
+
+	admin := queue.NewAdmin(q.Client(), q.Layout(), keyring)
+	report, err := admin.EraseSubject(context.Background(), queue.ErasureRequest{
+		Field: field,
+		Value: value,
+		Mode:  mode,
+	})
+	if err != nil {
+		logger.Error("Erasure failed", zap.Error(err))
+	}
+	if report == nil {
+		return
+	}
+
+	fmt.Printf("Erasure (%s) complete:\n", mode)
+	fmt.Printf("  Pending jobs:     %d\n", report.Pending)
+	fmt.Printf("  Scheduled jobs:   %d\n", report.Scheduled)
+	fmt.Printf("  Failed jobs:      %d\n", report.DLQ)
+	fmt.Printf("  History:          %d\n", report.History)
+	fmt.Printf("  Recorded jobs:    %d\n", report.Recordings)
+	fmt.Printf("  Held jobs:        %d\n", report.Dependencies)
+	fmt.Printf("  Workflow nodes:   %d\n", report.Workflows)
+	fmt.Printf("  Quarantined jobs: %d\n", report.Quarantine)
+	fmt.Printf("  Results:          %d\n", report.Results)
+	fmt.Printf("  Job statuses:     %d\n", report.Statuses)
+	fmt.Println("  Spooled jobs are erased by each server as it receives the request")
+}
+
+func exportRecording(cfg *config.Config, redisOpts queue.RedisOptions, logger *zap.Logger, path string) {
+	keyring, err := cfg.Encryption.Keyring()
+	if err != nil {
+		logger.Error("Failed to load encryption keys", zap.Error(err))
+		return
+	}
+
+	q, err := queue.NewRedisQueue(redisOpts)
+	if err != nil {
+		logger.Error("Failed to connect to Redis", zap.Error(err))
+		return
+	}
+	defer q.Close()
+
+	recorded, err := queue.LoadRecording(context.Background(), q.Client(), q.Layout(), keyring)
+	if err != nil {
+		logger.Error("Failed to load recording", zap.Error(err))
+		return
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		logger.Error("Failed to create output file", zap.Error(err))
+		return
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, rec := range recorded {
+		if err := encoder.Encode(rec); err != nil {
+			logger.Error("Failed to write recorded job", zap.Error(err))
+			return
+		}
+	}
+
+	fmt.Printf("Exported %d recorded jobs to %s\n", len(recorded), path)
+}
+
+func clearRecording(redisOpts queue.RedisOptions, logger *zap.Logger) {
+	q, err := queue.NewRedisQueue(redisOpts)
+	if err != nil {
+		logger.Error("Failed to connect to Redis", zap.Error(err))
+		return
+	}
+	defer q.Close()
+
+	if err := queue.ClearRecording(context.Background(), q.Client(), q.Layout()); err != nil {
+		logger.Error("Failed to clear recording", zap.Error(err))
+		return
+	}
+
+	fmt.Println("Recording cleared")
+}
+
+func replayJobs(cfg *config.Config, redisOpts queue.RedisOptions, logger *zap.Logger, path, target string, speed float64) {
+	keyring, err := cfg.Encryption.Keyring()
+	if err != nil {
+		logger.Error("Failed to load encryption keys", zap.Error(err))
+		return
+	}
+
+	recorded, err := loadReplaySource(redisOpts, keyring, path)
+	if err != nil {
+		logger.Error("Failed to load recorded jobs", zap.Error(err))
+		return
+	}
+	if len(recorded) == 0 {
+		fmt.Println("No recorded jobs to replay")
+		return
+	}
+
+	targetOpts := redisOpts
+	if target != "" {
+		targetOpts.URL = target
+	}
+
+	q, err := queue.NewRedisQueue(targetOpts)
+	if err != nil {
+		logger.Error("Failed to connect to target Redis", zap.Error(err))
+		return
+	}
+	defer q.Close()
+
+	if keyring != nil {
+		q.SetKeyring(keyring)
+	}
+
+	fmt.Printf("Replaying %d jobs to %s at %vx speed...\n", len(recorded), targetOpts.URL, speed)
+
+	replayed, err := queue.Replay(context.Background(), q, recorded, speed, func(job *types.Job) {
+		fmt.Printf("  %s %s\n", job.ID, job.Type)
+	})
+	if err != nil {
+		if replayed > 0 {
+			setExitCode(exitPartial)
+		}
+		logger.Error("Replay stopped", zap.Error(err))
+	}
+
+	fmt.Printf("Replayed %d of %d jobs\n", replayed, len(recorded))
+}
+
+// loadReplaySource reads recorded jobs from an exported file, or from Redis when path is empty
+func loadReplaySource(redisOpts queue.RedisOptions, keyring *encryption.Keyring, path string) ([]queue.RecordedJob, error) {
+	if path == "" {
+		q, err := queue.NewRedisQueue(redisOpts)
+		if err != nil {
+			return nil, err
+		}
+		defer q.Close()
+
+		return queue.LoadRecording(context.Background(), q.Client(), q.Layout(), keyring)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording file: %w", err)
+	}
+	defer file.Close()
+
+	var recorded []queue.RecordedJob
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		var rec queue.RecordedJob
+		if err := decoder.Decode(&rec); err != nil {
+			return nil, fmt.Errorf("failed to parse recording file: %w", err)
+		}
+		recorded = append(recorded, rec)
+	}
+
+	return recorded, nil
+}
+
+func listAliases(redisOpts queue.RedisOptions, logger *zap.Logger) {
+	if remote.URL != "" {
+		listAliasesRemote(logger)
+		return
+	}
+
+	q, err := queue.NewRedisQueue(redisOpts)
+	if err != nil {
+		logger.Error("Failed to connect to Redis", zap.Error(err))
+		return
+	}
+	defer q.Close()
+
+	aliases, err := queue.NewAdmin(q.Client(), q.Layout(), nil).Aliases(context.Background())
+	if err != nil {
+		logger.Error("Failed to list aliases", zap.Error(err))
+		return
+	}
+
+	printAliases(logger, aliases)
+}
+
+// printAliases renders queue aliases sorted by name
+func printAliases(logger *zap.Logger, aliases map[string]string) {
+	if printStructured(logger, aliases) {
+		return
+	}
+
+	if len(aliases) == 0 {
+		fmt.Println("No queue aliases defined")
+		return
+	}
+
+	names := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+
+	rows := make([][]string, 0, len(names))
+	for _, alias := range names {
+		rows = append(rows, []string{alias, aliases[alias]})
+	}
+	printTable([]string{"ALIAS", "QUEUE"}, rows)
+}
+
+func switchAlias(redisOpts queue.RedisOptions, logger *zap.Logger, alias, target string) {
+	if remote.URL != "" {
+		switchAliasRemote(logger, alias, target)
+		return
+	}
+
+	q, err := queue.NewRedisQueue(redisOpts)
+	if err != nil {
+		logger.Error("Failed to connect to Redis", zap.Error(err))
+		return
+	}
+	defer q.Close()
+
+	result, err := queue.NewAdmin(q.Client(), q.Layout(), nil).SwitchAlias(context.Background(), alias, target)
+	if err != nil {
+		logger.Error("Failed to switch alias", zap.Error(err))
+		return
+	}
+
+	printAliasSwitch(result)
+}
+
+func printAliasSwitch(result *queue.AliasSwitch) {
+	fmt.Printf("Alias %s now points to %s\n", result.Alias, result.To)
+	if result.From != "" && result.From != result.To {
+		fmt.Printf("  %d jobs left to drain in %s\n", result.FromPending, result.From)
+	}
+}
+
+func pauseQueue(redisOpts queue.RedisOptions, logger *zap.Logger, name, reason string) {
+	if err := types.ValidateQueueName(name); err != nil {
+		logger.Error("Invalid queue name", zap.Error(err))
+		return
+	}
+
+	if remote.URL != "" {
+		pauseQueueRemote(logger, name, reason)
+		return
+	}
+
+	q, err := queue.NewRedisQueue(redisOpts)
+	if err != nil {
+		logger.Error("Failed to connect to Redis", zap.Error(err))
+		return
+	}
+	defer q.Close()
+
+	pause, err := queue.NewAdmin(q.Client(), q.Layout(), nil).PauseQueue(context.Background(), name, reason)
+	if err != nil {
+		logger.Error("Failed to pause queue", zap.Error(err))
+		return
+	}
+
+	fmt.Printf("Queue %s paused; workers stop dequeuing within a few seconds\n", pause.Queue)
+}
+
+func resumeQueue(redisOpts queue.RedisOptions, logger *zap.Logger, name string) {
+	if err := types.ValidateQueueName(name); err != nil {
+		logger.Error("Invalid queue name", zap.Error(err))
+		return
+	}
+
+	if remote.URL != "" {
+		resumeQueueRemote(logger, name)
+		return
+	}
+
+	q, err := queue.NewRedisQueue(redisOpts)
+	if err != nil {
+		logger.Error("Failed to connect to Redis", zap.Error(err))
+		return
+	}
+	defer q.Close()
+
+	resumed, err := queue.NewAdmin(q.Client(), q.Layout(), nil).ResumeQueue(context.Background(), name)
+	if err != nil {
+		logger.Error("Failed to resume queue", zap.Error(err))
+		return
+	}
+
+	if !resumed {
+		fmt.Printf("Queue %s is not paused\n", name)
+		return
+	}
+	fmt.Printf("Queue %s resumed\n", name)
+}
+
+func listPausedQueues(redisOpts queue.RedisOptions, logger *zap.Logger) {
+	if remote.URL != "" {
+		listPausedQueuesRemote(logger)
+		return
+	}
+
+	q, err := queue.NewRedisQueue(redisOpts)
+	if err != nil {
+		logger.Error("Failed to connect to Redis", zap.Error(err))
+		return
+	}
+	defer q.Close()
+
+	pauses, err := queue.NewAdmin(q.Client(), q.Layout(), nil).PausedQueues(context.Background())
+	if err != nil {
+		logger.Error("Failed to list paused queues", zap.Error(err))
+		return
+	}
+
+	printPausedQueues(logger, pauses)
+}
+
+func printPausedQueues(logger *zap.Logger, pauses []queue.QueuePause) {
+	if printStructured(logger, pauses) {
+		return
+	}
+
+	if len(pauses) == 0 {
+		fmt.Println("No queues are paused")
+		return
+	}
+
+	rows := make([][]string, 0, len(pauses))
+	for _, pause := range pauses {
+		rows = append(rows, []string{pause.Queue, formatTime(pause.PausedAt), pause.Reason})
+	}
+	printTable([]string{"QUEUE", "PAUSED AT", "REASON"}, rows)
+}
+
+func getRateLimit(cfg *config.Config, redisOpts queue.RedisOptions, logger *zap.Logger, jobType string) {
+	if remote.URL != "" {
+		getRateLimitRemote(logger, jobType)
+		return
+	}
+
+	q, err := queue.NewRedisQueue(redisOpts)
+	if err != nil {
+		logger.Error("Failed to connect to Redis", zap.Error(err))
+		return
+	}
+	defer q.Close()
+
+	rateLimiter := limiter.NewRedisRateLimiter(q.Client(), cfg.Redis.Key(cfg.RateLimit.Prefix), limiter.Unlimited, 0)
+	limit, err := rateLimiter.GetLimit(context.Background(), jobType)
+	if err != nil {
+		logger.Error("Failed to get rate limit", zap.Error(err))
+		return
+	}
+
+	printRateLimit(limit)
+}
+
+func setRateLimit(cfg *config.Config, redisOpts queue.RedisOptions, logger *zap.Logger, jobType string, rate float64, burst int) {
+	if remote.URL != "" {
+		setRateLimitRemote(logger, jobType, rate, burst)
+		return
+	}
+
+	q, err := queue.NewRedisQueue(redisOpts)
+	if err != nil {
+		logger.Error("Failed to connect to Redis", zap.Error(err))
+		return
+	}
+	defer q.Close()
+
+	if burst == 0 && rate > 0 {
+		burst = int(math.Ceil(rate))
+	}
+
+	ctx := context.Background()
+	rateLimiter := limiter.NewRedisRateLimiter(q.Client(), cfg.Redis.Key(cfg.RateLimit.Prefix), limiter.Unlimited, 0)
+	if err := rateLimiter.SetLimit(ctx, jobType, rate, burst); err != nil {
+		logger.Error("Failed to set rate limit", zap.Error(err))
+		return
+	}
+
+	limit, err := rateLimiter.GetLimit(ctx, jobType)
+	if err != nil {
+		logger.Error("Failed to get rate limit", zap.Error(err))
+		return
+	}
+
+	printRateLimit(limit)
+}
+
+func printRateLimit(limit *limiter.Limit) {
+	switch {
+	case limit.Limit < 0:
+		fmt.Printf("%s: unlimited\n", limit.JobType)
+	case limit.Limit == 0:
+		fmt.Printf("%s: stopped (limit 0)\n", limit.JobType)
+	default:
+		fmt.Printf("%s: %.2f jobs/s, burst %d, %.2f tokens available\n", limit.JobType, limit.Limit, limit.Burst, limit.Tokens)
+	}
+}
+
+func listSchedules(cfg *config.Config, redisOpts queue.RedisOptions, logger *zap.Logger) {
+	if remote.URL != "" {
+		listSchedulesRemote(logger)
+		return
+	}
+
+	scheduled, closeQueue, err := openScheduledQueue(cfg, redisOpts)
+	if err != nil {
+		logger.Error("Failed to open scheduled queue", zap.Error(err))
+		return
+	}
+	defer closeQueue()
+
+	entries, err := scheduled.List(context.Background())
+	if err != nil {
+		logger.Error("Failed to list schedules", zap.Error(err))
+		return
+	}
+
+	redactor, err := cfg.Redaction.Redactor()
+	if err != nil {
+		logger.Error("Failed to load redaction rules", zap.Error(err))
+		return
+	}
+
+	schedules := []api.ScheduleInfo{}
+	for _, entry := range entries {
+		if !entry.Recurring {
+			continue
+		}
+		schedules = append(schedules, api.ScheduleInfo{
+			ID:             entry.ID,
+			Type:           entry.Job.Type,
+			Payload:        redactor.Payload(entry.Job.Type, entry.Job.Payload),
+			CronExpression: entry.CronExpression,
+			NextRunAt:      entry.ExecuteAt,
+			Paused:         entry.Paused,
+			PausedAt:       entry.PausedAt,
+		})
+	}
+
+	printSchedules(logger, schedules)
+}
+
+// printSchedules renders recurring schedules, with their payloads in wide output
+func printSchedules(logger *zap.Logger, schedules []api.ScheduleInfo) {
+	if printStructured(logger, schedules) {
+		return
+	}
+
+	if len(schedules) == 0 {
+		fmt.Println("No recurring schedules defined")
+		return
+	}
+
+	headers := []string{"ID", "TYPE", "CRON", "STATE", "NEXT RUN"}
+	if outputFormat == outputWide {
+		headers = append(headers, "PAUSED AT", "PAYLOAD")
+	}
+	rows := make([][]string, 0, len(schedules))
+	for _, schedule := range schedules {
+		state := "active"
+		if schedule.Paused {
+			state = "paused"
+		}
+		row := []string{schedule.ID, schedule.Type, schedule.CronExpression, state, formatTime(schedule.NextRunAt)}
+		if outputFormat == outputWide {
+			pausedAt := ""
+			if schedule.PausedAt != nil {
+				pausedAt = formatTime(*schedule.PausedAt)
+			}
+			row = append(row, pausedAt, string(schedule.Payload))
+		}
+		rows = append(rows, row)
+	}
+	printTable(headers, rows)
+}
+
+func setSchedulePaused(cfg *config.Config, redisOpts queue.RedisOptions, logger *zap.Logger, id string, paused bool) {
+	if remote.URL != "" {
+		setSchedulePausedRemote(logger, id, paused)
+		return
+	}
+
+	scheduled, closeQueue, err := openScheduledQueue(cfg, redisOpts)
+	if err != nil {
+		logger.Error("Failed to open scheduled queue", zap.Error(err))
+		return
+	}
+	defer closeQueue()
+
+	entry, err := scheduled.SetPaused(context.Background(), id, paused)
+	if err != nil {
+		logger.Error("Failed to update schedule", zap.String("schedule_id", id), zap.Error(err))
+		return
+	}
+
+	printSchedulePaused(entry.ID, entry.Paused, entry.ExecuteAt)
+}
+
+func printSchedulePaused(id string, paused bool, nextRunAt time.Time) {
+	if paused {
+		fmt.Printf("Schedule %s paused\n", id)
+	} else {
+		fmt.Printf("Schedule %s resumed, next run at %s\n", id, formatTime(nextRunAt))
+	}
+}
+
+func listScheduleRuns(cfg *config.Config, redisOpts queue.RedisOptions, logger *zap.Logger, id string, limit int) {
+	if remote.URL != "" {
+		listScheduleRunsRemote(logger, id, limit)
+		return
+	}
+
+	scheduled, closeQueue, err := openScheduledQueue(cfg, redisOpts)
+	if err != nil {
+		logger.Error("Failed to open scheduled queue", zap.Error(err))
+		return
+	}
+	defer closeQueue()
+
+	runs, err := scheduled.Runs(context.Background(), id, limit)
+	if err != nil {
+		logger.Error("Failed to list schedule runs", zap.String("schedule_id", id), zap.Error(err))
+		return
+	}
+
+	printScheduleRuns(logger, id, runs)
+}
+
+// printScheduleRuns renders the runs of a recurring schedule, newest first
+func printScheduleRuns(logger *zap.Logger, id string, runs []types.ScheduleRun) {
+	if printStructured(logger, runs) {
+		return
+	}
+
+	if len(runs) == 0 {
+		fmt.Printf("No runs recorded for schedule %s\n", id)
+		return
+	}
+
+	rows := make([][]string, 0, len(runs))
+	for _, run := range runs {
+		status := string(run.Status)
+		if status == "" {
+			status = "pending"
+		}
+		finishedAt := ""
+		if run.FinishedAt != nil {
+			finishedAt = formatTime(*run.FinishedAt)
+		}
+		attempts := ""
+		if run.Attempts > 0 {
+			attempts = fmt.Sprint(run.Attempts)
+		}
+		rows = append(rows, []string{run.JobID, formatTime(run.EnqueuedAt), status, attempts, run.Duration, finishedAt, run.Error})
+	}
+	printTable([]string{"JOB ID", "ENQUEUED AT", "STATUS", "ATTEMPTS", "DURATION", "FINISHED AT", "ERROR"}, rows)
+}
+
+// openScheduledQueue connects a scheduled queue with the configured keyring
+func openScheduledQueue(cfg *config.Config, redisOpts queue.RedisOptions) (*queue.ScheduledQueue, func(), error) {
+	keyring, err := cfg.Encryption.Keyring()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load encryption keys: %w", err)
+	}
+
+	q, err := queue.NewRedisQueue(redisOpts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	scheduled := queue.NewScheduledQueue(q.Client(), q.Layout(), q)
+	scheduled.SetKeyring(keyring)
+	return scheduled, func() { q.Close() }, nil
+}
+
+// apiOptions locates the server for commands that go through its HTTP API
+type apiOptions struct {
+	URL     string
+	APIKey  string
+	Timeout time.Duration
+}
+
+// apiError is a non-2xx response from the server's API
+type apiError struct {
+	StatusCode int
+	Message    string
+	Violations []types.SchemaViolation // Why a payload was rejected with 422
+}
+
+func (e *apiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("server returned %d", e.StatusCode)
+	}
+	return fmt.Sprintf("server returned %d: %s", e.StatusCode, e.Message)
+}
+
+// endpoint returns the URL of path on the server
+func (o apiOptions) endpoint(path string) string {
+	return strings.TrimSuffix(o.URL, "/") + path
+}
+
+// callAPI sends a request to the server's API, with body encoded as JSON
+// unless nil, and decodes the JSON response into v unless nil
+func callAPI(ctx context.Context, opts apiOptions, method, path string, body, v interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, opts.endpoint(path), reader)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if opts.APIKey != "" {
+		req.Header.Set("X-API-Key", opts.APIKey)
+	}
+
+	client := &http.Client{Timeout: opts.Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &apiError{StatusCode: resp.StatusCode}
+		var failure struct {
+			Error      string                  `json:"error"`
+			Details    string                  `json:"details"`
+			Violations []types.SchemaViolation `json:"violations"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&failure) == nil {
+			apiErr.Message = failure.Error
+			if failure.Details != "" {
+				apiErr.Message += ": " + failure.Details
+			}
+			apiErr.Violations = failure.Violations
+		}
+		return apiErr
+	}
+
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// hasStatus reports whether err is an API response with the given status code
+func hasStatus(err error, statusCode int) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
+
+// remoteStatsSource samples statistics through the server's API. Sections
+// the server has disabled are left out, as is the scheduled count, which
+// the API doesn't expose.
+func remoteStatsSource(opts apiOptions) func(context.Context) (*statsReport, error) {
+	return func(ctx context.Context) (*statsReport, error) {
+		var stats queue.QueueStats
+		if err := callAPI(ctx, opts, http.MethodGet, "/api/v1/queue/stats", nil, &stats); err != nil {
+			return nil, err
+		}
+		report := &statsReport{
+			QueueSize:     stats.QueueSize,
+			Retrying:      stats.Retrying,
+			TotalEnqueued: stats.TotalEnqueued,
+			TotalDequeued: stats.TotalDequeued,
+			Priority:      stats.ByPriority,
+			SampledAt:     time.Now(),
+		}
+
+		var dlqStats queue.DLQStats
+		if err := callAPI(ctx, opts, http.MethodGet, "/api/v1/dlq/stats", nil, &dlqStats); err == nil {
+			report.DLQSize = dlqStats.Size
+		} else if !hasStatus(err, http.StatusNotImplemented) {
+			return nil, fmt.Errorf("failed to get DLQ size: %w", err)
+		}
+
+		var retries struct {
+			Summary *queue.RetrySummary `json:"summary"`
+		}
+		if err := callAPI(ctx, opts, http.MethodGet, "/api/v1/queue/retries?limit=0", nil, &retries); err == nil {
+			report.Retries = retries.Summary
+		} else if !hasStatus(err, http.StatusNotImplemented) {
+			return nil, fmt.Errorf("failed to get pending retries: %w", err)
+		}
+
+		var workers api.WorkerStatsResponse
+		if err := callAPI(ctx, opts, http.MethodGet, "/api/v1/workers/stats", nil, &workers); err == nil {
+			report.Workers = &workerCounts{
+				Pools:       workers.Pools,
+				ActiveJobs:  workers.ActiveWorkers,
+				Concurrency: workers.TotalWorkers,
+			}
+			for _, worker := range workers.WorkersInfo {
+				if worker.Status == "draining" {
+					report.Workers.Draining++
+				}
+			}
+		} else if !hasStatus(err, http.StatusNotImplemented) {
+			return nil, fmt.Errorf("failed to get worker stats: %w", err)
+		}
+		return report, nil
+	}
+}
+
+// submitRemote submits job through the server, which assigns it a new ID
+func submitRemote(ctx context.Context, job *types.Job) error {
+	request := types.JobRequest{
+		Type:       job.Type,
+		Payload:    job.Payload,
+		MaxRetries: &job.MaxRetries,
+		Queue:      job.Queue,
+		ExpiresAt:  job.ExpiresAt,
+
+		PayloadVersion: job.PayloadVersion,
+	}
+	if job.Timeout > 0 {
+		request.Timeout = job.Timeout.String()
+	}
+
+	var response types.JobResponse
+	if err := callAPI(ctx, remote, http.MethodPost, "/api/v1/jobs", request, &response); err != nil {
+		return err
+	}
+	job.ID = response.JobID
+	return nil
+}
+
+func listFailedJobsRemote(logger *zap.Logger, reason types.FailureReason, offset, limit int) {
+	query := url.Values{}
+	query.Set("offset", fmt.Sprint(offset))
+	query.Set("limit", fmt.Sprint(limit))
+	if reason != "" {
+		query.Set("reason", string(reason))
+	}
+
+	var response api.ListFailedJobsResponse
+	if err := callAPI(context.Background(), remote, http.MethodGet, "/api/v1/dlq?"+query.Encode(), nil, &response); err != nil {
+		logger.Error("Failed to list failed jobs", zap.Error(err))
+		return
+	}
+
+	printFailedJobs(logger, failedJobsReport{Total: response.TotalCount, Jobs: response.Jobs})
+}
+
+func triageFailedJobsRemote(logger *zap.Logger, maxEntries, top int) {
+	// Ask for every group so the count of those not shown matches direct mode
+	var response api.DLQTriageResponse
+	path := fmt.Sprintf("/api/v1/dlq/triage?max=%d&top=%d", maxEntries, maxEntries)
+	if err := callAPI(context.Background(), remote, http.MethodGet, path, nil, &response); err != nil {
+		logger.Error("Failed to triage dead letter queue", zap.Error(err))
+		return
+	}
+
+	report := &queue.TriageReport{
+		GeneratedAt: response.GeneratedAt,
+		Scanned:     response.Scanned,
+		Truncated:   response.Truncated,
+		Groups:      make([]queue.TriageGroup, 0, len(response.Groups)),
+	}
+	for _, group := range response.Groups {
+		report.Groups = append(report.Groups, queue.TriageGroup{
+			JobType:       group.JobType,
+			Reason:        types.FailureReason(group.Reason),
+			Fingerprint:   group.Fingerprint,
+			Count:         group.Count,
+			Example:       group.Example,
+			FirstFailedAt: group.FirstFailedAt,
+			LastFailedAt:  group.LastFailedAt,
+			SampleJobIDs:  group.SampleJobIDs,
+		})
+	}
+	printTriage(logger, report, top)
+}
+
+func purgeQueueRemote(logger *zap.Logger, target, queueName string, force bool) {
+	switch target {
+	case "main", "priority", "scheduled", "failed":
+	default:
+		logger.Error("Invalid queue, must be main, priority, scheduled or failed", zap.String("queue", target))
+		return
+	}
+
+	// The API has no size for every target, so the prompt can't give a count
+	if !force {
+		fmt.Printf("Permanently delete every job in the %s queue? Type 'yes' to confirm: ", target)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.TrimSpace(answer) != "yes" {
+			fmt.Println("Purge cancelled")
+			return
+		}
+	}
+
+	query := url.Values{}
+	query.Set("force", "true")
+	if queueName != "" {
+		query.Set("queue", queueName)
+	}
+
+	var response struct {
+		Purged int `json:"purged"`
+	}
+	path := "/api/v1/admin/queues/" + url.PathEscape(target) + "?" + query.Encode()
+	if err := callAPI(context.Background(), remote, http.MethodDelete, path, nil, &response); err != nil {
+		logger.Error("Failed to purge queue", zap.Error(err))
+		return
+	}
+
+	fmt.Printf("Purged %d jobs from the %s queue\n", response.Purged, target)
+}
+
+func listAliasesRemote(logger *zap.Logger) {
+	var response struct {
+		Aliases map[string]string `json:"aliases"`
+	}
+	if err := callAPI(context.Background(), remote, http.MethodGet, "/api/v1/admin/aliases", nil, &response); err != nil {
+		logger.Error("Failed to list aliases", zap.Error(err))
+		return
+	}
+
+	printAliases(logger, response.Aliases)
+}
+
+func switchAliasRemote(logger *zap.Logger, alias, target string) {
+	request := map[string]string{"queue": target}
+	var result queue.AliasSwitch
+	if err := callAPI(context.Background(), remote, http.MethodPut, "/api/v1/admin/aliases/"+url.PathEscape(alias), request, &result); err != nil {
+		logger.Error("Failed to switch alias", zap.Error(err))
+		return
+	}
+
+	printAliasSwitch(&result)
+}
+
+func retryFailedJobRemote(logger *zap.Logger, jobID string) {
+	err := callAPI(context.Background(), remote, http.MethodPost, "/api/v1/admin/dlq/"+url.PathEscape(jobID)+"/retry", nil, nil)
+	if hasStatus(err, http.StatusNotFound) {
+		fmt.Printf("Job %s is not in the dead letter queue\n", jobID)
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to retry job", zap.String("job_id", jobID), zap.Error(err))
+		return
+	}
+
+	fmt.Printf("Job %s moved back to its queue\n", jobID)
+}
+
+func retryAllFailedJobsRemote(logger *zap.Logger) {
+	ctx := context.Background()
+	var jobIDs []string
+	for offset := 0; ; offset += retryPageSize {
+		var response api.ListFailedJobsResponse
+		path := fmt.Sprintf("/api/v1/dlq?offset=%d&limit=%d", offset, retryPageSize)
+		if err := callAPI(ctx, remote, http.MethodGet, path, nil, &response); err != nil {
+			logger.Error("Failed to list failed jobs", zap.Error(err))
+			return
+		}
+		for _, job := range response.Jobs {
+			jobIDs = append(jobIDs, job.JobID)
+		}
+		if len(response.Jobs) < retryPageSize {
+			break
+		}
+	}
+
+	retried := retryEach(logger, jobIDs, func(jobID string) error {
+		err := callAPI(ctx, remote, http.MethodPost, "/api/v1/admin/dlq/"+url.PathEscape(jobID)+"/retry", nil, nil)
+		if hasStatus(err, http.StatusNotFound) {
+			return queue.ErrNotInDLQ
+		}
+		return err
+	})
+	fmt.Printf("Retried %d of %d failed jobs\n", retried, len(jobIDs))
+}
+
+func pauseQueueRemote(logger *zap.Logger, name, reason string) {
+	request := map[string]string{"reason": reason}
+	var pause queue.QueuePause
+	if err := callAPI(context.Background(), remote, http.MethodPost, "/api/v1/admin/queues/"+url.PathEscape(name)+"/pause", request, &pause); err != nil {
+		logger.Error("Failed to pause queue", zap.Error(err))
+		return
+	}
+
+	fmt.Printf("Queue %s paused; workers stop dequeuing within a few seconds\n", pause.Queue)
+}
+
+func resumeQueueRemote(logger *zap.Logger, name string) {
+	err := callAPI(context.Background(), remote, http.MethodPost, "/api/v1/admin/queues/"+url.PathEscape(name)+"/resume", nil, nil)
+	if hasStatus(err, http.StatusNotFound) {
+		fmt.Printf("Queue %s is not paused\n", name)
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to resume queue", zap.Error(err))
+		return
+	}
+
+	fmt.Printf("Queue %s resumed\n", name)
+}
+
+func listPausedQueuesRemote(logger *zap.Logger) {
+	var response struct {
+		Paused []queue.QueuePause `json:"paused"`
+	}
+	if err := callAPI(context.Background(), remote, http.MethodGet, "/api/v1/admin/queues/paused", nil, &response); err != nil {
+		logger.Error("Failed to list paused queues", zap.Error(err))
+		return
+	}
+
+	printPausedQueues(logger, response.Paused)
+}
+
+func getRateLimitRemote(logger *zap.Logger, jobType string) {
+	var limit limiter.Limit
+	if err := callAPI(context.Background(), remote, http.MethodGet, "/api/v1/admin/ratelimits/"+url.PathEscape(jobType), nil, &limit); err != nil {
+		logger.Error("Failed to get rate limit", zap.Error(err))
+		return
+	}
+
+	printRateLimit(&limit)
+}
+
+func setRateLimitRemote(logger *zap.Logger, jobType string, rate float64, burst int) {
+	// The server defaults the burst to the limit rounded up, like direct mode
+	request := map[string]interface{}{"limit": rate, "burst": burst}
+	var limit limiter.Limit
+	if err := callAPI(context.Background(), remote, http.MethodPut, "/api/v1/admin/ratelimits/"+url.PathEscape(jobType), request, &limit); err != nil {
+		logger.Error("Failed to set rate limit", zap.Error(err))
+		return
+	}
+
+	printRateLimit(&limit)
+}
+
+func listSchedulesRemote(logger *zap.Logger) {
+	var response api.ListSchedulesResponse
+	if err := callAPI(context.Background(), remote, http.MethodGet, "/api/v1/schedules", nil, &response); err != nil {
+		logger.Error("Failed to list schedules", zap.Error(err))
+		return
+	}
+
+	printSchedules(logger, response.Schedules)
+}
+
+func setSchedulePausedRemote(logger *zap.Logger, id string, paused bool) {
+	action := "resume"
+	if paused {
+		action = "pause"
+	}
+
+	var schedule api.ScheduleInfo
+	if err := callAPI(context.Background(), remote, http.MethodPost, "/api/v1/schedules/"+url.PathEscape(id)+"/"+action, nil, &schedule); err != nil {
+		logger.Error("Failed to update schedule", zap.String("schedule_id", id), zap.Error(err))
+		return
+	}
+
+	printSchedulePaused(schedule.ID, schedule.Paused, schedule.NextRunAt)
+}
+
+func listScheduleRunsRemote(logger *zap.Logger, id string, limit int) {
+	var response api.ListScheduleRunsResponse
+	path := fmt.Sprintf("/api/v1/schedules/%s/runs?limit=%d", url.PathEscape(id), limit)
+	if err := callAPI(context.Background(), remote, http.MethodGet, path, nil, &response); err != nil {
+		logger.Error("Failed to list schedule runs", zap.String("schedule_id", id), zap.Error(err))
+		return
+	}
+
+	runs := make([]types.ScheduleRun, 0, len(response.Runs))
+	for _, run := range response.Runs {
+		runs = append(runs, types.ScheduleRun{
+			JobID:      run.JobID,
+			EnqueuedAt: run.EnqueuedAt,
+			Status:     types.JobStatus(run.Status),
+			Attempts:   run.Attempts,
+			Duration:   run.Duration,
+			Error:      run.Error,
+			FinishedAt: run.FinishedAt,
+		})
+	}
+	printScheduleRuns(logger, id, runs)
+}
+
+func listWorkersRemote(logger *zap.Logger) {
+	var response api.WorkerStatsResponse
+	if err := callAPI(context.Background(), remote, http.MethodGet, "/api/v1/workers/stats", nil, &response); err != nil {
+		logger.Error("Failed to list workers", zap.Error(err))
+		return
+	}
+
+	workers := make([]queue.WorkerHeartbeat, 0, len(response.WorkersInfo))
+	for _, info := range response.WorkersInfo {
+		workers = append(workers, queue.WorkerHeartbeat{
+			ID:            info.ID,
+			Hostname:      info.Hostname,
+			PID:           info.PID,
+			Concurrency:   info.Concurrency,
+			ActiveJobs:    info.ActiveJobs,
+			Draining:      info.Status == "draining",
+			StartedAt:     info.StartedAt,
+			LastSeen:      info.LastSeen,
+			JobsProcessed: int64(info.JobsProcessed),
+			JobsFailed:    int64(info.JobsFailed),
+			JobsRetried:   int64(info.JobsRetried),
+		})
+	}
+	printWorkers(logger, workers)
+}
+
+func inspectJobRemote(logger *zap.Logger, jobID string) {
+	var response api.JobStatusResponse
+	if err := callAPI(context.Background(), remote, http.MethodGet, "/api/v1/jobs/"+url.PathEscape(jobID), nil, &response); err != nil {
+		logger.Error("Failed to get job status", zap.String("job_id", jobID), zap.Error(err))
+		return
+	}
+
+	if printStructured(logger, response) {
+		return
+	}
+	printJobStatus(&queue.JobStatusRecord{
+		JobID:       response.JobID,
+		Type:        response.Type,
+		Status:      response.Status,
+		EnqueuedAt:  response.EnqueuedAt,
+		StartedAt:   response.StartedAt,
+		CompletedAt: response.CompletedAt,
+		Attempts:    response.Attempts,
+		MaxRetries:  response.MaxRetries,
+		Error:       response.Error,
+		Progress:    response.Progress,
+	})
+}
+
+// serverOptions locates the server for commands that always go through its
+// API: --server if set, otherwise the configured server address
+func serverOptions(cfg *config.Config) apiOptions {
+	opts := remote
+	if opts.URL == "" {
+		opts.URL = fmt.Sprintf("http://%s", cfg.Server.Address())
+	}
+	return opts
+}
+
+// listJobTypes returns the job types the server accepts
+func listJobTypes(opts apiOptions) ([]api.JobTypeInfo, error) {
+	var response struct {
+		Handlers []api.JobTypeInfo `json:"handlers"`
+	}
+	if err := callAPI(context.Background(), opts, http.MethodGet, "/api/v1/jobs/types", nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Handlers, nil
+}
+
+func describeJobTypes(logger *zap.Logger, opts apiOptions, jobType, path string) {
+	handlers, err := listJobTypes(opts)
+	if err != nil {
+		logger.Error("Failed to list job types", zap.Error(err))
+		return
+	}
+
+	infos := handlers
+	if jobType != "" {
+		infos = nil
+		for _, info := range handlers {
+			if info.Type == jobType {
+				infos = append(infos, info)
+			}
+		}
+		if len(infos) == 0 {
+			setExitCode(exitNotFound)
+			logger.Error("Unknown job type", zap.String("job_type", jobType))
+			return
+		}
+	}
+
+	if path != "" {
+		if jobType == "" {
+			logger.Error("--write needs a job type")
+			return
+		}
+		writeExamplePayload(logger, infos[0], path)
+		return
+	}
+
+	if printStructured(logger, infos) {
+		return
+	}
+
+	if len(infos) == 0 {
+		fmt.Println("No job types registered")
+		return
+	}
+
+	for i, info := range infos {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s\n", info.Type)
+		if info.Description != "" {
+			fmt.Printf("  %s\n", info.Description)
+		}
+		if info.Version != "" {
+			fmt.Printf("  Version: %s\n", info.Version)
+		}
+		if info.Policy != nil {
+			if data, err := json.Marshal(info.Policy); err == nil && string(data) != "{}" {
+				fmt.Printf("  Policy: %s\n", data)
+			}
+		}
+		if len(info.PayloadSchema) > 0 {
+			fmt.Printf("  Payload schema: %s\n", info.PayloadSchema)
+		}
+		fmt.Printf("  Example:\n    gopher submit -t %s -p %s\n", info.Type, shellQuote(string(examplePayload(info))))
+	}
+}
+
+// writeExamplePayload writes a job type's example payload as indented JSON
+// for editing before submission
+func writeExamplePayload(logger *zap.Logger, info api.JobTypeInfo, path string) {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, examplePayload(info), "", "  "); err != nil {
+		logger.Error("Invalid example payload", zap.String("job_type", info.Type), zap.Error(err))
+		return
+	}
+	indented.WriteByte('\n')
+
+	if err := os.WriteFile(path, indented.Bytes(), 0644); err != nil {
+		logger.Error("Failed to write example payload", zap.String("file", path), zap.Error(err))
+		return
+	}
+
+	fmt.Printf("Wrote an example %s payload to %s\n", info.Type, path)
+	fmt.Printf("Edit it, then submit with:\n  gopher submit -t %s --from-file %s\n", info.Type, path)
+}
+
+// examplePayload returns the payload a job type advertises, or an empty object
+func examplePayload(info api.JobTypeInfo) json.RawMessage {
+	if len(info.ExamplePayload) == 0 {
+		return json.RawMessage("{}")
+	}
+	return info.ExamplePayload
+}
+
+// shellQuote wraps s in single quotes for pasting into a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func listWorkers(cfg *config.Config, redisOpts queue.RedisOptions, logger *zap.Logger) {
+	if remote.URL != "" {
+		listWorkersRemote(logger)
+		return
+	}
+
+	q, err := queue.NewRedisQueue(redisOpts)
+	if err != nil {
+		logger.Error("Failed to connect to Redis", zap.Error(err))
+		return
+	}
+	defer q.Close()
+
+	workers, err := queue.NewHeartbeatRegistry(q.Client(), q.Layout(), 3*cfg.Worker.HeartbeatInterval).List(context.Background())
+	if err != nil {
+		logger.Error("Failed to list workers", zap.Error(err))
+		return
+	}
+
+	printWorkers(logger, workers)
+}
+
+// printWorkers renders live worker pools, with process details in wide output
+func printWorkers(logger *zap.Logger, workers []queue.WorkerHeartbeat) {
+	if printStructured(logger, workers) {
+		return
+	}
+
+	if len(workers) == 0 {
+		fmt.Println("No live workers")
+		return
+	}
+
+	headers := []string{"ID", "HOSTNAME", "ACTIVE", "STATE", "PROCESSED", "FAILED", "LAST SEEN"}
+	if outputFormat == outputWide {
+		headers = append(headers, "PID", "RETRIED", "STARTED AT", "JOB TYPES")
+	}
+	rows := make([][]string, 0, len(workers))
+	for _, worker := range workers {
+		state := "running"
+		if worker.Draining {
+			state = "draining"
+		}
+		row := []string{worker.ID, worker.Hostname, fmt.Sprintf("%d/%d", worker.ActiveJobs, worker.Concurrency), state,
+			fmt.Sprint(worker.JobsProcessed), fmt.Sprint(worker.JobsFailed), formatTime(worker.LastSeen)}
+		if outputFormat == outputWide {
+			row = append(row, fmt.Sprint(worker.PID), fmt.Sprint(worker.JobsRetried), formatTime(worker.StartedAt),
+				strings.Join(worker.JobTypes, ","))
+		}
+		rows = append(rows, row)
+	}
+	printTable(headers, rows)
+}
+
+func inspectJob(cfg *config.Config, redisOpts queue.RedisOptions, logger *zap.Logger, jobID string) {
+	if remote.URL != "" {
+		inspectJobRemote(logger, jobID)
+		return
+	}
+
+	if !cfg.Status.Enabled {
+		logger.Error("Job status tracking is not enabled (STATUS_ENABLED=false)")
+		return
+	}
+
+	q, err := queue.NewRedisQueue(redisOpts)
+	if err != nil {
+		logger.Error("Failed to connect to Redis", zap.Error(err))
+		return
+	}
+	defer q.Close()
+
+	record, err := queue.NewStatusStore(q.Client(), q.Layout(), cfg.Status.TTL).Get(context.Background(), jobID)
+	if err != nil {
+		logger.Error("Failed to get job status", zap.String("job_id", jobID), zap.Error(err))
+		return
+	}
+	if record == nil {
+		setExitCode(exitNotFound)
+		logger.Error("Job not found", zap.String("job_id", jobID))
+		return
+	}
+
+	if printStructured(logger, record) {
+		return
+	}
+	printJobStatus(record)
+}
+
+// printJobStatus renders a job's status and the progress its handler reported
+func printJobStatus(record *queue.JobStatusRecord) {
+	fmt.Printf("Job %s\n", record.JobID)
+	fmt.Printf("  Type: %s\n", record.Type)
+	fmt.Printf("  Status: %s\n", record.Status)
+	fmt.Printf("  Attempts: %d/%d\n", record.Attempts, record.MaxRetries)
+	fmt.Printf("  Enqueued at: %s\n", formatTime(record.EnqueuedAt))
+	if record.StartedAt != nil {
+		fmt.Printf("  Started at: %s\n", formatTime(*record.StartedAt))
+	}
+	if record.CompletedAt != nil {
+		fmt.Printf("  Completed at: %s\n", formatTime(*record.CompletedAt))
+	}
+	if progress := record.Progress; progress != nil {
+		fmt.Printf("  Progress: %.0f%%", progress.Percent)
+		if progress.Total > 0 {
+			fmt.Printf(" (%d/%d)", progress.Done, progress.Total)
+		}
+		if progress.Step != "" {
+			fmt.Printf(" %s", progress.Step)
+		}
+		fmt.Printf(", reported %s\n", formatTime(progress.UpdatedAt))
+	}
+	if record.Error != "" {
+		fmt.Printf("  Error: %s\n", record.Error)
+	}
+}
+
+// printStructured prints v as JSON or YAML when --output asks for it and
+// reports whether it did, leaving table output to the caller
+func printStructured(logger *zap.Logger, v interface{}) bool {
+	switch outputFormat {
+	case outputJSON:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			logger.Error("Failed to encode output", zap.Error(err))
+			return true
+		}
+		fmt.Println(string(data))
+		return true
+
+	case outputYAML:
+		// Going through JSON keeps the field names and omissions of the json tags
+		data, err := json.Marshal(v)
+		if err != nil {
+			logger.Error("Failed to encode output", zap.Error(err))
+			return true
+		}
+		var doc interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			logger.Error("Failed to encode output", zap.Error(err))
+			return true
+		}
+		out, err := yaml.Marshal(doc)
+		if err != nil {
+			logger.Error("Failed to encode output", zap.Error(err))
+			return true
+		}
+		fmt.Print(string(out))
+		return true
+	}
+	return false
+}
+
+// tableCell keeps multi-line values such as errors on one table row
+var tableCell = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ")
+
+// printTable writes rows as columns aligned under headers
+func printTable(headers []string, rows [][]string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = tableCell.Replace(cell)
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	w.Flush()
+}
+
+// formatTime renders t in the display timezone, falling back to UTC for unknown zones
+func formatTime(t time.Time) string {
+	loc, err := time.LoadLocation(displayTimezone)
+	if err != nil || displayTimezone == "" {
+		loc = time.UTC
+	}
+	return t.In(loc).Format(time.RFC3339)
 }
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/aneeshsunganahalli/Gopher/examples/handlers"
+	"github.com/aneeshsunganahalli/Gopher/internal/config"
+	"github.com/aneeshsunganahalli/Gopher/internal/ingest"
+	"github.com/aneeshsunganahalli/Gopher/internal/job"
+	"github.com/aneeshsunganahalli/Gopher/internal/queue"
+	"github.com/aneeshsunganahalli/Gopher/internal/trigger"
+	"go.uber.org/zap"
+)
+
+// The ingester enqueues jobs for S3 notifications delivered through SQS and
+// GCS notifications delivered through Pub/Sub, using the storage triggers in
+// TRIGGERS_DEFINITIONS.
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	logger, err := initLogger(cfg.Log)
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
+	if cfg.Queue.Backend != config.QueueBackendRedis {
+		logger.Fatal("The ingester only supports the redis queue backend")
+	}
+	if cfg.Ingest.SQSQueueURL == "" && cfg.Ingest.PubSubSubscription == "" {
+		logger.Fatal("Set INGEST_SQS_QUEUE_URL and/or INGEST_PUBSUB_SUBSCRIPTION")
+	}
+
+	// Sign enqueued jobs and verify dequeued ones when signing keys are configured
+	signer, err := cfg.Signing.Signer()
+	if err != nil {
+		logger.Fatal("Failed to load signing keys", zap.Error(err))
+	}
+	if signer != nil {
+		logger.Info("Job signing enabled",
+			zap.String("algorithm", signer.Algorithm()),
+			zap.Bool("verify_only", !signer.CanSign()),
+		)
+	}
+
+	jobQueue, err := queue.NewRedisQueue(queue.RedisOptions{
+		URL:                   cfg.Redis.URL,
+		Password:              cfg.Redis.Password,
+		DB:                    cfg.Redis.DB,
+		ConnectTimeout:        cfg.Redis.Timeout,
+		CommandTimeout:        cfg.Redis.Timeout,
+		ClusterAddrs:          cfg.Redis.ClusterAddrs,
+		KeyPrefix:             cfg.Redis.KeyPrefix,
+		Compression:           cfg.Redis.Compression,
+		CompressionThreshold:  cfg.Redis.CompressionThreshold,
+		Format:                cfg.Redis.Format,
+		SentinelMaster:        cfg.Redis.SentinelMaster,
+		SentinelAddrs:         cfg.Redis.SentinelAddrs,
+		SentinelPassword:      cfg.Redis.SentinelPassword,
+		TLSCACert:             cfg.Redis.TLSCACert,
+		TLSCert:               cfg.Redis.TLSCert,
+		TLSKey:                cfg.Redis.TLSKey,
+		TLSInsecureSkipVerify: cfg.Redis.TLSInsecureSkipVerify,
+		Signing:               queue.Signing{Signer: signer, AllowUnsigned: cfg.Signing.AllowUnsigned},
+	})
+	if err != nil {
+		logger.Fatal("Failed to initialize Redis queue", zap.Error(err))
+	}
+	defer jobQueue.Close()
+
+	keyring, err := cfg.Encryption.Keyring()
+	if err != nil {
+		logger.Fatal("Failed to load encryption keys", zap.Error(err))
+	}
+	if keyring != nil {
+		jobQueue.SetKeyring(keyring)
+	}
+
+	var ingestQueue queue.Queue = jobQueue
+	if cfg.Server.QueueAlias != "" {
+		ingestQueue = queue.NewAliasQueue(jobQueue, cfg.Server.QueueAlias)
+	}
+
+	registry := job.NewRegistry(logger)
+	if err := registerJobHandlers(registry, logger); err != nil {
+		logger.Fatal("Failed to register job handlers", zap.Error(err))
+	}
+
+	defs, err := cfg.Triggers.Parse()
+	if err != nil {
+		logger.Fatal("Failed to load triggers", zap.Error(err))
+	}
+	triggers, err := trigger.NewManager(defs, ingestQueue, registry, cfg.Worker.MaxRetries, logger)
+	if err != nil {
+		logger.Fatal("Failed to configure triggers", zap.Error(err))
+	}
+	// Notifications arriving in read-only mode are left for redelivery
+	triggers.SetReadOnly(queue.NewReadOnlyStore(jobQueue.Client(), jobQueue.Layout()))
+	if !triggers.HasSource(trigger.SourceStorage) {
+		logger.Fatal("No storage triggers configured in TRIGGERS_DEFINITIONS")
+	}
+
+	ingester := ingest.New(triggers, logger)
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+
+	if cfg.Ingest.SQSQueueURL != "" {
+		client, err := cfg.AWS.SQSClient(ctx)
+		if err != nil {
+			logger.Fatal("Failed to configure SQS", zap.Error(err))
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ingester.RunSQS(ctx, client, cfg.Ingest.SQSQueueURL, cfg.Ingest.SQSWait)
+		}()
+	}
+
+	if cfg.Ingest.PubSubSubscription != "" {
+		client := ingest.NewPubSubClient(cfg.Ingest.PubSubEndpoint, cfg.Ingest.PubSubToken)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ingester.RunPubSub(ctx, client, cfg.Ingest.PubSubSubscription)
+		}()
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logger.Info("Shutting down ingester...")
+	cancel()
+	wg.Wait()
+	logger.Info("Ingester shutdown complete")
+}
+
+func initLogger(cfg config.LogConfig) (*zap.Logger, error) {
+	var zapConfig zap.Config
+
+	if cfg.Format == "console" {
+		zapConfig = zap.NewDevelopmentConfig()
+	} else {
+		zapConfig = zap.NewProductionConfig()
+	}
+
+	// Set log level
+	switch cfg.Level {
+	case "debug":
+		zapConfig.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
+	case "info":
+		zapConfig.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
+	case "warn":
+		zapConfig.Level = zap.NewAtomicLevelAt(zap.WarnLevel)
+	case "error":
+		zapConfig.Level = zap.NewAtomicLevelAt(zap.ErrorLevel)
+	default:
+		zapConfig.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
+	}
+
+	return zapConfig.Build()
+}
+
+func registerJobHandlers(registry *job.Registry, logger *zap.Logger) error {
+	// Register email handler
+	emailHandler := handlers.NewEmailJobHandler(logger)
+	if err := registry.Register(emailHandler); err != nil {
+		return err
+	}
+
+	// Register image handler
+	imageHandler := handlers.NewImageJobHandler(logger)
+	if err := registry.Register(imageHandler); err != nil {
+		return err
+	}
+
+	// Register math handler
+	mathHandler := handlers.NewMathJobHandler(logger)
+	if err := registry.Register(mathHandler); err != nil {
+		return err
+	}
+
+	logger.Info("All job handlers registered successfully")
+	return nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"os"
 	"os/signal"
@@ -9,14 +10,28 @@ import (
 	"time"
 
 	"github.com/aneeshsunganahalli/Gopher/examples/handlers"
+	"github.com/aneeshsunganahalli/Gopher/internal/auth"
+	"github.com/aneeshsunganahalli/Gopher/internal/backend"
 	"github.com/aneeshsunganahalli/Gopher/internal/config"
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
 	"github.com/aneeshsunganahalli/Gopher/internal/job"
+	_ "github.com/aneeshsunganahalli/Gopher/internal/kafka" // Client for the kafka queue backend
+	"github.com/aneeshsunganahalli/Gopher/internal/limiter"
+	"github.com/aneeshsunganahalli/Gopher/internal/metrics"
 	"github.com/aneeshsunganahalli/Gopher/internal/queue"
+	"github.com/aneeshsunganahalli/Gopher/internal/selfcheck"
 	"github.com/aneeshsunganahalli/Gopher/internal/server"
+	"github.com/aneeshsunganahalli/Gopher/internal/systemd"
+	"github.com/aneeshsunganahalli/Gopher/internal/trigger"
+	"github.com/aneeshsunganahalli/Gopher/internal/worker"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
 func main() {
+	skipChecks := flag.Bool("skip-checks", false, "Start even when the startup self-check finds fatal problems")
+	flag.Parse()
+
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
@@ -34,13 +49,50 @@ func main() {
 		zap.String("address", cfg.Server.Address()),
 	)
 
+	// Sign enqueued jobs and verify dequeued ones when signing keys are configured
+	signer, err := cfg.Signing.Signer()
+	if err != nil {
+		logger.Fatal("Failed to load signing keys", zap.Error(err))
+	}
+	signing := queue.Signing{Signer: signer, AllowUnsigned: cfg.Signing.AllowUnsigned}
+	if signer != nil {
+		logger.Info("Job signing enabled",
+			zap.String("algorithm", signer.Algorithm()),
+			zap.Bool("verify_only", !signer.CanSign()),
+		)
+	}
+
+	if cfg.Queue.Backend == config.QueueBackendMemory {
+		runInMemory(cfg, signing, logger)
+		return
+	}
+	// Every backend but Redis is opened through the backend registry
+	backend.RegisterBuiltins(cfg)
+	if cfg.Queue.Backend != config.QueueBackendRedis {
+		runRegistered(cfg, signing, logger)
+		return
+	}
+
 	// Initialize Redis queue
 	redisConfig := queue.RedisOptions{
-		URL:             cfg.Redis.URL,
-		Password:        cfg.Redis.Password,
-		DB:              cfg.Redis.DB,
-		ConnectTimeout:  cfg.Redis.Timeout,
-		CommandTimeout:  cfg.Redis.Timeout,
+		URL:                   cfg.Redis.URL,
+		Password:              cfg.Redis.Password,
+		DB:                    cfg.Redis.DB,
+		ConnectTimeout:        cfg.Redis.Timeout,
+		CommandTimeout:        cfg.Redis.Timeout,
+		ClusterAddrs:          cfg.Redis.ClusterAddrs,
+		KeyPrefix:             cfg.Redis.KeyPrefix,
+		Compression:           cfg.Redis.Compression,
+		CompressionThreshold:  cfg.Redis.CompressionThreshold,
+		Format:                cfg.Redis.Format,
+		SentinelMaster:        cfg.Redis.SentinelMaster,
+		SentinelAddrs:         cfg.Redis.SentinelAddrs,
+		SentinelPassword:      cfg.Redis.SentinelPassword,
+		TLSCACert:             cfg.Redis.TLSCACert,
+		TLSCert:               cfg.Redis.TLSCert,
+		TLSKey:                cfg.Redis.TLSKey,
+		TLSInsecureSkipVerify: cfg.Redis.TLSInsecureSkipVerify,
+		Signing:               signing,
 	}
 
 	jobQueue, err := queue.NewRedisQueue(redisConfig)
@@ -49,6 +101,19 @@ func main() {
 	}
 	defer jobQueue.Close()
 
+	// Every Redis-backed store shares the queue's key prefix and job format
+	layout := jobQueue.Layout()
+
+	// Enable payload encryption when keys are configured
+	keyring, err := cfg.Encryption.Keyring()
+	if err != nil {
+		logger.Fatal("Failed to load encryption keys", zap.Error(err))
+	}
+	if keyring != nil {
+		jobQueue.SetKeyring(keyring)
+		logger.Info("Payload encryption enabled", zap.String("active_key", keyring.ActiveKeyID()))
+	}
+
 	// Initialize job registry
 	registry := job.NewRegistry(logger)
 
@@ -56,9 +121,243 @@ func main() {
 	if err := registerJobHandlers(registry, logger); err != nil {
 		logger.Fatal("Failed to register job handlers", zap.Error(err))
 	}
+	if err := registerEnqueueHooks(cfg, registry); err != nil {
+		logger.Fatal("Failed to register enqueue hooks", zap.Error(err))
+	}
+
+	// Verify Redis and the handler setup before serving
+	runSelfCheck(cfg, *skipChecks, selfcheck.Options{
+		Client:       jobQueue.Client(),
+		Layout:       layout,
+		Registry:     registry,
+		MaxClockSkew: cfg.SelfCheck.MaxClockSkew,
+		Heartbeats:   queue.NewHeartbeatRegistry(jobQueue.Client(), layout, 3*cfg.Worker.HeartbeatInterval),
+	}, logger)
+
+	redactor, err := cfg.Redaction.Redactor()
+	if err != nil {
+		logger.Fatal("Failed to load redaction rules", zap.Error(err))
+	}
+
+	authProvider, err := cfg.Auth.Provider()
+	if err != nil {
+		logger.Fatal("Failed to configure authentication", zap.Error(err))
+	}
+
+	// Priority mode routes jobs through the high/normal/low priority queues
+	var serverQueue queue.Queue = jobQueue
+	var priorityQueue *queue.PriorityQueue
+	if cfg.Queue.Priority {
+		priorityQueue, err = newPriorityQueue(cfg, redisConfig, keyring)
+		if err != nil {
+			logger.Fatal("Failed to initialize priority queue", zap.Error(err))
+		}
+		defer priorityQueue.Close()
+		serverQueue = priorityQueue
+		logger.Info("Priority queues enabled", zap.String("ratio", priorityQueue.PriorityRatio().String()))
+	}
+
+	// Record job state transitions for lookup by ID
+	var statuses *queue.StatusStore
+	if cfg.Status.Enabled {
+		statuses = queue.NewStatusStore(jobQueue.Client(), layout, cfg.Status.TTL)
+		jobQueue.SetStatusStore(statuses)
+		if priorityQueue != nil {
+			priorityQueue.SetStatusStore(statuses)
+		}
+	}
+
+	// Dead letter queue shares the main queue's Redis connection
+	dlq := queue.NewRedisDLQ(jobQueue.Client(), layout, serverQueue)
+	dlq.SetKeyring(keyring)
+
+	// Producers may target a logical alias that can be switched between queues
+	if cfg.Server.QueueAlias != "" {
+		if priorityQueue != nil {
+			logger.Warn("Queue aliases do not apply to priority queues, ignoring SERVER_QUEUE_ALIAS")
+		} else {
+			serverQueue = queue.NewAliasQueue(jobQueue, cfg.Server.QueueAlias)
+			logger.Info("Enqueuing through queue alias", zap.String("alias", cfg.Server.QueueAlias))
+		}
+	}
+
+	// Ride out brief Redis outages instead of failing API enqueues
+	serverQueue = queue.NewResilientQueue(serverQueue, queue.ResilienceOptions{
+		Attempts:         cfg.Redis.RetryAttempts,
+		Backoff:          cfg.Redis.RetryBackoff,
+		BreakerThreshold: cfg.Redis.BreakerThreshold,
+		BreakerCooldown:  cfg.Redis.BreakerCooldown,
+	}, logger)
+
+	var chaosStore *queue.ChaosStore
+	if cfg.Chaos.Enabled {
+		chaosStore = queue.NewChaosStore(jobQueue.Client(), layout)
+		serverQueue = queue.NewChaosQueue(serverQueue, chaosStore, cfg.Chaos.Settings(), logger)
+		logger.Warn("Chaos mode enabled, queue operations will fail on purpose")
+	}
+
+	// Record a sample of incoming jobs for later replay
+	if cfg.Recording.Enabled {
+		recording := queue.NewRecordingQueue(serverQueue, jobQueue.Client(), layout, queue.RecordingOptions{
+			SampleRate: cfg.Recording.SampleRate,
+			MaxEntries: cfg.Recording.MaxEntries,
+		}, redactor, logger)
+		recording.SetKeyring(keyring)
+		serverQueue = recording
+		logger.Info("Job recording enabled", zap.Float64("sample_rate", cfg.Recording.SampleRate))
+	}
+
+	policies, err := cfg.Policies.Parse()
+	if err != nil {
+		logger.Fatal("Failed to load job policies", zap.Error(err))
+	}
+	// Handlers' advertised defaults apply to types without a configured policy
+	policies = policies.WithDefaults(registry.DefaultPolicies())
+
+	// Policy overrides saved through the admin API replace the configured ones
+	policyStore := queue.NewPolicyStore(jobQueue.Client(), layout, policies)
+	if err := policyStore.Refresh(context.Background()); err != nil {
+		logger.Warn("Failed to load job policy overrides", zap.Error(err))
+	}
 
 	// Initialize HTTP server
-	srv := server.NewServer(cfg, jobQueue, registry, logger)
+	srv := server.NewServer(cfg, serverQueue, registry, logger)
+	srv.SetDeadLetterQueue(dlq)
+	srv.SetRedactor(redactor)
+	srv.SetPolicies(policyStore)
+	if authProvider != nil {
+		srv.SetAuthProvider(authProvider)
+		logger.Info("API authentication enabled", zap.String("providers", authProvider.Name()))
+	}
+	if cfg.Auth.OIDCEnabled() {
+		oidc, sessions, err := newOIDC(cfg)
+		if err != nil {
+			logger.Fatal("Failed to configure OIDC login", zap.Error(err))
+		}
+		srv.SetOIDC(oidc, sessions)
+		logger.Info("OIDC login enabled", zap.String("issuer", cfg.Auth.OIDCIssuerURL))
+	}
+	srv.SetAdmin(queue.NewAdmin(jobQueue.Client(), layout, keyring))
+	readOnly := queue.NewReadOnlyStore(jobQueue.Client(), layout)
+	srv.SetReadOnly(readOnly)
+	rateLimiter := limiter.NewRedisRateLimiter(jobQueue.Client(), cfg.Redis.Key(cfg.RateLimit.Prefix), limiter.Unlimited, 0)
+	rateLimiter.ApplyPolicies(policyStore.Policies())
+	policyStore.OnChange(rateLimiter.ApplyPolicies)
+	srv.SetRateLimiter(rateLimiter)
+	if chaosStore != nil {
+		srv.SetChaos(chaosStore)
+	}
+	if priorityQueue != nil {
+		srv.SetPriorityQueue(priorityQueue)
+	}
+	srv.SetRetryTracker(queue.NewRetryTracker(jobQueue.Client(), layout))
+	srv.SetHeartbeats(queue.NewHeartbeatRegistry(jobQueue.Client(), layout, 3*cfg.Worker.HeartbeatInterval))
+	if cfg.Results.Enabled {
+		srv.SetResultStore(queue.NewResultStore(jobQueue.Client(), layout, cfg.Results.TTL))
+	}
+	if cfg.Status.Enabled {
+		srv.SetStatusTracker(jobQueue)
+	}
+
+	scheduled := queue.NewScheduledQueue(jobQueue.Client(), layout, serverQueue)
+	scheduled.SetKeyring(keyring)
+	srv.SetScheduledQueue(scheduled)
+
+	// Jobs workers quarantined wait here for an admin to approve or reject them
+	quarantine := queue.NewQuarantine(jobQueue.Client(), layout, serverQueue)
+	quarantine.SetKeyring(keyring)
+	quarantine.SetStatusStore(statuses)
+	srv.SetQuarantine(quarantine)
+
+	if cfg.Deps.Enabled {
+		deps := queue.NewDependencyTracker(jobQueue.Client(), layout, serverQueue, cfg.Deps.OutcomeTTL)
+		deps.SetKeyring(keyring)
+		deps.SetStatusStore(statuses)
+		srv.SetDependencyTracker(deps)
+	}
+	if cfg.Workflows.Enabled {
+		workflows := queue.NewWorkflowEngine(jobQueue.Client(), layout, serverQueue, cfg.Workflows.TTL)
+		workflows.SetKeyring(keyring)
+		srv.SetWorkflowEngine(workflows)
+	}
+	if cfg.Idempotency.Enabled {
+		srv.SetIdempotencyStore(queue.NewIdempotencyStore(jobQueue.Client(), layout, cfg.Idempotency.TTL))
+	}
+
+	// Archive and prune job history in the background
+	sweepCtx, stopSweep := context.WithCancel(context.Background())
+	defer stopSweep()
+
+	if cfg.History.Enabled {
+		history := queue.NewHistory(jobQueue.Client(), layout, queue.HistoryOptions{
+			Retention:        cfg.History.Retention,
+			ArchiveRetention: cfg.History.ArchiveRetention,
+		})
+		history.SetKeyring(keyring)
+		history.SetRedactor(redactor)
+		srv.SetHistory(history)
+
+		go runHistorySweep(sweepCtx, history, cfg.History.SweepInterval, logger)
+	}
+
+	if cfg.Server.SchedulerInterval > 0 {
+		go runScheduler(sweepCtx, scheduled, cfg.Server.SchedulerInterval, logger)
+	}
+
+	// Pick up policy overrides saved through other servers
+	go policyStore.Watch(sweepCtx)
+
+	// Buffer enqueues locally while Redis is unreachable
+	if cfg.Spool.Enabled {
+		spool, err := queue.NewSpool(serverQueue, queue.SpoolOptions{
+			Path:          cfg.Spool.Path,
+			MaxJobs:       cfg.Spool.MaxJobs,
+			FlushInterval: cfg.Spool.FlushInterval,
+			Keyring:       keyring,
+		}, logger)
+		if err != nil {
+			logger.Fatal("Failed to open enqueue spool", zap.Error(err))
+		}
+		defer spool.Close()
+		srv.SetSpool(spool)
+
+		go spool.Run(sweepCtx)
+		if subscriber, ok := jobQueue.Client().(queue.ErasureSubscriber); ok {
+			go spool.FollowErasures(sweepCtx, subscriber, layout)
+		}
+	}
+
+	// Enqueue templated jobs for configured Redis channels and webhooks
+	triggers, err := newTriggers(cfg, serverQueue, registry, logger)
+	if err != nil {
+		logger.Fatal("Failed to configure triggers", zap.Error(err))
+	}
+	if triggers != nil {
+		triggers.SetReadOnly(readOnly)
+		// One server at a time subscribes, so each message enqueues one job
+		triggers.SetLeaderLease(jobQueue.Client(), layout, uuid.NewString())
+		srv.SetTriggers(triggers)
+		if subscriber, ok := jobQueue.Client().(trigger.Subscriber); ok {
+			go func() {
+				if err := triggers.Run(sweepCtx, subscriber); err != nil {
+					logger.Error("Trigger subscriber stopped", zap.Error(err))
+				}
+			}()
+		}
+	}
+
+	// Templates saved through the API are shared by every server through Redis
+	templateDefs, err := cfg.Templates.Parse()
+	if err != nil {
+		logger.Fatal("Failed to configure job templates", zap.Error(err))
+	}
+	srv.SetTemplates(queue.NewTemplateStore(jobQueue.Client(), layout, templateDefs))
+
+	// Serve Prometheus metrics on a dedicated listener when configured
+	serverMetrics := startMetrics(cfg, logger)
+	if serverMetrics != nil {
+		srv.SetMetrics(serverMetrics)
+	}
 
 	// Start server in goroutine
 	go func() {
@@ -67,12 +366,17 @@ func main() {
 		}
 	}()
 
+	// Report readiness and liveness to systemd when running under it
+	notifySystemd(systemd.Ready, logger)
+	go systemd.RunWatchdog(sweepCtx, jobQueue.Health, logger)
+
 	// Wait for interrupt signal to gracefully shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	logger.Info("Shutting down server...")
+	notifySystemd(systemd.Stopping, logger)
 
 	// Create shutdown context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -83,9 +387,405 @@ func main() {
 		logger.Error("Failed to shutdown server gracefully", zap.Error(err))
 	}
 
+	stopMetrics(ctx, serverMetrics, logger)
+
 	logger.Info("Server shutdown complete")
 }
 
+// startMetrics serves Prometheus metrics on METRICS_ADDRESS, or returns nil
+// when no address is configured
+func startMetrics(cfg *config.Config, logger *zap.Logger) *metrics.Metrics {
+	if cfg.Metrics.Address == "" {
+		return nil
+	}
+
+	m := metrics.NewMetrics(logger)
+	go func() {
+		if err := m.StartServer(cfg.Metrics.Address); err != nil {
+			logger.Error("Metrics server failed", zap.Error(err))
+		}
+	}()
+	return m
+}
+
+// stopMetrics shuts down the metrics listener started by startMetrics
+func stopMetrics(ctx context.Context, m *metrics.Metrics, logger *zap.Logger) {
+	if m == nil {
+		return
+	}
+	if err := m.StopServer(ctx); err != nil {
+		logger.Error("Failed to shutdown metrics server", zap.Error(err))
+	}
+}
+
+// runSelfCheck logs the startup self-check report and exits on fatal
+// problems unless checks are skipped
+func runSelfCheck(cfg *config.Config, skip bool, opts selfcheck.Options, logger *zap.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	report := selfcheck.Run(ctx, opts)
+	report.Log(logger)
+	if !report.Fatal() {
+		return
+	}
+
+	if skip || cfg.SelfCheck.Skip {
+		logger.Warn("Startup self-check failed, starting anyway because checks are skipped")
+		return
+	}
+	logger.Fatal("Startup self-check failed; fix the problems above or start with --skip-checks")
+}
+
+// runInMemory serves the API on an in-process queue with embedded workers,
+// for developing handlers without Redis. Redis-backed features such as the
+// DLQ, schedules, history and results are disabled.
+func runInMemory(cfg *config.Config, signing queue.Signing, logger *zap.Logger) {
+	logger.Warn("Using the in-memory queue backend, jobs are lost on exit")
+
+	registry := job.NewRegistry(logger)
+	if err := registerJobHandlers(registry, logger); err != nil {
+		logger.Fatal("Failed to register job handlers", zap.Error(err))
+	}
+	if err := registerEnqueueHooks(cfg, registry); err != nil {
+		logger.Fatal("Failed to register enqueue hooks", zap.Error(err))
+	}
+
+	redactor, err := cfg.Redaction.Redactor()
+	if err != nil {
+		logger.Fatal("Failed to load redaction rules", zap.Error(err))
+	}
+
+	memoryQueue := queue.NewMemoryQueue(cfg.Worker.PollTimeout)
+	memoryQueue.SetSigning(signing)
+	defer memoryQueue.Close()
+
+	// The embedded workers and the API share one metrics listener
+	serverMetrics := startMetrics(cfg, logger)
+
+	pool := worker.NewPool(worker.PoolConfig{
+		Concurrency:       cfg.Worker.Concurrency,
+		ShutdownTimeout:   cfg.Worker.ShutdownTimeout,
+		PollInterval:      cfg.Worker.PollInterval,
+		PollTimeout:       cfg.Worker.PollTimeout,
+		DequeueTimeout:    cfg.Worker.DequeueTimeout,
+		JobTimeout:        cfg.Worker.JobTimeout,
+		HeartbeatInterval: cfg.Worker.HeartbeatInterval,
+	}, memoryQueue, registry, logger)
+	pool.SetRedactor(redactor)
+	jobTimeouts, err := cfg.Worker.JobTimeouts()
+	if err != nil {
+		logger.Fatal("Failed to load job timeouts", zap.Error(err))
+	}
+	pool.SetJobTimeouts(jobTimeouts)
+	policies, err := cfg.Policies.Parse()
+	if err != nil {
+		logger.Fatal("Failed to load job policies", zap.Error(err))
+	}
+	// Handlers' advertised defaults apply to types without a configured policy
+	policies = policies.WithDefaults(registry.DefaultPolicies())
+	pool.SetPolicies(policies)
+	pool.SetSigning(signing)
+	if serverMetrics != nil {
+		pool.SetMetrics(serverMetrics)
+	}
+	if err := pool.Start(); err != nil {
+		logger.Fatal("Failed to start worker pool", zap.Error(err))
+	}
+
+	authProvider, err := cfg.Auth.Provider()
+	if err != nil {
+		logger.Fatal("Failed to configure authentication", zap.Error(err))
+	}
+
+	srv := server.NewServer(cfg, memoryQueue, registry, logger)
+	srv.SetRedactor(redactor)
+	if serverMetrics != nil {
+		srv.SetMetrics(serverMetrics)
+	}
+	srv.SetPolicies(queue.NewPolicyStore(nil, nil, policies))
+	if authProvider != nil {
+		srv.SetAuthProvider(authProvider)
+	}
+	triggers, err := newTriggers(cfg, memoryQueue, registry, logger)
+	if err != nil {
+		logger.Fatal("Failed to configure triggers", zap.Error(err))
+	}
+	if triggers != nil {
+		srv.SetTriggers(triggers)
+	}
+	templates, err := newTemplates(cfg)
+	if err != nil {
+		logger.Fatal("Failed to configure job templates", zap.Error(err))
+	}
+	if templates != nil {
+		srv.SetTemplates(templates)
+	}
+
+	go func() {
+		if err := srv.Start(); err != nil {
+			logger.Fatal("Failed to start HTTP server", zap.Error(err))
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logger.Info("Shutting down server...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := srv.Stop(ctx); err != nil {
+		logger.Error("Failed to shutdown server gracefully", zap.Error(err))
+	}
+	if err := pool.Stop(); err != nil {
+		logger.Error("Failed to shutdown worker pool gracefully", zap.Error(err))
+	}
+	stopMetrics(ctx, serverMetrics, logger)
+
+	logger.Info("Server shutdown complete")
+}
+
+// runRegistered serves the API on a backend opened through queue.RegisterBackend
+func runRegistered(cfg *config.Config, signing queue.Signing, logger *zap.Logger) {
+	keyring, err := cfg.Encryption.Keyring()
+	if err != nil {
+		logger.Fatal("Failed to load encryption keys", zap.Error(err))
+	}
+
+	ctx, cancelOpen := context.WithTimeout(context.Background(), cfg.Redis.Timeout)
+	jobQueue, dlq, err := queue.OpenBackend(ctx, cfg.Queue.Backend, queue.BackendOptions{
+		PollTimeout: cfg.Worker.PollTimeout,
+		Keyring:     keyring,
+		Signing:     signing,
+	})
+	cancelOpen()
+	if err != nil {
+		logger.Fatal("Failed to initialize queue", zap.String("backend", cfg.Queue.Backend), zap.Error(err))
+	}
+
+	runStandalone(cfg, jobQueue, dlq, logger)
+}
+
+// runStandalone serves the API on a backend other than Redis. Workers run
+// separately; Redis-backed features such as admin, history and results are
+// disabled, and recurring schedules run only on backends that keep them.
+func runStandalone(cfg *config.Config, jobQueue queue.Queue, dlq queue.DeadLetterQueue, logger *zap.Logger) {
+	defer jobQueue.Close()
+
+	registry := job.NewRegistry(logger)
+	if err := registerJobHandlers(registry, logger); err != nil {
+		logger.Fatal("Failed to register job handlers", zap.Error(err))
+	}
+	if err := registerEnqueueHooks(cfg, registry); err != nil {
+		logger.Fatal("Failed to register enqueue hooks", zap.Error(err))
+	}
+
+	redactor, err := cfg.Redaction.Redactor()
+	if err != nil {
+		logger.Fatal("Failed to load redaction rules", zap.Error(err))
+	}
+
+	authProvider, err := cfg.Auth.Provider()
+	if err != nil {
+		logger.Fatal("Failed to configure authentication", zap.Error(err))
+	}
+
+	policies, err := cfg.Policies.Parse()
+	if err != nil {
+		logger.Fatal("Failed to load job policies", zap.Error(err))
+	}
+	// Handlers' advertised defaults apply to types without a configured policy
+	policies = policies.WithDefaults(registry.DefaultPolicies())
+
+	srv := server.NewServer(cfg, jobQueue, registry, logger)
+	if dlq != nil {
+		srv.SetDeadLetterQueue(dlq)
+	}
+	serverMetrics := startMetrics(cfg, logger)
+	if serverMetrics != nil {
+		srv.SetMetrics(serverMetrics)
+	}
+	srv.SetRedactor(redactor)
+	srv.SetPolicies(queue.NewPolicyStore(nil, nil, policies))
+	if authProvider != nil {
+		srv.SetAuthProvider(authProvider)
+	}
+	if cfg.Auth.OIDCEnabled() {
+		oidc, sessions, err := newOIDC(cfg)
+		if err != nil {
+			logger.Fatal("Failed to configure OIDC login", zap.Error(err))
+		}
+		srv.SetOIDC(oidc, sessions)
+	}
+
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	if backend, ok := jobQueue.(queue.ScheduleBackend); ok {
+		scheduled := backend.Schedules()
+		srv.SetScheduledQueue(scheduled)
+		if cfg.Server.SchedulerInterval > 0 {
+			go runScheduler(schedulerCtx, scheduled, cfg.Server.SchedulerInterval, logger)
+		}
+	}
+
+	triggers, err := newTriggers(cfg, jobQueue, registry, logger)
+	if err != nil {
+		logger.Fatal("Failed to configure triggers", zap.Error(err))
+	}
+	if triggers != nil {
+		srv.SetTriggers(triggers)
+	}
+	templates, err := newTemplates(cfg)
+	if err != nil {
+		logger.Fatal("Failed to configure job templates", zap.Error(err))
+	}
+	if templates != nil {
+		srv.SetTemplates(templates)
+	}
+
+	go func() {
+		if err := srv.Start(); err != nil {
+			logger.Fatal("Failed to start HTTP server", zap.Error(err))
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logger.Info("Shutting down server...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := srv.Stop(ctx); err != nil {
+		logger.Error("Failed to shutdown server gracefully", zap.Error(err))
+	}
+	stopMetrics(ctx, serverMetrics, logger)
+
+	logger.Info("Server shutdown complete")
+}
+
+// notifySystemd sends an sd_notify state, logging failures
+func notifySystemd(state string, logger *zap.Logger) {
+	if _, err := systemd.Notify(state); err != nil {
+		logger.Warn("Failed to notify systemd", zap.String("state", state), zap.Error(err))
+	}
+}
+
+// newPriorityQueue connects the high/normal/low priority queues with the configured ratio
+func newPriorityQueue(cfg *config.Config, opts queue.RedisOptions, keyring *encryption.Keyring) (*queue.PriorityQueue, error) {
+	priorityQueue, err := queue.NewPriorityQueue(opts)
+	if err != nil {
+		return nil, err
+	}
+	priorityQueue.SetKeyring(keyring)
+
+	ratio, err := cfg.Queue.Ratio()
+	if err != nil {
+		priorityQueue.Close()
+		return nil, err
+	}
+	if err := priorityQueue.SetPriorityRatio(ratio.High, ratio.Normal, ratio.Low); err != nil {
+		priorityQueue.Close()
+		return nil, err
+	}
+	return priorityQueue, nil
+}
+
+// newTriggers builds the trigger manager, or returns nil when no triggers are configured
+func newTriggers(cfg *config.Config, q queue.Queue, registry *job.Registry, logger *zap.Logger) (*trigger.Manager, error) {
+	defs, err := cfg.Triggers.Parse()
+	if err != nil || len(defs) == 0 {
+		return nil, err
+	}
+
+	triggers, err := trigger.NewManager(defs, q, registry, cfg.Worker.MaxRetries, logger)
+	if err != nil {
+		return nil, err
+	}
+	triggers.SetWebhookTolerance(cfg.Triggers.WebhookTolerance)
+	logger.Info("Job triggers enabled", zap.Strings("triggers", triggers.Names()))
+	return triggers, nil
+}
+
+// newTemplates serves the configured job templates read-only, or returns nil
+// when none are configured
+func newTemplates(cfg *config.Config) (*queue.TemplateStore, error) {
+	defs, err := cfg.Templates.Parse()
+	if err != nil || len(defs) == 0 {
+		return nil, err
+	}
+	return queue.NewTemplateStore(nil, nil, defs), nil
+}
+
+// newOIDC discovers the OpenID provider and builds the session manager
+func newOIDC(cfg *config.Config) (*auth.OIDC, *auth.SessionManager, error) {
+	sessions, err := cfg.Auth.Sessions()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	oidc, err := cfg.Auth.OIDC(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return oidc, sessions, nil
+}
+
+// runScheduler periodically moves due scheduled jobs onto the queue
+func runScheduler(ctx context.Context, scheduled queue.Schedules, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := scheduled.ProcessDueJobs(ctx)
+			if err != nil {
+				logger.Warn("Failed to process due scheduled jobs", zap.Error(err))
+				continue
+			}
+			if count > 0 {
+				logger.Debug("Enqueued due scheduled jobs", zap.Int("count", count))
+			}
+		}
+	}
+}
+
+// runHistorySweep periodically archives expired history and prunes the archive
+func runHistorySweep(ctx context.Context, history *queue.History, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			archived, pruned, err := history.Sweep(ctx)
+			if err != nil {
+				logger.Warn("Job history sweep failed", zap.Error(err))
+				continue
+			}
+			if archived > 0 || pruned > 0 {
+				logger.Info("Job history swept",
+					zap.Int("archived", archived),
+					zap.Int("pruned", pruned),
+				)
+			}
+		}
+	}
+}
+
 // initLogger initializes the logger based on configuration
 func initLogger(cfg config.LogConfig) (*zap.Logger, error) {
 	var zapConfig zap.Config
@@ -113,6 +813,16 @@ func initLogger(cfg config.LogConfig) (*zap.Logger, error) {
 	return zapConfig.Build()
 }
 
+// registerEnqueueHooks registers the configured payload transforms; hooks
+// written in Go belong here too, via registry.RegisterEnqueueHook
+func registerEnqueueHooks(cfg *config.Config, registry *job.Registry) error {
+	transforms, err := cfg.Transforms.Parse()
+	if err != nil {
+		return err
+	}
+	return registry.RegisterTransforms(transforms)
+}
+
 // registerJobHandlers registers all available job handlers
 func registerJobHandlers( registry *job.Registry, logger *zap.Logger) error {
 
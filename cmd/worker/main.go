@@ -1,21 +1,43 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/aneeshsunganahalli/Gopher/examples/handlers"
+	"github.com/aneeshsunganahalli/Gopher/internal/backend"
 	"github.com/aneeshsunganahalli/Gopher/internal/config"
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
 	"github.com/aneeshsunganahalli/Gopher/internal/job"
+	_ "github.com/aneeshsunganahalli/Gopher/internal/kafka" // Client for the kafka queue backend and sink
+	"github.com/aneeshsunganahalli/Gopher/internal/limiter"
+	"github.com/aneeshsunganahalli/Gopher/internal/metrics"
 	"github.com/aneeshsunganahalli/Gopher/internal/queue"
+	"github.com/aneeshsunganahalli/Gopher/internal/selfcheck"
+	"github.com/aneeshsunganahalli/Gopher/internal/sink"
+	"github.com/aneeshsunganahalli/Gopher/internal/systemd"
 	"github.com/aneeshsunganahalli/Gopher/internal/worker"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
 	"go.uber.org/zap"
 )
 
+// Exit codes let deploy tooling tell a clean drain from one that requeued jobs
+const (
+	exitCleanDrain   = 0
+	exitDrainTimeout = 3
+)
 
 func main() {
+	skipChecks := flag.Bool("skip-checks", false, "Start even when the startup self-check finds fatal problems")
+	flag.Parse()
+
 cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
@@ -32,13 +54,54 @@ cfg, err := config.Load()
 		zap.Int("concurrency", cfg.Worker.Concurrency),
 	)
 
+	// An in-memory queue is private to one process, so the server runs the workers itself
+	if cfg.Queue.Backend == config.QueueBackendMemory {
+		logger.Fatal("QUEUE_BACKEND=memory runs workers inside the server process; start the server instead")
+	}
+
+	// Sign enqueued jobs and verify dequeued ones when signing keys are configured
+	signer, err := cfg.Signing.Signer()
+	if err != nil {
+		logger.Fatal("Failed to load signing keys", zap.Error(err))
+	}
+	signing := queue.Signing{Signer: signer, AllowUnsigned: cfg.Signing.AllowUnsigned}
+	if signer != nil {
+		logger.Info("Job signing enabled",
+			zap.String("algorithm", signer.Algorithm()),
+			zap.Bool("verify_only", !signer.CanSign()),
+		)
+	}
+
+	// Every backend but Redis is opened through the backend registry
+	backend.RegisterBuiltins(cfg)
+	if cfg.Queue.Backend != config.QueueBackendRedis {
+		runRegistered(cfg, signing, logger)
+		return
+	}
+
 	// Initialize Redis queue
 	redisConfig := queue.RedisOptions{
-		URL:             cfg.Redis.URL,
-		Password:        cfg.Redis.Password,
-		DB:              cfg.Redis.DB,
-		ConnectTimeout:  cfg.Redis.Timeout,
-		CommandTimeout:  cfg.Redis.Timeout,
+		URL:                   cfg.Redis.URL,
+		Password:              cfg.Redis.Password,
+		DB:                    cfg.Redis.DB,
+		ConnectTimeout:        cfg.Redis.Timeout,
+		CommandTimeout:        cfg.Redis.Timeout,
+		ClusterAddrs:          cfg.Redis.ClusterAddrs,
+		KeyPrefix:             cfg.Redis.KeyPrefix,
+		Compression:           cfg.Redis.Compression,
+		CompressionThreshold:  cfg.Redis.CompressionThreshold,
+		Format:                cfg.Redis.Format,
+		SentinelMaster:        cfg.Redis.SentinelMaster,
+		SentinelAddrs:         cfg.Redis.SentinelAddrs,
+		SentinelPassword:      cfg.Redis.SentinelPassword,
+		TLSCACert:             cfg.Redis.TLSCACert,
+		TLSCert:               cfg.Redis.TLSCert,
+		TLSKey:                cfg.Redis.TLSKey,
+		TLSInsecureSkipVerify: cfg.Redis.TLSInsecureSkipVerify,
+		QueueName:             cfg.Worker.Queue,
+		Queues:                cfg.Worker.Queues,
+		PollTimeout:           cfg.Worker.PollTimeout,
+		Signing:               signing,
 	}
 
 	jobQueue, err := queue.NewRedisQueue(redisConfig)
@@ -47,41 +110,576 @@ cfg, err := config.Load()
 	}
 	defer jobQueue.Close()
 
-	// Initialize job registry
+	// Every Redis-backed store shares the queue's key prefix and job format
+	layout := jobQueue.Layout()
+
+	// Enable payload encryption when keys are configured
+	keyring, err := cfg.Encryption.Keyring()
+	if err != nil {
+		logger.Fatal("Failed to load encryption keys", zap.Error(err))
+	}
+	if keyring != nil {
+		jobQueue.SetKeyring(keyring)
+		logger.Info("Payload encryption enabled", zap.String("active_key", keyring.ActiveKeyID()))
+	}
+
+	// Initialize job registry; idempotent handlers share results through Redis
 	registry := job.NewRegistry(logger)
+	registry.SetResultCache(queue.NewResultCache(jobQueue.Client(), layout))
 
 	// Register job handlers
 	if err := registerJobHandlers(registry, logger); err != nil {
 		logger.Fatal("Failed to register job handlers", zap.Error(err))
 	}
 
+	// Verify Redis and the handler setup before taking jobs
+	queueKeys := []string{layout.QueueKey(cfg.Worker.Queue)}
+	for _, name := range cfg.Worker.Queues {
+		queueKeys = append(queueKeys, layout.QueueKey(name))
+	}
+	runSelfCheck(cfg, *skipChecks, selfcheck.Options{
+		Client:       jobQueue.Client(),
+		Layout:       layout,
+		Registry:     registry,
+		MaxClockSkew: cfg.SelfCheck.MaxClockSkew,
+		Worker:       true,
+		QueueKeys:    queueKeys,
+	}, logger)
+
 // Initialize worker pool
 	poolConfig := worker.PoolConfig{
-		Concurrency:     cfg.Worker.Concurrency,
-		ShutdownTimeout: cfg.Worker.ShutdownTimeout,
-		PollInterval:    cfg.Worker.PollInterval,
+		Concurrency:       cfg.Worker.Concurrency,
+		ShutdownTimeout:   cfg.Worker.ShutdownTimeout,
+		PollInterval:      cfg.Worker.PollInterval,
+		PollTimeout:       cfg.Worker.PollTimeout,
+		DequeueTimeout:    cfg.Worker.DequeueTimeout,
+		JobTimeout:        cfg.Worker.JobTimeout,
+		HeartbeatInterval: cfg.Worker.HeartbeatInterval,
 	}	
 
-	pool := worker.NewPool(poolConfig, jobQueue, registry, logger)
+	redactor, err := cfg.Redaction.Redactor()
+	if err != nil {
+		logger.Fatal("Failed to load redaction rules", zap.Error(err))
+	}
+
+	// Priority mode dequeues from the high/normal/low queues by ratio
+	var workerQueue queue.Queue = jobQueue
+	var priorityQueue *queue.PriorityQueue
+	if cfg.Queue.Priority {
+		priorityQueue, err = newPriorityQueue(cfg, redisConfig, keyring)
+		if err != nil {
+			logger.Fatal("Failed to initialize priority queue", zap.Error(err))
+		}
+		defer priorityQueue.Close()
+		workerQueue = priorityQueue
+		logger.Info("Priority queues enabled", zap.String("ratio", priorityQueue.PriorityRatio().String()))
+	}
+	baseQueue := workerQueue
+
+	// Chaos mode injects faults into the queue layer for resilience testing
+	if cfg.Chaos.Enabled {
+		workerQueue = queue.NewChaosQueue(workerQueue, queue.NewChaosStore(jobQueue.Client(), layout), cfg.Chaos.Settings(), logger)
+		logger.Warn("Chaos mode enabled, queue operations will fail on purpose")
+	}
+
+	pool := worker.NewPool(poolConfig, workerQueue, registry, logger)
+	pool.SetRedactor(redactor)
+	maxAges, err := cfg.Worker.MaxAges()
+	if err != nil {
+		logger.Fatal("Failed to load max job ages", zap.Error(err))
+	}
+	pool.SetMaxAges(maxAges)
+	jobTimeouts, err := cfg.Worker.JobTimeouts()
+	if err != nil {
+		logger.Fatal("Failed to load job timeouts", zap.Error(err))
+	}
+	pool.SetJobTimeouts(jobTimeouts)
+	pool.SetParkingQueue(cfg.Worker.ParkingQueue)
+	pool.SetSigning(signing)
+	policies, err := cfg.Policies.Parse()
+	if err != nil {
+		logger.Fatal("Failed to load job policies", zap.Error(err))
+	}
+	// Handlers' advertised defaults apply to types without a configured policy
+	policies = policies.WithDefaults(registry.DefaultPolicies())
+	// Policy overrides saved through the admin API replace the configured ones
+	policyStore := queue.NewPolicyStore(jobQueue.Client(), layout, policies)
+	if err := policyStore.Refresh(context.Background()); err != nil {
+		logger.Warn("Failed to load job policy overrides", zap.Error(err))
+	}
+	pool.SetPolicies(policyStore.Policies())
+	pool.SetRetryTracker(queue.NewRetryTracker(jobQueue.Client(), layout))
+	if cfg.Results.Enabled {
+		pool.SetResultStore(queue.NewResultStore(jobQueue.Client(), layout, cfg.Results.TTL))
+	}
+	// Record job state transitions for lookup by ID
+	var statuses *queue.StatusStore
+	if cfg.Status.Enabled {
+		statuses = queue.NewStatusStore(jobQueue.Client(), layout, cfg.Status.TTL)
+		jobQueue.SetStatusStore(statuses)
+		if priorityQueue != nil {
+			priorityQueue.SetStatusStore(statuses)
+		}
+		pool.SetStatusStore(statuses)
+	}
+	rateLimiter := limiter.NewRedisRateLimiter(jobQueue.Client(), cfg.Redis.Key(cfg.RateLimit.Prefix), limiter.Unlimited, 0)
+	rateLimiter.ApplyPolicies(policyStore.Policies())
+	pool.SetRateLimiter(rateLimiter)
+	policyStore.OnChange(func(policies types.JobPolicies) {
+		pool.SetPolicies(policies)
+		rateLimiter.ApplyPolicies(policies)
+		logger.Info("Job policies changed", zap.Int("policies", len(policies)))
+	})
+	heartbeats := queue.NewHeartbeatRegistry(jobQueue.Client(), layout, 3*cfg.Worker.HeartbeatInterval)
+	pool.SetHeartbeats(heartbeats)
+	sloTargets, err := metrics.ParseSLOTargets(cfg.Metrics.SLOTargets)
+	if err != nil {
+		logger.Fatal("Failed to load SLO targets", zap.Error(err))
+	}
+	workerMetrics := metrics.NewMetrics(logger)
+	workerMetrics.SetSLOTargets(sloTargets)
+	pool.SetMetrics(workerMetrics)
+	rateLimiter.SetObserver(workerMetrics.ObserveRateLimit)
+	if priorityQueue != nil {
+		ratio := priorityQueue.PriorityRatio()
+		workerMetrics.SetPriorityRatio(ratio.High, ratio.Normal, ratio.Low)
+		priorityQueue.OnRatioChange(func(ratio queue.PriorityRatio) {
+			workerMetrics.SetPriorityRatio(ratio.High, ratio.Normal, ratio.Low)
+			logger.Info("Priority ratio changed", zap.String("ratio", ratio.String()))
+		})
+	}
+
+	if cfg.Metrics.Address != "" {
+		go func() {
+			if err := workerMetrics.StartServer(cfg.Metrics.Address); err != nil {
+				logger.Error("Metrics server failed", zap.Error(err))
+			}
+		}()
+	}
+
+	// Permanently failed jobs go to the dead letter queue
+	dlq := queue.NewRedisDLQ(jobQueue.Client(), layout, baseQueue)
+	dlq.SetKeyring(keyring)
+	pool.SetDeadLetterQueue(dlq)
+
+	// Suspicious jobs wait for review in the quarantine instead
+	if cfg.Worker.Quarantine {
+		quarantine := queue.NewQuarantine(jobQueue.Client(), layout, baseQueue)
+		quarantine.SetKeyring(keyring)
+		quarantine.SetStatusStore(statuses)
+		pool.SetQuarantine(quarantine)
+		logger.Info("Quarantine enabled")
+	}
+
+	// Record how jobs spawned by recurring schedules finished
+	pool.SetScheduledQueue(queue.NewScheduledQueue(jobQueue.Client(), layout, baseQueue))
+
+	// Enqueue jobs waiting on the ones this worker finishes
+	if cfg.Deps.Enabled {
+		deps := queue.NewDependencyTracker(jobQueue.Client(), layout, baseQueue, cfg.Deps.OutcomeTTL)
+		deps.SetKeyring(keyring)
+		pool.SetDependencyTracker(deps)
+	}
+
+	// Release the next nodes of workflows this worker advances
+	if cfg.Workflows.Enabled {
+		workflows := queue.NewWorkflowEngine(jobQueue.Client(), layout, baseQueue, cfg.Workflows.TTL)
+		workflows.SetKeyring(keyring)
+		pool.SetWorkflowEngine(workflows)
+	}
+
+	if cfg.History.Enabled {
+		history := queue.NewHistory(jobQueue.Client(), layout, queue.HistoryOptions{
+			Retention:        cfg.History.Retention,
+			ArchiveRetention: cfg.History.ArchiveRetention,
+		})
+		history.SetKeyring(keyring)
+		history.SetRedactor(redactor)
+		pool.SetHistory(history)
+	}
+
+	// Completed and permanently failed jobs are forwarded to the configured sinks
+	events := newEventSinks(cfg, jobQueue.Client(), logger)
+	if events != nil {
+		events.SetMetrics(workerMetrics)
+		pool.SetEventSink(events)
+	}
+
+	// Spikes in enqueue rate, failure rate or latency are logged and sent to
+	// the sinks. One worker at a time runs the detector, so a spike alerts once.
+	if cfg.Anomaly.Enabled {
+		pool.SetAnomalyDetector(cfg.Anomaly.Detector(), cfg.Anomaly.Interval)
+		pool.SetAnomalyLease(queue.NewLeaderLease(jobQueue.Client(), layout, "anomaly", pool.ID(), 3*cfg.Anomaly.Interval))
+		logger.Info("Anomaly detection enabled", zap.Duration("interval", cfg.Anomaly.Interval))
+	}
 
 	// Start worker pool
 	if err := pool.Start(); err != nil {
 		logger.Fatal("Failed to start worker pool", zap.Error(err))
 	}
 
+	// Jobs dequeued by workers that died without acking go back to the queue
+	recoveryCtx, stopRecovery := context.WithCancel(context.Background())
+	defer stopRecovery()
+	go runRecovery(recoveryCtx, jobQueue, heartbeats, 3*cfg.Worker.HeartbeatInterval, logger)
+
+	// Apply policy overrides saved through the admin API while running
+	go policyStore.Watch(recoveryCtx)
+
+	// Expose health, readiness, metrics and stats for orchestrators
+	var healthServer *worker.HealthServer
+	if cfg.Worker.HealthAddress != "" {
+		healthServer = worker.NewHealthServer(cfg.Worker.HealthAddress, pool, jobQueue, logger)
+		go func() {
+			if err := healthServer.Start(); err != nil {
+				logger.Error("Worker health server failed", zap.Error(err))
+			}
+		}()
+	}
+
+	// Report readiness and liveness to systemd when running under it
+	notifySystemd(systemd.Ready, logger)
+	watchdogCtx, stopWatchdog := context.WithCancel(context.Background())
+	defer stopWatchdog()
+	go systemd.RunWatchdog(watchdogCtx, func(ctx context.Context) error {
+		if !pool.IsRunning() {
+			return fmt.Errorf("worker pool is not running")
+		}
+		return jobQueue.Health(ctx)
+	}, logger)
+
+	// SIGUSR1 dumps pool state, SIGUSR2 toggles dequeuing
+	debugSignals := make(chan os.Signal, 1)
+	signal.Notify(debugSignals, syscall.SIGUSR1, syscall.SIGUSR2)
+	go handleDebugSignals(debugSignals, pool, logger)
+
 	// Wait for interrupt signal to gracefully shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
+	signal.Stop(debugSignals)
 
 	logger.Info("Shutting down worker pool...")
+	notifySystemd(systemd.Stopping, logger)
+	stopWatchdog()
+	stopRecovery()
+
+	// Stop dequeuing and let in-flight jobs finish; stragglers are requeued
+	exitCode := exitCleanDrain
+	report, err := pool.Drain()
+	if err != nil {
+		logger.Error("Failed to shutdown worker pool gracefully",
+			zap.Int("requeued", report.Requeued),
+			zap.Error(err),
+		)
+		exitCode = exitDrainTimeout
+	}
+
+	// Deliver events of the drained jobs
+	if events != nil {
+		events.Close()
+	}
 
-	// Stop worker pool gracefully
-	if err := pool.Stop(); err != nil {
-		logger.Error("Failed to shutdown worker pool gracefully", zap.Error(err))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if healthServer != nil {
+		if err := healthServer.Stop(ctx); err != nil {
+			logger.Error("Failed to shutdown worker health server", zap.Error(err))
+		}
+	}
+
+	if err := workerMetrics.StopServer(ctx); err != nil {
+		logger.Error("Failed to shutdown metrics server", zap.Error(err))
+	}
+
+	logger.Info("Worker pool shutdown complete", zap.Int("exit_code", exitCode))
+
+	if exitCode != exitCleanDrain {
+		// os.Exit skips deferred calls
+		jobQueue.Close()
+		logger.Sync()
+		os.Exit(exitCode)
+	}
+}
+
+// runSelfCheck logs the startup self-check report and exits on fatal
+// problems unless checks are skipped
+func runSelfCheck(cfg *config.Config, skip bool, opts selfcheck.Options, logger *zap.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	report := selfcheck.Run(ctx, opts)
+	report.Log(logger)
+	if !report.Fatal() {
+		return
+	}
+
+	if skip || cfg.SelfCheck.Skip {
+		logger.Warn("Startup self-check failed, starting anyway because checks are skipped")
+		return
+	}
+	logger.Fatal("Startup self-check failed; fix the problems above or start with --skip-checks")
+}
+
+// runRecovery periodically requeues jobs left in the processing lists of
+// worker pools whose heartbeat expired
+func runRecovery(ctx context.Context, jobQueue *queue.RedisQueue, heartbeats *queue.HeartbeatRegistry, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			recoverCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			recovered, err := jobQueue.Recover(recoverCtx, heartbeats)
+			cancel()
+			if err != nil {
+				logger.Warn("Failed to recover orphaned jobs", zap.Error(err))
+				continue
+			}
+			if recovered > 0 {
+				logger.Warn("Requeued jobs of dead workers", zap.Int("recovered", recovered))
+			}
+		}
+	}
+}
+
+// runRegistered processes jobs from a backend opened through queue.RegisterBackend
+func runRegistered(cfg *config.Config, signing queue.Signing, logger *zap.Logger) {
+	keyring, err := cfg.Encryption.Keyring()
+	if err != nil {
+		logger.Fatal("Failed to load encryption keys", zap.Error(err))
 	}
 
-	logger.Info("Worker pool shutdown complete")
+	ctx, cancelOpen := context.WithTimeout(context.Background(), cfg.Redis.Timeout)
+	jobQueue, dlq, err := queue.OpenBackend(ctx, cfg.Queue.Backend, queue.BackendOptions{
+		QueueName:   cfg.Worker.Queue,
+		PollTimeout: cfg.Worker.PollTimeout,
+		Keyring:     keyring,
+		Signing:     signing,
+	})
+	cancelOpen()
+	if err != nil {
+		logger.Fatal("Failed to initialize queue", zap.String("backend", cfg.Queue.Backend), zap.Error(err))
+	}
+
+	runStandalone(cfg, jobQueue, dlq, signing, logger)
+}
+
+// runStandalone processes jobs from a backend other than Redis. Redis-backed
+// features such as rate limits, heartbeats, history and results are disabled.
+func runStandalone(cfg *config.Config, jobQueue queue.Queue, dlq queue.DeadLetterQueue, signing queue.Signing, logger *zap.Logger) {
+	defer jobQueue.Close()
+
+	registry := job.NewRegistry(logger)
+	if err := registerJobHandlers(registry, logger); err != nil {
+		logger.Fatal("Failed to register job handlers", zap.Error(err))
+	}
+
+	redactor, err := cfg.Redaction.Redactor()
+	if err != nil {
+		logger.Fatal("Failed to load redaction rules", zap.Error(err))
+	}
+	maxAges, err := cfg.Worker.MaxAges()
+	if err != nil {
+		logger.Fatal("Failed to load max job ages", zap.Error(err))
+	}
+	jobTimeouts, err := cfg.Worker.JobTimeouts()
+	if err != nil {
+		logger.Fatal("Failed to load job timeouts", zap.Error(err))
+	}
+	policies, err := cfg.Policies.Parse()
+	if err != nil {
+		logger.Fatal("Failed to load job policies", zap.Error(err))
+	}
+	// Handlers' advertised defaults apply to types without a configured policy
+	policies = policies.WithDefaults(registry.DefaultPolicies())
+	sloTargets, err := metrics.ParseSLOTargets(cfg.Metrics.SLOTargets)
+	if err != nil {
+		logger.Fatal("Failed to load SLO targets", zap.Error(err))
+	}
+
+	pool := worker.NewPool(worker.PoolConfig{
+		Concurrency:       cfg.Worker.Concurrency,
+		ShutdownTimeout:   cfg.Worker.ShutdownTimeout,
+		PollInterval:      cfg.Worker.PollInterval,
+		PollTimeout:       cfg.Worker.PollTimeout,
+		DequeueTimeout:    cfg.Worker.DequeueTimeout,
+		JobTimeout:        cfg.Worker.JobTimeout,
+		HeartbeatInterval: cfg.Worker.HeartbeatInterval,
+	}, jobQueue, registry, logger)
+	pool.SetRedactor(redactor)
+	pool.SetMaxAges(maxAges)
+	pool.SetJobTimeouts(jobTimeouts)
+	pool.SetParkingQueue(cfg.Worker.ParkingQueue)
+	pool.SetPolicies(policies)
+	pool.SetSigning(signing)
+
+	workerMetrics := metrics.NewMetrics(logger)
+	workerMetrics.SetSLOTargets(sloTargets)
+	pool.SetMetrics(workerMetrics)
+	if cfg.Metrics.Address != "" {
+		go func() {
+			if err := workerMetrics.StartServer(cfg.Metrics.Address); err != nil {
+				logger.Error("Metrics server failed", zap.Error(err))
+			}
+		}()
+	}
+
+	if dlq != nil {
+		pool.SetDeadLetterQueue(dlq)
+	}
+
+	// Record how jobs spawned by recurring schedules finished
+	if backend, ok := jobQueue.(queue.ScheduleBackend); ok {
+		pool.SetScheduledQueue(backend.Schedules())
+	}
+
+	events := newEventSinks(cfg, nil, logger)
+	if events != nil {
+		events.SetMetrics(workerMetrics)
+		pool.SetEventSink(events)
+	}
+
+	if cfg.Anomaly.Enabled {
+		pool.SetAnomalyDetector(cfg.Anomaly.Detector(), cfg.Anomaly.Interval)
+		logger.Info("Anomaly detection enabled", zap.Duration("interval", cfg.Anomaly.Interval))
+	}
+
+	if err := pool.Start(); err != nil {
+		logger.Fatal("Failed to start worker pool", zap.Error(err))
+	}
+
+	var healthServer *worker.HealthServer
+	if cfg.Worker.HealthAddress != "" {
+		healthServer = worker.NewHealthServer(cfg.Worker.HealthAddress, pool, jobQueue, logger)
+		go func() {
+			if err := healthServer.Start(); err != nil {
+				logger.Error("Worker health server failed", zap.Error(err))
+			}
+		}()
+	}
+
+	notifySystemd(systemd.Ready, logger)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logger.Info("Shutting down worker pool...")
+	notifySystemd(systemd.Stopping, logger)
+
+	exitCode := exitCleanDrain
+	report, err := pool.Drain()
+	if err != nil {
+		logger.Error("Failed to shutdown worker pool gracefully",
+			zap.Int("requeued", report.Requeued),
+			zap.Error(err),
+		)
+		exitCode = exitDrainTimeout
+	}
+
+	// Deliver events of the drained jobs
+	if events != nil {
+		events.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if healthServer != nil {
+		if err := healthServer.Stop(ctx); err != nil {
+			logger.Error("Failed to shutdown worker health server", zap.Error(err))
+		}
+	}
+	if err := workerMetrics.StopServer(ctx); err != nil {
+		logger.Error("Failed to shutdown metrics server", zap.Error(err))
+	}
+
+	logger.Info("Worker pool shutdown complete", zap.Int("exit_code", exitCode))
+
+	if exitCode != exitCleanDrain {
+		// os.Exit skips deferred calls
+		jobQueue.Close()
+		logger.Sync()
+		os.Exit(exitCode)
+	}
+}
+
+// newEventSinks builds the forwarder for the configured sinks, or nil when
+// none are configured. The Redis stream sink needs a Redis client.
+func newEventSinks(cfg *config.Config, client redis.Cmdable, logger *zap.Logger) *sink.Forwarder {
+	if !cfg.Sinks.Enabled() {
+		return nil
+	}
+
+	var sinks []sink.Sink
+	if cfg.Sinks.KafkaTopic != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Redis.Timeout)
+		producer, err := queue.DialKafka(ctx, queue.KafkaOptions{Brokers: cfg.Kafka.Brokers})
+		cancel()
+		if err != nil {
+			logger.Fatal("Failed to connect the Kafka sink", zap.Error(err))
+		}
+		sinks = append(sinks, sink.NewKafkaSink(producer, cfg.Sinks.KafkaTopic))
+	}
+	if cfg.Sinks.WebhookURL != "" {
+		sinks = append(sinks, sink.NewWebhookSink(cfg.Sinks.WebhookURL, cfg.Sinks.WebhookSecret))
+	}
+	if cfg.Sinks.RedisStream != "" && client != nil {
+		sinks = append(sinks, sink.NewStreamSink(client, cfg.Redis.Key(cfg.Sinks.RedisStream), cfg.Sinks.StreamMaxLen))
+	}
+
+	names := make([]string, 0, len(sinks))
+	for _, s := range sinks {
+		names = append(names, s.Name())
+	}
+	logger.Info("Forwarding job events", zap.Strings("sinks", names))
+
+	return sink.NewForwarder(sinks, cfg.Sinks.Buffer, logger)
+}
+
+// newPriorityQueue connects the high/normal/low priority queues with the configured ratio
+func newPriorityQueue(cfg *config.Config, opts queue.RedisOptions, keyring *encryption.Keyring) (*queue.PriorityQueue, error) {
+	priorityQueue, err := queue.NewPriorityQueue(opts)
+	if err != nil {
+		return nil, err
+	}
+	priorityQueue.SetKeyring(keyring)
+
+	ratio, err := cfg.Queue.Ratio()
+	if err != nil {
+		priorityQueue.Close()
+		return nil, err
+	}
+	if err := priorityQueue.SetPriorityRatio(ratio.High, ratio.Normal, ratio.Low); err != nil {
+		priorityQueue.Close()
+		return nil, err
+	}
+	return priorityQueue, nil
+}
+
+// notifySystemd sends an sd_notify state, logging failures
+func notifySystemd(state string, logger *zap.Logger) {
+	if _, err := systemd.Notify(state); err != nil {
+		logger.Warn("Failed to notify systemd", zap.String("state", state), zap.Error(err))
+	}
+}
+
+// handleDebugSignals serves operator signals until the channel is closed
+func handleDebugSignals(signals <-chan os.Signal, pool *worker.Pool, logger *zap.Logger) {
+	for sig := range signals {
+		switch sig {
+		case syscall.SIGUSR1:
+			pool.DumpState()
+		case syscall.SIGUSR2:
+			paused := pool.TogglePause()
+			logger.Info("Dequeuing toggled by SIGUSR2", zap.Bool("paused", paused))
+		}
+	}
 }
 
 func initLogger(cfg config.LogConfig) (*zap.Logger, error) {
@@ -34,6 +34,23 @@ func (h *EmailJobHandler) Description() string {
 	return "Sends emails to specified recipients"
 }
 
+// Capabilities advertises the email payload at GET /jobs/types
+func (h *EmailJobHandler) Capabilities() types.HandlerCapabilities {
+	return types.HandlerCapabilities{
+		Version: "1",
+		PayloadSchema: json.RawMessage(`{
+			"type": "object",
+			"required": ["to", "subject"],
+			"properties": {
+				"to": {"type": "string", "format": "email"},
+				"subject": {"type": "string", "minLength": 1},
+				"body": {"type": "string"}
+			}
+		}`),
+		ExamplePayload: json.RawMessage(`{"to":"user@example.com","subject":"Welcome","body":"Thanks for signing up"}`),
+	}
+}
+
 func (h *EmailJobHandler) Handle(ctx context.Context, job *types.Job) error {
 	// Parse payload
 	var payload EmailPayload
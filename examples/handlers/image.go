@@ -35,6 +35,26 @@ func (h *ImageJobHandler) Description() string {
 	return "Resizes images to specified dimensions"
 }
 
+// Capabilities advertises the image payload at GET /jobs/types; resizing
+// large images can take longer than the worker's default timeout
+func (h *ImageJobHandler) Capabilities() types.HandlerCapabilities {
+	return types.HandlerCapabilities{
+		Version: "1",
+		PayloadSchema: json.RawMessage(`{
+			"type": "object",
+			"required": ["url", "width", "height"],
+			"properties": {
+				"url": {"type": "string", "format": "uri"},
+				"width": {"type": "integer", "minimum": 1},
+				"height": {"type": "integer", "minimum": 1},
+				"format": {"type": "string"}
+			}
+		}`),
+		ExamplePayload: json.RawMessage(`{"url":"https://example.com/photo.jpg","width":800,"height":600,"format":"jpeg"}`),
+		DefaultPolicy:  &types.JobPolicy{Timeout: "2m"},
+	}
+}
+
 func (h *ImageJobHandler) Handle(ctx context.Context, job *types.Job) error {
 	// Parse payload
 	var payload ImagePayload
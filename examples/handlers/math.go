@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"time"
 
 	"github.com/aneeshsunganahalli/Gopher/pkg/types"
 	"go.uber.org/zap"
@@ -33,6 +34,32 @@ func (h *MathJobHandler) Description() string {
 	return "Performs mathematical computations (fibonacci, prime checking, factorial)"
 }
 
+// CacheKey makes math jobs idempotent: the same payload gives the same answer
+func (h *MathJobHandler) CacheKey(job *types.Job) string {
+	return types.PayloadCacheKey(job)
+}
+
+func (h *MathJobHandler) CacheTTL() time.Duration {
+	return 10 * time.Minute
+}
+
+// Capabilities advertises the math payload at GET /jobs/types
+func (h *MathJobHandler) Capabilities() types.HandlerCapabilities {
+	return types.HandlerCapabilities{
+		Version: "1",
+		PayloadSchema: json.RawMessage(`{
+			"type": "object",
+			"required": ["operation", "number"],
+			"properties": {
+				"operation": {"type": "string", "enum": ["fibonacci", "prime", "factorial"]},
+				"number": {"type": "integer", "minimum": 0},
+				"precision": {"type": "integer", "minimum": 0}
+			}
+		}`),
+		ExamplePayload: json.RawMessage(`{"operation":"fibonacci","number":30}`),
+	}
+}
+
 func (h *MathJobHandler) Handle(ctx context.Context, job *types.Job) error {
 	// Parse payload
 	var payload MathPayload
@@ -80,7 +107,12 @@ func (h *MathJobHandler) Handle(ctx context.Context, job *types.Job) error {
 		zap.Any("result", result),
 	)
 	
-	return nil
+	// Keep the answer so clients can fetch it from GET /api/v1/jobs/<id>/result
+	return types.SetResult(ctx, map[string]interface{}{
+		"operation": payload.Operation,
+		"number":    payload.Number,
+		"result":    result,
+	})
 }
 
 func (h *MathJobHandler) fibonacci(ctx context.Context, n int64) (int64, error) {
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/aneeshsunganahalli/Gopher/pkg/types"
@@ -37,15 +38,38 @@ type JobStatusRequest struct {
 
 // JobStatusResponse represents the response with job status information
 type JobStatusResponse struct {
+	JobID       string             `json:"job_id"`
+	Type        string             `json:"type"`
+	Status      types.JobStatus    `json:"status"`
+	EnqueuedAt  time.Time          `json:"enqueued_at"`
+	StartedAt   *time.Time         `json:"started_at,omitempty"`
+	CompletedAt *time.Time         `json:"completed_at,omitempty"`
+	Attempts    int                `json:"attempts"`
+	MaxRetries  int                `json:"max_retries"`
+	Error       string             `json:"error,omitempty"`
+	Progress    *types.JobProgress `json:"progress,omitempty"` // Latest progress reported by the running handler
+}
+
+// JobResultResponse represents the response with the value a job produced
+type JobResultResponse struct {
 	JobID       string          `json:"job_id"`
 	Type        string          `json:"type"`
 	Status      types.JobStatus `json:"status"`
-	EnqueuedAt  time.Time       `json:"enqueued_at"`
-	StartedAt   *time.Time      `json:"started_at,omitempty"`
-	CompletedAt *time.Time      `json:"completed_at,omitempty"`
-	Attempts    int             `json:"attempts"`
-	MaxRetries  int             `json:"max_retries"`
+	Result      json.RawMessage `json:"result,omitempty"` // Value the handler recorded, absent when it recorded none
 	Error       string          `json:"error,omitempty"`
+	CompletedAt time.Time       `json:"completed_at"`
+	CachedFrom  string          `json:"cached_from,omitempty"`
+}
+
+// JobTypeInfo describes how to submit one job type
+type JobTypeInfo struct {
+	Type           string           `json:"type"`
+	Description    string           `json:"description"`
+	Version        string           `json:"version,omitempty"`
+	PayloadSchema  json.RawMessage  `json:"payload_schema,omitempty"`
+	ExamplePayload json.RawMessage  `json:"example_payload,omitempty"`
+	DefaultPolicy  *types.JobPolicy `json:"default_policy,omitempty"` // What the handler advertises
+	Policy         *types.JobPolicy `json:"policy,omitempty"`         // What is in effect, after configuration and overrides
 }
 
 // BatchEnqueueRequest represents a request to enqueue multiple jobs at once
@@ -92,20 +116,30 @@ type JobStats struct {
 
 // WorkerStatsResponse represents the response with worker statistics
 type WorkerStatsResponse struct {
-	TotalWorkers  int          `json:"total_workers"`
-	ActiveWorkers int          `json:"active_workers"`
+	Pools         int          `json:"pools"`          // Live worker pool processes
+	TotalWorkers  int          `json:"total_workers"`  // Worker goroutines across all pools
+	ActiveWorkers int          `json:"active_workers"` // Workers currently running a job
 	IdleWorkers   int          `json:"idle_workers"`
+	JobsProcessed int          `json:"jobs_processed"` // Totals since each live pool started
+	JobsFailed    int          `json:"jobs_failed"`
+	JobsRetried   int          `json:"jobs_retried"`
 	WorkersInfo   []WorkerInfo `json:"workers"`
 }
 
-// WorkerInfo holds information about a specific worker
+// WorkerInfo holds information about a specific worker pool
 type WorkerInfo struct {
-	ID            string `json:"id"`
-	Status        string `json:"status"` // idle, processing
-	JobsProcessed int    `json:"jobs_processed"`
-	JobsFailed    int    `json:"jobs_failed"`
-	JobsRetried   int    `json:"jobs_retried"`
-	CurrentJobID  string `json:"current_job_id,omitempty"`
+	ID            string    `json:"id"`
+	Status        string    `json:"status"` // idle, processing, draining
+	Hostname      string    `json:"hostname,omitempty"`
+	PID           int       `json:"pid,omitempty"`
+	Concurrency   int       `json:"concurrency"`
+	ActiveJobs    int       `json:"active_jobs"`
+	JobsProcessed int       `json:"jobs_processed"`
+	JobsFailed    int       `json:"jobs_failed"`
+	JobsRetried   int       `json:"jobs_retried"`
+	CurrentJobID  string    `json:"current_job_id,omitempty"`
+	StartedAt     time.Time `json:"started_at"`
+	LastSeen      time.Time `json:"last_seen"`
 }
 
 // ListFailedJobsResponse represents the response with failed jobs
@@ -116,13 +150,95 @@ type ListFailedJobsResponse struct {
 
 // FailedJobInfo holds information about a failed job
 type FailedJobInfo struct {
-	JobID      string    `json:"job_id"`
-	Type       string    `json:"type"`
-	Payload    string    `json:"payload"`
-	Error      string    `json:"error"`
-	Attempts   int       `json:"attempts"`
-	MaxRetries int       `json:"max_retries"`
-	FailedAt   time.Time `json:"failed_at"`
+	JobID       string    `json:"job_id"`
+	Type        string    `json:"type"`
+	Payload     string    `json:"payload"`
+	Error       string    `json:"error"`
+	Fingerprint string    `json:"fingerprint"`
+	Reason      string    `json:"reason"`
+	Attempts    int       `json:"attempts"`
+	MaxRetries  int       `json:"max_retries"`
+	FailedAt    time.Time `json:"failed_at"`
+}
+
+// ListQuarantinedJobsResponse represents the response with quarantined jobs
+type ListQuarantinedJobsResponse struct {
+	Jobs       []QuarantinedJob `json:"jobs"`
+	TotalCount int              `json:"total_count"`
+}
+
+// QuarantinedJob holds information about a job waiting for review
+type QuarantinedJob struct {
+	JobID         string    `json:"job_id"`
+	Type          string    `json:"type"`
+	Queue         string    `json:"queue,omitempty"`
+	Payload       string    `json:"payload"`
+	Reason        string    `json:"reason"`
+	Error         string    `json:"error"`
+	Attempts      int       `json:"attempts"`
+	QuarantinedAt time.Time `json:"quarantined_at"`
+}
+
+// CreateScheduleRequest represents a request to create a recurring schedule
+type CreateScheduleRequest struct {
+	Type           string          `json:"type" binding:"required"`
+	Payload        json.RawMessage `json:"payload" binding:"required"`
+	MaxRetries     *int            `json:"max_retries,omitempty"`
+	CronExpression string          `json:"cron_expression" binding:"required"`
+}
+
+// ScheduleInfo holds information about a recurring schedule
+type ScheduleInfo struct {
+	ID             string          `json:"id"`
+	Type           string          `json:"type"`
+	Payload        json.RawMessage `json:"payload"`
+	CronExpression string          `json:"cron_expression"`
+	NextRunAt      time.Time       `json:"next_run_at"`
+	Paused         bool            `json:"paused"`
+	PausedAt       *time.Time      `json:"paused_at,omitempty"`
+}
+
+// ListSchedulesResponse represents the response with recurring schedules
+type ListSchedulesResponse struct {
+	Schedules []ScheduleInfo `json:"schedules"`
+}
+
+// ScheduleRunInfo holds one job spawned by a recurring schedule and its outcome
+type ScheduleRunInfo struct {
+	JobID      string     `json:"job_id"`
+	EnqueuedAt time.Time  `json:"enqueued_at"`
+	Status     string     `json:"status,omitempty"` // Empty while the job is pending or running
+	Attempts   int        `json:"attempts,omitempty"`
+	Duration   string     `json:"duration,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// ListScheduleRunsResponse represents the runs of a recurring schedule, newest first
+type ListScheduleRunsResponse struct {
+	ScheduleID string            `json:"schedule_id"`
+	Runs       []ScheduleRunInfo `json:"runs"`
+}
+
+// DLQTriageGroup summarizes dead letter jobs of one type that failed the same way
+type DLQTriageGroup struct {
+	Summary       string    `json:"summary"` // e.g. "82× email: SMTP timeout after <n>s"
+	JobType       string    `json:"job_type"`
+	Reason        string    `json:"reason"`
+	Fingerprint   string    `json:"fingerprint"`
+	Count         int       `json:"count"`
+	Example       string    `json:"example"`
+	FirstFailedAt time.Time `json:"first_failed_at"`
+	LastFailedAt  time.Time `json:"last_failed_at"`
+	SampleJobIDs  []string  `json:"sample_job_ids"`
+}
+
+// DLQTriageResponse represents the triage report, largest group first
+type DLQTriageResponse struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	Scanned     int              `json:"scanned"`
+	Truncated   bool             `json:"truncated"` // Only the newest max entries were scanned
+	Groups      []DLQTriageGroup `json:"groups"`
 }
 
 // RetryFailedJobRequest represents a request to retry a failed job
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// APIKeyProvider authenticates static API keys sent in the X-API-Key header
+type APIKeyProvider struct {
+	keys map[string]Principal
+}
+
+// ParseAPIKeys builds a provider from comma separated
+// name:key[:role[:types]] entries. Keys without a role get RoleUser; types
+// is a |-separated list of job types or globs, e.g. email|report_*, the key
+// may enqueue, and keys without one may enqueue any type.
+func ParseAPIKeys(value string) (*APIKeyProvider, error) {
+	provider := &APIKeyProvider{keys: make(map[string]Principal)}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 4 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid API key entry %q, expected name:key[:role[:types]]", entry)
+		}
+
+		role := RoleUser
+		if len(parts) >= 3 && parts[2] != "" {
+			role = parts[2]
+		}
+		if role != RoleUser && role != RoleAdmin {
+			return nil, fmt.Errorf("invalid role %q for API key %s", role, parts[0])
+		}
+		if _, exists := provider.keys[parts[1]]; exists {
+			return nil, fmt.Errorf("duplicate API key for %s", parts[0])
+		}
+
+		var jobTypes []string
+		if len(parts) == 4 {
+			for _, jobType := range strings.Split(parts[3], "|") {
+				jobType = strings.TrimSpace(jobType)
+				if jobType == "" {
+					continue
+				}
+				if _, err := path.Match(jobType, ""); err != nil {
+					return nil, fmt.Errorf("invalid job type pattern %q for API key %s", jobType, parts[0])
+				}
+				jobTypes = append(jobTypes, jobType)
+			}
+			if len(jobTypes) == 0 {
+				return nil, fmt.Errorf("API key %s lists no job types", parts[0])
+			}
+		}
+
+		provider.keys[parts[1]] = Principal{Subject: parts[0], Roles: []string{role}, JobTypes: jobTypes}
+	}
+
+	if len(provider.keys) == 0 {
+		return nil, fmt.Errorf("no API keys configured")
+	}
+	return provider, nil
+}
+
+// Name implements Provider
+func (p *APIKeyProvider) Name() string {
+	return "apikey"
+}
+
+// Authenticate implements Provider
+func (p *APIKeyProvider) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	// Compare against every key so timing doesn't reveal near matches
+	var match *Principal
+	for candidate, principal := range p.keys {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(key)) == 1 {
+			found := principal
+			match = &found
+		}
+	}
+	if match == nil {
+		return nil, invalid("unknown API key")
+	}
+
+	match.Provider = p.Name()
+	return match, nil
+}
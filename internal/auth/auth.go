@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Roles understood by the server
+const (
+	RoleUser  = "user"  // May submit jobs and read queue state
+	RoleAdmin = "admin" // May also use the /admin endpoints
+)
+
+var (
+	// ErrNoCredentials means the request carries nothing this provider understands
+	ErrNoCredentials = errors.New("no credentials")
+
+	// ErrInvalidCredentials means credentials were present but rejected
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Subject  string   `json:"subject"`
+	Roles    []string `json:"roles"`
+	Provider string   `json:"provider"`            // Name of the provider that authenticated the caller
+	JobTypes []string `json:"job_types,omitempty"` // Job types the caller may enqueue, as names or globs; empty for every type
+}
+
+// HasRole reports whether the principal holds role; admins hold every role
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role || r == RoleAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// CanEnqueue reports whether the principal may submit jobs of jobType.
+// Admins are held to their list too, so a key scoped to a few types stays
+// scoped whatever its role.
+func (p *Principal) CanEnqueue(jobType string) bool {
+	if len(p.JobTypes) == 0 {
+		return true
+	}
+	for _, pattern := range p.JobTypes {
+		if matched, _ := path.Match(pattern, jobType); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Provider authenticates HTTP requests against an identity system.
+// Authenticate returns ErrNoCredentials when the request has no credentials
+// for this provider, so providers can be chained.
+type Provider interface {
+	// Name identifies the provider in logs and principals
+	Name() string
+
+	// Authenticate returns the caller of the request
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Chain tries providers in order; the first one that finds credentials decides
+type Chain []Provider
+
+// Name lists the chained providers
+func (c Chain) Name() string {
+	names := make([]string, len(c))
+	for i, provider := range c {
+		names[i] = provider.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// Authenticate returns the result of the first provider that finds credentials
+func (c Chain) Authenticate(r *http.Request) (*Principal, error) {
+	for _, provider := range c {
+		principal, err := provider.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return principal, err
+	}
+	return nil, ErrNoCredentials
+}
+
+// bearerToken returns the token from an "Authorization: Bearer" header
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+// invalid wraps a rejection reason in ErrInvalidCredentials
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidCredentials, fmt.Sprintf(format, args...))
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the authenticated caller
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the authenticated caller, or nil when auth is disabled
+func FromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IntrospectionOptions configures OAuth 2.0 token introspection (RFC 7662),
+// as offered by most OIDC providers
+type IntrospectionOptions struct {
+	URL          string
+	ClientID     string
+	ClientSecret string
+	RolesClaim   string        // Claim holding the caller's roles, e.g. roles or groups
+	CacheTTL     time.Duration // How long an active token is trusted before asking again
+	Timeout      time.Duration
+}
+
+// IntrospectionProvider authenticates opaque bearer tokens by asking the
+// identity provider whether they are active
+type IntrospectionProvider struct {
+	opts   IntrospectionOptions
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedPrincipal
+}
+
+type cachedPrincipal struct {
+	principal *Principal
+	expires   time.Time
+}
+
+// NewIntrospectionProvider creates the provider
+func NewIntrospectionProvider(opts IntrospectionOptions) (*IntrospectionProvider, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("introspection URL is required")
+	}
+	if opts.RolesClaim == "" {
+		opts.RolesClaim = "roles"
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+
+	return &IntrospectionProvider{
+		opts:   opts,
+		client: &http.Client{Timeout: opts.Timeout},
+		cache:  make(map[string]cachedPrincipal),
+	}, nil
+}
+
+// Name implements Provider
+func (p *IntrospectionProvider) Name() string {
+	return "introspection"
+}
+
+// Authenticate implements Provider
+func (p *IntrospectionProvider) Authenticate(r *http.Request) (*Principal, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	cacheKey := hex.EncodeToString(sum[:])
+
+	if principal := p.cached(cacheKey); principal != nil {
+		return principal, nil
+	}
+
+	principal, expires, err := p.introspect(r, token)
+	if err != nil {
+		return nil, err
+	}
+
+	p.store(cacheKey, principal, expires)
+	return principal, nil
+}
+
+// introspect asks the identity provider about the token
+func (p *IntrospectionProvider) introspect(r *http.Request, token string) (*Principal, time.Time, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, p.opts.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to build introspection request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.opts.ClientID != "" {
+		req.SetBasicAuth(p.opts.ClientID, p.opts.ClientSecret)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to introspect token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, time.Time{}, fmt.Errorf("failed to introspect token: identity provider returned %s", resp.Status)
+	}
+
+	var claims map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to decode introspection response: %w", err)
+	}
+
+	if active, _ := claims["active"].(bool); !active {
+		return nil, time.Time{}, invalid("token is not active")
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		subject, _ = claims["username"].(string)
+	}
+	if subject == "" {
+		return nil, time.Time{}, invalid("token has no subject")
+	}
+
+	expires := time.Now().Add(p.opts.CacheTTL)
+	if exp, ok := claims["exp"].(float64); ok && time.Unix(int64(exp), 0).Before(expires) {
+		expires = time.Unix(int64(exp), 0)
+	}
+
+	return &Principal{
+		Subject:  subject,
+		Roles:    rolesFromClaim(claims[p.opts.RolesClaim]),
+		Provider: p.Name(),
+	}, expires, nil
+}
+
+func (p *IntrospectionProvider) cached(key string) *Principal {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.cache[key]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.expires) {
+		delete(p.cache, key)
+		return nil
+	}
+	return entry.principal
+}
+
+func (p *IntrospectionProvider) store(key string, principal *Principal, expires time.Time) {
+	if p.opts.CacheTTL <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Drop expired entries as we go so the cache can't grow without bound
+	now := time.Now()
+	for k, entry := range p.cache {
+		if now.After(entry.expires) {
+			delete(p.cache, k)
+		}
+	}
+	p.cache[key] = cachedPrincipal{principal: principal, expires: expires}
+}
@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// JWTOptions configures JWT verification. Exactly one of Secret (HS256) or
+// PublicKeyPEM (RS256) must be set.
+type JWTOptions struct {
+	Secret       []byte
+	PublicKeyPEM []byte
+	Issuer       string // Required iss claim, empty to skip the check
+	Audience     string // Required aud entry, empty to skip the check
+	RolesClaim   string // Claim holding the caller's roles, e.g. roles or groups
+}
+
+// JWTProvider authenticates bearer JWTs signed with HS256 or RS256
+type JWTProvider struct {
+	opts      JWTOptions
+	publicKey *rsa.PublicKey
+	now       func() time.Time
+}
+
+// NewJWTProvider validates the options and creates the provider
+func NewJWTProvider(opts JWTOptions) (*JWTProvider, error) {
+	if (len(opts.Secret) == 0) == (len(opts.PublicKeyPEM) == 0) {
+		return nil, fmt.Errorf("exactly one of a JWT secret or public key is required")
+	}
+	if opts.RolesClaim == "" {
+		opts.RolesClaim = "roles"
+	}
+
+	provider := &JWTProvider{opts: opts, now: time.Now}
+
+	if len(opts.PublicKeyPEM) > 0 {
+		block, _ := pem.Decode(opts.PublicKeyPEM)
+		if block == nil {
+			return nil, fmt.Errorf("failed to decode JWT public key PEM")
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JWT public key: %w", err)
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("JWT public key must be RSA")
+		}
+		provider.publicKey = rsaKey
+	}
+
+	return provider, nil
+}
+
+// Name implements Provider
+func (p *JWTProvider) Name() string {
+	return "jwt"
+}
+
+// Authenticate implements Provider
+func (p *JWTProvider) Authenticate(r *http.Request) (*Principal, error) {
+	token := bearerToken(r)
+	if token == "" || strings.Count(token, ".") != 2 {
+		// Opaque bearer tokens are left to other providers such as introspection
+		return nil, ErrNoCredentials
+	}
+
+	claims, err := p.verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, invalid("token has no subject")
+	}
+
+	return &Principal{
+		Subject:  subject,
+		Roles:    rolesFromClaim(claims[p.opts.RolesClaim]),
+		Provider: p.Name(),
+	}, nil
+}
+
+// verify checks the signature and standard claims and returns all claims
+func (p *JWTProvider) verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, invalid("malformed token header")
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalid("malformed token signature")
+	}
+
+	switch {
+	case header.Alg == "HS256" && p.publicKey == nil:
+		mac := hmac.New(sha256.New, p.opts.Secret)
+		mac.Write(signed)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return nil, invalid("bad token signature")
+		}
+	case header.Alg == "RS256" && p.publicKey != nil:
+		digest := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(p.publicKey, crypto.SHA256, digest[:], signature); err != nil {
+			return nil, invalid("bad token signature")
+		}
+	default:
+		return nil, invalid("unsupported token algorithm %q", header.Alg)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, invalid("malformed token claims")
+	}
+
+	now := p.now()
+	if exp, ok := claims["exp"].(float64); !ok || now.After(time.Unix(int64(exp), 0)) {
+		return nil, invalid("token expired or has no expiry")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Before(time.Unix(int64(nbf), 0)) {
+		return nil, invalid("token not valid yet")
+	}
+	if p.opts.Issuer != "" && claims["iss"] != p.opts.Issuer {
+		return nil, invalid("unexpected token issuer")
+	}
+	if p.opts.Audience != "" && !hasAudience(claims["aud"], p.opts.Audience) {
+		return nil, invalid("token not issued for this audience")
+	}
+
+	return claims, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// hasAudience accepts aud as a single string or a list
+func hasAudience(aud interface{}, want string) bool {
+	switch value := aud.(type) {
+	case string:
+		return value == want
+	case []interface{}:
+		for _, entry := range value {
+			if entry == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rolesFromClaim reads roles from a list claim or a space separated string,
+// defaulting to RoleUser for authenticated callers without roles
+func rolesFromClaim(claim interface{}) []string {
+	var roles []string
+	switch value := claim.(type) {
+	case string:
+		roles = strings.Fields(value)
+	case []interface{}:
+		for _, entry := range value {
+			if role, ok := entry.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+	}
+
+	if len(roles) == 0 {
+		return []string{RoleUser}
+	}
+	return roles
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"net/http"
+)
+
+// MTLSProvider authenticates TLS client certificates that the server already
+// verified against its client CA. The subject is the certificate's common
+// name and the roles are its organizational units.
+type MTLSProvider struct{}
+
+// NewMTLSProvider creates the provider
+func NewMTLSProvider() *MTLSProvider {
+	return &MTLSProvider{}
+}
+
+// Name implements Provider
+func (p *MTLSProvider) Name() string {
+	return "mtls"
+}
+
+// Authenticate implements Provider
+func (p *MTLSProvider) Authenticate(r *http.Request) (*Principal, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, ErrNoCredentials
+	}
+
+	cert := r.TLS.VerifiedChains[0][0]
+	if cert.Subject.CommonName == "" {
+		return nil, invalid("client certificate has no common name")
+	}
+
+	roles := cert.Subject.OrganizationalUnit
+	if len(roles) == 0 {
+		roles = []string{RoleUser}
+	}
+
+	return &Principal{
+		Subject:  cert.Subject.CommonName,
+		Roles:    roles,
+		Provider: p.Name(),
+	}, nil
+}
@@ -0,0 +1,371 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OIDCOptions configures the OpenID Connect authorization code flow
+type OIDCOptions struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string            // Callback URL registered with the identity provider
+	Scopes       []string          // Requested scopes; openid is always included
+	GroupsClaim  string            // ID token claim listing the user's groups
+	RoleMapping  map[string]string // Identity provider group to Gopher role
+}
+
+// OIDC runs the authorization code flow with PKCE against an OpenID provider
+// and maps the user's groups to Gopher roles
+type OIDC struct {
+	opts   OIDCOptions
+	client *http.Client
+
+	issuer                string
+	authorizationEndpoint string
+	tokenEndpoint         string
+	jwksURI               string
+
+	mu   sync.Mutex
+	keys map[string]*rsa.PublicKey // Signing keys by kid
+}
+
+// OIDCLogin is the state kept in the browser between login and callback
+type OIDCLogin struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"` // PKCE code verifier
+	ReturnTo string `json:"return_to,omitempty"`
+	Expires  int64  `json:"exp"`
+}
+
+// ParseRoleMapping parses comma separated group=role pairs
+func ParseRoleMapping(value string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		group, role, ok := strings.Cut(entry, "=")
+		if !ok || group == "" {
+			return nil, fmt.Errorf("invalid role mapping %q, expected group=role", entry)
+		}
+		if role != RoleUser && role != RoleAdmin {
+			return nil, fmt.Errorf("invalid role %q for group %s", role, group)
+		}
+		mapping[group] = role
+	}
+	if len(mapping) == 0 {
+		return nil, fmt.Errorf("no group to role mapping configured")
+	}
+	return mapping, nil
+}
+
+// NewOIDC discovers the provider's endpoints from its issuer URL
+func NewOIDC(ctx context.Context, opts OIDCOptions) (*OIDC, error) {
+	if opts.IssuerURL == "" || opts.ClientID == "" || opts.RedirectURL == "" {
+		return nil, fmt.Errorf("OIDC issuer, client ID and redirect URL are required")
+	}
+	if len(opts.RoleMapping) == 0 {
+		return nil, fmt.Errorf("OIDC role mapping is required")
+	}
+	if opts.GroupsClaim == "" {
+		opts.GroupsClaim = "groups"
+	}
+
+	o := &OIDC{
+		opts:   opts,
+		client: &http.Client{Timeout: 10 * time.Second},
+		keys:   make(map[string]*rsa.PublicKey),
+	}
+
+	var discovery struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		JWKSURI               string `json:"jwks_uri"`
+	}
+	wellKnown := strings.TrimSuffix(opts.IssuerURL, "/") + "/.well-known/openid-configuration"
+	if err := o.getJSON(ctx, wellKnown, &discovery); err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC provider: %w", err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != strings.TrimSuffix(opts.IssuerURL, "/") {
+		return nil, fmt.Errorf("OIDC issuer mismatch: configured %s, provider reports %s", opts.IssuerURL, discovery.Issuer)
+	}
+
+	o.issuer = discovery.Issuer
+	o.authorizationEndpoint = discovery.AuthorizationEndpoint
+	o.tokenEndpoint = discovery.TokenEndpoint
+	o.jwksURI = discovery.JWKSURI
+
+	return o, nil
+}
+
+// Begin starts a login and returns the provider URL to redirect the browser to
+func (o *OIDC) Begin(returnTo string) (*OIDCLogin, string) {
+	login := &OIDCLogin{
+		State:    randomToken(),
+		Nonce:    randomToken(),
+		Verifier: randomToken(),
+		ReturnTo: returnTo,
+		Expires:  time.Now().Add(10 * time.Minute).Unix(),
+	}
+
+	challenge := sha256.Sum256([]byte(login.Verifier))
+	scopes := append([]string{"openid"}, o.opts.Scopes...)
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {o.opts.ClientID},
+		"redirect_uri":          {o.opts.RedirectURL},
+		"scope":                 {strings.Join(dedupe(scopes), " ")},
+		"state":                 {login.State},
+		"nonce":                 {login.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(o.authorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return login, o.authorizationEndpoint + separator + query.Encode()
+}
+
+// Finish exchanges the callback's code for an ID token and returns the user
+func (o *OIDC) Finish(ctx context.Context, login *OIDCLogin, state, code string) (*Principal, error) {
+	if login == nil || time.Now().Unix() > login.Expires {
+		return nil, invalid("login expired, start again")
+	}
+	if state == "" || state != login.State {
+		return nil, invalid("login state mismatch")
+	}
+	if code == "" {
+		return nil, invalid("missing authorization code")
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {o.opts.RedirectURL},
+		"code_verifier": {login.Verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(o.opts.ClientID), url.QueryEscape(o.opts.ClientSecret))
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, invalid("identity provider rejected the authorization code: %s", resp.Status)
+	}
+
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil || token.IDToken == "" {
+		return nil, fmt.Errorf("failed to read ID token from token response")
+	}
+
+	claims, err := o.verifyIDToken(ctx, token.IDToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims["nonce"] != login.Nonce {
+		return nil, invalid("ID token nonce mismatch")
+	}
+
+	subject, _ := claims["email"].(string)
+	if subject == "" {
+		subject, _ = claims["sub"].(string)
+	}
+
+	roles := o.mapRoles(claims[o.opts.GroupsClaim])
+	if len(roles) == 0 {
+		return nil, invalid("none of %s's groups grant access to Gopher", subject)
+	}
+
+	return &Principal{Subject: subject, Roles: roles, Provider: "oidc"}, nil
+}
+
+// mapRoles converts the groups claim into Gopher roles
+func (o *OIDC) mapRoles(claim interface{}) []string {
+	var groups []string
+	switch value := claim.(type) {
+	case string:
+		groups = strings.Fields(value)
+	case []interface{}:
+		for _, entry := range value {
+			if group, ok := entry.(string); ok {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	var roles []string
+	for _, group := range groups {
+		if role, ok := o.opts.RoleMapping[group]; ok {
+			roles = append(roles, role)
+		}
+	}
+	return dedupe(roles)
+}
+
+// verifyIDToken checks an RS256 ID token against the provider's JWKS
+func (o *OIDC) verifyIDToken(ctx context.Context, token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, invalid("malformed ID token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, invalid("malformed ID token header")
+	}
+	if header.Alg != "RS256" {
+		return nil, invalid("unsupported ID token algorithm %q", header.Alg)
+	}
+
+	key, err := o.signingKey(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalid("malformed ID token signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, invalid("bad ID token signature")
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, invalid("malformed ID token claims")
+	}
+	if claims["iss"] != o.issuer {
+		return nil, invalid("unexpected ID token issuer")
+	}
+	if !hasAudience(claims["aud"], o.opts.ClientID) {
+		return nil, invalid("ID token not issued for this client")
+	}
+	if exp, ok := claims["exp"].(float64); !ok || time.Now().After(time.Unix(int64(exp), 0)) {
+		return nil, invalid("ID token expired")
+	}
+
+	return claims, nil
+}
+
+// signingKey returns the key for kid, refreshing the JWKS when it is unknown
+func (o *OIDC) signingKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	o.mu.Lock()
+	key, ok := o.keys[kid]
+	o.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := o.getJSON(ctx, o.jwksURI, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC signing keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	o.mu.Lock()
+	o.keys = keys
+	o.mu.Unlock()
+
+	key, ok = keys[kid]
+	if !ok {
+		return nil, invalid("unknown ID token signing key %q", kid)
+	}
+	return key, nil
+}
+
+func (o *OIDC) getJSON(ctx context.Context, target string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", target, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// randomToken returns 32 random bytes, URL-safe encoded
+func randomToken() string {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("failed to read random bytes: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func dedupe(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := values[:0:0]
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			out = append(out, value)
+		}
+	}
+	return out
+}
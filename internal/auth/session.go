@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SessionCookieName is the cookie carrying a signed browser session
+const SessionCookieName = "gopher_session"
+
+// SessionManager issues and verifies HMAC-signed session cookies, so browser
+// logins need no server-side session store
+type SessionManager struct {
+	secret []byte
+	ttl    time.Duration
+	secure bool // Only send cookies over HTTPS
+}
+
+// session is the signed cookie payload
+type session struct {
+	Subject string   `json:"sub"`
+	Roles   []string `json:"roles"`
+	Expires int64    `json:"exp"`
+}
+
+// NewSessionManager creates a session manager; the secret must be at least 32 bytes
+func NewSessionManager(secret []byte, ttl time.Duration, secure bool) (*SessionManager, error) {
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("session secret must be at least 32 bytes")
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("session TTL must be positive")
+	}
+	return &SessionManager{secret: secret, ttl: ttl, secure: secure}, nil
+}
+
+// Name implements Provider
+func (m *SessionManager) Name() string {
+	return "session"
+}
+
+// Authenticate implements Provider
+func (m *SessionManager) Authenticate(r *http.Request) (*Principal, error) {
+	cookie, err := r.Cookie(SessionCookieName)
+	if err != nil || cookie.Value == "" {
+		return nil, ErrNoCredentials
+	}
+
+	var s session
+	if err := m.Open(cookie.Value, &s); err != nil {
+		return nil, err
+	}
+	if time.Now().Unix() > s.Expires {
+		return nil, invalid("session expired")
+	}
+
+	return &Principal{Subject: s.Subject, Roles: s.Roles, Provider: m.Name()}, nil
+}
+
+// Issue sets a session cookie for the principal
+func (m *SessionManager) Issue(w http.ResponseWriter, principal *Principal) error {
+	value, err := m.Seal(session{
+		Subject: principal.Subject,
+		Roles:   principal.Roles,
+		Expires: time.Now().Add(m.ttl).Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	m.SetCookie(w, SessionCookieName, value, m.ttl)
+	return nil
+}
+
+// Clear removes the session cookie
+func (m *SessionManager) Clear(w http.ResponseWriter) {
+	m.SetCookie(w, SessionCookieName, "", -1)
+}
+
+// SetCookie writes an HTTP-only, SameSite=Lax cookie; a negative maxAge deletes it
+func (m *SessionManager) SetCookie(w http.ResponseWriter, name, value string, maxAge time.Duration) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(maxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   m.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Seal encodes v as a signed value
+func (m *SessionManager) Seal(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(m.sign(payload)), nil
+}
+
+// Open verifies a value from Seal and decodes it into v
+func (m *SessionManager) Open(value string, v interface{}) error {
+	payload, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return invalid("malformed session")
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, m.sign(payload)) {
+		return invalid("bad session signature")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return invalid("malformed session")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return invalid("malformed session")
+	}
+	return nil
+}
+
+func (m *SessionManager) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
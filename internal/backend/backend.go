@@ -0,0 +1,94 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/config"
+	"github.com/aneeshsunganahalli/Gopher/internal/queue"
+)
+
+// RegisterBuiltins registers the postgres, sqlite, kafka and sqs backends
+// with queue.RegisterBackend, reading their settings from cfg, so the
+// binaries open them the same way as third-party backends. Redis is the
+// default path of each binary and the memory backend only runs inside the
+// server, so neither is registered. Call it once per process.
+func RegisterBuiltins(cfg *config.Config) {
+	queue.RegisterBackend(config.QueueBackendPostgres, func(ctx context.Context, opts queue.BackendOptions) (queue.Queue, queue.DeadLetterQueue, error) {
+		q, err := queue.OpenPostgres(ctx, cfg.Postgres.Driver, cfg.Postgres.DSN, queue.PostgresOptions{
+			QueueName:   opts.QueueName,
+			PollTimeout: opts.PollTimeout,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		q.SetKeyring(opts.Keyring)
+		q.SetSigning(opts.Signing)
+
+		dlq := queue.NewPostgresDLQ(q.DB(), q)
+		dlq.SetKeyring(opts.Keyring)
+		dlq.SetSigning(opts.Signing)
+		return q, dlq, nil
+	})
+
+	queue.RegisterBackend(config.QueueBackendSQLite, func(ctx context.Context, opts queue.BackendOptions) (queue.Queue, queue.DeadLetterQueue, error) {
+		q, err := queue.OpenSQLite(ctx, cfg.SQLite.Driver, cfg.SQLite.Path, queue.SQLiteOptions{
+			QueueName:    opts.QueueName,
+			PollTimeout:  opts.PollTimeout,
+			BusyTimeout:  cfg.SQLite.BusyTimeout,
+			LeaseTimeout: cfg.SQLite.LeaseTimeout,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		q.SetKeyring(opts.Keyring)
+		q.SetSigning(opts.Signing)
+
+		dlq := queue.NewSQLiteDLQ(q)
+		dlq.SetKeyring(opts.Keyring)
+		dlq.SetSigning(opts.Signing)
+		return q, dlq, nil
+	})
+
+	// Kafka has no DLQ, so permanently failed jobs are only logged
+	queue.RegisterBackend(config.QueueBackendKafka, func(ctx context.Context, opts queue.BackendOptions) (queue.Queue, queue.DeadLetterQueue, error) {
+		q, err := queue.OpenKafka(ctx, queue.KafkaOptions{
+			Brokers:     cfg.Kafka.Brokers,
+			Topic:       cfg.Kafka.Topic,
+			Group:       cfg.Kafka.Group,
+			PollTimeout: opts.PollTimeout,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		q.SetKeyring(opts.Keyring)
+		q.SetSigning(opts.Signing)
+		return q, nil, nil
+	})
+
+	// Jobs are deleted once handled, so the queue's redrive policy moves
+	// repeatedly crashing jobs to its DLQ
+	queue.RegisterBackend(config.QueueBackendSQS, func(ctx context.Context, opts queue.BackendOptions) (queue.Queue, queue.DeadLetterQueue, error) {
+		client, err := cfg.AWS.SQSClient(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		q := queue.NewSQSQueue(client, queue.SQSOptions{
+			QueueURL:          cfg.SQS.QueueURL,
+			DLQURL:            cfg.SQS.DLQURL,
+			VisibilityTimeout: cfg.SQS.VisibilityTimeout,
+			PollTimeout:       opts.PollTimeout,
+		})
+		q.SetKeyring(opts.Keyring)
+		q.SetSigning(opts.Signing)
+
+		dlqURL, err := q.DeadLetterURL(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		dlq := queue.NewSQSDLQ(client, dlqURL, q)
+		dlq.SetKeyring(opts.Keyring)
+		dlq.SetSigning(opts.Signing)
+		return q, dlq, nil
+	})
+}
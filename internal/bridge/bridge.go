@@ -0,0 +1,177 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/job"
+	"github.com/aneeshsunganahalli/Gopher/internal/queue"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"go.uber.org/zap"
+)
+
+// retryBackoff caps the wait between attempts to enqueue a record
+const retryBackoff = 30 * time.Second
+
+// Bridge consumes Kafka topics and enqueues each record as a job of the
+// topic's type. A partition's offset is only committed after its records are
+// enqueued, so a crash redelivers them and may enqueue some twice.
+type Bridge struct {
+	client     queue.KafkaClient
+	routes     map[string]string // Job type by topic
+	queue      queue.Queue
+	maxRetries int
+	logger     *zap.Logger
+}
+
+// New creates a bridge; every job type in routes must have a registered handler
+func New(client queue.KafkaClient, routes map[string]string, q queue.Queue, registry *job.Registry, maxRetries int, logger *zap.Logger) (*Bridge, error) {
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("no topics to bridge")
+	}
+	for topic, jobType := range routes {
+		if _, err := registry.Get(jobType); err != nil {
+			return nil, fmt.Errorf("topic %s: job type %q is not registered", topic, jobType)
+		}
+	}
+
+	return &Bridge{
+		client:     client,
+		routes:     routes,
+		queue:      q,
+		maxRetries: maxRetries,
+		logger:     logger,
+	}, nil
+}
+
+// Topics returns the bridged topics in order
+func (b *Bridge) Topics() []string {
+	topics := make([]string, 0, len(b.routes))
+	for topic := range b.routes {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// Run bridges records until ctx is cancelled
+func (b *Bridge) Run(ctx context.Context) error {
+	b.logger.Info("Bridging Kafka topics", zap.Strings("topics", b.Topics()))
+
+	for ctx.Err() == nil {
+		records, err := b.client.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			b.logger.Error("Failed to fetch Kafka records", zap.Error(err))
+			sleep(ctx, 5*time.Second)
+			continue
+		}
+
+		// Next offset to commit per partition, advanced as records are enqueued
+		next := make(map[partition]int64)
+		for _, record := range records {
+			if !b.handle(ctx, record) {
+				break
+			}
+			next[partition{record.Topic, record.Partition}] = record.Offset + 1
+		}
+
+		for p, offset := range next {
+			commitCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := b.client.Commit(commitCtx, p.topic, p.partition, offset); err != nil {
+				b.logger.Error("Failed to commit Kafka offset",
+					zap.String("topic", p.topic),
+					zap.Int32("partition", p.partition),
+					zap.Int64("offset", offset),
+					zap.Error(err),
+				)
+			}
+			cancel()
+		}
+	}
+	return nil
+}
+
+type partition struct {
+	topic     string
+	partition int32
+}
+
+// handle enqueues the record's job, retrying until it succeeds or ctx is
+// cancelled. It reports whether the record is done with, either enqueued or
+// permanently unusable.
+func (b *Bridge) handle(ctx context.Context, record queue.KafkaRecord) bool {
+	fields := []zap.Field{
+		zap.String("topic", record.Topic),
+		zap.Int32("partition", record.Partition),
+		zap.Int64("offset", record.Offset),
+	}
+
+	bridged, err := b.job(record)
+	if err != nil {
+		// Redelivering a malformed record won't fix it, so skip it
+		b.logger.Warn("Skipping unusable Kafka record", append(fields, zap.Error(err))...)
+		return true
+	}
+
+	backoff := time.Second
+	for {
+		err := b.queue.Enqueue(ctx, bridged)
+		if err == nil {
+			b.logger.Debug("Bridged Kafka record",
+				append(fields, zap.String("job_id", bridged.ID), zap.String("job_type", bridged.Type))...)
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+
+		b.logger.Error("Failed to enqueue bridged job, retrying",
+			append(fields, zap.Duration("backoff", backoff), zap.Error(err))...)
+		sleep(ctx, backoff)
+		if ctx.Err() != nil {
+			return false
+		}
+		if backoff *= 2; backoff > retryBackoff {
+			backoff = retryBackoff
+		}
+	}
+}
+
+// job builds the job for a record; its JSON value becomes the payload
+func (b *Bridge) job(record queue.KafkaRecord) (*types.Job, error) {
+	jobType, ok := b.routes[record.Topic]
+	if !ok {
+		return nil, fmt.Errorf("topic %s is not bridged", record.Topic)
+	}
+	if !json.Valid(record.Value) {
+		return nil, fmt.Errorf("record value is not JSON")
+	}
+
+	bridged := types.NewJob(jobType, json.RawMessage(record.Value), b.maxRetries)
+	bridged.Metadata = types.JobMetadata{
+		"kafka_topic":     record.Topic,
+		"kafka_partition": record.Partition,
+		"kafka_offset":    record.Offset,
+	}
+	if len(record.Key) > 0 {
+		bridged.Metadata["kafka_key"] = string(record.Key)
+	}
+
+	if err := bridged.Validate(); err != nil {
+		return nil, err
+	}
+	return bridged, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
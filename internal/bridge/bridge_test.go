@@ -0,0 +1,213 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/job"
+	"github.com/aneeshsunganahalli/Gopher/internal/queue"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"go.uber.org/zap"
+)
+
+type commit struct {
+	topic     string
+	partition int32
+	offset    int64
+}
+
+// fakeKafka hands out one batch of records, then waits for ctx like a
+// consumer with nothing left to read
+type fakeKafka struct {
+	mu      sync.Mutex
+	batch   []queue.KafkaRecord
+	fetches int
+	drained chan struct{} // Closed on the fetch after the batch
+	commits []commit
+}
+
+func (f *fakeKafka) Produce(ctx context.Context, record queue.KafkaRecord) error { return nil }
+
+func (f *fakeKafka) Fetch(ctx context.Context) ([]queue.KafkaRecord, error) {
+	f.mu.Lock()
+	f.fetches++
+	fetches := f.fetches
+	f.mu.Unlock()
+
+	if fetches == 1 {
+		return f.batch, nil
+	}
+	if fetches == 2 {
+		close(f.drained)
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (f *fakeKafka) Commit(ctx context.Context, topic string, partition int32, offset int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commits = append(f.commits, commit{topic, partition, offset})
+	return nil
+}
+
+func (f *fakeKafka) Lag(ctx context.Context, topic string) (int64, error) { return 0, nil }
+
+func (f *fakeKafka) Ping(ctx context.Context) error { return nil }
+
+func (f *fakeKafka) Close() error { return nil }
+
+// failingQueue rejects jobs of one type and reports the first rejection
+type failingQueue struct {
+	*queue.MemoryQueue
+	failType string
+	failed   chan struct{}
+	once     sync.Once
+}
+
+func (q *failingQueue) Enqueue(ctx context.Context, j *types.Job) error {
+	if j.Type == q.failType {
+		q.once.Do(func() { close(q.failed) })
+		return errors.New("queue unavailable")
+	}
+	return q.MemoryQueue.Enqueue(ctx, j)
+}
+
+type testHandler struct{ jobType string }
+
+func (h testHandler) Handle(ctx context.Context, j *types.Job) error { return nil }
+func (h testHandler) Type() string                                   { return h.jobType }
+func (h testHandler) Description() string                            { return "test handler" }
+
+func testRegistry(t *testing.T, jobTypes ...string) *job.Registry {
+	t.Helper()
+	registry := job.NewRegistry(zap.NewNop())
+	for _, jobType := range jobTypes {
+		if err := registry.Register(testHandler{jobType}); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+	return registry
+}
+
+func TestNew(t *testing.T) {
+	registry := testRegistry(t, "email")
+
+	tests := []struct {
+		name    string
+		routes  map[string]string
+		wantErr bool
+	}{
+		{name: "registered type", routes: map[string]string{"signups": "email"}},
+		{name: "unregistered type", routes: map[string]string{"signups": "email", "orders": "invoice"}, wantErr: true},
+		{name: "no routes", routes: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(&fakeKafka{}, tt.routes, queue.NewMemoryQueue(time.Millisecond), registry, 3, zap.NewNop())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New error = %v, want an error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBridgeRun(t *testing.T) {
+	record := func(topic string, partition int32, offset int64, value string) queue.KafkaRecord {
+		return queue.KafkaRecord{Topic: topic, Partition: partition, Offset: offset, Key: []byte("k"), Value: []byte(value)}
+	}
+
+	tests := []struct {
+		name        string
+		batch       []queue.KafkaRecord
+		failType    string // Job type the queue rejects until the bridge is stopped
+		wantTypes   []string
+		wantCommits map[commit]bool
+	}{
+		{
+			name: "records become jobs of their topic's type",
+			batch: []queue.KafkaRecord{
+				record("signups", 0, 10, `{"to":"a"}`),
+				record("orders", 1, 4, `{"id":1}`),
+				record("signups", 0, 11, `{"to":"b"}`),
+			},
+			wantTypes:   []string{"email", "invoice", "email"},
+			wantCommits: map[commit]bool{{"signups", 0, 12}: true, {"orders", 1, 5}: true},
+		},
+		{
+			name: "malformed record is skipped and committed past",
+			batch: []queue.KafkaRecord{
+				record("signups", 0, 10, `not json`),
+				record("signups", 0, 11, `{"to":"b"}`),
+			},
+			wantTypes:   []string{"email"},
+			wantCommits: map[commit]bool{{"signups", 0, 12}: true},
+		},
+		{
+			name: "record that can't be enqueued holds back the commit",
+			batch: []queue.KafkaRecord{
+				record("signups", 0, 10, `{"to":"a"}`),
+				record("orders", 1, 4, `{"id":1}`),
+				record("signups", 0, 11, `{"to":"b"}`),
+			},
+			failType:    "invoice",
+			wantTypes:   []string{"email"},
+			wantCommits: map[commit]bool{{"signups", 0, 11}: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			fake := &fakeKafka{batch: tt.batch, drained: make(chan struct{})}
+			jobs := &failingQueue{MemoryQueue: queue.NewMemoryQueue(time.Millisecond), failType: tt.failType, failed: make(chan struct{})}
+			b, err := New(fake, map[string]string{"signups": "email", "orders": "invoice"}, jobs, testRegistry(t, "email", "invoice"), 3, zap.NewNop())
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			done := make(chan error, 1)
+			go func() { done <- b.Run(ctx) }()
+
+			// Stop once the batch is through, or the bridge is stuck retrying
+			select {
+			case <-fake.drained:
+			case <-jobs.failed:
+			case <-time.After(5 * time.Second):
+				t.Fatal("bridge didn't get through the batch")
+			}
+			cancel()
+			if err := <-done; err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+
+			for _, want := range tt.wantTypes {
+				got, err := jobs.Dequeue(context.Background())
+				if err != nil || got == nil || got.Type != want {
+					t.Fatalf("Dequeue = %v, %v; want a %s job", got, err, want)
+				}
+				if got.Metadata["kafka_key"] != "k" || got.MaxRetries != 3 {
+					t.Fatalf("job = %+v, want the record's key and the bridge's retries", got)
+				}
+			}
+			if got, _ := jobs.Dequeue(context.Background()); got != nil {
+				t.Fatalf("extra job %+v enqueued", got)
+			}
+
+			if len(fake.commits) != len(tt.wantCommits) {
+				t.Fatalf("commits = %v, want %v", fake.commits, tt.wantCommits)
+			}
+			for _, c := range fake.commits {
+				if !tt.wantCommits[c] {
+					t.Fatalf("commits = %v, want %v", fake.commits, tt.wantCommits)
+				}
+			}
+		})
+	}
+}
@@ -1,24 +1,82 @@
 package config
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/aneeshsunganahalli/Gopher/internal/auth"
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/aneeshsunganahalli/Gopher/internal/metrics"
+	"github.com/aneeshsunganahalli/Gopher/internal/queue"
+	"github.com/aneeshsunganahalli/Gopher/internal/redact"
+	"github.com/aneeshsunganahalli/Gopher/internal/sqs"
+	"github.com/aneeshsunganahalli/Gopher/internal/trigger"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
 	"github.com/kelseyhightower/envconfig"
 )
 
 type Config struct {
-	Server ServerConfig `envconfig:"SERVER"`
-	Redis  RedisConfig  `envconfig:"REDIS"`
-	Worker WorkerConfig `envconfig:"WORKER"`
-	Log    LogConfig    `envconfig:"LOG"`
+	Server   ServerConfig   `envconfig:"SERVER"`
+	Redis    RedisConfig    `envconfig:"REDIS"`
+	Postgres PostgresConfig `envconfig:"POSTGRES"`
+	Kafka    KafkaConfig    `envconfig:"KAFKA"`
+	SQS      SQSConfig      `envconfig:"SQS"`
+	SQLite   SQLiteConfig   `envconfig:"SQLITE"`
+	Worker   WorkerConfig   `envconfig:"WORKER"`
+	Log      LogConfig      `envconfig:"LOG"`
+
+	Encryption  EncryptionConfig  `envconfig:"ENCRYPTION"`
+	Signing     SigningConfig     `envconfig:"SIGNING"`
+	Redaction   RedactionConfig   `envconfig:"REDACTION"`
+	History     HistoryConfig     `envconfig:"HISTORY"`
+	Metrics     MetricsConfig     `envconfig:"METRICS"`
+	Anomaly     AnomalyConfig     `envconfig:"ANOMALY"`
+	Chaos       ChaosConfig       `envconfig:"CHAOS"`
+	Recording   RecordingConfig   `envconfig:"RECORDING"`
+	Spool       SpoolConfig       `envconfig:"SPOOL"`
+	SelfCheck   SelfCheckConfig   `envconfig:"SELF_CHECK"`
+	RateLimit   RateLimitConfig   `envconfig:"RATE_LIMIT"`
+	Queue       QueueConfig       `envconfig:"QUEUE"`
+	Results     ResultsConfig     `envconfig:"RESULTS"`
+	Status      StatusConfig      `envconfig:"STATUS"`
+	Auth        AuthConfig        `envconfig:"AUTH"`
+	Triggers    TriggersConfig    `envconfig:"TRIGGERS"`
+	Templates   TemplatesConfig   `envconfig:"TEMPLATES"`
+	Policies    PoliciesConfig    `envconfig:"POLICIES"`
+	Transforms  TransformsConfig  `envconfig:"TRANSFORMS"`
+	Deps        DepsConfig        `envconfig:"DEPENDENCIES"`
+	Workflows   WorkflowsConfig   `envconfig:"WORKFLOWS"`
+	Idempotency IdempotencyConfig `envconfig:"IDEMPOTENCY"`
+	Ingest      IngestConfig      `envconfig:"INGEST"`
+	AWS         AWSConfig         `envconfig:"AWS"`
+	Bridge      BridgeConfig      `envconfig:"BRIDGE"`
+	Sinks       SinksConfig       `envconfig:"SINKS"`
 }
 
 type ServerConfig struct {
-	Port         int           `envconfig:"PORT" default:"8080"`
-	Host         string        `envconfig:"HOST" default:"localhost"`
-	ReadTimeout  time.Duration `envconfig:"READ_TIMEOUT" default:"10s"`
-	WriteTimeout time.Duration `envconfig:"WRITE_TIMEOUT" default:"10s"`
+	Port              int           `envconfig:"PORT" default:"8080"`
+	Host              string        `envconfig:"HOST" default:"localhost"`
+	ReadTimeout       time.Duration `envconfig:"READ_TIMEOUT" default:"10s"`
+	WriteTimeout      time.Duration `envconfig:"WRITE_TIMEOUT" default:"10s"`
+	QueueAlias        string        `envconfig:"QUEUE_ALIAS" default:""`          // Enqueue through this alias instead of the default queue
+	SchedulerInterval time.Duration `envconfig:"SCHEDULER_INTERVAL" default:"1s"` // How often due scheduled jobs are enqueued, 0 disables
+	DisplayTimezone   string        `envconfig:"DISPLAY_TIMEZONE" default:"UTC"`  // IANA zone for timestamps in API responses and CLI output
+}
+
+// DisplayLocation resolves the configured display timezone
+func (s ServerConfig) DisplayLocation() (*time.Location, error) {
+	if s.DisplayTimezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(s.DisplayTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid display timezone %q: %w", s.DisplayTimezone, err)
+	}
+	return loc, nil
 }
 
 type RedisConfig struct {
@@ -26,13 +84,121 @@ type RedisConfig struct {
 	Password string        `envconfig:"PASSWORD" default:""`
 	DB       int           `envconfig:"DB" default:"0"`
 	Timeout  time.Duration `envconfig:"TIMEOUT" default:"5s"`
+
+	ClusterAddrs []string `envconfig:"CLUSTER_ADDRS" default:""` // Redis Cluster seed nodes (host:port); keys are hash-tagged onto one slot
+	KeyPrefix    string   `envconfig:"KEY_PREFIX" default:""`    // Prepended to every key, e.g. gopher:prod: to share one Redis between deployments
+
+	Compression          string `envconfig:"COMPRESSION" default:""`                // Compress large payloads stored in Redis: gzip, or empty to disable
+	CompressionThreshold int    `envconfig:"COMPRESSION_THRESHOLD" default:"65536"` // Payload size in bytes compression starts at
+
+	Format string `envconfig:"FORMAT" default:"json"` // Wire format of queued jobs: json, protobuf or msgpack; all are always read
+
+	SentinelMaster   string   `envconfig:"SENTINEL_MASTER" default:""`   // Primary name monitored by Sentinel; enables failover
+	SentinelAddrs    []string `envconfig:"SENTINEL_ADDRS" default:""`    // Sentinel addresses (host:port)
+	SentinelPassword string   `envconfig:"SENTINEL_PASSWORD" default:""` // Password for the sentinels themselves
+
+	TLSCACert             string `envconfig:"TLS_CA_CERT" default:""`                   // PEM CA file for verifying the server; any TLS option enables TLS
+	TLSCert               string `envconfig:"TLS_CERT" default:""`                      // PEM client certificate for mutual TLS
+	TLSKey                string `envconfig:"TLS_KEY" default:""`                       // PEM client key for mutual TLS
+	TLSInsecureSkipVerify bool   `envconfig:"TLS_INSECURE_SKIP_VERIFY" default:"false"` // Don't verify the server certificate (testing only)
+
+	RetryAttempts    int           `envconfig:"RETRY_ATTEMPTS" default:"3"`     // Tries per API enqueue on transient errors, 1 disables retries
+	RetryBackoff     time.Duration `envconfig:"RETRY_BACKOFF" default:"100ms"`  // First retry delay, doubled per attempt with full jitter
+	BreakerThreshold int           `envconfig:"BREAKER_THRESHOLD" default:"5"`  // Consecutive failed enqueues that open the circuit, 0 disables it
+	BreakerCooldown  time.Duration `envconfig:"BREAKER_COOLDOWN" default:"10s"` // How long the open circuit fails fast before a trial enqueue
+}
+
+// Key returns name under the configured key prefix, for keys kept outside the queue package
+func (r RedisConfig) Key(name string) string {
+	return r.KeyPrefix + name
+}
+
+type PostgresConfig struct {
+	DSN    string `envconfig:"DSN" default:""`       // Connection string used when QUEUE_BACKEND=postgres
+	Driver string `envconfig:"DRIVER" default:"pgx"` // database/sql driver name; pgx is bundled, others must be linked in
+}
+
+type KafkaConfig struct {
+	Brokers []string `envconfig:"BROKERS" default:""`             // Seed brokers used when QUEUE_BACKEND=kafka
+	Topic   string   `envconfig:"TOPIC" default:"gopher-jobs"`    // Jobs topic, partitioned by job type
+	Group   string   `envconfig:"GROUP" default:"gopher-workers"` // Consumer group shared by workers
+}
+
+type SQSConfig struct {
+	QueueURL          string        `envconfig:"QUEUE_URL" default:""`            // Jobs queue used when QUEUE_BACKEND=sqs
+	DLQURL            string        `envconfig:"DLQ_URL" default:""`              // Dead letter queue, empty to use the queue's redrive policy target
+	VisibilityTimeout time.Duration `envconfig:"VISIBILITY_TIMEOUT" default:"2m"` // How long a dequeued job stays hidden before redelivery
+}
+
+type SQLiteConfig struct {
+	Path         string        `envconfig:"PATH" default:"gopher.db"`   // Database file used when QUEUE_BACKEND=sqlite, shared by server, workers and CLI
+	Driver       string        `envconfig:"DRIVER" default:"sqlite3"`   // database/sql driver name; mattn/go-sqlite3 is bundled and needs cgo
+	BusyTimeout  time.Duration `envconfig:"BUSY_TIMEOUT" default:"5s"`  // How long a write waits for another process's lock
+	LeaseTimeout time.Duration `envconfig:"LEASE_TIMEOUT" default:"5m"` // How long a dequeued job stays claimed before another worker may take it
 }
 
 type WorkerConfig struct {
-	Concurrency     int           `envconfig:"CONCURRENCY" default:"5"`
-	PollInterval    time.Duration `envconfig:"POLL_INTERVAL" default:"1s"`
-	MaxRetries      int           `envconfig:"MAX_RETRIES" default:"3"`
-	ShutdownTimeout time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"30s"`
+	Concurrency       int           `envconfig:"CONCURRENCY" default:"5"`
+	PollInterval      time.Duration `envconfig:"POLL_INTERVAL" default:"1s"`
+	PollTimeout       time.Duration `envconfig:"POLL_TIMEOUT" default:"5s"`     // Long-poll (BRPOP) timeout while waiting for jobs
+	DequeueTimeout    time.Duration `envconfig:"DEQUEUE_TIMEOUT" default:"30s"` // Allowance on top of POLL_TIMEOUT before a stuck dequeue is abandoned
+	JobTimeout        time.Duration `envconfig:"JOB_TIMEOUT" default:"30s"`     // How long a handler may run before its context is cancelled
+	MaxRetries        int           `envconfig:"MAX_RETRIES" default:"3"`
+	ShutdownTimeout   time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"30s"`
+	HealthAddress     string        `envconfig:"HEALTH_ADDRESS" default:":8081"` // Empty disables the health server
+	HeartbeatInterval time.Duration `envconfig:"HEARTBEAT_INTERVAL" default:"10s"`
+	Queue             string        `envconfig:"QUEUE" default:""`           // Physical queue to consume, empty for the default queue
+	Queues            []string      `envconfig:"QUEUES" default:""`          // Additional physical queues to consume, polled after Queue
+	MaxAge            string        `envconfig:"MAX_AGE" default:""`         // JSON object of job type to max age at dequeue, e.g. {"otp_email":"5m"}
+	TypeJobTimeout    string        `envconfig:"JOB_TIMEOUTS" default:""`    // JSON object of job type to handler timeout, e.g. {"report":"2h"}; overrides JOB_TIMEOUT
+	ParkingQueue      string        `envconfig:"PARKING_QUEUE" default:""`   // Named queue for jobs whose payload version the handler doesn't accept; empty dead-letters them
+	Quarantine        bool          `envconfig:"QUARANTINE" default:"false"` // Hold untrusted, schema-violating and poison jobs for review instead of dead-lettering them; needs the redis backend
+}
+
+// MaxAges parses the per job type max age at dequeue
+func (w WorkerConfig) MaxAges() (map[string]time.Duration, error) {
+	maxAges := make(map[string]time.Duration)
+	if strings.TrimSpace(w.MaxAge) == "" {
+		return maxAges, nil
+	}
+
+	var raw map[string]string
+	if err := json.Unmarshal([]byte(w.MaxAge), &raw); err != nil {
+		return nil, fmt.Errorf("invalid max age config: %w", err)
+	}
+
+	for jobType, value := range raw {
+		maxAge, err := time.ParseDuration(value)
+		if err != nil || maxAge <= 0 {
+			return nil, fmt.Errorf("invalid max age for %s: %q", jobType, value)
+		}
+		maxAges[jobType] = maxAge
+	}
+
+	return maxAges, nil
+}
+
+// JobTimeouts parses the per job type handler timeouts
+func (w WorkerConfig) JobTimeouts() (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	if strings.TrimSpace(w.TypeJobTimeout) == "" {
+		return timeouts, nil
+	}
+
+	var raw map[string]string
+	if err := json.Unmarshal([]byte(w.TypeJobTimeout), &raw); err != nil {
+		return nil, fmt.Errorf("invalid job timeout config: %w", err)
+	}
+
+	for jobType, value := range raw {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid job timeout for %s: %q", jobType, value)
+		}
+		timeouts[jobType] = timeout
+	}
+
+	return timeouts, nil
 }
 
 type LogConfig struct {
@@ -40,6 +206,480 @@ type LogConfig struct {
 	Format string `envconfig:"FORMAT" default:"console"` // json in prod
 }
 
+type EncryptionConfig struct {
+	Keys      string `envconfig:"KEYS" default:""`       // Comma separated id:base64key pairs
+	ActiveKey string `envconfig:"ACTIVE_KEY" default:""` // Defaults to the last key in Keys
+}
+
+// Enabled reports whether payload encryption is configured
+func (e EncryptionConfig) Enabled() bool {
+	return e.Keys != ""
+}
+
+// Keyring builds the payload keyring, or returns nil when encryption is disabled
+func (e EncryptionConfig) Keyring() (*encryption.Keyring, error) {
+	if !e.Enabled() {
+		return nil, nil
+	}
+	return encryption.ParseKeyring(e.Keys, e.ActiveKey)
+}
+
+type SigningConfig struct {
+	Algorithm     string `envconfig:"ALGORITHM" default:"hmac"`       // hmac or ed25519
+	Keys          string `envconfig:"KEYS" default:""`                // Comma separated id:base64key pairs; HMAC secrets or Ed25519 private keys
+	VerifyKeys    string `envconfig:"VERIFY_KEYS" default:""`         // Ed25519 public keys, for processes that only verify
+	ActiveKey     string `envconfig:"ACTIVE_KEY" default:""`          // Defaults to the last key in Keys
+	AllowUnsigned bool   `envconfig:"ALLOW_UNSIGNED" default:"false"` // Run unsigned jobs, while rolling signing out
+}
+
+// Enabled reports whether job signing is configured
+func (s SigningConfig) Enabled() bool {
+	return s.Keys != "" || s.VerifyKeys != ""
+}
+
+// Signer builds the job signer, or returns nil when signing is disabled
+func (s SigningConfig) Signer() (*encryption.Signer, error) {
+	if !s.Enabled() {
+		return nil, nil
+	}
+	return encryption.ParseSigner(s.Algorithm, s.Keys, s.VerifyKeys, s.ActiveKey)
+}
+
+type AuthConfig struct {
+	Providers                 string        `envconfig:"PROVIDERS" default:""`           // Comma separated, tried in order: apikey, jwt, introspection, mtls; empty disables auth
+	APIKeys                   string        `envconfig:"API_KEYS" default:""`            // Comma separated name:key[:role[:types]] entries, types |-separated
+	JWTSecret                 string        `envconfig:"JWT_SECRET" default:""`          // HS256 signing secret
+	JWTPublicKeyFile          string        `envconfig:"JWT_PUBLIC_KEY_FILE" default:""` // RS256 public key in PEM, instead of a secret
+	JWTIssuer                 string        `envconfig:"JWT_ISSUER" default:""`
+	JWTAudience               string        `envconfig:"JWT_AUDIENCE" default:""`
+	RolesClaim                string        `envconfig:"ROLES_CLAIM" default:"roles"` // Token claim listing the caller's roles
+	IntrospectionURL          string        `envconfig:"INTROSPECTION_URL" default:""`
+	IntrospectionClientID     string        `envconfig:"INTROSPECTION_CLIENT_ID" default:""`
+	IntrospectionClientSecret string        `envconfig:"INTROSPECTION_CLIENT_SECRET" default:""`
+	IntrospectionCacheTTL     time.Duration `envconfig:"INTROSPECTION_CACHE_TTL" default:"1m"`
+
+	// Browser login through OpenID Connect, enabled when OIDC_ISSUER_URL is set
+	OIDCIssuerURL    string        `envconfig:"OIDC_ISSUER_URL" default:""`
+	OIDCClientID     string        `envconfig:"OIDC_CLIENT_ID" default:""`
+	OIDCClientSecret string        `envconfig:"OIDC_CLIENT_SECRET" default:""`
+	OIDCRedirectURL  string        `envconfig:"OIDC_REDIRECT_URL" default:""` // e.g. https://gopher.example.com/auth/callback
+	OIDCScopes       string        `envconfig:"OIDC_SCOPES" default:"profile,email"`
+	OIDCGroupsClaim  string        `envconfig:"OIDC_GROUPS_CLAIM" default:"groups"`
+	OIDCRoleMapping  string        `envconfig:"OIDC_ROLE_MAPPING" default:""` // Comma separated group=role pairs, e.g. platform-admins=admin,engineering=user
+	SessionSecret    string        `envconfig:"SESSION_SECRET" default:""`    // At least 32 bytes, signs session cookies
+	SessionTTL       time.Duration `envconfig:"SESSION_TTL" default:"8h"`
+	SessionSecure    bool          `envconfig:"SESSION_SECURE" default:"true"` // Send session cookies over HTTPS only
+}
+
+// OIDCEnabled reports whether browser login through OIDC is configured
+func (a AuthConfig) OIDCEnabled() bool {
+	return a.OIDCIssuerURL != ""
+}
+
+// Sessions builds the session cookie manager used by OIDC logins
+func (a AuthConfig) Sessions() (*auth.SessionManager, error) {
+	return auth.NewSessionManager([]byte(a.SessionSecret), a.SessionTTL, a.SessionSecure)
+}
+
+// OIDC discovers the configured OpenID provider
+func (a AuthConfig) OIDC(ctx context.Context) (*auth.OIDC, error) {
+	mapping, err := auth.ParseRoleMapping(a.OIDCRoleMapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var scopes []string
+	for _, scope := range strings.Split(a.OIDCScopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return auth.NewOIDC(ctx, auth.OIDCOptions{
+		IssuerURL:    a.OIDCIssuerURL,
+		ClientID:     a.OIDCClientID,
+		ClientSecret: a.OIDCClientSecret,
+		RedirectURL:  a.OIDCRedirectURL,
+		Scopes:       scopes,
+		GroupsClaim:  a.OIDCGroupsClaim,
+		RoleMapping:  mapping,
+	})
+}
+
+// Provider builds the configured authentication providers, or returns nil when auth is disabled
+func (a AuthConfig) Provider() (auth.Provider, error) {
+	var chain auth.Chain
+
+	// Browser sessions from OIDC logins are checked first
+	if a.OIDCEnabled() {
+		sessions, err := a.Sessions()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := auth.ParseRoleMapping(a.OIDCRoleMapping); err != nil {
+			return nil, err
+		}
+		chain = append(chain, sessions)
+	}
+
+	for _, name := range strings.Split(a.Providers, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+			continue
+		case "apikey":
+			provider, err := auth.ParseAPIKeys(a.APIKeys)
+			if err != nil {
+				return nil, fmt.Errorf("invalid API keys: %w", err)
+			}
+			chain = append(chain, provider)
+		case "jwt":
+			opts := auth.JWTOptions{
+				Secret:     []byte(a.JWTSecret),
+				Issuer:     a.JWTIssuer,
+				Audience:   a.JWTAudience,
+				RolesClaim: a.RolesClaim,
+			}
+			if a.JWTPublicKeyFile != "" {
+				pem, err := os.ReadFile(a.JWTPublicKeyFile)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read JWT public key: %w", err)
+				}
+				opts.PublicKeyPEM = pem
+			}
+			provider, err := auth.NewJWTProvider(opts)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, provider)
+		case "introspection":
+			provider, err := auth.NewIntrospectionProvider(auth.IntrospectionOptions{
+				URL:          a.IntrospectionURL,
+				ClientID:     a.IntrospectionClientID,
+				ClientSecret: a.IntrospectionClientSecret,
+				RolesClaim:   a.RolesClaim,
+				CacheTTL:     a.IntrospectionCacheTTL,
+			})
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, provider)
+		case "mtls":
+			chain = append(chain, auth.NewMTLSProvider())
+		default:
+			return nil, fmt.Errorf("unknown auth provider %q", name)
+		}
+	}
+
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return chain, nil
+}
+
+type RedactionConfig struct {
+	Rules string `envconfig:"RULES" default:""` // JSON object of job type to field paths, "*" for all types
+}
+
+// Redactor builds the payload redactor from the configured rules
+func (r RedactionConfig) Redactor() (*redact.Redactor, error) {
+	rules, err := redact.ParseRules(r.Rules)
+	if err != nil {
+		return nil, err
+	}
+	return redact.NewRedactor(rules), nil
+}
+
+type HistoryConfig struct {
+	Enabled          bool          `envconfig:"ENABLED" default:"true"`
+	Retention        time.Duration `envconfig:"RETENTION" default:"24h"`          // Live records are archived after this
+	ArchiveRetention time.Duration `envconfig:"ARCHIVE_RETENTION" default:"168h"` // Archived records are dropped after this
+	SweepInterval    time.Duration `envconfig:"SWEEP_INTERVAL" default:"1m"`
+}
+
+type ResultsConfig struct {
+	Enabled bool          `envconfig:"ENABLED" default:"true"`
+	TTL     time.Duration `envconfig:"TTL" default:"72h"` // How long job results stay queryable
+}
+
+type StatusConfig struct {
+	Enabled bool          `envconfig:"ENABLED" default:"true"`
+	TTL     time.Duration `envconfig:"TTL" default:"24h"` // How long a job's state stays queryable after its last transition
+}
+
+type DepsConfig struct {
+	Enabled    bool          `envconfig:"ENABLED" default:"true"`
+	OutcomeTTL time.Duration `envconfig:"OUTCOME_TTL" default:"168h"` // How long a finished job still releases jobs submitted to depend on it
+}
+
+type WorkflowsConfig struct {
+	Enabled bool          `envconfig:"ENABLED" default:"true"`
+	TTL     time.Duration `envconfig:"TTL" default:"168h"` // How long a workflow is kept after its last progress
+}
+
+type IdempotencyConfig struct {
+	Enabled bool          `envconfig:"ENABLED" default:"true"`
+	TTL     time.Duration `envconfig:"TTL" default:"24h"` // How long a repeated Idempotency-Key returns the original job
+}
+
+type MetricsConfig struct {
+	Address    string `envconfig:"ADDRESS" default:""`     // Dedicated Prometheus listener, empty disables it
+	SLOTargets string `envconfig:"SLO_TARGETS" default:""` // JSON object of queue to {"objective":0.95,"threshold":"30s"}
+}
+
+type AnomalyConfig struct {
+	Enabled   bool          `envconfig:"ENABLED" default:"false"` // Watch enqueue rate, failure rate and latency for spikes in workers
+	Interval  time.Duration `envconfig:"INTERVAL" default:"1m"`   // How often the signals are sampled
+	Threshold float64       `envconfig:"THRESHOLD" default:"3"`   // z-score from which a sample is anomalous
+	Alpha     float64       `envconfig:"ALPHA" default:"0.1"`     // Weight of the newest sample in the moving average
+	Warmup    int           `envconfig:"WARMUP" default:"10"`     // Samples learned from before anything is flagged
+}
+
+// Detector creates an anomaly detector with the configured tuning
+func (a AnomalyConfig) Detector() *metrics.AnomalyDetector {
+	return metrics.NewAnomalyDetector(metrics.AnomalyConfig{
+		Alpha:     a.Alpha,
+		Threshold: a.Threshold,
+		Warmup:    a.Warmup,
+	})
+}
+
+type ChaosConfig struct {
+	Enabled     bool          `envconfig:"ENABLED" default:"false"` // Opt-in; never enable in production
+	FailureRate float64       `envconfig:"FAILURE_RATE" default:"0"`
+	Latency     time.Duration `envconfig:"LATENCY" default:"0s"` // Maximum added latency per queue operation
+	DropAckRate float64       `envconfig:"DROP_ACK_RATE" default:"0"`
+}
+
+// Settings returns the configured default fault injection settings
+func (c ChaosConfig) Settings() queue.ChaosSettings {
+	return queue.ChaosSettings{
+		FailureRate: c.FailureRate,
+		LatencyMs:   int(c.Latency / time.Millisecond),
+		DropAckRate: c.DropAckRate,
+	}
+}
+
+type TriggersConfig struct {
+	Definitions      string        `envconfig:"DEFINITIONS" default:""`         // JSON list of redis/webhook triggers that enqueue templated jobs
+	WebhookTolerance time.Duration `envconfig:"WEBHOOK_TOLERANCE" default:"5m"` // How far a webhook's X-Gopher-Timestamp may be from the server's clock
+}
+
+// Parse decodes the configured trigger definitions
+func (t TriggersConfig) Parse() ([]trigger.Definition, error) {
+	return trigger.Parse(t.Definitions)
+}
+
+type TemplatesConfig struct {
+	Definitions string `envconfig:"DEFINITIONS" default:""` // JSON list of job templates enqueued as "template:<name>"
+}
+
+// Parse decodes and validates the configured job templates
+func (t TemplatesConfig) Parse() ([]types.JobTemplate, error) {
+	if strings.TrimSpace(t.Definitions) == "" {
+		return nil, nil
+	}
+
+	var templates []types.JobTemplate
+	if err := json.Unmarshal([]byte(t.Definitions), &templates); err != nil {
+		return nil, fmt.Errorf("invalid job template definitions: %w", err)
+	}
+
+	seen := make(map[string]bool, len(templates))
+	for i := range templates {
+		tpl := &templates[i]
+		if err := tpl.Validate(); err != nil {
+			return nil, err
+		}
+		if seen[tpl.Name] {
+			return nil, fmt.Errorf("duplicate job template %q", tpl.Name)
+		}
+		seen[tpl.Name] = true
+
+		if tpl.Priority != "" && tpl.Priority != queue.PriorityHigh &&
+			tpl.Priority != queue.PriorityNormal && tpl.Priority != queue.PriorityLow {
+			return nil, fmt.Errorf("template %s: invalid priority %q", tpl.Name, tpl.Priority)
+		}
+	}
+	return templates, nil
+}
+
+type PoliciesConfig struct {
+	Definitions string `envconfig:"DEFINITIONS" default:""` // JSON object of job type to policy, e.g. {"report":{"timeout":"2h","concurrency":2}}
+	File        string `envconfig:"FILE" default:""`        // JSON file with the same object, instead of DEFINITIONS
+}
+
+// Parse loads and validates the per job type policies
+func (p PoliciesConfig) Parse() (types.JobPolicies, error) {
+	definitions := p.Definitions
+	if p.File != "" {
+		if strings.TrimSpace(definitions) != "" {
+			return nil, fmt.Errorf("set either policy definitions or a policy file, not both")
+		}
+		data, err := os.ReadFile(p.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read policy file: %w", err)
+		}
+		definitions = string(data)
+	}
+	if strings.TrimSpace(definitions) == "" {
+		return types.JobPolicies{}, nil
+	}
+
+	var policies types.JobPolicies
+	if err := json.Unmarshal([]byte(definitions), &policies); err != nil {
+		return nil, fmt.Errorf("invalid job policy definitions: %w", err)
+	}
+	if err := policies.Validate(); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+type TransformsConfig struct {
+	Definitions string `envconfig:"DEFINITIONS" default:""` // JSON object of job type ("*" for all) to transforms, e.g. {"report":[{"op":"set","path":"schema_version","value":2}]}
+	File        string `envconfig:"FILE" default:""`        // JSON file with the same object, instead of DEFINITIONS
+}
+
+// Parse loads and validates the enqueue-time payload transforms
+func (t TransformsConfig) Parse() (types.PayloadTransforms, error) {
+	definitions := t.Definitions
+	if t.File != "" {
+		if strings.TrimSpace(definitions) != "" {
+			return nil, fmt.Errorf("set either transform definitions or a transform file, not both")
+		}
+		data, err := os.ReadFile(t.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read transform file: %w", err)
+		}
+		definitions = string(data)
+	}
+	if strings.TrimSpace(definitions) == "" {
+		return types.PayloadTransforms{}, nil
+	}
+
+	var transforms types.PayloadTransforms
+	if err := json.Unmarshal([]byte(definitions), &transforms); err != nil {
+		return nil, fmt.Errorf("invalid payload transform definitions: %w", err)
+	}
+	if err := transforms.Validate(); err != nil {
+		return nil, err
+	}
+	return transforms, nil
+}
+
+type IngestConfig struct {
+	SQSQueueURL        string        `envconfig:"SQS_QUEUE_URL" default:""`       // SQS queue receiving S3 event notifications
+	SQSWait            time.Duration `envconfig:"SQS_WAIT" default:"20s"`         // ReceiveMessage long-poll time, at most 20s
+	PubSubSubscription string        `envconfig:"PUBSUB_SUBSCRIPTION" default:""` // projects/<project>/subscriptions/<name> receiving GCS notifications
+	PubSubEndpoint     string        `envconfig:"PUBSUB_ENDPOINT" default:""`     // Override for the Pub/Sub emulator
+	PubSubToken        string        `envconfig:"PUBSUB_TOKEN" default:""`        // Static access token, empty to use the GCP metadata server
+}
+
+type BridgeConfig struct {
+	Topics string `envconfig:"TOPICS" default:""`             // Comma separated topic:job_type pairs consumed by the Kafka bridge
+	Group  string `envconfig:"GROUP" default:"gopher-bridge"` // Consumer group the bridge commits offsets for
+}
+
+// Routes parses the topic to job type mapping
+func (b BridgeConfig) Routes() (map[string]string, error) {
+	routes := make(map[string]string)
+	for _, entry := range strings.Split(b.Topics, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		topic, jobType, ok := strings.Cut(entry, ":")
+		if !ok || topic == "" || jobType == "" {
+			return nil, fmt.Errorf("invalid bridge topic %q, expected topic:job_type", entry)
+		}
+		if _, exists := routes[topic]; exists {
+			return nil, fmt.Errorf("duplicate bridge topic %s", topic)
+		}
+		routes[topic] = jobType
+	}
+	return routes, nil
+}
+
+type SinksConfig struct {
+	KafkaTopic    string `envconfig:"KAFKA_TOPIC" default:""`          // Topic receiving job events, produced through KAFKA_BROKERS
+	WebhookURL    string `envconfig:"WEBHOOK_URL" default:""`          // URL receiving job events as JSON POSTs
+	WebhookSecret string `envconfig:"WEBHOOK_SECRET" default:""`       // Signs webhook bodies in X-Gopher-Signature
+	RedisStream   string `envconfig:"REDIS_STREAM" default:""`         // Redis stream receiving job events
+	StreamMaxLen  int64  `envconfig:"STREAM_MAX_LEN" default:"100000"` // Approximate stream length cap, 0 for unbounded
+	Buffer        int    `envconfig:"BUFFER" default:"1000"`           // Events held for slow sinks before new ones are dropped
+}
+
+// Enabled reports whether any sink is configured
+func (s SinksConfig) Enabled() bool {
+	return s.KafkaTopic != "" || s.WebhookURL != "" || s.RedisStream != ""
+}
+
+type AWSConfig struct {
+	Region          string `envconfig:"REGION" default:""`
+	AccessKeyID     string `envconfig:"ACCESS_KEY_ID" default:""`
+	SecretAccessKey string `envconfig:"SECRET_ACCESS_KEY" default:""`
+	SessionToken    string `envconfig:"SESSION_TOKEN" default:""`
+	SQSEndpoint     string `envconfig:"SQS_ENDPOINT" default:""` // Override for LocalStack or VPC endpoints
+}
+
+// SQSClient creates an SQS client from the AWS settings. Without an access
+// key it falls back to the AWS SDK's default credential chain.
+func (a AWSConfig) SQSClient(ctx context.Context) (*sqs.Client, error) {
+	return sqs.NewClient(ctx, sqs.Options{
+		Region:   a.Region,
+		Endpoint: a.SQSEndpoint,
+		Credentials: sqs.Credentials{
+			AccessKeyID:     a.AccessKeyID,
+			SecretAccessKey: a.SecretAccessKey,
+			SessionToken:    a.SessionToken,
+		},
+	})
+}
+
+type RecordingConfig struct {
+	Enabled    bool    `envconfig:"ENABLED" default:"false"`
+	SampleRate float64 `envconfig:"SAMPLE_RATE" default:"0.01"` // Fraction of API-enqueued jobs recorded for replay
+	MaxEntries int     `envconfig:"MAX_ENTRIES" default:"10000"`
+}
+
+type SpoolConfig struct {
+	Enabled       bool          `envconfig:"ENABLED" default:"false"`     // Hold API enqueues in a local spool while Redis is unreachable
+	Path          string        `envconfig:"PATH" default:""`             // NDJSON file keeping spooled jobs across restarts, empty keeps them in memory only
+	MaxJobs       int           `envconfig:"MAX_JOBS" default:"10000"`    // Enqueues fail once this many jobs are spooled
+	FlushInterval time.Duration `envconfig:"FLUSH_INTERVAL" default:"2s"` // How often spooled jobs are retried against Redis
+}
+
+type SelfCheckConfig struct {
+	Skip         bool          `envconfig:"SKIP" default:"false"`         // Start even when the startup self-check fails, like --skip-checks
+	MaxClockSkew time.Duration `envconfig:"MAX_CLOCK_SKEW" default:"30s"` // Local clock offset from Redis TIME that refuses startup, a tenth of it warns
+}
+
+type RateLimitConfig struct {
+	Prefix string `envconfig:"PREFIX" default:"ratelimit"` // Redis key prefix for per job type limits
+}
+
+type QueueConfig struct {
+	Backend       string `envconfig:"BACKEND" default:"redis"`        // redis, postgres, sqlite, kafka, sqs, memory for local development, or a backend registered with queue.RegisterBackend
+	Priority      bool   `envconfig:"PRIORITY" default:"false"`       // Use the high/normal/low priority queues
+	PriorityRatio string `envconfig:"PRIORITY_RATIO" default:"5:3:1"` // Dequeue ratio high:normal:low, overridable at runtime
+}
+
+// Queue backends selectable with QUEUE_BACKEND
+const (
+	QueueBackendRedis    = "redis"
+	QueueBackendPostgres = "postgres"
+	QueueBackendSQLite   = "sqlite" // Single node sharing one database file; needs a cgo build
+	QueueBackendKafka    = "kafka"  // Plaintext listeners only: the bundled client has no TLS or SASL
+	QueueBackendSQS      = "sqs"    // Uses the AWS SDK's default credential chain
+	QueueBackendMemory   = "memory" // In-process only; the server runs its own workers
+)
+
+// Ratio parses the configured priority ratio
+func (q QueueConfig) Ratio() (queue.PriorityRatio, error) {
+	return queue.ParsePriorityRatio(q.PriorityRatio)
+}
+
 // Address returns the full server address
 func (s ServerConfig) Address() string {
 	return fmt.Sprintf("%s:%d", s.Host, s.Port)
@@ -67,13 +707,257 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
 
+	if _, err := c.Server.DisplayLocation(); err != nil {
+		return err
+	}
+
+	if len(c.Redis.ClusterAddrs) > 0 && c.Redis.DB != 0 {
+		return fmt.Errorf("redis cluster only has database 0, got REDIS_DB=%d", c.Redis.DB)
+	}
+	if (c.Redis.SentinelMaster != "") != (len(c.Redis.SentinelAddrs) > 0) {
+		return fmt.Errorf("redis sentinel needs both REDIS_SENTINEL_MASTER and REDIS_SENTINEL_ADDRS")
+	}
+	if c.Redis.SentinelMaster != "" && len(c.Redis.ClusterAddrs) > 0 {
+		return fmt.Errorf("redis sentinel and cluster can't be used together")
+	}
+	if (c.Redis.TLSCert != "") != (c.Redis.TLSKey != "") {
+		return fmt.Errorf("redis TLS client auth needs both REDIS_TLS_CERT and REDIS_TLS_KEY")
+	}
+	if c.Redis.RetryAttempts < 1 {
+		return fmt.Errorf("redis retry attempts must be at least 1, got: %d", c.Redis.RetryAttempts)
+	}
+	if c.Redis.RetryBackoff <= 0 {
+		return fmt.Errorf("redis retry backoff must be positive, got: %v", c.Redis.RetryBackoff)
+	}
+	if c.Redis.BreakerThreshold < 0 {
+		return fmt.Errorf("redis breaker threshold cannot be negative, got: %d", c.Redis.BreakerThreshold)
+	}
+	if c.Redis.BreakerThreshold > 0 && c.Redis.BreakerCooldown <= 0 {
+		return fmt.Errorf("redis breaker cooldown must be positive, got: %v", c.Redis.BreakerCooldown)
+	}
+	if err := queue.ValidateCompression(c.Redis.Compression); err != nil {
+		return err
+	}
+	if c.Redis.CompressionThreshold <= 0 {
+		return fmt.Errorf("redis compression threshold must be positive, got: %d", c.Redis.CompressionThreshold)
+	}
+
 	if c.Worker.Concurrency <= 0 {
 		return fmt.Errorf("worker Concurrency must be positive, got: %d", c.Worker.Concurrency)
 	}
 
+	if c.Worker.DequeueTimeout <= 0 {
+		return fmt.Errorf("worker dequeue timeout must be positive, got: %v", c.Worker.DequeueTimeout)
+	}
+
+	if c.Worker.JobTimeout <= 0 {
+		return fmt.Errorf("worker job timeout must be positive, got: %v", c.Worker.JobTimeout)
+	}
+
+	if c.Worker.HeartbeatInterval <= 0 {
+		return fmt.Errorf("worker heartbeat interval must be positive, got: %v", c.Worker.HeartbeatInterval)
+	}
+
+	for _, name := range append([]string{c.Worker.Queue, c.Worker.ParkingQueue}, c.Worker.Queues...) {
+		if name == "" {
+			continue
+		}
+		if err := types.ValidateQueueName(name); err != nil {
+			return fmt.Errorf("invalid worker queue: %w", err)
+		}
+	}
+
+	if _, err := c.Worker.MaxAges(); err != nil {
+		return err
+	}
+	if _, err := c.Worker.JobTimeouts(); err != nil {
+		return err
+	}
+
+	if c.Worker.PollTimeout < time.Second || c.Worker.PollTimeout > time.Minute {
+		return fmt.Errorf("worker poll timeout must be between 1s and 1m, got: %s", c.Worker.PollTimeout)
+	}
+
+	if c.Results.Enabled && c.Results.TTL <= 0 {
+		return fmt.Errorf("results TTL must be positive, got: %s", c.Results.TTL)
+	}
+	if c.Status.Enabled && c.Status.TTL <= 0 {
+		return fmt.Errorf("status TTL must be positive, got: %s", c.Status.TTL)
+	}
+
+	if c.Deps.Enabled && c.Deps.OutcomeTTL <= 0 {
+		return fmt.Errorf("dependency outcome TTL must be positive, got: %s", c.Deps.OutcomeTTL)
+	}
+	if c.Workflows.Enabled && c.Workflows.TTL <= 0 {
+		return fmt.Errorf("workflow TTL must be positive, got: %s", c.Workflows.TTL)
+	}
+	if c.Idempotency.Enabled && c.Idempotency.TTL <= 0 {
+		return fmt.Errorf("idempotency TTL must be positive, got: %s", c.Idempotency.TTL)
+	}
+
 	if c.Worker.MaxRetries < 0 {
 		return fmt.Errorf("max retries cannot be negative, got: %d", c.Worker.MaxRetries)
 	}
 
+	if _, err := c.Encryption.Keyring(); err != nil {
+		return fmt.Errorf("invalid encryption keys: %w", err)
+	}
+	if _, err := c.Signing.Signer(); err != nil {
+		return fmt.Errorf("invalid signing keys: %w", err)
+	}
+
+	if _, err := c.Redaction.Redactor(); err != nil {
+		return err
+	}
+
+	if _, err := c.Auth.Provider(); err != nil {
+		return fmt.Errorf("invalid auth config: %w", err)
+	}
+
+	if c.History.Enabled && c.History.SweepInterval <= 0 {
+		return fmt.Errorf("history sweep interval must be positive, got: %s", c.History.SweepInterval)
+	}
+
+	if _, err := metrics.ParseSLOTargets(c.Metrics.SLOTargets); err != nil {
+		return err
+	}
+	if c.Anomaly.Enabled {
+		if c.Anomaly.Interval <= 0 {
+			return fmt.Errorf("anomaly interval must be positive, got: %s", c.Anomaly.Interval)
+		}
+		if c.Anomaly.Threshold <= 0 {
+			return fmt.Errorf("anomaly threshold must be positive, got: %v", c.Anomaly.Threshold)
+		}
+		if c.Anomaly.Alpha <= 0 || c.Anomaly.Alpha > 1 {
+			return fmt.Errorf("anomaly alpha must be above 0 and at most 1, got: %v", c.Anomaly.Alpha)
+		}
+		if c.Anomaly.Warmup < 0 {
+			return fmt.Errorf("anomaly warmup cannot be negative, got: %d", c.Anomaly.Warmup)
+		}
+	}
+
+	if c.Recording.Enabled && (c.Recording.SampleRate <= 0 || c.Recording.SampleRate > 1) {
+		return fmt.Errorf("recording sample rate must be between 0 and 1, got: %v", c.Recording.SampleRate)
+	}
+
+	if _, err := c.Queue.Ratio(); err != nil {
+		return fmt.Errorf("invalid queue priority ratio: %w", err)
+	}
+
+	switch c.Queue.Backend {
+	case QueueBackendRedis:
+	case QueueBackendPostgres:
+		if c.Postgres.DSN == "" {
+			return fmt.Errorf("the postgres queue backend needs POSTGRES_DSN")
+		}
+		if c.Queue.Priority {
+			return fmt.Errorf("priority queues need the redis queue backend")
+		}
+	case QueueBackendSQLite:
+		if c.SQLite.Path == "" {
+			return fmt.Errorf("the sqlite queue backend needs SQLITE_PATH")
+		}
+		if c.SQLite.LeaseTimeout <= c.Worker.JobTimeout {
+			return fmt.Errorf("sqlite lease timeout must exceed the worker job timeout (%v), got: %v", c.Worker.JobTimeout, c.SQLite.LeaseTimeout)
+		}
+		if c.Queue.Priority {
+			return fmt.Errorf("priority queues need the redis queue backend")
+		}
+	case QueueBackendKafka:
+		if len(c.Kafka.Brokers) == 0 || c.Kafka.Topic == "" || c.Kafka.Group == "" {
+			return fmt.Errorf("the kafka queue backend needs KAFKA_BROKERS, KAFKA_TOPIC and KAFKA_GROUP")
+		}
+		if c.Queue.Priority {
+			return fmt.Errorf("priority queues need the redis queue backend")
+		}
+	case QueueBackendSQS:
+		if c.SQS.QueueURL == "" {
+			return fmt.Errorf("the sqs queue backend needs SQS_QUEUE_URL")
+		}
+		if c.SQS.VisibilityTimeout < time.Second || c.SQS.VisibilityTimeout > 12*time.Hour {
+			return fmt.Errorf("sqs visibility timeout must be between 1s and 12h, got: %v", c.SQS.VisibilityTimeout)
+		}
+		if c.Queue.Priority {
+			return fmt.Errorf("priority queues need the redis queue backend")
+		}
+	case QueueBackendMemory:
+		if c.Queue.Priority {
+			return fmt.Errorf("priority queues need the redis queue backend")
+		}
+	default:
+		if !queue.BackendRegistered(c.Queue.Backend) {
+			registered := "none"
+			if names := queue.Backends(); len(names) > 0 {
+				registered = strings.Join(names, ", ")
+			}
+			return fmt.Errorf("invalid queue backend: %q (must be redis, postgres, sqlite, kafka, sqs, memory or a registered backend; registered: %s)",
+				c.Queue.Backend, registered)
+		}
+		if c.Queue.Priority {
+			return fmt.Errorf("priority queues need the redis queue backend")
+		}
+	}
+
+	triggers, err := c.Triggers.Parse()
+	if err != nil {
+		return err
+	}
+	if c.Triggers.WebhookTolerance <= 0 {
+		return fmt.Errorf("trigger webhook tolerance must be positive, got: %s", c.Triggers.WebhookTolerance)
+	}
+	for _, def := range triggers {
+		if def.Source == trigger.SourceRedis && c.Queue.Backend != QueueBackendRedis {
+			return fmt.Errorf("trigger %s: redis triggers need the redis queue backend", def.Name)
+		}
+	}
+
+	if _, err := c.Templates.Parse(); err != nil {
+		return err
+	}
+
+	if _, err := c.Policies.Parse(); err != nil {
+		return err
+	}
+
+	if c.Ingest.SQSWait < 0 || c.Ingest.SQSWait > 20*time.Second {
+		return fmt.Errorf("ingest SQS wait must be between 0 and 20s, got: %s", c.Ingest.SQSWait)
+	}
+
+	if _, err := c.Bridge.Routes(); err != nil {
+		return err
+	}
+
+	if c.Sinks.KafkaTopic != "" && len(c.Kafka.Brokers) == 0 {
+		return fmt.Errorf("the kafka sink needs KAFKA_BROKERS")
+	}
+	if c.Sinks.RedisStream != "" && c.Queue.Backend != QueueBackendRedis {
+		return fmt.Errorf("the redis stream sink needs the redis queue backend")
+	}
+	if c.Worker.Quarantine && c.Queue.Backend != QueueBackendRedis {
+		return fmt.Errorf("the quarantine needs the redis queue backend")
+	}
+
+	if c.Spool.Enabled {
+		if c.Queue.Backend != QueueBackendRedis {
+			return fmt.Errorf("the enqueue spool needs the redis queue backend")
+		}
+		if c.Spool.MaxJobs <= 0 {
+			return fmt.Errorf("spool max jobs must be positive, got: %d", c.Spool.MaxJobs)
+		}
+		if c.Spool.FlushInterval <= 0 {
+			return fmt.Errorf("spool flush interval must be positive, got: %v", c.Spool.FlushInterval)
+		}
+	}
+
+	if c.SelfCheck.MaxClockSkew < 0 {
+		return fmt.Errorf("self-check max clock skew cannot be negative, got: %v", c.SelfCheck.MaxClockSkew)
+	}
+
+	if c.Chaos.Enabled {
+		if err := c.Chaos.Settings().Validate(); err != nil {
+			return fmt.Errorf("invalid chaos settings: %w", err)
+		}
+	}
+
 	return nil
 }
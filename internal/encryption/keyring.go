@@ -0,0 +1,133 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Keyring holds every key version that may still be needed to decrypt
+// payloads, plus the active version used for new encryptions
+type Keyring struct {
+	activeID string
+	keys     map[string]cipher.AEAD
+}
+
+// NewKeyring builds a keyring from raw AES keys indexed by key ID
+func NewKeyring(keys map[string][]byte, activeID string) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("keyring requires at least one key")
+	}
+	if _, ok := keys[activeID]; !ok {
+		return nil, fmt.Errorf("active key %q is not in the keyring", activeID)
+	}
+
+	aeads := make(map[string]cipher.AEAD, len(keys))
+	for id, key := range keys {
+		if id == "" {
+			return nil, fmt.Errorf("key ID cannot be empty")
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", id, err)
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCM for key %q: %w", id, err)
+		}
+		aeads[id] = aead
+	}
+
+	return &Keyring{
+		activeID: activeID,
+		keys:     aeads,
+	}, nil
+}
+
+// ParseKeyring parses a "id:base64key,id:base64key" spec into a keyring.
+// When activeID is empty the last key in the spec is used.
+func ParseKeyring(spec, activeID string) (*Keyring, error) {
+	keys := make(map[string][]byte)
+	lastID := ""
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid key entry %q, expected id:base64key", entry)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 for key %q: %w", id, err)
+		}
+
+		keys[id] = key
+		lastID = id
+	}
+
+	if activeID == "" {
+		activeID = lastID
+	}
+
+	return NewKeyring(keys, activeID)
+}
+
+// ActiveKeyID returns the key ID used for new encryptions
+func (k *Keyring) ActiveKeyID() string {
+	return k.activeID
+}
+
+// KeyIDs returns all key IDs in the keyring
+func (k *Keyring) KeyIDs() []string {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Encrypt seals plaintext with the active key and returns the key ID used
+func (k *Keyring) Encrypt(plaintext []byte) (string, []byte, error) {
+	aead := k.keys[k.activeID]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	// Nonce is prepended to the ciphertext
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+	return k.activeID, sealed, nil
+}
+
+// Decrypt opens ciphertext produced by Encrypt with the given key version
+func (k *Keyring) Decrypt(keyID string, ciphertext []byte) ([]byte, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown encryption key %q", keyID)
+	}
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt with key %q: %w", keyID, err)
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,189 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func keySpec(entries ...string) string {
+	return strings.Join(entries, ",")
+}
+
+func keyEntry(id string, key []byte) string {
+	return id + ":" + base64.StdEncoding.EncodeToString(key)
+}
+
+func TestParseKeyring(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       string
+		activeID   string
+		wantActive string
+		wantIDs    []string
+		wantErr    string
+	}{
+		{
+			name:       "last key is active by default",
+			spec:       keySpec(keyEntry("v1", testKey(1)), keyEntry("v2", testKey(2))),
+			wantActive: "v2",
+			wantIDs:    []string{"v1", "v2"},
+		},
+		{
+			name:       "explicit active key",
+			spec:       keySpec(keyEntry("v1", testKey(1)), keyEntry("v2", testKey(2))),
+			activeID:   "v1",
+			wantActive: "v1",
+			wantIDs:    []string{"v1", "v2"},
+		},
+		{
+			name:       "blank entries and spaces are skipped",
+			spec:       " " + keyEntry("v1", testKey(1)) + " ,, ",
+			wantActive: "v1",
+			wantIDs:    []string{"v1"},
+		},
+		{
+			name:    "empty spec",
+			spec:    "",
+			wantErr: "at least one key",
+		},
+		{
+			name:    "missing separator",
+			spec:    "v1",
+			wantErr: "expected id:base64key",
+		},
+		{
+			name:    "bad base64",
+			spec:    "v1:not-base64!",
+			wantErr: "invalid base64",
+		},
+		{
+			name:    "wrong key size",
+			spec:    keyEntry("v1", testKey(1)[:10]),
+			wantErr: "invalid key",
+		},
+		{
+			name:     "unknown active key",
+			spec:     keyEntry("v1", testKey(1)),
+			activeID: "v9",
+			wantErr:  "not in the keyring",
+		},
+		{
+			name:    "empty key ID",
+			spec:    keyEntry("", testKey(1)),
+			wantErr: "cannot be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := ParseKeyring(tt.spec, tt.activeID)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseKeyring error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseKeyring: %v", err)
+			}
+			if got := keyring.ActiveKeyID(); got != tt.wantActive {
+				t.Errorf("ActiveKeyID = %q, want %q", got, tt.wantActive)
+			}
+			if got := strings.Join(keyring.KeyIDs(), ","); got != strings.Join(tt.wantIDs, ",") {
+				t.Errorf("KeyIDs = %s, want %s", got, strings.Join(tt.wantIDs, ","))
+			}
+		})
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	old, err := NewKeyring(map[string][]byte{"v1": testKey(1)}, "v1")
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	rotated, err := NewKeyring(map[string][]byte{"v1": testKey(1), "v2": testKey(2)}, "v2")
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	retired, err := NewKeyring(map[string][]byte{"v2": testKey(2)}, "v2")
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+
+	plaintext := []byte(`{"card":"4111111111111111"}`)
+	oldID, oldSealed, err := old.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	newID, newSealed, err := rotated.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if oldID != "v1" || newID != "v2" {
+		t.Fatalf("key IDs = %q, %q; want v1, v2", oldID, newID)
+	}
+
+	tests := []struct {
+		name       string
+		keyring    *Keyring
+		keyID      string
+		ciphertext []byte
+		wantErr    string
+	}{
+		{name: "old key after rotation", keyring: rotated, keyID: oldID, ciphertext: oldSealed},
+		{name: "new key after rotation", keyring: rotated, keyID: newID, ciphertext: newSealed},
+		{name: "retired key", keyring: retired, keyID: oldID, ciphertext: oldSealed, wantErr: "unknown encryption key"},
+		{name: "wrong key ID", keyring: rotated, keyID: "v2", ciphertext: oldSealed, wantErr: "failed to decrypt"},
+		{name: "tampered ciphertext", keyring: rotated, keyID: newID, ciphertext: flipLastByte(newSealed), wantErr: "failed to decrypt"},
+		{name: "truncated ciphertext", keyring: rotated, keyID: newID, ciphertext: newSealed[:4], wantErr: "too short"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.keyring.Decrypt(tt.keyID, tt.ciphertext)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Decrypt error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decrypt: %v", err)
+			}
+			if !bytes.Equal(got, plaintext) {
+				t.Fatalf("Decrypt = %q, want %q", got, plaintext)
+			}
+		})
+	}
+}
+
+func TestKeyringNonces(t *testing.T) {
+	keyring, err := NewKeyring(map[string][]byte{"v1": testKey(1)}, "v1")
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+
+	_, first, err := keyring.Encrypt([]byte("same"))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	_, second, err := keyring.Encrypt([]byte("same"))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if bytes.Equal(first, second) {
+		t.Fatal("encrypting the same plaintext twice gave the same ciphertext")
+	}
+}
+
+func flipLastByte(b []byte) []byte {
+	flipped := append([]byte(nil), b...)
+	flipped[len(flipped)-1] ^= 0xff
+	return flipped
+}
@@ -0,0 +1,181 @@
+package encryption
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Signature algorithms
+const (
+	SignHMAC    = "hmac"    // HMAC-SHA256; producers and workers share the secret
+	SignEd25519 = "ed25519" // Producers hold the private key, workers only the public key
+)
+
+// ErrBadSignature is returned for signatures that are missing, malformed or
+// don't match the message
+var ErrBadSignature = errors.New("invalid signature")
+
+// Signer signs messages with its active key and verifies signatures made
+// with any of its keys. A signer built from public keys alone only verifies.
+type Signer struct {
+	algorithm  string
+	activeID   string
+	secrets    map[string][]byte            // HMAC
+	privateKey ed25519.PrivateKey           // Ed25519 active key, nil when verifying only
+	publicKeys map[string]ed25519.PublicKey // Ed25519
+}
+
+// ParseSigner builds a signer from "id:base64key" lists. For HMAC, keys
+// are the shared secrets. For Ed25519, keys are private keys (32 byte seeds
+// or 64 byte keys) for producers and verifyKeys are public keys for
+// processes that only verify. When activeID is empty the last key is used.
+func ParseSigner(algorithm, keys, verifyKeys, activeID string) (*Signer, error) {
+	private, lastID, err := parseKeyList(keys)
+	if err != nil {
+		return nil, err
+	}
+	public, _, err := parseKeyList(verifyKeys)
+	if err != nil {
+		return nil, err
+	}
+	if activeID == "" {
+		activeID = lastID
+	}
+	if len(private) == 0 && len(public) == 0 {
+		return nil, fmt.Errorf("signing requires at least one key")
+	}
+	if _, ok := private[activeID]; len(private) > 0 && !ok {
+		return nil, fmt.Errorf("active signing key %q is not in the keys", activeID)
+	}
+
+	s := &Signer{algorithm: algorithm, activeID: activeID}
+	switch algorithm {
+	case SignHMAC:
+		if len(public) > 0 {
+			return nil, fmt.Errorf("hmac signing takes shared keys, not verify keys")
+		}
+		for id, secret := range private {
+			if len(secret) < 32 {
+				return nil, fmt.Errorf("hmac signing key %q must be at least 32 bytes", id)
+			}
+		}
+		s.secrets = private
+	case SignEd25519:
+		s.publicKeys = make(map[string]ed25519.PublicKey, len(private)+len(public))
+		for id, key := range public {
+			if len(key) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("ed25519 verify key %q must be %d bytes", id, ed25519.PublicKeySize)
+			}
+			s.publicKeys[id] = ed25519.PublicKey(key)
+		}
+		for id, key := range private {
+			var privateKey ed25519.PrivateKey
+			switch len(key) {
+			case ed25519.SeedSize:
+				privateKey = ed25519.NewKeyFromSeed(key)
+			case ed25519.PrivateKeySize:
+				privateKey = ed25519.PrivateKey(key)
+			default:
+				return nil, fmt.Errorf("ed25519 signing key %q must be a %d byte seed or %d byte key", id, ed25519.SeedSize, ed25519.PrivateKeySize)
+			}
+			s.publicKeys[id] = privateKey.Public().(ed25519.PublicKey)
+			if id == activeID {
+				s.privateKey = privateKey
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q, expected %s or %s", algorithm, SignHMAC, SignEd25519)
+	}
+	return s, nil
+}
+
+func parseKeyList(spec string) (map[string][]byte, string, error) {
+	keys := make(map[string][]byte)
+	lastID := ""
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, "", fmt.Errorf("invalid signing key entry %q, expected id:base64key", entry)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid base64 for signing key %q: %w", id, err)
+		}
+		if _, exists := keys[id]; exists {
+			return nil, "", fmt.Errorf("duplicate signing key %q", id)
+		}
+		keys[id] = key
+		lastID = id
+	}
+	return keys, lastID, nil
+}
+
+// Algorithm returns the signature algorithm
+func (s *Signer) Algorithm() string {
+	return s.algorithm
+}
+
+// CanSign reports whether the signer holds a key to sign with
+func (s *Signer) CanSign() bool {
+	return s.secrets != nil || s.privateKey != nil
+}
+
+// Sign returns "keyID:base64signature" for message, made with the active key
+func (s *Signer) Sign(message []byte) (string, error) {
+	var signature []byte
+	switch {
+	case s.secrets != nil:
+		mac := hmac.New(sha256.New, s.secrets[s.activeID])
+		mac.Write(message)
+		signature = mac.Sum(nil)
+	case s.privateKey != nil:
+		signature = ed25519.Sign(s.privateKey, message)
+	default:
+		return "", fmt.Errorf("signer has only verify keys")
+	}
+	return s.activeID + ":" + base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// Verify checks a signature made by Sign with any of the signer's keys
+func (s *Signer) Verify(message []byte, signature string) error {
+	keyID, encoded, ok := strings.Cut(signature, ":")
+	if !ok {
+		return fmt.Errorf("%w: malformed", ErrBadSignature)
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("%w: malformed", ErrBadSignature)
+	}
+
+	if s.secrets != nil {
+		secret, ok := s.secrets[keyID]
+		if !ok {
+			return fmt.Errorf("%w: unknown key %q", ErrBadSignature, keyID)
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(message)
+		if !hmac.Equal(raw, mac.Sum(nil)) {
+			return fmt.Errorf("%w: does not match key %q", ErrBadSignature, keyID)
+		}
+		return nil
+	}
+
+	publicKey, ok := s.publicKeys[keyID]
+	if !ok {
+		return fmt.Errorf("%w: unknown key %q", ErrBadSignature, keyID)
+	}
+	if !ed25519.Verify(publicKey, message, raw) {
+		return fmt.Errorf("%w: does not match key %q", ErrBadSignature, keyID)
+	}
+	return nil
+}
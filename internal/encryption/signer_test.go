@@ -0,0 +1,131 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseSigner(t *testing.T) {
+	seed := bytes.Repeat([]byte{7}, ed25519.SeedSize)
+	public := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+
+	tests := []struct {
+		name       string
+		algorithm  string
+		keys       string
+		verifyKeys string
+		activeID   string
+		wantSign   bool
+		wantErr    string
+	}{
+		{name: "hmac", algorithm: SignHMAC, keys: keyEntry("k1", testKey(1)), wantSign: true},
+		{name: "ed25519 seed", algorithm: SignEd25519, keys: keyEntry("k1", seed), wantSign: true},
+		{name: "ed25519 full private key", algorithm: SignEd25519, keys: keyEntry("k1", ed25519.NewKeyFromSeed(seed)), wantSign: true},
+		{name: "ed25519 verify only", algorithm: SignEd25519, verifyKeys: keyEntry("k1", public), wantSign: false},
+		{name: "no keys", algorithm: SignHMAC, wantErr: "at least one key"},
+		{name: "unknown algorithm", algorithm: "rsa", keys: keyEntry("k1", testKey(1)), wantErr: "unsupported signing algorithm"},
+		{name: "short hmac secret", algorithm: SignHMAC, keys: keyEntry("k1", testKey(1)[:16]), wantErr: "at least 32 bytes"},
+		{name: "hmac verify keys", algorithm: SignHMAC, keys: keyEntry("k1", testKey(1)), verifyKeys: keyEntry("k2", testKey(2)), wantErr: "not verify keys"},
+		{name: "bad ed25519 key size", algorithm: SignEd25519, keys: keyEntry("k1", testKey(1)[:20]), wantErr: "byte seed"},
+		{name: "bad ed25519 verify key size", algorithm: SignEd25519, verifyKeys: keyEntry("k1", testKey(1)[:20]), wantErr: "must be 32 bytes"},
+		{name: "duplicate key", algorithm: SignHMAC, keys: keySpec(keyEntry("k1", testKey(1)), keyEntry("k1", testKey(2))), wantErr: "duplicate"},
+		{name: "unknown active key", algorithm: SignHMAC, keys: keyEntry("k1", testKey(1)), activeID: "k9", wantErr: "not in the keys"},
+		{name: "missing key ID", algorithm: SignHMAC, keys: ":" + base64.StdEncoding.EncodeToString(testKey(1)), wantErr: "expected id:base64key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := ParseSigner(tt.algorithm, tt.keys, tt.verifyKeys, tt.activeID)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseSigner error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSigner: %v", err)
+			}
+			if signer.Algorithm() != tt.algorithm {
+				t.Errorf("Algorithm = %q, want %q", signer.Algorithm(), tt.algorithm)
+			}
+			if signer.CanSign() != tt.wantSign {
+				t.Errorf("CanSign = %v, want %v", signer.CanSign(), tt.wantSign)
+			}
+			if _, err := signer.Sign([]byte("message")); (err == nil) != tt.wantSign {
+				t.Errorf("Sign error = %v, want signing to work: %v", err, tt.wantSign)
+			}
+		})
+	}
+}
+
+func TestSignerVerify(t *testing.T) {
+	seed1 := bytes.Repeat([]byte{1}, ed25519.SeedSize)
+	seed2 := bytes.Repeat([]byte{2}, ed25519.SeedSize)
+	public1 := ed25519.NewKeyFromSeed(seed1).Public().(ed25519.PublicKey)
+	public2 := ed25519.NewKeyFromSeed(seed2).Public().(ed25519.PublicKey)
+
+	mustSigner := func(algorithm, keys, verifyKeys, activeID string) *Signer {
+		signer, err := ParseSigner(algorithm, keys, verifyKeys, activeID)
+		if err != nil {
+			t.Fatalf("ParseSigner: %v", err)
+		}
+		return signer
+	}
+
+	hmacOld := mustSigner(SignHMAC, keyEntry("k1", testKey(1)), "", "")
+	hmacRotated := mustSigner(SignHMAC, keySpec(keyEntry("k1", testKey(1)), keyEntry("k2", testKey(2))), "", "k2")
+	hmacOther := mustSigner(SignHMAC, keyEntry("k1", testKey(9)), "", "")
+	edProducer := mustSigner(SignEd25519, keyEntry("k1", seed1), "", "")
+	edWorker := mustSigner(SignEd25519, "", keyEntry("k1", public1), "")
+	edRotatedWorker := mustSigner(SignEd25519, "", keySpec(keyEntry("k1", public1), keyEntry("k2", public2)), "")
+	edImpostor := mustSigner(SignEd25519, keyEntry("k1", seed2), "", "")
+
+	message := []byte(`{"id":"job-1","type":"email"}`)
+	sign := func(s *Signer, msg []byte) string {
+		signature, err := s.Sign(msg)
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		return signature
+	}
+
+	tests := []struct {
+		name      string
+		verifier  *Signer
+		message   []byte
+		signature string
+		wantErr   string
+	}{
+		{name: "hmac", verifier: hmacOld, message: message, signature: sign(hmacOld, message)},
+		{name: "hmac signed before rotation", verifier: hmacRotated, message: message, signature: sign(hmacOld, message)},
+		{name: "hmac signed after rotation", verifier: hmacRotated, message: message, signature: sign(hmacRotated, message)},
+		{name: "hmac unknown key", verifier: hmacOld, message: message, signature: sign(hmacRotated, message), wantErr: "unknown key"},
+		{name: "hmac other secret", verifier: hmacOther, message: message, signature: sign(hmacOld, message), wantErr: "does not match"},
+		{name: "hmac tampered message", verifier: hmacOld, message: []byte(`{"id":"job-2"}`), signature: sign(hmacOld, message), wantErr: "does not match"},
+		{name: "ed25519 public key", verifier: edWorker, message: message, signature: sign(edProducer, message)},
+		{name: "ed25519 after rotation", verifier: edRotatedWorker, message: message, signature: sign(edProducer, message)},
+		{name: "ed25519 impostor", verifier: edWorker, message: message, signature: sign(edImpostor, message), wantErr: "does not match"},
+		{name: "ed25519 tampered message", verifier: edWorker, message: []byte(`{"id":"job-2"}`), signature: sign(edProducer, message), wantErr: "does not match"},
+		{name: "no key ID", verifier: hmacOld, message: message, signature: "c2lnbmF0dXJl", wantErr: "malformed"},
+		{name: "bad base64", verifier: hmacOld, message: message, signature: "k1:!!!", wantErr: "malformed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.verifier.Verify(tt.message, tt.signature)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrBadSignature) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Verify error = %v, want ErrBadSignature containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
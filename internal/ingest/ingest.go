@@ -0,0 +1,147 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/sqs"
+	"github.com/aneeshsunganahalli/Gopher/internal/trigger"
+	"go.uber.org/zap"
+)
+
+// Ingester turns storage notifications into jobs through the storage triggers.
+// A notification is only deleted or acked once all its jobs are enqueued, so
+// a failure redelivers it and may enqueue some jobs twice.
+type Ingester struct {
+	triggers *trigger.Manager
+	logger   *zap.Logger
+}
+
+// New creates an ingester for the manager's storage triggers
+func New(triggers *trigger.Manager, logger *zap.Logger) *Ingester {
+	return &Ingester{triggers: triggers, logger: logger}
+}
+
+// RunSQS long-polls an SQS queue receiving S3 notifications until ctx is cancelled
+func (i *Ingester) RunSQS(ctx context.Context, client *sqs.Client, queueURL string, wait time.Duration) error {
+	i.logger.Info("Ingesting S3 notifications from SQS", zap.String("queue_url", queueURL))
+
+	for ctx.Err() == nil {
+		messages, err := client.ReceiveMessage(ctx, queueURL, 10, wait, 0)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			i.logger.Error("Failed to receive SQS messages", zap.Error(err))
+			sleep(ctx, 5*time.Second)
+			continue
+		}
+
+		for _, message := range messages {
+			if !i.handleS3(ctx, message) {
+				continue
+			}
+			if err := client.DeleteMessage(ctx, queueURL, message.ReceiptHandle); err != nil {
+				i.logger.Error("Failed to delete SQS message",
+					zap.String("message_id", message.MessageID),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+	return nil
+}
+
+// handleS3 enqueues jobs for one message and reports whether it can be deleted
+func (i *Ingester) handleS3(ctx context.Context, message sqs.Message) bool {
+	objects, err := ParseS3Notification(message.Body)
+	if err != nil {
+		// Redelivering a malformed body won't fix it, so drop it
+		i.logger.Warn("Dropping unreadable S3 notification",
+			zap.String("message_id", message.MessageID),
+			zap.Error(err),
+		)
+		return true
+	}
+
+	for _, object := range objects {
+		if !i.handleObject(ctx, object) {
+			return false
+		}
+	}
+	return true
+}
+
+// RunPubSub pulls GCS notifications from a Pub/Sub subscription until ctx is cancelled
+func (i *Ingester) RunPubSub(ctx context.Context, client *PubSubClient, subscription string) error {
+	i.logger.Info("Ingesting GCS notifications from Pub/Sub", zap.String("subscription", subscription))
+
+	for ctx.Err() == nil {
+		messages, err := client.Pull(ctx, subscription, 10)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			i.logger.Error("Failed to pull Pub/Sub messages", zap.Error(err))
+			sleep(ctx, 5*time.Second)
+			continue
+		}
+
+		var acks []string
+		for _, message := range messages {
+			object, err := ParseGCSNotification(message.Attributes, message.Data)
+			if err != nil {
+				i.logger.Warn("Dropping unreadable GCS notification", zap.Error(err))
+				acks = append(acks, message.AckID)
+				continue
+			}
+			if i.handleObject(ctx, object) {
+				acks = append(acks, message.AckID)
+			}
+		}
+
+		if err := client.Acknowledge(ctx, subscription, acks...); err != nil {
+			i.logger.Error("Failed to acknowledge Pub/Sub messages", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// handleObject fires the matching triggers and reports whether the
+// notification is done with, either enqueued or permanently unusable
+func (i *Ingester) handleObject(ctx context.Context, object trigger.ObjectEvent) bool {
+	enqueued, err := i.triggers.HandleObject(ctx, object)
+	if errors.Is(err, trigger.ErrInvalidEvent) {
+		i.logger.Warn("Object event does not match trigger payload template",
+			zap.String("bucket", object.Bucket),
+			zap.String("key", object.Key),
+			zap.Error(err),
+		)
+		return true
+	}
+	if err != nil {
+		i.logger.Error("Failed to enqueue jobs for object",
+			zap.String("bucket", object.Bucket),
+			zap.String("key", object.Key),
+			zap.Error(err),
+		)
+		return false
+	}
+
+	i.logger.Debug("Ingested object event",
+		zap.String("provider", object.Provider),
+		zap.String("event", object.Event),
+		zap.String("bucket", object.Bucket),
+		zap.String("key", object.Key),
+		zap.Int("jobs", enqueued),
+	)
+	return true
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -0,0 +1,99 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/trigger"
+)
+
+// ParseS3Notification extracts object events from an SQS message body. Bodies
+// may be S3 notifications or SNS envelopes wrapping them; S3's test events
+// yield no objects.
+func ParseS3Notification(body string) ([]trigger.ObjectEvent, error) {
+	var envelope struct {
+		Type    string `json:"Type"`
+		Message string `json:"Message"`
+		Event   string `json:"Event"`
+		Records []map[string]interface{}
+	}
+	if err := json.Unmarshal([]byte(body), &envelope); err != nil {
+		return nil, fmt.Errorf("invalid S3 notification: %w", err)
+	}
+
+	if envelope.Type == "Notification" && envelope.Message != "" {
+		return ParseS3Notification(envelope.Message)
+	}
+	if envelope.Event == "s3:TestEvent" {
+		return nil, nil
+	}
+
+	events := make([]trigger.ObjectEvent, 0, len(envelope.Records))
+	for _, record := range envelope.Records {
+		var parsed struct {
+			EventName string `json:"eventName"`
+			EventTime string `json:"eventTime"`
+			S3        struct {
+				Bucket struct {
+					Name string `json:"name"`
+				} `json:"bucket"`
+				Object struct {
+					Key  string `json:"key"`
+					Size int64  `json:"size"`
+					ETag string `json:"eTag"`
+				} `json:"object"`
+			} `json:"s3"`
+		}
+		data, _ := json.Marshal(record)
+		if err := json.Unmarshal(data, &parsed); err != nil {
+			return nil, fmt.Errorf("invalid S3 event record: %w", err)
+		}
+
+		// S3 form-encodes keys, e.g. spaces arrive as "+"
+		key, err := url.QueryUnescape(parsed.S3.Object.Key)
+		if err != nil {
+			key = parsed.S3.Object.Key
+		}
+
+		events = append(events, trigger.ObjectEvent{
+			Provider: "s3",
+			Event:    parsed.EventName,
+			Bucket:   parsed.S3.Bucket.Name,
+			Key:      key,
+			Size:     parsed.S3.Object.Size,
+			ETag:     parsed.S3.Object.ETag,
+			Time:     parsed.EventTime,
+			Raw:      record,
+		})
+	}
+	return events, nil
+}
+
+// ParseGCSNotification builds the object event from a Pub/Sub message sent
+// by a GCS bucket notification (JSON_API_V1 payload format)
+func ParseGCSNotification(attributes map[string]string, data []byte) (trigger.ObjectEvent, error) {
+	if attributes["bucketId"] == "" || attributes["objectId"] == "" {
+		return trigger.ObjectEvent{}, fmt.Errorf("message is not a GCS notification")
+	}
+
+	event := trigger.ObjectEvent{
+		Provider: "gcs",
+		Event:    attributes["eventType"],
+		Bucket:   attributes["bucketId"],
+		Key:      attributes["objectId"],
+		Time:     attributes["eventTime"],
+	}
+
+	var object map[string]interface{}
+	if len(data) > 0 && json.Unmarshal(data, &object) == nil {
+		event.Raw = object
+		if size, ok := object["size"].(string); ok {
+			event.Size, _ = strconv.ParseInt(size, 10, 64)
+		}
+		event.ETag, _ = object["etag"].(string)
+		event.ContentType, _ = object["contentType"].(string)
+	}
+	return event, nil
+}
@@ -0,0 +1,170 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metadataTokenURL serves the default service account's token on GCE, GKE and Cloud Run
+const metadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// PubSubMessage is a message pulled from a subscription
+type PubSubMessage struct {
+	AckID      string
+	Attributes map[string]string
+	Data       []byte
+}
+
+// PubSubClient pulls and acknowledges messages with the Pub/Sub REST API.
+// It authenticates with a static token or the GCP metadata server.
+type PubSubClient struct {
+	endpoint string
+	token    string
+	http     *http.Client
+
+	mu      sync.Mutex
+	cached  string
+	expires time.Time
+}
+
+// NewPubSubClient creates a client; an empty endpoint uses pubsub.googleapis.com
+// and an empty token uses the metadata server
+func NewPubSubClient(endpoint, token string) *PubSubClient {
+	if endpoint == "" {
+		endpoint = "https://pubsub.googleapis.com"
+	}
+	return &PubSubClient{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		token:    token,
+		http:     &http.Client{Timeout: 90 * time.Second},
+	}
+}
+
+// Pull waits for up to max messages from the subscription
+// (projects/<project>/subscriptions/<name>)
+func (p *PubSubClient) Pull(ctx context.Context, subscription string, max int) ([]PubSubMessage, error) {
+	var out struct {
+		ReceivedMessages []struct {
+			AckID   string `json:"ackId"`
+			Message struct {
+				Data       string            `json:"data"`
+				Attributes map[string]string `json:"attributes"`
+			} `json:"message"`
+		} `json:"receivedMessages"`
+	}
+	if err := p.call(ctx, subscription+":pull", map[string]interface{}{"maxMessages": max}, &out); err != nil {
+		return nil, err
+	}
+
+	messages := make([]PubSubMessage, 0, len(out.ReceivedMessages))
+	for _, received := range out.ReceivedMessages {
+		data, err := base64.StdEncoding.DecodeString(received.Message.Data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Pub/Sub message data: %w", err)
+		}
+		messages = append(messages, PubSubMessage{
+			AckID:      received.AckID,
+			Attributes: received.Message.Attributes,
+			Data:       data,
+		})
+	}
+	return messages, nil
+}
+
+// Acknowledge removes handled messages from the subscription
+func (p *PubSubClient) Acknowledge(ctx context.Context, subscription string, ackIDs ...string) error {
+	if len(ackIDs) == 0 {
+		return nil
+	}
+	return p.call(ctx, subscription+":acknowledge", map[string]interface{}{"ackIds": ackIDs}, nil)
+}
+
+func (p *PubSubClient) call(ctx context.Context, path string, input interface{}, out interface{}) error {
+	token, err := p.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Pub/Sub request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/v1/"+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build Pub/Sub request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("pub/sub request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read Pub/Sub response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("pub/sub %s returned %s: %s", path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode Pub/Sub response: %w", err)
+	}
+	return nil
+}
+
+// accessToken returns the static token or a cached metadata server token
+func (p *PubSubClient) accessToken(ctx context.Context) (string, error) {
+	if p.token != "" {
+		return p.token, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cached != "" && time.Now().Before(p.expires) {
+		return p.cached, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get GCP access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get GCP access token: metadata server returned %s", resp.Status)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode GCP access token: %w", err)
+	}
+
+	// Refresh a minute early so in-flight requests don't carry an expired token
+	p.cached = token.AccessToken
+	p.expires = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return p.cached, nil
+}
@@ -0,0 +1,71 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"go.uber.org/zap"
+)
+
+// ResultCache stores completed results of idempotent handlers by job type
+// and cache key
+type ResultCache interface {
+	// Get returns the cached result, or nil when there is none
+	Get(ctx context.Context, jobType, key string) (*types.JobResult, error)
+
+	// Put caches a completed result for ttl
+	Put(ctx context.Context, jobType, key string, result *types.JobResult, ttl time.Duration) error
+}
+
+// cacheKey returns the job's cache key and TTL, or an empty key when the
+// result must not be cached
+func (r *Registry) cacheKey(handler types.JobHandler, job *types.Job) (string, time.Duration) {
+	idempotent, ok := handler.(types.IdempotentHandler)
+	if !ok || r.cache == nil {
+		return "", 0
+	}
+
+	ttl := idempotent.CacheTTL()
+	if ttl <= 0 {
+		return "", 0
+	}
+	return idempotent.CacheKey(job), ttl
+}
+
+// cachedResult looks up a reusable result; cache errors run the job normally
+func (r *Registry) cachedResult(ctx context.Context, job *types.Job, key string) *types.JobResult {
+	if key == "" {
+		return nil
+	}
+
+	cached, err := r.cache.Get(ctx, job.Type, key)
+	if err != nil {
+		r.logger.Warn("Failed to read job result cache",
+			zap.String("job_id", job.ID),
+			zap.String("job_type", job.Type),
+			zap.Error(err),
+		)
+		return nil
+	}
+	return cached
+}
+
+// cacheResult stores a completed result for later jobs with the same key
+func (r *Registry) cacheResult(ctx context.Context, job *types.Job, key string, ttl time.Duration, result *types.JobResult) {
+	if key == "" {
+		return
+	}
+
+	// The handler may have used up ctx; the cache write is still worth making
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	if err := r.cache.Put(ctx, job.Type, key, result, ttl); err != nil {
+		r.logger.Warn("Failed to cache job result",
+			zap.String("job_id", job.ID),
+			zap.String("job_type", job.Type),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,103 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+// Enqueuer submits follow-up jobs from inside a handler
+type Enqueuer interface {
+	Enqueue(ctx context.Context, job *types.Job) error
+}
+
+type enqueuerContextKey struct{}
+
+// WithEnqueuer returns a context carrying the given enqueuer
+func WithEnqueuer(ctx context.Context, enqueuer Enqueuer) context.Context {
+	return context.WithValue(ctx, enqueuerContextKey{}, enqueuer)
+}
+
+// EnqueuerFromContext returns the enqueuer attached to the handler context, if any
+func EnqueuerFromContext(ctx context.Context) (Enqueuer, bool) {
+	enqueuer, ok := ctx.Value(enqueuerContextKey{}).(Enqueuer)
+	return enqueuer, ok
+}
+
+// Enqueue submits a follow-up job through the enqueuer in ctx.
+// Inside a worker the job is only sent once the current handler returns nil.
+func Enqueue(ctx context.Context, job *types.Job) error {
+	enqueuer, ok := EnqueuerFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("no enqueuer available in context")
+	}
+	return enqueuer.Enqueue(ctx, job)
+}
+
+// DeferredEnqueuer buffers enqueued jobs until Flush is called, so a handler
+// that fails half way through does not emit downstream jobs for work that is
+// about to be retried
+type DeferredEnqueuer struct {
+	mu      sync.Mutex
+	target  Enqueuer
+	pending []*types.Job
+}
+
+// NewDeferredEnqueuer creates a deferred enqueuer that flushes into target
+func NewDeferredEnqueuer(target Enqueuer) *DeferredEnqueuer {
+	return &DeferredEnqueuer{target: target}
+}
+
+// Enqueue validates the job and buffers it until Flush
+func (d *DeferredEnqueuer) Enqueue(ctx context.Context, job *types.Job) error {
+	if err := job.Validate(); err != nil {
+		return fmt.Errorf("job validation failed: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.pending = append(d.pending, job)
+	return nil
+}
+
+// Pending returns the number of buffered jobs
+func (d *DeferredEnqueuer) Pending() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return len(d.pending)
+}
+
+// Flush sends all buffered jobs to the target enqueuer.
+// Jobs that could not be enqueued stay buffered and the first error is returned.
+func (d *DeferredEnqueuer) Flush(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var firstErr error
+	remaining := d.pending[:0]
+	for _, job := range d.pending {
+		if err := d.target.Enqueue(ctx, job); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to enqueue deferred job %s: %w", job.ID, err)
+			}
+			remaining = append(remaining, job)
+		}
+	}
+	d.pending = remaining
+
+	return firstErr
+}
+
+// Discard drops all buffered jobs and returns how many were dropped
+func (d *DeferredEnqueuer) Discard() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dropped := len(d.pending)
+	d.pending = nil
+	return dropped
+}
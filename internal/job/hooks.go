@@ -0,0 +1,88 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/auth"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"go.uber.org/zap"
+)
+
+// ErrPayloadRejected marks enqueue hook errors caused by the submitted job
+// rather than by the hook failing, so callers can answer with a client error
+var ErrPayloadRejected = errors.New("payload rejected by enqueue hook")
+
+// EnqueueHook rewrites a job before it is persisted, e.g. to inject tenant
+// details, normalize fields or stamp a schema version. Hooks run on the
+// server, independently of the handler, and an error rejects the job.
+type EnqueueHook func(ctx context.Context, job *types.Job) error
+
+type namedHook struct {
+	name string
+	hook EnqueueHook
+}
+
+// TransformHook returns a hook applying declarative payload transforms. The
+// caller source reads the principal authenticated on ctx.
+func TransformHook(transforms []types.PayloadTransform) EnqueueHook {
+	return func(ctx context.Context, job *types.Job) error {
+		var input types.TransformInput
+		if principal := auth.FromContext(ctx); principal != nil {
+			input.Caller = principal.Subject
+		}
+		if err := types.ApplyTransforms(job, transforms, input); err != nil {
+			return fmt.Errorf("%w: %v", ErrPayloadRejected, err)
+		}
+		return nil
+	}
+}
+
+// RegisterEnqueueHook adds a named hook for a registered job type, or for
+// every type with types.TransformAllTypes. Hooks for every type run first,
+// then the type's own, each in registration order.
+func (r *Registry) RegisterEnqueueHook(jobType, name string, hook EnqueueHook) error {
+	if hook == nil {
+		return fmt.Errorf("enqueue hook cannot be nil")
+	}
+	if name == "" {
+		return fmt.Errorf("enqueue hook name cannot be empty")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.handlers[jobType]; !exists && jobType != types.TransformAllTypes {
+		return fmt.Errorf("enqueue hook %s: no handler registered for type '%s'", name, jobType)
+	}
+
+	r.hooks[jobType] = append(r.hooks[jobType], namedHook{name: name, hook: hook})
+	r.logger.Info("Registered enqueue hook", zap.String("type", jobType), zap.String("hook", name))
+	return nil
+}
+
+// RegisterTransforms adds a hook per job type applying the configured transforms
+func (r *Registry) RegisterTransforms(transforms types.PayloadTransforms) error {
+	for _, jobType := range transforms.Types() {
+		if err := r.RegisterEnqueueHook(jobType, "transforms", TransformHook(transforms[jobType])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyEnqueueHooks runs the hooks for the job's type against it. The job
+// may be partially rewritten when a hook fails, so it must not be enqueued.
+func (r *Registry) ApplyEnqueueHooks(ctx context.Context, job *types.Job) error {
+	r.mu.RLock()
+	hooks := append(append([]namedHook(nil), r.hooks[types.TransformAllTypes]...), r.hooks[job.Type]...)
+	r.mu.RUnlock()
+
+	for _, h := range hooks {
+		if err := h.hook(ctx, job); err != nil {
+			return fmt.Errorf("enqueue hook %s: %w", h.name, err)
+		}
+	}
+	return nil
+}
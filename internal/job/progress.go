@@ -0,0 +1,54 @@
+package job
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+// ProgressReporter records how far a running job has got, so long-running
+// handlers can be followed through the job status endpoint
+type ProgressReporter interface {
+	ReportProgress(ctx context.Context, progress types.JobProgress) error
+}
+
+type progressReporterContextKey struct{}
+
+// WithProgressReporter returns a context carrying the given progress reporter
+func WithProgressReporter(ctx context.Context, reporter ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressReporterContextKey{}, reporter)
+}
+
+// ProgressReporterFromContext returns the progress reporter attached to the handler context, if any
+func ProgressReporterFromContext(ctx context.Context) (ProgressReporter, bool) {
+	reporter, ok := ctx.Value(progressReporterContextKey{}).(ProgressReporter)
+	return reporter, ok
+}
+
+// ReportProgress records progress through the reporter in ctx. Each report
+// replaces the previous one and is cleared when the job is retried.
+func ReportProgress(ctx context.Context, progress types.JobProgress) error {
+	reporter, ok := ProgressReporterFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("no progress reporter available in context")
+	}
+	if err := progress.Validate(); err != nil {
+		return err
+	}
+	return reporter.ReportProgress(ctx, progress)
+}
+
+// ReportStep records that done of total steps are finished and the handler
+// is now on step, e.g. ReportStep(ctx, "resizing", 3, 10) for 30%
+func ReportStep(ctx context.Context, step string, done, total int) error {
+	if total <= 0 || done < 0 || done > total {
+		return fmt.Errorf("invalid progress step %d of %d", done, total)
+	}
+	return ReportProgress(ctx, types.JobProgress{
+		Percent: float64(done) * 100 / float64(total),
+		Step:    step,
+		Done:    done,
+		Total:   total,
+	})
+}
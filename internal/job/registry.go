@@ -2,7 +2,10 @@ package job
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"sync"
 	"time"
 
@@ -10,20 +13,45 @@ import (
 	"go.uber.org/zap"
 )
 
+// Handler variants reported in JobResult.Variant
+const (
+	VariantStable = "stable"
+	VariantCanary = "canary"
+)
+
 type Registry struct {
 	mu       sync.RWMutex
 	handlers map[string]types.JobHandler
+	schemas  map[string]*types.PayloadSchema // Payload schemas of the stable handlers that advertise one
+	canaries map[string]*canary
+	hooks    map[string][]namedHook // Enqueue hooks by job type, types.TransformAllTypes for every type
+	cache    ResultCache            // Optional, reuses results of idempotent handlers
 	logger   *zap.Logger
 }
 
+// canary is a second handler for a type that receives a share of its jobs
+type canary struct {
+	handler types.JobHandler
+	schema  *types.PayloadSchema
+	percent float64
+}
+
 // NewRegistry creates a new job handler registry
 func NewRegistry(logger *zap.Logger) *Registry {
 	return &Registry{
 		handlers: make(map[string]types.JobHandler),
+		schemas:  make(map[string]*types.PayloadSchema),
+		canaries: make(map[string]*canary),
+		hooks:    make(map[string][]namedHook),
 		logger:   logger,
 	}
 }
 
+// SetResultCache enables reusing results of handlers implementing types.IdempotentHandler
+func (r *Registry) SetResultCache(cache ResultCache) {
+	r.cache = cache
+}
+
 // Register adds a job handler to the registry
 func (r *Registry) Register(handler types.JobHandler) error {
 	if handler == nil {
@@ -35,6 +63,11 @@ func (r *Registry) Register(handler types.JobHandler) error {
 		return fmt.Errorf("handler type cannot be empty")
 	}
 
+	schema, err := handlerSchema(handler)
+	if err != nil {
+		return fmt.Errorf("handler for type '%s': %w", jobType, err)
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -43,6 +76,9 @@ func (r *Registry) Register(handler types.JobHandler) error {
 	}
 
 	r.handlers[jobType] = handler
+	if schema != nil {
+		r.schemas[jobType] = schema
+	}
 	r.logger.Info("Registered job handler",
 		zap.String("type", jobType),
 		zap.String("description", handler.Description()),
@@ -51,6 +87,184 @@ func (r *Registry) Register(handler types.JobHandler) error {
 	return nil
 }
 
+// RegisterCanary adds a second handler for an already registered type that
+// receives percent (0-100) of its jobs. Routing is by job ID, so retries of a
+// job stay on the same variant.
+func (r *Registry) RegisterCanary(handler types.JobHandler, percent float64) error {
+	if handler == nil {
+		return fmt.Errorf("handler cannot be nil")
+	}
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("canary percent must be between 0 and 100, got %v", percent)
+	}
+
+	jobType := handler.Type()
+	schema, err := handlerSchema(handler)
+	if err != nil {
+		return fmt.Errorf("canary handler for type '%s': %w", jobType, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.handlers[jobType]; !exists {
+		return fmt.Errorf("no stable handler registered for type '%s'", jobType)
+	}
+	if _, exists := r.canaries[jobType]; exists {
+		return fmt.Errorf("canary for type '%s' already exists", jobType)
+	}
+
+	r.canaries[jobType] = &canary{handler: handler, schema: schema, percent: percent}
+	r.logger.Info("Registered canary job handler",
+		zap.String("type", jobType),
+		zap.String("description", handler.Description()),
+		zap.Float64("percent", percent),
+	)
+
+	return nil
+}
+
+// SetCanaryPercent changes the share of jobs routed to a type's canary
+func (r *Registry) SetCanaryPercent(jobType string, percent float64) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("canary percent must be between 0 and 100, got %v", percent)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, exists := r.canaries[jobType]
+	if !exists {
+		return fmt.Errorf("no canary registered for type '%s'", jobType)
+	}
+
+	c.percent = percent
+	r.logger.Info("Canary traffic changed", zap.String("type", jobType), zap.Float64("percent", percent))
+	return nil
+}
+
+// PromoteCanary makes a type's canary its stable handler
+func (r *Registry) PromoteCanary(jobType string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, exists := r.canaries[jobType]
+	if !exists {
+		return fmt.Errorf("no canary registered for type '%s'", jobType)
+	}
+
+	r.handlers[jobType] = c.handler
+	if c.schema != nil {
+		r.schemas[jobType] = c.schema
+	} else {
+		delete(r.schemas, jobType)
+	}
+	delete(r.canaries, jobType)
+	r.logger.Info("Promoted canary job handler", zap.String("type", jobType))
+	return nil
+}
+
+// RemoveCanary stops routing jobs to a type's canary
+func (r *Registry) RemoveCanary(jobType string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.canaries[jobType]; exists {
+		delete(r.canaries, jobType)
+		r.logger.Info("Removed canary job handler", zap.String("type", jobType))
+	}
+}
+
+// CanaryPercents returns the canary traffic share for each type that has one
+func (r *Registry) CanaryPercents() map[string]float64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	percents := make(map[string]float64, len(r.canaries))
+	for t, c := range r.canaries {
+		percents[t] = c.percent
+	}
+	return percents
+}
+
+// route picks the handler variant for a job
+func (r *Registry) route(job *types.Job) (types.JobHandler, string, error) {
+	r.mu.RLock()
+	c, hasCanary := r.canaries[job.Type]
+	r.mu.RUnlock()
+
+	if hasCanary && canaryBucket(job.ID) < c.percent {
+		return c.handler, VariantCanary, nil
+	}
+
+	handler, err := r.Get(job.Type)
+	return handler, VariantStable, err
+}
+
+// canaryBucket maps a job ID onto [0, 100)
+func canaryBucket(jobID string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(jobID))
+	return float64(h.Sum32()%10000) / 100
+}
+
+// handlerSchema validates what a handler advertises and compiles its payload
+// schema, if it has one
+func handlerSchema(handler types.JobHandler) (*types.PayloadSchema, error) {
+	described, ok := handler.(types.DescribedHandler)
+	if !ok {
+		return nil, nil
+	}
+
+	capabilities := described.Capabilities()
+	if err := capabilities.Validate(); err != nil {
+		return nil, err
+	}
+	if len(capabilities.PayloadSchema) == 0 {
+		return nil, nil
+	}
+	return types.CompileSchema(capabilities.PayloadSchema)
+}
+
+// ValidatePayload checks a payload against the schema the stable handler
+// for jobType advertises. Types without a schema accept any payload; a
+// canary is expected to accept what the stable handler does.
+func (r *Registry) ValidatePayload(jobType string, payload json.RawMessage) []types.SchemaViolation {
+	r.mu.RLock()
+	schema := r.schemas[jobType]
+	r.mu.RUnlock()
+
+	if schema == nil {
+		return nil
+	}
+	return schema.Validate(payload)
+}
+
+// ErrUnsupportedPayloadVersion is returned by CheckPayloadVersion for jobs
+// whose payload version the handler doesn't accept
+var ErrUnsupportedPayloadVersion = errors.New("unsupported payload version")
+
+// CheckPayloadVersion checks the job's payload version against the versions
+// the handler it routes to declares. Jobs without a handler pass, so
+// Process reports them as usual.
+func (r *Registry) CheckPayloadVersion(job *types.Job) error {
+	handler, _, err := r.route(job)
+	if err != nil {
+		return nil
+	}
+	described, ok := handler.(types.DescribedHandler)
+	if !ok {
+		return nil
+	}
+
+	capabilities := described.Capabilities()
+	if capabilities.AcceptsPayloadVersion(job.PayloadVersion) {
+		return nil
+	}
+	return fmt.Errorf("%w: handler for %s accepts payload versions %v, job has %d",
+		ErrUnsupportedPayloadVersion, job.Type, capabilities.PayloadVersions, job.PayloadVersion)
+}
+
 // Get retrieves a handler for the given job type
 func (r *Registry) Get(jobType string) (types.JobHandler, error) {
 	r.mu.RLock()
@@ -77,24 +291,43 @@ func (r *Registry) Type() []string {
 	return types
 }
 
+type startTimeContextKey struct{}
+
+// WithStartTime returns a context recording when the worker picked the job up
+func WithStartTime(ctx context.Context, startedAt time.Time) context.Context {
+	return context.WithValue(ctx, startTimeContextKey{}, startedAt)
+}
+
+// StartTimeFromContext returns the start time set by WithStartTime, if any
+func StartTimeFromContext(ctx context.Context) (time.Time, bool) {
+	startedAt, ok := ctx.Value(startTimeContextKey{}).(time.Time)
+	return startedAt, ok
+}
+
 // Process executes a job using appropriate handler
 func (r *Registry) Process(ctx context.Context, job *types.Job) *types.JobResult {
-	startTime := ctx.Value("start_time").(int64)
+	// The worker stamps when it picked the job up; fall back to now when
+	// Process is called directly
+	startedAt, ok := StartTimeFromContext(ctx)
+	if !ok {
+		startedAt = time.Now()
+	}
 
 	result := &types.JobResult{
-		JobID:       job.ID,
-		CompletedAt: time.Now().UTC(),
+		JobID: job.ID,
+		Timing: types.JobTiming{
+			StartedAt: startedAt.UTC(),
+		},
 	}
 
-	// Calculate duration
-	duration := time.Since(time.Unix(0, startTime))
-	result.Duration = duration.String()
-
-	// Get handler
-	handler, err := r.Get(job.Type)
+	// Get handler, which may be the type's canary
+	handler, variant, err := r.route(job)
 	if err != nil {
 		result.Status = types.StatusFailed
 		result.Error = err.Error()
+		result.ErrorFingerprint = types.ErrorFingerprint(result.Error)
+		result.FailureReason = types.ReasonPoison
+		finishTiming(result, time.Now())
 		r.logger.Error("No handler found for job",
 			zap.String("job_id", job.ID),
 			zap.String("job_type", job.Type),
@@ -103,37 +336,110 @@ func (r *Registry) Process(ctx context.Context, job *types.Job) *types.JobResult
 		return result
 	}
 
+	result.Variant = variant
+
+	// Idempotent handlers reuse a recent result for the same input
+	cacheKey, cacheTTL := r.cacheKey(handler, job)
+	if cached := r.cachedResult(ctx, job, cacheKey); cached != nil {
+		result.Status = types.StatusCompleted
+		result.CachedFrom = cached.JobID
+		result.Output = cached.Output
+		finishTiming(result, time.Now())
+		r.logger.Info("Reused cached job result",
+			zap.String("job_id", job.ID),
+			zap.String("job_type", job.Type),
+			zap.String("cached_from", cached.JobID),
+		)
+		return result
+	}
+
 	// Execute job
 	r.logger.Info("Processing job",
 		zap.String("job_id", job.ID),
 		zap.String("job_type", job.Type),
+		zap.String("variant", variant),
 		zap.Int("attempt", job.Attempts+1),
 	)
 
-	if err := handler.Handle(ctx, job); err != nil {
+	handlerStart := time.Now()
+	result.Timing.HandlerStartedAt = handlerStart.UTC()
+
+	output := &types.ResultBuffer{}
+	err = runHandler(types.WithResultWriter(ctx, output), handler, job)
+	finishTiming(result, handlerStart)
+
+	if err != nil {
 		result.Status = types.StatusFailed
 		result.Error = err.Error()
+		result.ErrorFingerprint = types.ErrorFingerprint(result.Error)
+		result.FailureReason = classifyFailure(ctx, err)
 
 		r.logger.Error("Job processing failed",
 			zap.String("job_id", job.ID),
 			zap.String("job_type", job.Type),
+			zap.String("reason", string(result.FailureReason)),
 			zap.Error(err),
-			zap.Duration("duration", duration),
+			zap.Duration("duration", result.Timing.HandlerDuration),
 		)
 
 		return result
 	}
 
 	result.Status = types.StatusCompleted
+	result.Output = output.Output()
 	r.logger.Info("Job completed successfully",
 		zap.String("job_id", job.ID),
 		zap.String("job_type", job.Type),
-		zap.Duration("duration", duration),
+		zap.Duration("duration", result.Timing.HandlerDuration),
 	)
 
+	r.cacheResult(ctx, job, cacheKey, cacheTTL, result)
 	return result
 }
 
+// errHandlerPanic wraps a recovered handler panic
+var errHandlerPanic = errors.New("handler panicked")
+
+// classifyFailure maps a handler error to a DLQ failure reason
+func classifyFailure(ctx context.Context, err error) types.FailureReason {
+	switch {
+	case errors.Is(err, errHandlerPanic):
+		return types.ReasonPanic
+	case errors.Is(err, types.ErrPoisonJob):
+		return types.ReasonPoison
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return types.ReasonTimeout
+	case errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled):
+		return types.ReasonCancelled
+	default:
+		return types.ReasonHandlerError
+	}
+}
+
+// runHandler calls the handler, turning a panic into an error so one bad
+// job cannot take down the worker
+func runHandler(ctx context.Context, handler types.JobHandler, job *types.Job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%w: %v", errHandlerPanic, recovered)
+		}
+	}()
+
+	return handler.Handle(ctx, job)
+}
+
+// finishTiming stamps the completion time and durations on the result.
+// handlerStart is when the handler was called, or now if it never ran.
+func finishTiming(result *types.JobResult, handlerStart time.Time) {
+	finishedAt := time.Now()
+
+	result.CompletedAt = finishedAt.UTC()
+	result.Timing.FinishedAt = finishedAt.UTC()
+	result.Timing.HandlerDuration = finishedAt.Sub(handlerStart)
+	result.Timing.TotalDuration = finishedAt.Sub(result.Timing.StartedAt)
+	result.Duration = result.Timing.HandlerDuration.String()
+}
+
 func (r *Registry) ListHandlers() map[string]string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -141,6 +447,35 @@ func (r *Registry) ListHandlers() map[string]string {
 	handlers := make(map[string]string)
 	for t, h := range r.handlers {
 		handlers[t] = h.Description()
+		if c, exists := r.canaries[t]; exists {
+			handlers[t] = fmt.Sprintf("%s (canary %.1f%%: %s)", h.Description(), c.percent, c.handler.Description())
+		}
 	}
 	return handlers
 }
+
+// Capabilities returns what each handler implementing types.DescribedHandler
+// advertises; other handlers are left out
+func (r *Registry) Capabilities() map[string]types.HandlerCapabilities {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	capabilities := make(map[string]types.HandlerCapabilities)
+	for t, h := range r.handlers {
+		if described, ok := h.(types.DescribedHandler); ok {
+			capabilities[t] = described.Capabilities()
+		}
+	}
+	return capabilities
+}
+
+// DefaultPolicies returns the default policy each handler advertises
+func (r *Registry) DefaultPolicies() types.JobPolicies {
+	policies := make(types.JobPolicies)
+	for t, capabilities := range r.Capabilities() {
+		if capabilities.DefaultPolicy != nil {
+			policies[t] = *capabilities.DefaultPolicy
+		}
+	}
+	return policies
+}
@@ -0,0 +1,336 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	kafka "github.com/segmentio/kafka-go"
+	_ "github.com/segmentio/kafka-go/gzip"   // Decodes gzip batches
+	_ "github.com/segmentio/kafka-go/snappy" // Decodes snappy batches, including xerial's framing
+)
+
+const (
+	defaultClientID = "gopher"
+	dialTimeout     = 10 * time.Second
+	batchTimeout    = 10 * time.Millisecond // Produce is synchronous, so don't hold records for a batch
+	fetchMaxWait    = 500 * time.Millisecond
+)
+
+// Options configures a Client
+type Options struct {
+	Brokers  []string // Bootstrap brokers, host:port
+	ClientID string   // Defaults to "gopher"
+	Group    string   // Consumer group Fetch joins, empty for a producer-only client
+	Topics   []string // Topics the group consumes
+}
+
+// Record is a record produced to or fetched from a partition
+type Record struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+}
+
+// Client runs the parts of Kafka Gopher needs on segmentio/kafka-go:
+// producing records, consuming them as a member of a consumer group,
+// committing offsets and reading a group's lag. It connects to plaintext
+// listeners; gzip, snappy and zstd batches are decoded, lz4 ones aren't.
+type Client struct {
+	opts   Options
+	dialer *kafka.Dialer
+
+	mu      sync.Mutex
+	writers map[string]*kafka.Writer // By topic
+	readers map[string]*kafka.Reader // By topic, created by the first Fetch
+	closed  bool
+}
+
+var errClosed = errors.New("kafka: client closed")
+
+// Dial creates a client and checks that a bootstrap broker answers. A
+// consumer group isn't joined until the first Fetch, so a process that only
+// produces isn't assigned partitions it never reads.
+func Dial(ctx context.Context, opts Options) (*Client, error) {
+	if len(opts.Brokers) == 0 {
+		return nil, fmt.Errorf("kafka: at least one broker is required")
+	}
+	if opts.Group != "" && len(opts.Topics) == 0 {
+		return nil, fmt.Errorf("kafka: consumer group %s has no topics", opts.Group)
+	}
+	if opts.ClientID == "" {
+		opts.ClientID = defaultClientID
+	}
+
+	c := &Client{
+		opts:    opts,
+		dialer:  &kafka.Dialer{ClientID: opts.ClientID, Timeout: dialTimeout, DualStack: true},
+		writers: make(map[string]*kafka.Writer),
+	}
+	if err := c.Ping(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Ping connects to the first bootstrap broker that answers
+func (c *Client) Ping(ctx context.Context) error {
+	return c.anyBroker(ctx, func(addr string) error {
+		conn, err := c.dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	})
+}
+
+// Close leaves the consumer group and flushes and closes the writers
+func (c *Client) Close() error {
+	c.mu.Lock()
+	writers, readers := c.writers, c.readers
+	c.writers, c.readers = nil, nil
+	c.closed = true
+	c.mu.Unlock()
+
+	var errs []error
+	for _, r := range readers {
+		errs = append(errs, r.Close())
+	}
+	for _, w := range writers {
+		errs = append(errs, w.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Produce appends a record to its topic, waiting for all in-sync replicas to
+// acknowledge it. Records with a key go to the partition the Java client
+// would pick for it, the others to a random one. Partition is ignored.
+func (c *Client) Produce(ctx context.Context, record Record) error {
+	w, err := c.writer(record.Topic)
+	if err != nil {
+		return err
+	}
+	if err := w.WriteMessages(ctx, kafka.Message{Key: record.Key, Value: record.Value}); err != nil {
+		return fmt.Errorf("kafka: failed to produce to %s: %w", record.Topic, err)
+	}
+	return nil
+}
+
+func (c *Client) writer(topic string) (*kafka.Writer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, errClosed
+	}
+
+	w, ok := c.writers[topic]
+	if !ok {
+		w = kafka.NewWriter(kafka.WriterConfig{
+			Brokers:      c.opts.Brokers,
+			Topic:        topic,
+			Dialer:       c.dialer,
+			Balancer:     kafka.Murmur2Balancer{},
+			BatchTimeout: batchTimeout,
+			RequiredAcks: -1,
+		})
+		c.writers[topic] = w
+	}
+	return w, nil
+}
+
+// Fetch returns the next records of the partitions assigned to this group
+// member, joining the group on the first call. It waits until a record
+// arrives on any of the topics and returns none once ctx expires. A new
+// group starts from each partition's earliest offset.
+func (c *Client) Fetch(ctx context.Context) ([]Record, error) {
+	readers, err := c.consume()
+	if err != nil {
+		return nil, err
+	}
+
+	// A reader keeps records it has fetched until FetchMessage takes them,
+	// so cancelling the other topics' calls once one returns loses nothing
+	fetchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		records []Record
+		failed  error
+	)
+	for _, r := range readers {
+		wg.Add(1)
+		go func(r *kafka.Reader) {
+			defer wg.Done()
+			msg, err := r.FetchMessage(fetchCtx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				records = append(records, recordOf(msg))
+			case fetchCtx.Err() == nil:
+				failed = fmt.Errorf("kafka: failed to fetch from %s: %w", r.Config().Topic, err)
+			default:
+				return
+			}
+			cancel()
+		}(r)
+	}
+	wg.Wait()
+
+	if len(records) > 0 {
+		return records, nil
+	}
+	if ctx.Err() != nil {
+		return nil, nil
+	}
+	return nil, failed
+}
+
+// consume returns a reader per topic, creating them on the first call
+func (c *Client) consume() ([]*kafka.Reader, error) {
+	if c.opts.Group == "" {
+		return nil, fmt.Errorf("kafka: fetching needs a consumer group")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, errClosed
+	}
+
+	if c.readers == nil {
+		c.readers = make(map[string]*kafka.Reader, len(c.opts.Topics))
+		for _, topic := range c.opts.Topics {
+			c.readers[topic] = kafka.NewReader(kafka.ReaderConfig{
+				Brokers:     c.opts.Brokers,
+				GroupID:     c.opts.Group,
+				Topic:       topic,
+				Dialer:      c.dialer,
+				MaxWait:     fetchMaxWait,
+				StartOffset: kafka.FirstOffset,
+			})
+		}
+	}
+
+	readers := make([]*kafka.Reader, 0, len(c.readers))
+	for _, r := range c.readers {
+		readers = append(readers, r)
+	}
+	return readers, nil
+}
+
+// Commit stores offset as the next one the group reads from a partition
+func (c *Client) Commit(ctx context.Context, topic string, partition int32, offset int64) error {
+	c.mu.Lock()
+	r, ok := c.readers[topic]
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return errClosed
+	}
+	if !ok {
+		return fmt.Errorf("kafka: topic %s isn't being consumed", topic)
+	}
+
+	// kafka-go commits the offset after the message's
+	msg := kafka.Message{Topic: topic, Partition: int(partition), Offset: offset - 1}
+	if err := r.CommitMessages(ctx, msg); err != nil {
+		return fmt.Errorf("kafka: failed to commit %s/%d: %w", topic, partition, err)
+	}
+	return nil
+}
+
+// Lag is how many records of topic the group hasn't committed yet. Without
+// a group, or for partitions it hasn't committed, it counts from the
+// earliest offset.
+func (c *Client) Lag(ctx context.Context, topic string) (int64, error) {
+	var partitions []kafka.Partition
+	err := c.anyBroker(ctx, func(addr string) error {
+		var err error
+		partitions, err = c.dialer.LookupPartitions(ctx, "tcp", addr, topic)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	committed := make(map[int]int64)
+	if c.opts.Group != "" {
+		client := kafka.NewClientWith(kafka.ClientConfig{Brokers: c.opts.Brokers, Dialer: c.dialer})
+		committed, err = client.ConsumerOffsets(ctx, kafka.TopicAndGroup{Topic: topic, GroupId: c.opts.Group})
+		if err != nil {
+			return 0, fmt.Errorf("kafka: failed to read committed offsets of %s: %w", topic, err)
+		}
+	}
+
+	var lag int64
+	for _, p := range partitions {
+		first, last, err := c.offsets(ctx, p)
+		if err != nil {
+			return 0, err
+		}
+		from, ok := committed[p.ID]
+		if !ok || from < 0 {
+			from = first
+		}
+		lag += behind(from, last)
+	}
+	return lag, nil
+}
+
+// offsets returns a partition's earliest offset and the one after its last record
+func (c *Client) offsets(ctx context.Context, p kafka.Partition) (first, last int64, err error) {
+	conn, err := c.dialer.DialPartition(ctx, "tcp", "", p)
+	if err != nil {
+		return 0, 0, fmt.Errorf("kafka: failed to reach the leader of %s/%d: %w", p.Topic, p.ID, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if first, last, err = conn.ReadOffsets(); err != nil {
+		return 0, 0, fmt.Errorf("kafka: failed to read offsets of %s/%d: %w", p.Topic, p.ID, err)
+	}
+	return first, last, nil
+}
+
+// behind is how many records lie between a committed offset and the end of
+// a partition. A commit past the end, left by a truncated topic, counts none.
+func behind(committed, end int64) int64 {
+	if end > committed {
+		return end - committed
+	}
+	return 0
+}
+
+// anyBroker calls fn with each bootstrap broker until it succeeds
+func (c *Client) anyBroker(ctx context.Context, fn func(addr string) error) error {
+	var lastErr error
+	for _, addr := range c.opts.Brokers {
+		if lastErr = fn(addr); lastErr == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return fmt.Errorf("kafka: no broker reachable: %w", lastErr)
+}
+
+func recordOf(msg kafka.Message) Record {
+	return Record{
+		Topic:     msg.Topic,
+		Partition: int32(msg.Partition),
+		Offset:    msg.Offset,
+		Key:       msg.Key,
+		Value:     msg.Value,
+	}
+}
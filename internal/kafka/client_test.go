@@ -0,0 +1,137 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDialOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr string
+	}{
+		{
+			name:    "no brokers",
+			opts:    Options{},
+			wantErr: "at least one broker",
+		},
+		{
+			name:    "group without topics",
+			opts:    Options{Brokers: []string{"localhost:9092"}, Group: "workers"},
+			wantErr: "has no topics",
+		},
+		{
+			name:    "unreachable broker",
+			opts:    Options{Brokers: []string{closedAddr(t)}},
+			wantErr: "no broker reachable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			client, err := Dial(ctx, tt.opts)
+			if err == nil {
+				client.Close()
+				t.Fatalf("Dial succeeded, want error containing %q", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Dial error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProducerOnlyClient(t *testing.T) {
+	client := &Client{opts: Options{Brokers: []string{"localhost:9092"}}}
+
+	if _, err := client.Fetch(context.Background()); err == nil {
+		t.Fatal("Fetch without a group succeeded")
+	}
+	if err := client.Commit(context.Background(), "jobs", 0, 1); err == nil {
+		t.Fatal("Commit of an unconsumed topic succeeded")
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := client.writer("jobs"); err != errClosed {
+		t.Fatalf("writer after Close = %v, want %v", err, errClosed)
+	}
+}
+
+func TestBehind(t *testing.T) {
+	tests := []struct {
+		name      string
+		committed int64
+		end       int64
+		want      int64
+	}{
+		{name: "caught up", committed: 10, end: 10, want: 0},
+		{name: "behind", committed: 4, end: 10, want: 6},
+		{name: "empty partition", committed: 0, end: 0, want: 0},
+		{name: "commit past a truncated end", committed: 12, end: 10, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := behind(tt.committed, tt.end); got != tt.want {
+				t.Fatalf("behind(%d, %d) = %d, want %d", tt.committed, tt.end, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestZstdCodec(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "json", data: []byte(`{"type":"email","payload":{"to":"a@example.com"}}`)},
+		{name: "repetitive", data: bytes.Repeat([]byte("gopher"), 10000)},
+	}
+
+	codec := zstdCodec{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var compressed bytes.Buffer
+			w := codec.NewWriter(&compressed)
+			if _, err := w.Write(tt.data); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close writer: %v", err)
+			}
+
+			r := codec.NewReader(&compressed)
+			defer r.Close()
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("ReadAll: %v", err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Fatalf("round trip returned %d bytes, want %d", len(got), len(tt.data))
+			}
+		})
+	}
+}
+
+// closedAddr returns an address nothing listens on
+func closedAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
@@ -0,0 +1,45 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/queue"
+)
+
+// Importing the package makes it the client behind queue.DialKafka
+func init() {
+	queue.RegisterKafkaDialer(dialQueueClient)
+}
+
+func dialQueueClient(ctx context.Context, opts queue.KafkaOptions) (queue.KafkaClient, error) {
+	topics := opts.Topics
+	if len(topics) == 0 && opts.Topic != "" {
+		topics = []string{opts.Topic}
+	}
+	client, err := Dial(ctx, Options{Brokers: opts.Brokers, Group: opts.Group, Topics: topics})
+	if err != nil {
+		return nil, err
+	}
+	return queueClient{client}, nil
+}
+
+// queueClient adapts Client to queue.KafkaClient
+type queueClient struct {
+	*Client
+}
+
+func (c queueClient) Produce(ctx context.Context, record queue.KafkaRecord) error {
+	return c.Client.Produce(ctx, Record(record))
+}
+
+func (c queueClient) Fetch(ctx context.Context) ([]queue.KafkaRecord, error) {
+	records, err := c.Client.Fetch(ctx)
+	if len(records) == 0 {
+		return nil, err
+	}
+	converted := make([]queue.KafkaRecord, len(records))
+	for i, record := range records {
+		converted[i] = queue.KafkaRecord(record)
+	}
+	return converted, err
+}
@@ -0,0 +1,60 @@
+package kafka
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// kafka-go's own zstd codec needs cgo, so batches are decoded with the pure
+// Go implementation instead
+func init() {
+	kafka.RegisterCompressionCodec(zstdCodec{})
+}
+
+const zstdCode = 4
+
+type zstdCodec struct{}
+
+func (zstdCodec) Code() int8 { return zstdCode }
+
+func (zstdCodec) Name() string { return "zstd" }
+
+func (zstdCodec) NewReader(r io.Reader) io.ReadCloser {
+	d, err := zstd.NewReader(r)
+	if err != nil {
+		return errorReader{err}
+	}
+	return zstdReader{d}
+}
+
+func (zstdCodec) NewWriter(w io.Writer) io.WriteCloser {
+	e, err := zstd.NewWriter(w)
+	if err != nil {
+		return errorWriter{err}
+	}
+	return e
+}
+
+// zstdReader adapts a decoder, whose Close returns nothing, to io.ReadCloser
+type zstdReader struct {
+	*zstd.Decoder
+}
+
+func (r zstdReader) Close() error {
+	r.Decoder.Close()
+	return nil
+}
+
+type errorReader struct{ err error }
+
+func (r errorReader) Read([]byte) (int, error) { return 0, r.err }
+
+func (r errorReader) Close() error { return nil }
+
+type errorWriter struct{ err error }
+
+func (w errorWriter) Write([]byte) (int, error) { return 0, w.err }
+
+func (w errorWriter) Close() error { return w.err }
@@ -3,10 +3,13 @@ package limiter
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
 	"github.com/go-redis/redis/v8"
 )
 
@@ -20,7 +23,43 @@ type RateLimiter interface {
 
 	// SetLimit sets the rate limit for a job type
 	SetLimit(ctx context.Context, jobType string, limit float64, burst int) error
-} // LocalRateLimiter implements in-memory rate limiting
+
+	// GetLimit returns the rate limit in effect for a job type
+	GetLimit(ctx context.Context, jobType string) (*Limit, error)
+
+	// Limits returns the state of every job type the limiter has seen
+	Limits(ctx context.Context) ([]*Limit, error)
+
+	// SetObserver registers a callback for every allow or throttle decision
+	SetObserver(observer Observer)
+}
+
+// Observer is told about each rate limit decision, e.g. to count throttling in metrics
+type Observer func(jobType string, allowed bool)
+
+// Unlimited as a default limit lets job types without an explicit limit run freely.
+// A limit of 0 stops a job type entirely.
+const Unlimited = -1
+
+// Limit describes the rate limit for a job type
+type Limit struct {
+	JobType string  `json:"job_type"`
+	Limit   float64 `json:"limit"` // Jobs per second, negative for unlimited
+	Burst   int     `json:"burst"`
+	Default bool    `json:"default"` // True when no explicit limit is set
+	Tokens  float64 `json:"tokens"`  // Tokens available right now; below 1 means the type is throttled
+}
+
+// refill returns the token count after refilling at limit per second since last, capped at burst
+func refill(tokens float64, last, now time.Time, limit float64, burst int) float64 {
+	tokens += now.Sub(last).Seconds() * limit
+	if tokens > float64(burst) {
+		tokens = float64(burst)
+	}
+	return tokens
+}
+
+// LocalRateLimiter implements in-memory rate limiting
 type LocalRateLimiter struct {
 	mu           sync.RWMutex
 	limits       map[string]float64 // requests per second
@@ -29,6 +68,7 @@ type LocalRateLimiter struct {
 	tokenBuckets map[string]float64
 	defaults     float64
 	defaultBurst int
+	observer     Observer
 }
 
 // NewLocalRateLimiter creates a new in-memory rate limiter
@@ -45,6 +85,19 @@ func NewLocalRateLimiter(defaultLimit float64, defaultBurst int) *LocalRateLimit
 
 // Allow checks if a job can be processed under rate limits
 func (l *LocalRateLimiter) Allow(ctx context.Context, jobType string) (bool, error) {
+	allowed := l.allow(jobType)
+
+	l.mu.RLock()
+	observer := l.observer
+	l.mu.RUnlock()
+	if observer != nil {
+		observer(jobType, allowed)
+	}
+
+	return allowed, nil
+}
+
+func (l *LocalRateLimiter) allow(jobType string) bool {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
@@ -54,6 +107,9 @@ func (l *LocalRateLimiter) Allow(ctx context.Context, jobType string) (bool, err
 		limit = l.defaults
 		l.limits[jobType] = limit
 	}
+	if limit < 0 {
+		return true
+	}
 
 	burst, ok := l.bursts[jobType]
 	if !ok {
@@ -75,16 +131,11 @@ func (l *LocalRateLimiter) Allow(ctx context.Context, jobType string) (bool, err
 
 	// Calculate token refill based on time elapsed
 	now := time.Now()
-	elapsed := now.Sub(lastTime)
-	refill := elapsed.Seconds() * limit
-	newTokens := tokens + refill
-	if newTokens > float64(burst) {
-		newTokens = float64(burst)
-	}
+	newTokens := refill(tokens, lastTime, now, limit, burst)
 
 	// Try to take a token
 	if newTokens < 1 {
-		return false, nil
+		return false
 	}
 
 	// Take a token and update state
@@ -92,7 +143,7 @@ func (l *LocalRateLimiter) Allow(ctx context.Context, jobType string) (bool, err
 	l.tokenBuckets[jobType] = newTokens
 	l.lastAllowed[jobType] = now
 
-	return true, nil
+	return true
 }
 
 // Done is a no-op for the local limiter
@@ -111,12 +162,64 @@ func (l *LocalRateLimiter) SetLimit(ctx context.Context, jobType string, limit f
 	return nil
 }
 
+// GetLimit returns the rate limit for a job type
+func (l *LocalRateLimiter) GetLimit(ctx context.Context, jobType string) (*Limit, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.limitLocked(jobType), nil
+}
+
+// Limits returns the state of every job type seen by Allow or SetLimit
+func (l *LocalRateLimiter) Limits(ctx context.Context) ([]*Limit, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	limits := make([]*Limit, 0, len(l.limits))
+	for jobType := range l.limits {
+		limits = append(limits, l.limitLocked(jobType))
+	}
+	return limits, nil
+}
+
+// SetObserver registers a callback for every allow or throttle decision
+func (l *LocalRateLimiter) SetObserver(observer Observer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.observer = observer
+}
+
+// limitLocked builds the limit state for a job type; callers hold l.mu
+func (l *LocalRateLimiter) limitLocked(jobType string) *Limit {
+	result := &Limit{JobType: jobType, Limit: l.defaults, Burst: l.defaultBurst, Default: true}
+	if limit, ok := l.limits[jobType]; ok {
+		result.Limit = limit
+		result.Burst = l.bursts[jobType]
+		result.Default = false
+	}
+
+	result.Tokens = float64(result.Burst)
+	if tokens, ok := l.tokenBuckets[jobType]; ok {
+		result.Tokens = refill(tokens, l.lastAllowed[jobType], time.Now(), result.Limit, result.Burst)
+	}
+	return result
+}
+
 // RedisRateLimiter implements distributed rate limiting using Redis
 type RedisRateLimiter struct {
 	client       redis.Cmdable
 	prefix       string
 	defaults     float64
 	defaultBurst int
+	observer     Observer
+
+	mu           sync.RWMutex
+	typeDefaults map[string]typeDefault // Per job type fallback when no explicit limit is set
+}
+
+type typeDefault struct {
+	limit float64
+	burst int
 }
 
 // NewRedisRateLimiter creates a new Redis-backed rate limiter
@@ -126,11 +229,54 @@ func NewRedisRateLimiter(client redis.Cmdable, prefix string, defaultLimit float
 		prefix:       prefix,
 		defaults:     defaultLimit,
 		defaultBurst: defaultBurst,
+		typeDefaults: make(map[string]typeDefault),
+	}
+}
+
+// SetTypeDefault sets the limit a job type gets until one is set explicitly,
+// e.g. from its policy
+func (r *RedisRateLimiter) SetTypeDefault(jobType string, limit float64, burst int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.typeDefaults[jobType] = typeDefault{limit: limit, burst: burst}
+}
+
+// ApplyPolicies makes each policy's rate limit the default for its job type,
+// replacing the defaults of an earlier call; limits set through the API still
+// take precedence
+func (r *RedisRateLimiter) ApplyPolicies(policies types.JobPolicies) {
+	defaults := make(map[string]typeDefault)
+	for jobType, policy := range policies {
+		if policy.RateLimit != nil {
+			defaults[jobType] = typeDefault{limit: policy.RateLimit.Limit, burst: policy.RateLimit.Burst}
+		}
 	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.typeDefaults = defaults
+}
+
+// defaultFor returns the limit and burst a job type gets without an explicit limit
+func (r *RedisRateLimiter) defaultFor(jobType string) (float64, int) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if def, ok := r.typeDefaults[jobType]; ok {
+		return def.limit, def.burst
+	}
+	return r.defaults, r.defaultBurst
 }
 
 // Allow checks if a job can be processed using Redis-based token bucket
 func (r *RedisRateLimiter) Allow(ctx context.Context, jobType string) (bool, error) {
+	allowed, err := r.allow(ctx, jobType)
+	if err == nil && r.observer != nil {
+		r.observer(jobType, allowed)
+	}
+	return allowed, err
+}
+
+func (r *RedisRateLimiter) allow(ctx context.Context, jobType string) (bool, error) {
 	limitsKey := fmt.Sprintf("%s:limits:%s", r.prefix, jobType)
 	tokensKey := fmt.Sprintf("%s:tokens:%s", r.prefix, jobType)
 
@@ -143,8 +289,7 @@ func (r *RedisRateLimiter) Allow(ctx context.Context, jobType string) (bool, err
 	_, err := pipe.Exec(ctx)
 
 	// Parse values with defaults
-	limit := r.defaults
-	burst := r.defaultBurst
+	limit, burst := r.defaultFor(jobType)
 	var lastUpdated time.Time
 	currentTokens := float64(burst)
 
@@ -174,14 +319,13 @@ func (r *RedisRateLimiter) Allow(ctx context.Context, jobType string) (bool, err
 		}
 	}
 
+	if limit < 0 {
+		return true, nil
+	}
+
 	// Calculate token refill based on time elapsed
 	now := time.Now()
-	elapsed := now.Sub(lastUpdated)
-	refill := float64(elapsed.Seconds()) * float64(limit)
-	newTokens := currentTokens + refill
-	if newTokens > float64(burst) {
-		newTokens = float64(burst)
-	}
+	newTokens := refill(currentTokens, lastUpdated, now, limit, burst)
 
 	// Try to take a token
 	if newTokens < 1 {
@@ -223,3 +367,108 @@ func (r *RedisRateLimiter) SetLimit(ctx context.Context, jobType string, limit f
 
 	return nil
 }
+
+// GetLimit returns the rate limit for a job type
+func (r *RedisRateLimiter) GetLimit(ctx context.Context, jobType string) (*Limit, error) {
+	limitsKey := fmt.Sprintf("%s:limits:%s", r.prefix, jobType)
+
+	tokensKey := fmt.Sprintf("%s:tokens:%s", r.prefix, jobType)
+
+	pipe := r.client.Pipeline()
+	limitsCmd := pipe.HMGet(ctx, limitsKey, "limit", "burst", "last_updated")
+	tokensCmd := pipe.Get(ctx, tokensKey)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get rate limit: %w", err)
+	}
+	values := limitsCmd.Val()
+
+	result := &Limit{JobType: jobType, Default: true}
+	result.Limit, result.Burst = r.defaultFor(jobType)
+
+	if limitVal, ok := values[0].(string); ok {
+		if l, err := strconv.ParseFloat(limitVal, 64); err == nil {
+			result.Limit = l
+			result.Default = false
+		}
+	}
+
+	if burstVal, ok := values[1].(string); ok {
+		if b, err := strconv.Atoi(burstVal); err == nil {
+			result.Burst = b
+		}
+	}
+
+	// Mirror Allow: a missing bucket is full, refilled since the last allowed job
+	result.Tokens = float64(result.Burst)
+	if t, err := strconv.ParseFloat(tokensCmd.Val(), 64); err == nil {
+		result.Tokens = t
+	}
+	lastUpdated := time.Now().Add(-24 * time.Hour)
+	if lastVal, ok := values[2].(string); ok {
+		if t, err := time.Parse(time.RFC3339, lastVal); err == nil {
+			lastUpdated = t
+		}
+	}
+	result.Tokens = refill(result.Tokens, lastUpdated, time.Now(), result.Limit, result.Burst)
+
+	return result, nil
+}
+
+// Limits returns the state of every job type with an explicit limit or a token bucket
+func (r *RedisRateLimiter) Limits(ctx context.Context) ([]*Limit, error) {
+	jobTypes := make(map[string]bool)
+	var mu sync.Mutex
+	scan := func(ctx context.Context, client redis.Cmdable) error {
+		for _, kind := range []string{"limits", "tokens"} {
+			pattern := fmt.Sprintf("%s:%s:", r.prefix, kind)
+
+			iter := client.Scan(ctx, 0, pattern+"*", 100).Iterator()
+			for iter.Next(ctx) {
+				mu.Lock()
+				jobTypes[strings.TrimPrefix(iter.Val(), pattern)] = true
+				mu.Unlock()
+			}
+			if err := iter.Err(); err != nil {
+				return fmt.Errorf("failed to scan rate limits: %w", err)
+			}
+		}
+		return nil
+	}
+
+	// Limits are spread over the cluster by job type, so scan every primary
+	if cluster, ok := r.client.(*redis.ClusterClient); ok {
+		err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return scan(ctx, node)
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else if err := scan(ctx, r.client); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	for jobType := range r.typeDefaults {
+		jobTypes[jobType] = true
+	}
+	r.mu.RUnlock()
+
+	limits := make([]*Limit, 0, len(jobTypes))
+	for jobType := range jobTypes {
+		limit, err := r.GetLimit(ctx, jobType)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, limit)
+	}
+
+	sort.Slice(limits, func(i, j int) bool {
+		return limits[i].JobType < limits[j].JobType
+	})
+	return limits, nil
+}
+
+// SetObserver registers a callback for every allow or throttle decision.
+// Call it before the limiter is shared between goroutines.
+func (r *RedisRateLimiter) SetObserver(observer Observer) {
+	r.observer = observer
+}
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Signals watched for anomalies
+const (
+	SignalEnqueueRate = "enqueue_rate" // Jobs enqueued per second
+	SignalFailureRate = "failure_rate" // Fraction of finished attempts that failed
+	SignalLatency     = "latency"      // Mean handler duration in seconds
+)
+
+// deviationFloors keep a steady signal, whose variance tends to zero, from
+// flagging the smallest change, e.g. the first failure after a run of
+// successes. Each signal's deviation is at least its floor plus a tenth of
+// its average.
+var deviationFloors = map[string]float64{
+	SignalEnqueueRate: 0.1,  // Jobs per second
+	SignalFailureRate: 0.02, // Two percentage points
+	SignalLatency:     0.01, // Seconds
+}
+
+// Anomaly is a sample well above its signal's recent average
+type Anomaly struct {
+	Signal     string    `json:"signal"`
+	Value      float64   `json:"value"`
+	Mean       float64   `json:"mean"`   // Average before the sample
+	StdDev     float64   `json:"stddev"` // Deviation the z-score is measured in
+	ZScore     float64   `json:"z_score"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// String describes the anomaly in one line, e.g.
+// "failure_rate 0.41 is 6.2σ above its average of 0.02"
+func (a Anomaly) String() string {
+	return fmt.Sprintf("%s %.3g is %.1fσ above its average of %.3g", a.Signal, a.Value, a.ZScore, a.Mean)
+}
+
+// AnomalyConfig tunes an AnomalyDetector
+type AnomalyConfig struct {
+	Alpha     float64 // Weight of the newest sample in the moving average, in (0, 1]
+	Threshold float64 // z-score from which a sample is anomalous
+	Warmup    int     // Samples per signal to learn from before flagging any
+}
+
+// baseline is a signal's exponentially weighted mean and variance
+type baseline struct {
+	mean     float64
+	variance float64
+	samples  int
+	spiking  bool // The last sample was anomalous
+}
+
+// AnomalyDetector flags samples that spike above an exponentially weighted
+// moving average of their signal. Only rises are flagged, and a spike is
+// reported once, when it starts; the next is reported after the signal
+// has come back down.
+type AnomalyDetector struct {
+	mu        sync.Mutex
+	config    AnomalyConfig
+	baselines map[string]*baseline
+}
+
+// NewAnomalyDetector creates a detector with no history
+func NewAnomalyDetector(config AnomalyConfig) *AnomalyDetector {
+	return &AnomalyDetector{
+		config:    config,
+		baselines: make(map[string]*baseline),
+	}
+}
+
+// Observe scores a sample of signal against its baseline and then folds it
+// in. It returns the sample's score, with ok set when a spike starts.
+func (d *AnomalyDetector) Observe(signal string, value float64, now time.Time) (anomaly Anomaly, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	b, exists := d.baselines[signal]
+	if !exists {
+		b = &baseline{}
+		d.baselines[signal] = b
+	}
+
+	anomaly = Anomaly{Signal: signal, Value: value, Mean: b.mean, DetectedAt: now.UTC()}
+	if b.samples >= d.config.Warmup && b.samples > 0 {
+		floor := deviationFloors[signal] + 0.1*math.Abs(b.mean)
+		anomaly.StdDev = math.Max(math.Sqrt(b.variance), floor)
+		anomaly.ZScore = (value - b.mean) / anomaly.StdDev
+
+		spiking := anomaly.ZScore >= d.config.Threshold
+		ok = spiking && !b.spiking
+		b.spiking = spiking
+	}
+
+	// Incremental EWMA of the mean and variance
+	if b.samples == 0 {
+		b.mean = value
+	} else {
+		diff := value - b.mean
+		increment := d.config.Alpha * diff
+		b.mean += increment
+		b.variance = (1 - d.config.Alpha) * (b.variance + diff*increment)
+	}
+	b.samples++
+	return anomaly, ok
+}
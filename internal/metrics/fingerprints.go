@@ -0,0 +1,42 @@
+package metrics
+
+import "sync"
+
+const (
+	// maxFingerprintsPerType bounds the fingerprint label values of each job type
+	maxFingerprintsPerType = 20
+
+	// otherFingerprint labels failures past the per type limit
+	otherFingerprint = "other"
+)
+
+// fingerprintLimiter admits the first fingerprints seen for each job type and
+// folds the rest into "other" so label cardinality stays bounded
+type fingerprintLimiter struct {
+	mu     sync.Mutex
+	byType map[string]map[string]struct{}
+}
+
+func newFingerprintLimiter() *fingerprintLimiter {
+	return &fingerprintLimiter{byType: make(map[string]map[string]struct{})}
+}
+
+// label returns the fingerprint to use as a label value
+func (l *fingerprintLimiter) label(jobType, fingerprint string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	seen, ok := l.byType[jobType]
+	if !ok {
+		seen = make(map[string]struct{})
+		l.byType[jobType] = seen
+	}
+	if _, ok := seen[fingerprint]; ok {
+		return fingerprint
+	}
+	if len(seen) >= maxFingerprintsPerType {
+		return otherFingerprint
+	}
+	seen[fingerprint] = struct{}{}
+	return fingerprint
+}
@@ -3,6 +3,8 @@ package metrics
 import (
 	"context"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -25,24 +27,69 @@ type Metrics struct {
 	QueueSize          *prometheus.GaugeVec
 	ScheduledQueueSize prometheus.Gauge
 	DLQSize            prometheus.Gauge
+	DLQOldestAge       prometheus.Gauge
+	DLQInflowRate      prometheus.Gauge
+	JobsDeadLettered   *prometheus.CounterVec
+	JobsExpired        *prometheus.CounterVec
+	JobsPastDeadline   *prometheus.CounterVec
+	JobFailures        *prometheus.CounterVec
+
+	// Per handler variant metrics, for comparing canaries with stable handlers
+	HandlerJobs     *prometheus.CounterVec
+	HandlerDuration *prometheus.HistogramVec
+
+	// Latency SLO metrics
+	QueueWaitTime *prometheus.HistogramVec
+	SLOEvents     *prometheus.CounterVec
+	SLOBurnRate   *prometheus.GaugeVec
+	SLOObjective  *prometheus.GaugeVec
+
+	// Rate limiter decisions, to tell throttling apart from missing capacity
+	RateLimitDecisions *prometheus.CounterVec
+
+	// Repeated submissions answered from an idempotency key instead of enqueued
+	EnqueuesSuppressed *prometheus.CounterVec
+
+	// Priority mix metrics, achieved share is rate(dequeued) over the sum
+	PriorityDequeued *prometheus.CounterVec
+	PriorityTarget   *prometheus.GaugeVec
+
+	// Job events forwarded to outbound sinks
+	SinkEvents *prometheus.CounterVec
+
+	// Anomaly detection on enqueue rate, failure rate and latency
+	Anomalies    *prometheus.CounterVec
+	AnomalyScore *prometheus.GaugeVec
 
 	// Worker metrics
 	WorkerCount       prometheus.Gauge
 	ActiveWorkers     prometheus.Gauge
 	WorkerUtilization prometheus.Gauge
+	PoolJobs          *prometheus.CounterVec
 
 	// System metrics
 	APIRequestCount    *prometheus.CounterVec
 	APIRequestDuration *prometheus.HistogramVec
 
-	logger *zap.Logger
-	server *http.Server
+	logger       *zap.Logger
+	server       *http.Server
+	slo          *sloTracker
+	fingerprints *fingerprintLimiter
+	pool         poolTotals
+}
+
+// poolTotals remembers the pool counters last published, so PoolJobs only
+// advances by what changed since
+type poolTotals struct {
+	mu                         sync.Mutex
+	processed, failed, retried int64
 }
 
 // NewMetrics creates and registers all Prometheus metrics
 func NewMetrics(logger *zap.Logger) *Metrics {
 	m := &Metrics{
-		logger: logger,
+		logger:       logger,
+		fingerprints: newFingerprintLimiter(),
 
 		// Job metrics
 		JobsEnqueued: promauto.NewCounterVec(prometheus.CounterOpts{
@@ -92,6 +139,105 @@ func NewMetrics(logger *zap.Logger) *Metrics {
 			Help: "Current number of jobs in the dead letter queue",
 		}),
 
+		DLQOldestAge: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "gopher_dlq_oldest_age_seconds",
+			Help: "Age of the oldest entry in the dead letter queue",
+		}),
+
+		DLQInflowRate: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "gopher_dlq_inflow_per_minute",
+			Help: "Jobs sent to the dead letter queue per minute, averaged over 5 minutes",
+		}),
+
+		JobsDeadLettered: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "gopher_dlq_jobs_total",
+			Help: "Total number of jobs sent to the dead letter queue",
+		}, []string{"job_type", "reason"}),
+
+		JobsExpired: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "gopher_jobs_expired_total",
+			Help: "Total number of jobs rejected at dequeue for exceeding their type's max age",
+		}, []string{"job_type"}),
+
+		JobsPastDeadline: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "gopher_jobs_past_deadline_total",
+			Help: "Total number of jobs discarded at dequeue because their expires_at had passed",
+		}, []string{"job_type"}),
+
+		JobFailures: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "gopher_job_failures_total",
+			Help: "Failed job attempts by failure reason and error fingerprint (at most 20 fingerprints per job type, the rest count as \"other\")",
+		}, []string{"job_type", "reason", "fingerprint"}),
+
+		// Per handler variant metrics
+		HandlerJobs: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "gopher_handler_jobs_total",
+			Help: "Jobs handled, by handler variant and outcome",
+		}, []string{"job_type", "variant", "status"}),
+
+		HandlerDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gopher_handler_duration_seconds",
+			Help:    "Handler execution time by handler variant",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"job_type", "variant"}),
+
+		// Latency SLO metrics
+		QueueWaitTime: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gopher_queue_wait_seconds",
+			Help:    "Time jobs spent waiting between enqueue and start of execution",
+			Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+		}, []string{"queue"}),
+
+		SLOEvents: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "gopher_queue_latency_slo_events_total",
+			Help: "Job starts counted against the queue latency SLO, by outcome",
+		}, []string{"queue", "outcome"}),
+
+		SLOBurnRate: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "gopher_queue_latency_slo_burn_rate",
+			Help: "Error budget burn rate of the queue latency SLO (1 = burning exactly at budget)",
+		}, []string{"queue", "window"}),
+
+		SLOObjective: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "gopher_queue_latency_slo_objective",
+			Help: "Configured fraction of jobs that must start within the SLO threshold",
+		}, []string{"queue", "threshold"}),
+
+		RateLimitDecisions: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "gopher_rate_limit_decisions_total",
+			Help: "Rate limiter decisions per job type (allowed or throttled)",
+		}, []string{"job_type", "decision"}),
+
+		EnqueuesSuppressed: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "gopher_enqueues_suppressed_total",
+			Help: "Repeated submissions per job type that an idempotency key kept from being enqueued",
+		}, []string{"job_type"}),
+
+		PriorityDequeued: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "gopher_priority_dequeued_total",
+			Help: "Total number of jobs dequeued per priority level",
+		}, []string{"priority"}),
+
+		PriorityTarget: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "gopher_priority_target_share",
+			Help: "Fraction of dequeues the configured priority ratio assigns to each level",
+		}, []string{"priority"}),
+
+		SinkEvents: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "gopher_sink_events_total",
+			Help: "Job events forwarded to outbound sinks, by sink and outcome (sent, failed or dropped)",
+		}, []string{"sink", "outcome"}),
+
+		Anomalies: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "gopher_anomalies_total",
+			Help: "Spikes flagged by the anomaly detector, by signal",
+		}, []string{"signal"}),
+
+		AnomalyScore: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "gopher_anomaly_zscore",
+			Help: "z-score of each signal's latest sample against its moving average",
+		}, []string{"signal"}),
+
 		// Worker metrics
 		WorkerCount: promauto.NewGauge(prometheus.GaugeOpts{
 			Name: "gopher_worker_count",
@@ -108,6 +254,11 @@ func NewMetrics(logger *zap.Logger) *Metrics {
 			Help: "Percentage of workers currently active (0-100)",
 		}),
 
+		PoolJobs: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "gopher_worker_pool_jobs_total",
+			Help: "Jobs finished by the worker pool, by outcome (processed, failed or retried)",
+		}, []string{"outcome"}),
+
 		// API metrics
 		APIRequestCount: promauto.NewCounterVec(prometheus.CounterOpts{
 			Name: "gopher_api_requests_total",
@@ -125,6 +276,129 @@ func NewMetrics(logger *zap.Logger) *Metrics {
 	return m
 }
 
+// ObserveEnqueue counts one job accepted for the queue
+func (m *Metrics) ObserveEnqueue(jobType, priority string) {
+	m.JobsEnqueued.WithLabelValues(jobType, priority).Inc()
+}
+
+// ObserveSuppressed counts one repeated submission that enqueued nothing
+func (m *Metrics) ObserveSuppressed(jobType string) {
+	m.EnqueuesSuppressed.WithLabelValues(jobType).Inc()
+}
+
+// ObserveRequest records one API request under its route pattern
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	m.APIRequestCount.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.APIRequestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// ObserveHandler records the outcome and duration of one handler execution
+func (m *Metrics) ObserveHandler(jobType, variant, status string, duration time.Duration) {
+	m.HandlerJobs.WithLabelValues(jobType, variant, status).Inc()
+	m.HandlerDuration.WithLabelValues(jobType, variant).Observe(duration.Seconds())
+}
+
+// ObserveFailure counts one failed attempt under its error fingerprint
+func (m *Metrics) ObserveFailure(jobType, reason, fingerprint string) {
+	m.JobFailures.WithLabelValues(jobType, reason, m.fingerprints.label(jobType, fingerprint)).Inc()
+}
+
+// ObserveRateLimit counts one rate limiter decision; it matches limiter.Observer
+func (m *Metrics) ObserveRateLimit(jobType string, allowed bool) {
+	decision := "throttled"
+	if allowed {
+		decision = "allowed"
+	}
+	m.RateLimitDecisions.WithLabelValues(jobType, decision).Inc()
+}
+
+// SetPriorityRatio publishes the target share of each priority level
+func (m *Metrics) SetPriorityRatio(high, normal, low int) {
+	total := float64(high + normal + low)
+	if total == 0 {
+		return
+	}
+	m.PriorityTarget.WithLabelValues("high").Set(float64(high) / total)
+	m.PriorityTarget.WithLabelValues("normal").Set(float64(normal) / total)
+	m.PriorityTarget.WithLabelValues("low").Set(float64(low) / total)
+}
+
+// ObservePriority counts one dequeued job for its priority level
+func (m *Metrics) ObservePriority(priority string) {
+	m.PriorityDequeued.WithLabelValues(priority).Inc()
+}
+
+// SetSLOTargets configures per-queue latency objectives
+func (m *Metrics) SetSLOTargets(targets map[string]SLOTarget) {
+	m.slo = newSLOTracker(targets)
+	for queue, target := range targets {
+		m.SLOObjective.WithLabelValues(queue, target.Threshold.String()).Set(target.Objective)
+	}
+}
+
+// ObserveQueueWait records how long a job waited before it started and
+// updates the latency SLO burn rates for its queue
+func (m *Metrics) ObserveQueueWait(queue string, wait time.Duration) {
+	m.QueueWaitTime.WithLabelValues(queue).Observe(wait.Seconds())
+
+	if m.slo == nil {
+		return
+	}
+
+	good, burnRates, ok := m.slo.observe(queue, wait, time.Now())
+	if !ok {
+		return
+	}
+
+	outcome := "good"
+	if !good {
+		outcome = "bad"
+	}
+	m.SLOEvents.WithLabelValues(queue, outcome).Inc()
+
+	for window, rate := range burnRates {
+		m.SLOBurnRate.WithLabelValues(queue, window).Set(rate)
+	}
+}
+
+// ObserveDLQ updates the dead letter queue gauges from a stats snapshot
+func (m *Metrics) ObserveDLQ(size int, oldestAgeSeconds, inflowPerMinute float64) {
+	m.DLQSize.Set(float64(size))
+	m.DLQOldestAge.Set(oldestAgeSeconds)
+	m.DLQInflowRate.Set(inflowPerMinute)
+}
+
+// ObserveAnomaly publishes a sample's z-score and counts it when it starts a spike
+func (m *Metrics) ObserveAnomaly(anomaly Anomaly, detected bool) {
+	m.AnomalyScore.WithLabelValues(anomaly.Signal).Set(anomaly.ZScore)
+	if detected {
+		m.Anomalies.WithLabelValues(anomaly.Signal).Inc()
+	}
+}
+
+// ObservePool publishes the worker pool's size, utilization and job counters
+func (m *Metrics) ObservePool(workers, active int, processed, failed, retried int64) {
+	m.WorkerCount.Set(float64(workers))
+	m.ActiveWorkers.Set(float64(active))
+	if workers > 0 {
+		m.WorkerUtilization.Set(100 * float64(active) / float64(workers))
+	}
+
+	m.pool.mu.Lock()
+	defer m.pool.mu.Unlock()
+
+	for outcome, delta := range map[string]int64{
+		"processed": processed - m.pool.processed,
+		"failed":    failed - m.pool.failed,
+		"retried":   retried - m.pool.retried,
+	} {
+		if delta > 0 {
+			m.PoolJobs.WithLabelValues(outcome).Add(float64(delta))
+		}
+	}
+	m.pool.processed, m.pool.failed, m.pool.retried = processed, failed, retried
+}
+
 // StartServer starts the Prometheus metrics HTTP server
 func (m *Metrics) StartServer(address string) error {
 	mux := http.NewServeMux()
@@ -136,11 +410,18 @@ func (m *Metrics) StartServer(address string) error {
 	}
 
 	m.logger.Info("Starting Prometheus metrics server", zap.String("address", address))
-	return m.server.ListenAndServe()
+	if err := m.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
 }
 
 // StopServer stops the Prometheus metrics HTTP server
 func (m *Metrics) StopServer(ctx context.Context) error {
+	if m.server == nil {
+		return nil
+	}
+
 	m.logger.Info("Stopping Prometheus metrics server")
 	return m.server.Shutdown(ctx)
 }
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Burn rate windows, following the multi-window alerting practice
+var sloWindows = []struct {
+	label   string
+	minutes int
+}{
+	{"5m", 5},
+	{"1h", 60},
+}
+
+// SLOTarget is a queue latency objective, e.g. 95% of jobs start within 30s
+type SLOTarget struct {
+	Objective float64       // Fraction of jobs that must start within Threshold
+	Threshold time.Duration // Maximum acceptable wait between enqueue and start
+}
+
+// ParseSLOTargets parses a JSON object such as
+// {"high":{"objective":0.99,"threshold":"5s"},"normal":{"objective":0.95,"threshold":"30s"}}
+func ParseSLOTargets(spec string) (map[string]SLOTarget, error) {
+	targets := make(map[string]SLOTarget)
+	if strings.TrimSpace(spec) == "" {
+		return targets, nil
+	}
+
+	var raw map[string]struct {
+		Objective float64 `json:"objective"`
+		Threshold string  `json:"threshold"`
+	}
+	if err := json.Unmarshal([]byte(spec), &raw); err != nil {
+		return nil, fmt.Errorf("invalid SLO targets: %w", err)
+	}
+
+	for queue, target := range raw {
+		if target.Objective <= 0 || target.Objective >= 1 {
+			return nil, fmt.Errorf("SLO objective for %s must be between 0 and 1, got %v", queue, target.Objective)
+		}
+
+		threshold, err := time.ParseDuration(target.Threshold)
+		if err != nil || threshold <= 0 {
+			return nil, fmt.Errorf("invalid SLO threshold for %s: %q", queue, target.Threshold)
+		}
+
+		targets[queue] = SLOTarget{
+			Objective: target.Objective,
+			Threshold: threshold,
+		}
+	}
+
+	return targets, nil
+}
+
+// sloBucket counts jobs that started in one minute
+type sloBucket struct {
+	minute int64
+	total  int
+	bad    int
+}
+
+// sloTracker keeps an hour of per-minute buckets per queue to compute burn rates
+type sloTracker struct {
+	mu      sync.Mutex
+	targets map[string]SLOTarget
+	buckets map[string][]sloBucket
+}
+
+func newSLOTracker(targets map[string]SLOTarget) *sloTracker {
+	buckets := make(map[string][]sloBucket, len(targets))
+	for queue := range targets {
+		buckets[queue] = make([]sloBucket, 60)
+	}
+
+	return &sloTracker{
+		targets: targets,
+		buckets: buckets,
+	}
+}
+
+// observe records one job start and returns whether it met the target and
+// the burn rate per window. ok is false for queues without a target.
+func (t *sloTracker) observe(queue string, wait time.Duration, now time.Time) (good bool, burnRates map[string]float64, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	target, exists := t.targets[queue]
+	if !exists {
+		return false, nil, false
+	}
+
+	good = wait <= target.Threshold
+
+	minute := now.Unix() / 60
+	ring := t.buckets[queue]
+	slot := &ring[minute%int64(len(ring))]
+	if slot.minute != minute {
+		*slot = sloBucket{minute: minute}
+	}
+	slot.total++
+	if !good {
+		slot.bad++
+	}
+
+	burnRates = make(map[string]float64, len(sloWindows))
+	errorBudget := 1 - target.Objective
+	for _, window := range sloWindows {
+		total, bad := 0, 0
+		for _, bucket := range ring {
+			if bucket.minute > minute-int64(window.minutes) && bucket.minute <= minute {
+				total += bucket.total
+				bad += bucket.bad
+			}
+		}
+
+		if total > 0 {
+			burnRates[window.label] = (float64(bad) / float64(total)) / errorBudget
+		}
+	}
+
+	return good, burnRates, true
+}
@@ -0,0 +1,80 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/go-redis/redis/v8"
+)
+
+// Admin performs maintenance operations that span the pending, scheduled
+// and dead letter structures in Redis
+type Admin struct {
+	client  redis.Cmdable
+	layout  *Layout
+	keyring *encryption.Keyring
+}
+
+// NewAdmin creates an admin helper; keyring may be nil when encryption is disabled
+func NewAdmin(client redis.Cmdable, layout *Layout, keyring *encryption.Keyring) *Admin {
+	return &Admin{
+		client:  client,
+		layout:  layout,
+		keyring: keyring,
+	}
+}
+
+// processingQueueKeys lists the processing lists of every consumer, holding
+// jobs dequeued but not yet acked
+func (l *Layout) processingQueueKeys(ctx context.Context, client redis.Cmdable) ([]string, error) {
+	node, err := l.scanNode(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	var cursor uint64
+	for {
+		found, next, err := node.Scan(ctx, cursor, l.key(jobQueueKey)+"*"+processingInfix+"*", 100).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan processing lists: %w", err)
+		}
+		keys = append(keys, found...)
+
+		cursor = next
+		if cursor == 0 {
+			return keys, nil
+		}
+	}
+}
+
+// pendingQueueKeys lists every Redis list that can hold pending jobs,
+// including named physical queues but not the processing lists of jobs
+// being worked on
+func (l *Layout) pendingQueueKeys(ctx context.Context, client redis.Cmdable) ([]string, error) {
+	keys := []string{l.key(jobQueueKey), l.key(highPriorityQueueKey), l.key(normalPriorityQueueKey), l.key(lowPriorityQueueKey)}
+
+	node, err := l.scanNode(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor uint64
+	for {
+		named, next, err := node.Scan(ctx, cursor, l.key(jobQueueKey)+":*", 100).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan named queues: %w", err)
+		}
+		for _, key := range named {
+			if !isProcessingKey(key) {
+				keys = append(keys, key)
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return keys, nil
+		}
+	}
+}
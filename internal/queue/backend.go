@@ -0,0 +1,87 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+)
+
+// BackendOptions are the settings Gopher passes to a registered backend.
+// Backend-specific settings such as addresses or credentials are up to the
+// backend, e.g. read from its own environment variables.
+type BackendOptions struct {
+	QueueName   string              // Physical queue to consume, empty for the default queue
+	PollTimeout time.Duration       // How long a dequeue blocks waiting for a job
+	Keyring     *encryption.Keyring // Payload encryption keys, nil when encryption is off
+	Signing     Signing             // How jobs are signed as they are stored
+}
+
+// BackendFactory opens a registered backend's queue and its dead letter
+// queue, which may be nil when the backend has none
+type BackendFactory func(ctx context.Context, opts BackendOptions) (Queue, DeadLetterQueue, error)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = make(map[string]BackendFactory)
+)
+
+// RegisterBackend makes a queue backend selectable with QUEUE_BACKEND=<name>.
+// Call it from an init function in the package implementing the backend and
+// link that package into the binaries. The server and worker register the
+// built-in postgres, sqlite, kafka and sqs backends the same way, so
+// registering a name twice, built-in names included, panics.
+func RegisterBackend(name string, factory BackendFactory) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	if name == "" || factory == nil {
+		panic("queue: RegisterBackend needs a name and a factory")
+	}
+	if _, exists := backends[name]; exists {
+		panic(fmt.Sprintf("queue: backend %q registered twice", name))
+	}
+	backends[name] = factory
+}
+
+// BackendRegistered reports whether a backend was registered under name
+func BackendRegistered(name string) bool {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	_, ok := backends[name]
+	return ok
+}
+
+// Backends returns the registered backend names, sorted
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// OpenBackend opens the queue of the backend registered under name
+func OpenBackend(ctx context.Context, name string, opts BackendOptions) (Queue, DeadLetterQueue, error) {
+	backendsMu.RLock()
+	factory, ok := backends[name]
+	backendsMu.RUnlock()
+
+	if !ok {
+		return nil, nil, fmt.Errorf("no queue backend registered as %q", name)
+	}
+
+	q, dlq, err := factory(ctx, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s queue backend: %w", name, err)
+	}
+	return q, dlq, nil
+}
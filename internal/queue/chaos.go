@@ -0,0 +1,254 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	chaosSettingsKey = "chaos:settings" // Redis string holding runtime chaos overrides
+
+	chaosRefreshInterval = 5 * time.Second
+)
+
+var (
+	// ErrChaosInjected is returned for operations failed on purpose by chaos mode
+	ErrChaosInjected = errors.New("chaos: injected failure")
+
+	// ErrChaosAckDropped is returned when a job was enqueued but chaos mode
+	// dropped the acknowledgement, so callers that retry create a duplicate
+	ErrChaosAckDropped = errors.New("chaos: acknowledgement dropped")
+)
+
+// ChaosSettings controls fault injection in the queue layer
+type ChaosSettings struct {
+	FailureRate float64 `json:"failure_rate"`  // Fraction of enqueues/dequeues that fail outright
+	LatencyMs   int     `json:"latency_ms"`    // Maximum latency added to each operation
+	DropAckRate float64 `json:"drop_ack_rate"` // Fraction of operations whose acknowledgement is lost
+}
+
+// Validate checks that rates are fractions and latency is not negative
+func (s ChaosSettings) Validate() error {
+	if s.FailureRate < 0 || s.FailureRate > 1 {
+		return fmt.Errorf("failure_rate must be between 0 and 1, got %v", s.FailureRate)
+	}
+	if s.DropAckRate < 0 || s.DropAckRate > 1 {
+		return fmt.Errorf("drop_ack_rate must be between 0 and 1, got %v", s.DropAckRate)
+	}
+	if s.LatencyMs < 0 {
+		return fmt.Errorf("latency_ms must not be negative, got %d", s.LatencyMs)
+	}
+	return nil
+}
+
+// Active reports whether any fault is configured
+func (s ChaosSettings) Active() bool {
+	return s.FailureRate > 0 || s.LatencyMs > 0 || s.DropAckRate > 0
+}
+
+// ChaosStore shares chaos overrides between the server and workers through Redis
+type ChaosStore struct {
+	client redis.Cmdable
+	layout *Layout
+}
+
+// NewChaosStore creates a chaos settings store
+func NewChaosStore(client redis.Cmdable, layout *Layout) *ChaosStore {
+	return &ChaosStore{client: client, layout: layout}
+}
+
+// Get returns the stored override, or false when none is set
+func (c *ChaosStore) Get(ctx context.Context) (ChaosSettings, bool, error) {
+	var settings ChaosSettings
+
+	data, err := c.client.Get(ctx, c.layout.key(chaosSettingsKey)).Bytes()
+	if err == redis.Nil {
+		return settings, false, nil
+	}
+	if err != nil {
+		return settings, false, fmt.Errorf("failed to get chaos settings: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return settings, false, fmt.Errorf("failed to unmarshal chaos settings: %w", err)
+	}
+
+	return settings, true, nil
+}
+
+// Set stores an override that every chaos-enabled process picks up
+func (c *ChaosStore) Set(ctx context.Context, settings ChaosSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal chaos settings: %w", err)
+	}
+
+	if err := c.client.Set(ctx, c.layout.key(chaosSettingsKey), data, 0).Err(); err != nil {
+		return fmt.Errorf("failed to store chaos settings: %w", err)
+	}
+
+	return nil
+}
+
+// Clear removes the override, falling back to each process's configured defaults
+func (c *ChaosStore) Clear(ctx context.Context) error {
+	if err := c.client.Del(ctx, c.layout.key(chaosSettingsKey)).Err(); err != nil {
+		return fmt.Errorf("failed to clear chaos settings: %w", err)
+	}
+	return nil
+}
+
+// ChaosQueue wraps a Queue and injects failures, latency and dropped acks
+type ChaosQueue struct {
+	Queue
+	store    *ChaosStore
+	defaults ChaosSettings
+	logger   *zap.Logger
+
+	mu          sync.Mutex
+	settings    ChaosSettings
+	refreshedAt time.Time
+	rand        *rand.Rand
+}
+
+// NewChaosQueue wraps inner; store may be nil to use only the defaults
+func NewChaosQueue(inner Queue, store *ChaosStore, defaults ChaosSettings, logger *zap.Logger) *ChaosQueue {
+	return &ChaosQueue{
+		Queue:    inner,
+		store:    store,
+		defaults: defaults,
+		settings: defaults,
+		logger:   logger,
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Enqueue may delay, fail, or store the job and then report a dropped ack
+func (c *ChaosQueue) Enqueue(ctx context.Context, job *types.Job) error {
+	settings := c.current(ctx)
+	if err := c.delay(ctx, settings); err != nil {
+		return err
+	}
+
+	if c.roll(settings.FailureRate) {
+		c.logger.Debug("Chaos: failing enqueue", zap.String("job_id", job.ID))
+		return ErrChaosInjected
+	}
+
+	if err := c.Queue.Enqueue(ctx, job); err != nil {
+		return err
+	}
+
+	if c.roll(settings.DropAckRate) {
+		c.logger.Debug("Chaos: dropping enqueue ack", zap.String("job_id", job.ID))
+		return ErrChaosAckDropped
+	}
+
+	return nil
+}
+
+// Dequeue may delay or fail, and a dropped ack redelivers the job later
+func (c *ChaosQueue) Dequeue(ctx context.Context) (*types.Job, error) {
+	settings := c.current(ctx)
+	if err := c.delay(ctx, settings); err != nil {
+		return nil, err
+	}
+
+	if c.roll(settings.FailureRate) {
+		return nil, ErrChaosInjected
+	}
+
+	job, err := c.Queue.Dequeue(ctx)
+	if err != nil || job == nil {
+		return job, err
+	}
+
+	if c.roll(settings.DropAckRate) {
+		c.logger.Debug("Chaos: dropping dequeue ack, job will be delivered again", zap.String("job_id", job.ID))
+		redelivery := *job
+		if err := c.Queue.Enqueue(ctx, &redelivery); err != nil {
+			c.logger.Warn("Chaos: failed to redeliver job", zap.String("job_id", job.ID), zap.Error(err))
+		}
+	}
+
+	return job, nil
+}
+
+// Ack passes acks on to queues that track deliveries
+func (c *ChaosQueue) Ack(ctx context.Context, job *types.Job) error {
+	if acker, ok := c.Queue.(Acker); ok {
+		return acker.Ack(ctx, job)
+	}
+	return nil
+}
+
+// Settings returns the settings currently in effect
+func (c *ChaosQueue) Settings(ctx context.Context) ChaosSettings {
+	return c.current(ctx)
+}
+
+// current returns the active settings, refreshing the store override periodically
+func (c *ChaosQueue) current(ctx context.Context) ChaosSettings {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.store == nil || time.Since(c.refreshedAt) < chaosRefreshInterval {
+		return c.settings
+	}
+	c.refreshedAt = time.Now()
+
+	override, ok, err := c.store.Get(ctx)
+	switch {
+	case err != nil:
+		c.logger.Warn("Failed to refresh chaos settings", zap.Error(err))
+	case ok:
+		c.settings = override
+	default:
+		c.settings = c.defaults
+	}
+
+	return c.settings
+}
+
+// delay sleeps for a random duration up to the configured latency
+func (c *ChaosQueue) delay(ctx context.Context, settings ChaosSettings) error {
+	if settings.LatencyMs <= 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	d := time.Duration(c.rand.Intn(settings.LatencyMs+1)) * time.Millisecond
+	c.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// roll returns true with the given probability
+func (c *ChaosQueue) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.rand.Float64() < rate
+}
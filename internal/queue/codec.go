@@ -0,0 +1,149 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+// Job wire formats for RedisOptions.Format
+const (
+	FormatJSON     = "json"
+	FormatProtobuf = "protobuf"
+	FormatMsgpack  = "msgpack"
+)
+
+// Codec serializes jobs for the Redis queues. Every encoded job but JSON
+// starts with the codec's format byte, so entries are decoded by their own
+// format and queues can hold a mix while a deployment switches.
+type Codec interface {
+	// Name is the format name used in configuration, e.g. protobuf
+	Name() string
+
+	// FormatByte prefixes every job the codec encodes; 0 for unprefixed JSON
+	FormatByte() byte
+
+	// Marshal encodes the job, without the format byte
+	Marshal(job *types.Job) ([]byte, error)
+
+	// Unmarshal decodes a job encoded by Marshal
+	Unmarshal(data []byte, job *types.Job) error
+}
+
+// codecs holds the known codecs by format name
+var codecs = map[string]Codec{
+	FormatJSON:     jsonCodec{},
+	FormatProtobuf: protobufCodec{},
+	FormatMsgpack:  msgpackCodec{},
+}
+
+// RegisterCodec adds a codec that RedisOptions.Format can then select. It
+// must be called before the queues are created, and every process reading
+// the queues needs it registered too. The format byte must be unique and
+// can't be '{' or whitespace, which mark JSON.
+func RegisterCodec(c Codec) error {
+	if c == nil || c.Name() == "" {
+		return fmt.Errorf("codec needs a name")
+	}
+	if _, exists := codecs[c.Name()]; exists {
+		return fmt.Errorf("codec %s is already registered", c.Name())
+	}
+
+	format := c.FormatByte()
+	if format == 0 || bytes.IndexByte([]byte("{ \t\r\n"), format) >= 0 {
+		return fmt.Errorf("codec %s: format byte 0x%02x is reserved", c.Name(), format)
+	}
+	for _, existing := range codecs {
+		if existing.FormatByte() == format {
+			return fmt.Errorf("codec %s: format byte 0x%02x is taken by %s", c.Name(), format, existing.Name())
+		}
+	}
+
+	codecs[c.Name()] = c
+	return nil
+}
+
+// ValidateFormat checks that format is empty or a known codec
+func ValidateFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	if _, ok := codecs[format]; !ok {
+		return fmt.Errorf("unsupported job format %q, expected one of %s", format, strings.Join(Formats(), ", "))
+	}
+	return nil
+}
+
+// Formats returns the names of the known codecs, sorted
+func Formats() []string {
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// codecFor returns the codec of a format, JSON when it is empty
+func codecFor(format string) Codec {
+	if codec, ok := codecs[format]; ok {
+		return codec
+	}
+	return jsonCodec{}
+}
+
+// DecodeJob parses a job stored in a Redis queue in any known format
+func DecodeJob(data []byte) (*types.Job, error) {
+	var job types.Job
+	if err := decodeJob(data, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// decodeJob parses a job by its format byte; JSON, which starts with '{'
+// or whitespace, carries none
+func decodeJob(data []byte, job *types.Job) error {
+	if len(data) == 0 {
+		return fmt.Errorf("failed to unmarshal job: empty entry")
+	}
+
+	codec := Codec(jsonCodec{})
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) == 0 || trimmed[0] != '{' {
+		codec = nil
+		for _, candidate := range codecs {
+			if format := candidate.FormatByte(); format != 0 && format == data[0] {
+				codec = candidate
+				data = data[1:]
+				break
+			}
+		}
+		if codec == nil {
+			return fmt.Errorf("failed to unmarshal job: unknown format byte 0x%02x", data[0])
+		}
+	}
+
+	if err := codec.Unmarshal(data, job); err != nil {
+		return fmt.Errorf("failed to unmarshal %s job: %w", codec.Name(), err)
+	}
+	return nil
+}
+
+// jsonCodec writes jobs as plain JSON, the format jobs were always stored in
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return FormatJSON }
+
+func (jsonCodec) FormatByte() byte { return 0 }
+
+func (jsonCodec) Marshal(job *types.Job) ([]byte, error) {
+	return json.Marshal(job)
+}
+
+func (jsonCodec) Unmarshal(data []byte, job *types.Job) error {
+	return json.Unmarshal(data, job)
+}
@@ -0,0 +1,187 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/ugorji/go/codec"
+)
+
+// formatMsgpack prefixes jobs in MessagePack
+const formatMsgpack byte = 0x02
+
+// msgpackHandle writes byte slices as bin and strings as str (the new spec)
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+// msgpackJob is the MessagePack layout of a job. Times are Unix nanoseconds
+// and metadata stays JSON, so a job decodes exactly as it would from JSON.
+type msgpackJob struct {
+	ID              string        `codec:"id"`
+	Type            string        `codec:"type"`
+	Payload         []byte        `codec:"payload,omitempty"`
+	SealedPayload   []byte        `codec:"sealed_payload,omitempty"` // Compressed or encrypted payload as raw bytes instead of base64
+	Attempts        int           `codec:"attempts,omitempty"`
+	MaxRetries      int           `codec:"max_retries,omitempty"`
+	CreatedAt       int64         `codec:"created_at,omitempty"`
+	UpdatedAt       int64         `codec:"updated_at,omitempty"`
+	EnqueuedAt      int64         `codec:"enqueued_at,omitempty"`
+	Metadata        []byte        `codec:"metadata,omitempty"`
+	KeyID           string        `codec:"key_id,omitempty"`
+	ScheduleID      string        `codec:"schedule_id,omitempty"`
+	Queue           string        `codec:"queue,omitempty"`
+	Encoding        string        `codec:"encoding,omitempty"`
+	EnvelopeVersion int           `codec:"envelope_version,omitempty"`
+	Timeout         time.Duration `codec:"timeout_ns,omitempty"`
+	ExpiresAt       *int64        `codec:"expires_at,omitempty"`
+	DependsOn       []string      `codec:"depends_on,omitempty"`
+	OnParentFailure string        `codec:"on_parent_failure,omitempty"`
+	ChainID         string        `codec:"chain_id,omitempty"`
+	PreviousOutput  []byte        `codec:"previous_output,omitempty"`
+	WorkflowID      string        `codec:"workflow_id,omitempty"`
+	WorkflowNode    string        `codec:"workflow_node,omitempty"`
+	Signature       string        `codec:"signature,omitempty"`
+	PayloadVersion  int           `codec:"payload_version,omitempty"`
+	Backoff         []byte        `codec:"backoff,omitempty"` // JSON
+}
+
+// msgpackCodec writes jobs as MessagePack maps. Compressed and encrypted
+// payloads, which JSON has to carry as base64 strings, are stored as raw
+// bytes, which is where most of the saving on binary-heavy queues comes from.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return FormatMsgpack }
+
+func (msgpackCodec) FormatByte() byte { return formatMsgpack }
+
+func (msgpackCodec) Marshal(job *types.Job) ([]byte, error) {
+	stored := msgpackJob{
+		ID:              job.ID,
+		Type:            job.Type,
+		Attempts:        job.Attempts,
+		MaxRetries:      job.MaxRetries,
+		CreatedAt:       unixNano(job.CreatedAt),
+		UpdatedAt:       unixNano(job.UpdatedAt),
+		EnqueuedAt:      unixNano(job.EnqueuedAt),
+		KeyID:           job.KeyID,
+		ScheduleID:      job.ScheduleID,
+		Queue:           job.Queue,
+		Encoding:        job.Encoding,
+		EnvelopeVersion: job.EnvelopeVersion,
+		Timeout:         job.Timeout,
+		DependsOn:       job.DependsOn,
+		OnParentFailure: job.OnParentFailure,
+		ChainID:         job.ChainID,
+		PreviousOutput:  job.PreviousOutput,
+		WorkflowID:      job.WorkflowID,
+		WorkflowNode:    job.WorkflowNode,
+		Signature:       job.Signature,
+		PayloadVersion:  job.PayloadVersion,
+	}
+
+	// A sealed payload is a JSON string of base64; anything else stays as is
+	var sealed []byte
+	if (job.Encoding != "" || job.KeyID != "") && json.Unmarshal(job.Payload, &sealed) == nil {
+		stored.SealedPayload = sealed
+	} else {
+		stored.Payload = job.Payload
+	}
+
+	if len(job.Metadata) > 0 {
+		metadata, err := json.Marshal(job.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		stored.Metadata = metadata
+	}
+	if job.Backoff != nil {
+		backoff, err := json.Marshal(job.Backoff)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal backoff: %w", err)
+		}
+		stored.Backoff = backoff
+	}
+	if job.ExpiresAt != nil {
+		expiresAt := job.ExpiresAt.UnixNano()
+		stored.ExpiresAt = &expiresAt
+	}
+
+	var data []byte
+	if err := codec.NewEncoderBytes(&data, msgpackHandle).Encode(&stored); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, job *types.Job) error {
+	var stored msgpackJob
+	if err := codec.NewDecoderBytes(data, msgpackHandle).Decode(&stored); err != nil {
+		return err
+	}
+
+	*job = types.Job{
+		ID:              stored.ID,
+		Type:            stored.Type,
+		Payload:         stored.Payload,
+		Attempts:        stored.Attempts,
+		MaxRetries:      stored.MaxRetries,
+		CreatedAt:       fromUnixNano(stored.CreatedAt),
+		UpdatedAt:       fromUnixNano(stored.UpdatedAt),
+		EnqueuedAt:      fromUnixNano(stored.EnqueuedAt),
+		KeyID:           stored.KeyID,
+		ScheduleID:      stored.ScheduleID,
+		Queue:           stored.Queue,
+		Encoding:        stored.Encoding,
+		EnvelopeVersion: stored.EnvelopeVersion,
+		Timeout:         stored.Timeout,
+		DependsOn:       stored.DependsOn,
+		OnParentFailure: stored.OnParentFailure,
+		ChainID:         stored.ChainID,
+		PreviousOutput:  stored.PreviousOutput,
+		WorkflowID:      stored.WorkflowID,
+		WorkflowNode:    stored.WorkflowNode,
+		Signature:       stored.Signature,
+		PayloadVersion:  stored.PayloadVersion,
+	}
+
+	if stored.SealedPayload != nil {
+		payload, err := json.Marshal(stored.SealedPayload)
+		if err != nil {
+			return fmt.Errorf("failed to encode sealed payload: %w", err)
+		}
+		job.Payload = payload
+	}
+	if len(stored.Metadata) > 0 {
+		if err := json.Unmarshal(stored.Metadata, &job.Metadata); err != nil {
+			return fmt.Errorf("invalid metadata: %w", err)
+		}
+	}
+	if len(stored.Backoff) > 0 {
+		job.Backoff = &types.BackoffPolicy{}
+		if err := json.Unmarshal(stored.Backoff, job.Backoff); err != nil {
+			return fmt.Errorf("invalid backoff: %w", err)
+		}
+	}
+	if stored.ExpiresAt != nil {
+		expiresAt := time.Unix(0, *stored.ExpiresAt).UTC()
+		job.ExpiresAt = &expiresAt
+	}
+	return nil
+}
+
+// unixNano returns t in Unix nanoseconds, 0 for the zero time
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// fromUnixNano is the inverse of unixNano
+func fromUnixNano(nanos int64) time.Time {
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos).UTC()
+}
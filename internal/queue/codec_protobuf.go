@@ -0,0 +1,239 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// formatProtobuf prefixes jobs in the Protobuf wire format
+const formatProtobuf byte = 0x01
+
+// Field numbers of the Job message, see job.proto. Numbers are never reused;
+// readers skip fields they don't know.
+const (
+	pbID              protowire.Number = 1
+	pbType            protowire.Number = 2
+	pbPayload         protowire.Number = 3
+	pbAttempts        protowire.Number = 4
+	pbMaxRetries      protowire.Number = 5
+	pbCreatedAt       protowire.Number = 6
+	pbUpdatedAt       protowire.Number = 7
+	pbEnqueuedAt      protowire.Number = 8
+	pbMetadata        protowire.Number = 9
+	pbKeyID           protowire.Number = 10
+	pbScheduleID      protowire.Number = 11
+	pbQueue           protowire.Number = 12
+	pbEncoding        protowire.Number = 13
+	pbEnvelopeVersion protowire.Number = 14
+	pbTimeout         protowire.Number = 15
+	pbExpiresAt       protowire.Number = 16
+	pbDependsOn       protowire.Number = 17
+	pbOnParentFailure protowire.Number = 18
+	pbChainID         protowire.Number = 19
+	pbPreviousOutput  protowire.Number = 20
+	pbWorkflowID      protowire.Number = 21
+	pbWorkflowNode    protowire.Number = 22
+	pbSignature       protowire.Number = 23
+	pbPayloadVersion  protowire.Number = 24
+	pbBackoff         protowire.Number = 25
+)
+
+// protobufCodec writes jobs as the Job message in job.proto. The payload,
+// metadata and previous output stay JSON bytes, so only the envelope is
+// binary; handlers see the same job either way.
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string { return FormatProtobuf }
+
+func (protobufCodec) FormatByte() byte { return formatProtobuf }
+
+func (protobufCodec) Marshal(job *types.Job) ([]byte, error) {
+	buf := make([]byte, 0, 128+len(job.Payload)+len(job.PreviousOutput))
+
+	buf = appendString(buf, pbID, job.ID)
+	buf = appendString(buf, pbType, job.Type)
+	buf = appendBytes(buf, pbPayload, job.Payload)
+	buf = appendVarint(buf, pbAttempts, int64(job.Attempts))
+	buf = appendVarint(buf, pbMaxRetries, int64(job.MaxRetries))
+	buf = appendTime(buf, pbCreatedAt, job.CreatedAt)
+	buf = appendTime(buf, pbUpdatedAt, job.UpdatedAt)
+	buf = appendTime(buf, pbEnqueuedAt, job.EnqueuedAt)
+	if len(job.Metadata) > 0 {
+		metadata, err := json.Marshal(job.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		buf = appendBytes(buf, pbMetadata, metadata)
+	}
+	buf = appendString(buf, pbKeyID, job.KeyID)
+	buf = appendString(buf, pbScheduleID, job.ScheduleID)
+	buf = appendString(buf, pbQueue, job.Queue)
+	buf = appendString(buf, pbEncoding, job.Encoding)
+	buf = appendVarint(buf, pbEnvelopeVersion, int64(job.EnvelopeVersion))
+	buf = appendVarint(buf, pbTimeout, int64(job.Timeout))
+	if job.ExpiresAt != nil {
+		// Written even when zero, since presence is what sets the deadline
+		buf = protowire.AppendTag(buf, pbExpiresAt, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, uint64(job.ExpiresAt.UnixNano()))
+	}
+	for _, parent := range job.DependsOn {
+		buf = protowire.AppendTag(buf, pbDependsOn, protowire.BytesType)
+		buf = protowire.AppendString(buf, parent)
+	}
+	buf = appendString(buf, pbOnParentFailure, job.OnParentFailure)
+	buf = appendString(buf, pbChainID, job.ChainID)
+	buf = appendBytes(buf, pbPreviousOutput, job.PreviousOutput)
+	buf = appendString(buf, pbWorkflowID, job.WorkflowID)
+	buf = appendString(buf, pbWorkflowNode, job.WorkflowNode)
+	buf = appendString(buf, pbSignature, job.Signature)
+	buf = appendVarint(buf, pbPayloadVersion, int64(job.PayloadVersion))
+	if job.Backoff != nil {
+		backoff, err := json.Marshal(job.Backoff)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal backoff: %w", err)
+		}
+		buf = appendBytes(buf, pbBackoff, backoff)
+	}
+	return buf, nil
+}
+
+func (protobufCodec) Unmarshal(data []byte, job *types.Job) error {
+	*job = types.Job{}
+	for len(data) > 0 {
+		number, wireType, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch wireType {
+		case protowire.VarintType:
+			value, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+			setVarintField(job, number, int64(value))
+		case protowire.BytesType:
+			value, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+			if err := setBytesField(job, number, value); err != nil {
+				return err
+			}
+		default:
+			n := protowire.ConsumeFieldValue(number, wireType, data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+		}
+	}
+	return nil
+}
+
+func setVarintField(job *types.Job, number protowire.Number, value int64) {
+	switch number {
+	case pbAttempts:
+		job.Attempts = int(value)
+	case pbMaxRetries:
+		job.MaxRetries = int(value)
+	case pbCreatedAt:
+		job.CreatedAt = time.Unix(0, value).UTC()
+	case pbUpdatedAt:
+		job.UpdatedAt = time.Unix(0, value).UTC()
+	case pbEnqueuedAt:
+		job.EnqueuedAt = time.Unix(0, value).UTC()
+	case pbEnvelopeVersion:
+		job.EnvelopeVersion = int(value)
+	case pbPayloadVersion:
+		job.PayloadVersion = int(value)
+	case pbTimeout:
+		job.Timeout = time.Duration(value)
+	case pbExpiresAt:
+		expiresAt := time.Unix(0, value).UTC()
+		job.ExpiresAt = &expiresAt
+	}
+}
+
+func setBytesField(job *types.Job, number protowire.Number, value []byte) error {
+	switch number {
+	case pbID:
+		job.ID = string(value)
+	case pbType:
+		job.Type = string(value)
+	case pbPayload:
+		job.Payload = append(json.RawMessage(nil), value...)
+	case pbMetadata:
+		if err := json.Unmarshal(value, &job.Metadata); err != nil {
+			return fmt.Errorf("invalid metadata: %w", err)
+		}
+	case pbKeyID:
+		job.KeyID = string(value)
+	case pbScheduleID:
+		job.ScheduleID = string(value)
+	case pbQueue:
+		job.Queue = string(value)
+	case pbEncoding:
+		job.Encoding = string(value)
+	case pbDependsOn:
+		job.DependsOn = append(job.DependsOn, string(value))
+	case pbOnParentFailure:
+		job.OnParentFailure = string(value)
+	case pbChainID:
+		job.ChainID = string(value)
+	case pbPreviousOutput:
+		job.PreviousOutput = append(json.RawMessage(nil), value...)
+	case pbWorkflowID:
+		job.WorkflowID = string(value)
+	case pbWorkflowNode:
+		job.WorkflowNode = string(value)
+	case pbSignature:
+		job.Signature = string(value)
+	case pbBackoff:
+		job.Backoff = &types.BackoffPolicy{}
+		if err := json.Unmarshal(value, job.Backoff); err != nil {
+			return fmt.Errorf("invalid backoff: %w", err)
+		}
+	}
+	return nil
+}
+
+// appendString writes a string field, leaving out empty ones as proto3 does
+func appendString(buf []byte, number protowire.Number, value string) []byte {
+	if value == "" {
+		return buf
+	}
+	buf = protowire.AppendTag(buf, number, protowire.BytesType)
+	return protowire.AppendString(buf, value)
+}
+
+func appendBytes(buf []byte, number protowire.Number, value []byte) []byte {
+	if len(value) == 0 {
+		return buf
+	}
+	buf = protowire.AppendTag(buf, number, protowire.BytesType)
+	return protowire.AppendBytes(buf, value)
+}
+
+func appendVarint(buf []byte, number protowire.Number, value int64) []byte {
+	if value == 0 {
+		return buf
+	}
+	buf = protowire.AppendTag(buf, number, protowire.VarintType)
+	return protowire.AppendVarint(buf, uint64(value))
+}
+
+// appendTime writes a timestamp as Unix nanoseconds, leaving out the zero time
+func appendTime(buf []byte, number protowire.Number, value time.Time) []byte {
+	if value.IsZero() {
+		return buf
+	}
+	return appendVarint(buf, number, value.UnixNano())
+}
@@ -0,0 +1,91 @@
+package queue
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+// Payload encodings recorded in Job.Encoding
+const (
+	EncodingGzip = "gzip"
+)
+
+// DefaultCompressionThreshold is the payload size compression starts at when
+// RedisOptions leaves the threshold unset
+const DefaultCompressionThreshold = 64 * 1024
+
+// ValidateCompression checks that encoding is empty or supported
+func ValidateCompression(encoding string) error {
+	switch encoding {
+	case "", EncodingGzip:
+		return nil
+	default:
+		return fmt.Errorf("unsupported payload compression %q", encoding)
+	}
+}
+
+// compressJob returns a copy of the job with its payload compressed when the
+// layout turns compression on and the payload is at least the threshold.
+// Jobs are decoded by their Encoding marker regardless, so turning
+// compression off never strands jobs.
+func (l *Layout) compressJob(job *types.Job) (*types.Job, error) {
+	if l.compression == "" || job.Encoding != "" || job.KeyID != "" || len(job.Payload) < l.compressionThreshold {
+		return job, nil
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(job.Payload); err != nil {
+		return nil, fmt.Errorf("failed to compress payload: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress payload: %w", err)
+	}
+
+	// []byte marshals to a base64 JSON string, keeping Payload valid JSON
+	encoded, err := json.Marshal(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode compressed payload: %w", err)
+	}
+
+	compressed := *job
+	compressed.Payload = encoded
+	compressed.Encoding = l.compression
+	return &compressed, nil
+}
+
+// decompressJob restores a compressed payload in place
+func decompressJob(job *types.Job) error {
+	switch job.Encoding {
+	case "":
+		return nil
+	case EncodingGzip:
+	default:
+		return fmt.Errorf("job %s has unsupported payload encoding %q", job.ID, job.Encoding)
+	}
+
+	var compressed []byte
+	if err := json.Unmarshal(job.Payload, &compressed); err != nil {
+		return fmt.Errorf("invalid compressed payload: %w", err)
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return fmt.Errorf("failed to decompress payload: %w", err)
+	}
+	defer reader.Close()
+
+	payload, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to decompress payload: %w", err)
+	}
+
+	job.Payload = payload
+	job.Encoding = ""
+	return nil
+}
@@ -0,0 +1,225 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	dependencyKeyPrefix     = "dependency:"                // Prefix of the per-job keys below
+	dependencyJobsKey       = "dependency:jobs"            // Hash of waiting job ID → job JSON
+	dependencyIgnoreKey     = "dependency:ignore_failures" // Set of waiting job IDs that run even if a parent fails
+	dependencyPendingPrefix = "dependency:pending:"        // Set per waiting job of parents that haven't finished
+	dependencyChildPrefix   = "dependency:children:"       // Set per unfinished parent of the jobs waiting on it
+	dependencyOutcomePrefix = "dependency:outcome:"        // String per finished job, completed or failed, expires after the TTL
+)
+
+// holdScript holds a job until its unfinished parents finish.
+// KEYS[1] jobs hash, KEYS[2] ignore set; ARGV[1] key prefix, ARGV[2] job ID,
+// ARGV[3] job JSON, ARGV[4] 1 to ignore parent failures, ARGV[5..] parents.
+// Returns {ready}, {waiting} or {failed, parent}.
+var holdScript = redis.NewScript(`
+local prefix = ARGV[1]
+local ignore = ARGV[4] == '1'
+local pending = {}
+for i = 5, #ARGV do
+	local outcome = redis.call('GET', prefix .. 'outcome:' .. ARGV[i])
+	if outcome == 'failed' and not ignore then
+		return {'failed', ARGV[i]}
+	end
+	if not outcome then
+		table.insert(pending, ARGV[i])
+	end
+end
+if #pending == 0 then
+	return {'ready'}
+end
+redis.call('HSET', KEYS[1], ARGV[2], ARGV[3])
+if ignore then
+	redis.call('SADD', KEYS[2], ARGV[2])
+end
+redis.call('SADD', prefix .. 'pending:' .. ARGV[2], unpack(pending))
+for _, parent in ipairs(pending) do
+	redis.call('SADD', prefix .. 'children:' .. parent, ARGV[2])
+end
+return {'waiting'}
+`)
+
+// resolveScript records a finished parent and releases the jobs waiting on
+// it. KEYS[1] jobs hash, KEYS[2] ignore set; ARGV[1] key prefix, ARGV[2]
+// parent ID, ARGV[3] completed or failed, ARGV[4] outcome TTL in seconds.
+// Returns pairs of ready or failed and the released job's JSON.
+var resolveScript = redis.NewScript(`
+local prefix = ARGV[1]
+local failed = ARGV[3] == 'failed'
+redis.call('SET', prefix .. 'outcome:' .. ARGV[2], ARGV[3], 'EX', ARGV[4])
+local childrenKey = prefix .. 'children:' .. ARGV[2]
+local children = redis.call('SMEMBERS', childrenKey)
+redis.call('DEL', childrenKey)
+local released = {}
+for _, child in ipairs(children) do
+	local data = redis.call('HGET', KEYS[1], child)
+	if data then
+		local pendingKey = prefix .. 'pending:' .. child
+		local cancelled = failed and redis.call('SISMEMBER', KEYS[2], child) == 0
+		if not cancelled then
+			redis.call('SREM', pendingKey, ARGV[2])
+		end
+		if cancelled or redis.call('SCARD', pendingKey) == 0 then
+			redis.call('HDEL', KEYS[1], child)
+			redis.call('SREM', KEYS[2], child)
+			redis.call('DEL', pendingKey)
+			table.insert(released, cancelled and 'failed' or 'ready')
+			table.insert(released, data)
+		end
+	end
+end
+return released
+`)
+
+// ErrDependencyFailed is returned when a job depends on a job that already failed
+var ErrDependencyFailed = errors.New("dependency failed")
+
+// DependencyTracker holds jobs submitted with depends_on until every job
+// they depend on has finished, then enqueues them. Outcomes of finished jobs
+// are kept for a TTL, so a job may also depend on one that already ran.
+type DependencyTracker struct {
+	client   redis.Cmdable
+	layout   *Layout
+	queue    Queue // Where released jobs are enqueued
+	keyring  *encryption.Keyring
+	statuses *StatusStore
+	ttl      time.Duration
+}
+
+// NewDependencyTracker creates a dependency tracker; a finished job's
+// outcome is remembered for ttl
+func NewDependencyTracker(client redis.Cmdable, layout *Layout, queue Queue, ttl time.Duration) *DependencyTracker {
+	return &DependencyTracker{
+		client: client,
+		layout: layout,
+		queue:  queue,
+		ttl:    ttl,
+	}
+}
+
+// SetKeyring enables payload encryption for waiting jobs
+func (d *DependencyTracker) SetKeyring(keyring *encryption.Keyring) {
+	d.keyring = keyring
+}
+
+// SetStatusStore records the waiting state of held jobs
+func (d *DependencyTracker) SetStatusStore(statuses *StatusStore) {
+	d.statuses = statuses
+}
+
+// Submit enqueues the job if everything it depends on has completed and
+// otherwise holds it, reporting whether it was held. A parent that already
+// failed returns ErrDependencyFailed unless the job ignores parent failures.
+func (d *DependencyTracker) Submit(ctx context.Context, job *types.Job) (bool, error) {
+	if err := job.Validate(); err != nil {
+		return false, fmt.Errorf("job validation failed: %w", err)
+	}
+
+	sealed, err := d.layout.sealJob(job, d.keyring)
+	if err != nil {
+		return false, err
+	}
+	jobData, err := d.layout.encodeJob(sealed)
+	if err != nil {
+		return false, err
+	}
+
+	ignore := "0"
+	if job.IgnoresParentFailure() {
+		ignore = "1"
+	}
+	args := []interface{}{d.layout.key(dependencyKeyPrefix), job.ID, jobData, ignore}
+	for _, parentID := range job.DependsOn {
+		args = append(args, parentID)
+	}
+
+	reply, err := holdScript.Run(ctx, d.client, []string{d.layout.key(dependencyJobsKey), d.layout.key(dependencyIgnoreKey)}, args...).StringSlice()
+	if err != nil {
+		return false, fmt.Errorf("failed to hold job: %w", err)
+	}
+
+	switch reply[0] {
+	case "failed":
+		return false, fmt.Errorf("%w: job %s failed", ErrDependencyFailed, reply[1])
+	case "ready":
+		if err := d.queue.Enqueue(ctx, job); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+
+	if d.statuses != nil {
+		if err := d.statuses.Set(ctx, job, types.StatusWaiting, ""); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// Resolve records how a job finished and releases the jobs waiting on it.
+// Jobs whose last parent finished are enqueued, the next job of a chain
+// carrying the finished job's output; jobs that can no longer run because
+// the parent failed are returned for the caller to fail, which in turn
+// resolves their own dependents.
+func (d *DependencyTracker) Resolve(ctx context.Context, finished *types.Job, result *types.JobResult) ([]*types.Job, error) {
+	outcome := string(types.StatusCompleted)
+	if result.Status != types.StatusCompleted {
+		outcome = string(types.StatusFailed)
+	}
+
+	ttl := strconv.Itoa(int(d.ttl.Seconds()))
+	reply, err := resolveScript.Run(ctx, d.client, []string{d.layout.key(dependencyJobsKey), d.layout.key(dependencyIgnoreKey)},
+		d.layout.key(dependencyKeyPrefix), finished.ID, outcome, ttl).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve dependents: %w", err)
+	}
+
+	var cancelled []*types.Job
+	var errs []error
+	for i := 0; i+1 < len(reply); i += 2 {
+		var released types.Job
+		if err := decodeJob([]byte(reply[i+1]), &released); err != nil {
+			errs = append(errs, fmt.Errorf("dependent job: %w", err))
+			continue
+		}
+		if err := openJob(&released, d.keyring); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if reply[i] == "failed" {
+			cancelled = append(cancelled, &released)
+			continue
+		}
+		if released.ChainID != "" && released.ChainID == finished.ChainID {
+			released.PreviousOutput = result.Output
+		}
+		if err := d.queue.Enqueue(ctx, &released); err != nil {
+			errs = append(errs, fmt.Errorf("failed to enqueue dependent job %s: %w", released.ID, err))
+		}
+	}
+	return cancelled, errors.Join(errs...)
+}
+
+// Pending returns the jobs a waiting job still depends on, empty once it
+// has been released or when it never waited
+func (d *DependencyTracker) Pending(ctx context.Context, jobID string) ([]string, error) {
+	parents, err := d.client.SMembers(ctx, d.layout.key(dependencyPendingPrefix)+jobID).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending dependencies: %w", err)
+	}
+	return parents, nil
+}
@@ -3,22 +3,32 @@ package queue
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
 	"github.com/aneeshsunganahalli/Gopher/pkg/types"
 	"github.com/go-redis/redis/v8"
 )
 
 const (
-	deadLetterQueueKey = "dlq:jobs"  // Redis list storing dead letter jobs
-	dlqStatsKey        = "dlq:stats" // Redis hash storing DLQ stats
+	deadLetterQueueKey = "dlq:jobs"   // Redis list storing dead letter jobs
+	dlqStatsKey        = "dlq:stats"  // Redis hash storing DLQ stats
+	dlqInflowKeyPrefix = "dlq:inflow" // Per-minute counters of jobs sent to the DLQ
 )
 
+// dlqInflowWindow is how long per-minute inflow counters are kept
+const dlqInflowWindow = time.Hour
+
+// ErrNotInDLQ is returned by Reprocess for job IDs with no dead-lettered job
+var ErrNotInDLQ = errors.New("job not in dead letter queue")
+
 // DeadLetterQueue handles failed jobs that have exhausted retry attempts
 type DeadLetterQueue interface {
-	// Send a job to the dead letter queue
-	Send(ctx context.Context, job *types.Job, errorMsg string) error
+	// Send a job to the dead letter queue, classified by reason
+	Send(ctx context.Context, job *types.Job, reason types.FailureReason, errorMsg string) error
 
 	// Get the number of jobs in the DLQ
 	Size(ctx context.Context) (int, error)
@@ -28,6 +38,26 @@ type DeadLetterQueue interface {
 
 	// List jobs in the DLQ with pagination
 	List(ctx context.Context, offset, limit int) ([]*types.FailedJobInfo, error)
+
+	// ListByReason lists jobs with the given failure reason and returns the total that match
+	ListByReason(ctx context.Context, reason types.FailureReason, offset, limit int) ([]*types.FailedJobInfo, int, error)
+
+	// GetStats returns size, age and inflow statistics
+	GetStats(ctx context.Context) (*DLQStats, error)
+}
+
+// DLQStats summarizes the dead letter queue
+type DLQStats struct {
+	Size            int            `json:"size"`
+	TotalSent       int            `json:"total_sent"`
+	Reprocessed     int            `json:"reprocessed"`
+	OldestFailedAt  *time.Time     `json:"oldest_failed_at,omitempty"`
+	OldestAgeSecs   float64        `json:"oldest_age_seconds"`
+	InflowLast5m    int            `json:"inflow_last_5m"`
+	InflowLastHour  int            `json:"inflow_last_hour"`
+	InflowPerMinute float64        `json:"inflow_per_minute"` // Averaged over the last 5 minutes
+	ByType          map[string]int `json:"by_type"`
+	ByReason        map[string]int `json:"by_reason"`
 }
 
 // FailedJobInfo contains information about a failed job in the DLQ
@@ -39,24 +69,43 @@ type FailedJobInfo struct {
 
 // RedisDLQ implements the DeadLetterQueue interface using Redis
 type RedisDLQ struct {
-	client redis.Cmdable
-	queue  Queue // Reference to the main queue for reprocessing
+	client  redis.Cmdable
+	layout  *Layout
+	queue   Queue // Reference to the main queue for reprocessing
+	keyring *encryption.Keyring
 }
 
 // NewRedisDLQ creates a new Redis-backed dead letter queue
-func NewRedisDLQ(client redis.Cmdable, queue Queue) *RedisDLQ {
+func NewRedisDLQ(client redis.Cmdable, layout *Layout, queue Queue) *RedisDLQ {
 	return &RedisDLQ{
 		client: client,
+		layout: layout,
 		queue:  queue,
 	}
 }
 
+// SetKeyring enables payload encryption for dead-lettered jobs
+func (d *RedisDLQ) SetKeyring(keyring *encryption.Keyring) {
+	d.keyring = keyring
+}
+
 // Send puts a failed job into the dead letter queue
-func (d *RedisDLQ) Send(ctx context.Context, job *types.Job, errorMsg string) error {
+func (d *RedisDLQ) Send(ctx context.Context, job *types.Job, reason types.FailureReason, errorMsg string) error {
+	if reason == "" {
+		reason = types.ReasonHandlerError
+	}
+
+	sealed, err := d.layout.sealJob(job, d.keyring)
+	if err != nil {
+		return err
+	}
+
 	failedInfo := &types.FailedJobInfo{
-		Job:      job,
-		Error:    errorMsg,
-		FailedAt: time.Now().UTC(),
+		Job:         sealed,
+		Error:       errorMsg,
+		Reason:      reason,
+		Fingerprint: types.ErrorFingerprint(errorMsg),
+		FailedAt:    time.Now().UTC(),
 	}
 
 	data, err := json.Marshal(failedInfo)
@@ -67,11 +116,18 @@ func (d *RedisDLQ) Send(ctx context.Context, job *types.Job, errorMsg string) er
 	pipe := d.client.Pipeline()
 
 	// Add to DLQ
-	pipe.LPush(ctx, deadLetterQueueKey, data)
+	pipe.LPush(ctx, d.layout.key(deadLetterQueueKey), data)
 
 	// Update stats
-	pipe.HIncrBy(ctx, dlqStatsKey, "total", 1)
-	pipe.HIncrBy(ctx, dlqStatsKey, fmt.Sprintf("type:%s", job.Type), 1)
+	pipe.HIncrBy(ctx, d.layout.key(dlqStatsKey), "total", 1)
+	pipe.HIncrBy(ctx, d.layout.key(dlqStatsKey), "total_sent", 1)
+	pipe.HIncrBy(ctx, d.layout.key(dlqStatsKey), fmt.Sprintf("type:%s", job.Type), 1)
+	pipe.HIncrBy(ctx, d.layout.key(dlqStatsKey), fmt.Sprintf("reason:%s", reason), 1)
+
+	// Track inflow per minute for rate reporting
+	inflowKey := d.layout.dlqInflowKey(failedInfo.FailedAt)
+	pipe.Incr(ctx, inflowKey)
+	pipe.Expire(ctx, inflowKey, dlqInflowWindow+time.Minute)
 
 	_, err = pipe.Exec(ctx)
 	if err != nil {
@@ -83,7 +139,7 @@ func (d *RedisDLQ) Send(ctx context.Context, job *types.Job, errorMsg string) er
 
 // Size returns the number of jobs in the DLQ
 func (d *RedisDLQ) Size(ctx context.Context) (int, error) {
-	result := d.client.LLen(ctx, deadLetterQueueKey)
+	result := d.client.LLen(ctx, d.layout.key(deadLetterQueueKey))
 	if err := result.Err(); err != nil {
 		return 0, fmt.Errorf("failed to get DLQ size: %w", err)
 	}
@@ -94,7 +150,7 @@ func (d *RedisDLQ) Size(ctx context.Context) (int, error) {
 // Reprocess moves a job from the DLQ back to the main queue
 func (d *RedisDLQ) Reprocess(ctx context.Context, jobID string) error {
 	// Get all jobs in the DLQ
-	result := d.client.LRange(ctx, deadLetterQueueKey, 0, -1)
+	result := d.client.LRange(ctx, d.layout.key(deadLetterQueueKey), 0, -1)
 	if err := result.Err(); err != nil {
 		return fmt.Errorf("failed to list DLQ jobs: %w", err)
 	}
@@ -111,12 +167,16 @@ func (d *RedisDLQ) Reprocess(ctx context.Context, jobID string) error {
 		if failedInfo.Job.ID == jobID {
 			found = true
 
+			if err := openJob(failedInfo.Job, d.keyring); err != nil {
+				return fmt.Errorf("failed to decrypt job: %w", err)
+			}
+
 			// Reset attempts counter
 			failedInfo.Job.Attempts = 0
 			failedInfo.Job.UpdatedAt = time.Now().UTC()
 
 			// Remove from DLQ
-			d.client.LRem(ctx, deadLetterQueueKey, 1, item)
+			d.client.LRem(ctx, d.layout.key(deadLetterQueueKey), 1, item)
 
 			// Add to main queue
 			if err := d.queue.Enqueue(ctx, failedInfo.Job); err != nil {
@@ -125,9 +185,10 @@ func (d *RedisDLQ) Reprocess(ctx context.Context, jobID string) error {
 
 			// Update stats
 			pipe := d.client.Pipeline()
-			pipe.HIncrBy(ctx, dlqStatsKey, "total", -1)
-			pipe.HIncrBy(ctx, dlqStatsKey, fmt.Sprintf("type:%s", failedInfo.Job.Type), -1)
-			pipe.HIncrBy(ctx, dlqStatsKey, "reprocessed", 1)
+			pipe.HIncrBy(ctx, d.layout.key(dlqStatsKey), "total", -1)
+			pipe.HIncrBy(ctx, d.layout.key(dlqStatsKey), fmt.Sprintf("type:%s", failedInfo.Job.Type), -1)
+			pipe.HIncrBy(ctx, d.layout.key(dlqStatsKey), fmt.Sprintf("reason:%s", failedInfo.ReasonOrUnknown()), -1)
+			pipe.HIncrBy(ctx, d.layout.key(dlqStatsKey), "reprocessed", 1)
 			_, err := pipe.Exec(ctx)
 			if err != nil {
 				return fmt.Errorf("failed to update DLQ stats: %w", err)
@@ -138,7 +199,7 @@ func (d *RedisDLQ) Reprocess(ctx context.Context, jobID string) error {
 	}
 
 	if !found {
-		return fmt.Errorf("job with ID %s not found in DLQ", jobID)
+		return fmt.Errorf("%w: %s", ErrNotInDLQ, jobID)
 	}
 
 	return nil
@@ -146,7 +207,7 @@ func (d *RedisDLQ) Reprocess(ctx context.Context, jobID string) error {
 
 // List returns jobs in the DLQ with pagination
 func (d *RedisDLQ) List(ctx context.Context, offset, limit int) ([]*types.FailedJobInfo, error) {
-	result := d.client.LRange(ctx, deadLetterQueueKey, int64(offset), int64(offset+limit-1))
+	result := d.client.LRange(ctx, d.layout.key(deadLetterQueueKey), int64(offset), int64(offset+limit-1))
 	if err := result.Err(); err != nil {
 		return nil, fmt.Errorf("failed to list DLQ jobs: %w", err)
 	}
@@ -159,8 +220,122 @@ func (d *RedisDLQ) List(ctx context.Context, offset, limit int) ([]*types.Failed
 			continue
 		}
 
+		if err := openJob(failedInfo.Job, d.keyring); err != nil {
+			continue
+		}
+
 		jobs = append(jobs, &failedInfo)
 	}
 
 	return jobs, nil
 }
+
+// ListByReason scans the DLQ for jobs with the given failure reason.
+// Entries recorded before reasons existed match ReasonUnknown.
+func (d *RedisDLQ) ListByReason(ctx context.Context, reason types.FailureReason, offset, limit int) ([]*types.FailedJobInfo, int, error) {
+	result := d.client.LRange(ctx, d.layout.key(deadLetterQueueKey), 0, -1)
+	if err := result.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list DLQ jobs: %w", err)
+	}
+
+	jobs := make([]*types.FailedJobInfo, 0, limit)
+	matched := 0
+
+	for _, item := range result.Val() {
+		var failedInfo types.FailedJobInfo
+		if err := json.Unmarshal([]byte(item), &failedInfo); err != nil {
+			continue
+		}
+		if failedInfo.ReasonOrUnknown() != reason {
+			continue
+		}
+
+		matched++
+		if matched <= offset || len(jobs) >= limit {
+			continue
+		}
+
+		if err := openJob(failedInfo.Job, d.keyring); err != nil {
+			continue
+		}
+		jobs = append(jobs, &failedInfo)
+	}
+
+	return jobs, matched, nil
+}
+
+// GetStats returns size, age and inflow statistics for the DLQ
+func (d *RedisDLQ) GetStats(ctx context.Context) (*DLQStats, error) {
+	now := time.Now().UTC()
+	minutes := int(dlqInflowWindow / time.Minute)
+
+	inflowKeys := make([]string, minutes)
+	for i := 0; i < minutes; i++ {
+		inflowKeys[i] = d.layout.dlqInflowKey(now.Add(-time.Duration(i) * time.Minute))
+	}
+
+	pipe := d.client.Pipeline()
+	sizeCmd := pipe.LLen(ctx, d.layout.key(deadLetterQueueKey))
+	oldestCmd := pipe.LIndex(ctx, d.layout.key(deadLetterQueueKey), -1) // LPUSH keeps the oldest entry at the tail
+	statsCmd := pipe.HGetAll(ctx, d.layout.key(dlqStatsKey))
+	inflowCmd := pipe.MGet(ctx, inflowKeys...)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get DLQ stats: %w", err)
+	}
+
+	stats := &DLQStats{
+		Size:     int(sizeCmd.Val()),
+		ByType:   make(map[string]int),
+		ByReason: make(map[string]int),
+	}
+
+	for field, value := range statsCmd.Val() {
+		var count int
+		fmt.Sscanf(value, "%d", &count)
+
+		switch {
+		case field == "total_sent":
+			stats.TotalSent = count
+		case field == "reprocessed":
+			stats.Reprocessed = count
+		case strings.HasPrefix(field, "type:"):
+			if count > 0 {
+				stats.ByType[strings.TrimPrefix(field, "type:")] = count
+			}
+		case strings.HasPrefix(field, "reason:"):
+			if count > 0 {
+				stats.ByReason[strings.TrimPrefix(field, "reason:")] = count
+			}
+		}
+	}
+
+	if oldest := oldestCmd.Val(); oldest != "" {
+		var info types.FailedJobInfo
+		if err := json.Unmarshal([]byte(oldest), &info); err == nil && !info.FailedAt.IsZero() {
+			stats.OldestFailedAt = &info.FailedAt
+			stats.OldestAgeSecs = now.Sub(info.FailedAt).Seconds()
+		}
+	}
+
+	for i, value := range inflowCmd.Val() {
+		str, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var count int
+		fmt.Sscanf(str, "%d", &count)
+
+		stats.InflowLastHour += count
+		if i < 5 {
+			stats.InflowLast5m += count
+		}
+	}
+	stats.InflowPerMinute = float64(stats.InflowLast5m) / 5
+
+	return stats, nil
+}
+
+// dlqInflowKey returns the per-minute inflow counter key for t
+func (l *Layout) dlqInflowKey(t time.Time) string {
+	return fmt.Sprintf("%s:%d", l.key(dlqInflowKeyPrefix), t.Unix()/60)
+}
@@ -0,0 +1,127 @@
+package queue
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+const (
+	drainKeyPrefix = "drain:" // Holding lists for drained jobs until they are written out
+	ingestBatch    = 500      // Jobs pushed per pipeline when re-ingesting
+)
+
+// DrainTo atomically takes every job out of the queue and writes them to w as
+// NDJSON, oldest first, without executing them. Jobs in a binary format are
+// converted to JSON but otherwise written as stored, so encrypted payloads
+// stay encrypted. If writing fails the jobs are kept in the returned holding
+// key rather than lost.
+func (r *RedisQueue) DrainTo(ctx context.Context, w io.Writer) (int, string, error) {
+	holdingKey := fmt.Sprintf("%s%s:%d", r.layout.key(drainKeyPrefix), r.key, time.Now().UnixNano())
+
+	// RENAME is atomic, so producers and workers see an empty queue from here on
+	if err := r.client.Rename(ctx, r.key, holdingKey).Err(); err != nil {
+		if strings.Contains(err.Error(), "no such key") {
+			return 0, "", nil
+		}
+		return 0, "", fmt.Errorf("failed to drain queue: %w", err)
+	}
+
+	entries, err := r.client.LRange(ctx, holdingKey, 0, -1).Result()
+	if err != nil {
+		return 0, holdingKey, fmt.Errorf("failed to read drained jobs: %w", err)
+	}
+
+	// Jobs are pushed on the left, so the oldest is last
+	buffered := bufio.NewWriter(w)
+	for i := len(entries) - 1; i >= 0; i-- {
+		line, err := jsonEntry(entries[i])
+		if err != nil {
+			return 0, holdingKey, fmt.Errorf("failed to convert drained job: %w", err)
+		}
+		if _, err := buffered.WriteString(line + "\n"); err != nil {
+			return 0, holdingKey, fmt.Errorf("failed to write drained jobs: %w", err)
+		}
+	}
+	if err := buffered.Flush(); err != nil {
+		return 0, holdingKey, fmt.Errorf("failed to write drained jobs: %w", err)
+	}
+
+	if err := r.client.Del(ctx, holdingKey).Err(); err != nil {
+		return len(entries), holdingKey, fmt.Errorf("failed to remove holding list: %w", err)
+	}
+
+	return len(entries), "", nil
+}
+
+// Ingest pushes jobs from an NDJSON stream written by DrainTo back onto the
+// queue, preserving their order. Every line is checked before anything is
+// pushed, so a malformed file leaves the queue untouched.
+func (r *RedisQueue) Ingest(ctx context.Context, rd io.Reader) (int, error) {
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var entries []string
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var job types.Job
+		if err := json.Unmarshal([]byte(text), &job); err != nil {
+			return 0, fmt.Errorf("failed to parse job on line %d: %w", line, err)
+		}
+		if job.ID == "" || job.Type == "" {
+			return 0, fmt.Errorf("job on line %d is missing an id or type", line)
+		}
+		entry, err := r.layout.encodeJob(&job)
+		if err != nil {
+			return 0, fmt.Errorf("job on line %d: %w", line, err)
+		}
+		entries = append(entries, string(entry))
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read jobs: %w", err)
+	}
+
+	ingested := 0
+	for start := 0; start < len(entries); start += ingestBatch {
+		end := start + ingestBatch
+		if end > len(entries) {
+			end = len(entries)
+		}
+
+		pipe := r.client.Pipeline()
+		for _, entry := range entries[start:end] {
+			pipe.LPush(ctx, r.key, entry)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return ingested, fmt.Errorf("failed to ingest jobs: %w", err)
+		}
+		ingested = end
+	}
+
+	return ingested, nil
+}
+
+// jsonEntry returns a stored job as JSON, converting it from a binary format
+func jsonEntry(entry string) (string, error) {
+	if strings.HasPrefix(strings.TrimSpace(entry), "{") {
+		return entry, nil
+	}
+	job, err := DecodeJob([]byte(entry))
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(job)
+	return string(data), err
+}
@@ -0,0 +1,449 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+)
+
+// replaceListItemScript swaps a list element in place without disturbing its position
+var replaceListItemScript = redis.NewScript(`
+local idx = redis.call('LPOS', KEYS[1], ARGV[1])
+if not idx then
+	return 0
+end
+redis.call('LSET', KEYS[1], idx, ARGV[2])
+return 1
+`)
+
+// replaceSetMemberScript swaps a sorted set member while keeping its score
+var replaceSetMemberScript = redis.NewScript(`
+local score = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not score then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('ZADD', KEYS[1], score, ARGV[2])
+return 1
+`)
+
+// replaceHashFieldScript swaps a hash field's value, or deletes the field
+// when the replacement is empty, only while it still holds the value read
+var replaceHashFieldScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+if ARGV[3] == '' then
+	redis.call('HDEL', KEYS[1], ARGV[1])
+else
+	redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+end
+return 1
+`)
+
+// sealJob returns a copy of the job stamped with the current envelope
+// version, signed when the layout signs, its payload compressed when large
+// and encrypted by the active key. Jobs in a newer envelope are stored
+// untouched.
+func (l *Layout) sealJob(job *types.Job, keyring *encryption.Keyring) (*types.Job, error) {
+	if job.CheckEnvelope() != nil {
+		return job, nil
+	}
+	if job.EnvelopeVersion < types.CurrentEnvelopeVersion {
+		stamped := *job
+		stamped.EnvelopeVersion = types.CurrentEnvelopeVersion
+		job = &stamped
+	}
+
+	// Signed before compression and encryption, so the signature covers the
+	// plaintext every reader sees once the job is opened
+	job, err := l.signing.sign(job)
+	if err != nil {
+		return nil, err
+	}
+	job, err = l.compressJob(job)
+	if err != nil {
+		return nil, err
+	}
+	if keyring == nil || job.KeyID != "" {
+		return job, nil
+	}
+
+	encoded, keyID, err := sealPayload(job.Payload, keyring)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := *job
+	sealed.Payload = encoded
+	sealed.KeyID = keyID
+	return &sealed, nil
+}
+
+// sealPayload encrypts a payload with the active key and returns it with
+// that key's ID
+func sealPayload(payload json.RawMessage, keyring *encryption.Keyring) (json.RawMessage, string, error) {
+	keyID, ciphertext, err := keyring.Encrypt(payload)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encrypt payload: %w", err)
+	}
+
+	// []byte marshals to a base64 JSON string, keeping the payload valid JSON
+	encoded, err := json.Marshal(ciphertext)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode encrypted payload: %w", err)
+	}
+	return encoded, keyID, nil
+}
+
+// openPayload decrypts a payload sealed with the key keyID
+func openPayload(payload json.RawMessage, keyID string, keyring *encryption.Keyring) (json.RawMessage, error) {
+	if keyring == nil {
+		return nil, fmt.Errorf("payload is encrypted with key %q but no keyring is configured", keyID)
+	}
+
+	var ciphertext []byte
+	if err := json.Unmarshal(payload, &ciphertext); err != nil {
+		return nil, fmt.Errorf("invalid encrypted payload: %w", err)
+	}
+	return keyring.Decrypt(keyID, ciphertext)
+}
+
+// openJob decrypts and decompresses the job payload in place
+func openJob(job *types.Job, keyring *encryption.Keyring) error {
+	// A newer envelope may encode its payload in ways this build doesn't
+	// know; it is left as stored for the worker to dead-letter
+	if job == nil || job.CheckEnvelope() != nil {
+		return nil
+	}
+	if job.KeyID == "" {
+		return decompressJob(job)
+	}
+	if keyring == nil {
+		return fmt.Errorf("job %s is encrypted with key %q but no keyring is configured", job.ID, job.KeyID)
+	}
+
+	plaintext, err := openPayload(job.Payload, job.KeyID, keyring)
+	if err != nil {
+		return err
+	}
+
+	job.Payload = plaintext
+	job.KeyID = ""
+	return decompressJob(job)
+}
+
+// needsRotation reports whether the job should be re-encrypted with the active key
+func needsRotation(job *types.Job, keyring *encryption.Keyring) bool {
+	return job.KeyID != keyring.ActiveKeyID()
+}
+
+// rotateJob decrypts the job with its current key and seals it with the active key
+func (l *Layout) rotateJob(job *types.Job, keyring *encryption.Keyring) (*types.Job, error) {
+	if err := openJob(job, keyring); err != nil {
+		return nil, err
+	}
+	return l.sealJob(job, keyring)
+}
+
+// RotationReport summarizes a key rotation run
+type RotationReport struct {
+	Pending      int `json:"pending"`
+	Processing   int `json:"processing"` // Jobs workers have dequeued but not yet acked
+	Scheduled    int `json:"scheduled"`
+	DLQ          int `json:"dlq"`
+	Recordings   int `json:"recordings"`
+	History      int `json:"history"` // Live and archived history records
+	Dependencies int `json:"dependencies"`
+	Workflows    int `json:"workflows"`
+	Quarantine   int `json:"quarantine"`
+	Skipped      int `json:"skipped"`
+}
+
+// RotateEncryptionKeys re-encrypts every job Redis keeps whose payload was
+// sealed with anything other than the active key: pending, processing,
+// scheduled, dead-lettered, recorded, historical, dependency-held,
+// workflow and quarantined jobs. Spool files are re-sealed by each server
+// when it next writes or loads them.
+func RotateEncryptionKeys(ctx context.Context, client redis.Cmdable, layout *Layout, keyring *encryption.Keyring) (*RotationReport, error) {
+	if keyring == nil {
+		return nil, fmt.Errorf("encryption is not enabled")
+	}
+
+	report := &RotationReport{}
+	rotateStoredJob := func(item string) (string, bool, error) {
+		var job types.Job
+		if err := decodeJob([]byte(item), &job); err != nil {
+			return "", false, err
+		}
+		if !needsRotation(&job, keyring) {
+			return "", false, nil
+		}
+		sealed, err := layout.rotateJob(&job, keyring)
+		if err != nil {
+			return "", false, err
+		}
+		data, err := layout.encodeJob(sealed)
+		return string(data), true, err
+	}
+
+	// Pending jobs in the plain, named and priority queues
+	keys, err := layout.pendingQueueKeys(ctx, client)
+	if err != nil {
+		return report, err
+	}
+
+	for _, key := range keys {
+		rotated, skipped, err := rotateList(ctx, client, key, rotateStoredJob)
+		if err != nil {
+			return report, err
+		}
+		report.Pending += rotated
+		report.Skipped += skipped
+	}
+
+	// Jobs in workers' processing lists; Ack finds a rotated job by its ID
+	keys, err = layout.processingQueueKeys(ctx, client)
+	if err != nil {
+		return report, err
+	}
+
+	for _, key := range keys {
+		rotated, skipped, err := rotateList(ctx, client, key, rotateStoredJob)
+		if err != nil {
+			return report, err
+		}
+		report.Processing += rotated
+		report.Skipped += skipped
+	}
+
+	// Dead-lettered jobs
+	rotated, skipped, err := rotateList(ctx, client, layout.key(deadLetterQueueKey), func(item string) (string, bool, error) {
+		var info types.FailedJobInfo
+		if err := json.Unmarshal([]byte(item), &info); err != nil || info.Job == nil {
+			return "", false, fmt.Errorf("invalid DLQ entry")
+		}
+		if !needsRotation(info.Job, keyring) {
+			return "", false, nil
+		}
+		sealed, err := layout.rotateJob(info.Job, keyring)
+		if err != nil {
+			return "", false, err
+		}
+		info.Job = sealed
+		data, err := json.Marshal(&info)
+		return string(data), true, err
+	})
+	if err != nil {
+		return report, err
+	}
+	report.DLQ = rotated
+	report.Skipped += skipped
+
+	// Jobs recorded for replay
+	rotated, skipped, err = rotateList(ctx, client, layout.key(recordingKey), func(item string) (string, bool, error) {
+		recorded, err := decodeRecordedJob(item)
+		if err != nil {
+			return "", false, err
+		}
+		if recorded.KeyID == keyring.ActiveKeyID() {
+			return "", false, nil
+		}
+		if err := recorded.open(keyring); err != nil {
+			return "", false, err
+		}
+		if recorded.Payload, recorded.KeyID, err = sealPayload(recorded.Payload, keyring); err != nil {
+			return "", false, err
+		}
+		data, err := json.Marshal(recorded)
+		return string(data), true, err
+	})
+	if err != nil {
+		return report, err
+	}
+	report.Recordings = rotated
+	report.Skipped += skipped
+
+	// Live and archived history records
+	for _, store := range []struct {
+		key        string
+		compressed bool
+	}{
+		{layout.key(historyRecordsKey), false},
+		{layout.key(historyArchiveKey), true},
+	} {
+		rotated, skipped, err := rotateHash(ctx, client, store.key, func(_, item string) (string, bool, error) {
+			record, err := decodeHistoryEntry(item, store.compressed)
+			if err != nil || record.Job == nil {
+				return "", false, fmt.Errorf("invalid history record")
+			}
+			if !needsRotation(record.Job, keyring) {
+				return "", false, nil
+			}
+			if record.Job, err = layout.rotateJob(record.Job, keyring); err != nil {
+				return "", false, err
+			}
+			data, err := encodeHistoryEntry(record, store.compressed)
+			return data, true, err
+		})
+		if err != nil {
+			return report, err
+		}
+		report.History += rotated
+		report.Skipped += skipped
+	}
+
+	// Jobs held until the jobs they depend on finish
+	rotated, skipped, err = rotateHash(ctx, client, layout.key(dependencyJobsKey), func(_, item string) (string, bool, error) {
+		return rotateStoredJob(item)
+	})
+	if err != nil {
+		return report, err
+	}
+	report.Dependencies = rotated
+	report.Skipped += skipped
+
+	// Workflow node jobs
+	workflowIDs, err := client.ZRange(ctx, layout.key(workflowIndexKey), 0, -1).Result()
+	if err != nil {
+		return report, fmt.Errorf("failed to list workflows: %w", err)
+	}
+
+	for _, workflowID := range workflowIDs {
+		rotated, skipped, err := rotateHash(ctx, client, layout.key(workflowKeyPrefix)+workflowID, func(field, item string) (string, bool, error) {
+			if !strings.HasPrefix(field, workflowJobPrefix) {
+				return "", false, nil
+			}
+			return rotateStoredJob(item)
+		})
+		if err != nil {
+			return report, err
+		}
+		report.Workflows += rotated
+		report.Skipped += skipped
+	}
+
+	// Quarantined jobs
+	rotated, skipped, err = rotateHash(ctx, client, layout.key(quarantineKey), func(_, item string) (string, bool, error) {
+		var entry types.QuarantinedJob
+		if err := json.Unmarshal([]byte(item), &entry); err != nil || entry.Job == nil {
+			return "", false, fmt.Errorf("invalid quarantine entry")
+		}
+		if !needsRotation(entry.Job, keyring) {
+			return "", false, nil
+		}
+		sealed, err := layout.rotateJob(entry.Job, keyring)
+		if err != nil {
+			return "", false, err
+		}
+		entry.Job = sealed
+		data, err := json.Marshal(&entry)
+		return string(data), true, err
+	})
+	if err != nil {
+		return report, err
+	}
+	report.Quarantine = rotated
+	report.Skipped += skipped
+
+	// Scheduled jobs
+	members, err := client.ZRange(ctx, layout.key(scheduledJobsKey), 0, -1).Result()
+	if err != nil {
+		return report, fmt.Errorf("failed to list scheduled jobs: %w", err)
+	}
+
+	for _, member := range members {
+		var scheduledJob types.ScheduledJob
+		if err := json.Unmarshal([]byte(member), &scheduledJob); err != nil || scheduledJob.Job == nil {
+			report.Skipped++
+			continue
+		}
+		if !needsRotation(scheduledJob.Job, keyring) {
+			continue
+		}
+
+		sealed, err := layout.rotateJob(scheduledJob.Job, keyring)
+		if err != nil {
+			report.Skipped++
+			continue
+		}
+		scheduledJob.Job = sealed
+
+		data, err := json.Marshal(&scheduledJob)
+		if err != nil {
+			report.Skipped++
+			continue
+		}
+
+		replaced, err := replaceSetMemberScript.Run(ctx, client, []string{layout.key(scheduledJobsKey)}, member, data).Int()
+		if err != nil {
+			return report, fmt.Errorf("failed to rotate scheduled job: %w", err)
+		}
+		report.Scheduled += replaced
+	}
+
+	return report, nil
+}
+
+// rotateList rewrites every list item the transform asks to change.
+// Items that were popped concurrently are silently ignored.
+func rotateList(ctx context.Context, client redis.Cmdable, key string, transform func(string) (string, bool, error)) (int, int, error) {
+	items, err := client.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list %s: %w", key, err)
+	}
+
+	rotated, skipped := 0, 0
+	for _, item := range items {
+		replacement, changed, err := transform(item)
+		if err != nil {
+			skipped++
+			continue
+		}
+		if !changed {
+			continue
+		}
+
+		n, err := replaceListItemScript.Run(ctx, client, []string{key}, item, replacement).Int()
+		if err != nil {
+			return rotated, skipped, fmt.Errorf("failed to rotate item in %s: %w", key, err)
+		}
+		rotated += n
+	}
+
+	return rotated, skipped, nil
+}
+
+// rotateHash rewrites every hash field the transform asks to change.
+// Fields removed or replaced concurrently are silently ignored.
+func rotateHash(ctx context.Context, client redis.Cmdable, key string, transform func(field, item string) (string, bool, error)) (int, int, error) {
+	items, err := client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list %s: %w", key, err)
+	}
+
+	rotated, skipped := 0, 0
+	for field, item := range items {
+		replacement, changed, err := transform(field, item)
+		if err != nil {
+			skipped++
+			continue
+		}
+		if !changed {
+			continue
+		}
+
+		n, err := replaceHashFieldScript.Run(ctx, client, []string{key}, field, item, replacement).Int()
+		if err != nil {
+			return rotated, skipped, fmt.Errorf("failed to rotate field in %s: %w", key, err)
+		}
+		rotated += n
+	}
+
+	return rotated, skipped, nil
+}
@@ -0,0 +1,96 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+func TestRotateEncryptionKeys(t *testing.T) {
+	v1 := bytes.Repeat([]byte{1}, 32)
+	v2 := bytes.Repeat([]byte{2}, 32)
+
+	tests := []struct {
+		name           string
+		dequeued       bool // Whether a worker holds the job, unacked, during rotation
+		wantPending    int
+		wantProcessing int
+	}{
+		{name: "pending job", dequeued: false, wantPending: 1},
+		{name: "job a worker holds", dequeued: true, wantProcessing: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server := miniredis.RunT(t)
+			q := newTestRedisQueue(t, server, RedisOptions{})
+
+			old, _ := encryption.NewKeyring(map[string][]byte{"v1": v1}, "v1")
+			rotated, _ := encryption.NewKeyring(map[string][]byte{"v1": v1, "v2": v2}, "v2")
+			retired, _ := encryption.NewKeyring(map[string][]byte{"v2": v2}, "v2")
+
+			q.SetKeyring(old)
+			job := types.NewJob("email", json.RawMessage(`{"to":"a@example.com"}`), 3)
+			if err := q.Enqueue(ctx, job); err != nil {
+				t.Fatalf("Enqueue: %v", err)
+			}
+			var held *types.Job
+			if tt.dequeued {
+				var err error
+				if held, err = q.Dequeue(ctx); err != nil || held == nil {
+					t.Fatalf("Dequeue = %v, %v; want a job", held, err)
+				}
+			}
+
+			report, err := RotateEncryptionKeys(ctx, q.Client(), q.Layout(), rotated)
+			if err != nil {
+				t.Fatalf("RotateEncryptionKeys: %v", err)
+			}
+			if report.Pending != tt.wantPending || report.Processing != tt.wantProcessing || report.Skipped != 0 {
+				t.Fatalf("report = %+v, want %d pending and %d processing", report, tt.wantPending, tt.wantProcessing)
+			}
+
+			// A second run finds nothing left to rotate
+			again, err := RotateEncryptionKeys(ctx, q.Client(), q.Layout(), rotated)
+			if err != nil || again.Pending+again.Processing != 0 {
+				t.Fatalf("second rotation = %+v, %v; want nothing rotated", again, err)
+			}
+
+			if tt.dequeued {
+				// Ack finds the re-encrypted job by its ID
+				if err := q.Ack(ctx, held); err != nil {
+					t.Fatalf("Ack: %v", err)
+				}
+				if n := listLen(server, "job_queue:processing:test-consumer"); n != 0 {
+					t.Fatalf("processing list holds %d jobs after ack, want 0", n)
+				}
+				return
+			}
+
+			// The old key can be dropped once rotation finished
+			q.SetKeyring(retired)
+			got, err := q.Dequeue(ctx)
+			if err != nil || got == nil || got.ID != job.ID {
+				t.Fatalf("Dequeue with the old key retired = %v, %v; want job %s", got, err, job.ID)
+			}
+			if got.KeyID != "" || !jsonEqual(t, got.Payload, job.Payload) {
+				t.Fatalf("payload = %s (key %q), want %s in plaintext", got.Payload, got.KeyID, job.Payload)
+			}
+		})
+	}
+}
+
+func TestRotateEncryptionKeysDisabled(t *testing.T) {
+	server := miniredis.RunT(t)
+	q := newTestRedisQueue(t, server, RedisOptions{})
+
+	if _, err := RotateEncryptionKeys(context.Background(), q.Client(), q.Layout(), nil); err == nil {
+		t.Fatal("rotation without a keyring succeeded")
+	}
+}
@@ -0,0 +1,592 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+)
+
+const erasureChannel = "erasure:requests" // Redis channel passing erasure requests on to every server's spool
+
+// Erasure modes
+const (
+	ErasureDelete = "delete" // Remove matching entries entirely
+	ErasureScrub  = "scrub"  // Keep the entry but replace its payload
+)
+
+// ErasureRequest identifies a data subject by a payload field value
+type ErasureRequest struct {
+	Field string `json:"field" binding:"required"` // Dotted payload path, e.g. "user.id"
+	Value string `json:"value" binding:"required"`
+	Mode  string `json:"mode,omitempty"` // delete (default) or scrub
+}
+
+// ErasureReport counts the entries removed or scrubbed per structure
+type ErasureReport struct {
+	Pending      int `json:"pending"`
+	Scheduled    int `json:"scheduled"`
+	DLQ          int `json:"dlq"`
+	History      int `json:"history"`
+	Recordings   int `json:"recordings"`
+	Dependencies int `json:"dependencies"` // Jobs held until the jobs they depend on finish
+	Workflows    int `json:"workflows"`    // Workflow nodes, always scrubbed
+	Quarantine   int `json:"quarantine"`
+	Results      int `json:"results"`  // Results of the jobs erased above
+	Statuses     int `json:"statuses"` // Status and progress records of the jobs erased above
+	Spool        int `json:"spool"`    // Jobs in the spool of the server that handled the request
+}
+
+// EraseSubject removes or scrubs every pending, scheduled, dead-lettered,
+// historical, recorded, dependency-held, workflow and quarantined job whose
+// payload field matches the request, along with the results and status
+// records of those jobs, for data-subject deletion. The request is then
+// published so every server erases the jobs in its own spool.
+func (a *Admin) EraseSubject(ctx context.Context, req ErasureRequest) (*ErasureReport, error) {
+	req, err := req.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	path := strings.Split(req.Field, ".")
+	report := &ErasureReport{}
+	erasedIDs := make(map[string]bool)
+
+	keys, err := a.layout.pendingQueueKeys(ctx, a.client)
+	if err != nil {
+		return report, err
+	}
+
+	for _, key := range keys {
+		n, err := a.eraseFromList(ctx, key, path, req, erasedIDs, decodeListJob, a.layout.encodeListJob)
+		if err != nil {
+			return report, err
+		}
+		report.Pending += n
+	}
+
+	n, err := a.eraseFromList(ctx, a.layout.key(deadLetterQueueKey), path, req, erasedIDs, decodeDLQJob, encodeEntry)
+	if err != nil {
+		return report, err
+	}
+	report.DLQ = n
+
+	n, err = a.eraseScheduled(ctx, path, req, erasedIDs)
+	if err != nil {
+		return report, err
+	}
+	report.Scheduled = n
+
+	n, err = a.eraseHistory(ctx, path, req, erasedIDs)
+	if err != nil {
+		return report, err
+	}
+	report.History = n
+
+	n, err = a.eraseRecordings(ctx, path, req)
+	if err != nil {
+		return report, err
+	}
+	report.Recordings = n
+
+	n, err = a.eraseFromHash(ctx, a.layout.key(dependencyJobsKey), path, req, erasedIDs, decodeListJob, a.layout.encodeListJob,
+		func(pipe redis.Pipeliner, jobID string) {
+			pipe.SRem(ctx, a.layout.key(dependencyIgnoreKey), jobID)
+			pipe.Del(ctx, a.layout.key(dependencyPendingPrefix)+jobID)
+		})
+	if err != nil {
+		return report, err
+	}
+	report.Dependencies = n
+
+	n, err = a.eraseWorkflows(ctx, path, req, erasedIDs)
+	if err != nil {
+		return report, err
+	}
+	report.Workflows = n
+
+	n, err = a.eraseFromHash(ctx, a.layout.key(quarantineKey), path, req, erasedIDs, decodeQuarantinedJob, encodeEntry, nil)
+	if err != nil {
+		return report, err
+	}
+	report.Quarantine = n
+
+	report.Results, report.Statuses, err = a.eraseJobRecords(ctx, erasedIDs, req)
+	if err != nil {
+		return report, err
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return report, fmt.Errorf("failed to marshal erasure request: %w", err)
+	}
+	if err := a.client.Publish(ctx, a.layout.key(erasureChannel), data).Err(); err != nil {
+		return report, fmt.Errorf("failed to pass erasure on to spools: %w", err)
+	}
+
+	return report, nil
+}
+
+// normalize checks the request and fills in the default mode
+func (req ErasureRequest) normalize() (ErasureRequest, error) {
+	if req.Field == "" || req.Value == "" {
+		return req, fmt.Errorf("erasure requires both field and value")
+	}
+	if req.Mode == "" {
+		req.Mode = ErasureDelete
+	}
+	if req.Mode != ErasureDelete && req.Mode != ErasureScrub {
+		return req, fmt.Errorf("invalid erasure mode %q", req.Mode)
+	}
+	return req, nil
+}
+
+// eraseFromList handles one Redis list of serialized entries
+func (a *Admin) eraseFromList(ctx context.Context, key string, path []string, req ErasureRequest, erasedIDs map[string]bool,
+	decode func(string) (interface{}, *types.Job, error), encode func(interface{}) (string, error)) (int, error) {
+
+	items, err := a.client.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list %s: %w", key, err)
+	}
+
+	erased := 0
+	for _, item := range items {
+		entry, job, err := decode(item)
+		if err != nil || !a.subjectMatches(job, path, req.Value) {
+			continue
+		}
+
+		if req.Mode == ErasureDelete {
+			n, err := a.client.LRem(ctx, key, 1, item).Result()
+			if err != nil {
+				return erased, fmt.Errorf("failed to remove entry from %s: %w", key, err)
+			}
+			erased += int(n)
+			erasedIDs[job.ID] = true
+			continue
+		}
+
+		scrubJob(job)
+		replacement, err := encode(entry)
+		if err != nil {
+			continue
+		}
+		n, err := replaceListItemScript.Run(ctx, a.client, []string{key}, item, replacement).Int()
+		if err != nil {
+			return erased, fmt.Errorf("failed to scrub entry in %s: %w", key, err)
+		}
+		erased += n
+		erasedIDs[job.ID] = true
+	}
+
+	return erased, nil
+}
+
+// eraseFromHash handles one Redis hash of job ID → serialized entry. forget,
+// if not nil, adds the commands dropping the other keys of a deleted entry.
+func (a *Admin) eraseFromHash(ctx context.Context, key string, path []string, req ErasureRequest, erasedIDs map[string]bool,
+	decode func(string) (interface{}, *types.Job, error), encode func(interface{}) (string, error),
+	forget func(pipe redis.Pipeliner, jobID string)) (int, error) {
+
+	entries, err := a.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list %s: %w", key, err)
+	}
+
+	erased := 0
+	for jobID, item := range entries {
+		entry, job, err := decode(item)
+		if err != nil || !a.subjectMatches(job, path, req.Value) {
+			continue
+		}
+
+		// An empty replacement deletes the entry
+		replacement := ""
+		if req.Mode == ErasureScrub {
+			scrubJob(job)
+			if replacement, err = encode(entry); err != nil {
+				continue
+			}
+		}
+
+		// Entries released or replaced since they were read are left alone
+		n, err := replaceHashFieldScript.Run(ctx, a.client, []string{key}, jobID, item, replacement).Int()
+		if err != nil {
+			return erased, fmt.Errorf("failed to erase entry in %s: %w", key, err)
+		}
+		if n == 0 {
+			continue
+		}
+		if req.Mode == ErasureDelete && forget != nil {
+			pipe := a.client.TxPipeline()
+			forget(pipe, jobID)
+			if _, err := pipe.Exec(ctx); err != nil {
+				return erased, fmt.Errorf("failed to erase entry in %s: %w", key, err)
+			}
+		}
+		erased++
+		erasedIDs[jobID] = true
+	}
+
+	return erased, nil
+}
+
+// eraseWorkflows scrubs matching workflow node jobs and drops their output
+// and error, in either mode: removing a node would break its workflow, and
+// a node that hasn't run yet runs with the scrubbed payload
+func (a *Admin) eraseWorkflows(ctx context.Context, path []string, req ErasureRequest, erasedIDs map[string]bool) (int, error) {
+	workflowIDs, err := a.client.ZRange(ctx, a.layout.key(workflowIndexKey), 0, -1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list workflows: %w", err)
+	}
+
+	erased := 0
+	for _, workflowID := range workflowIDs {
+		key := a.layout.key(workflowKeyPrefix) + workflowID
+		fields, err := a.client.HGetAll(ctx, key).Result()
+		if err != nil {
+			return erased, fmt.Errorf("failed to load workflow %s: %w", workflowID, err)
+		}
+
+		for field, data := range fields {
+			node, ok := strings.CutPrefix(field, workflowJobPrefix)
+			if !ok {
+				continue
+			}
+			job, err := DecodeJob([]byte(data))
+			if err != nil || !a.subjectMatches(job, path, req.Value) {
+				continue
+			}
+
+			scrubJob(job)
+			replacement, err := a.layout.encodeJob(job)
+			if err != nil {
+				continue
+			}
+			n, err := replaceHashFieldScript.Run(ctx, a.client, []string{key}, field, data, replacement).Int()
+			if err != nil {
+				return erased, fmt.Errorf("failed to scrub workflow node: %w", err)
+			}
+			if n == 0 {
+				continue
+			}
+			if err := a.client.HDel(ctx, key, workflowOutputPrefix+node, workflowErrorPrefix+node).Err(); err != nil {
+				return erased, fmt.Errorf("failed to drop workflow node output: %w", err)
+			}
+			erased++
+			erasedIDs[job.ID] = true
+		}
+	}
+
+	return erased, nil
+}
+
+// eraseJobRecords deletes the results and status records of erased jobs,
+// or in scrub mode drops the output, errors and progress they hold
+func (a *Admin) eraseJobRecords(ctx context.Context, erasedIDs map[string]bool, req ErasureRequest) (int, int, error) {
+	results, statuses := 0, 0
+	for jobID := range erasedIDs {
+		resultKey := a.layout.key(resultKeyPrefix) + jobID
+		statusKey := a.layout.key(jobStatusKeyPrefix) + jobID
+
+		if req.Mode == ErasureDelete {
+			pipe := a.client.TxPipeline()
+			deletedResult := pipe.Del(ctx, resultKey)
+			pipe.ZRem(ctx, a.layout.key(resultIndexKey), jobID)
+			deletedStatus := pipe.Del(ctx, statusKey)
+			if _, err := pipe.Exec(ctx); err != nil {
+				return results, statuses, fmt.Errorf("failed to erase records of job %s: %w", jobID, err)
+			}
+			results += int(deletedResult.Val())
+			statuses += int(deletedStatus.Val())
+			continue
+		}
+
+		data, err := a.client.Get(ctx, resultKey).Bytes()
+		if err != nil && err != redis.Nil {
+			return results, statuses, fmt.Errorf("failed to load result of job %s: %w", jobID, err)
+		}
+		if err == nil {
+			var record ResultRecord
+			if json.Unmarshal(data, &record) == nil {
+				record.Output = nil
+				record.Error = ""
+				if scrubbed, err := json.Marshal(&record); err == nil {
+					if err := a.client.Set(ctx, resultKey, scrubbed, redis.KeepTTL).Err(); err != nil {
+						return results, statuses, fmt.Errorf("failed to scrub result of job %s: %w", jobID, err)
+					}
+					results++
+				}
+			}
+		}
+
+		scrubbed, err := a.client.HDel(ctx, statusKey, "error", "progress").Result()
+		if err != nil {
+			return results, statuses, fmt.Errorf("failed to scrub status of job %s: %w", jobID, err)
+		}
+		if scrubbed > 0 {
+			statuses++
+		}
+	}
+
+	return results, statuses, nil
+}
+
+// eraseScheduled handles the scheduled jobs sorted set
+func (a *Admin) eraseScheduled(ctx context.Context, path []string, req ErasureRequest, erasedIDs map[string]bool) (int, error) {
+	members, err := a.client.ZRange(ctx, a.layout.key(scheduledJobsKey), 0, -1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list scheduled jobs: %w", err)
+	}
+
+	erased := 0
+	for _, member := range members {
+		var scheduledJob types.ScheduledJob
+		if err := json.Unmarshal([]byte(member), &scheduledJob); err != nil || scheduledJob.Job == nil {
+			continue
+		}
+		if !a.subjectMatches(scheduledJob.Job, path, req.Value) {
+			continue
+		}
+
+		if req.Mode == ErasureDelete {
+			n, err := a.client.ZRem(ctx, a.layout.key(scheduledJobsKey), member).Result()
+			if err != nil {
+				return erased, fmt.Errorf("failed to remove scheduled job: %w", err)
+			}
+			erased += int(n)
+			erasedIDs[scheduledJob.Job.ID] = true
+			continue
+		}
+
+		scrubJob(scheduledJob.Job)
+		data, err := json.Marshal(&scheduledJob)
+		if err != nil {
+			continue
+		}
+		n, err := replaceSetMemberScript.Run(ctx, a.client, []string{a.layout.key(scheduledJobsKey)}, member, data).Int()
+		if err != nil {
+			return erased, fmt.Errorf("failed to scrub scheduled job: %w", err)
+		}
+		erased += n
+		erasedIDs[scheduledJob.Job.ID] = true
+	}
+
+	return erased, nil
+}
+
+// eraseHistory handles live and archived job history records
+func (a *Admin) eraseHistory(ctx context.Context, path []string, req ErasureRequest, erasedIDs map[string]bool) (int, error) {
+	erased := 0
+
+	for _, store := range []struct {
+		recordsKey string
+		indexKey   string
+		compressed bool
+	}{
+		{a.layout.key(historyRecordsKey), a.layout.key(historyIndexKey), false},
+		{a.layout.key(historyArchiveKey), a.layout.key(historyArchiveIndexKey), true},
+	} {
+		records, err := a.client.HGetAll(ctx, store.recordsKey).Result()
+		if err != nil {
+			return erased, fmt.Errorf("failed to load job history: %w", err)
+		}
+
+		for id, data := range records {
+			record, err := decodeHistoryEntry(data, store.compressed)
+			if err != nil || !a.subjectMatches(record.Job, path, req.Value) {
+				continue
+			}
+
+			pipe := a.client.TxPipeline()
+			if req.Mode == ErasureDelete {
+				pipe.HDel(ctx, store.recordsKey, id)
+				pipe.ZRem(ctx, store.indexKey, id)
+			} else {
+				scrubJob(record.Job)
+				replacement, err := encodeHistoryEntry(record, store.compressed)
+				if err != nil {
+					continue
+				}
+				pipe.HSet(ctx, store.recordsKey, id, replacement)
+			}
+			if _, err := pipe.Exec(ctx); err != nil {
+				return erased, fmt.Errorf("failed to erase job history: %w", err)
+			}
+			erased++
+			erasedIDs[id] = true
+		}
+	}
+
+	return erased, nil
+}
+
+// eraseRecordings handles the jobs recorded for replay
+func (a *Admin) eraseRecordings(ctx context.Context, path []string, req ErasureRequest) (int, error) {
+	key := a.layout.key(recordingKey)
+	items, err := a.client.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list recorded jobs: %w", err)
+	}
+
+	erased := 0
+	for _, item := range items {
+		recorded, err := decodeRecordedJob(item)
+		if err != nil {
+			continue
+		}
+		plain := *recorded
+		if plain.open(a.keyring) != nil || !payloadMatches(plain.Payload, path, req.Value) {
+			continue
+		}
+
+		if req.Mode == ErasureDelete {
+			n, err := a.client.LRem(ctx, key, 1, item).Result()
+			if err != nil {
+				return erased, fmt.Errorf("failed to remove recorded job: %w", err)
+			}
+			erased += int(n)
+			continue
+		}
+
+		recorded.Payload = json.RawMessage(`{}`)
+		recorded.KeyID = ""
+		if recorded.Metadata == nil {
+			recorded.Metadata = make(types.JobMetadata)
+		}
+		recorded.Metadata["erased_at"] = time.Now().UTC().Format(time.RFC3339)
+		replacement, err := encodeEntry(recorded)
+		if err != nil {
+			continue
+		}
+		n, err := replaceListItemScript.Run(ctx, a.client, []string{key}, item, replacement).Int()
+		if err != nil {
+			return erased, fmt.Errorf("failed to scrub recorded job: %w", err)
+		}
+		erased += n
+	}
+
+	return erased, nil
+}
+
+// subjectMatches decrypts a copy of the job and compares the payload field
+func (a *Admin) subjectMatches(job *types.Job, path []string, value string) bool {
+	if job == nil {
+		return false
+	}
+
+	plain := *job
+	if err := openJob(&plain, a.keyring); err != nil {
+		return false
+	}
+
+	return payloadMatches(plain.Payload, path, value)
+}
+
+// payloadMatches compares the field at path of a plaintext payload
+func payloadMatches(payload json.RawMessage, path []string, value string) bool {
+	var doc interface{}
+	if err := json.Unmarshal(payload, &doc); err != nil {
+		return false
+	}
+
+	return fieldMatches(doc, path, value)
+}
+
+// fieldMatches walks a decoded JSON document looking for value at path
+func fieldMatches(doc interface{}, path []string, value string) bool {
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		if len(path) == 0 {
+			return false
+		}
+		child, ok := node[path[0]]
+		if !ok {
+			return false
+		}
+		return fieldMatches(child, path[1:], value)
+
+	case []interface{}:
+		for _, item := range node {
+			if fieldMatches(item, path, value) {
+				return true
+			}
+		}
+		return false
+
+	default:
+		if len(path) != 0 || node == nil {
+			return false
+		}
+		return fmt.Sprint(node) == value
+	}
+}
+
+// scrubJob replaces the payload so no personal data remains in the entry
+func scrubJob(job *types.Job) {
+	job.Payload = json.RawMessage(`{}`)
+	job.KeyID = ""
+	job.AddMetadata("erased_at", time.Now().UTC().Format(time.RFC3339))
+}
+
+func decodeListJob(item string) (interface{}, *types.Job, error) {
+	job, err := DecodeJob([]byte(item))
+	if err != nil {
+		return nil, nil, err
+	}
+	return job, job, nil
+}
+
+func (l *Layout) encodeListJob(entry interface{}) (string, error) {
+	data, err := l.encodeJob(entry.(*types.Job))
+	return string(data), err
+}
+
+func decodeDLQJob(item string) (interface{}, *types.Job, error) {
+	var info types.FailedJobInfo
+	if err := json.Unmarshal([]byte(item), &info); err != nil {
+		return nil, nil, err
+	}
+	return &info, info.Job, nil
+}
+
+func decodeQuarantinedJob(item string) (interface{}, *types.Job, error) {
+	var entry types.QuarantinedJob
+	if err := json.Unmarshal([]byte(item), &entry); err != nil {
+		return nil, nil, err
+	}
+	return &entry, entry.Job, nil
+}
+
+func encodeEntry(entry interface{}) (string, error) {
+	data, err := json.Marshal(entry)
+	return string(data), err
+}
+
+func decodeHistoryEntry(data string, compressed bool) (*types.JobRecord, error) {
+	raw := []byte(data)
+	if compressed {
+		var err error
+		if raw, err = decompressRecord(data); err != nil {
+			return nil, err
+		}
+	}
+
+	var record types.JobRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func encodeHistoryEntry(record *types.JobRecord, compressed bool) (string, error) {
+	if compressed {
+		return compressRecord(record)
+	}
+	return encodeEntry(record)
+}
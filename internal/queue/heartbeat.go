@@ -0,0 +1,106 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	workerHeartbeatsKey = "workers:heartbeats" // Redis hash of worker pool ID → latest heartbeat
+)
+
+// WorkerHeartbeat describes a live worker pool process
+type WorkerHeartbeat struct {
+	ID          string    `json:"id"`
+	Hostname    string    `json:"hostname"`
+	PID         int       `json:"pid"`
+	Concurrency int       `json:"concurrency"`
+	ActiveJobs  int       `json:"active_jobs"`
+	Draining    bool      `json:"draining"`
+	StartedAt   time.Time `json:"started_at"`
+	LastSeen    time.Time `json:"last_seen"`
+
+	// Jobs the pool finished since it started
+	JobsProcessed int64 `json:"jobs_processed"`
+	JobsFailed    int64 `json:"jobs_failed"`
+	JobsRetried   int64 `json:"jobs_retried"`
+
+	// Job types the pool has handlers for
+	JobTypes []string `json:"job_types,omitempty"`
+}
+
+// HeartbeatRegistry tracks worker pools through periodic heartbeats in Redis
+type HeartbeatRegistry struct {
+	client redis.Cmdable
+	layout *Layout
+	ttl    time.Duration
+}
+
+// NewHeartbeatRegistry creates a registry; heartbeats older than ttl are treated as dead
+func NewHeartbeatRegistry(client redis.Cmdable, layout *Layout, ttl time.Duration) *HeartbeatRegistry {
+	return &HeartbeatRegistry{
+		client: client,
+		layout: layout,
+		ttl:    ttl,
+	}
+}
+
+// Beat records a heartbeat for the given worker pool
+func (h *HeartbeatRegistry) Beat(ctx context.Context, hb WorkerHeartbeat) error {
+	hb.LastSeen = time.Now().UTC()
+
+	data, err := json.Marshal(hb)
+	if err != nil {
+		return fmt.Errorf("failed to marshal heartbeat: %w", err)
+	}
+
+	if err := h.client.HSet(ctx, h.layout.key(workerHeartbeatsKey), hb.ID, data).Err(); err != nil {
+		return fmt.Errorf("failed to record heartbeat: %w", err)
+	}
+
+	return nil
+}
+
+// Deregister removes a worker pool's heartbeat on clean shutdown
+func (h *HeartbeatRegistry) Deregister(ctx context.Context, id string) error {
+	if err := h.client.HDel(ctx, h.layout.key(workerHeartbeatsKey), id).Err(); err != nil {
+		return fmt.Errorf("failed to deregister heartbeat: %w", err)
+	}
+	return nil
+}
+
+// List returns heartbeats seen within the ttl, pruning stale entries
+func (h *HeartbeatRegistry) List(ctx context.Context) ([]WorkerHeartbeat, error) {
+	entries, err := h.client.HGetAll(ctx, h.layout.key(workerHeartbeatsKey)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list heartbeats: %w", err)
+	}
+
+	cutoff := time.Now().Add(-h.ttl)
+	heartbeats := make([]WorkerHeartbeat, 0, len(entries))
+	var stale []string
+
+	for id, data := range entries {
+		var hb WorkerHeartbeat
+		if err := json.Unmarshal([]byte(data), &hb); err != nil || hb.LastSeen.Before(cutoff) {
+			stale = append(stale, id)
+			continue
+		}
+		heartbeats = append(heartbeats, hb)
+	}
+
+	if len(stale) > 0 {
+		h.client.HDel(ctx, h.layout.key(workerHeartbeatsKey), stale...)
+	}
+
+	sort.Slice(heartbeats, func(i, j int) bool {
+		return heartbeats[i].ID < heartbeats[j].ID
+	})
+
+	return heartbeats, nil
+}
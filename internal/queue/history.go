@@ -0,0 +1,395 @@
+package queue
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/aneeshsunganahalli/Gopher/internal/redact"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	historyRecordsKey      = "history:records"       // Redis hash of job ID → finished job record
+	historyIndexKey        = "history:index"         // Redis sorted set of job IDs by finish time
+	historyArchiveKey      = "history:archive"       // Redis hash of job ID → gzip-compressed record
+	historyArchiveIndexKey = "history:archive:index" // Redis sorted set of archived job IDs by archive time
+)
+
+// archiveRecordScript moves a record into the archive only if it has not changed since it was read
+var archiveRecordScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call('HDEL', KEYS[1], ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('HSET', KEYS[3], ARGV[1], ARGV[3])
+redis.call('ZADD', KEYS[4], ARGV[4], ARGV[1])
+return 1
+`)
+
+// ErrRecordNotFound is returned when a job has no live or archived history record
+var ErrRecordNotFound = errors.New("job record not found")
+
+// HistoryOptions configures job history retention
+type HistoryOptions struct {
+	Retention        time.Duration // How long finished records stay live before archival
+	ArchiveRetention time.Duration // How long archived records stay recoverable
+}
+
+// History keeps records of finished jobs. Deleted or expired records are
+// archived in compressed form rather than dropped, so they remain
+// recoverable for the archive retention window.
+type History struct {
+	client   redis.Cmdable
+	layout   *Layout
+	opts     HistoryOptions
+	keyring  *encryption.Keyring
+	redactor *redact.Redactor
+}
+
+// NewHistory creates a Redis-backed job history store
+func NewHistory(client redis.Cmdable, layout *Layout, opts HistoryOptions) *History {
+	return &History{
+		client: client,
+		layout: layout,
+		opts:   opts,
+	}
+}
+
+// SetKeyring enables payload encryption for stored records
+func (h *History) SetKeyring(keyring *encryption.Keyring) {
+	h.keyring = keyring
+}
+
+// SetRedactor sets the redactor applied to payloads before they are stored
+func (h *History) SetRedactor(redactor *redact.Redactor) {
+	h.redactor = redactor
+}
+
+// Record stores the outcome of a finished job
+func (h *History) Record(ctx context.Context, job *types.Job, result *types.JobResult) error {
+	sealed, err := h.layout.sealJob(h.redactor.Job(job), h.keyring)
+	if err != nil {
+		return err
+	}
+
+	record := &types.JobRecord{
+		Job:        sealed,
+		Status:     result.Status,
+		Error:      result.Error,
+		Duration:   result.Duration,
+		FinishedAt: result.CompletedAt,
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job record: %w", err)
+	}
+
+	pipe := h.client.Pipeline()
+	pipe.HSet(ctx, h.layout.key(historyRecordsKey), job.ID, data)
+	pipe.ZAdd(ctx, h.layout.key(historyIndexKey), &redis.Z{
+		Score:  float64(record.FinishedAt.Unix()),
+		Member: job.ID,
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record job history: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the live record for a job
+func (h *History) Get(ctx context.Context, jobID string) (*types.JobRecord, error) {
+	data, err := h.client.HGet(ctx, h.layout.key(historyRecordsKey), jobID).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("job %s: %w", jobID, ErrRecordNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job record: %w", err)
+	}
+
+	return h.decodeRecord([]byte(data))
+}
+
+// List returns live records, most recently finished first
+func (h *History) List(ctx context.Context, offset, limit int) ([]*types.JobRecord, error) {
+	return h.list(ctx, h.layout.key(historyIndexKey), h.layout.key(historyRecordsKey), offset, limit, false)
+}
+
+// Delete soft-deletes a record by moving it into the archive
+func (h *History) Delete(ctx context.Context, jobID string) error {
+	data, err := h.client.HGet(ctx, h.layout.key(historyRecordsKey), jobID).Result()
+	if err == redis.Nil {
+		return fmt.Errorf("job %s: %w", jobID, ErrRecordNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get job record: %w", err)
+	}
+
+	archived, err := h.archive(ctx, jobID, data)
+	if err != nil {
+		return err
+	}
+	if !archived {
+		return fmt.Errorf("job %s changed while archiving, try again", jobID)
+	}
+
+	return nil
+}
+
+// GetArchived returns an archived record
+func (h *History) GetArchived(ctx context.Context, jobID string) (*types.JobRecord, error) {
+	data, err := h.client.HGet(ctx, h.layout.key(historyArchiveKey), jobID).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("archived job %s: %w", jobID, ErrRecordNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archived record: %w", err)
+	}
+
+	return h.decodeArchived(data)
+}
+
+// ListArchived returns archived records, most recently archived first
+func (h *History) ListArchived(ctx context.Context, offset, limit int) ([]*types.JobRecord, error) {
+	return h.list(ctx, h.layout.key(historyArchiveIndexKey), h.layout.key(historyArchiveKey), offset, limit, true)
+}
+
+// Restore moves an archived record back into the live history
+func (h *History) Restore(ctx context.Context, jobID string) error {
+	record, err := h.GetArchived(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	record.ArchivedAt = nil
+
+	// Records are decrypted on read, so seal the payload again before storing
+	record.Job, err = h.layout.sealJob(record.Job, h.keyring)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job record: %w", err)
+	}
+
+	pipe := h.client.TxPipeline()
+	pipe.HSet(ctx, h.layout.key(historyRecordsKey), jobID, data)
+	pipe.ZAdd(ctx, h.layout.key(historyIndexKey), &redis.Z{
+		Score:  float64(record.FinishedAt.Unix()),
+		Member: jobID,
+	})
+	pipe.HDel(ctx, h.layout.key(historyArchiveKey), jobID)
+	pipe.ZRem(ctx, h.layout.key(historyArchiveIndexKey), jobID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to restore job record: %w", err)
+	}
+
+	return nil
+}
+
+// Sweep archives live records past the retention window and permanently
+// drops archived records past the archive retention window
+func (h *History) Sweep(ctx context.Context) (int, int, error) {
+	archived := 0
+	if h.opts.Retention > 0 {
+		cutoff := time.Now().Add(-h.opts.Retention).Unix()
+		ids, err := h.client.ZRangeByScore(ctx, h.layout.key(historyIndexKey), &redis.ZRangeBy{
+			Min: "-inf",
+			Max: fmt.Sprintf("%d", cutoff),
+		}).Result()
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to find expired records: %w", err)
+		}
+
+		for _, id := range ids {
+			data, err := h.client.HGet(ctx, h.layout.key(historyRecordsKey), id).Result()
+			if err == redis.Nil {
+				h.client.ZRem(ctx, h.layout.key(historyIndexKey), id)
+				continue
+			}
+			if err != nil {
+				return archived, 0, fmt.Errorf("failed to get job record: %w", err)
+			}
+
+			ok, err := h.archive(ctx, id, data)
+			if err != nil {
+				return archived, 0, err
+			}
+			if ok {
+				archived++
+			}
+		}
+	}
+
+	pruned := 0
+	if h.opts.ArchiveRetention > 0 {
+		cutoff := time.Now().Add(-h.opts.ArchiveRetention).Unix()
+		ids, err := h.client.ZRangeByScore(ctx, h.layout.key(historyArchiveIndexKey), &redis.ZRangeBy{
+			Min: "-inf",
+			Max: fmt.Sprintf("%d", cutoff),
+		}).Result()
+		if err != nil {
+			return archived, 0, fmt.Errorf("failed to find expired archive records: %w", err)
+		}
+
+		if len(ids) > 0 {
+			members := make([]interface{}, len(ids))
+			for i, id := range ids {
+				members[i] = id
+			}
+
+			pipe := h.client.TxPipeline()
+			pipe.HDel(ctx, h.layout.key(historyArchiveKey), ids...)
+			pipe.ZRem(ctx, h.layout.key(historyArchiveIndexKey), members...)
+			if _, err := pipe.Exec(ctx); err != nil {
+				return archived, 0, fmt.Errorf("failed to prune archive: %w", err)
+			}
+			pruned = len(ids)
+		}
+	}
+
+	return archived, pruned, nil
+}
+
+// archive compresses a record and moves it into the archive atomically
+func (h *History) archive(ctx context.Context, jobID, data string) (bool, error) {
+	record, err := h.decodeStored([]byte(data))
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now().UTC()
+	record.ArchivedAt = &now
+
+	compressed, err := compressRecord(record)
+	if err != nil {
+		return false, err
+	}
+
+	keys := []string{h.layout.key(historyRecordsKey), h.layout.key(historyIndexKey), h.layout.key(historyArchiveKey), h.layout.key(historyArchiveIndexKey)}
+	moved, err := archiveRecordScript.Run(ctx, h.client, keys, jobID, data, compressed, now.Unix()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to archive job record: %w", err)
+	}
+
+	return moved == 1, nil
+}
+
+// list pages through an index and loads the matching records
+func (h *History) list(ctx context.Context, indexKey, recordsKey string, offset, limit int, compressed bool) ([]*types.JobRecord, error) {
+	ids, err := h.client.ZRevRange(ctx, indexKey, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job records: %w", err)
+	}
+	if len(ids) == 0 {
+		return []*types.JobRecord{}, nil
+	}
+
+	values, err := h.client.HMGet(ctx, recordsKey, ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load job records: %w", err)
+	}
+
+	records := make([]*types.JobRecord, 0, len(values))
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		var record *types.JobRecord
+		if compressed {
+			record, err = h.decodeArchived(data)
+		} else {
+			record, err = h.decodeRecord([]byte(data))
+		}
+		if err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// decodeStored unmarshals a record without touching its payload encryption
+func (h *History) decodeStored(data []byte) (*types.JobRecord, error) {
+	var record types.JobRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job record: %w", err)
+	}
+	return &record, nil
+}
+
+// decodeRecord unmarshals a record and decrypts its payload
+func (h *History) decodeRecord(data []byte) (*types.JobRecord, error) {
+	record, err := h.decodeStored(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := openJob(record.Job, h.keyring); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// decodeArchived decompresses and decodes an archived record
+func (h *History) decodeArchived(data string) (*types.JobRecord, error) {
+	raw, err := decompressRecord(data)
+	if err != nil {
+		return nil, err
+	}
+	return h.decodeRecord(raw)
+}
+
+// decompressRecord reverses compressRecord
+func decompressRecord(data string) ([]byte, error) {
+	compressed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archived record: %w", err)
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("invalid archived record: %w", err)
+	}
+	defer reader.Close()
+
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress archived record: %w", err)
+	}
+
+	return raw, nil
+}
+
+// compressRecord gzips a record and base64-encodes it for storage
+func compressRecord(record *types.JobRecord) (string, error) {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal job record: %w", err)
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(raw); err != nil {
+		return "", fmt.Errorf("failed to compress job record: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress job record: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,130 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	idempotencyKeyPrefix     = "idempotency:"           // Redis string per idempotency key holding the job it created
+	idempotencySuppressedKey = "idempotency_suppressed" // Redis hash of job type → repeated submissions that enqueued nothing
+)
+
+// MaxIdempotencyKeyLength caps the length of client supplied idempotency keys
+const MaxIdempotencyKeyLength = 255
+
+// releaseIdempotencyScript deletes an idempotency key only while it still
+// belongs to the given job
+var releaseIdempotencyScript = redis.NewScript(`
+local data = redis.call("GET", KEYS[1])
+if not data then
+	return 0
+end
+if cjson.decode(data).job_id ~= ARGV[1] then
+	return 0
+end
+return redis.call("DEL", KEYS[1])
+`)
+
+// IdempotencyRecord is the job an idempotency key was first used for
+type IdempotencyRecord struct {
+	JobID       string    `json:"job_id"`
+	Fingerprint string    `json:"fingerprint"` // Hash of the request, to catch a key reused for a different job
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// IdempotencyStore maps client supplied idempotency keys to the jobs they
+// created, so a submission retried after a network error returns the
+// original job instead of enqueuing it twice
+type IdempotencyStore struct {
+	client redis.Cmdable
+	layout *Layout
+	ttl    time.Duration
+}
+
+// NewIdempotencyStore creates a store whose keys expire after ttl
+func NewIdempotencyStore(client redis.Cmdable, layout *Layout, ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{
+		client: client,
+		layout: layout,
+		ttl:    ttl,
+	}
+}
+
+func (l *Layout) idempotencyKey(key string) string {
+	return l.key(idempotencyKeyPrefix + key)
+}
+
+// Claim records key for the job in record unless the key is already taken.
+// It returns nil when the claim succeeded and the existing record
+// otherwise, which may belong to a submission still in flight.
+func (s *IdempotencyStore) Claim(ctx context.Context, key string, record IdempotencyRecord) (*IdempotencyRecord, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+
+	claimed, err := s.client.SetNX(ctx, s.layout.idempotencyKey(key), data, s.ttl).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	if claimed {
+		return nil, nil
+	}
+
+	existing, err := s.client.Get(ctx, s.layout.idempotencyKey(key)).Bytes()
+	if err == redis.Nil {
+		// Released or expired in between; the caller's retry will claim it
+		return nil, fmt.Errorf("idempotency key was released concurrently")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	var previous IdempotencyRecord
+	if err := json.Unmarshal(existing, &previous); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal idempotency record: %w", err)
+	}
+	return &previous, nil
+}
+
+// Release frees key when it still belongs to jobID, so a submission that
+// failed can be retried under the same key
+func (s *IdempotencyStore) Release(ctx context.Context, key, jobID string) error {
+	if err := releaseIdempotencyScript.Run(ctx, s.client, []string{s.layout.idempotencyKey(key)}, jobID).Err(); err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// RecordSuppressed counts a repeated submission of jobType that was answered
+// with the original job instead of being enqueued
+func (s *IdempotencyStore) RecordSuppressed(ctx context.Context, jobType string) error {
+	if err := s.client.HIncrBy(ctx, s.layout.key(idempotencySuppressedKey), jobType, 1).Err(); err != nil {
+		return fmt.Errorf("failed to count suppressed submission: %w", err)
+	}
+	return nil
+}
+
+// Suppressed returns the number of repeated submissions suppressed per job type
+func (s *IdempotencyStore) Suppressed(ctx context.Context) (map[string]int, error) {
+	stored, err := s.client.HGetAll(ctx, s.layout.key(idempotencySuppressedKey)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suppressed submissions: %w", err)
+	}
+
+	counts := make(map[string]int, len(stored))
+	for jobType, value := range stored {
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+		counts[jobType] = count
+	}
+	return counts, nil
+}
@@ -0,0 +1,109 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestIdempotencyStoreClaim(t *testing.T) {
+	first := IdempotencyRecord{JobID: "job-1", Fingerprint: "f1", CreatedAt: time.Now().UTC()}
+	second := IdempotencyRecord{JobID: "job-2", Fingerprint: "f2", CreatedAt: time.Now().UTC()}
+
+	tests := []struct {
+		name string
+		// between runs after the first claim of the key
+		between     func(t *testing.T, server *miniredis.Miniredis, store *IdempotencyStore)
+		wantExisted bool // Whether the second claim finds the first job's record
+	}{
+		{
+			name:        "repeated key returns the first job",
+			between:     func(t *testing.T, server *miniredis.Miniredis, store *IdempotencyStore) {},
+			wantExisted: true,
+		},
+		{
+			name: "key expires after the TTL",
+			between: func(t *testing.T, server *miniredis.Miniredis, store *IdempotencyStore) {
+				server.FastForward(time.Hour + time.Second)
+			},
+			wantExisted: false,
+		},
+		{
+			name: "released key can be claimed again",
+			between: func(t *testing.T, server *miniredis.Miniredis, store *IdempotencyStore) {
+				if err := store.Release(context.Background(), "order-42", "job-1"); err != nil {
+					t.Fatalf("Release: %v", err)
+				}
+			},
+			wantExisted: false,
+		},
+		{
+			name: "release by another job keeps the key",
+			between: func(t *testing.T, server *miniredis.Miniredis, store *IdempotencyStore) {
+				if err := store.Release(context.Background(), "order-42", "job-other"); err != nil {
+					t.Fatalf("Release: %v", err)
+				}
+			},
+			wantExisted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server := miniredis.RunT(t)
+			q := newTestRedisQueue(t, server, RedisOptions{KeyPrefix: "app:"})
+			store := NewIdempotencyStore(q.Client(), q.Layout(), time.Hour)
+
+			existing, err := store.Claim(ctx, "order-42", first)
+			if err != nil || existing != nil {
+				t.Fatalf("first Claim = %+v, %v; want a fresh claim", existing, err)
+			}
+			if !server.Exists("app:idempotency:order-42") {
+				t.Fatal("claim is not stored under the key prefix")
+			}
+
+			tt.between(t, server, store)
+
+			existing, err = store.Claim(ctx, "order-42", second)
+			if err != nil {
+				t.Fatalf("second Claim: %v", err)
+			}
+			if tt.wantExisted {
+				if existing == nil || existing.JobID != first.JobID || existing.Fingerprint != first.Fingerprint {
+					t.Fatalf("second Claim = %+v, want the record of %s", existing, first.JobID)
+				}
+			} else if existing != nil {
+				t.Fatalf("second Claim = %+v, want a fresh claim", existing)
+			}
+		})
+	}
+}
+
+func TestIdempotencyStoreSuppressed(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	q := newTestRedisQueue(t, server, RedisOptions{})
+	store := NewIdempotencyStore(q.Client(), q.Layout(), time.Hour)
+
+	counts, err := store.Suppressed(ctx)
+	if err != nil || len(counts) != 0 {
+		t.Fatalf("Suppressed before any = %v, %v; want none", counts, err)
+	}
+
+	for _, jobType := range []string{"email", "email", "report"} {
+		if err := store.RecordSuppressed(ctx, jobType); err != nil {
+			t.Fatalf("RecordSuppressed: %v", err)
+		}
+	}
+
+	counts, err = store.Suppressed(ctx)
+	if err != nil {
+		t.Fatalf("Suppressed: %v", err)
+	}
+	if counts["email"] != 2 || counts["report"] != 1 || len(counts) != 2 {
+		t.Fatalf("Suppressed = %v, want email:2 report:1", counts)
+	}
+}
@@ -0,0 +1,322 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+// KafkaRecord is a message produced to or fetched from Kafka
+type KafkaRecord struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+}
+
+// KafkaClient is the Kafka client KafkaQueue runs on. The bundled one in
+// internal/kafka registers itself when imported; an adapter around another
+// library can implement this instead and join the consumer group given in
+// KafkaOptions.
+type KafkaClient interface {
+	// Produce writes a record synchronously, partitioning by its key
+	Produce(ctx context.Context, record KafkaRecord) error
+
+	// Fetch returns the next records from the partitions assigned to this
+	// group member, in offset order per partition, or none once ctx expires
+	Fetch(ctx context.Context) ([]KafkaRecord, error)
+
+	// Commit stores offset as the next offset the group reads from the partition
+	Commit(ctx context.Context, topic string, partition int32, offset int64) error
+
+	// Lag returns the group's unconsumed records across the topic's partitions
+	Lag(ctx context.Context, topic string) (int64, error)
+
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// KafkaOptions configures the Kafka queue backend
+type KafkaOptions struct {
+	Brokers     []string
+	Topic       string        // Topic jobs are produced to and consumed from
+	Topics      []string      // Topics to consume instead of Topic, used by the Kafka bridge
+	Group       string        // Consumer group shared by all workers, empty for producer-only clients
+	PollTimeout time.Duration // How long a dequeue waits for records, defaults to 1s
+}
+
+// KafkaDialer connects a KafkaClient for the given options
+type KafkaDialer func(ctx context.Context, opts KafkaOptions) (KafkaClient, error)
+
+var kafkaDialer KafkaDialer
+
+// RegisterKafkaDialer makes a Kafka client available to QUEUE_BACKEND=kafka.
+// Call it from an init function in the package that links the client library.
+func RegisterKafkaDialer(dialer KafkaDialer) {
+	kafkaDialer = dialer
+}
+
+// DialKafka connects a client with the registered dialer
+func DialKafka(ctx context.Context, opts KafkaOptions) (KafkaClient, error) {
+	if kafkaDialer == nil {
+		return nil, fmt.Errorf("no Kafka client registered: import internal/kafka or an adapter that calls queue.RegisterKafkaDialer")
+	}
+
+	client, err := kafkaDialer(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Kafka: %w", err)
+	}
+	return client, nil
+}
+
+// OpenKafka dials Kafka with the registered dialer and creates the queue
+func OpenKafka(ctx context.Context, opts KafkaOptions) (*KafkaQueue, error) {
+	client, err := DialKafka(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewKafkaQueue(client, opts), nil
+}
+
+// KafkaQueue implements Queue on a Kafka topic. Jobs are keyed by type so
+// each type keeps its order within a partition, and a partition's offset is
+// only committed once every job before it has been acked, so a crashed
+// worker's jobs are redelivered to the group.
+type KafkaQueue struct {
+	client  KafkaClient
+	opts    KafkaOptions
+	keyring *encryption.Keyring
+	layout  *Layout
+
+	fetchMu sync.Mutex // Serializes fetches so records are buffered once
+
+	mu        sync.Mutex
+	buffered  []KafkaRecord
+	inFlight  map[string][]kafkaDelivery       // Delivered, unacked records by job ID
+	partition map[kafkaPartition]*offsetWindow // Uncommitted offsets per partition
+}
+
+type kafkaPartition struct {
+	topic     string
+	partition int32
+}
+
+type kafkaDelivery struct {
+	partition kafkaPartition
+	offset    int64
+}
+
+// offsetWindow tracks delivered offsets of a partition in order
+type offsetWindow struct {
+	offsets []int64
+	acked   map[int64]bool
+}
+
+// NewKafkaQueue creates a queue on a connected client
+func NewKafkaQueue(client KafkaClient, opts KafkaOptions) *KafkaQueue {
+	if opts.PollTimeout <= 0 {
+		opts.PollTimeout = time.Second
+	}
+
+	return &KafkaQueue{
+		client:    client,
+		opts:      opts,
+		inFlight:  make(map[string][]kafkaDelivery),
+		partition: make(map[kafkaPartition]*offsetWindow),
+		layout:    signingLayout(Signing{}),
+	}
+}
+
+// SetKeyring enables payload encryption with the given keyring
+func (k *KafkaQueue) SetKeyring(keyring *encryption.Keyring) {
+	k.keyring = keyring
+}
+
+// SetSigning signs jobs as they are stored
+func (k *KafkaQueue) SetSigning(signing Signing) {
+	k.layout = signingLayout(signing)
+}
+
+// Enqueue produces the job, keyed by its type
+func (k *KafkaQueue) Enqueue(ctx context.Context, job *types.Job) error {
+	if err := job.Validate(); err != nil {
+		return fmt.Errorf("job validation failed: %w", err)
+	}
+
+	job.EnqueuedAt = time.Now().UTC()
+
+	sealed, err := k.layout.sealJob(job, k.keyring)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(sealed)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	if err := k.client.Produce(ctx, KafkaRecord{
+		Topic: k.opts.Topic,
+		Key:   []byte(job.Type),
+		Value: data,
+	}); err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return nil
+}
+
+// Dequeue returns the next buffered job, fetching when the buffer is empty.
+// It returns nil without an error when nothing arrived within the poll timeout.
+func (k *KafkaQueue) Dequeue(ctx context.Context) (*types.Job, error) {
+	record, ok := k.next()
+	if !ok {
+		if err := k.fetch(ctx); err != nil {
+			return nil, err
+		}
+		if record, ok = k.next(); !ok {
+			return nil, nil
+		}
+	}
+
+	var job types.Job
+	if err := json.Unmarshal(record.Value, &job); err != nil {
+		// Skip poison records so they don't hold back the partition's offset
+		k.settle(kafkaDelivery{kafkaPartition{record.Topic, record.Partition}, record.Offset})
+		return nil, fmt.Errorf("failed to unmarshal job at %s/%d@%d: %w", record.Topic, record.Partition, record.Offset, err)
+	}
+	if err := openJob(&job, k.keyring); err != nil {
+		k.settle(kafkaDelivery{kafkaPartition{record.Topic, record.Partition}, record.Offset})
+		return nil, fmt.Errorf("failed to decrypt job: %w", err)
+	}
+
+	k.mu.Lock()
+	k.inFlight[job.ID] = append(k.inFlight[job.ID], kafkaDelivery{kafkaPartition{record.Topic, record.Partition}, record.Offset})
+	k.mu.Unlock()
+
+	return &job, nil
+}
+
+// next pops a buffered record and starts tracking its offset
+func (k *KafkaQueue) next() (KafkaRecord, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if len(k.buffered) == 0 {
+		return KafkaRecord{}, false
+	}
+	record := k.buffered[0]
+	k.buffered = k.buffered[1:]
+
+	key := kafkaPartition{record.Topic, record.Partition}
+	window, ok := k.partition[key]
+	if !ok {
+		window = &offsetWindow{acked: make(map[int64]bool)}
+		k.partition[key] = window
+	}
+	window.offsets = append(window.offsets, record.Offset)
+
+	return record, true
+}
+
+// fetch fills the buffer, waiting up to the poll timeout
+func (k *KafkaQueue) fetch(ctx context.Context) error {
+	k.fetchMu.Lock()
+	defer k.fetchMu.Unlock()
+
+	// Another worker may have fetched while this one waited for the lock
+	k.mu.Lock()
+	buffered := len(k.buffered)
+	k.mu.Unlock()
+	if buffered > 0 {
+		return nil
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, k.opts.PollTimeout)
+	defer cancel()
+
+	records, err := k.client.Fetch(fetchCtx)
+	if err != nil && ctx.Err() == nil && fetchCtx.Err() == nil {
+		return fmt.Errorf("failed to fetch jobs: %w", err)
+	}
+
+	k.mu.Lock()
+	k.buffered = append(k.buffered, records...)
+	k.mu.Unlock()
+	return ctx.Err()
+}
+
+// Ack marks the job's delivery as handled and commits the partition offset
+// past every contiguous handled record
+func (k *KafkaQueue) Ack(ctx context.Context, job *types.Job) error {
+	k.mu.Lock()
+	deliveries := k.inFlight[job.ID]
+	if len(deliveries) == 0 {
+		k.mu.Unlock()
+		return nil
+	}
+	delivery := deliveries[0]
+	if len(deliveries) == 1 {
+		delete(k.inFlight, job.ID)
+	} else {
+		k.inFlight[job.ID] = deliveries[1:]
+	}
+	k.mu.Unlock()
+
+	commit, ok := k.settle(delivery)
+	if !ok {
+		return nil
+	}
+	if err := k.client.Commit(ctx, delivery.partition.topic, delivery.partition.partition, commit); err != nil {
+		return fmt.Errorf("failed to commit offset: %w", err)
+	}
+	return nil
+}
+
+// settle marks an offset acked and returns the offset to commit, if it advanced
+func (k *KafkaQueue) settle(delivery kafkaDelivery) (int64, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	window, ok := k.partition[delivery.partition]
+	if !ok {
+		return 0, false
+	}
+	window.acked[delivery.offset] = true
+
+	advanced := false
+	var commit int64
+	for len(window.offsets) > 0 && window.acked[window.offsets[0]] {
+		delete(window.acked, window.offsets[0])
+		commit = window.offsets[0] + 1
+		window.offsets = window.offsets[1:]
+		advanced = true
+	}
+	return commit, advanced
+}
+
+// Size returns the consumer group's lag
+func (k *KafkaQueue) Size(ctx context.Context) (int, error) {
+	lag, err := k.client.Lag(ctx, k.opts.Topic)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get queue size: %w", err)
+	}
+	return int(lag), nil
+}
+
+// Health checks if Kafka is reachable
+func (k *KafkaQueue) Health(ctx context.Context) error {
+	if err := k.client.Ping(ctx); err != nil {
+		return fmt.Errorf("kafka health check failed: %w", err)
+	}
+	return nil
+}
+
+// Close closes the client; unacked jobs are redelivered to the group
+func (k *KafkaQueue) Close() error {
+	return k.client.Close()
+}
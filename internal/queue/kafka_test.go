@@ -0,0 +1,196 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+type kafkaCommit struct {
+	partition int32
+	offset    int64
+}
+
+// fakeKafka keeps one topic in memory. Records go to partition 0 unless
+// their key is "p1", and every fetch hands out all records not yet fetched.
+type fakeKafka struct {
+	mu      sync.Mutex
+	records []KafkaRecord
+	fetched int
+	next    map[int32]int64
+	commits []kafkaCommit
+}
+
+func newFakeKafka() *fakeKafka {
+	return &fakeKafka{next: make(map[int32]int64)}
+}
+
+func (f *fakeKafka) Produce(ctx context.Context, record KafkaRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if string(record.Key) == "p1" {
+		record.Partition = 1
+	}
+	record.Offset = f.next[record.Partition]
+	f.next[record.Partition]++
+	f.records = append(f.records, record)
+	return nil
+}
+
+func (f *fakeKafka) Fetch(ctx context.Context) ([]KafkaRecord, error) {
+	f.mu.Lock()
+	records := f.records[f.fetched:]
+	f.fetched = len(f.records)
+	f.mu.Unlock()
+
+	if len(records) == 0 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return records, nil
+}
+
+func (f *fakeKafka) Commit(ctx context.Context, topic string, partition int32, offset int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commits = append(f.commits, kafkaCommit{partition, offset})
+	return nil
+}
+
+func (f *fakeKafka) Lag(ctx context.Context, topic string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return int64(len(f.records) - f.fetched), nil
+}
+
+func (f *fakeKafka) Ping(ctx context.Context) error { return nil }
+
+func (f *fakeKafka) Close() error { return nil }
+
+func TestKafkaQueueCommits(t *testing.T) {
+	tests := []struct {
+		name        string
+		keys        []string // Job type of each record, which is its partition key
+		ackOrder    []int    // Indexes of the dequeued jobs, in the order they are acked
+		wantCommits []kafkaCommit
+	}{
+		{
+			name:        "in order",
+			keys:        []string{"p0", "p0", "p0"},
+			ackOrder:    []int{0, 1, 2},
+			wantCommits: []kafkaCommit{{0, 1}, {0, 2}, {0, 3}},
+		},
+		{
+			name:        "out of order waits for the gap",
+			keys:        []string{"p0", "p0", "p0"},
+			ackOrder:    []int{2, 1, 0},
+			wantCommits: []kafkaCommit{{0, 3}},
+		},
+		{
+			name:        "gap in the middle",
+			keys:        []string{"p0", "p0", "p0", "p0"},
+			ackOrder:    []int{0, 2, 3, 1},
+			wantCommits: []kafkaCommit{{0, 1}, {0, 4}},
+		},
+		{
+			name:        "partitions commit independently",
+			keys:        []string{"p0", "p1", "p0", "p1"},
+			ackOrder:    []int{1, 3, 2, 0},
+			wantCommits: []kafkaCommit{{1, 1}, {1, 2}, {0, 2}},
+		},
+		{
+			name:        "unacked job holds back its partition",
+			keys:        []string{"p0", "p0"},
+			ackOrder:    []int{1},
+			wantCommits: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			fake := newFakeKafka()
+			q := NewKafkaQueue(fake, KafkaOptions{Topic: "jobs", Group: "workers", PollTimeout: 20 * time.Millisecond})
+
+			for _, key := range tt.keys {
+				if err := q.Enqueue(ctx, types.NewJob(key, json.RawMessage(`{}`), 0)); err != nil {
+					t.Fatalf("Enqueue: %v", err)
+				}
+			}
+
+			var jobs []*types.Job
+			for range tt.keys {
+				job, err := q.Dequeue(ctx)
+				if err != nil || job == nil {
+					t.Fatalf("Dequeue = %v, %v; want a job", job, err)
+				}
+				jobs = append(jobs, job)
+			}
+			if job, err := q.Dequeue(ctx); err != nil || job != nil {
+				t.Fatalf("Dequeue of a drained topic = %v, %v; want nothing", job, err)
+			}
+
+			for _, i := range tt.ackOrder {
+				if err := q.Ack(ctx, jobs[i]); err != nil {
+					t.Fatalf("Ack: %v", err)
+				}
+			}
+			// A second ack of the same delivery changes nothing
+			if len(tt.ackOrder) > 0 {
+				q.Ack(ctx, jobs[tt.ackOrder[0]])
+			}
+
+			if len(fake.commits) != len(tt.wantCommits) {
+				t.Fatalf("commits = %v, want %v", fake.commits, tt.wantCommits)
+			}
+			for i := range fake.commits {
+				if fake.commits[i] != tt.wantCommits[i] {
+					t.Fatalf("commits = %v, want %v", fake.commits, tt.wantCommits)
+				}
+			}
+		})
+	}
+}
+
+func TestKafkaQueuePoisonRecord(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeKafka()
+	q := NewKafkaQueue(fake, KafkaOptions{Topic: "jobs", Group: "workers", PollTimeout: 20 * time.Millisecond})
+
+	fake.Produce(ctx, KafkaRecord{Topic: "jobs", Key: []byte("p0"), Value: []byte("not json")})
+	job := types.NewJob("p0", json.RawMessage(`{}`), 0)
+	if err := q.Enqueue(ctx, job); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	if got, err := q.Dequeue(ctx); err == nil || got != nil {
+		t.Fatalf("Dequeue of a poison record = %v, %v; want an error", got, err)
+	}
+	got, err := q.Dequeue(ctx)
+	if err != nil || got == nil || got.ID != job.ID {
+		t.Fatalf("Dequeue = %v, %v; want job %s", got, err, job.ID)
+	}
+
+	// The poison record doesn't hold back the commit
+	if err := q.Ack(ctx, got); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	if len(fake.commits) != 1 || fake.commits[0] != (kafkaCommit{0, 2}) {
+		t.Fatalf("commits = %v, want past both records", fake.commits)
+	}
+}
+
+func TestKafkaQueueDequeueCancelled(t *testing.T) {
+	q := NewKafkaQueue(newFakeKafka(), KafkaOptions{Topic: "jobs", Group: "workers", PollTimeout: time.Second})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if job, err := q.Dequeue(ctx); !errors.Is(err, context.Canceled) || job != nil {
+		t.Fatalf("Dequeue = %v, %v; want %v", job, err, context.Canceled)
+	}
+}
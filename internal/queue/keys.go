@@ -0,0 +1,38 @@
+package queue
+
+// KeyTypes returns the Redis type each fixed key of this package holds,
+// keyed by full key name. A key of another type under the same name belongs
+// to something else sharing the Redis database and prefix.
+func (l *Layout) KeyTypes() map[string]string {
+	types := map[string]string{
+		jobQueueKey:            "list",
+		statsKey:               "hash",
+		deadLetterQueueKey:     "list",
+		dlqStatsKey:            "hash",
+		highPriorityQueueKey:   "list",
+		normalPriorityQueueKey: "list",
+		lowPriorityQueueKey:    "list",
+		priorityRatioKey:       "hash",
+		priorityCountersKey:    "hash",
+		scheduledJobsKey:       "zset",
+		scheduledJobsStatsKey:  "hash",
+		pendingRetriesKey:      "hash",
+		workerHeartbeatsKey:    "hash",
+		queueAliasesKey:        "hash",
+		pausedQueuesKey:        "hash",
+		jobTemplatesKey:        "hash",
+		historyRecordsKey:      "hash",
+		historyIndexKey:        "zset",
+		resultIndexKey:         "zset",
+		recordingKey:           "list",
+		chaosSettingsKey:       "string",
+		readOnlyKey:            "string",
+		quarantineKey:          "hash",
+	}
+
+	prefixed := make(map[string]string, len(types))
+	for name, kind := range types {
+		prefixed[l.key(name)] = kind
+	}
+	return prefixed
+}
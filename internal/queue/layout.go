@@ -0,0 +1,69 @@
+package queue
+
+import (
+	"fmt"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+// Layout is how a deployment keeps jobs: the prefix of its Redis keys, the
+// wire format and payload compression of stored jobs, and job signing. The
+// Redis queues build one from their options and every Redis-backed store
+// takes the queue's, so they all agree on it within a process.
+type Layout struct {
+	prefix               string
+	codec                Codec
+	compression          string
+	compressionThreshold int
+	signing              Signing
+}
+
+// newLayout returns the layout the options call for
+func newLayout(opts RedisOptions) *Layout {
+	threshold := opts.CompressionThreshold
+	if threshold <= 0 {
+		threshold = DefaultCompressionThreshold
+	}
+
+	return &Layout{
+		prefix:               opts.keyPrefix(),
+		codec:                codecFor(opts.Format),
+		compression:          opts.Compression,
+		compressionThreshold: threshold,
+		signing:              opts.Signing,
+	}
+}
+
+// signingLayout returns the layout of a backend that keeps jobs outside
+// Redis, where only signing applies
+func signingLayout(signing Signing) *Layout {
+	return newLayout(RedisOptions{Signing: signing})
+}
+
+// key returns the full Redis key for name
+func (l *Layout) key(name string) string {
+	return l.prefix + name
+}
+
+// Prefix returns the prefix in effect for the layout's keys, including the
+// hash tag added in cluster mode
+func (l *Layout) Prefix() string {
+	return l.prefix
+}
+
+// Signing returns how jobs are signed and verified
+func (l *Layout) Signing() Signing {
+	return l.signing
+}
+
+// encodeJob serializes a job with the layout's codec, prefixed with its format byte
+func (l *Layout) encodeJob(job *types.Job) ([]byte, error) {
+	data, err := l.codec.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job: %w", err)
+	}
+	if format := l.codec.FormatByte(); format != 0 {
+		data = append([]byte{format}, data...)
+	}
+	return data, nil
+}
@@ -0,0 +1,80 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const leaderKeyPrefix = "leader:" // Redis string per lease holding the ID of the process that leads
+
+// acquireLeaseScript takes a free lease or extends one the caller already holds
+var acquireLeaseScript = redis.NewScript(`
+local holder = redis.call('GET', KEYS[1])
+if holder == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+if holder then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return 1
+`)
+
+// releaseLeaseScript deletes a lease only while the caller still holds it
+var releaseLeaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// LeaderLease elects one process among the replicas sharing a Redis instance
+// to run a task that must not run once per replica. The holder keeps the
+// lease by acquiring it again before it expires; when the holder stops or
+// dies the lease expires and the next replica to ask takes it over.
+type LeaderLease struct {
+	client redis.Cmdable
+	layout *Layout
+	name   string
+	holder string
+	ttl    time.Duration
+}
+
+// NewLeaderLease creates the lease called name for the process holder,
+// which lapses ttl after it was last acquired
+func NewLeaderLease(client redis.Cmdable, layout *Layout, name, holder string, ttl time.Duration) *LeaderLease {
+	return &LeaderLease{
+		client: client,
+		layout: layout,
+		name:   name,
+		holder: holder,
+		ttl:    ttl,
+	}
+}
+
+func (l *LeaderLease) key() string {
+	return l.layout.key(leaderKeyPrefix + l.name)
+}
+
+// Acquire takes the lease if it is free or extends it if this process
+// already holds it, and reports whether this process leads
+func (l *LeaderLease) Acquire(ctx context.Context) (bool, error) {
+	held, err := acquireLeaseScript.Run(ctx, l.client, []string{l.key()}, l.holder, l.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire %s lease: %w", l.name, err)
+	}
+	return held == 1, nil
+}
+
+// Release gives the lease up so another replica can lead without waiting
+// for it to expire. It does nothing if this process doesn't hold it.
+func (l *LeaderLease) Release(ctx context.Context) error {
+	if err := releaseLeaseScript.Run(ctx, l.client, []string{l.key()}, l.holder).Err(); err != nil {
+		return fmt.Errorf("failed to release %s lease: %w", l.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+// ErrQueueClosed is returned by a MemoryQueue after Close
+var ErrQueueClosed = errors.New("queue closed")
+
+// MemoryQueue is an in-process FIFO queue for local development and tests.
+// Jobs are lost when the process exits and are only visible to that process.
+type MemoryQueue struct {
+	mu       sync.Mutex
+	jobs     []*types.Job
+	enqueued int
+	dequeued int
+
+	ready       chan struct{} // Signalled when a job is added
+	closed      chan struct{}
+	closeOnce   sync.Once
+	pollTimeout time.Duration
+	signing     Signing
+}
+
+// NewMemoryQueue creates an empty queue; Dequeue blocks for up to pollTimeout
+// waiting for a job, like BRPOP on the Redis queue
+func NewMemoryQueue(pollTimeout time.Duration) *MemoryQueue {
+	if pollTimeout <= 0 {
+		pollTimeout = time.Second
+	}
+	return &MemoryQueue{
+		ready:       make(chan struct{}, 1),
+		closed:      make(chan struct{}),
+		pollTimeout: pollTimeout,
+	}
+}
+
+// SetSigning signs jobs as they are enqueued
+func (m *MemoryQueue) SetSigning(signing Signing) {
+	m.signing = signing
+}
+
+// Enqueue adds a copy of the job to the back of the queue
+func (m *MemoryQueue) Enqueue(ctx context.Context, job *types.Job) error {
+	if err := job.Validate(); err != nil {
+		return fmt.Errorf("job validation failed: %w", err)
+	}
+
+	select {
+	case <-m.closed:
+		return ErrQueueClosed
+	default:
+	}
+
+	job.EnqueuedAt = time.Now().UTC()
+
+	signed, err := m.signing.sign(job)
+	if err != nil {
+		return err
+	}
+
+	// Store a copy so callers can't change a queued job, as with serialization
+	stored := *signed
+	stored.Payload = append([]byte(nil), job.Payload...)
+	stored.Metadata = job.Metadata.Clone()
+
+	m.mu.Lock()
+	m.jobs = append(m.jobs, &stored)
+	m.enqueued++
+	m.mu.Unlock()
+
+	m.signal()
+	return nil
+}
+
+// Dequeue removes the oldest job, waiting up to the poll timeout for one.
+// It returns nil without an error when no job arrived in time.
+func (m *MemoryQueue) Dequeue(ctx context.Context) (*types.Job, error) {
+	timer := time.NewTimer(m.pollTimeout)
+	defer timer.Stop()
+
+	for {
+		if job := m.pop(); job != nil {
+			return job, nil
+		}
+
+		select {
+		case <-m.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-m.closed:
+			return nil, ErrQueueClosed
+		case <-timer.C:
+			return nil, nil
+		}
+	}
+}
+
+// pop takes the oldest job, passing the signal on if more are waiting
+func (m *MemoryQueue) pop() *types.Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.jobs) == 0 {
+		return nil
+	}
+
+	job := m.jobs[0]
+	m.jobs[0] = nil
+	m.jobs = m.jobs[1:]
+	m.dequeued++
+
+	if len(m.jobs) > 0 {
+		m.signal()
+	}
+	return job
+}
+
+// signal wakes one waiting Dequeue without blocking
+func (m *MemoryQueue) signal() {
+	select {
+	case m.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Size returns the number of queued jobs
+func (m *MemoryQueue) Size(ctx context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.jobs), nil
+}
+
+// Health fails once the queue is closed
+func (m *MemoryQueue) Health(ctx context.Context) error {
+	select {
+	case <-m.closed:
+		return ErrQueueClosed
+	default:
+		return nil
+	}
+}
+
+// Close stops the queue; waiting Dequeue calls return ErrQueueClosed
+func (m *MemoryQueue) Close() error {
+	m.closeOnce.Do(func() {
+		close(m.closed)
+	})
+	return nil
+}
+
+// GetStats returns the queue size and throughput counters
+func (m *MemoryQueue) GetStats(ctx context.Context) (*QueueStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return &QueueStats{
+		QueueSize:     len(m.jobs),
+		TotalEnqueued: m.enqueued,
+		TotalDequeued: m.dequeued,
+	}, nil
+}
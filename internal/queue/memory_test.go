@@ -0,0 +1,157 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+func TestMemoryQueueDequeue(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup runs before the Dequeue under test
+		setup   func(t *testing.T, q *MemoryQueue) context.Context
+		wantJob bool
+		wantErr error
+	}{
+		{
+			name: "queued job",
+			setup: func(t *testing.T, q *MemoryQueue) context.Context {
+				if err := q.Enqueue(context.Background(), types.NewJob("email", json.RawMessage(`{}`), 0)); err != nil {
+					t.Fatalf("Enqueue: %v", err)
+				}
+				return context.Background()
+			},
+			wantJob: true,
+		},
+		{
+			name: "job enqueued while waiting",
+			setup: func(t *testing.T, q *MemoryQueue) context.Context {
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					q.Enqueue(context.Background(), types.NewJob("email", json.RawMessage(`{}`), 0))
+				}()
+				return context.Background()
+			},
+			wantJob: true,
+		},
+		{
+			name:    "empty queue times out",
+			setup:   func(t *testing.T, q *MemoryQueue) context.Context { return context.Background() },
+			wantJob: false,
+		},
+		{
+			name: "cancelled context",
+			setup: func(t *testing.T, q *MemoryQueue) context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			wantErr: context.Canceled,
+		},
+		{
+			name: "closed queue",
+			setup: func(t *testing.T, q *MemoryQueue) context.Context {
+				q.Close()
+				return context.Background()
+			},
+			wantErr: ErrQueueClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewMemoryQueue(100 * time.Millisecond)
+			ctx := tt.setup(t, q)
+
+			job, err := q.Dequeue(ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Dequeue error = %v, want %v", err, tt.wantErr)
+			}
+			if (job != nil) != tt.wantJob {
+				t.Fatalf("Dequeue = %v, want a job: %v", job, tt.wantJob)
+			}
+		})
+	}
+}
+
+func TestMemoryQueueOrderAndStats(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue(10 * time.Millisecond)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		job := types.NewJob("email", json.RawMessage(`{"n":1}`), 0)
+		ids = append(ids, job.ID)
+		if err := q.Enqueue(ctx, job); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+		// The queue keeps a copy, so later changes by the caller don't leak in
+		job.Payload[5] = '9'
+	}
+	if err := q.Enqueue(ctx, &types.Job{}); err == nil {
+		t.Fatal("Enqueue of an invalid job succeeded")
+	}
+
+	for _, want := range ids {
+		job, err := q.Dequeue(ctx)
+		if err != nil || job == nil || job.ID != want {
+			t.Fatalf("Dequeue = %v, %v; want job %s", job, err, want)
+		}
+		if string(job.Payload) != `{"n":1}` {
+			t.Fatalf("payload = %s, want the payload as enqueued", job.Payload)
+		}
+	}
+
+	stats, err := q.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+	if stats.QueueSize != 0 || stats.TotalEnqueued != 3 || stats.TotalDequeued != 3 {
+		t.Fatalf("stats = %+v, want 3 enqueued and dequeued", stats)
+	}
+}
+
+func TestMemoryQueueConcurrentConsumers(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue(20 * time.Millisecond)
+
+	const jobs = 200
+	for i := 0; i < jobs; i++ {
+		if err := q.Enqueue(ctx, types.NewJob("email", json.RawMessage(`{}`), 0)); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+
+	var mu sync.Mutex
+	seen := make(map[string]bool, jobs)
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				job, err := q.Dequeue(ctx)
+				if err != nil || job == nil {
+					return
+				}
+				mu.Lock()
+				if seen[job.ID] {
+					t.Errorf("job %s handed out twice", job.ID)
+				}
+				seen[job.ID] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != jobs {
+		t.Fatalf("consumers got %d jobs, want %d", len(seen), jobs)
+	}
+}
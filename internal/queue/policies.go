@@ -0,0 +1,194 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	jobPoliciesKey = "job:policies" // Redis hash of job type → JSON policy overriding the configured one
+
+	policyRefreshInterval = 5 * time.Second // How often processes reload policy overrides
+)
+
+// ErrPoliciesReadOnly is returned when overriding policies without Redis
+var ErrPoliciesReadOnly = errors.New("job policies are read-only without the redis backend")
+
+// PolicyStore serves the job type policies in effect. Policies from
+// configuration are the baseline; overrides saved through the API live in
+// Redis, replace the configured policy of their type and are picked up by
+// every process within a few seconds.
+type PolicyStore struct {
+	client     redis.Cmdable
+	layout     *Layout
+	configured types.JobPolicies
+
+	mu        sync.RWMutex
+	overrides types.JobPolicies
+	current   types.JobPolicies
+	onChange  func(types.JobPolicies)
+}
+
+// NewPolicyStore creates a policy store; a nil client serves only the
+// configured policies
+func NewPolicyStore(client redis.Cmdable, layout *Layout, configured types.JobPolicies) *PolicyStore {
+	return &PolicyStore{
+		client:     client,
+		layout:     layout,
+		configured: configured,
+		overrides:  types.JobPolicies{},
+		current:    mergePolicies(configured, nil),
+	}
+}
+
+// Get returns the policy in effect for a job type; the zero policy when none is set
+func (p *PolicyStore) Get(jobType string) types.JobPolicy {
+	if p == nil {
+		return types.JobPolicy{}
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.current.Get(jobType)
+}
+
+// Policies returns every policy in effect
+func (p *PolicyStore) Policies() types.JobPolicies {
+	if p == nil {
+		return types.JobPolicies{}
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.current
+}
+
+// Overridden reports whether the job type's policy comes from a runtime override
+func (p *PolicyStore) Overridden(jobType string) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	_, ok := p.overrides[jobType]
+	return ok
+}
+
+// OnChange registers a callback invoked with the policies in effect whenever
+// an override is saved, cleared or picked up from Redis
+func (p *PolicyStore) OnChange(fn func(types.JobPolicies)) {
+	p.mu.Lock()
+	p.onChange = fn
+	p.mu.Unlock()
+}
+
+// Put saves an override for the job type and applies it immediately
+func (p *PolicyStore) Put(ctx context.Context, jobType string, policy types.JobPolicy) error {
+	if p.client == nil {
+		return ErrPoliciesReadOnly
+	}
+	if err := (types.JobPolicies{jobType: policy}).Validate(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job policy: %w", err)
+	}
+	if err := p.client.HSet(ctx, p.layout.key(jobPoliciesKey), jobType, data).Err(); err != nil {
+		return fmt.Errorf("failed to save job policy: %w", err)
+	}
+
+	return p.Refresh(ctx)
+}
+
+// Clear removes the job type's override, reverting it to its configured
+// policy, and reports whether there was one
+func (p *PolicyStore) Clear(ctx context.Context, jobType string) (bool, error) {
+	if p.client == nil {
+		return false, ErrPoliciesReadOnly
+	}
+
+	removed, err := p.client.HDel(ctx, p.layout.key(jobPoliciesKey), jobType).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to clear job policy: %w", err)
+	}
+
+	return removed > 0, p.Refresh(ctx)
+}
+
+// Refresh reloads the overrides from Redis, notifying OnChange when the
+// policies in effect changed. Overrides that no longer validate are skipped.
+func (p *PolicyStore) Refresh(ctx context.Context) error {
+	if p.client == nil {
+		return nil
+	}
+
+	values, err := p.client.HGetAll(ctx, p.layout.key(jobPoliciesKey)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to load job policies: %w", err)
+	}
+
+	overrides := make(types.JobPolicies, len(values))
+	for jobType, data := range values {
+		var policy types.JobPolicy
+		if err := json.Unmarshal([]byte(data), &policy); err != nil {
+			continue
+		}
+		if policy.Validate() != nil {
+			continue
+		}
+		overrides[jobType] = policy
+	}
+	current := mergePolicies(p.configured, overrides)
+
+	p.mu.Lock()
+	changed := !reflect.DeepEqual(current, p.current)
+	p.overrides = overrides
+	p.current = current
+	onChange := p.onChange
+	p.mu.Unlock()
+
+	if changed && onChange != nil {
+		onChange(current)
+	}
+	return nil
+}
+
+// Watch refreshes the overrides every few seconds until ctx is done
+func (p *PolicyStore) Watch(ctx context.Context) {
+	if p.client == nil {
+		return
+	}
+
+	ticker := time.NewTicker(policyRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.Refresh(ctx)
+		}
+	}
+}
+
+// mergePolicies returns the configured policies with overrides replacing
+// those of the same job type
+func mergePolicies(configured, overrides types.JobPolicies) types.JobPolicies {
+	merged := make(types.JobPolicies, len(configured)+len(overrides))
+	for jobType, policy := range configured {
+		merged[jobType] = policy
+	}
+	for jobType, policy := range overrides {
+		merged[jobType] = policy
+	}
+	return merged
+}
@@ -0,0 +1,868 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	_ "github.com/jackc/pgx/v5/stdlib" // Registers the "pgx" driver POSTGRES_DRIVER defaults to
+)
+
+// postgresSchema creates the tables used by the Postgres backend
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS gopher_jobs (
+	seq         BIGSERIAL PRIMARY KEY,
+	job_id      TEXT NOT NULL,
+	queue       TEXT NOT NULL,
+	data        JSONB NOT NULL,
+	run_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+	enqueued_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS gopher_jobs_ready ON gopher_jobs (queue, run_at, seq);
+
+CREATE TABLE IF NOT EXISTS gopher_stats (
+	queue TEXT NOT NULL,
+	name  TEXT NOT NULL,
+	value BIGINT NOT NULL DEFAULT 0,
+	PRIMARY KEY (queue, name)
+);
+
+CREATE TABLE IF NOT EXISTS gopher_dead_jobs (
+	job_id    TEXT PRIMARY KEY,
+	job_type  TEXT NOT NULL,
+	data      JSONB NOT NULL,
+	error     TEXT NOT NULL,
+	reason    TEXT NOT NULL,
+	failed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS gopher_dead_jobs_failed_at ON gopher_dead_jobs (failed_at);
+
+CREATE TABLE IF NOT EXISTS gopher_schedules (
+	id        TEXT PRIMARY KEY,
+	cron      TEXT NOT NULL,
+	data      JSONB NOT NULL,
+	next_run  TIMESTAMPTZ NOT NULL,
+	paused_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS gopher_schedules_next_run ON gopher_schedules (next_run);
+
+CREATE TABLE IF NOT EXISTS gopher_schedule_runs (
+	schedule_id TEXT NOT NULL,
+	job_id      TEXT NOT NULL,
+	enqueued_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	outcome     JSONB,
+	PRIMARY KEY (schedule_id, job_id)
+);
+CREATE INDEX IF NOT EXISTS gopher_schedule_runs_enqueued_at ON gopher_schedule_runs (schedule_id, enqueued_at);
+`
+
+const (
+	postgresPollStep = 250 * time.Millisecond // How often an empty queue is checked during a long poll
+	dlqStatsQueue    = "gopher:dlq"           // gopher_stats row group for DLQ counters
+)
+
+// PostgresOptions configures the Postgres queue backend
+type PostgresOptions struct {
+	QueueName   string        // Logical queue stored in the jobs table, empty for the default queue
+	PollTimeout time.Duration // How long a dequeue waits for a job, defaults to 1s
+}
+
+// PostgresQueue implements Queue on Postgres, claiming jobs with
+// SELECT ... FOR UPDATE SKIP LOCKED so workers never block each other.
+// The database/sql driver (e.g. pgx or lib/pq) must be linked into the binary.
+type PostgresQueue struct {
+	db      *sql.DB
+	name    string
+	opts    PostgresOptions
+	keyring *encryption.Keyring
+	layout  *Layout
+}
+
+// NewPostgresQueue creates the schema if needed and returns the queue
+func NewPostgresQueue(ctx context.Context, db *sql.DB, opts PostgresOptions) (*PostgresQueue, error) {
+	if err := db.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to Postgres: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, postgresSchema); err != nil {
+		return nil, fmt.Errorf("failed to create Postgres schema: %w", err)
+	}
+
+	name := opts.QueueName
+	if name == "" {
+		name = DefaultQueueName
+	}
+	if opts.PollTimeout <= 0 {
+		opts.PollTimeout = time.Second
+	}
+
+	return &PostgresQueue{db: db, name: name, opts: opts, layout: signingLayout(Signing{})}, nil
+}
+
+// OpenPostgres opens dsn with the named database/sql driver and creates the queue
+func OpenPostgres(ctx context.Context, driver, dsn string, opts PostgresOptions) (*PostgresQueue, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Postgres with driver %q (is it linked into the binary?): %w", driver, err)
+	}
+
+	p, err := NewPostgresQueue(ctx, db, opts)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// SetKeyring enables payload encryption with the given keyring
+func (p *PostgresQueue) SetKeyring(keyring *encryption.Keyring) {
+	p.keyring = keyring
+}
+
+// SetSigning signs jobs as they are stored
+func (p *PostgresQueue) SetSigning(signing Signing) {
+	p.layout = signingLayout(signing)
+}
+
+// DB returns the underlying database handle
+func (p *PostgresQueue) DB() *sql.DB {
+	return p.db
+}
+
+// Enqueue adds a job that is ready immediately
+func (p *PostgresQueue) Enqueue(ctx context.Context, job *types.Job) error {
+	return p.Schedule(ctx, job, time.Now())
+}
+
+// Schedule adds a job that becomes ready at runAt; Postgres needs no separate scheduled set
+func (p *PostgresQueue) Schedule(ctx context.Context, job *types.Job, runAt time.Time) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := p.insert(ctx, tx, job, runAt); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return nil
+}
+
+// insert adds a job that becomes ready at runAt within tx
+func (p *PostgresQueue) insert(ctx context.Context, tx *sql.Tx, job *types.Job, runAt time.Time) error {
+	if err := job.Validate(); err != nil {
+		return fmt.Errorf("job validation failed: %w", err)
+	}
+
+	job.EnqueuedAt = time.Now().UTC()
+
+	sealed, err := p.layout.sealJob(job, p.keyring)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(sealed)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	name := targetQueue(job, p.name)
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO gopher_jobs (job_id, queue, data, run_at) VALUES ($1, $2, $3, $4)`,
+		job.ID, name, data, runAt.UTC()); err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return incrementStat(ctx, tx, name, "total_enqueued")
+}
+
+// Dequeue claims the oldest ready job, polling for up to the poll timeout.
+// It returns nil without an error when no job became ready in time.
+func (p *PostgresQueue) Dequeue(ctx context.Context) (*types.Job, error) {
+	deadline := time.Now().Add(p.opts.PollTimeout)
+
+	for {
+		job, err := p.claim(ctx)
+		if err != nil || job != nil {
+			return job, err
+		}
+
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return nil, nil
+		}
+		if wait > postgresPollStep {
+			wait = postgresPollStep
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// claim deletes and returns one ready job, skipping rows other workers hold
+func (p *PostgresQueue) claim(ctx context.Context) (*types.Job, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dequeue job: %w", err)
+	}
+	defer tx.Rollback()
+
+	var data []byte
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM gopher_jobs WHERE seq = (
+			SELECT seq FROM gopher_jobs
+			WHERE queue = $1 AND run_at <= now()
+			ORDER BY run_at, seq
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING data`, p.name).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to dequeue job: %w", err)
+	}
+
+	if err := incrementStat(ctx, tx, p.name, "total_dequeued"); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to dequeue job: %w", err)
+	}
+
+	var job types.Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+	if err := openJob(&job, p.keyring); err != nil {
+		return nil, fmt.Errorf("failed to decrypt job: %w", err)
+	}
+	return &job, nil
+}
+
+// Size returns the number of ready jobs
+func (p *PostgresQueue) Size(ctx context.Context) (int, error) {
+	var size int
+	err := p.db.QueryRowContext(ctx,
+		`SELECT count(*) FROM gopher_jobs WHERE queue = $1 AND run_at <= now()`, p.name).Scan(&size)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get queue size: %w", err)
+	}
+	return size, nil
+}
+
+// Health checks if Postgres is reachable
+func (p *PostgresQueue) Health(ctx context.Context) error {
+	if err := p.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("postgres health check failed: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database handle
+func (p *PostgresQueue) Close() error {
+	return p.db.Close()
+}
+
+// GetStats returns the queue size and throughput counters
+func (p *PostgresQueue) GetStats(ctx context.Context) (*QueueStats, error) {
+	size, err := p.Size(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db.QueryContext(ctx, `SELECT name, value FROM gopher_stats WHERE queue = $1`, p.name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := &QueueStats{QueueSize: size}
+	for rows.Next() {
+		var name string
+		var value int
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, fmt.Errorf("failed to get stats: %w", err)
+		}
+		switch name {
+		case "total_enqueued":
+			stats.TotalEnqueued = value
+		case "total_dequeued":
+			stats.TotalDequeued = value
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+
+	// Jobs scheduled for later are the Postgres equivalent of pending retries
+	if err := p.db.QueryRowContext(ctx,
+		`SELECT count(*) FROM gopher_jobs WHERE queue = $1 AND run_at > now()`, p.name).Scan(&stats.Retrying); err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// incrementStat bumps a counter in gopher_stats within tx
+func incrementStat(ctx context.Context, tx *sql.Tx, queue, name string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO gopher_stats (queue, name, value) VALUES ($1, $2, 1)
+		ON CONFLICT (queue, name) DO UPDATE SET value = gopher_stats.value + 1`, queue, name)
+	if err != nil {
+		return fmt.Errorf("failed to update stats: %w", err)
+	}
+	return nil
+}
+
+// PostgresDLQ implements DeadLetterQueue on Postgres
+type PostgresDLQ struct {
+	db      *sql.DB
+	queue   Queue
+	keyring *encryption.Keyring
+	layout  *Layout
+}
+
+// NewPostgresDLQ creates a dead letter queue that requeues into queue
+func NewPostgresDLQ(db *sql.DB, queue Queue) *PostgresDLQ {
+	return &PostgresDLQ{db: db, queue: queue, layout: signingLayout(Signing{})}
+}
+
+// SetKeyring enables payload encryption with the given keyring
+func (d *PostgresDLQ) SetKeyring(keyring *encryption.Keyring) {
+	d.keyring = keyring
+}
+
+// SetSigning signs jobs as they are stored
+func (d *PostgresDLQ) SetSigning(signing Signing) {
+	d.layout = signingLayout(signing)
+}
+
+// Send records a failed job
+func (d *PostgresDLQ) Send(ctx context.Context, job *types.Job, reason types.FailureReason, errorMsg string) error {
+	sealed, err := d.layout.sealJob(job, d.keyring)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(sealed)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to add job to DLQ: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO gopher_dead_jobs (job_id, job_type, data, error, reason, failed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (job_id) DO UPDATE SET
+			job_type = EXCLUDED.job_type, data = EXCLUDED.data, error = EXCLUDED.error,
+			reason = EXCLUDED.reason, failed_at = EXCLUDED.failed_at`,
+		job.ID, job.Type, data, errorMsg, string(reason), time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to add job to DLQ: %w", err)
+	}
+	if err := incrementStat(ctx, tx, dlqStatsQueue, "total_sent"); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to add job to DLQ: %w", err)
+	}
+	return nil
+}
+
+// Size returns the number of jobs in the DLQ
+func (d *PostgresDLQ) Size(ctx context.Context) (int, error) {
+	var size int
+	if err := d.db.QueryRowContext(ctx, `SELECT count(*) FROM gopher_dead_jobs`).Scan(&size); err != nil {
+		return 0, fmt.Errorf("failed to get DLQ size: %w", err)
+	}
+	return size, nil
+}
+
+// Reprocess moves a job from the DLQ back to the main queue
+func (d *PostgresDLQ) Reprocess(ctx context.Context, jobID string) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to reprocess job: %w", err)
+	}
+	defer tx.Rollback()
+
+	var data []byte
+	err = tx.QueryRowContext(ctx,
+		`DELETE FROM gopher_dead_jobs WHERE job_id = $1 RETURNING data`, jobID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", ErrNotInDLQ, jobID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reprocess job: %w", err)
+	}
+
+	var job types.Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+	if err := openJob(&job, d.keyring); err != nil {
+		return fmt.Errorf("failed to decrypt job: %w", err)
+	}
+
+	// Reset attempts counter
+	job.Attempts = 0
+	job.UpdatedAt = time.Now().UTC()
+
+	if err := incrementStat(ctx, tx, dlqStatsQueue, "reprocessed"); err != nil {
+		return err
+	}
+
+	// Requeue before committing so a failed enqueue leaves the job in the DLQ
+	if err := d.queue.Enqueue(ctx, &job); err != nil {
+		return fmt.Errorf("failed to requeue job: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to reprocess job: %w", err)
+	}
+	return nil
+}
+
+// List returns jobs in the DLQ with pagination, newest first
+func (d *PostgresDLQ) List(ctx context.Context, offset, limit int) ([]*types.FailedJobInfo, error) {
+	return d.query(ctx, `
+		SELECT data, error, reason, failed_at FROM gopher_dead_jobs
+		ORDER BY failed_at DESC OFFSET $1 LIMIT $2`, offset, limit)
+}
+
+// ListByReason returns jobs with the given failure reason and the total match count
+func (d *PostgresDLQ) ListByReason(ctx context.Context, reason types.FailureReason, offset, limit int) ([]*types.FailedJobInfo, int, error) {
+	var total int
+	if err := d.db.QueryRowContext(ctx,
+		`SELECT count(*) FROM gopher_dead_jobs WHERE reason = $1`, string(reason)).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to list DLQ jobs: %w", err)
+	}
+
+	jobs, err := d.query(ctx, `
+		SELECT data, error, reason, failed_at FROM gopher_dead_jobs
+		WHERE reason = $3
+		ORDER BY failed_at DESC OFFSET $1 LIMIT $2`, offset, limit, string(reason))
+	if err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
+
+// query scans FailedJobInfo rows, skipping entries that can't be decoded
+func (d *PostgresDLQ) query(ctx context.Context, query string, args ...interface{}) ([]*types.FailedJobInfo, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list DLQ jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*types.FailedJobInfo
+	for rows.Next() {
+		var data []byte
+		var reason string
+		info := &types.FailedJobInfo{Job: &types.Job{}}
+		if err := rows.Scan(&data, &info.Error, &reason, &info.FailedAt); err != nil {
+			return nil, fmt.Errorf("failed to list DLQ jobs: %w", err)
+		}
+		if err := json.Unmarshal(data, info.Job); err != nil {
+			continue
+		}
+		if err := openJob(info.Job, d.keyring); err != nil {
+			continue
+		}
+		info.Reason = types.FailureReason(reason)
+		jobs = append(jobs, info)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list DLQ jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// GetStats returns size, age and inflow statistics for the DLQ
+func (d *PostgresDLQ) GetStats(ctx context.Context) (*DLQStats, error) {
+	now := time.Now().UTC()
+	stats := &DLQStats{
+		ByType:   make(map[string]int),
+		ByReason: make(map[string]int),
+	}
+
+	var oldest sql.NullTime
+	if err := d.db.QueryRowContext(ctx, `
+		SELECT count(*), min(failed_at),
+			count(*) FILTER (WHERE failed_at > $1),
+			count(*) FILTER (WHERE failed_at > $2)
+		FROM gopher_dead_jobs`,
+		now.Add(-5*time.Minute), now.Add(-dlqInflowWindow)).
+		Scan(&stats.Size, &oldest, &stats.InflowLast5m, &stats.InflowLastHour); err != nil {
+		return nil, fmt.Errorf("failed to get DLQ stats: %w", err)
+	}
+	if oldest.Valid {
+		failedAt := oldest.Time.UTC()
+		stats.OldestFailedAt = &failedAt
+		stats.OldestAgeSecs = now.Sub(failedAt).Seconds()
+	}
+	stats.InflowPerMinute = float64(stats.InflowLast5m) / 5
+
+	groups := []struct {
+		column string
+		into   map[string]int
+	}{
+		{"job_type", stats.ByType},
+		{"reason", stats.ByReason},
+	}
+	for _, group := range groups {
+		rows, err := d.db.QueryContext(ctx,
+			fmt.Sprintf(`SELECT %s, count(*) FROM gopher_dead_jobs GROUP BY 1`, group.column))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get DLQ stats: %w", err)
+		}
+		for rows.Next() {
+			var key string
+			var count int
+			if err := rows.Scan(&key, &count); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to get DLQ stats: %w", err)
+			}
+			group.into[key] = count
+		}
+		rows.Close()
+	}
+
+	rows, err := d.db.QueryContext(ctx, `SELECT name, value FROM gopher_stats WHERE queue = $1`, dlqStatsQueue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DLQ stats: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var value int
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, fmt.Errorf("failed to get DLQ stats: %w", err)
+		}
+		switch name {
+		case "total_sent":
+			stats.TotalSent = value
+		case "reprocessed":
+			stats.Reprocessed = value
+		}
+	}
+
+	return stats, rows.Err()
+}
+
+// PostgresSchedules implements Schedules on Postgres, next to the jobs the
+// schedules spawn. Due schedules are claimed with FOR UPDATE SKIP LOCKED and
+// their runs enqueued in the same transaction, so any number of servers can
+// run the scheduler without enqueuing a run twice.
+type PostgresSchedules struct {
+	queue *PostgresQueue
+}
+
+// Schedules returns the recurring schedules kept in the queue's database.
+// They share the queue's keyring and signing, so call it once those are set.
+func (p *PostgresQueue) Schedules() Schedules {
+	return &PostgresSchedules{queue: p}
+}
+
+// ScheduleRecurring adds a schedule that first runs job on the cron expression
+func (s *PostgresSchedules) ScheduleRecurring(ctx context.Context, job *types.Job, cronExpr string) error {
+	if err := job.Validate(); err != nil {
+		return fmt.Errorf("job validation failed: %w", err)
+	}
+	schedule, err := parseCronExpression(cronExpr)
+	if err != nil {
+		return fmt.Errorf("invalid cron expression: %w", err)
+	}
+
+	// Every run carries the schedule's ID
+	id := fmt.Sprintf("sched-%d", time.Now().UnixNano())
+	job.ScheduleID = id
+	data, err := s.seal(job)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.queue.db.ExecContext(ctx,
+		`INSERT INTO gopher_schedules (id, cron, data, next_run) VALUES ($1, $2, $3, $4)`,
+		id, cronExpr, data, schedule.Next(time.Now()).UTC()); err != nil {
+		return fmt.Errorf("failed to schedule job: %w", err)
+	}
+	return nil
+}
+
+// ProcessDueJobs enqueues a run of every due schedule and moves it on to its
+// next run. Paused schedules skip the run.
+func (s *PostgresSchedules) ProcessDueJobs(ctx context.Context) (int, error) {
+	tx, err := s.queue.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get due jobs: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, cron, data, paused_at IS NOT NULL FROM gopher_schedules
+		WHERE next_run <= now()
+		ORDER BY next_run
+		FOR UPDATE SKIP LOCKED`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get due jobs: %w", err)
+	}
+	type dueSchedule struct {
+		id, cron string
+		data     []byte
+		paused   bool
+	}
+	var due []dueSchedule
+	for rows.Next() {
+		var d dueSchedule
+		if err := rows.Scan(&d.id, &d.cron, &d.data, &d.paused); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to get due jobs: %w", err)
+		}
+		due = append(due, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to get due jobs: %w", err)
+	}
+
+	processed := 0
+	for _, d := range due {
+		schedule, err := parseCronExpression(d.cron)
+		if err != nil {
+			continue
+		}
+		data := d.data
+
+		if !d.paused {
+			job, err := s.open(data)
+			if err != nil {
+				continue
+			}
+			if err := s.queue.insert(ctx, tx, job, time.Now()); err != nil {
+				return processed, err
+			}
+			if err := s.recordRun(ctx, tx, d.id, job.ID); err != nil {
+				return processed, err
+			}
+
+			// The next run is a fresh copy of the job
+			next := *job
+			next.ID = generateJobID()
+			next.Attempts = 0
+			next.CreatedAt = time.Now().UTC()
+			next.UpdatedAt = time.Now().UTC()
+			if data, err = s.seal(&next); err != nil {
+				return processed, err
+			}
+			processed++
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE gopher_schedules SET data = $2, next_run = $3 WHERE id = $1`,
+			d.id, data, schedule.Next(time.Now()).UTC()); err != nil {
+			return processed, fmt.Errorf("failed to reschedule job: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to enqueue due jobs: %w", err)
+	}
+	return processed, nil
+}
+
+// recordRun adds a spawned job to its schedule's runs, trimming the oldest
+func (s *PostgresSchedules) recordRun(ctx context.Context, tx *sql.Tx, scheduleID, jobID string) error {
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO gopher_schedule_runs (schedule_id, job_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		scheduleID, jobID); err != nil {
+		return fmt.Errorf("failed to record schedule run: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM gopher_schedule_runs WHERE schedule_id = $1 AND job_id NOT IN (
+			SELECT job_id FROM gopher_schedule_runs WHERE schedule_id = $1
+			ORDER BY enqueued_at DESC LIMIT $2
+		)`, scheduleID, maxScheduleRuns); err != nil {
+		return fmt.Errorf("failed to trim schedule runs: %w", err)
+	}
+	return nil
+}
+
+// RecordOutcome stores how a job spawned by a schedule finished. Jobs that
+// don't belong to a schedule, or whose run has been trimmed, are ignored.
+func (s *PostgresSchedules) RecordOutcome(ctx context.Context, finished *types.Job, result *types.JobResult) error {
+	if finished.ScheduleID == "" {
+		return nil
+	}
+
+	finishedAt := result.CompletedAt
+	if finishedAt.IsZero() {
+		finishedAt = time.Now().UTC()
+	}
+	data, err := json.Marshal(types.ScheduleRun{
+		Status:     result.Status,
+		Attempts:   finished.Attempts,
+		Duration:   result.Duration,
+		Error:      result.Error,
+		FinishedAt: &finishedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal schedule run outcome: %w", err)
+	}
+
+	if _, err := s.queue.db.ExecContext(ctx,
+		`UPDATE gopher_schedule_runs SET outcome = $3 WHERE schedule_id = $1 AND job_id = $2`,
+		finished.ScheduleID, finished.ID, data); err != nil {
+		return fmt.Errorf("failed to record schedule run outcome: %w", err)
+	}
+	return nil
+}
+
+// Runs returns the most recent jobs spawned by a schedule with their
+// outcomes, newest first. Runs that haven't finished have no status.
+func (s *PostgresSchedules) Runs(ctx context.Context, id string, limit int) ([]types.ScheduleRun, error) {
+	rows, err := s.queue.db.QueryContext(ctx, `
+		SELECT job_id, enqueued_at, outcome FROM gopher_schedule_runs
+		WHERE schedule_id = $1
+		ORDER BY enqueued_at DESC
+		LIMIT $2`, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedule runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []types.ScheduleRun{}
+	for rows.Next() {
+		var jobID string
+		var enqueuedAt time.Time
+		var outcome []byte
+		if err := rows.Scan(&jobID, &enqueuedAt, &outcome); err != nil {
+			return nil, fmt.Errorf("failed to list schedule runs: %w", err)
+		}
+
+		var run types.ScheduleRun
+		if outcome != nil {
+			json.Unmarshal(outcome, &run)
+		}
+		run.JobID = jobID
+		run.EnqueuedAt = enqueuedAt.UTC()
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// List returns all schedules ordered by their next run, with payloads decrypted
+func (s *PostgresSchedules) List(ctx context.Context) ([]types.ScheduledJob, error) {
+	rows, err := s.queue.db.QueryContext(ctx,
+		`SELECT id, cron, data, next_run, paused_at FROM gopher_schedules ORDER BY next_run, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled jobs: %w", err)
+	}
+	defer rows.Close()
+
+	scheduled := []types.ScheduledJob{}
+	for rows.Next() {
+		entry, err := s.scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list scheduled jobs: %w", err)
+		}
+		if entry != nil {
+			scheduled = append(scheduled, *entry)
+		}
+	}
+	return scheduled, rows.Err()
+}
+
+// SetPaused pauses or resumes a schedule without changing when it next runs
+func (s *PostgresSchedules) SetPaused(ctx context.Context, id string, paused bool) (*types.ScheduledJob, error) {
+	var pausedAt sql.NullTime
+	if paused {
+		pausedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	}
+
+	row := s.queue.db.QueryRowContext(ctx, `
+		UPDATE gopher_schedules SET paused_at = $2 WHERE id = $1
+		RETURNING id, cron, data, next_run, paused_at`, id, pausedAt)
+	entry, err := s.scan(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrScheduleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update schedule: %w", err)
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("failed to decrypt job of schedule %s", id)
+	}
+	return entry, nil
+}
+
+// rowScanner is a *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scan reads a gopher_schedules row, returning nil for one whose job can't
+// be read
+func (s *PostgresSchedules) scan(row rowScanner) (*types.ScheduledJob, error) {
+	var entry types.ScheduledJob
+	var data []byte
+	var pausedAt sql.NullTime
+	if err := row.Scan(&entry.ID, &entry.CronExpression, &data, &entry.ExecuteAt, &pausedAt); err != nil {
+		return nil, err
+	}
+
+	job, err := s.open(data)
+	if err != nil {
+		return nil, nil
+	}
+	entry.Job = job
+	entry.ExecuteAt = entry.ExecuteAt.UTC()
+	entry.Recurring = true
+	if pausedAt.Valid {
+		at := pausedAt.Time.UTC()
+		entry.Paused = true
+		entry.PausedAt = &at
+	}
+	return &entry, nil
+}
+
+// seal serializes a schedule's job the way the queue stores jobs
+func (s *PostgresSchedules) seal(job *types.Job) ([]byte, error) {
+	sealed, err := s.queue.layout.sealJob(job, s.queue.keyring)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal scheduled job: %w", err)
+	}
+	return data, nil
+}
+
+// open reads a schedule's job
+func (s *PostgresSchedules) open(data []byte) (*types.Job, error) {
+	var job types.Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal scheduled job: %w", err)
+	}
+	if err := openJob(&job, s.queue.keyring); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
@@ -0,0 +1,294 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+// openTestPostgres connects to the database in GOPHER_TEST_POSTGRES_DSN and
+// empties the gopher_* tables; the tests are skipped when it isn't set.
+// Point it at a throwaway database.
+func openTestPostgres(t *testing.T, opts PostgresOptions) *PostgresQueue {
+	t.Helper()
+
+	dsn := os.Getenv("GOPHER_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("GOPHER_TEST_POSTGRES_DSN is not set")
+	}
+	if opts.PollTimeout == 0 {
+		opts.PollTimeout = 50 * time.Millisecond
+	}
+
+	q, err := OpenPostgres(context.Background(), "pgx", dsn, opts)
+	if err != nil {
+		t.Fatalf("OpenPostgres: %v", err)
+	}
+	t.Cleanup(func() { q.Close() })
+
+	if _, err := q.DB().Exec(`TRUNCATE gopher_jobs, gopher_stats, gopher_dead_jobs, gopher_schedules, gopher_schedule_runs`); err != nil {
+		t.Fatalf("failed to empty the tables: %v", err)
+	}
+	return q
+}
+
+func TestPostgresQueue(t *testing.T) {
+	keyring, err := encryption.NewKeyring(map[string][]byte{"v1": bytes.Repeat([]byte{1}, 32)}, "v1")
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		keyring *encryption.Keyring
+		runAt   time.Duration // Delay of the job, 0 to enqueue it
+		other   bool          // Whether the job targets another queue
+		wantJob bool
+	}{
+		{name: "ready job", wantJob: true},
+		{name: "encrypted job", keyring: keyring, wantJob: true},
+		{name: "delayed job due", runAt: -time.Second, wantJob: true},
+		{name: "delayed job not yet due", runAt: time.Hour, wantJob: false},
+		{name: "job of another queue", other: true, wantJob: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			q := openTestPostgres(t, PostgresOptions{})
+			q.SetKeyring(tt.keyring)
+
+			job := types.NewJob("email", json.RawMessage(`{"card":"4111111111111111"}`), 3)
+			if tt.other {
+				job.Queue = "bulk"
+			}
+			var err error
+			if tt.runAt != 0 {
+				err = q.Schedule(ctx, job, time.Now().Add(tt.runAt))
+			} else {
+				err = q.Enqueue(ctx, job)
+			}
+			if err != nil {
+				t.Fatalf("store job: %v", err)
+			}
+
+			if tt.keyring != nil {
+				var stored string
+				q.DB().QueryRow(`SELECT data::text FROM gopher_jobs WHERE job_id = $1`, job.ID).Scan(&stored)
+				if bytes.Contains([]byte(stored), []byte("4111111111111111")) {
+					t.Fatal("encrypted job is stored in plaintext")
+				}
+			}
+
+			got, err := q.Dequeue(ctx)
+			if err != nil {
+				t.Fatalf("Dequeue: %v", err)
+			}
+			if !tt.wantJob {
+				if got != nil {
+					t.Fatalf("Dequeue = %s, want nothing", got.ID)
+				}
+				return
+			}
+			if got == nil || got.ID != job.ID || !jsonEqual(t, got.Payload, job.Payload) {
+				t.Fatalf("Dequeue = %+v, want job %s", got, job.ID)
+			}
+
+			stats, err := q.GetStats(ctx)
+			if err != nil {
+				t.Fatalf("GetStats: %v", err)
+			}
+			if stats.TotalEnqueued != 1 || stats.TotalDequeued != 1 || stats.QueueSize != 0 {
+				t.Fatalf("stats = %+v, want one job in and out", stats)
+			}
+		})
+	}
+}
+
+func TestPostgresQueueConcurrentClaims(t *testing.T) {
+	ctx := context.Background()
+	q := openTestPostgres(t, PostgresOptions{})
+
+	const jobs = 100
+	for i := 0; i < jobs; i++ {
+		if err := q.Enqueue(ctx, types.NewJob("email", json.RawMessage(`{}`), 0)); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+
+	var mu sync.Mutex
+	seen := make(map[string]bool, jobs)
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				job, err := q.Dequeue(ctx)
+				if err != nil {
+					t.Errorf("Dequeue: %v", err)
+					return
+				}
+				if job == nil {
+					return
+				}
+				mu.Lock()
+				if seen[job.ID] {
+					t.Errorf("job %s claimed twice", job.ID)
+				}
+				seen[job.ID] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != jobs {
+		t.Fatalf("workers claimed %d jobs, want %d", len(seen), jobs)
+	}
+}
+
+func TestPostgresDLQ(t *testing.T) {
+	ctx := context.Background()
+	q := openTestPostgres(t, PostgresOptions{})
+	dlq := NewPostgresDLQ(q.DB(), q)
+
+	failed := []struct {
+		job    *types.Job
+		reason types.FailureReason
+	}{
+		{job: types.NewJob("email", json.RawMessage(`{}`), 3), reason: types.ReasonHandlerError},
+		{job: types.NewJob("email", json.RawMessage(`{}`), 3), reason: types.ReasonTimeout},
+		{job: types.NewJob("report", json.RawMessage(`{}`), 3), reason: types.ReasonHandlerError},
+	}
+	for _, f := range failed {
+		f.job.Attempts = 3
+		if err := dlq.Send(ctx, f.job, f.reason, "boom"); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	tests := []struct {
+		reason    types.FailureReason
+		wantTotal int
+	}{
+		{reason: types.ReasonHandlerError, wantTotal: 2},
+		{reason: types.ReasonTimeout, wantTotal: 1},
+		{reason: types.ReasonPanic, wantTotal: 0},
+	}
+	for _, tt := range tests {
+		jobs, total, err := dlq.ListByReason(ctx, tt.reason, 0, 10)
+		if err != nil || total != tt.wantTotal || len(jobs) != tt.wantTotal {
+			t.Fatalf("ListByReason(%s) = %d jobs, total %d, %v; want %d", tt.reason, len(jobs), total, err, tt.wantTotal)
+		}
+	}
+
+	if err := dlq.Reprocess(ctx, failed[0].job.ID); err != nil {
+		t.Fatalf("Reprocess: %v", err)
+	}
+	if err := dlq.Reprocess(ctx, failed[0].job.ID); !errors.Is(err, ErrNotInDLQ) {
+		t.Fatalf("second Reprocess = %v, want %v", err, ErrNotInDLQ)
+	}
+
+	got, err := q.Dequeue(ctx)
+	if err != nil || got == nil || got.ID != failed[0].job.ID || got.Attempts != 0 {
+		t.Fatalf("Dequeue after Reprocess = %+v, %v; want job %s with attempts reset", got, err, failed[0].job.ID)
+	}
+
+	stats, err := dlq.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+	if stats.Size != 2 || stats.TotalSent != 3 || stats.Reprocessed != 1 {
+		t.Fatalf("stats = %+v, want 2 left of 3 sent and 1 reprocessed", stats)
+	}
+}
+
+func TestPostgresSchedules(t *testing.T) {
+	tests := []struct {
+		name         string
+		paused       bool
+		wantEnqueued int
+	}{
+		{name: "due schedule runs", paused: false, wantEnqueued: 1},
+		{name: "paused schedule skips its run", paused: true, wantEnqueued: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			q := openTestPostgres(t, PostgresOptions{})
+			schedules := q.Schedules()
+
+			job := types.NewJob("report", json.RawMessage(`{}`), 0)
+			if err := schedules.ScheduleRecurring(ctx, job, "* * * * *"); err != nil {
+				t.Fatalf("ScheduleRecurring: %v", err)
+			}
+			if _, err := schedules.SetPaused(ctx, job.ScheduleID, tt.paused); err != nil {
+				t.Fatalf("SetPaused: %v", err)
+			}
+
+			// Nothing is due until the next run comes round
+			if n, err := schedules.ProcessDueJobs(ctx); err != nil || n != 0 {
+				t.Fatalf("ProcessDueJobs before the run = %d, %v; want 0", n, err)
+			}
+			if _, err := q.DB().Exec(`UPDATE gopher_schedules SET next_run = now() - interval '1 second'`); err != nil {
+				t.Fatalf("failed to make the schedule due: %v", err)
+			}
+
+			n, err := schedules.ProcessDueJobs(ctx)
+			if err != nil || n != tt.wantEnqueued {
+				t.Fatalf("ProcessDueJobs = %d, %v; want %d", n, err, tt.wantEnqueued)
+			}
+			if again, err := schedules.ProcessDueJobs(ctx); err != nil || again != 0 {
+				t.Fatalf("second ProcessDueJobs = %d, %v; want 0", again, err)
+			}
+
+			listed, err := schedules.List(ctx)
+			if err != nil || len(listed) != 1 {
+				t.Fatalf("List = %v, %v; want the schedule", listed, err)
+			}
+			if entry := listed[0]; entry.ID != job.ScheduleID || entry.Paused != tt.paused || !entry.ExecuteAt.After(time.Now()) {
+				t.Fatalf("schedule = %+v, want %s due in the future", entry, job.ScheduleID)
+			}
+
+			runs, err := schedules.Runs(ctx, job.ScheduleID, 10)
+			if err != nil || len(runs) != tt.wantEnqueued {
+				t.Fatalf("Runs = %v, %v; want %d", runs, err, tt.wantEnqueued)
+			}
+			if tt.wantEnqueued == 0 {
+				return
+			}
+
+			got, err := q.Dequeue(ctx)
+			if err != nil || got == nil || got.ID != job.ID || got.ScheduleID != job.ScheduleID {
+				t.Fatalf("Dequeue = %+v, %v; want job %s of the schedule", got, err, job.ID)
+			}
+			if listed[0].Job.ID == job.ID {
+				t.Fatal("the next run reuses the job ID of the last one")
+			}
+
+			result := &types.JobResult{JobID: got.ID, Status: types.StatusFailed, Error: "boom"}
+			if err := schedules.RecordOutcome(ctx, got, result); err != nil {
+				t.Fatalf("RecordOutcome: %v", err)
+			}
+			runs, err = schedules.Runs(ctx, job.ScheduleID, 10)
+			if err != nil || len(runs) != 1 || runs[0].JobID != job.ID || runs[0].Status != types.StatusFailed || runs[0].FinishedAt == nil {
+				t.Fatalf("Runs after the outcome = %+v, %v; want the failed run", runs, err)
+			}
+		})
+	}
+
+	q := openTestPostgres(t, PostgresOptions{})
+	if _, err := q.Schedules().SetPaused(context.Background(), "sched-missing", true); err != ErrScheduleNotFound {
+		t.Fatalf("SetPaused of an unknown schedule = %v, want %v", err, ErrScheduleNotFound)
+	}
+}
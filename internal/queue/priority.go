@@ -2,10 +2,13 @@ package queue
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
 	"github.com/aneeshsunganahalli/Gopher/pkg/types"
 	"github.com/go-redis/redis/v8"
 )
@@ -22,59 +25,233 @@ const (
 	highPriorityQueueKey   = "queue:high"
 	normalPriorityQueueKey = "queue:normal"
 	lowPriorityQueueKey    = "queue:low"
+
+	priorityRatioKey    = "priority:ratio"    // Redis hash holding the runtime processing ratio
+	priorityCountersKey = "priority_counters" // Redis hash counting dequeues per priority
+
+	priorityRatioRefreshInterval = 5 * time.Second
 )
 
-// PriorityQueue implements Queue interface with priority levels
-type PriorityQueue struct {
-	client        redis.Cmdable
-	opts          RedisOptions
-	priorityRatio map[string]int // Processing ratio for different priority levels
+// PriorityRatio is the relative share of dequeues given to each priority level
+type PriorityRatio struct {
+	High   int `json:"high"`
+	Normal int `json:"normal"`
+	Low    int `json:"low"`
 }
 
-// NewPriorityQueue creates a new priority queue
-func NewPriorityQueue(opts RedisOptions) (*PriorityQueue, error) {
-	// Parse URL to create new client
-	redisOpts, err := redis.ParseURL(opts.URL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+// DefaultPriorityRatio processes 5 high, 3 normal and 1 low priority job per round
+var DefaultPriorityRatio = PriorityRatio{High: 5, Normal: 3, Low: 1}
+
+// ParsePriorityRatio parses a "high:normal:low" ratio such as "5:3:1"
+func ParsePriorityRatio(value string) (PriorityRatio, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return PriorityRatio{}, fmt.Errorf("priority ratio must be high:normal:low, got %q", value)
+	}
+
+	weights := make([]int, len(parts))
+	for i, part := range parts {
+		weight, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return PriorityRatio{}, fmt.Errorf("invalid priority ratio weight %q: %w", part, err)
+		}
+		weights[i] = weight
 	}
 
-	redisOpts.Password = opts.Password
-	redisOpts.DB = opts.DB
-	redisOpts.DialTimeout = opts.ConnectTimeout
-	redisOpts.ReadTimeout = opts.CommandTimeout
-	redisOpts.WriteTimeout = opts.CommandTimeout
+	ratio := PriorityRatio{High: weights[0], Normal: weights[1], Low: weights[2]}
+	return ratio, ratio.Validate()
+}
 
-	client := redis.NewClient(redisOpts)
+// Validate checks that no weight is negative and at least one is positive
+func (r PriorityRatio) Validate() error {
+	if r.High < 0 || r.Normal < 0 || r.Low < 0 {
+		return fmt.Errorf("priority ratio weights must not be negative, got %s", r)
+	}
+	if r.High+r.Normal+r.Low == 0 {
+		return fmt.Errorf("priority ratio needs at least one positive weight")
+	}
+	return nil
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), opts.ConnectTimeout)
-	defer cancel()
+// String formats the ratio as high:normal:low
+func (r PriorityRatio) String() string {
+	return fmt.Sprintf("%d:%d:%d", r.High, r.Normal, r.Low)
+}
 
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+func (r PriorityRatio) weight(priority string) int {
+	switch priority {
+	case PriorityHigh:
+		return r.High
+	case PriorityLow:
+		return r.Low
+	default:
+		return r.Normal
 	}
+}
+
+// PriorityMix reports how many jobs were dequeued per priority against the configured ratio
+type PriorityMix struct {
+	Ratio    PriorityRatio      `json:"ratio"`
+	Dequeued map[string]int     `json:"dequeued"`
+	Achieved map[string]float64 `json:"achieved"` // Fraction of dequeues per priority
+	Target   map[string]float64 `json:"target"`   // Fraction of dequeues the ratio asks for
+}
+
+// PriorityQueue implements Queue interface with priority levels
+type PriorityQueue struct {
+	client  redis.Cmdable
+	layout  *Layout
+	opts    RedisOptions
+	keyring *encryption.Keyring // Optional payload encryption
+	pauses  pauseCache
+
+	statuses *StatusStore // Records the pending state of enqueued jobs, nil when tracking is off
+
+	mu            sync.RWMutex
+	priorityRatio PriorityRatio // Processing ratio for different priority levels
+	refreshedAt   time.Time
+	onRatioChange func(PriorityRatio)
+}
 
-	// Default processing ratio: process 5 high, 3 normal, 1 low priority jobs
-	priorityRatio := map[string]int{
-		PriorityHigh:   5,
-		PriorityNormal: 3,
-		PriorityLow:    1,
+// NewPriorityQueue creates a new priority queue
+func NewPriorityQueue(opts RedisOptions) (*PriorityQueue, error) {
+	client, err := newRedisClient(opts)
+	if err != nil {
+		return nil, err
 	}
 
 	return &PriorityQueue{
 		client:        client,
+		layout:        newLayout(opts),
 		opts:          opts,
-		priorityRatio: priorityRatio,
+		priorityRatio: DefaultPriorityRatio,
 	}, nil
 }
 
 // SetPriorityRatio configures the ratio for processing jobs of different priorities
-func (p *PriorityQueue) SetPriorityRatio(high, normal, low int) {
-	p.priorityRatio = map[string]int{
-		PriorityHigh:   high,
-		PriorityNormal: normal,
-		PriorityLow:    low,
+func (p *PriorityQueue) SetPriorityRatio(high, normal, low int) error {
+	ratio := PriorityRatio{High: high, Normal: normal, Low: low}
+	if err := ratio.Validate(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	changed := ratio != p.priorityRatio
+	p.priorityRatio = ratio
+	onChange := p.onRatioChange
+	p.mu.Unlock()
+
+	if changed && onChange != nil {
+		onChange(ratio)
+	}
+	return nil
+}
+
+// PriorityRatio returns the ratio currently used for dequeuing
+func (p *PriorityQueue) PriorityRatio() PriorityRatio {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.priorityRatio
+}
+
+// OnRatioChange registers a callback invoked whenever the ratio changes,
+// including when a runtime override is picked up from Redis
+func (p *PriorityQueue) OnRatioChange(fn func(PriorityRatio)) {
+	p.mu.Lock()
+	p.onRatioChange = fn
+	p.mu.Unlock()
+}
+
+// SavePriorityRatio stores a runtime ratio that every priority queue picks up
+// within a few seconds, and applies it to this queue immediately
+func (p *PriorityQueue) SavePriorityRatio(ctx context.Context, ratio PriorityRatio) error {
+	if err := ratio.Validate(); err != nil {
+		return err
+	}
+
+	err := p.client.HSet(ctx, p.layout.key(priorityRatioKey),
+		PriorityHigh, ratio.High,
+		PriorityNormal, ratio.Normal,
+		PriorityLow, ratio.Low,
+	).Err()
+	if err != nil {
+		return fmt.Errorf("failed to save priority ratio: %w", err)
+	}
+
+	return p.SetPriorityRatio(ratio.High, ratio.Normal, ratio.Low)
+}
+
+// refreshRatio periodically loads the runtime ratio override from Redis
+func (p *PriorityQueue) refreshRatio(ctx context.Context) {
+	p.mu.Lock()
+	if time.Since(p.refreshedAt) < priorityRatioRefreshInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.refreshedAt = time.Now()
+	p.mu.Unlock()
+
+	values, err := p.client.HGetAll(ctx, p.layout.key(priorityRatioKey)).Result()
+	if err != nil || len(values) == 0 {
+		return
+	}
+
+	weights := make(map[string]int, len(values))
+	for priority, value := range values {
+		if weight, err := strconv.Atoi(value); err == nil {
+			weights[priority] = weight
+		}
 	}
+
+	// Invalid overrides are ignored, keeping the current ratio
+	p.SetPriorityRatio(weights[PriorityHigh], weights[PriorityNormal], weights[PriorityLow])
+}
+
+// Mix returns the dequeue counts per priority along with achieved and target shares
+func (p *PriorityQueue) Mix(ctx context.Context) (*PriorityMix, error) {
+	counters, err := p.getPriorityCounters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ratio := p.PriorityRatio()
+	mix := &PriorityMix{
+		Ratio:    ratio,
+		Dequeued: counters,
+		Achieved: make(map[string]float64, len(counters)),
+		Target:   make(map[string]float64, len(counters)),
+	}
+
+	total := 0
+	for _, count := range counters {
+		total += count
+	}
+	weights := ratio.High + ratio.Normal + ratio.Low
+
+	for _, priority := range []string{PriorityHigh, PriorityNormal, PriorityLow} {
+		if total > 0 {
+			mix.Achieved[priority] = float64(counters[priority]) / float64(total)
+		}
+		mix.Target[priority] = float64(ratio.weight(priority)) / float64(weights)
+	}
+
+	return mix, nil
+}
+
+// SetKeyring enables payload encryption with the given keyring
+func (p *PriorityQueue) SetKeyring(keyring *encryption.Keyring) {
+	p.keyring = keyring
+}
+
+// Client returns the underlying Redis client
+func (p *PriorityQueue) Client() redis.Cmdable {
+	return p.client
+}
+
+// Layout returns the key prefix, job format and signing the queue uses,
+// for the stores sharing its Redis
+func (p *PriorityQueue) Layout() *Layout {
+	return p.layout
 }
 
 // Enqueue adds a job to the queue with the specified priority
@@ -95,29 +272,43 @@ func (p *PriorityQueue) Enqueue(ctx context.Context, job *types.Job) error {
 		}
 	}
 
-	// Serialize job to JSON
-	jobData, err := json.Marshal(job)
+	job.EnqueuedAt = time.Now().UTC()
+
+	sealed, err := p.layout.sealJob(job, p.keyring)
 	if err != nil {
-		return fmt.Errorf("failed to marshal job: %w", err)
+		return err
+	}
+
+	jobData, err := p.layout.encodeJob(sealed)
+	if err != nil {
+		return err
 	}
 
 	// Select queue key based on priority
-	queueKey := normalPriorityQueueKey
+	queueKey := p.layout.key(normalPriorityQueueKey)
 	switch priority {
 	case PriorityHigh:
-		queueKey = highPriorityQueueKey
+		queueKey = p.layout.key(highPriorityQueueKey)
 	case PriorityLow:
-		queueKey = lowPriorityQueueKey
+		queueKey = p.layout.key(lowPriorityQueueKey)
 	}
 
 	pipe := p.client.Pipeline()
 
-	// Add job to the appropriate priority queue
-	pipe.LPush(ctx, queueKey, jobData)
+	// Jobs naming a queue skip the priority queues
+	if job.Queue != "" {
+		pipe.LPush(ctx, p.layout.QueueKey(job.Queue), jobData)
+		pipe.HIncrBy(ctx, p.layout.key(statsKey), queueEnqueuedField+pauseName(job.Queue), 1)
+	} else {
+		pipe.LPush(ctx, queueKey, jobData)
+		pipe.HIncrBy(ctx, p.layout.key(statsKey), fmt.Sprintf("enqueued:%s", priority), 1)
+	}
 
 	// Update stats
-	pipe.HIncrBy(ctx, statsKey, "total_enqueued", 1)
-	pipe.HIncrBy(ctx, statsKey, fmt.Sprintf("enqueued:%s", priority), 1)
+	pipe.HIncrBy(ctx, p.layout.key(statsKey), "total_enqueued", 1)
+	if p.statuses != nil {
+		p.statuses.queue(ctx, pipe, job, types.StatusPending, "")
+	}
 
 	// Execute pipeline
 	_, err = pipe.Exec(ctx)
@@ -128,97 +319,126 @@ func (p *PriorityQueue) Enqueue(ctx context.Context, job *types.Job) error {
 	return nil
 }
 
+// priorityDequeueScript picks the non-empty priority queue furthest below
+// its share of the ratio, pops its oldest job and counts the dequeue, all in
+// one step so concurrent workers never act on stale counters.
+// KEYS: high, normal and low queues, counters hash, stats hash
+// ARGV: high, normal and low ratio weights
+var priorityDequeueScript = redis.NewScript(`
+local priorities = {'high', 'normal', 'low'}
+local counts = redis.call('HMGET', KEYS[4], 'high', 'normal', 'low')
+
+local best, bestScore
+for i = 1, 3 do
+	if redis.call('LLEN', KEYS[i]) > 0 then
+		local score = tonumber(ARGV[i]) / ((tonumber(counts[i]) or 0) + 1)
+		if best == nil or score > bestScore then
+			best, bestScore = i, score
+		end
+	end
+end
+if best == nil then
+	return false
+end
+
+local priority = priorities[best]
+local job = redis.call('RPOP', KEYS[best])
+redis.call('HINCRBY', KEYS[5], 'total_dequeued', 1)
+redis.call('HINCRBY', KEYS[5], 'dequeued:' .. priority, 1)
+redis.call('HINCRBY', KEYS[4], priority, 1)
+return {priority, job}
+`)
+
+// priorityKeys lists the queue keys in descending priority
+func (l *Layout) priorityKeys() []string {
+	return []string{l.key(highPriorityQueueKey), l.key(normalPriorityQueueKey), l.key(lowPriorityQueueKey)}
+}
+
 // Dequeue removes and returns a job from the queue, respecting priority ratios
 func (p *PriorityQueue) Dequeue(ctx context.Context) (*types.Job, error) {
-	// Get current counts to determine which queue to pull from
-	counters, err := p.getPriorityCounters(ctx)
+	if p.pauses.isPaused(ctx, p.client, p.layout, PriorityQueueName) {
+		return nil, nil
+	}
+
+	p.refreshRatio(ctx)
+
+	jobData, err := p.popByRatio(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// Determine which queue to pull from based on ratio
-	queueKey := p.selectQueueByRatio(counters)
+	// All queues were empty: block until a job arrives in any of them. Only
+	// one queue can have a job then, so there's no ratio to apply.
+	if jobData == "" {
+		if jobData, err = p.waitForJob(ctx); err != nil || jobData == "" {
+			return nil, err
+		}
+	}
+
+	// Deserialize job
+	var job types.Job
+	if err := decodeJob([]byte(jobData), &job); err != nil {
+		return nil, err
+	}
 
-	// Try to get a job from the selected queue
-	result := p.client.BRPop(ctx, time.Second, queueKey)
-	if err := result.Err(); err != nil {
-		if err == redis.Nil {
-			// No job available, try other queues in priority order
-			for _, key := range []string{highPriorityQueueKey, normalPriorityQueueKey, lowPriorityQueueKey} {
-				if key == queueKey {
-					continue // Already tried this one
-				}
+	if err := openJob(&job, p.keyring); err != nil {
+		return nil, fmt.Errorf("failed to decrypt job: %w", err)
+	}
 
-				result = p.client.BRPop(ctx, 0, key)
-				if err := result.Err(); err != nil {
-					if err == redis.Nil {
-						continue
-					}
-					return nil, fmt.Errorf("failed to dequeue job: %w", err)
-				}
+	return &job, nil
+}
 
-				// Found a job, break out of loop
-				break
-			}
+// popByRatio pops a job with priorityDequeueScript, returning an empty job
+// when every queue is empty
+func (p *PriorityQueue) popByRatio(ctx context.Context) (string, error) {
+	ratio := p.PriorityRatio()
+	keys := append(p.layout.priorityKeys(), p.layout.key(priorityCountersKey), p.layout.key(statsKey))
 
-			// If still no job after trying all queues
-			if result.Err() == redis.Nil {
-				return nil, nil
-			}
-		} else {
-			return nil, fmt.Errorf("failed to dequeue job: %w", err)
+	popped, err := priorityDequeueScript.Run(ctx, p.client, keys, ratio.High, ratio.Normal, ratio.Low).StringSlice()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to dequeue job: %w", err)
+	}
+	if len(popped) != 2 {
+		return "", fmt.Errorf("unexpected dequeue result: %v", popped)
+	}
+	return popped[1], nil
+}
+
+// waitForJob long-polls every priority queue for up to the poll timeout and
+// counts the dequeue in one transaction. The job is already popped, so a
+// failed stats update doesn't fail the dequeue.
+func (p *PriorityQueue) waitForJob(ctx context.Context) (string, error) {
+	result := p.client.BRPop(ctx, p.opts.pollTimeout(), p.layout.priorityKeys()...)
+	if err := result.Err(); err != nil {
+		if err == redis.Nil {
+			return "", nil
 		}
+		return "", fmt.Errorf("failed to dequeue job: %w", err)
 	}
 
 	values := result.Val()
 	if len(values) != 2 {
-		return nil, fmt.Errorf("unexpected BRPOP result: %v", values)
+		return "", fmt.Errorf("unexpected BRPOP result: %v", values)
 	}
 
-	jobData := values[1]
-
-	// Update dequeue stats
-	priority := "normal"
+	priority := PriorityNormal
 	switch values[0] {
-	case highPriorityQueueKey:
+	case p.layout.key(highPriorityQueueKey):
 		priority = PriorityHigh
-	case lowPriorityQueueKey:
+	case p.layout.key(lowPriorityQueueKey):
 		priority = PriorityLow
 	}
 
-	pipe := p.client.Pipeline()
-	pipe.HIncrBy(ctx, statsKey, "total_dequeued", 1)
-	pipe.HIncrBy(ctx, statsKey, fmt.Sprintf("dequeued:%s", priority), 1)
-	pipe.HIncrBy(ctx, "priority_counters", priority, 1)
-	_, err = pipe.Exec(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update dequeue stats: %w", err)
-	}
-
-	// Deserialize job
-	var job types.Job
-	if err := json.Unmarshal([]byte(jobData), &job); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
-	}
+	pipe := p.client.TxPipeline()
+	pipe.HIncrBy(ctx, p.layout.key(statsKey), "total_dequeued", 1)
+	pipe.HIncrBy(ctx, p.layout.key(statsKey), fmt.Sprintf("dequeued:%s", priority), 1)
+	pipe.HIncrBy(ctx, p.layout.key(priorityCountersKey), priority, 1)
+	pipe.Exec(ctx)
 
-	return &job, nil
-}
-
-// selectQueueByRatio determines which queue to pull from based on the priority ratio
-func (p *PriorityQueue) selectQueueByRatio(counters map[string]int) string {
-	// Calculate ratio of high:normal:low based on configured values and current counts
-	highRatio := float64(p.priorityRatio[PriorityHigh]) / float64(counters[PriorityHigh]+1)
-	normalRatio := float64(p.priorityRatio[PriorityNormal]) / float64(counters[PriorityNormal]+1)
-	lowRatio := float64(p.priorityRatio[PriorityLow]) / float64(counters[PriorityLow]+1)
-
-	// Select queue with highest ratio
-	if highRatio >= normalRatio && highRatio >= lowRatio {
-		return highPriorityQueueKey
-	} else if normalRatio >= highRatio && normalRatio >= lowRatio {
-		return normalPriorityQueueKey
-	} else {
-		return lowPriorityQueueKey
-	}
+	return values[1], nil
 }
 
 // getPriorityCounters gets the current dequeue counters for each priority
@@ -230,7 +450,7 @@ func (p *PriorityQueue) getPriorityCounters(ctx context.Context) (map[string]int
 	}
 
 	// Get current counters
-	result := p.client.HGetAll(ctx, "priority_counters")
+	result := p.client.HGetAll(ctx, p.layout.key(priorityCountersKey))
 	if err := result.Err(); err != nil && err != redis.Nil {
 		return counters, fmt.Errorf("failed to get priority counters: %w", err)
 	}
@@ -250,9 +470,9 @@ func (p *PriorityQueue) getPriorityCounters(ctx context.Context) (map[string]int
 func (p *PriorityQueue) Size(ctx context.Context) (int, error) {
 	pipe := p.client.Pipeline()
 
-	highCmd := pipe.LLen(ctx, highPriorityQueueKey)
-	normalCmd := pipe.LLen(ctx, normalPriorityQueueKey)
-	lowCmd := pipe.LLen(ctx, lowPriorityQueueKey)
+	highCmd := pipe.LLen(ctx, p.layout.key(highPriorityQueueKey))
+	normalCmd := pipe.LLen(ctx, p.layout.key(normalPriorityQueueKey))
+	lowCmd := pipe.LLen(ctx, p.layout.key(lowPriorityQueueKey))
 
 	_, err := pipe.Exec(ctx)
 	if err != nil {
@@ -267,9 +487,9 @@ func (p *PriorityQueue) Size(ctx context.Context) (int, error) {
 func (p *PriorityQueue) SizeByPriority(ctx context.Context) (map[string]int, error) {
 	pipe := p.client.Pipeline()
 
-	highCmd := pipe.LLen(ctx, highPriorityQueueKey)
-	normalCmd := pipe.LLen(ctx, normalPriorityQueueKey)
-	lowCmd := pipe.LLen(ctx, lowPriorityQueueKey)
+	highCmd := pipe.LLen(ctx, p.layout.key(highPriorityQueueKey))
+	normalCmd := pipe.LLen(ctx, p.layout.key(normalPriorityQueueKey))
+	lowCmd := pipe.LLen(ctx, p.layout.key(lowPriorityQueueKey))
 
 	_, err := pipe.Exec(ctx)
 	if err != nil {
@@ -283,6 +503,57 @@ func (p *PriorityQueue) SizeByPriority(ctx context.Context) (map[string]int, err
 	}, nil
 }
 
+// PriorityStats is the depth and throughput of one priority level. Enqueued
+// growing faster than Dequeued while Size climbs means the level is starved.
+type PriorityStats struct {
+	Size     int `json:"size"`
+	Enqueued int `json:"enqueued"`
+	Dequeued int `json:"dequeued"`
+}
+
+// StatsByPriority returns the depth and enqueue/dequeue counters of each priority queue
+func (p *PriorityQueue) StatsByPriority(ctx context.Context) (map[string]PriorityStats, error) {
+	stats, err := p.GetStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return stats.ByPriority, nil
+}
+
+// GetStats returns totals across the priority queues with a per-priority breakdown
+func (p *PriorityQueue) GetStats(ctx context.Context) (*QueueStats, error) {
+	pipe := p.client.Pipeline()
+
+	sizeCmds := map[string]*redis.IntCmd{
+		PriorityHigh:   pipe.LLen(ctx, p.layout.key(highPriorityQueueKey)),
+		PriorityNormal: pipe.LLen(ctx, p.layout.key(normalPriorityQueueKey)),
+		PriorityLow:    pipe.LLen(ctx, p.layout.key(lowPriorityQueueKey)),
+	}
+	statsCmd := pipe.HGetAll(ctx, p.layout.key(statsKey))
+	retryingCmd := pipe.HLen(ctx, p.layout.key(pendingRetriesKey))
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+
+	counters := statsCmd.Val()
+	stats := &QueueStats{
+		Retrying:   int(retryingCmd.Val()),
+		ByPriority: make(map[string]PriorityStats, len(sizeCmds)),
+	}
+	fmt.Sscanf(counters["total_enqueued"], "%d", &stats.TotalEnqueued)
+	fmt.Sscanf(counters["total_dequeued"], "%d", &stats.TotalDequeued)
+
+	for priority, sizeCmd := range sizeCmds {
+		level := PriorityStats{Size: int(sizeCmd.Val())}
+		fmt.Sscanf(counters["enqueued:"+priority], "%d", &level.Enqueued)
+		fmt.Sscanf(counters["dequeued:"+priority], "%d", &level.Dequeued)
+		stats.ByPriority[priority] = level
+		stats.QueueSize += level.Size
+	}
+	return stats, nil
+}
+
 // Health checks if the queue is healthy/reachable
 func (p *PriorityQueue) Health(ctx context.Context) error {
 	return p.client.Ping(ctx).Err()
@@ -290,7 +561,7 @@ func (p *PriorityQueue) Health(ctx context.Context) error {
 
 // Close closes the queue connection
 func (p *PriorityQueue) Close() error {
-	if client, ok := p.client.(*redis.Client); ok {
+	if client, ok := p.client.(redis.UniversalClient); ok {
 		return client.Close()
 	}
 	return nil
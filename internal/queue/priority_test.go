@@ -0,0 +1,177 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+func TestParsePriorityRatio(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    PriorityRatio
+		wantErr string
+	}{
+		{value: "5:3:1", want: PriorityRatio{High: 5, Normal: 3, Low: 1}},
+		{value: " 1 : 0 : 0 ", want: PriorityRatio{High: 1}},
+		{value: "5:3", wantErr: "high:normal:low"},
+		{value: "5:x:1", wantErr: "invalid priority ratio weight"},
+		{value: "5:-1:1", wantErr: "must not be negative"},
+		{value: "0:0:0", wantErr: "at least one positive weight"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParsePriorityRatio(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParsePriorityRatio error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("ParsePriorityRatio = %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriorityQueueRatio(t *testing.T) {
+	tests := []struct {
+		name    string
+		ratio   PriorityRatio
+		queued  map[string]int // Jobs enqueued per priority
+		dequeue int
+		want    map[string]int // Jobs dequeued per priority
+	}{
+		{
+			name:    "default ratio",
+			ratio:   DefaultPriorityRatio,
+			queued:  map[string]int{PriorityHigh: 10, PriorityNormal: 10, PriorityLow: 10},
+			dequeue: 9,
+			want:    map[string]int{PriorityHigh: 5, PriorityNormal: 3, PriorityLow: 1},
+		},
+		{
+			name:    "equal shares",
+			ratio:   PriorityRatio{High: 1, Normal: 1, Low: 1},
+			queued:  map[string]int{PriorityHigh: 10, PriorityNormal: 10, PriorityLow: 10},
+			dequeue: 6,
+			want:    map[string]int{PriorityHigh: 2, PriorityNormal: 2, PriorityLow: 2},
+		},
+		{
+			name:    "empty level's share goes to the others",
+			ratio:   DefaultPriorityRatio,
+			queued:  map[string]int{PriorityNormal: 10, PriorityLow: 10},
+			dequeue: 8,
+			want:    map[string]int{PriorityNormal: 6, PriorityLow: 2},
+		},
+		{
+			name:    "zero weight only runs when nothing else is queued",
+			ratio:   PriorityRatio{High: 1},
+			queued:  map[string]int{PriorityHigh: 2, PriorityLow: 2},
+			dequeue: 4,
+			want:    map[string]int{PriorityHigh: 2, PriorityLow: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server := miniredis.RunT(t)
+			q, err := NewPriorityQueue(RedisOptions{URL: "redis://" + server.Addr(), ConnectTimeout: time.Second})
+			if err != nil {
+				t.Fatalf("NewPriorityQueue: %v", err)
+			}
+			defer q.Close()
+			if err := q.SetPriorityRatio(tt.ratio.High, tt.ratio.Normal, tt.ratio.Low); err != nil {
+				t.Fatalf("SetPriorityRatio: %v", err)
+			}
+
+			for priority, n := range tt.queued {
+				for i := 0; i < n; i++ {
+					job := types.NewJob("email", json.RawMessage(`{}`), 0)
+					job.Metadata = types.JobMetadata{"priority": priority}
+					if err := q.Enqueue(ctx, job); err != nil {
+						t.Fatalf("Enqueue: %v", err)
+					}
+				}
+			}
+
+			got := make(map[string]int)
+			for i := 0; i < tt.dequeue; i++ {
+				job, err := q.Dequeue(ctx)
+				if err != nil || job == nil {
+					t.Fatalf("Dequeue = %v, %v; want a job", job, err)
+				}
+				got[job.Metadata["priority"].(string)]++
+			}
+
+			for _, priority := range []string{PriorityHigh, PriorityNormal, PriorityLow} {
+				if got[priority] != tt.want[priority] {
+					t.Fatalf("dequeued %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPriorityQueueWaitForJob(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority string // Priority of the job enqueued while Dequeue waits, empty for none
+	}{
+		{name: "high job arrives", priority: PriorityHigh},
+		{name: "normal job arrives", priority: PriorityNormal},
+		{name: "low job arrives", priority: PriorityLow},
+		{name: "nothing arrives", priority: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server := miniredis.RunT(t)
+			q, err := NewPriorityQueue(RedisOptions{URL: "redis://" + server.Addr(), ConnectTimeout: time.Second, PollTimeout: time.Second})
+			if err != nil {
+				t.Fatalf("NewPriorityQueue: %v", err)
+			}
+			defer q.Close()
+
+			job := types.NewJob("email", json.RawMessage(`{}`), 0)
+			job.Metadata = types.JobMetadata{"priority": tt.priority}
+			if tt.priority != "" {
+				go func() {
+					time.Sleep(100 * time.Millisecond)
+					q.Enqueue(ctx, job)
+				}()
+			}
+
+			got, err := q.Dequeue(ctx)
+			if err != nil {
+				t.Fatalf("Dequeue: %v", err)
+			}
+			if tt.priority == "" {
+				if got != nil {
+					t.Fatalf("Dequeue = %s, want nothing", got.ID)
+				}
+				return
+			}
+			if got == nil || got.ID != job.ID {
+				t.Fatalf("Dequeue = %v, want job %s", got, job.ID)
+			}
+
+			// The wait counts the dequeue like the ratio path does
+			stats, err := q.StatsByPriority(ctx)
+			if err != nil {
+				t.Fatalf("StatsByPriority: %v", err)
+			}
+			if level := stats[tt.priority]; level.Enqueued != 1 || level.Dequeued != 1 || level.Size != 0 {
+				t.Fatalf("%s stats = %+v, want one job in and out", tt.priority, level)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+)
+
+// Purger is implemented by queues that can drop every job they hold
+type Purger interface {
+	// Purge deletes all pending jobs and returns how many were removed
+	Purge(ctx context.Context) (int, error)
+}
+
+// purgeLists deletes Redis lists in one transaction and returns how many
+// entries they held
+func purgeLists(ctx context.Context, client redis.Cmdable, keys ...string) (int, error) {
+	pipe := client.TxPipeline()
+	lengths := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		lengths[i] = pipe.LLen(ctx, key)
+	}
+	pipe.Del(ctx, keys...)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to purge queue: %w", err)
+	}
+
+	purged := 0
+	for _, length := range lengths {
+		purged += int(length.Val())
+	}
+	return purged, nil
+}
+
+// Purge deletes every job waiting in this queue's own physical queue. Jobs
+// already dequeued into processing lists are left to their workers.
+func (r *RedisQueue) Purge(ctx context.Context) (int, error) {
+	return purgeLists(ctx, r.client, r.key)
+}
+
+// Purge deletes every job waiting in the high, normal and low priority queues
+func (p *PriorityQueue) Purge(ctx context.Context) (int, error) {
+	return purgeLists(ctx, p.client, p.layout.priorityKeys()...)
+}
+
+// Purge deletes every job in the dead letter queue
+func (d *RedisDLQ) Purge(ctx context.Context) (int, error) {
+	return purgeLists(ctx, d.client, d.layout.key(deadLetterQueueKey))
+}
+
+// PurgeQueue deletes every job waiting in the named physical queue
+func (a *Admin) PurgeQueue(ctx context.Context, queueName string) (int, error) {
+	return purgeLists(ctx, a.client, a.layout.QueueKey(queueName))
+}
+
+// Purge deletes every delayed job waiting to become due. Recurring schedules
+// are kept; remove those individually.
+func (s *ScheduledQueue) Purge(ctx context.Context) (int, error) {
+	members, err := s.client.ZRange(ctx, s.layout.key(scheduledJobsKey), 0, -1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list scheduled jobs: %w", err)
+	}
+
+	var delayed []interface{}
+	for _, member := range members {
+		var scheduled types.ScheduledJob
+		if err := json.Unmarshal([]byte(member), &scheduled); err == nil && scheduled.Recurring {
+			continue
+		}
+		delayed = append(delayed, member)
+	}
+	if len(delayed) == 0 {
+		return 0, nil
+	}
+
+	purged, err := s.client.ZRem(ctx, s.layout.key(scheduledJobsKey), delayed...).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge scheduled jobs: %w", err)
+	}
+	return int(purged), nil
+}
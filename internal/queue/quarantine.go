@@ -0,0 +1,201 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+)
+
+const quarantineKey = "quarantine:jobs" // Redis hash of job ID → JSON quarantined job
+
+// QuarantineApprovedKey is the metadata key set on jobs approved out of
+// quarantine, so the dequeue checks that can be overruled let them run
+const QuarantineApprovedKey = "quarantine_approved"
+
+// ErrNotQuarantined is returned for job IDs with no quarantined job
+var ErrNotQuarantined = errors.New("job not in quarantine")
+
+// Quarantine holds suspicious jobs for manual review instead of running or
+// dead-lettering them. An approved job goes back to its queue; a rejected
+// one is dropped.
+type Quarantine struct {
+	client   redis.Cmdable
+	layout   *Layout
+	queue    Queue // Where approved jobs are enqueued
+	keyring  *encryption.Keyring
+	statuses *StatusStore
+}
+
+// NewQuarantine creates a Redis-backed quarantine that releases approved
+// jobs into q
+func NewQuarantine(client redis.Cmdable, layout *Layout, q Queue) *Quarantine {
+	return &Quarantine{client: client, layout: layout, queue: q}
+}
+
+// SetKeyring enables payload encryption for quarantined jobs
+func (q *Quarantine) SetKeyring(keyring *encryption.Keyring) {
+	q.keyring = keyring
+}
+
+// SetStatusStore records quarantined and rejected jobs in the job status API
+func (q *Quarantine) SetStatusStore(statuses *StatusStore) {
+	q.statuses = statuses
+}
+
+// Add holds a job for review, replacing any earlier entry for the same ID
+func (q *Quarantine) Add(ctx context.Context, job *types.Job, reason types.FailureReason, errorMsg string) error {
+	sealed, err := q.layout.sealJob(job, q.keyring)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(&types.QuarantinedJob{
+		Job:           sealed,
+		Reason:        reason,
+		Error:         errorMsg,
+		QuarantinedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal quarantined job: %w", err)
+	}
+
+	pipe := q.client.Pipeline()
+	pipe.HSet(ctx, q.layout.key(quarantineKey), job.ID, data)
+	// Untrusted jobs may reuse a real job's ID, so they leave its status alone
+	if q.statuses != nil && reason != types.ReasonUntrusted {
+		q.statuses.queue(ctx, pipe, job, types.StatusQuarantined, errorMsg)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to quarantine job: %w", err)
+	}
+	return nil
+}
+
+// Get returns a quarantined job
+func (q *Quarantine) Get(ctx context.Context, jobID string) (*types.QuarantinedJob, error) {
+	data, err := q.client.HGet(ctx, q.layout.key(quarantineKey), jobID).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotQuarantined
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quarantined job: %w", err)
+	}
+	return q.decode(data)
+}
+
+// Size returns the number of quarantined jobs
+func (q *Quarantine) Size(ctx context.Context) (int, error) {
+	size, err := q.client.HLen(ctx, q.layout.key(quarantineKey)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get quarantine size: %w", err)
+	}
+	return int(size), nil
+}
+
+// List returns quarantined jobs, newest first, with the total held
+func (q *Quarantine) List(ctx context.Context, offset, limit int) ([]*types.QuarantinedJob, int, error) {
+	stored, err := q.client.HGetAll(ctx, q.layout.key(quarantineKey)).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list quarantined jobs: %w", err)
+	}
+
+	entries := make([]*types.QuarantinedJob, 0, len(stored))
+	for _, data := range stored {
+		entry, err := q.decode([]byte(data))
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].QuarantinedAt.After(entries[j].QuarantinedAt)
+	})
+
+	total := len(entries)
+	if offset >= total {
+		return []*types.QuarantinedJob{}, total, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return entries[offset:end], total, nil
+}
+
+// Approve releases a quarantined job back to its queue with its attempts
+// reset. The job is marked approved, and is signed again when this process
+// holds a signing key, so the check that quarantined it lets it through.
+func (q *Quarantine) Approve(ctx context.Context, jobID string) (*types.QuarantinedJob, error) {
+	entry, err := q.remove(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	released := *entry.Job
+	released.Metadata = released.Metadata.Clone()
+	released.AddMetadata(QuarantineApprovedKey, true)
+	released.Attempts = 0
+	released.UpdatedAt = time.Now().UTC()
+
+	if err := q.queue.Enqueue(ctx, &released); err != nil {
+		// Put the entry back so the job isn't lost
+		if restoreErr := q.Add(ctx, entry.Job, entry.Reason, entry.Error); restoreErr != nil {
+			return nil, fmt.Errorf("failed to release job: %v; failed to restore it: %w", err, restoreErr)
+		}
+		return nil, fmt.Errorf("failed to release job: %w", err)
+	}
+	entry.Job = &released
+	return entry, nil
+}
+
+// Reject drops a quarantined job. Jobs other than untrusted ones are
+// recorded as failed; the caller settles their dependents.
+func (q *Quarantine) Reject(ctx context.Context, jobID string) (*types.QuarantinedJob, error) {
+	entry, err := q.remove(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.statuses != nil && entry.Reason != types.ReasonUntrusted {
+		if err := q.statuses.Set(ctx, entry.Job, types.StatusFailed, entry.Error); err != nil {
+			return entry, err
+		}
+	}
+	return entry, nil
+}
+
+// remove takes a job out of the quarantine, opening its payload. Of two
+// concurrent reviews of the same job only one gets it.
+func (q *Quarantine) remove(ctx context.Context, jobID string) (*types.QuarantinedJob, error) {
+	entry, err := q.Get(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := q.client.HDel(ctx, q.layout.key(quarantineKey), jobID).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove quarantined job: %w", err)
+	}
+	if removed == 0 {
+		return nil, ErrNotQuarantined
+	}
+	return entry, nil
+}
+
+func (q *Quarantine) decode(data []byte) (*types.QuarantinedJob, error) {
+	var entry types.QuarantinedJob
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Job == nil {
+		return nil, fmt.Errorf("invalid quarantine entry")
+	}
+	if err := openJob(entry.Job, q.keyring); err != nil {
+		return nil, fmt.Errorf("failed to decrypt quarantined job: %w", err)
+	}
+	return &entry, nil
+}
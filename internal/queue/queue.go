@@ -2,6 +2,7 @@ package queue
 
 import (
 	"context"
+	"time"
 
 	"github.com/aneeshsunganahalli/Gopher/pkg/types"
 )
@@ -24,8 +25,34 @@ type Queue interface {
 	Close() error
 }
 
+// Acker is implemented by queues that must be told when a dequeued job has
+// been handled (completed, dead-lettered or re-enqueued), e.g. to commit offsets
+type Acker interface {
+	Ack(ctx context.Context, job *types.Job) error
+}
+
+// Scheduler is implemented by queues that can hold a job back until runAt,
+// letting workers hand retries to the backend instead of waiting in memory
+type Scheduler interface {
+	Schedule(ctx context.Context, job *types.Job, runAt time.Time) error
+}
+
+// ScheduleBackend is implemented by queues other than Redis that can keep
+// recurring schedules next to their jobs
+type ScheduleBackend interface {
+	Schedules() Schedules
+}
+
+// StatsProvider is implemented by queues that keep throughput counters
+type StatsProvider interface {
+	GetStats(ctx context.Context) (*QueueStats, error)
+}
+
 type QueueStats struct {
-	QueueSize int `json:"queue_size"`
-	TotalEnqueued int `json:"total_enqueued"`
-	TotalDequeued int `json:"total_dequeued"`
+	QueueSize     int                      `json:"queue_size"`
+	TotalEnqueued int                      `json:"total_enqueued"`
+	TotalDequeued int                      `json:"total_dequeued"`
+	Retrying      int                      `json:"retrying"`              // Jobs waiting out a retry backoff, not in the queue
+	ByPriority    map[string]PriorityStats `json:"by_priority,omitempty"` // Set when the priority queues are in use
+	Suppressed    map[string]int           `json:"suppressed,omitempty"`  // Repeated submissions answered from an idempotency key, by job type
 }
@@ -0,0 +1,161 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Per-queue throughput counters in the stats hash, followed by the queue name
+const (
+	queueEnqueuedField = "queue_enqueued:"
+	queueDequeuedField = "queue_dequeued:"
+)
+
+// Kinds of queue reported by ListQueues
+const (
+	QueueKindNamed    = "queue"    // The default queue or a named physical queue
+	QueueKindPriority = "priority" // One level of the priority queues
+)
+
+// QueueInfo summarizes one queue for dashboards
+type QueueInfo struct {
+	Name             string     `json:"name"` // Queue name, or priority:<level> for the priority queues
+	Kind             string     `json:"kind"`
+	Size             int        `json:"size"`
+	Paused           bool       `json:"paused"`
+	Enqueued         int        `json:"enqueued"` // Jobs pushed since counters started
+	Dequeued         int        `json:"dequeued"` // Jobs handed to workers since counters started
+	OldestEnqueuedAt *time.Time `json:"oldest_enqueued_at,omitempty"`
+	OldestAgeSeconds float64    `json:"oldest_age_seconds"` // Zero when the queue is empty
+}
+
+// ListQueues returns every known queue with its size, paused state,
+// throughput counters and the age of the job that has waited longest. Known
+// queues are the default queue, named queues holding jobs or with counters,
+// paused queues and, once used, the priority levels.
+func (a *Admin) ListQueues(ctx context.Context) ([]QueueInfo, error) {
+	keys, err := a.layout.pendingQueueKeys(ctx, a.client)
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := a.client.Pipeline()
+	statsCmd := pipe.HGetAll(ctx, a.layout.key(statsKey))
+	pausedCmd := pipe.HKeys(ctx, a.layout.key(pausedQueuesKey))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to read queue counters: %w", err)
+	}
+	counters := statsCmd.Val()
+
+	paused := make(map[string]bool)
+	for _, name := range pausedCmd.Val() {
+		paused[name] = true
+	}
+
+	priorityLevels := map[string]string{
+		a.layout.key(highPriorityQueueKey):   PriorityHigh,
+		a.layout.key(normalPriorityQueueKey): PriorityNormal,
+		a.layout.key(lowPriorityQueueKey):    PriorityLow,
+	}
+
+	// Named queues come from their lists, counters and pauses, since an
+	// empty list no longer exists in Redis
+	queues := map[string]*QueueInfo{}
+	names := map[string]bool{DefaultQueueName: true}
+	for _, key := range keys {
+		if _, ok := priorityLevels[key]; !ok {
+			names[a.layout.queueName(key)] = true
+		}
+	}
+	for field := range counters {
+		if name, ok := strings.CutPrefix(field, queueEnqueuedField); ok {
+			names[name] = true
+		}
+	}
+	for name := range paused {
+		if name != PriorityQueueName {
+			names[name] = true
+		}
+	}
+	for name := range names {
+		info := &QueueInfo{Name: name, Kind: QueueKindNamed, Paused: paused[name]}
+		fmt.Sscanf(counters[queueEnqueuedField+name], "%d", &info.Enqueued)
+		fmt.Sscanf(counters[queueDequeuedField+name], "%d", &info.Dequeued)
+		queues[a.layout.QueueKey(name)] = info
+	}
+	for key, level := range priorityLevels {
+		info := &QueueInfo{Name: PriorityQueueName + ":" + level, Kind: QueueKindPriority, Paused: paused[PriorityQueueName]}
+		fmt.Sscanf(counters["enqueued:"+level], "%d", &info.Enqueued)
+		fmt.Sscanf(counters["dequeued:"+level], "%d", &info.Dequeued)
+		queues[key] = info
+	}
+
+	// Jobs are pushed on the left and taken from the right, so the oldest is last
+	pipe = a.client.Pipeline()
+	sizeCmds := make(map[string]*redis.IntCmd, len(queues))
+	oldestCmds := make(map[string]*redis.StringCmd, len(queues))
+	for key := range queues {
+		sizeCmds[key] = pipe.LLen(ctx, key)
+		oldestCmds[key] = pipe.LIndex(ctx, key, -1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to read queue sizes: %w", err)
+	}
+
+	now := time.Now().UTC()
+	list := make([]QueueInfo, 0, len(queues))
+	for key, info := range queues {
+		info.Size = int(sizeCmds[key].Val())
+		if enqueuedAt, ok := enqueuedAt(oldestCmds[key].Val()); ok {
+			info.OldestEnqueuedAt = &enqueuedAt
+			info.OldestAgeSeconds = now.Sub(enqueuedAt).Seconds()
+		}
+
+		// Priority levels nobody has used are left out
+		if info.Kind == QueueKindPriority && info.Size == 0 && info.Enqueued == 0 && info.Dequeued == 0 {
+			continue
+		}
+		list = append(list, *info)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Kind != list[j].Kind {
+			return list[i].Kind == QueueKindNamed
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list, nil
+}
+
+// queueName returns the name of the queue stored under a pending queue key
+func (l *Layout) queueName(key string) string {
+	if name, ok := strings.CutPrefix(key, l.key(jobQueueKey)+":"); ok {
+		return name
+	}
+	return DefaultQueueName
+}
+
+// enqueuedAt reads when a stored job entered its queue, falling back to its
+// creation for jobs written before enqueue times were recorded
+func enqueuedAt(jobData string) (time.Time, bool) {
+	if jobData == "" {
+		return time.Time{}, false
+	}
+
+	stored, err := DecodeJob([]byte(jobData))
+	if err != nil {
+		return time.Time{}, false
+	}
+	if !stored.EnqueuedAt.IsZero() {
+		return stored.EnqueuedAt.UTC(), true
+	}
+	if !stored.CreatedAt.IsZero() {
+		return stored.CreatedAt.UTC(), true
+	}
+	return time.Time{}, false
+}
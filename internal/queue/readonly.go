@@ -0,0 +1,118 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	readOnlyKey = "maintenance:read_only" // Redis string holding the read-only mode, absent when writable
+
+	readOnlyRefreshInterval = 2 * time.Second
+
+	defaultReadOnlyRetryAfter = 300 // Seconds suggested to rejected clients when none is given
+)
+
+// ReadOnlyMode describes a maintenance window during which enqueues are rejected
+type ReadOnlyMode struct {
+	Reason     string    `json:"reason,omitempty"`
+	RetryAfter int       `json:"retry_after_seconds"` // Sent to rejected clients in the Retry-After header
+	Since      time.Time `json:"since"`
+}
+
+// ReadOnlyStore shares read-only mode between API replicas through Redis
+type ReadOnlyStore struct {
+	client redis.Cmdable
+	layout *Layout
+
+	mu          sync.Mutex
+	cached      *ReadOnlyMode
+	refreshedAt time.Time
+}
+
+// NewReadOnlyStore creates a read-only mode store
+func NewReadOnlyStore(client redis.Cmdable, layout *Layout) *ReadOnlyStore {
+	return &ReadOnlyStore{client: client, layout: layout}
+}
+
+// Get returns the stored mode, or nil when the API is writable
+func (r *ReadOnlyStore) Get(ctx context.Context) (*ReadOnlyMode, error) {
+	data, err := r.client.Get(ctx, r.layout.key(readOnlyKey)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get read-only mode: %w", err)
+	}
+
+	var mode ReadOnlyMode
+	if err := json.Unmarshal(data, &mode); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal read-only mode: %w", err)
+	}
+	return &mode, nil
+}
+
+// Enable puts every replica into read-only mode until Disable is called
+func (r *ReadOnlyStore) Enable(ctx context.Context, mode ReadOnlyMode) (*ReadOnlyMode, error) {
+	if mode.RetryAfter < 0 {
+		return nil, fmt.Errorf("retry_after_seconds must not be negative, got %d", mode.RetryAfter)
+	}
+	if mode.RetryAfter == 0 {
+		mode.RetryAfter = defaultReadOnlyRetryAfter
+	}
+	mode.Since = time.Now().UTC()
+
+	data, err := json.Marshal(mode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal read-only mode: %w", err)
+	}
+	if err := r.client.Set(ctx, r.layout.key(readOnlyKey), data, 0).Err(); err != nil {
+		return nil, fmt.Errorf("failed to store read-only mode: %w", err)
+	}
+
+	r.remember(&mode)
+	return &mode, nil
+}
+
+// Disable makes the API writable again
+func (r *ReadOnlyStore) Disable(ctx context.Context) error {
+	if err := r.client.Del(ctx, r.layout.key(readOnlyKey)).Err(); err != nil {
+		return fmt.Errorf("failed to clear read-only mode: %w", err)
+	}
+
+	r.remember(nil)
+	return nil
+}
+
+// Current returns the mode in effect, re-reading Redis every few seconds so
+// changes made through another replica are picked up. When Redis can't be
+// read the last known mode stays in effect.
+func (r *ReadOnlyStore) Current(ctx context.Context) *ReadOnlyMode {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.refreshedAt) < readOnlyRefreshInterval {
+		return r.cached
+	}
+	r.refreshedAt = time.Now()
+
+	mode, err := r.Get(ctx)
+	if err == nil {
+		r.cached = mode
+	}
+	return r.cached
+}
+
+// remember caches a mode this replica just changed
+func (r *ReadOnlyStore) remember(mode *ReadOnlyMode) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cached = mode
+	r.refreshedAt = time.Now()
+}
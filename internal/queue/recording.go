@@ -0,0 +1,254 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/aneeshsunganahalli/Gopher/internal/redact"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	recordingKey = "recording:jobs" // Redis list of sampled enqueued jobs, oldest first
+)
+
+// RecordedJob is a sampled job kept for later replay, with redacted payload
+type RecordedJob struct {
+	RecordedAt time.Time         `json:"recorded_at"`
+	Type       string            `json:"type"`
+	Payload    json.RawMessage   `json:"payload"`
+	KeyID      string            `json:"key_id,omitempty"` // Key the payload is encrypted with in Redis, empty once opened
+	MaxRetries int               `json:"max_retries"`
+	Metadata   types.JobMetadata `json:"metadata,omitempty"`
+}
+
+// RecordingOptions controls job sampling
+type RecordingOptions struct {
+	SampleRate float64 // Fraction of enqueued jobs to record
+	MaxEntries int     // Oldest recordings are dropped beyond this
+}
+
+// RecordingQueue wraps a Queue and records a sample of successfully enqueued jobs
+type RecordingQueue struct {
+	Queue
+	client   redis.Cmdable
+	layout   *Layout
+	opts     RecordingOptions
+	redactor *redact.Redactor
+	keyring  *encryption.Keyring
+	logger   *zap.Logger
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// NewRecordingQueue wraps inner; payloads are redacted before they are stored
+func NewRecordingQueue(inner Queue, client redis.Cmdable, layout *Layout, opts RecordingOptions, redactor *redact.Redactor, logger *zap.Logger) *RecordingQueue {
+	return &RecordingQueue{
+		Queue:    inner,
+		client:   client,
+		layout:   layout,
+		opts:     opts,
+		redactor: redactor,
+		logger:   logger,
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// SetKeyring enables payload encryption for recorded jobs
+func (r *RecordingQueue) SetKeyring(keyring *encryption.Keyring) {
+	r.keyring = keyring
+}
+
+// Enqueue enqueues the job and records it if it is sampled
+func (r *RecordingQueue) Enqueue(ctx context.Context, job *types.Job) error {
+	if err := r.Queue.Enqueue(ctx, job); err != nil {
+		return err
+	}
+
+	if r.sampled() {
+		if err := r.record(ctx, job); err != nil {
+			r.logger.Warn("Failed to record job", zap.String("job_id", job.ID), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// Ack acknowledges the job on the wrapped queue, if it takes acks
+func (r *RecordingQueue) Ack(ctx context.Context, job *types.Job) error {
+	if acker, ok := r.Queue.(Acker); ok {
+		return acker.Ack(ctx, job)
+	}
+	return nil
+}
+
+// Schedule holds the job back on the wrapped queue. Scheduled jobs are not
+// recorded.
+func (r *RecordingQueue) Schedule(ctx context.Context, job *types.Job, runAt time.Time) error {
+	scheduler, ok := r.Queue.(Scheduler)
+	if !ok {
+		return fmt.Errorf("queue does not schedule jobs")
+	}
+	return scheduler.Schedule(ctx, job, runAt)
+}
+
+// GetStats returns the wrapped queue's stats
+func (r *RecordingQueue) GetStats(ctx context.Context) (*QueueStats, error) {
+	provider, ok := r.Queue.(StatsProvider)
+	if !ok {
+		return nil, fmt.Errorf("queue does not keep stats")
+	}
+	return provider.GetStats(ctx)
+}
+
+// GetStatus returns a job's state from the wrapped queue
+func (r *RecordingQueue) GetStatus(ctx context.Context, jobID string) (*JobStatusRecord, error) {
+	tracker, ok := r.Queue.(StatusTracker)
+	if !ok {
+		return nil, fmt.Errorf("queue does not track job status")
+	}
+	return tracker.GetStatus(ctx, jobID)
+}
+
+// Purge drops every job the wrapped queue holds
+func (r *RecordingQueue) Purge(ctx context.Context) (int, error) {
+	purger, ok := r.Queue.(Purger)
+	if !ok {
+		return 0, fmt.Errorf("queue cannot be purged")
+	}
+	return purger.Purge(ctx)
+}
+
+func (r *RecordingQueue) sampled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.rand.Float64() < r.opts.SampleRate
+}
+
+func (r *RecordingQueue) record(ctx context.Context, job *types.Job) error {
+	recorded := RecordedJob{
+		RecordedAt: time.Now().UTC(),
+		Type:       job.Type,
+		Payload:    r.redactor.Payload(job.Type, job.Payload),
+		MaxRetries: job.MaxRetries,
+		Metadata:   job.Metadata,
+	}
+	if r.keyring != nil {
+		sealed, keyID, err := sealPayload(recorded.Payload, r.keyring)
+		if err != nil {
+			return err
+		}
+		recorded.Payload, recorded.KeyID = sealed, keyID
+	}
+
+	data, err := json.Marshal(recorded)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recorded job: %w", err)
+	}
+
+	pipe := r.client.Pipeline()
+	pipe.RPush(ctx, r.layout.key(recordingKey), data)
+	if r.opts.MaxEntries > 0 {
+		pipe.LTrim(ctx, r.layout.key(recordingKey), int64(-r.opts.MaxEntries), -1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store recorded job: %w", err)
+	}
+
+	return nil
+}
+
+// LoadRecording returns all recorded jobs, oldest first, with their
+// payloads decrypted by keyring. Jobs that can't be read are skipped.
+func LoadRecording(ctx context.Context, client redis.Cmdable, layout *Layout, keyring *encryption.Keyring) ([]RecordedJob, error) {
+	entries, err := client.LRange(ctx, layout.key(recordingKey), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recording: %w", err)
+	}
+
+	jobs := make([]RecordedJob, 0, len(entries))
+	for _, entry := range entries {
+		job, err := decodeRecordedJob(entry)
+		if err != nil || job.open(keyring) != nil {
+			continue
+		}
+		jobs = append(jobs, *job)
+	}
+
+	return jobs, nil
+}
+
+func decodeRecordedJob(entry string) (*RecordedJob, error) {
+	var job RecordedJob
+	if err := json.Unmarshal([]byte(entry), &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// open decrypts the payload in place
+func (j *RecordedJob) open(keyring *encryption.Keyring) error {
+	if j.KeyID == "" {
+		return nil
+	}
+	payload, err := openPayload(j.Payload, j.KeyID, keyring)
+	if err != nil {
+		return err
+	}
+	j.Payload, j.KeyID = payload, ""
+	return nil
+}
+
+// ClearRecording deletes all recorded jobs
+func ClearRecording(ctx context.Context, client redis.Cmdable, layout *Layout) error {
+	if err := client.Del(ctx, layout.key(recordingKey)).Err(); err != nil {
+		return fmt.Errorf("failed to clear recording: %w", err)
+	}
+	return nil
+}
+
+// Replay enqueues recorded jobs into target as new jobs, preserving the gaps
+// between them divided by speed. A speed of 0 replays as fast as possible.
+// onJob, if not nil, is called after each job is enqueued.
+func Replay(ctx context.Context, target Queue, recorded []RecordedJob, speed float64, onJob func(job *types.Job)) (int, error) {
+	replayed := 0
+
+	for i, rec := range recorded {
+		if i > 0 && speed > 0 {
+			gap := rec.RecordedAt.Sub(recorded[i-1].RecordedAt)
+			if gap > 0 {
+				select {
+				case <-ctx.Done():
+					return replayed, ctx.Err()
+				case <-time.After(time.Duration(float64(gap) / speed)):
+				}
+			}
+		}
+
+		job := types.NewJob(rec.Type, rec.Payload, rec.MaxRetries)
+		for key, value := range rec.Metadata {
+			job.AddMetadata(key, value)
+		}
+		job.AddMetadata("replayed_from", rec.RecordedAt.Format(time.RFC3339Nano))
+
+		if err := target.Enqueue(ctx, job); err != nil {
+			return replayed, fmt.Errorf("failed to replay job %d: %w", i, err)
+		}
+
+		replayed++
+		if onJob != nil {
+			onJob(job)
+		}
+	}
+
+	return replayed, nil
+}
@@ -2,17 +2,26 @@ package queue
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
 	"github.com/aneeshsunganahalli/Gopher/pkg/types"
 	"github.com/go-redis/redis/v8"
 )
 
 const (
-	jobQueueKey = "job_queue"   //  Redis list storing jobs.
+	jobQueueKey = "job_queue"   //  Redis list storing jobs; named queues use job_queue:<name>
 	statsKey    = "queue_stats" //  Redis hash storing counters like total enqueued/dequeued
+
+	// clusterHashTag puts every key on one Redis Cluster slot, so scripts,
+	// transactions and multi-key BRPOPs keep working
+	clusterHashTag = "{gopher}:"
 )
 
 type RedisOptions struct {
@@ -21,37 +30,273 @@ type RedisOptions struct {
 	DB             int
 	ConnectTimeout time.Duration
 	CommandTimeout time.Duration
+	QueueName      string        // Physical queue to use, empty for the default queue
+	Queues         []string      // Additional physical queues to consume, polled after QueueName
+	PollTimeout    time.Duration // How long a dequeue blocks waiting for a job, defaults to 1s
+	ClusterAddrs   []string      // Redis Cluster seed nodes; when set, URL is only used for its credentials
+	KeyPrefix      string        // Prepended to every key so deployments can share one Redis, e.g. "gopher:prod:"
+	ConsumerID     string        // Names this process's processing lists, defaults to <hostname>-<pid> like the worker pool ID
+
+	// Sentinel-managed primary; when set, URL is only used for its credentials
+	SentinelMaster   string
+	SentinelAddrs    []string
+	SentinelPassword string
+
+	// TLS for managed Redis; setting any of these enables TLS even for a redis:// URL
+	TLSCACert             string // PEM file of the CA that signed the server certificate, system roots when empty
+	TLSCert               string // PEM client certificate file, for servers requiring mutual TLS
+	TLSKey                string // PEM private key file for TLSCert
+	TLSInsecureSkipVerify bool   // Skip server certificate verification; only for testing
+
+	// Compression of large payloads; jobs are decoded by their Encoding marker either way
+	Compression          string // EncodingGzip, or empty to store payloads as is
+	CompressionThreshold int    // Payload size in bytes compression starts at, DefaultCompressionThreshold when 0
+
+	Format string // Wire format of queued jobs, FormatJSON (default), FormatProtobuf, FormatMsgpack or a registered codec; jobs are decoded by their format byte either way
+
+	Signing Signing // Signs stored jobs and verifies dequeued ones, off when zero
 }
 
-type RedisQueue struct {
-	client redis.Cmdable // Client used to talk to Redis
-	opts   RedisOptions
+// keyPrefix returns the key prefix the options call for. In cluster mode a
+// prefix without a hash tag becomes one, e.g. "{gopher:prod:}", so all keys
+// of a deployment still share a slot.
+func (o RedisOptions) keyPrefix() string {
+	if len(o.ClusterAddrs) == 0 {
+		return o.KeyPrefix
+	}
+	if o.KeyPrefix == "" {
+		return clusterHashTag
+	}
+	if open := strings.Index(o.KeyPrefix, "{"); open >= 0 && strings.Index(o.KeyPrefix[open:], "}") > 1 {
+		return o.KeyPrefix
+	}
+	return "{" + o.KeyPrefix + "}"
 }
 
-func NewRedisQueue(opts RedisOptions) (*RedisQueue, error) {
-	// Parse URl to create new client
-	redisOpts, err := redis.ParseURL(opts.URL)
+// urlOptions returns the username, password and TLS settings that cluster
+// and sentinel clients take from the URL; Password overrides the URL's
+func (o RedisOptions) urlOptions() (*redis.Options, error) {
+	base := &redis.Options{}
+	if o.URL != "" {
+		parsed, err := redis.ParseURL(o.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+		}
+		base = parsed
+	}
+	if o.Password != "" {
+		base.Password = o.Password
+	}
+	tlsConfig, err := o.tlsConfig(base.TLSConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+		return nil, err
+	}
+	base.TLSConfig = tlsConfig
+	return base, nil
+}
+
+// tlsConfig applies the TLS options on top of the URL's TLS settings, which
+// are nil for plaintext URLs
+func (o RedisOptions) tlsConfig(base *tls.Config) (*tls.Config, error) {
+	if o.TLSCACert == "" && o.TLSCert == "" && o.TLSKey == "" && !o.TLSInsecureSkipVerify {
+		return base, nil
+	}
+
+	config := &tls.Config{}
+	if base != nil {
+		config = base.Clone()
+	}
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
 	}
-	redisOpts.Password = opts.Password
-	redisOpts.DB = opts.DB
-	redisOpts.DialTimeout = opts.ConnectTimeout
-	redisOpts.ReadTimeout = opts.CommandTimeout
-	redisOpts.WriteTimeout = opts.CommandTimeout
 
-	client := redis.NewClient(redisOpts) // creates actual connection pool to redis
+	if o.TLSCACert != "" {
+		pem, err := os.ReadFile(o.TLSCACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Redis TLS CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in Redis TLS CA file %s", o.TLSCACert)
+		}
+		config.RootCAs = pool
+	}
+	if o.TLSCert != "" || o.TLSKey != "" {
+		cert, err := tls.LoadX509KeyPair(o.TLSCert, o.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Redis TLS client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	config.InsecureSkipVerify = o.TLSInsecureSkipVerify
+
+	return config, nil
+}
+
+// newRedisClient connects to a single Redis node or a Redis Cluster
+func newRedisClient(opts RedisOptions) (redis.UniversalClient, error) {
+	var client redis.UniversalClient
+	switch {
+	case len(opts.ClusterAddrs) > 0:
+		base, err := opts.urlOptions()
+		if err != nil {
+			return nil, err
+		}
+		client = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        opts.ClusterAddrs,
+			Username:     base.Username,
+			Password:     base.Password,
+			TLSConfig:    base.TLSConfig,
+			DialTimeout:  opts.ConnectTimeout,
+			ReadTimeout:  opts.CommandTimeout,
+			WriteTimeout: opts.CommandTimeout,
+		})
+	case opts.SentinelMaster != "":
+		base, err := opts.urlOptions()
+		if err != nil {
+			return nil, err
+		}
+		// The failover client asks the sentinels for the current primary and
+		// reconnects to the new one after a failover
+		client = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       opts.SentinelMaster,
+			SentinelAddrs:    opts.SentinelAddrs,
+			SentinelPassword: opts.SentinelPassword,
+			Username:         base.Username,
+			Password:         base.Password,
+			TLSConfig:        base.TLSConfig,
+			DB:               opts.DB,
+			DialTimeout:      opts.ConnectTimeout,
+			ReadTimeout:      opts.CommandTimeout,
+			WriteTimeout:     opts.CommandTimeout,
+		})
+	default:
+		// Parse URl to create new client
+		redisOpts, err := redis.ParseURL(opts.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+		}
+		redisOpts.Password = opts.Password
+		redisOpts.DB = opts.DB
+		redisOpts.DialTimeout = opts.ConnectTimeout
+		redisOpts.ReadTimeout = opts.CommandTimeout
+		redisOpts.WriteTimeout = opts.CommandTimeout
+		if redisOpts.TLSConfig, err = opts.tlsConfig(redisOpts.TLSConfig); err != nil {
+			return nil, err
+		}
+
+		client = redis.NewClient(redisOpts) // creates actual connection pool to redis
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), opts.ConnectTimeout)
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
+	if err := ValidateCompression(opts.Compression); err != nil {
+		client.Close()
+		return nil, err
+	}
+	if err := ValidateFormat(opts.Format); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// scanNode returns the client to SCAN for keys in this package. On a
+// cluster that is the node owning the hash tag's slot, since a cluster
+// client would otherwise scan an arbitrary node.
+func (l *Layout) scanNode(ctx context.Context, client redis.Cmdable) (redis.Cmdable, error) {
+	cluster, ok := client.(*redis.ClusterClient)
+	if !ok {
+		return client, nil
+	}
+	node, err := cluster.MasterForKey(ctx, l.key(jobQueueKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find cluster node: %w", err)
+	}
+	return node, nil
+}
+
+// pollTimeout returns the BRPOP timeout for the options
+func (o RedisOptions) pollTimeout() time.Duration {
+	if o.PollTimeout <= 0 {
+		return time.Second
+	}
+	return o.PollTimeout
+}
+
+type RedisQueue struct {
+	client  redis.Cmdable // Client used to talk to Redis
+	layout  *Layout       // Key prefix, job format and signing shared with the stores
+	opts    RedisOptions
+	key     string              // Redis list backing this queue
+	keys    []string            // Lists consumed by Dequeue, key first
+	names   []string            // Queue names of keys, checked against paused queues
+	keyring *encryption.Keyring // Optional payload encryption
+	pauses  pauseCache
+
+	statuses *StatusStore // Records the pending state of enqueued jobs, nil when tracking is off
+
+	consumer    string // Owner of the processing lists dequeued jobs wait in until acked
+	recoverOnce sync.Once
+	mu          sync.Mutex
+	inFlight    map[*types.Job]delivery // Dequeued, unacked jobs
+}
+
+// QueueKey returns the Redis list backing a physical queue
+func (l *Layout) QueueKey(name string) string {
+	if name == "" || name == DefaultQueueName {
+		return l.key(jobQueueKey)
+	}
+	return l.key(jobQueueKey) + ":" + name
+}
+
+// targetQueue returns the named queue a job targets, or fallback when it names none
+func targetQueue(job *types.Job, fallback string) string {
+	if job.Queue != "" {
+		return job.Queue
+	}
+	return fallback
+}
+
+func NewRedisQueue(opts RedisOptions) (*RedisQueue, error) {
+	client, err := newRedisClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := newLayout(opts)
+	key := layout.QueueKey(opts.QueueName)
+	keys := []string{key}
+	names := []string{pauseName(opts.QueueName)}
+	for _, name := range opts.Queues {
+		if extra := layout.QueueKey(name); extra != key {
+			keys = append(keys, extra)
+			names = append(names, pauseName(name))
+		}
+	}
+
+	consumer := opts.ConsumerID
+	if consumer == "" {
+		hostname, _ := os.Hostname()
+		consumer = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+
 	return &RedisQueue{
-		client: client,
-		opts:   opts,
+		client:   client,
+		layout:   layout,
+		opts:     opts,
+		key:      key,
+		keys:     keys,
+		names:    names,
+		consumer: consumer,
+		inFlight: make(map[*types.Job]delivery),
 	}, nil
 }
 
@@ -60,17 +305,33 @@ func (r *RedisQueue) Enqueue(ctx context.Context, job *types.Job) error {
 		return fmt.Errorf("job validation failed: %w", err)
 	}
 
-	// Serialize job to JSON
-	jobData, err := json.Marshal(job)
+	job.EnqueuedAt = time.Now().UTC()
+
+	sealed, err := r.layout.sealJob(job, r.keyring)
+	if err != nil {
+		return err
+	}
+
+	jobData, err := r.layout.encodeJob(sealed)
 	if err != nil {
-		return fmt.Errorf("failed to marshal job: %w", err)
+		return err
+	}
+
+	// Jobs naming a queue go there instead of the producer's own queue
+	key := r.key
+	if job.Queue != "" {
+		key = r.layout.QueueKey(job.Queue)
 	}
 
 	pipe := r.client.Pipeline() // used for atomic operations
 
-	pipe.LPush(ctx, jobQueueKey, jobData) // adding job to queue
+	pipe.LPush(ctx, key, jobData) // adding job to queue
 
-	pipe.HIncrBy(ctx, statsKey, "total_enqueued", 1)
+	pipe.HIncrBy(ctx, r.layout.key(statsKey), "total_enqueued", 1)
+	pipe.HIncrBy(ctx, r.layout.key(statsKey), queueEnqueuedField+pauseName(targetQueue(job, r.names[0])), 1)
+	if r.statuses != nil {
+		r.statuses.queue(ctx, pipe, job, types.StatusPending, "")
+	}
 
 	// Execute pipeline
 	_, err = pipe.Exec(ctx)
@@ -81,44 +342,67 @@ func (r *RedisQueue) Enqueue(ctx context.Context, job *types.Job) error {
 	return nil
 }
 
+// Dequeue moves the next job into this consumer's processing list, where it
+// stays until Ack, so jobs of a crashed worker can be recovered. It long-polls
+// the first queue with BLMOVE; additional queues are checked before blocking.
 func (r *RedisQueue) Dequeue(ctx context.Context) (*types.Job, error) {
-	result := r.client.BRPop(ctx, time.Second, jobQueueKey)
-	if err := result.Err(); err != nil {
-		if err == redis.Nil {
-			// No job available, this is normal
-			return nil, nil
-		}
-		return nil, fmt.Errorf("failed to dequeue job: %w", err)
-	}
+	// Jobs left by an earlier process with the same consumer ID are orphans
+	r.recoverOnce.Do(func() {
+		r.recoverOwn(ctx)
+	})
 
-	values := result.Val()
-	if len(values) != 2 {
-		return nil, fmt.Errorf("unexpected BRPOP result: %v", values)
+	processing, jobData, err := r.claim(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if jobData == "" {
+		// No job available, this is normal
+		return nil, nil
 	}
-
-	jobData := values[1]
 
 	var job types.Job
-	if err := json.Unmarshal([]byte(jobData), &job); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+	if err := decodeJob([]byte(jobData), &job); err != nil {
+		r.client.LRem(ctx, processing, 1, jobData)
+		return nil, err
 	}
 
+	if err := openJob(&job, r.keyring); err != nil {
+		r.client.LRem(ctx, processing, 1, jobData)
+		return nil, fmt.Errorf("failed to decrypt job: %w", err)
+	}
+
+	r.mu.Lock()
+	r.inFlight[&job] = delivery{processing: processing, data: jobData}
+	r.mu.Unlock()
+
 	go func() {
 		// Use background context to avoid cancellation affecting stats
 		statsCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
-		r.client.HIncrBy(statsCtx, statsKey, "total_dequeued", 1)
+		pipe := r.client.Pipeline()
+		pipe.HIncrBy(statsCtx, r.layout.key(statsKey), "total_dequeued", 1)
+		pipe.HIncrBy(statsCtx, r.layout.key(statsKey), queueDequeuedField+r.claimedQueue(processing), 1)
+		pipe.Exec(statsCtx)
 	}()
 
 	return &job, nil
 }
 
 func (r *RedisQueue) Size(ctx context.Context) (int, error) {
-	result := r.client.LLen(ctx, jobQueueKey)
-	if err := result.Err(); err != nil {
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(r.keys))
+	for i, key := range r.keys {
+		cmds[i] = pipe.LLen(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
 		return 0, fmt.Errorf("failed to get queue size: %w", err)
 	}
-	return int(result.Val()), nil
+
+	size := 0
+	for _, cmd := range cmds {
+		size += int(cmd.Val())
+	}
+	return size, nil
 }
 
 func (r *RedisQueue) Health(ctx context.Context) error {
@@ -128,9 +412,25 @@ func (r *RedisQueue) Health(ctx context.Context) error {
 	return nil
 }
 
+// SetKeyring enables payload encryption with the given keyring
+func (r *RedisQueue) SetKeyring(keyring *encryption.Keyring) {
+	r.keyring = keyring
+}
+
+// Client returns the underlying Redis client
+func (r *RedisQueue) Client() redis.Cmdable {
+	return r.client
+}
+
+// Layout returns the key prefix, job format and signing the queue uses,
+// for the stores sharing its Redis
+func (r *RedisQueue) Layout() *Layout {
+	return r.layout
+}
+
 // Close closes the Redis connection
 func (r *RedisQueue) Close() error {
-	if client, ok := r.client.(*redis.Client); ok {
+	if client, ok := r.client.(redis.UniversalClient); ok {
 		return client.Close()
 	}
 	return nil
@@ -139,8 +439,9 @@ func (r *RedisQueue) Close() error {
 func (r *RedisQueue) GetStats(ctx context.Context) (*QueueStats, error) {
 	pipe := r.client.Pipeline()
 
-	sizeCmd := pipe.LLen(ctx, jobQueueKey)
-	statsCmd := pipe.HGetAll(ctx, statsKey)
+	sizeCmd := pipe.LLen(ctx, r.key)
+	statsCmd := pipe.HGetAll(ctx, r.layout.key(statsKey))
+	retryingCmd := pipe.HLen(ctx, r.layout.key(pendingRetriesKey))
 
 	_, err := pipe.Exec(ctx)
 	if err != nil {
@@ -149,6 +450,7 @@ func (r *RedisQueue) GetStats(ctx context.Context) (*QueueStats, error) {
 
 	stats := &QueueStats{
 		QueueSize: int(sizeCmd.Val()),
+		Retrying:  int(retryingCmd.Val()),
 	}
 
 	// Parse statistics if they exist
@@ -0,0 +1,252 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+// newTestRedisQueue connects a queue to a fresh in-process Redis. Queues
+// created with the same server share it.
+func newTestRedisQueue(t *testing.T, server *miniredis.Miniredis, opts RedisOptions) *RedisQueue {
+	t.Helper()
+
+	opts.URL = "redis://" + server.Addr()
+	if opts.ConnectTimeout == 0 {
+		opts.ConnectTimeout = time.Second
+	}
+	if opts.PollTimeout == 0 {
+		opts.PollTimeout = time.Second // The shortest blocking timeout Redis takes
+	}
+	if opts.ConsumerID == "" {
+		opts.ConsumerID = "test-consumer"
+	}
+
+	q, err := NewRedisQueue(opts)
+	if err != nil {
+		t.Fatalf("NewRedisQueue: %v", err)
+	}
+	t.Cleanup(func() { q.Close() })
+	return q
+}
+
+func TestRedisQueueRoundTrip(t *testing.T) {
+	keyring, err := encryption.NewKeyring(map[string][]byte{"v1": bytes.Repeat([]byte{1}, 32)}, "v1")
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    RedisOptions
+		keyring *encryption.Keyring
+		payload string
+		wantKey string // Key the job is stored under
+	}{
+		{name: "json", payload: `{"to":"a@example.com"}`, wantKey: "job_queue"},
+		{name: "protobuf", opts: RedisOptions{Format: FormatProtobuf}, payload: `{"to":"a@example.com"}`, wantKey: "job_queue"},
+		{name: "msgpack", opts: RedisOptions{Format: FormatMsgpack}, payload: `{"to":"a@example.com"}`, wantKey: "job_queue"},
+		{
+			name:    "gzip above the threshold",
+			opts:    RedisOptions{Compression: EncodingGzip, CompressionThreshold: 16},
+			payload: `{"body":"` + strings.Repeat("x", 256) + `"}`,
+			wantKey: "job_queue",
+		},
+		{name: "key prefix", opts: RedisOptions{KeyPrefix: "gopher:prod:"}, payload: `{}`, wantKey: "gopher:prod:job_queue"},
+		{name: "named queue", opts: RedisOptions{QueueName: "emails"}, payload: `{}`, wantKey: "job_queue:emails"},
+		{name: "encrypted", keyring: keyring, payload: `{"card":"4111111111111111"}`, wantKey: "job_queue"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server := miniredis.RunT(t)
+			q := newTestRedisQueue(t, server, tt.opts)
+			q.SetKeyring(tt.keyring)
+
+			job := types.NewJob("email", json.RawMessage(tt.payload), 3)
+			if err := q.Enqueue(ctx, job); err != nil {
+				t.Fatalf("Enqueue: %v", err)
+			}
+
+			stored, err := server.List(tt.wantKey)
+			if err != nil || len(stored) != 1 {
+				t.Fatalf("%s holds %d jobs (%v), want 1", tt.wantKey, len(stored), err)
+			}
+			if tt.keyring != nil && strings.Contains(stored[0], "4111111111111111") {
+				t.Fatal("encrypted job is stored in plaintext")
+			}
+
+			got, err := q.Dequeue(ctx)
+			if err != nil {
+				t.Fatalf("Dequeue: %v", err)
+			}
+			if got == nil || got.ID != job.ID || got.Type != job.Type {
+				t.Fatalf("Dequeue = %+v, want job %s", got, job.ID)
+			}
+			if !jsonEqual(t, got.Payload, job.Payload) {
+				t.Fatalf("payload = %s, want %s", got.Payload, job.Payload)
+			}
+		})
+	}
+}
+
+func TestRedisQueueReliableDequeue(t *testing.T) {
+	tests := []struct {
+		name string
+		// after runs once the job has been dequeued by the first consumer
+		after        func(t *testing.T, server *miniredis.Miniredis, q *RedisQueue, job *types.Job)
+		wantQueued   int // Jobs back in job_queue
+		wantInFlight int // Jobs left in the first consumer's processing list
+	}{
+		{
+			name: "ack removes the job",
+			after: func(t *testing.T, server *miniredis.Miniredis, q *RedisQueue, job *types.Job) {
+				if err := q.Ack(context.Background(), job); err != nil {
+					t.Fatalf("Ack: %v", err)
+				}
+			},
+			wantQueued:   0,
+			wantInFlight: 0,
+		},
+		{
+			name:         "unacked job waits in the processing list",
+			after:        func(t *testing.T, server *miniredis.Miniredis, q *RedisQueue, job *types.Job) {},
+			wantQueued:   0,
+			wantInFlight: 1,
+		},
+		{
+			name: "restarted consumer requeues its orphans",
+			after: func(t *testing.T, server *miniredis.Miniredis, q *RedisQueue, job *types.Job) {
+				restarted := newTestRedisQueue(t, server, RedisOptions{ConsumerID: "first"})
+				// Dequeue recovers first; the recovered job is claimed again at once
+				got, err := restarted.Dequeue(context.Background())
+				if err != nil || got == nil || got.ID != job.ID {
+					t.Fatalf("Dequeue after restart = %v, %v; want job %s", got, err, job.ID)
+				}
+			},
+			wantQueued:   0,
+			wantInFlight: 1,
+		},
+		{
+			name: "dead consumer's jobs are recovered",
+			after: func(t *testing.T, server *miniredis.Miniredis, q *RedisQueue, job *types.Job) {
+				other := newTestRedisQueue(t, server, RedisOptions{ConsumerID: "second"})
+				heartbeats := NewHeartbeatRegistry(other.Client(), other.Layout(), time.Minute)
+				recovered, err := other.Recover(context.Background(), heartbeats)
+				if err != nil || recovered != 1 {
+					t.Fatalf("Recover = %d, %v; want 1", recovered, err)
+				}
+			},
+			wantQueued:   1,
+			wantInFlight: 0,
+		},
+		{
+			name: "live consumer's jobs are left alone",
+			after: func(t *testing.T, server *miniredis.Miniredis, q *RedisQueue, job *types.Job) {
+				other := newTestRedisQueue(t, server, RedisOptions{ConsumerID: "second"})
+				heartbeats := NewHeartbeatRegistry(other.Client(), other.Layout(), time.Minute)
+				if err := heartbeats.Beat(context.Background(), WorkerHeartbeat{ID: "first"}); err != nil {
+					t.Fatalf("Beat: %v", err)
+				}
+				recovered, err := other.Recover(context.Background(), heartbeats)
+				if err != nil || recovered != 0 {
+					t.Fatalf("Recover = %d, %v; want 0", recovered, err)
+				}
+			},
+			wantQueued:   0,
+			wantInFlight: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server := miniredis.RunT(t)
+			q := newTestRedisQueue(t, server, RedisOptions{ConsumerID: "first"})
+
+			job := types.NewJob("email", json.RawMessage(`{}`), 3)
+			if err := q.Enqueue(ctx, job); err != nil {
+				t.Fatalf("Enqueue: %v", err)
+			}
+			got, err := q.Dequeue(ctx)
+			if err != nil || got == nil {
+				t.Fatalf("Dequeue = %v, %v; want a job", got, err)
+			}
+
+			tt.after(t, server, q, got)
+
+			if queued := listLen(server, "job_queue"); queued != tt.wantQueued {
+				t.Errorf("job_queue holds %d jobs, want %d", queued, tt.wantQueued)
+			}
+			if inFlight := listLen(server, "job_queue:processing:first"); inFlight != tt.wantInFlight {
+				t.Errorf("processing list holds %d jobs, want %d", inFlight, tt.wantInFlight)
+			}
+		})
+	}
+}
+
+func TestRedisQueueMultiQueue(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	q := newTestRedisQueue(t, server, RedisOptions{QueueName: "critical", Queues: []string{"default", "bulk"}})
+
+	for _, name := range []string{"bulk", "default", "critical"} {
+		job := types.NewJob("email", json.RawMessage(`{}`), 0)
+		job.Queue = name
+		if err := q.Enqueue(ctx, job); err != nil {
+			t.Fatalf("Enqueue to %s: %v", name, err)
+		}
+	}
+
+	// Queues are drained in consumption order, whatever order jobs arrived in
+	for _, want := range []string{"critical", "default", "bulk"} {
+		got, err := q.Dequeue(ctx)
+		if err != nil || got == nil {
+			t.Fatalf("Dequeue = %v, %v; want a job from %s", got, err, want)
+		}
+		if got.Queue != want {
+			t.Fatalf("Dequeue returned a job from %s, want %s", got.Queue, want)
+		}
+		q.Ack(ctx, got)
+	}
+
+	got, err := q.Dequeue(ctx)
+	if err != nil || got != nil {
+		t.Fatalf("Dequeue of empty queues = %v, %v; want nothing", got, err)
+	}
+
+	stats, err := q.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+	if stats.TotalEnqueued != 3 {
+		t.Fatalf("TotalEnqueued = %d, want 3", stats.TotalEnqueued)
+	}
+}
+
+func listLen(server *miniredis.Miniredis, key string) int {
+	items, _ := server.List(key)
+	return len(items)
+}
+
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var x, y interface{}
+	if err := json.Unmarshal(a, &x); err != nil {
+		t.Fatalf("invalid JSON %s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &y); err != nil {
+		t.Fatalf("invalid JSON %s: %v", b, err)
+	}
+	xs, _ := json.Marshal(x)
+	ys, _ := json.Marshal(y)
+	return bytes.Equal(xs, ys)
+}
@@ -0,0 +1,209 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+)
+
+// processingInfix separates a queue's key from the consumer owning one of
+// its processing lists: <queue key>:processing:<consumer ID>
+const processingInfix = ":processing:"
+
+// claimScript moves the oldest job of the first non-empty queue into that
+// queue's processing list. KEYS are queue and processing list pairs in
+// consumption order; it returns the processing list and the job.
+var claimScript = redis.NewScript(`
+for i = 1, #KEYS, 2 do
+	local job = redis.call('LMOVE', KEYS[i], KEYS[i + 1], 'RIGHT', 'LEFT')
+	if job then
+		return {KEYS[i + 1], job}
+	end
+end
+return false
+`)
+
+// requeueProcessingScript moves every job in a processing list (KEYS[1])
+// back to the front of its queue (KEYS[2]), oldest first in line
+var requeueProcessingScript = redis.NewScript(`
+local moved = 0
+while redis.call('LMOVE', KEYS[1], KEYS[2], 'LEFT', 'RIGHT') do
+	moved = moved + 1
+end
+return moved
+`)
+
+// delivery is where a dequeued job waits until it is acked
+type delivery struct {
+	processing string // Processing list holding the job
+	data       string // Job exactly as stored, for LREM
+}
+
+// processingKey returns this consumer's processing list for a queue
+func (r *RedisQueue) processingKey(queueKey string) string {
+	return queueKey + processingInfix + r.consumer
+}
+
+// claimedQueue returns the name of the queue a processing list belongs to
+func (r *RedisQueue) claimedQueue(processing string) string {
+	queueKey, _, _ := strings.Cut(processing, processingInfix)
+	return r.layout.queueName(queueKey)
+}
+
+// isProcessingKey reports whether key is a processing list rather than a queue
+func isProcessingKey(key string) bool {
+	return strings.Contains(key, processingInfix)
+}
+
+// claim moves the next job into a processing list, returning the list and
+// the job data, or an empty job when none arrived within the poll timeout.
+// Paused queues are skipped, and an empty job returns at once when all are.
+func (r *RedisQueue) claim(ctx context.Context) (string, string, error) {
+	active := r.activeKeys(ctx)
+	if len(active) == 0 {
+		return "", "", nil
+	}
+
+	if len(active) > 1 || active[0] != r.key {
+		keys := make([]string, 0, 2*len(active))
+		for _, key := range active {
+			keys = append(keys, key, r.processingKey(key))
+		}
+
+		claimed, err := claimScript.Run(ctx, r.client, keys).StringSlice()
+		if err != nil && err != redis.Nil {
+			return "", "", fmt.Errorf("failed to dequeue job: %w", err)
+		}
+		if len(claimed) == 2 {
+			return claimed[0], claimed[1], nil
+		}
+
+		// Only the first queue is long-polled; it is paused
+		if active[0] != r.key {
+			return "", "", nil
+		}
+	}
+
+	processing := r.processingKey(r.key)
+	jobData, err := r.client.BLMove(ctx, r.key, processing, "RIGHT", "LEFT", r.opts.pollTimeout()).Result()
+	if err == redis.Nil {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to dequeue job: %w", err)
+	}
+	return processing, jobData, nil
+}
+
+// activeKeys returns the consumed lists whose queues are not paused
+func (r *RedisQueue) activeKeys(ctx context.Context) []string {
+	active := make([]string, 0, len(r.keys))
+	for i, key := range r.keys {
+		if !r.pauses.isPaused(ctx, r.client, r.layout, r.names[i]) {
+			active = append(active, key)
+		}
+	}
+	return active
+}
+
+// Ack removes a handled job from its processing list
+func (r *RedisQueue) Ack(ctx context.Context, job *types.Job) error {
+	r.mu.Lock()
+	d, ok := r.inFlight[job]
+	delete(r.inFlight, job)
+	r.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	removed, err := r.client.LRem(ctx, d.processing, 1, d.data).Result()
+	if err != nil {
+		return fmt.Errorf("failed to ack job: %w", err)
+	}
+	if removed == 0 {
+		// Key rotation may have re-encrypted the job while it ran
+		return r.ackByID(ctx, d.processing, job.ID)
+	}
+	return nil
+}
+
+// ackByID removes the job with the given ID from a processing list, for a
+// job whose stored form changed after it was dequeued
+func (r *RedisQueue) ackByID(ctx context.Context, processing, jobID string) error {
+	items, err := r.client.LRange(ctx, processing, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("failed to ack job: %w", err)
+	}
+
+	for _, item := range items {
+		var stored types.Job
+		if err := decodeJob([]byte(item), &stored); err != nil || stored.ID != jobID {
+			continue
+		}
+		if err := r.client.LRem(ctx, processing, 1, item).Err(); err != nil {
+			return fmt.Errorf("failed to ack job: %w", err)
+		}
+		return nil
+	}
+	return nil
+}
+
+// recoverOwn requeues jobs an earlier process left in this consumer's
+// processing lists before it crashed
+func (r *RedisQueue) recoverOwn(ctx context.Context) {
+	for _, key := range r.keys {
+		requeueProcessingScript.Run(ctx, r.client, []string{r.processingKey(key), key})
+	}
+}
+
+// Recover requeues the jobs in processing lists of consumers that no longer
+// send worker pool heartbeats, ahead of the jobs waiting in their queues, and
+// returns how many were moved. A job that was running when its worker died
+// runs again.
+func (r *RedisQueue) Recover(ctx context.Context, heartbeats *HeartbeatRegistry) (int, error) {
+	live, err := heartbeats.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	alive := map[string]bool{r.consumer: true}
+	for _, hb := range live {
+		alive[hb.ID] = true
+	}
+
+	node, err := r.layout.scanNode(ctx, r.client)
+	if err != nil {
+		return 0, err
+	}
+
+	recovered := 0
+	var cursor uint64
+	for {
+		keys, next, err := node.Scan(ctx, cursor, r.layout.key(jobQueueKey)+"*"+processingInfix+"*", 100).Result()
+		if err != nil {
+			return recovered, fmt.Errorf("failed to scan processing lists: %w", err)
+		}
+
+		for _, key := range keys {
+			i := strings.LastIndex(key, processingInfix)
+			queueKey, consumer := key[:i], key[i+len(processingInfix):]
+			if alive[consumer] {
+				continue
+			}
+
+			moved, err := requeueProcessingScript.Run(ctx, r.client, []string{key, queueKey}).Int()
+			if err != nil {
+				return recovered, fmt.Errorf("failed to requeue jobs of consumer %s: %w", consumer, err)
+			}
+			recovered += moved
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return recovered, nil
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// ErrCircuitOpen is returned without contacting Redis while the circuit
+// breaker is open after repeated transient failures
+var ErrCircuitOpen = errors.New("queue unavailable: circuit breaker open")
+
+// ResilienceOptions controls retries and the circuit breaker of a ResilientQueue
+type ResilienceOptions struct {
+	Attempts         int           // Tries per operation, 1 disables retries
+	Backoff          time.Duration // Delay before the first retry, doubled per attempt with full jitter
+	BreakerThreshold int           // Consecutive failed operations that open the circuit, 0 disables the breaker
+	BreakerCooldown  time.Duration // How long the open circuit fails fast before a trial operation is let through
+}
+
+// ResilientQueue wraps a Queue so enqueues and stats reads ride out brief
+// Redis outages. Transient errors (timeouts, dropped connections, failovers)
+// are retried with jittered exponential backoff, and once operations keep
+// failing the circuit opens and they fail fast with ErrCircuitOpen until the
+// cooldown lets a trial through. An enqueue whose reply was lost may have
+// been stored, so a retry can enqueue the job twice.
+type ResilientQueue struct {
+	Queue
+	opts   ResilienceOptions
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	failures int       // Consecutive operations that failed after all retries
+	openedAt time.Time // Zero while the circuit is closed
+	probing  bool      // A trial operation is running on the half-open circuit
+}
+
+// NewResilientQueue wraps inner with retries and a circuit breaker
+func NewResilientQueue(inner Queue, opts ResilienceOptions, logger *zap.Logger) *ResilientQueue {
+	if opts.Attempts < 1 {
+		opts.Attempts = 1
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 100 * time.Millisecond
+	}
+	if opts.BreakerCooldown <= 0 {
+		opts.BreakerCooldown = 10 * time.Second
+	}
+
+	return &ResilientQueue{
+		Queue:  inner,
+		opts:   opts,
+		logger: logger,
+	}
+}
+
+// Enqueue adds the job, retrying transient failures
+func (r *ResilientQueue) Enqueue(ctx context.Context, job *types.Job) error {
+	return r.do(ctx, "enqueue", func() error {
+		return r.Queue.Enqueue(ctx, job)
+	})
+}
+
+// Size returns the queue size, retrying transient failures
+func (r *ResilientQueue) Size(ctx context.Context) (int, error) {
+	var size int
+	err := r.do(ctx, "size", func() error {
+		var err error
+		size, err = r.Queue.Size(ctx)
+		return err
+	})
+	return size, err
+}
+
+// GetStats returns the wrapped queue's stats, retrying transient failures
+func (r *ResilientQueue) GetStats(ctx context.Context) (*QueueStats, error) {
+	provider, ok := r.Queue.(StatsProvider)
+	if !ok {
+		return nil, fmt.Errorf("queue does not keep stats")
+	}
+
+	var stats *QueueStats
+	err := r.do(ctx, "stats", func() error {
+		var err error
+		stats, err = provider.GetStats(ctx)
+		return err
+	})
+	return stats, err
+}
+
+// do runs op through the circuit breaker, retrying transient errors
+func (r *ResilientQueue) do(ctx context.Context, name string, op func() error) error {
+	if !r.allow() {
+		return ErrCircuitOpen
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = op()
+		if err == nil || !IsTransient(err) || attempt >= r.opts.Attempts {
+			break
+		}
+
+		delay := r.backoff(attempt)
+		r.logger.Warn("Transient queue error, retrying",
+			zap.String("operation", name),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			r.record(err)
+			return err
+		case <-time.After(delay):
+		}
+	}
+
+	r.record(err)
+	return err
+}
+
+// backoff returns a random delay up to Backoff doubled per failed attempt
+func (r *ResilientQueue) backoff(attempt int) time.Duration {
+	ceiling := r.opts.Backoff << (attempt - 1)
+	return time.Duration(rand.Int63n(int64(ceiling))) + 1
+}
+
+// allow reports whether an operation may run: always while the circuit is
+// closed, and for a single trial once an open circuit has cooled down
+func (r *ResilientQueue) allow() bool {
+	if r.opts.BreakerThreshold <= 0 {
+		return true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.openedAt.IsZero() {
+		return true
+	}
+	if r.probing || time.Since(r.openedAt) < r.opts.BreakerCooldown {
+		return false
+	}
+	r.probing = true
+	return true
+}
+
+// record updates the breaker with an operation's outcome; only transient
+// errors count as failures, so invalid jobs don't open the circuit
+func (r *ResilientQueue) record(err error) {
+	if r.opts.BreakerThreshold <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wasOpen := !r.openedAt.IsZero()
+	r.probing = false
+
+	if err == nil || !IsTransient(err) {
+		r.failures = 0
+		if wasOpen {
+			r.openedAt = time.Time{}
+			r.logger.Info("Queue circuit breaker closed")
+		}
+		return
+	}
+
+	r.failures++
+	if wasOpen || r.failures >= r.opts.BreakerThreshold {
+		r.openedAt = time.Now()
+		if !wasOpen {
+			r.logger.Warn("Queue circuit breaker opened",
+				zap.Int("consecutive_failures", r.failures),
+				zap.Duration("cooldown", r.opts.BreakerCooldown),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// IsTransient reports whether err is a network failure, timeout or Redis
+// failover condition that is likely to pass when the operation is retried
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var redisErr redis.Error
+	if errors.As(err, &redisErr) {
+		for _, prefix := range []string{"LOADING ", "READONLY ", "MASTERDOWN ", "CLUSTERDOWN ", "TRYAGAIN "} {
+			if strings.HasPrefix(redisErr.Error(), prefix) {
+				return true
+			}
+		}
+		return redisErr.Error() == "ERR max number of clients reached"
+	}
+
+	return strings.Contains(err.Error(), "redis: connection pool timeout")
+}
@@ -0,0 +1,58 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+)
+
+const resultCacheKeyPrefix = "result_cache:" // Redis string per job type and cache key holding a completed result
+
+// ResultCache keeps completed results of idempotent handlers in Redis so
+// every worker reuses them; it implements job.ResultCache
+type ResultCache struct {
+	client redis.Cmdable
+	layout *Layout
+}
+
+// NewResultCache creates a Redis-backed result cache
+func NewResultCache(client redis.Cmdable, layout *Layout) *ResultCache {
+	return &ResultCache{client: client, layout: layout}
+}
+
+func (l *Layout) resultCacheKey(jobType, key string) string {
+	return l.key(resultCacheKeyPrefix + jobType + ":" + key)
+}
+
+// Get returns the cached result, or nil when there is none
+func (c *ResultCache) Get(ctx context.Context, jobType, key string) (*types.JobResult, error) {
+	data, err := c.client.Get(ctx, c.layout.resultCacheKey(jobType, key)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached result: %w", err)
+	}
+
+	var result types.JobResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cached result: %w", err)
+	}
+	return &result, nil
+}
+
+// Put caches a completed result for ttl
+func (c *ResultCache) Put(ctx context.Context, jobType, key string, result *types.JobResult, ttl time.Duration) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cached result: %w", err)
+	}
+	if err := c.client.Set(ctx, c.layout.resultCacheKey(jobType, key), data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to cache result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	resultKeyPrefix = "results:"      // Redis string per job ID holding its latest result, expires after the TTL
+	resultIndexKey  = "results:index" // Redis sorted set of job IDs scored by completion time
+)
+
+// ResultRecord is a persisted JobResult with the job details needed to query it
+type ResultRecord struct {
+	types.JobResult
+	Type     string `json:"type"`
+	Attempts int    `json:"attempts"`
+}
+
+// ResultFilter narrows a result query; zero values match everything
+type ResultFilter struct {
+	Type        string
+	Status      types.JobStatus
+	Fingerprint string // Matches results whose error fingerprint equals this
+	Since       time.Time
+	Limit       int
+}
+
+// ResultStore keeps the latest result of each job for a limited time
+type ResultStore struct {
+	client redis.Cmdable
+	layout *Layout
+	ttl    time.Duration
+}
+
+// NewResultStore creates a result store; results expire after ttl
+func NewResultStore(client redis.Cmdable, layout *Layout, ttl time.Duration) *ResultStore {
+	return &ResultStore{
+		client: client,
+		layout: layout,
+		ttl:    ttl,
+	}
+}
+
+// Save stores the result, replacing any earlier attempt of the same job
+func (s *ResultStore) Save(ctx context.Context, job *types.Job, result *types.JobResult) error {
+	data, err := json.Marshal(ResultRecord{
+		JobResult: *result,
+		Type:      job.Type,
+		Attempts:  job.Attempts,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal job result: %w", err)
+	}
+
+	completedAt := result.CompletedAt
+	if completedAt.IsZero() {
+		completedAt = time.Now()
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, s.layout.key(resultKeyPrefix)+job.ID, data, s.ttl)
+	pipe.ZAdd(ctx, s.layout.key(resultIndexKey), &redis.Z{Score: float64(completedAt.UnixNano()), Member: job.ID})
+
+	// Index entries older than the TTL point at expired results
+	cutoff := time.Now().Add(-s.ttl).UnixNano()
+	pipe.ZRemRangeByScore(ctx, s.layout.key(resultIndexKey), "-inf", "("+strconv.FormatInt(cutoff, 10))
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save job result: %w", err)
+	}
+	return nil
+}
+
+// Get returns the stored result for a job, or nil when none is kept
+func (s *ResultStore) Get(ctx context.Context, jobID string) (*ResultRecord, error) {
+	data, err := s.client.Get(ctx, s.layout.key(resultKeyPrefix)+jobID).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job result: %w", err)
+	}
+
+	var record ResultRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job result: %w", err)
+	}
+	return &record, nil
+}
+
+// Query returns results matching the filter, newest first
+func (s *ResultStore) Query(ctx context.Context, filter ResultFilter) ([]*ResultRecord, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 100
+	}
+
+	min := "-inf"
+	if !filter.Since.IsZero() {
+		min = strconv.FormatInt(filter.Since.UnixNano(), 10)
+	}
+
+	records := make([]*ResultRecord, 0, filter.Limit)
+	const batchSize = 200
+
+	// Page through the index since type and status are filtered client side
+	for offset := int64(0); len(records) < filter.Limit; offset += batchSize {
+		ids, err := s.client.ZRevRangeByScore(ctx, s.layout.key(resultIndexKey), &redis.ZRangeBy{
+			Min:    min,
+			Max:    "+inf",
+			Offset: offset,
+			Count:  batchSize,
+		}).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to query job results: %w", err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		keys := make([]string, len(ids))
+		for i, id := range ids {
+			keys[i] = s.layout.key(resultKeyPrefix) + id
+		}
+
+		values, err := s.client.MGet(ctx, keys...).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load job results: %w", err)
+		}
+
+		for _, value := range values {
+			data, ok := value.(string)
+			if !ok {
+				continue // Expired
+			}
+
+			var record ResultRecord
+			if err := json.Unmarshal([]byte(data), &record); err != nil {
+				continue
+			}
+			if filter.Type != "" && record.Type != filter.Type {
+				continue
+			}
+			if filter.Status != "" && record.Status != filter.Status {
+				continue
+			}
+			if filter.Fingerprint != "" && record.ErrorFingerprint != filter.Fingerprint {
+				continue
+			}
+
+			records = append(records, &record)
+			if len(records) == filter.Limit {
+				break
+			}
+		}
+	}
+
+	return records, nil
+}
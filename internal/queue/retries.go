@@ -0,0 +1,131 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	pendingRetriesKey = "retries:pending" // Redis hash of job ID → job waiting out its retry backoff
+)
+
+// PendingRetry is a failed job waiting for its backoff before being requeued
+type PendingRetry struct {
+	JobID    string    `json:"job_id"`
+	Type     string    `json:"type"`
+	Attempts int       `json:"attempts"`
+	DueAt    time.Time `json:"due_at"`
+	WorkerID string    `json:"worker_id"`
+}
+
+// RetryTypeSummary aggregates pending retries for one job type
+type RetryTypeSummary struct {
+	Count     int       `json:"count"`
+	NextDueAt time.Time `json:"next_due_at"`
+	LastDueAt time.Time `json:"last_due_at"`
+}
+
+// RetrySummary tells an empty queue apart from one whose jobs are all backing off
+type RetrySummary struct {
+	Total   int                         `json:"total"`
+	Overdue int                         `json:"overdue"` // Past due, e.g. the worker holding them died
+	ByType  map[string]RetryTypeSummary `json:"by_type"`
+}
+
+// RetryTracker records jobs waiting in retry backoff so they are visible in stats
+type RetryTracker struct {
+	client redis.Cmdable
+	layout *Layout
+}
+
+// NewRetryTracker creates a retry tracker
+func NewRetryTracker(client redis.Cmdable, layout *Layout) *RetryTracker {
+	return &RetryTracker{client: client, layout: layout}
+}
+
+// Track marks the job as waiting for a retry due at dueAt
+func (t *RetryTracker) Track(ctx context.Context, job *types.Job, dueAt time.Time, workerID string) error {
+	data, err := json.Marshal(PendingRetry{
+		JobID:    job.ID,
+		Type:     job.Type,
+		Attempts: job.Attempts,
+		DueAt:    dueAt.UTC(),
+		WorkerID: workerID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal pending retry: %w", err)
+	}
+
+	if err := t.client.HSet(ctx, t.layout.key(pendingRetriesKey), job.ID, data).Err(); err != nil {
+		return fmt.Errorf("failed to track pending retry: %w", err)
+	}
+	return nil
+}
+
+// Untrack removes the job once it is back on the queue
+func (t *RetryTracker) Untrack(ctx context.Context, jobID string) error {
+	if err := t.client.HDel(ctx, t.layout.key(pendingRetriesKey), jobID).Err(); err != nil {
+		return fmt.Errorf("failed to untrack pending retry: %w", err)
+	}
+	return nil
+}
+
+// List returns pending retries ordered by due time
+func (t *RetryTracker) List(ctx context.Context) ([]PendingRetry, error) {
+	values, err := t.client.HGetAll(ctx, t.layout.key(pendingRetriesKey)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending retries: %w", err)
+	}
+
+	retries := make([]PendingRetry, 0, len(values))
+	for _, value := range values {
+		var retry PendingRetry
+		if err := json.Unmarshal([]byte(value), &retry); err != nil {
+			continue
+		}
+		retries = append(retries, retry)
+	}
+
+	sort.Slice(retries, func(i, j int) bool {
+		return retries[i].DueAt.Before(retries[j].DueAt)
+	})
+
+	return retries, nil
+}
+
+// Summary aggregates pending retries per job type
+func (t *RetryTracker) Summary(ctx context.Context) (*RetrySummary, error) {
+	retries, err := t.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	summary := &RetrySummary{
+		Total:  len(retries),
+		ByType: make(map[string]RetryTypeSummary),
+	}
+
+	// retries are sorted by due time, so the first seen per type is the next due
+	for _, retry := range retries {
+		if retry.DueAt.Before(now) {
+			summary.Overdue++
+		}
+
+		typeSummary, exists := summary.ByType[retry.Type]
+		if !exists {
+			typeSummary.NextDueAt = retry.DueAt
+		}
+		typeSummary.Count++
+		typeSummary.LastDueAt = retry.DueAt
+		summary.ByType[retry.Type] = typeSummary
+	}
+
+	return summary, nil
+}
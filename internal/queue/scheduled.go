@@ -3,32 +3,96 @@ package queue
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
 	"github.com/aneeshsunganahalli/Gopher/pkg/types"
 	"github.com/go-redis/redis/v8"
 )
 
 const (
-	scheduledJobsKey      = "scheduled_jobs"  // Redis sorted set storing scheduled jobs
-	scheduledJobsStatsKey = "scheduled_stats" // Redis hash storing scheduled job stats
+	scheduledJobsKey          = "scheduled_jobs"     // Redis sorted set storing scheduled jobs
+	scheduledJobsStatsKey     = "scheduled_stats"    // Redis hash storing scheduled job stats
+	scheduleRunsKeyPrefix     = "schedule:runs:"     // Sorted set per schedule of spawned job IDs, scored by enqueue time
+	scheduleOutcomesKeyPrefix = "schedule:outcomes:" // Hash per schedule of job ID → JSON outcome of a finished run
+
+	maxScheduleRuns = 1000 // Runs kept per schedule; older entries are trimmed
 )
 
+// recordRunScript adds a run and trims the oldest runs with their outcomes.
+// KEYS[1] runs set, KEYS[2] outcomes hash; ARGV[1] score, ARGV[2] job ID,
+// ARGV[3] runs kept
+var recordRunScript = redis.NewScript(`
+redis.call('ZADD', KEYS[1], ARGV[1], ARGV[2])
+local stop = -tonumber(ARGV[3]) - 1
+local trimmed = redis.call('ZRANGE', KEYS[1], 0, stop)
+if #trimmed > 0 then
+	redis.call('ZREMRANGEBYRANK', KEYS[1], 0, stop)
+	redis.call('HDEL', KEYS[2], unpack(trimmed))
+end
+return #trimmed
+`)
+
+// recordOutcomeScript stores a run's outcome unless the run was trimmed.
+// KEYS[1] runs set, KEYS[2] outcomes hash; ARGV[1] job ID, ARGV[2] outcome
+var recordOutcomeScript = redis.NewScript(`
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return 0
+end
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+return 1
+`)
+
+// ErrScheduleNotFound is returned when no recurring schedule has the given ID
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+// Schedules keeps recurring schedules and the runs they spawn.
+// ScheduledQueue keeps them in Redis; other backends provide theirs through
+// ScheduleBackend.
+type Schedules interface {
+	// ScheduleRecurring adds a schedule that runs job on the cron expression
+	ScheduleRecurring(ctx context.Context, job *types.Job, cronExpr string) error
+
+	// ProcessDueJobs enqueues the runs that are due and returns how many
+	ProcessDueJobs(ctx context.Context) (int, error)
+
+	// RecordOutcome stores how a job spawned by a schedule finished
+	RecordOutcome(ctx context.Context, finished *types.Job, result *types.JobResult) error
+
+	// Runs returns a schedule's most recent runs, newest first
+	Runs(ctx context.Context, id string, limit int) ([]types.ScheduleRun, error)
+
+	// List returns the stored schedules ordered by their next run
+	List(ctx context.Context) ([]types.ScheduledJob, error)
+
+	// SetPaused pauses or resumes a schedule
+	SetPaused(ctx context.Context, id string, paused bool) (*types.ScheduledJob, error)
+}
+
 // ScheduledQueue manages delayed and recurring jobs
 type ScheduledQueue struct {
-	client redis.Cmdable
-	queue  Queue // Reference to the main queue for moving due jobs
+	client  redis.Cmdable
+	layout  *Layout
+	queue   Queue // Reference to the main queue for moving due jobs
+	keyring *encryption.Keyring
 }
 
 // NewScheduledQueue creates a new scheduled job queue
-func NewScheduledQueue(client redis.Cmdable, queue Queue) *ScheduledQueue {
+func NewScheduledQueue(client redis.Cmdable, layout *Layout, queue Queue) *ScheduledQueue {
 	return &ScheduledQueue{
 		client: client,
+		layout: layout,
 		queue:  queue,
 	}
 }
 
+// SetKeyring enables payload encryption for stored scheduled jobs
+func (s *ScheduledQueue) SetKeyring(keyring *encryption.Keyring) {
+	s.keyring = keyring
+}
+
 // Schedule adds a job to be processed at a future time
 func (s *ScheduledQueue) Schedule(ctx context.Context, job *types.Job, executeAt time.Time) error {
 	if err := job.Validate(); err != nil {
@@ -60,8 +124,11 @@ func (s *ScheduledQueue) ScheduleRecurring(ctx context.Context, job *types.Job,
 	// Calculate next execution time
 	nextExec := schedule.Next(time.Now())
 
-	// Create scheduled job wrapper
+	// Create scheduled job wrapper; every run carries the schedule's ID
+	id := fmt.Sprintf("sched-%d", time.Now().UnixNano())
+	job.ScheduleID = id
 	scheduledJob := &types.ScheduledJob{
+		ID:             id,
 		Job:            job,
 		ExecuteAt:      nextExec,
 		Recurring:      true,
@@ -73,15 +140,22 @@ func (s *ScheduledQueue) ScheduleRecurring(ctx context.Context, job *types.Job,
 
 // addScheduledJob adds a job to the scheduled queue
 func (s *ScheduledQueue) addScheduledJob(ctx context.Context, scheduledJob *types.ScheduledJob) error {
+	sealed, err := s.layout.sealJob(scheduledJob.Job, s.keyring)
+	if err != nil {
+		return err
+	}
+	stored := *scheduledJob
+	stored.Job = sealed
+
 	// Serialize job
-	jobData, err := json.Marshal(scheduledJob)
+	jobData, err := json.Marshal(&stored)
 	if err != nil {
 		return fmt.Errorf("failed to marshal scheduled job: %w", err)
 	}
 
 	// Add to sorted set with score as Unix timestamp
 	score := float64(scheduledJob.ExecuteAt.Unix())
-	err = s.client.ZAdd(ctx, scheduledJobsKey, &redis.Z{
+	err = s.client.ZAdd(ctx, s.layout.key(scheduledJobsKey), &redis.Z{
 		Score:  score,
 		Member: jobData,
 	}).Err()
@@ -92,13 +166,13 @@ func (s *ScheduledQueue) addScheduledJob(ctx context.Context, scheduledJob *type
 
 	// Update stats
 	pipe := s.client.Pipeline()
-	pipe.HIncrBy(ctx, scheduledJobsStatsKey, "total", 1)
+	pipe.HIncrBy(ctx, s.layout.key(scheduledJobsStatsKey), "total", 1)
 	if scheduledJob.Recurring {
-		pipe.HIncrBy(ctx, scheduledJobsStatsKey, "recurring", 1)
+		pipe.HIncrBy(ctx, s.layout.key(scheduledJobsStatsKey), "recurring", 1)
 	} else {
-		pipe.HIncrBy(ctx, scheduledJobsStatsKey, "one_time", 1)
+		pipe.HIncrBy(ctx, s.layout.key(scheduledJobsStatsKey), "one_time", 1)
 	}
-	pipe.HIncrBy(ctx, scheduledJobsStatsKey, fmt.Sprintf("type:%s", scheduledJob.Job.Type), 1)
+	pipe.HIncrBy(ctx, s.layout.key(scheduledJobsStatsKey), fmt.Sprintf("type:%s", scheduledJob.Job.Type), 1)
 
 	_, err = pipe.Exec(ctx)
 	if err != nil {
@@ -113,7 +187,7 @@ func (s *ScheduledQueue) ProcessDueJobs(ctx context.Context) (int, error) {
 	now := time.Now().Unix()
 
 	// Get all jobs that are due
-	result := s.client.ZRangeByScore(ctx, scheduledJobsKey, &redis.ZRangeBy{
+	result := s.client.ZRangeByScore(ctx, s.layout.key(scheduledJobsKey), &redis.ZRangeBy{
 		Min: "0",
 		Max: fmt.Sprintf("%d", now),
 	})
@@ -131,13 +205,42 @@ func (s *ScheduledQueue) ProcessDueJobs(ctx context.Context) (int, error) {
 			continue
 		}
 
-		// Move to main queue
+		if err := openJob(scheduledJob.Job, s.keyring); err != nil {
+			continue
+		}
+
+		// Paused schedules skip this run and move on to the next one
+		if scheduledJob.Recurring && scheduledJob.Paused {
+			if s.client.ZRem(ctx, s.layout.key(scheduledJobsKey), jobData).Val() == 0 {
+				continue
+			}
+			if schedule, err := parseCronExpression(scheduledJob.CronExpression); err == nil {
+				scheduledJob.ExecuteAt = schedule.Next(time.Now())
+				s.addScheduledJob(ctx, &scheduledJob)
+			}
+			continue
+		}
+
+		// Claim the entry first so concurrent schedulers never enqueue the same run twice
+		if s.client.ZRem(ctx, s.layout.key(scheduledJobsKey), jobData).Val() == 0 {
+			continue
+		}
+
+		// Move to main queue, putting the entry back if that fails
+		if scheduledJob.Recurring && scheduledJob.Job.ScheduleID == "" {
+			scheduledJob.Job.ScheduleID = scheduledJob.ID // Schedules created before IDs were carried
+		}
 		if err := s.queue.Enqueue(ctx, scheduledJob.Job); err != nil {
+			s.client.ZAdd(ctx, s.layout.key(scheduledJobsKey), &redis.Z{
+				Score:  float64(scheduledJob.ExecuteAt.Unix()),
+				Member: jobData,
+			})
 			continue
 		}
 
-		// Remove from scheduled queue
-		s.client.ZRem(ctx, scheduledJobsKey, jobData)
+		if scheduledJob.Job.ScheduleID != "" {
+			s.recordRun(ctx, scheduledJob.Job)
+		}
 
 		// If recurring, schedule next execution
 		if scheduledJob.Recurring {
@@ -155,6 +258,7 @@ func (s *ScheduledQueue) ProcessDueJobs(ctx context.Context) (int, error) {
 
 				// Schedule next execution
 				nextScheduledJob := types.ScheduledJob{
+					ID:             scheduledJob.ID,
 					Job:            &nextJob,
 					ExecuteAt:      nextExec,
 					Recurring:      true,
@@ -165,7 +269,7 @@ func (s *ScheduledQueue) ProcessDueJobs(ctx context.Context) (int, error) {
 			}
 		} else {
 			// Update stats for one-time jobs
-			s.client.HIncrBy(ctx, scheduledJobsStatsKey, "one_time", -1)
+			s.client.HIncrBy(ctx, s.layout.key(scheduledJobsStatsKey), "one_time", -1)
 		}
 
 		processedCount++
@@ -174,9 +278,94 @@ func (s *ScheduledQueue) ProcessDueJobs(ctx context.Context) (int, error) {
 	return processedCount, nil
 }
 
+// scheduleRunKeys returns the run index and outcomes keys of a schedule
+func (l *Layout) scheduleRunKeys(id string) []string {
+	return []string{l.key(scheduleRunsKeyPrefix) + id, l.key(scheduleOutcomesKeyPrefix) + id}
+}
+
+// recordRun adds a spawned job to its schedule's run index
+func (s *ScheduledQueue) recordRun(ctx context.Context, job *types.Job) {
+	recordRunScript.Run(ctx, s.client, s.layout.scheduleRunKeys(job.ScheduleID),
+		time.Now().UnixNano(), job.ID, maxScheduleRuns)
+}
+
+// RecordOutcome stores how a job spawned by a schedule finished. Jobs that
+// don't belong to a schedule, or whose run has been trimmed, are ignored.
+func (s *ScheduledQueue) RecordOutcome(ctx context.Context, finished *types.Job, result *types.JobResult) error {
+	if finished.ScheduleID == "" {
+		return nil
+	}
+
+	outcome := types.ScheduleRun{
+		Status:   result.Status,
+		Attempts: finished.Attempts,
+		Duration: result.Duration,
+		Error:    result.Error,
+	}
+	finishedAt := result.CompletedAt
+	if finishedAt.IsZero() {
+		finishedAt = time.Now().UTC()
+	}
+	outcome.FinishedAt = &finishedAt
+
+	data, err := json.Marshal(outcome)
+	if err != nil {
+		return fmt.Errorf("failed to marshal schedule run outcome: %w", err)
+	}
+
+	err = recordOutcomeScript.Run(ctx, s.client, s.layout.scheduleRunKeys(finished.ScheduleID), finished.ID, data).Err()
+	if err != nil {
+		return fmt.Errorf("failed to record schedule run outcome: %w", err)
+	}
+	return nil
+}
+
+// Runs returns the most recent jobs spawned by a schedule with their
+// outcomes, newest first. Runs that haven't finished have no status.
+func (s *ScheduledQueue) Runs(ctx context.Context, id string, limit int) ([]types.ScheduleRun, error) {
+	keys := s.layout.scheduleRunKeys(id)
+
+	entries, err := s.client.ZRevRangeWithScores(ctx, keys[0], 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedule runs: %w", err)
+	}
+	if len(entries) == 0 {
+		return []types.ScheduleRun{}, nil
+	}
+
+	jobIDs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if jobID, ok := entry.Member.(string); ok {
+			jobIDs = append(jobIDs, jobID)
+		}
+	}
+	outcomes, err := s.client.HMGet(ctx, keys[1], jobIDs...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule run outcomes: %w", err)
+	}
+
+	runs := make([]types.ScheduleRun, 0, len(entries))
+	for _, entry := range entries {
+		jobID, ok := entry.Member.(string)
+		if !ok {
+			continue
+		}
+
+		var run types.ScheduleRun
+		if data, ok := outcomes[len(runs)].(string); ok {
+			json.Unmarshal([]byte(data), &run)
+		}
+		run.JobID = jobID
+		run.EnqueuedAt = time.Unix(0, int64(entry.Score)).UTC()
+		runs = append(runs, run)
+	}
+
+	return runs, nil
+}
+
 // Size returns the number of scheduled jobs
 func (s *ScheduledQueue) Size(ctx context.Context) (int, error) {
-	result := s.client.ZCard(ctx, scheduledJobsKey)
+	result := s.client.ZCard(ctx, s.layout.key(scheduledJobsKey))
 	if err := result.Err(); err != nil {
 		return 0, fmt.Errorf("failed to get scheduled queue size: %w", err)
 	}
@@ -184,6 +373,72 @@ func (s *ScheduledQueue) Size(ctx context.Context) (int, error) {
 	return int(result.Val()), nil
 }
 
+// List returns all scheduled jobs ordered by execution time, with payloads decrypted
+func (s *ScheduledQueue) List(ctx context.Context) ([]types.ScheduledJob, error) {
+	members, err := s.client.ZRange(ctx, s.layout.key(scheduledJobsKey), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled jobs: %w", err)
+	}
+
+	scheduled := make([]types.ScheduledJob, 0, len(members))
+	for _, member := range members {
+		var scheduledJob types.ScheduledJob
+		if err := json.Unmarshal([]byte(member), &scheduledJob); err != nil || scheduledJob.Job == nil {
+			continue
+		}
+		if err := openJob(scheduledJob.Job, s.keyring); err != nil {
+			continue
+		}
+		scheduled = append(scheduled, scheduledJob)
+	}
+
+	return scheduled, nil
+}
+
+// SetPaused pauses or resumes a recurring schedule without changing when it next runs
+func (s *ScheduledQueue) SetPaused(ctx context.Context, id string, paused bool) (*types.ScheduledJob, error) {
+	members, err := s.client.ZRange(ctx, s.layout.key(scheduledJobsKey), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled jobs: %w", err)
+	}
+
+	for _, member := range members {
+		var scheduledJob types.ScheduledJob
+		if err := json.Unmarshal([]byte(member), &scheduledJob); err != nil || scheduledJob.ID != id {
+			continue
+		}
+
+		scheduledJob.Paused = paused
+		scheduledJob.PausedAt = nil
+		if paused {
+			now := time.Now().UTC()
+			scheduledJob.PausedAt = &now
+		}
+
+		// The stored job stays sealed; only the schedule flags change
+		data, err := json.Marshal(&scheduledJob)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal scheduled job: %w", err)
+		}
+
+		n, err := replaceSetMemberScript.Run(ctx, s.client, []string{s.layout.key(scheduledJobsKey)}, member, data).Int()
+		if err != nil {
+			return nil, fmt.Errorf("failed to update schedule: %w", err)
+		}
+		if n == 0 {
+			// The entry was just moved by the scheduler; callers may retry
+			return nil, fmt.Errorf("schedule %s changed concurrently, try again", id)
+		}
+
+		if err := openJob(scheduledJob.Job, s.keyring); err != nil {
+			return nil, fmt.Errorf("failed to decrypt job: %w", err)
+		}
+		return &scheduledJob, nil
+	}
+
+	return nil, ErrScheduleNotFound
+}
+
 // parseCronExpression parses a cron expression (stub - would use a cron library)
 func parseCronExpression(expr string) (CronSchedule, error) {
 	// This is a simplified stub - in a real implementation, you'd use a proper cron library
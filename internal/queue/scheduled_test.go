@@ -0,0 +1,245 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+)
+
+// newTestScheduledQueue returns a scheduled queue that moves due jobs onto
+// a memory queue
+func newTestScheduledQueue(t *testing.T) (*ScheduledQueue, *MemoryQueue) {
+	t.Helper()
+	q := newTestRedisQueue(t, miniredis.RunT(t), RedisOptions{})
+	jobs := NewMemoryQueue(10 * time.Millisecond)
+	return NewScheduledQueue(q.Client(), q.Layout(), jobs), jobs
+}
+
+// makeSchedulesDue moves every scheduled entry's run time into the past
+func makeSchedulesDue(t *testing.T, s *ScheduledQueue) {
+	t.Helper()
+	ctx := context.Background()
+	members, err := s.client.ZRange(ctx, s.layout.key(scheduledJobsKey), 0, -1).Result()
+	if err != nil {
+		t.Fatalf("ZRange: %v", err)
+	}
+	for _, member := range members {
+		s.client.ZAdd(ctx, s.layout.key(scheduledJobsKey), &redis.Z{Score: 0, Member: member})
+	}
+}
+
+func TestScheduledQueueProcessDueJobs(t *testing.T) {
+	tests := []struct {
+		name string
+		// schedule adds the job under test
+		schedule      func(t *testing.T, s *ScheduledQueue, job *types.Job)
+		wantProcessed int
+		wantLeft      int  // Entries still scheduled afterwards
+		wantSchedule  bool // Whether the enqueued job carries a schedule ID
+	}{
+		{
+			name: "one-time job due",
+			schedule: func(t *testing.T, s *ScheduledQueue, job *types.Job) {
+				if err := s.Schedule(context.Background(), job, time.Now().Add(-time.Second)); err != nil {
+					t.Fatalf("Schedule: %v", err)
+				}
+			},
+			wantProcessed: 1,
+			wantLeft:      0,
+		},
+		{
+			name: "one-time job not yet due",
+			schedule: func(t *testing.T, s *ScheduledQueue, job *types.Job) {
+				if err := s.Schedule(context.Background(), job, time.Now().Add(time.Hour)); err != nil {
+					t.Fatalf("Schedule: %v", err)
+				}
+			},
+			wantProcessed: 0,
+			wantLeft:      1,
+		},
+		{
+			name: "recurring job is rescheduled",
+			schedule: func(t *testing.T, s *ScheduledQueue, job *types.Job) {
+				if err := s.ScheduleRecurring(context.Background(), job, "* * * * *"); err != nil {
+					t.Fatalf("ScheduleRecurring: %v", err)
+				}
+				makeSchedulesDue(t, s)
+			},
+			wantProcessed: 1,
+			wantLeft:      1,
+			wantSchedule:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, jobs := newTestScheduledQueue(t)
+
+			job := types.NewJob("report", json.RawMessage(`{}`), 0)
+			tt.schedule(t, s, job)
+
+			processed, err := s.ProcessDueJobs(ctx)
+			if err != nil {
+				t.Fatalf("ProcessDueJobs: %v", err)
+			}
+			if processed != tt.wantProcessed {
+				t.Fatalf("ProcessDueJobs = %d, want %d", processed, tt.wantProcessed)
+			}
+			if left, _ := s.Size(ctx); left != tt.wantLeft {
+				t.Fatalf("%d entries left, want %d", left, tt.wantLeft)
+			}
+			if size, _ := jobs.Size(ctx); size != tt.wantProcessed {
+				t.Fatalf("%d jobs enqueued, want %d", size, tt.wantProcessed)
+			}
+
+			// Running again right away enqueues nothing more
+			if again, err := s.ProcessDueJobs(ctx); err != nil || again != 0 {
+				t.Fatalf("second ProcessDueJobs = %d, %v; want 0", again, err)
+			}
+
+			if tt.wantProcessed == 0 {
+				return
+			}
+			got, _ := jobs.Dequeue(ctx)
+			if got.ID != job.ID {
+				t.Fatalf("enqueued job %s, want %s", got.ID, job.ID)
+			}
+			if (got.ScheduleID != "") != tt.wantSchedule {
+				t.Fatalf("ScheduleID = %q, want one: %v", got.ScheduleID, tt.wantSchedule)
+			}
+			if tt.wantSchedule {
+				// The next run is a new job of the same schedule
+				next, _ := s.List(ctx)
+				if len(next) != 1 || next[0].ID != got.ScheduleID || next[0].Job.ID == job.ID {
+					t.Fatalf("next run = %+v, want a new job of schedule %s", next, got.ScheduleID)
+				}
+			}
+		})
+	}
+}
+
+func TestScheduledQueueRuns(t *testing.T) {
+	ctx := context.Background()
+	s, jobs := newTestScheduledQueue(t)
+
+	if err := s.ScheduleRecurring(ctx, types.NewJob("report", json.RawMessage(`{}`), 0), "* * * * *"); err != nil {
+		t.Fatalf("ScheduleRecurring: %v", err)
+	}
+
+	// Three runs: completed, failed, and one still running
+	results := []*types.JobResult{
+		{Status: types.StatusCompleted, Duration: "1s"},
+		{Status: types.StatusFailed, Error: "boom"},
+		nil,
+	}
+	var ran []*types.Job
+	for _, result := range results {
+		makeSchedulesDue(t, s)
+		if n, err := s.ProcessDueJobs(ctx); err != nil || n != 1 {
+			t.Fatalf("ProcessDueJobs = %d, %v; want 1", n, err)
+		}
+		job, _ := jobs.Dequeue(ctx)
+		ran = append(ran, job)
+		if result != nil {
+			result.JobID = job.ID
+			if err := s.RecordOutcome(ctx, job, result); err != nil {
+				t.Fatalf("RecordOutcome: %v", err)
+			}
+		}
+	}
+
+	// Jobs that don't belong to a schedule are ignored
+	if err := s.RecordOutcome(ctx, types.NewJob("report", json.RawMessage(`{}`), 0), &types.JobResult{Status: types.StatusCompleted}); err != nil {
+		t.Fatalf("RecordOutcome of an unscheduled job: %v", err)
+	}
+
+	runs, err := s.Runs(ctx, ran[0].ScheduleID, 10)
+	if err != nil {
+		t.Fatalf("Runs: %v", err)
+	}
+
+	tests := []struct {
+		run        int // Index into ran
+		wantStatus types.JobStatus
+		wantError  string
+	}{
+		{run: 2, wantStatus: ""},
+		{run: 1, wantStatus: types.StatusFailed, wantError: "boom"},
+		{run: 0, wantStatus: types.StatusCompleted},
+	}
+	if len(runs) != len(tests) {
+		t.Fatalf("Runs returned %d runs, want %d", len(runs), len(tests))
+	}
+	for i, tt := range tests {
+		got := runs[i]
+		if got.JobID != ran[tt.run].ID || got.Status != tt.wantStatus || got.Error != tt.wantError {
+			t.Errorf("run %d = %+v, want job %s with status %q", i, got, ran[tt.run].ID, tt.wantStatus)
+		}
+		if (got.FinishedAt != nil) != (tt.wantStatus != "") {
+			t.Errorf("run %d FinishedAt = %v, want one only once finished", i, got.FinishedAt)
+		}
+	}
+
+	limited, err := s.Runs(ctx, ran[0].ScheduleID, 1)
+	if err != nil || len(limited) != 1 || limited[0].JobID != ran[2].ID {
+		t.Fatalf("Runs with limit 1 = %+v, %v; want the newest run", limited, err)
+	}
+}
+
+func TestScheduledQueueSetPaused(t *testing.T) {
+	tests := []struct {
+		name         string
+		pause        bool
+		wantEnqueued int
+	}{
+		{name: "paused schedule skips its run", pause: true, wantEnqueued: 0},
+		{name: "resumed schedule runs", pause: false, wantEnqueued: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, jobs := newTestScheduledQueue(t)
+
+			job := types.NewJob("report", json.RawMessage(`{}`), 0)
+			if err := s.ScheduleRecurring(ctx, job, "* * * * *"); err != nil {
+				t.Fatalf("ScheduleRecurring: %v", err)
+			}
+			if _, err := s.SetPaused(ctx, job.ScheduleID, true); err != nil {
+				t.Fatalf("SetPaused: %v", err)
+			}
+			entry, err := s.SetPaused(ctx, job.ScheduleID, tt.pause)
+			if err != nil {
+				t.Fatalf("SetPaused: %v", err)
+			}
+			if entry.Paused != tt.pause || (entry.PausedAt != nil) != tt.pause {
+				t.Fatalf("schedule = %+v, want paused: %v", entry, tt.pause)
+			}
+
+			makeSchedulesDue(t, s)
+			if _, err := s.ProcessDueJobs(ctx); err != nil {
+				t.Fatalf("ProcessDueJobs: %v", err)
+			}
+			if size, _ := jobs.Size(ctx); size != tt.wantEnqueued {
+				t.Fatalf("%d jobs enqueued, want %d", size, tt.wantEnqueued)
+			}
+
+			// Either way the schedule stays, moved on to its next run
+			listed, _ := s.List(ctx)
+			if len(listed) != 1 || listed[0].ID != job.ScheduleID || listed[0].Paused != tt.pause || !listed[0].ExecuteAt.After(time.Now()) {
+				t.Fatalf("schedules = %+v, want %s due in the future", listed, job.ScheduleID)
+			}
+		})
+	}
+
+	s, _ := newTestScheduledQueue(t)
+	if _, err := s.SetPaused(context.Background(), "sched-missing", true); err != ErrScheduleNotFound {
+		t.Fatalf("SetPaused of an unknown schedule = %v, want %v", err, ErrScheduleNotFound)
+	}
+}
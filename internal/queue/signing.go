@@ -0,0 +1,127 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+// ErrUntrusted is returned by Signing.Verify for jobs whose signature is missing
+// or doesn't verify
+var ErrUntrusted = errors.New("untrusted job")
+
+// Signing turns job signatures on. Processes holding a signing key sign
+// every job they store; every process with a signer verifies dequeued jobs
+// in Verify. The zero value leaves signing off.
+type Signing struct {
+	Signer        *encryption.Signer
+	AllowUnsigned bool // Lets jobs enqueued before signing was turned on still run
+}
+
+// signedFields is what a job signature covers: the fields fixed at enqueue.
+// Attempts, timestamps, metadata and the chain's previous output change as
+// the job moves through workers and the DLQ, so they aren't signed.
+type signedFields struct {
+	ID              string               `json:"id"`
+	Type            string               `json:"type"`
+	Payload         json.RawMessage      `json:"payload"`
+	MaxRetries      int                  `json:"max_retries"`
+	CreatedAt       int64                `json:"created_at"`
+	Queue           string               `json:"queue"`
+	Timeout         int64                `json:"timeout_ns"`
+	ExpiresAt       int64                `json:"expires_at"`
+	ScheduleID      string               `json:"schedule_id"`
+	DependsOn       []string             `json:"depends_on"`
+	OnParentFailure string               `json:"on_parent_failure"`
+	ChainID         string               `json:"chain_id"`
+	WorkflowID      string               `json:"workflow_id"`
+	WorkflowNode    string               `json:"workflow_node"`
+	PayloadVersion  int                  `json:"payload_version,omitempty"`
+	Backoff         *types.BackoffPolicy `json:"backoff,omitempty"`
+}
+
+// signingMessage returns the bytes a job's signature is computed over. The
+// payload must be plaintext; marshalling compacts it, so the message is the
+// same whichever codec or compression the job went through.
+func signingMessage(job *types.Job) ([]byte, error) {
+	fields := signedFields{
+		ID:              job.ID,
+		Type:            job.Type,
+		Payload:         job.Payload,
+		MaxRetries:      job.MaxRetries,
+		CreatedAt:       unixNano(job.CreatedAt),
+		Queue:           job.Queue,
+		Timeout:         int64(job.Timeout),
+		ScheduleID:      job.ScheduleID,
+		DependsOn:       job.DependsOn,
+		OnParentFailure: job.OnParentFailure,
+		ChainID:         job.ChainID,
+		WorkflowID:      job.WorkflowID,
+		WorkflowNode:    job.WorkflowNode,
+		PayloadVersion:  job.PayloadVersion,
+		Backoff:         job.Backoff,
+	}
+	if job.ExpiresAt != nil {
+		fields.ExpiresAt = job.ExpiresAt.UnixNano()
+	}
+	if len(fields.Payload) == 0 {
+		fields.Payload = json.RawMessage("null")
+	}
+
+	message, err := json.Marshal(&fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build signing message: %w", err)
+	}
+	return message, nil
+}
+
+// sign returns a copy of the job signed with the active signing key. Jobs
+// are left as they are when this process can't sign, e.g. a worker holding
+// only an Ed25519 public key re-enqueuing a retry, which keeps the
+// producer's signature, or when the payload is already sealed.
+func (s Signing) sign(job *types.Job) (*types.Job, error) {
+	if s.Signer == nil || !s.Signer.CanSign() || job.KeyID != "" || job.Encoding != "" {
+		return job, nil
+	}
+
+	message, err := signingMessage(job)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := s.Signer.Sign(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign job: %w", err)
+	}
+
+	signed := *job
+	signed.Signature = signature
+	return &signed, nil
+}
+
+// Verify checks the signature of a dequeued job, whose payload must
+// already be opened. It returns nil when signing is off, and an
+// ErrUntrusted error for jobs that are unsigned, unless unsigned jobs are
+// allowed, or signed by a key this process doesn't trust.
+func (s Signing) Verify(job *types.Job) error {
+	if s.Signer == nil {
+		return nil
+	}
+	if job.Signature == "" {
+		if s.AllowUnsigned {
+			return nil
+		}
+		return fmt.Errorf("%w: job is not signed", ErrUntrusted)
+	}
+
+	message, err := signingMessage(job)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUntrusted, err)
+	}
+	if err := s.Signer.Verify(message, job.Signature); err != nil {
+		return fmt.Errorf("%w: %v", ErrUntrusted, err)
+	}
+	return nil
+}
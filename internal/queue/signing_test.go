@@ -0,0 +1,131 @@
+package queue
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+func testSigner(t *testing.T, secret string) *encryption.Signer {
+	t.Helper()
+	signer, err := encryption.ParseSigner(encryption.SignHMAC, "k1:"+base64.StdEncoding.EncodeToString([]byte(strings.Repeat(secret, 32))), "", "")
+	if err != nil {
+		t.Fatalf("ParseSigner: %v", err)
+	}
+	return signer
+}
+
+func TestSigningVerify(t *testing.T) {
+	producer := Signing{Signer: testSigner(t, "a")}
+
+	tests := []struct {
+		name     string
+		verifier Signing
+		sign     bool
+		mutate   func(job *types.Job)
+		wantErr  bool
+	}{
+		{name: "signed job", verifier: producer, sign: true},
+		{name: "signing off", verifier: Signing{}, sign: false},
+		{name: "unsigned job", verifier: producer, sign: false, wantErr: true},
+		{name: "unsigned job allowed", verifier: Signing{Signer: producer.Signer, AllowUnsigned: true}, sign: false},
+		{name: "other key", verifier: Signing{Signer: testSigner(t, "b")}, sign: true, wantErr: true},
+		{
+			name: "retry bookkeeping is not signed", verifier: producer, sign: true,
+			mutate: func(job *types.Job) {
+				job.Attempts = 2
+				job.Metadata = types.JobMetadata{"last_error": "timeout"}
+			},
+		},
+		{
+			name: "payload reformatted by a codec", verifier: producer, sign: true,
+			mutate: func(job *types.Job) { job.Payload = json.RawMessage("{ \"to\" : \"a@example.com\" }") },
+		},
+		{
+			name: "payload changed", verifier: producer, sign: true,
+			mutate:  func(job *types.Job) { job.Payload = json.RawMessage(`{"to":"mallory@example.com"}`) },
+			wantErr: true,
+		},
+		{
+			name: "type changed", verifier: producer, sign: true,
+			mutate:  func(job *types.Job) { job.Type = "wire_transfer" },
+			wantErr: true,
+		},
+		{
+			name: "queue changed", verifier: producer, sign: true,
+			mutate:  func(job *types.Job) { job.Queue = "critical" },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := types.NewJob("email", json.RawMessage(`{"to":"a@example.com"}`), 3)
+			if tt.sign {
+				signed, err := producer.sign(job)
+				if err != nil {
+					t.Fatalf("sign: %v", err)
+				}
+				if signed.Signature == "" || job.Signature != "" {
+					t.Fatalf("sign must return a signed copy, got %q on the copy and %q on the original", signed.Signature, job.Signature)
+				}
+				job = signed
+			}
+			if tt.mutate != nil {
+				tt.mutate(job)
+			}
+
+			err := tt.verifier.Verify(job)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Verify error = %v, want error: %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrUntrusted) {
+				t.Fatalf("Verify error = %v, want ErrUntrusted", err)
+			}
+		})
+	}
+}
+
+func TestSigningSkips(t *testing.T) {
+	public := "k1:" + base64.StdEncoding.EncodeToString(make([]byte, 32))
+	verifyOnly, err := encryption.ParseSigner(encryption.SignEd25519, "", public, "")
+	if err != nil {
+		t.Fatalf("ParseSigner: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		signing Signing
+		job     func() *types.Job
+	}{
+		{name: "signing off", signing: Signing{}, job: func() *types.Job { return types.NewJob("email", json.RawMessage(`{}`), 0) }},
+		{name: "verify-only signer", signing: Signing{Signer: verifyOnly}, job: func() *types.Job { return types.NewJob("email", json.RawMessage(`{}`), 0) }},
+		{
+			name:    "sealed payload",
+			signing: Signing{Signer: testSigner(t, "a")},
+			job: func() *types.Job {
+				job := types.NewJob("email", json.RawMessage(`"c2VhbGVk"`), 0)
+				job.KeyID = "v1"
+				return job
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := tt.job()
+			got, err := tt.signing.sign(job)
+			if err != nil {
+				t.Fatalf("sign: %v", err)
+			}
+			if got != job || got.Signature != "" {
+				t.Fatalf("sign changed a job it must leave as is: signature %q", got.Signature)
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package queue
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// ErrSpoolFull is returned when the spool already holds its maximum number of jobs
+var ErrSpoolFull = errors.New("spool is full")
+
+// SpoolOptions controls the producer-side outage spool
+type SpoolOptions struct {
+	Path          string              // NDJSON file keeping spooled jobs across restarts, empty keeps them in memory only
+	MaxJobs       int                 // Jobs beyond this are refused
+	FlushInterval time.Duration       // How often spooled jobs are retried against the queue
+	Keyring       *encryption.Keyring // Encrypts payloads written to Path, nil to write them as is
+}
+
+// Spool holds jobs that could not be enqueued during a queue outage and
+// enqueues them once the queue is reachable again. Flushed jobs land behind
+// jobs enqueued directly in the meantime, so order is not preserved.
+type Spool struct {
+	queue  Queue
+	opts   SpoolOptions
+	layout *Layout // Seals spooled jobs; the queue signs and compresses them when flushed
+	logger *zap.Logger
+
+	flushMu sync.Mutex // Serializes Flush and Erase, which both remove jobs
+	mu      sync.Mutex
+	jobs    []*types.Job
+	file    *os.File
+}
+
+// NewSpool creates a spool in front of q, loading jobs a previous process
+// left in opts.Path
+func NewSpool(q Queue, opts SpoolOptions, logger *zap.Logger) (*Spool, error) {
+	if opts.MaxJobs <= 0 {
+		opts.MaxJobs = 10000
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 2 * time.Second
+	}
+
+	s := &Spool{
+		queue:  q,
+		opts:   opts,
+		layout: signingLayout(Signing{}),
+		logger: logger,
+	}
+	if opts.Path == "" {
+		return s, nil
+	}
+
+	file, err := os.OpenFile(opts.Path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spool file: %w", err)
+	}
+	s.file = file
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	stale := false
+	for scanner.Scan() {
+		var job types.Job
+		if err := json.Unmarshal(scanner.Bytes(), &job); err != nil {
+			logger.Warn("Skipping unreadable spooled job", zap.Error(err))
+			continue
+		}
+		if opts.Keyring != nil && needsRotation(&job, opts.Keyring) {
+			stale = true
+		}
+		if err := openJob(&job, opts.Keyring); err != nil {
+			logger.Warn("Skipping spooled job that cannot be decrypted", zap.String("job_id", job.ID), zap.Error(err))
+			continue
+		}
+		s.jobs = append(s.jobs, &job)
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read spool file: %w", err)
+	}
+
+	if len(s.jobs) > 0 {
+		logger.Info("Loaded spooled jobs", zap.Int("jobs", len(s.jobs)), zap.String("path", opts.Path))
+	}
+
+	// Seal jobs written under a retired key with the active one, so the key
+	// can be dropped once rotation has finished
+	if stale {
+		if err := s.rewrite(); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Add spools a job for a later enqueue
+func (s *Spool) Add(job *types.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.jobs) >= s.opts.MaxJobs {
+		return ErrSpoolFull
+	}
+
+	if s.file != nil {
+		line, err := s.encode(job)
+		if err != nil {
+			return err
+		}
+		if _, err := s.file.Write(line); err != nil {
+			return fmt.Errorf("failed to write spool file: %w", err)
+		}
+		if err := s.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync spool file: %w", err)
+		}
+	}
+
+	s.jobs = append(s.jobs, job)
+	return nil
+}
+
+// Len returns the number of spooled jobs
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.jobs)
+}
+
+// Run flushes the spool every flush interval until ctx is cancelled
+func (s *Spool) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			flushed, err := s.Flush(ctx)
+			if flushed > 0 {
+				s.logger.Info("Flushed spooled jobs", zap.Int("jobs", flushed), zap.Int("remaining", s.Len()))
+			}
+			if err != nil && ctx.Err() == nil {
+				s.logger.Warn("Queue still unavailable, keeping jobs spooled", zap.Int("jobs", s.Len()), zap.Error(err))
+			}
+		}
+	}
+}
+
+// Flush enqueues spooled jobs oldest first, stopping at the first failure,
+// and returns how many were enqueued
+func (s *Spool) Flush(ctx context.Context) (int, error) {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	pending := append([]*types.Job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	flushed := 0
+	var flushErr error
+	for _, job := range pending {
+		if err := s.queue.Enqueue(ctx, job); err != nil {
+			flushErr = fmt.Errorf("failed to flush spooled job %s: %w", job.ID, err)
+			break
+		}
+		flushed++
+	}
+	if flushed == 0 {
+		return 0, flushErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs = s.jobs[flushed:]
+	if err := s.rewrite(); err != nil {
+		return flushed, err
+	}
+	return flushed, flushErr
+}
+
+// Erase removes or scrubs the spooled jobs whose payload field matches the
+// request and returns how many it touched
+func (s *Spool) Erase(req ErasureRequest) (int, error) {
+	req, err := req.normalize()
+	if err != nil {
+		return 0, err
+	}
+	path := strings.Split(req.Field, ".")
+
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	erased := 0
+	kept := s.jobs[:0]
+	for _, job := range s.jobs {
+		if !payloadMatches(job.Payload, path, req.Value) {
+			kept = append(kept, job)
+			continue
+		}
+		erased++
+		if req.Mode == ErasureScrub {
+			scrubJob(job)
+			kept = append(kept, job)
+		}
+	}
+	s.jobs = kept
+	if erased == 0 {
+		return 0, nil
+	}
+
+	return erased, s.rewrite()
+}
+
+// ErasureSubscriber is the part of a Redis client FollowErasures listens with
+type ErasureSubscriber interface {
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+}
+
+// FollowErasures erases from the spool the requests that EraseSubject
+// publishes under layout's keys, until ctx is cancelled, so a request
+// handled by one server also reaches the jobs spooled by the others
+func (s *Spool) FollowErasures(ctx context.Context, client ErasureSubscriber, layout *Layout) {
+	sub := client.Subscribe(ctx, layout.key(erasureChannel))
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-sub.Channel():
+			if !ok {
+				return
+			}
+			var req ErasureRequest
+			if err := json.Unmarshal([]byte(msg.Payload), &req); err != nil {
+				s.logger.Warn("Ignoring unreadable erasure request", zap.Error(err))
+				continue
+			}
+			erased, err := s.Erase(req)
+			if err != nil {
+				s.logger.Error("Failed to erase spooled jobs", zap.String("field", req.Field), zap.Error(err))
+				continue
+			}
+			if erased > 0 {
+				s.logger.Info("Erased spooled jobs", zap.String("field", req.Field), zap.Int("jobs", erased))
+			}
+		}
+	}
+}
+
+// Close releases the spool file; jobs still spooled stay in it for the next start
+func (s *Spool) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
+
+// rewrite replaces the spool file's contents with the jobs still spooled
+func (s *Spool) rewrite() error {
+	if s.file == nil {
+		return nil
+	}
+
+	if err := s.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate spool file: %w", err)
+	}
+	for _, job := range s.jobs {
+		line, err := s.encode(job)
+		if err != nil {
+			return err
+		}
+		if _, err := s.file.Write(line); err != nil {
+			return fmt.Errorf("failed to write spool file: %w", err)
+		}
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync spool file: %w", err)
+	}
+	return nil
+}
+
+// encode returns a job's spool file line, with its payload encrypted when a keyring is set
+func (s *Spool) encode(job *types.Job) ([]byte, error) {
+	sealed, err := s.layout.sealJob(job, s.opts.Keyring)
+	if err != nil {
+		return nil, err
+	}
+	line, err := json.Marshal(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job: %w", err)
+	}
+	return append(line, '\n'), nil
+}
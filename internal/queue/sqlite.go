@@ -0,0 +1,633 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	_ "github.com/mattn/go-sqlite3" // Registers the "sqlite3" driver SQLITE_DRIVER defaults to; needs cgo
+)
+
+// sqliteSchema creates the tables used by the SQLite backend. Times are Unix
+// milliseconds so they compare the same under every driver. A job whose
+// leased_until is in the future has been dequeued and not yet acked.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS gopher_jobs (
+	seq         INTEGER PRIMARY KEY AUTOINCREMENT,
+	job_id      TEXT NOT NULL,
+	queue       TEXT NOT NULL,
+	data        TEXT NOT NULL,
+	run_at      INTEGER NOT NULL,
+	enqueued_at INTEGER NOT NULL,
+	leased_until INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS gopher_jobs_ready ON gopher_jobs (queue, run_at, seq);
+
+CREATE TABLE IF NOT EXISTS gopher_stats (
+	queue TEXT NOT NULL,
+	name  TEXT NOT NULL,
+	value INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (queue, name)
+);
+
+CREATE TABLE IF NOT EXISTS gopher_dead_jobs (
+	job_id    TEXT PRIMARY KEY,
+	job_type  TEXT NOT NULL,
+	data      TEXT NOT NULL,
+	error     TEXT NOT NULL,
+	reason    TEXT NOT NULL,
+	failed_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS gopher_dead_jobs_failed_at ON gopher_dead_jobs (failed_at);
+`
+
+// sqlitePollStep is how often an empty queue is checked during a long poll
+const sqlitePollStep = 250 * time.Millisecond
+
+// sqliteAddLease adds the lease column to tables created before jobs were
+// leased, when they were deleted as they were dequeued
+const sqliteAddLease = `ALTER TABLE gopher_jobs ADD COLUMN leased_until INTEGER NOT NULL DEFAULT 0`
+
+// SQLiteOptions configures the SQLite queue backend
+type SQLiteOptions struct {
+	QueueName    string        // Logical queue stored in the jobs table, empty for the default queue
+	PollTimeout  time.Duration // How long a dequeue waits for a job, defaults to 1s
+	BusyTimeout  time.Duration // How long a statement waits for another process's write lock, defaults to 5s
+	LeaseTimeout time.Duration // How long a dequeued job stays claimed before it is handed out again, defaults to 5m
+}
+
+// SQLiteQueue implements Queue on a SQLite file for single-node deployments.
+// The database runs in WAL mode so readers don't block the writer. A job is
+// claimed by leasing it with a single UPDATE ... RETURNING, which SQLite's
+// write lock makes atomic across the server, workers and CLI sharing the
+// file, and deleted when the worker acks it; a job whose worker died is
+// handed out again once its lease runs out. The bundled mattn/go-sqlite3
+// driver needs cgo, so binaries built with CGO_ENABLED=0 can't open the
+// file. The Redis-only features stay unavailable.
+type SQLiteQueue struct {
+	db      *sql.DB
+	name    string
+	opts    SQLiteOptions
+	keyring *encryption.Keyring
+	layout  *Layout
+
+	mu     sync.Mutex
+	leases map[string][]sqliteLease // Leases of unacked deliveries by job ID
+}
+
+// sqliteLease identifies one delivery of a job: the row and the lease
+// expiry it was claimed with, which changes when the job is claimed again
+type sqliteLease struct {
+	seq   int64
+	until int64
+}
+
+// NewSQLiteQueue enables WAL mode, creates the schema if needed and returns the queue
+func NewSQLiteQueue(ctx context.Context, db *sql.DB, opts SQLiteOptions) (*SQLiteQueue, error) {
+	if opts.BusyTimeout <= 0 {
+		opts.BusyTimeout = 5 * time.Second
+	}
+	if opts.PollTimeout <= 0 {
+		opts.PollTimeout = time.Second
+	}
+	if opts.LeaseTimeout <= 0 {
+		opts.LeaseTimeout = 5 * time.Minute
+	}
+
+	// Pragmas other than journal_mode are per connection, so keep a single one
+	db.SetMaxOpenConns(1)
+
+	pragmas := []string{
+		"PRAGMA journal_mode = WAL",
+		"PRAGMA synchronous = NORMAL",
+		fmt.Sprintf("PRAGMA busy_timeout = %d", opts.BusyTimeout.Milliseconds()),
+	}
+	for _, pragma := range pragmas {
+		if _, err := db.ExecContext(ctx, pragma); err != nil {
+			return nil, fmt.Errorf("failed to configure SQLite (%s): %w", pragma, err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, sqliteSchema); err != nil {
+		return nil, fmt.Errorf("failed to create SQLite schema: %w", err)
+	}
+	var leased int
+	if err := db.QueryRowContext(ctx,
+		`SELECT count(*) FROM pragma_table_info('gopher_jobs') WHERE name = 'leased_until'`).Scan(&leased); err != nil {
+		return nil, fmt.Errorf("failed to inspect SQLite schema: %w", err)
+	}
+	if leased == 0 {
+		if _, err := db.ExecContext(ctx, sqliteAddLease); err != nil {
+			return nil, fmt.Errorf("failed to migrate SQLite schema: %w", err)
+		}
+	}
+
+	name := opts.QueueName
+	if name == "" {
+		name = DefaultQueueName
+	}
+
+	return &SQLiteQueue{
+		db:     db,
+		name:   name,
+		opts:   opts,
+		layout: signingLayout(Signing{}),
+		leases: make(map[string][]sqliteLease),
+	}, nil
+}
+
+// OpenSQLite opens the database file with the named database/sql driver and creates the queue
+func OpenSQLite(ctx context.Context, driver, path string, opts SQLiteOptions) (*SQLiteQueue, error) {
+	db, err := sql.Open(driver, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite with driver %q (is it linked into the binary?): %w", driver, err)
+	}
+
+	q, err := NewSQLiteQueue(ctx, db, opts)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return q, nil
+}
+
+// SetKeyring enables payload encryption with the given keyring
+func (q *SQLiteQueue) SetKeyring(keyring *encryption.Keyring) {
+	q.keyring = keyring
+}
+
+// SetSigning signs jobs as they are stored
+func (q *SQLiteQueue) SetSigning(signing Signing) {
+	q.layout = signingLayout(signing)
+}
+
+// DB returns the underlying database handle
+func (q *SQLiteQueue) DB() *sql.DB {
+	return q.db
+}
+
+// Enqueue adds a job that is ready immediately
+func (q *SQLiteQueue) Enqueue(ctx context.Context, job *types.Job) error {
+	return q.Schedule(ctx, job, time.Now())
+}
+
+// Schedule adds a job that becomes ready at runAt
+func (q *SQLiteQueue) Schedule(ctx context.Context, job *types.Job, runAt time.Time) error {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := q.insert(ctx, tx, job, runAt); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return nil
+}
+
+// insert adds a job and counts it within tx
+func (q *SQLiteQueue) insert(ctx context.Context, tx *sql.Tx, job *types.Job, runAt time.Time) error {
+	if err := job.Validate(); err != nil {
+		return fmt.Errorf("job validation failed: %w", err)
+	}
+
+	job.EnqueuedAt = time.Now().UTC()
+
+	sealed, err := q.layout.sealJob(job, q.keyring)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(sealed)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	name := targetQueue(job, q.name)
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO gopher_jobs (job_id, queue, data, run_at, enqueued_at) VALUES (?, ?, ?, ?, ?)`,
+		job.ID, name, string(data), runAt.UnixMilli(), job.EnqueuedAt.UnixMilli()); err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return sqliteIncrementStat(ctx, tx, name, "total_enqueued")
+}
+
+// Dequeue leases the oldest ready job, polling for up to the poll timeout.
+// It returns nil without an error when no job became ready in time.
+func (q *SQLiteQueue) Dequeue(ctx context.Context) (*types.Job, error) {
+	deadline := time.Now().Add(q.opts.PollTimeout)
+
+	for {
+		job, err := q.claim(ctx)
+		if err != nil || job != nil {
+			return job, err
+		}
+
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return nil, nil
+		}
+		if wait > sqlitePollStep {
+			wait = sqlitePollStep
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// claim leases and returns one ready job, which may be one whose previous
+// lease ran out before it was acked
+func (q *SQLiteQueue) claim(ctx context.Context) (*types.Job, error) {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dequeue job: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	lease := sqliteLease{until: now.Add(q.opts.LeaseTimeout).UnixMilli()}
+	var data string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE gopher_jobs SET leased_until = ? WHERE seq = (
+			SELECT seq FROM gopher_jobs
+			WHERE queue = ? AND run_at <= ? AND leased_until <= ?
+			ORDER BY run_at, seq
+			LIMIT 1
+		)
+		RETURNING seq, data`, lease.until, q.name, now.UnixMilli(), now.UnixMilli()).Scan(&lease.seq, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to dequeue job: %w", err)
+	}
+
+	if err := sqliteIncrementStat(ctx, tx, q.name, "total_dequeued"); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to dequeue job: %w", err)
+	}
+
+	var job types.Job
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+	if err := openJob(&job, q.keyring); err != nil {
+		return nil, fmt.Errorf("failed to decrypt job: %w", err)
+	}
+
+	q.mu.Lock()
+	q.leases[job.ID] = append(q.leases[job.ID], lease)
+	q.mu.Unlock()
+	return &job, nil
+}
+
+// Ack deletes the job's row once it has been handled. A job whose lease ran
+// out and was claimed again keeps its row, which the new delivery now owns.
+func (q *SQLiteQueue) Ack(ctx context.Context, job *types.Job) error {
+	q.mu.Lock()
+	leases := q.leases[job.ID]
+	if len(leases) == 0 {
+		q.mu.Unlock()
+		return nil
+	}
+	lease := leases[0]
+	if len(leases) == 1 {
+		delete(q.leases, job.ID)
+	} else {
+		q.leases[job.ID] = leases[1:]
+	}
+	q.mu.Unlock()
+
+	if _, err := q.db.ExecContext(ctx,
+		`DELETE FROM gopher_jobs WHERE seq = ? AND leased_until = ?`, lease.seq, lease.until); err != nil {
+		return fmt.Errorf("failed to ack job: %w", err)
+	}
+	return nil
+}
+
+// Size returns the number of ready jobs, leased ones excluded
+func (q *SQLiteQueue) Size(ctx context.Context) (int, error) {
+	now := time.Now().UnixMilli()
+	var size int
+	err := q.db.QueryRowContext(ctx,
+		`SELECT count(*) FROM gopher_jobs WHERE queue = ? AND run_at <= ? AND leased_until <= ?`, q.name, now, now).Scan(&size)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get queue size: %w", err)
+	}
+	return size, nil
+}
+
+// Health checks if the database file is usable
+func (q *SQLiteQueue) Health(ctx context.Context) error {
+	if err := q.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("sqlite health check failed: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database handle
+func (q *SQLiteQueue) Close() error {
+	return q.db.Close()
+}
+
+// GetStats returns the queue size and throughput counters
+func (q *SQLiteQueue) GetStats(ctx context.Context) (*QueueStats, error) {
+	now := time.Now().UnixMilli()
+	stats := &QueueStats{}
+
+	if err := q.db.QueryRowContext(ctx, `
+		SELECT
+			count(*) FILTER (WHERE run_at <= ? AND leased_until <= ?),
+			count(*) FILTER (WHERE run_at > ?)
+		FROM gopher_jobs WHERE queue = ?`, now, now, now, q.name).Scan(&stats.QueueSize, &stats.Retrying); err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+
+	rows, err := q.db.QueryContext(ctx, `SELECT name, value FROM gopher_stats WHERE queue = ?`, q.name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var value int
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, fmt.Errorf("failed to get stats: %w", err)
+		}
+		switch name {
+		case "total_enqueued":
+			stats.TotalEnqueued = value
+		case "total_dequeued":
+			stats.TotalDequeued = value
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+	return stats, nil
+}
+
+// sqliteIncrementStat bumps a counter in gopher_stats within tx
+func sqliteIncrementStat(ctx context.Context, tx *sql.Tx, queue, name string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO gopher_stats (queue, name, value) VALUES (?, ?, 1)
+		ON CONFLICT (queue, name) DO UPDATE SET value = value + 1`, queue, name)
+	if err != nil {
+		return fmt.Errorf("failed to update stats: %w", err)
+	}
+	return nil
+}
+
+// SQLiteDLQ implements DeadLetterQueue in the same SQLite file as its queue
+type SQLiteDLQ struct {
+	queue   *SQLiteQueue
+	keyring *encryption.Keyring
+	layout  *Layout
+}
+
+// NewSQLiteDLQ creates a dead letter queue that requeues into queue
+func NewSQLiteDLQ(queue *SQLiteQueue) *SQLiteDLQ {
+	return &SQLiteDLQ{queue: queue, layout: signingLayout(Signing{})}
+}
+
+// SetKeyring enables payload encryption with the given keyring
+func (d *SQLiteDLQ) SetKeyring(keyring *encryption.Keyring) {
+	d.keyring = keyring
+}
+
+// SetSigning signs jobs as they are stored
+func (d *SQLiteDLQ) SetSigning(signing Signing) {
+	d.layout = signingLayout(signing)
+}
+
+// Send records a failed job
+func (d *SQLiteDLQ) Send(ctx context.Context, job *types.Job, reason types.FailureReason, errorMsg string) error {
+	sealed, err := d.layout.sealJob(job, d.keyring)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(sealed)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	tx, err := d.queue.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to add job to DLQ: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO gopher_dead_jobs (job_id, job_type, data, error, reason, failed_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (job_id) DO UPDATE SET
+			job_type = excluded.job_type, data = excluded.data, error = excluded.error,
+			reason = excluded.reason, failed_at = excluded.failed_at`,
+		job.ID, job.Type, string(data), errorMsg, string(reason), time.Now().UnixMilli()); err != nil {
+		return fmt.Errorf("failed to add job to DLQ: %w", err)
+	}
+	if err := sqliteIncrementStat(ctx, tx, dlqStatsQueue, "total_sent"); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to add job to DLQ: %w", err)
+	}
+	return nil
+}
+
+// Size returns the number of jobs in the DLQ
+func (d *SQLiteDLQ) Size(ctx context.Context) (int, error) {
+	var size int
+	if err := d.queue.db.QueryRowContext(ctx, `SELECT count(*) FROM gopher_dead_jobs`).Scan(&size); err != nil {
+		return 0, fmt.Errorf("failed to get DLQ size: %w", err)
+	}
+	return size, nil
+}
+
+// Reprocess moves a job from the DLQ back to the main queue in one transaction
+func (d *SQLiteDLQ) Reprocess(ctx context.Context, jobID string) error {
+	tx, err := d.queue.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to reprocess job: %w", err)
+	}
+	defer tx.Rollback()
+
+	var data string
+	err = tx.QueryRowContext(ctx,
+		`DELETE FROM gopher_dead_jobs WHERE job_id = ? RETURNING data`, jobID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", ErrNotInDLQ, jobID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reprocess job: %w", err)
+	}
+
+	var job types.Job
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+	if err := openJob(&job, d.keyring); err != nil {
+		return fmt.Errorf("failed to decrypt job: %w", err)
+	}
+
+	// Reset attempts counter
+	job.Attempts = 0
+	job.UpdatedAt = time.Now().UTC()
+
+	if err := d.queue.insert(ctx, tx, &job, time.Now()); err != nil {
+		return fmt.Errorf("failed to requeue job: %w", err)
+	}
+	if err := sqliteIncrementStat(ctx, tx, dlqStatsQueue, "reprocessed"); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to reprocess job: %w", err)
+	}
+	return nil
+}
+
+// List returns jobs in the DLQ with pagination, newest first
+func (d *SQLiteDLQ) List(ctx context.Context, offset, limit int) ([]*types.FailedJobInfo, error) {
+	return d.query(ctx, `
+		SELECT data, error, reason, failed_at FROM gopher_dead_jobs
+		ORDER BY failed_at DESC LIMIT ? OFFSET ?`, limit, offset)
+}
+
+// ListByReason returns jobs with the given failure reason and the total match count
+func (d *SQLiteDLQ) ListByReason(ctx context.Context, reason types.FailureReason, offset, limit int) ([]*types.FailedJobInfo, int, error) {
+	var total int
+	if err := d.queue.db.QueryRowContext(ctx,
+		`SELECT count(*) FROM gopher_dead_jobs WHERE reason = ?`, string(reason)).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to list DLQ jobs: %w", err)
+	}
+
+	jobs, err := d.query(ctx, `
+		SELECT data, error, reason, failed_at FROM gopher_dead_jobs
+		WHERE reason = ?
+		ORDER BY failed_at DESC LIMIT ? OFFSET ?`, string(reason), limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
+
+// query scans FailedJobInfo rows, skipping entries that can't be decoded
+func (d *SQLiteDLQ) query(ctx context.Context, query string, args ...interface{}) ([]*types.FailedJobInfo, error) {
+	rows, err := d.queue.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list DLQ jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*types.FailedJobInfo
+	for rows.Next() {
+		var data, reason string
+		var failedAt int64
+		info := &types.FailedJobInfo{Job: &types.Job{}}
+		if err := rows.Scan(&data, &info.Error, &reason, &failedAt); err != nil {
+			return nil, fmt.Errorf("failed to list DLQ jobs: %w", err)
+		}
+		if err := json.Unmarshal([]byte(data), info.Job); err != nil {
+			continue
+		}
+		if err := openJob(info.Job, d.keyring); err != nil {
+			continue
+		}
+		info.Reason = types.FailureReason(reason)
+		info.FailedAt = time.UnixMilli(failedAt).UTC()
+		jobs = append(jobs, info)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list DLQ jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// GetStats returns size, age and inflow statistics for the DLQ
+func (d *SQLiteDLQ) GetStats(ctx context.Context) (*DLQStats, error) {
+	db := d.queue.db
+	now := time.Now().UTC()
+	stats := &DLQStats{
+		ByType:   make(map[string]int),
+		ByReason: make(map[string]int),
+	}
+
+	var oldest sql.NullInt64
+	if err := db.QueryRowContext(ctx, `
+		SELECT count(*), min(failed_at),
+			count(*) FILTER (WHERE failed_at > ?),
+			count(*) FILTER (WHERE failed_at > ?)
+		FROM gopher_dead_jobs`,
+		now.Add(-5*time.Minute).UnixMilli(), now.Add(-dlqInflowWindow).UnixMilli()).
+		Scan(&stats.Size, &oldest, &stats.InflowLast5m, &stats.InflowLastHour); err != nil {
+		return nil, fmt.Errorf("failed to get DLQ stats: %w", err)
+	}
+	if oldest.Valid {
+		failedAt := time.UnixMilli(oldest.Int64).UTC()
+		stats.OldestFailedAt = &failedAt
+		stats.OldestAgeSecs = now.Sub(failedAt).Seconds()
+	}
+	stats.InflowPerMinute = float64(stats.InflowLast5m) / 5
+
+	groups := []struct {
+		column string
+		into   map[string]int
+	}{
+		{"job_type", stats.ByType},
+		{"reason", stats.ByReason},
+	}
+	for _, group := range groups {
+		rows, err := db.QueryContext(ctx,
+			fmt.Sprintf(`SELECT %s, count(*) FROM gopher_dead_jobs GROUP BY 1`, group.column))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get DLQ stats: %w", err)
+		}
+		for rows.Next() {
+			var key string
+			var count int
+			if err := rows.Scan(&key, &count); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to get DLQ stats: %w", err)
+			}
+			group.into[key] = count
+		}
+		rows.Close()
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT name, value FROM gopher_stats WHERE queue = ?`, dlqStatsQueue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DLQ stats: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var value int
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, fmt.Errorf("failed to get DLQ stats: %w", err)
+		}
+		switch name {
+		case "total_sent":
+			stats.TotalSent = value
+		case "reprocessed":
+			stats.Reprocessed = value
+		}
+	}
+
+	return stats, rows.Err()
+}
@@ -0,0 +1,161 @@
+package queue
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+func openTestSQLite(t *testing.T, lease time.Duration) *SQLiteQueue {
+	t.Helper()
+
+	q, err := OpenSQLite(context.Background(), "sqlite3", filepath.Join(t.TempDir(), "gopher.db"), SQLiteOptions{
+		PollTimeout:  10 * time.Millisecond,
+		LeaseTimeout: lease,
+	})
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	t.Cleanup(func() { q.Close() })
+	return q
+}
+
+func TestSQLiteQueueLeases(t *testing.T) {
+	tests := []struct {
+		name string
+		// handle runs between the first dequeue and the check for redelivery
+		handle    func(t *testing.T, q *SQLiteQueue, job *types.Job)
+		wait      time.Duration
+		redeliver bool
+	}{
+		{
+			name: "acked job is deleted",
+			handle: func(t *testing.T, q *SQLiteQueue, job *types.Job) {
+				if err := q.Ack(context.Background(), job); err != nil {
+					t.Fatalf("Ack: %v", err)
+				}
+			},
+			wait:      80 * time.Millisecond,
+			redeliver: false,
+		},
+		{
+			name:      "unacked job is hidden while leased",
+			handle:    func(t *testing.T, q *SQLiteQueue, job *types.Job) {},
+			wait:      0,
+			redeliver: false,
+		},
+		{
+			name:      "unacked job is redelivered once its lease runs out",
+			handle:    func(t *testing.T, q *SQLiteQueue, job *types.Job) {},
+			wait:      80 * time.Millisecond,
+			redeliver: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			q := openTestSQLite(t, 50*time.Millisecond)
+
+			job := types.NewJob("email", []byte(`{"to":"a@example.com"}`), 3)
+			if err := q.Enqueue(ctx, job); err != nil {
+				t.Fatalf("Enqueue: %v", err)
+			}
+
+			got, err := q.Dequeue(ctx)
+			if err != nil || got == nil || got.ID != job.ID {
+				t.Fatalf("Dequeue = %v, %v; want job %s", got, err, job.ID)
+			}
+			tt.handle(t, q, got)
+			time.Sleep(tt.wait)
+
+			again, err := q.Dequeue(ctx)
+			if err != nil {
+				t.Fatalf("second Dequeue: %v", err)
+			}
+			if redelivered := again != nil; redelivered != tt.redeliver {
+				t.Fatalf("redelivered = %v, want %v", redelivered, tt.redeliver)
+			}
+		})
+	}
+}
+
+func TestSQLiteQueueStaleAck(t *testing.T) {
+	ctx := context.Background()
+	q := openTestSQLite(t, 50*time.Millisecond)
+
+	job := types.NewJob("email", []byte(`{}`), 0)
+	if err := q.Enqueue(ctx, job); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	first, err := q.Dequeue(ctx)
+	if err != nil || first == nil {
+		t.Fatalf("Dequeue = %v, %v", first, err)
+	}
+
+	// A second process claims the job once the first one's lease runs out
+	time.Sleep(80 * time.Millisecond)
+	other, err := NewSQLiteQueue(ctx, q.DB(), SQLiteOptions{LeaseTimeout: time.Minute})
+	if err != nil {
+		t.Fatalf("NewSQLiteQueue: %v", err)
+	}
+	second, err := other.Dequeue(ctx)
+	if err != nil || second == nil {
+		t.Fatalf("Dequeue after lease = %v, %v", second, err)
+	}
+
+	// The late ack of the first delivery must leave the row to the second
+	if err := q.Ack(ctx, first); err != nil {
+		t.Fatalf("stale Ack: %v", err)
+	}
+	var rows int
+	if err := q.DB().QueryRowContext(ctx, `SELECT count(*) FROM gopher_jobs`).Scan(&rows); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if rows != 1 {
+		t.Fatalf("rows after stale ack = %d, want 1", rows)
+	}
+
+	if err := other.Ack(ctx, second); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	if err := q.DB().QueryRowContext(ctx, `SELECT count(*) FROM gopher_jobs`).Scan(&rows); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if rows != 0 {
+		t.Fatalf("rows after ack = %d, want 0", rows)
+	}
+}
+
+func TestSQLiteQueueMigratesLeaseColumn(t *testing.T) {
+	ctx := context.Background()
+	q := openTestSQLite(t, time.Minute)
+
+	// Recreate the jobs table as it was before leases
+	if _, err := q.DB().ExecContext(ctx, `
+		DROP TABLE gopher_jobs;
+		CREATE TABLE gopher_jobs (
+			seq         INTEGER PRIMARY KEY AUTOINCREMENT,
+			job_id      TEXT NOT NULL,
+			queue       TEXT NOT NULL,
+			data        TEXT NOT NULL,
+			run_at      INTEGER NOT NULL,
+			enqueued_at INTEGER NOT NULL
+		)`); err != nil {
+		t.Fatalf("recreate table: %v", err)
+	}
+
+	migrated, err := NewSQLiteQueue(ctx, q.DB(), SQLiteOptions{})
+	if err != nil {
+		t.Fatalf("NewSQLiteQueue on old schema: %v", err)
+	}
+	if err := migrated.Enqueue(ctx, types.NewJob("email", []byte(`{}`), 0)); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if job, err := migrated.Dequeue(ctx); err != nil || job == nil {
+		t.Fatalf("Dequeue = %v, %v", job, err)
+	}
+}
@@ -0,0 +1,430 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/aneeshsunganahalli/Gopher/internal/sqs"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+const (
+	sqsMaxDelay  = 15 * time.Minute // Longest DelaySeconds SQS accepts
+	sqsRunAtAttr = "gopher_run_at"  // Due time of jobs delayed beyond sqsMaxDelay, RFC 3339
+	sqsMaxScan   = 10               // ReceiveMessage calls a DLQ reprocess scans before giving up
+)
+
+// SQSOptions configures the SQS queue backend
+type SQSOptions struct {
+	QueueURL          string
+	DLQURL            string        // Dead letter queue, empty to use the queue's redrive policy target
+	VisibilityTimeout time.Duration // How long a received job stays hidden before redelivery, defaults to 2m
+	PollTimeout       time.Duration // ReceiveMessage long-poll time, at most 20s
+}
+
+// SQSQueue implements Queue on Amazon SQS. Received jobs stay hidden for the
+// visibility timeout and are deleted when acked, so jobs of a crashed worker
+// reappear and, past the redrive policy's maxReceiveCount, move to its DLQ.
+type SQSQueue struct {
+	client  *sqs.Client
+	opts    SQSOptions
+	keyring *encryption.Keyring
+	layout  *Layout
+
+	mu       sync.Mutex
+	receipts map[string][]string // Receipt handles of unacked deliveries by job ID
+}
+
+// NewSQSQueue creates a queue on the given client
+func NewSQSQueue(client *sqs.Client, opts SQSOptions) *SQSQueue {
+	if opts.VisibilityTimeout <= 0 {
+		opts.VisibilityTimeout = 2 * time.Minute
+	}
+	if opts.PollTimeout <= 0 || opts.PollTimeout > 20*time.Second {
+		opts.PollTimeout = 20 * time.Second
+	}
+
+	return &SQSQueue{
+		client:   client,
+		opts:     opts,
+		receipts: make(map[string][]string),
+		layout:   signingLayout(Signing{}),
+	}
+}
+
+// SetKeyring enables payload encryption with the given keyring
+func (q *SQSQueue) SetKeyring(keyring *encryption.Keyring) {
+	q.keyring = keyring
+}
+
+// SetSigning signs jobs as they are stored
+func (q *SQSQueue) SetSigning(signing Signing) {
+	q.layout = signingLayout(signing)
+}
+
+// Client returns the SQS client
+func (q *SQSQueue) Client() *sqs.Client {
+	return q.client
+}
+
+// Enqueue sends a job that is ready immediately
+func (q *SQSQueue) Enqueue(ctx context.Context, job *types.Job) error {
+	return q.Schedule(ctx, job, time.Now())
+}
+
+// Schedule sends a job that becomes ready at runAt. Delays up to 15 minutes
+// use DelaySeconds; longer ones travel as a chain of messages delayed by up
+// to 15 minutes each, see Dequeue.
+func (q *SQSQueue) Schedule(ctx context.Context, job *types.Job, runAt time.Time) error {
+	if err := job.Validate(); err != nil {
+		return fmt.Errorf("job validation failed: %w", err)
+	}
+
+	job.EnqueuedAt = time.Now().UTC()
+
+	sealed, err := q.layout.sealJob(job, q.keyring)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(sealed)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	if err := q.send(ctx, string(data), runAt); err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return nil
+}
+
+// send sends a message body that becomes ready at runAt, delayed by at most
+// 15 minutes and carrying runAt if it is later than that
+func (q *SQSQueue) send(ctx context.Context, body string, runAt time.Time) error {
+	delay := time.Until(runAt)
+	var attributes map[string]string
+	if delay > sqsMaxDelay {
+		attributes = map[string]string{sqsRunAtAttr: runAt.UTC().Format(time.RFC3339)}
+		delay = sqsMaxDelay
+	}
+	if delay < 0 {
+		delay = 0
+	}
+
+	_, err := q.client.SendMessage(ctx, q.opts.QueueURL, body, delay, attributes)
+	return err
+}
+
+// Dequeue long-polls for one job. It returns nil without an error when none
+// arrived within the poll timeout or the received job isn't due yet.
+func (q *SQSQueue) Dequeue(ctx context.Context) (*types.Job, error) {
+	messages, err := q.client.ReceiveMessage(ctx, q.opts.QueueURL, 1, q.opts.PollTimeout, q.opts.VisibilityTimeout)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to dequeue job: %w", err)
+	}
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	message := messages[0]
+
+	// A job delayed past 15 minutes that isn't due yet moves on to a fresh
+	// message for its next hop. Re-hiding this one instead would count every
+	// early receive toward the redrive policy's maxReceiveCount.
+	if attr, ok := message.MessageAttributes[sqsRunAtAttr]; ok {
+		if runAt, err := time.Parse(time.RFC3339, attr.StringValue); err == nil && time.Until(runAt) > 0 {
+			if err := q.send(ctx, message.Body, runAt); err != nil {
+				return nil, fmt.Errorf("failed to defer scheduled job: %w", err)
+			}
+			if err := q.client.DeleteMessage(ctx, q.opts.QueueURL, message.ReceiptHandle); err != nil {
+				return nil, fmt.Errorf("failed to remove deferred job message: %w", err)
+			}
+			return nil, nil
+		}
+	}
+
+	var job types.Job
+	if err := json.Unmarshal([]byte(message.Body), &job); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job %s: %w", message.MessageID, err)
+	}
+	if err := openJob(&job, q.keyring); err != nil {
+		return nil, fmt.Errorf("failed to decrypt job: %w", err)
+	}
+
+	q.mu.Lock()
+	q.receipts[job.ID] = append(q.receipts[job.ID], message.ReceiptHandle)
+	q.mu.Unlock()
+
+	return &job, nil
+}
+
+// Ack deletes the job's oldest unacked delivery
+func (q *SQSQueue) Ack(ctx context.Context, job *types.Job) error {
+	q.mu.Lock()
+	receipts := q.receipts[job.ID]
+	if len(receipts) == 0 {
+		q.mu.Unlock()
+		return nil
+	}
+	receipt := receipts[0]
+	if len(receipts) == 1 {
+		delete(q.receipts, job.ID)
+	} else {
+		q.receipts[job.ID] = receipts[1:]
+	}
+	q.mu.Unlock()
+
+	if err := q.client.DeleteMessage(ctx, q.opts.QueueURL, receipt); err != nil {
+		return fmt.Errorf("failed to delete job message: %w", err)
+	}
+	return nil
+}
+
+// Size returns the approximate number of visible jobs
+func (q *SQSQueue) Size(ctx context.Context) (int, error) {
+	attributes, err := q.client.GetQueueAttributes(ctx, q.opts.QueueURL, "ApproximateNumberOfMessages")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get queue size: %w", err)
+	}
+	size, _ := strconv.Atoi(attributes["ApproximateNumberOfMessages"])
+	return size, nil
+}
+
+// GetStats returns the approximate queue size; delayed jobs count as retrying
+func (q *SQSQueue) GetStats(ctx context.Context) (*QueueStats, error) {
+	attributes, err := q.client.GetQueueAttributes(ctx, q.opts.QueueURL,
+		"ApproximateNumberOfMessages", "ApproximateNumberOfMessagesDelayed")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+
+	stats := &QueueStats{}
+	stats.QueueSize, _ = strconv.Atoi(attributes["ApproximateNumberOfMessages"])
+	stats.Retrying, _ = strconv.Atoi(attributes["ApproximateNumberOfMessagesDelayed"])
+	return stats, nil
+}
+
+// Health checks if the queue is reachable
+func (q *SQSQueue) Health(ctx context.Context) error {
+	if _, err := q.client.GetQueueAttributes(ctx, q.opts.QueueURL, "QueueArn"); err != nil {
+		return fmt.Errorf("sqs health check failed: %w", err)
+	}
+	return nil
+}
+
+// Close is a no-op; unacked jobs reappear after their visibility timeout
+func (q *SQSQueue) Close() error {
+	return nil
+}
+
+// DeadLetterURL returns the configured DLQ, or the redrive policy's target queue
+func (q *SQSQueue) DeadLetterURL(ctx context.Context) (string, error) {
+	if q.opts.DLQURL != "" {
+		return q.opts.DLQURL, nil
+	}
+
+	attributes, err := q.client.GetQueueAttributes(ctx, q.opts.QueueURL, "RedrivePolicy")
+	if err != nil {
+		return "", fmt.Errorf("failed to read redrive policy: %w", err)
+	}
+
+	var policy struct {
+		DeadLetterTargetArn string `json:"deadLetterTargetArn"`
+	}
+	if err := json.Unmarshal([]byte(attributes["RedrivePolicy"]), &policy); err != nil || policy.DeadLetterTargetArn == "" {
+		return "", fmt.Errorf("queue has no redrive policy; set a DLQ URL")
+	}
+
+	// arn:aws:sqs:<region>:<account>:<name> -> https://sqs.<region>.amazonaws.com/<account>/<name>
+	parts := strings.Split(policy.DeadLetterTargetArn, ":")
+	if len(parts) != 6 {
+		return "", fmt.Errorf("unexpected dead letter target ARN %q", policy.DeadLetterTargetArn)
+	}
+	base, err := url.Parse(q.opts.QueueURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid queue URL: %w", err)
+	}
+	return fmt.Sprintf("%s://%s/%s/%s", base.Scheme, base.Host, parts[4], parts[5]), nil
+}
+
+// SQSDLQ implements DeadLetterQueue on the SQS dead letter queue. It also
+// reads jobs SQS moved there itself after maxReceiveCount deliveries.
+// SQS can't page through messages, so List and Reprocess only see what a
+// few receives return.
+type SQSDLQ struct {
+	client  *sqs.Client
+	url     string
+	queue   Queue
+	keyring *encryption.Keyring
+	layout  *Layout
+}
+
+// NewSQSDLQ creates a dead letter queue on url that requeues into queue
+func NewSQSDLQ(client *sqs.Client, url string, queue Queue) *SQSDLQ {
+	return &SQSDLQ{client: client, url: url, queue: queue, layout: signingLayout(Signing{})}
+}
+
+// SetKeyring enables payload encryption with the given keyring
+func (d *SQSDLQ) SetKeyring(keyring *encryption.Keyring) {
+	d.keyring = keyring
+}
+
+// SetSigning signs jobs as they are stored
+func (d *SQSDLQ) SetSigning(signing Signing) {
+	d.layout = signingLayout(signing)
+}
+
+// Send adds a failed job to the DLQ
+func (d *SQSDLQ) Send(ctx context.Context, job *types.Job, reason types.FailureReason, errorMsg string) error {
+	sealed, err := d.layout.sealJob(job, d.keyring)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(types.FailedJobInfo{
+		Job:         sealed,
+		Error:       errorMsg,
+		Reason:      reason,
+		Fingerprint: types.ErrorFingerprint(errorMsg),
+		FailedAt:    time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal failed job: %w", err)
+	}
+
+	if _, err := d.client.SendMessage(ctx, d.url, string(data), 0, nil); err != nil {
+		return fmt.Errorf("failed to add job to DLQ: %w", err)
+	}
+	return nil
+}
+
+// Size returns the approximate number of jobs in the DLQ
+func (d *SQSDLQ) Size(ctx context.Context) (int, error) {
+	attributes, err := d.client.GetQueueAttributes(ctx, d.url, "ApproximateNumberOfMessages")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get DLQ size: %w", err)
+	}
+	size, _ := strconv.Atoi(attributes["ApproximateNumberOfMessages"])
+	return size, nil
+}
+
+// Reprocess finds the job among the next few DLQ messages and requeues it
+func (d *SQSDLQ) Reprocess(ctx context.Context, jobID string) error {
+	for i := 0; i < sqsMaxScan; i++ {
+		messages, err := d.client.ReceiveMessage(ctx, d.url, 10, 0, 30*time.Second)
+		if err != nil {
+			return fmt.Errorf("failed to list DLQ jobs: %w", err)
+		}
+		if len(messages) == 0 {
+			break
+		}
+
+		for _, message := range messages {
+			info, err := d.decode(message)
+			if err != nil || info.Job.ID != jobID {
+				continue
+			}
+
+			info.Job.Attempts = 0
+			info.Job.UpdatedAt = time.Now().UTC()
+			if err := d.queue.Enqueue(ctx, info.Job); err != nil {
+				return fmt.Errorf("failed to requeue job: %w", err)
+			}
+			if err := d.client.DeleteMessage(ctx, d.url, message.ReceiptHandle); err != nil {
+				return fmt.Errorf("failed to remove job from DLQ: %w", err)
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrNotInDLQ, jobID)
+}
+
+// List peeks at up to limit DLQ messages without hiding them; offset is ignored
+// because SQS has no stable order to page through
+func (d *SQSDLQ) List(ctx context.Context, offset, limit int) ([]*types.FailedJobInfo, error) {
+	if limit > 10 {
+		limit = 10
+	}
+
+	messages, err := d.client.ReceiveMessage(ctx, d.url, limit, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list DLQ jobs: %w", err)
+	}
+
+	jobs := make([]*types.FailedJobInfo, 0, len(messages))
+	for _, message := range messages {
+		info, err := d.decode(message)
+		if err != nil {
+			continue
+		}
+		jobs = append(jobs, info)
+	}
+	return jobs, nil
+}
+
+// ListByReason filters a peek at the DLQ by failure reason
+func (d *SQSDLQ) ListByReason(ctx context.Context, reason types.FailureReason, offset, limit int) ([]*types.FailedJobInfo, int, error) {
+	all, err := d.List(ctx, 0, 10)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	jobs := make([]*types.FailedJobInfo, 0, limit)
+	for _, info := range all {
+		if info.ReasonOrUnknown() == reason && len(jobs) < limit {
+			jobs = append(jobs, info)
+		}
+	}
+	return jobs, len(jobs), nil
+}
+
+// GetStats returns the DLQ's approximate size; SQS keeps no send counters
+func (d *SQSDLQ) GetStats(ctx context.Context) (*DLQStats, error) {
+	size, err := d.Size(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &DLQStats{
+		Size:     size,
+		ByType:   make(map[string]int),
+		ByReason: make(map[string]int),
+	}, nil
+}
+
+// decode reads a DLQ message sent by Send, or a raw job moved by the redrive policy
+func (d *SQSDLQ) decode(message sqs.Message) (*types.FailedJobInfo, error) {
+	var info types.FailedJobInfo
+	if err := json.Unmarshal([]byte(message.Body), &info); err != nil {
+		return nil, err
+	}
+
+	if info.Job == nil {
+		var job types.Job
+		if err := json.Unmarshal([]byte(message.Body), &job); err != nil || job.ID == "" {
+			return nil, errors.New("not a Gopher job")
+		}
+		info = types.FailedJobInfo{
+			Job:    &job,
+			Error:  fmt.Sprintf("moved by the SQS redrive policy after %s receives", message.Attributes["ApproximateReceiveCount"]),
+			Reason: types.ReasonUnknown,
+		}
+		if sent, err := strconv.ParseInt(message.Attributes["SentTimestamp"], 10, 64); err == nil {
+			info.FailedAt = time.UnixMilli(sent).UTC()
+		}
+	}
+
+	if err := openJob(info.Job, d.keyring); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
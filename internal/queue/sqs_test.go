@@ -0,0 +1,208 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/sqs"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+type fakeSQSMessage struct {
+	id         string
+	body       string
+	attributes map[string]interface{}
+	visibleAt  time.Time
+	receives   int
+}
+
+// fakeSQS is one standard queue behind the SQS JSON API
+type fakeSQS struct {
+	mu       sync.Mutex
+	seq      int
+	messages map[string]*fakeSQSMessage // By receipt handle, which is the message ID here
+	sent     []time.Duration            // DelaySeconds of each SendMessage
+}
+
+func (f *fakeSQS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var in struct {
+		MessageBody       string
+		DelaySeconds      int
+		MessageAttributes map[string]interface{}
+		VisibilityTimeout int
+		ReceiptHandle     string
+	}
+	json.NewDecoder(r.Body).Decode(&in)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	out := map[string]interface{}{}
+
+	switch r.Header.Get("X-Amz-Target") {
+	case "AmazonSQS.SendMessage":
+		f.seq++
+		id := strconv.Itoa(f.seq)
+		delay := time.Duration(in.DelaySeconds) * time.Second
+		f.messages[id] = &fakeSQSMessage{id: id, body: in.MessageBody, attributes: in.MessageAttributes, visibleAt: now.Add(delay)}
+		f.sent = append(f.sent, delay)
+		out["MessageId"] = id
+
+	case "AmazonSQS.ReceiveMessage":
+		var received []map[string]interface{}
+		for _, m := range f.messages {
+			if m.visibleAt.After(now) {
+				continue
+			}
+			m.receives++
+			m.visibleAt = now.Add(time.Duration(in.VisibilityTimeout) * time.Second)
+			received = append(received, map[string]interface{}{
+				"MessageId":         m.id,
+				"ReceiptHandle":     m.id,
+				"Body":              m.body,
+				"Attributes":        map[string]string{"ApproximateReceiveCount": strconv.Itoa(m.receives)},
+				"MessageAttributes": m.attributes,
+			})
+			break
+		}
+		out["Messages"] = received
+
+	case "AmazonSQS.DeleteMessage":
+		delete(f.messages, in.ReceiptHandle)
+
+	case "AmazonSQS.GetQueueAttributes":
+		visible, delayed := 0, 0
+		for _, m := range f.messages {
+			if m.visibleAt.After(now) {
+				delayed++
+			} else {
+				visible++
+			}
+		}
+		out["Attributes"] = map[string]string{
+			"ApproximateNumberOfMessages":        strconv.Itoa(visible),
+			"ApproximateNumberOfMessagesDelayed": strconv.Itoa(delayed),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	json.NewEncoder(w).Encode(out)
+}
+
+// makeDue makes every message visible now, as if its delay had passed
+func (f *fakeSQS) makeDue() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, m := range f.messages {
+		m.visibleAt = time.Time{}
+	}
+}
+
+func newTestSQSQueue(t *testing.T) (*SQSQueue, *fakeSQS) {
+	t.Helper()
+
+	fake := &fakeSQS{messages: make(map[string]*fakeSQSMessage)}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	client, err := sqs.NewClient(context.Background(), sqs.Options{
+		Region:      "us-east-1",
+		Endpoint:    srv.URL,
+		Credentials: sqs.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"},
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	q := NewSQSQueue(client, SQSOptions{QueueURL: srv.URL + "/123456789012/jobs", PollTimeout: time.Second})
+	return q, fake
+}
+
+func TestSQSQueueSchedule(t *testing.T) {
+	tests := []struct {
+		name      string
+		delay     time.Duration
+		wantDelay time.Duration // DelaySeconds of the first message
+		wantHops  int           // Early receives until the job is handed out
+	}{
+		{name: "ready now", delay: 0, wantDelay: 0, wantHops: 0},
+		{name: "within DelaySeconds", delay: 10 * time.Minute, wantDelay: 10 * time.Minute, wantHops: 0},
+		{name: "past 15 minutes", delay: 2 * time.Hour, wantDelay: sqsMaxDelay, wantHops: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			q, fake := newTestSQSQueue(t)
+
+			job := types.NewJob("email", []byte(`{"to":"a@example.com"}`), 3)
+			if err := q.Schedule(ctx, job, time.Now().Add(tt.delay)); err != nil {
+				t.Fatalf("Schedule: %v", err)
+			}
+			if got := fake.sent[0]; got < tt.wantDelay-time.Second || got > tt.wantDelay {
+				t.Fatalf("DelaySeconds = %v, want %v", got, tt.wantDelay)
+			}
+
+			for hop := 0; hop < tt.wantHops; hop++ {
+				fake.makeDue()
+				got, err := q.Dequeue(ctx)
+				if err != nil || got != nil {
+					t.Fatalf("early Dequeue = %v, %v; want nothing", got, err)
+				}
+			}
+
+			// Each hop is a new message, so no message is received more than once
+			for _, m := range fake.messages {
+				if m.receives > 0 {
+					t.Fatalf("message %s received %d times before the job was due", m.id, m.receives)
+				}
+			}
+			if len(fake.messages) != 1 {
+				t.Fatalf("%d messages queued, want 1", len(fake.messages))
+			}
+
+			if tt.wantHops > 0 {
+				// Let the job fall due before its last hop is received
+				for _, m := range fake.messages {
+					m.attributes = nil
+				}
+			}
+			fake.makeDue()
+			got, err := q.Dequeue(ctx)
+			if err != nil || got == nil || got.ID != job.ID {
+				t.Fatalf("Dequeue = %v, %v; want job %s", got, err, job.ID)
+			}
+			if err := q.Ack(ctx, got); err != nil {
+				t.Fatalf("Ack: %v", err)
+			}
+			if len(fake.messages) != 0 {
+				t.Fatalf("%d messages left after ack", len(fake.messages))
+			}
+		})
+	}
+}
+
+func TestSQSQueueStats(t *testing.T) {
+	ctx := context.Background()
+	q, _ := newTestSQSQueue(t)
+
+	if err := q.Enqueue(ctx, types.NewJob("email", []byte(`{}`), 0)); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if err := q.Schedule(ctx, types.NewJob("email", []byte(`{}`), 0), time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Schedule: %v", err)
+	}
+
+	stats, err := q.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+	if stats.QueueSize != 1 || stats.Retrying != 1 {
+		t.Fatalf("stats = %+v, want 1 queued and 1 retrying", stats)
+	}
+}
@@ -0,0 +1,176 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+)
+
+const jobStatusKeyPrefix = "job:status:" // Redis hash per job ID holding its latest state, expires after the TTL
+
+// StatusTracker is implemented by queues that record job state transitions
+type StatusTracker interface {
+	// GetStatus returns the job's latest state, or nil when none is kept
+	GetStatus(ctx context.Context, jobID string) (*JobStatusRecord, error)
+}
+
+// JobStatusRecord is a job's latest state and when it reached each stage
+type JobStatusRecord struct {
+	JobID       string             `json:"job_id"`
+	Type        string             `json:"type"`
+	Status      types.JobStatus    `json:"status"`
+	EnqueuedAt  time.Time          `json:"enqueued_at"`
+	StartedAt   *time.Time         `json:"started_at,omitempty"`
+	CompletedAt *time.Time         `json:"completed_at,omitempty"`
+	Attempts    int                `json:"attempts"`
+	MaxRetries  int                `json:"max_retries"`
+	Error       string             `json:"error,omitempty"`
+	Progress    *types.JobProgress `json:"progress,omitempty"` // Latest progress the handler reported in this attempt
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// StatusStore keeps each job's state transitions (pending → processing →
+// completed/failed) for a limited time
+type StatusStore struct {
+	client redis.Cmdable
+	layout *Layout
+	ttl    time.Duration
+}
+
+// NewStatusStore creates a status store; a job's state expires ttl after its last transition
+func NewStatusStore(client redis.Cmdable, layout *Layout, ttl time.Duration) *StatusStore {
+	return &StatusStore{
+		client: client,
+		layout: layout,
+		ttl:    ttl,
+	}
+}
+
+// Set records that the job reached status; errMsg is kept for failed and retrying jobs
+func (s *StatusStore) Set(ctx context.Context, job *types.Job, status types.JobStatus, errMsg string) error {
+	pipe := s.client.TxPipeline()
+	s.queue(ctx, pipe, job, status, errMsg)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record job status: %w", err)
+	}
+	return nil
+}
+
+// queue adds the commands recording a transition to pipe, so enqueues can
+// record the pending state in their own pipeline
+func (s *StatusStore) queue(ctx context.Context, pipe redis.Pipeliner, job *types.Job, status types.JobStatus, errMsg string) {
+	now := time.Now().UTC()
+	key := s.layout.key(jobStatusKeyPrefix) + job.ID
+
+	fields := map[string]interface{}{
+		"type":        job.Type,
+		"status":      string(status),
+		"attempts":    job.Attempts,
+		"max_retries": job.MaxRetries,
+		"error":       errMsg,
+		"updated_at":  now.Format(time.RFC3339Nano),
+	}
+	switch status {
+	case types.StatusPending:
+		enqueuedAt := job.EnqueuedAt
+		if enqueuedAt.IsZero() {
+			enqueuedAt = now
+		}
+		fields["enqueued_at"] = enqueuedAt.UTC().Format(time.RFC3339Nano)
+	case types.StatusProcessing:
+		fields["started_at"] = now.Format(time.RFC3339Nano)
+	case types.StatusCompleted, types.StatusFailed:
+		fields["completed_at"] = now.Format(time.RFC3339Nano)
+	}
+
+	// A retried job starts over, so its previous run's completion and progress no longer apply
+	if status == types.StatusPending || status == types.StatusProcessing || status == types.StatusRetrying {
+		pipe.HDel(ctx, key, "completed_at", "progress")
+	}
+	pipe.HSet(ctx, key, fields)
+	pipe.Expire(ctx, key, s.ttl)
+}
+
+// Get returns the job's latest state, or nil when none is kept
+func (s *StatusStore) Get(ctx context.Context, jobID string) (*JobStatusRecord, error) {
+	fields, err := s.client.HGetAll(ctx, s.layout.key(jobStatusKeyPrefix)+jobID).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job status: %w", err)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	record := &JobStatusRecord{
+		JobID:  jobID,
+		Type:   fields["type"],
+		Status: types.JobStatus(fields["status"]),
+		Error:  fields["error"],
+	}
+	record.Attempts, _ = strconv.Atoi(fields["attempts"])
+	record.MaxRetries, _ = strconv.Atoi(fields["max_retries"])
+	record.EnqueuedAt, _ = time.Parse(time.RFC3339Nano, fields["enqueued_at"])
+	record.UpdatedAt, _ = time.Parse(time.RFC3339Nano, fields["updated_at"])
+	if startedAt, err := time.Parse(time.RFC3339Nano, fields["started_at"]); err == nil {
+		record.StartedAt = &startedAt
+	}
+	if completedAt, err := time.Parse(time.RFC3339Nano, fields["completed_at"]); err == nil {
+		record.CompletedAt = &completedAt
+	}
+	if data, ok := fields["progress"]; ok {
+		var progress types.JobProgress
+		if err := json.Unmarshal([]byte(data), &progress); err == nil {
+			record.Progress = &progress
+		}
+	}
+	return record, nil
+}
+
+// SetProgress records the progress a running job's handler reported
+func (s *StatusStore) SetProgress(ctx context.Context, jobID string, progress types.JobProgress) error {
+	progress.UpdatedAt = time.Now().UTC()
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job progress: %w", err)
+	}
+
+	key := s.layout.key(jobStatusKeyPrefix) + jobID
+	pipe := s.client.TxPipeline()
+	pipe.HSet(ctx, key, "progress", data)
+	pipe.Expire(ctx, key, s.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record job progress: %w", err)
+	}
+	return nil
+}
+
+// SetStatusStore records the pending state of every job this queue enqueues
+func (r *RedisQueue) SetStatusStore(statuses *StatusStore) {
+	r.statuses = statuses
+}
+
+// GetStatus returns the job's latest state, or nil when none is kept
+func (r *RedisQueue) GetStatus(ctx context.Context, jobID string) (*JobStatusRecord, error) {
+	if r.statuses == nil {
+		return nil, fmt.Errorf("job status tracking is not enabled")
+	}
+	return r.statuses.Get(ctx, jobID)
+}
+
+// SetStatusStore records the pending state of every job this queue enqueues
+func (p *PriorityQueue) SetStatusStore(statuses *StatusStore) {
+	p.statuses = statuses
+}
+
+// GetStatus returns the job's latest state, or nil when none is kept
+func (p *PriorityQueue) GetStatus(ctx context.Context, jobID string) (*JobStatusRecord, error) {
+	if p.statuses == nil {
+		return nil, fmt.Errorf("job status tracking is not enabled")
+	}
+	return p.statuses.Get(ctx, jobID)
+}
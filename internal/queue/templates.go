@@ -0,0 +1,141 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+)
+
+const jobTemplatesKey = "job:templates" // Redis hash of template name → JSON template
+
+var (
+	// ErrTemplateNotFound is returned for names with no configured or stored template
+	ErrTemplateNotFound = errors.New("job template not found")
+
+	// ErrTemplatesReadOnly is returned when changing templates without Redis
+	ErrTemplatesReadOnly = errors.New("job templates are read-only without the redis backend")
+
+	// ErrTemplateConfigured is returned when deleting a template defined in configuration
+	ErrTemplateConfigured = errors.New("configured job templates can't be deleted")
+)
+
+// TemplateStore resolves job templates. Templates from configuration are the
+// baseline; ones saved through the API live in Redis, are shared by every
+// server and take precedence over a configured template of the same name.
+type TemplateStore struct {
+	client   redis.Cmdable
+	layout   *Layout
+	defaults map[string]types.JobTemplate
+}
+
+// NewTemplateStore creates a template store; a nil client serves only the
+// configured templates
+func NewTemplateStore(client redis.Cmdable, layout *Layout, defaults []types.JobTemplate) *TemplateStore {
+	store := &TemplateStore{
+		client:   client,
+		layout:   layout,
+		defaults: make(map[string]types.JobTemplate, len(defaults)),
+	}
+	for _, tpl := range defaults {
+		store.defaults[tpl.Name] = tpl
+	}
+	return store
+}
+
+// Get returns the named template
+func (t *TemplateStore) Get(ctx context.Context, name string) (*types.JobTemplate, error) {
+	if t.client != nil {
+		data, err := t.client.HGet(ctx, t.layout.key(jobTemplatesKey), name).Bytes()
+		if err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("failed to get job template: %w", err)
+		}
+		if err == nil {
+			var tpl types.JobTemplate
+			if err := json.Unmarshal(data, &tpl); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal job template: %w", err)
+			}
+			return &tpl, nil
+		}
+	}
+
+	tpl, ok := t.defaults[name]
+	if !ok {
+		return nil, ErrTemplateNotFound
+	}
+	return &tpl, nil
+}
+
+// List returns every template sorted by name
+func (t *TemplateStore) List(ctx context.Context) ([]types.JobTemplate, error) {
+	merged := make(map[string]types.JobTemplate, len(t.defaults))
+	for name, tpl := range t.defaults {
+		merged[name] = tpl
+	}
+
+	if t.client != nil {
+		stored, err := t.client.HGetAll(ctx, t.layout.key(jobTemplatesKey)).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list job templates: %w", err)
+		}
+		for name, data := range stored {
+			var tpl types.JobTemplate
+			if err := json.Unmarshal([]byte(data), &tpl); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal job template %s: %w", name, err)
+			}
+			merged[name] = tpl
+		}
+	}
+
+	templates := make([]types.JobTemplate, 0, len(merged))
+	for _, tpl := range merged {
+		templates = append(templates, tpl)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates, nil
+}
+
+// Put validates and saves a template, replacing any with the same name
+func (t *TemplateStore) Put(ctx context.Context, tpl types.JobTemplate) error {
+	if t.client == nil {
+		return ErrTemplatesReadOnly
+	}
+	if err := tpl.Validate(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(tpl)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job template: %w", err)
+	}
+	if err := t.client.HSet(ctx, t.layout.key(jobTemplatesKey), tpl.Name, data).Err(); err != nil {
+		return fmt.Errorf("failed to save job template: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a saved template. A configured template of the same name
+// applies again afterwards; configured templates themselves can't be deleted.
+func (t *TemplateStore) Delete(ctx context.Context, name string) error {
+	if t.client == nil {
+		return ErrTemplatesReadOnly
+	}
+
+	removed, err := t.client.HDel(ctx, t.layout.key(jobTemplatesKey), name).Result()
+	if err != nil {
+		return fmt.Errorf("failed to delete job template: %w", err)
+	}
+	if removed == 0 {
+		if _, ok := t.defaults[name]; ok {
+			return fmt.Errorf("template %s: %w", name, ErrTemplateConfigured)
+		}
+		return ErrTemplateNotFound
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+const (
+	triagePageSize   = 500 // DLQ entries fetched per List call
+	triageSampleSize = 5   // Job IDs kept per group
+)
+
+// TriageGroup is a set of dead letter jobs of one type that failed the same way
+type TriageGroup struct {
+	JobType       string              `json:"job_type"`
+	Reason        types.FailureReason `json:"reason"`
+	Fingerprint   string              `json:"fingerprint"`
+	Count         int                 `json:"count"`
+	Example       string              `json:"example"` // Most recent raw error
+	FirstFailedAt time.Time           `json:"first_failed_at"`
+	LastFailedAt  time.Time           `json:"last_failed_at"`
+	SampleJobIDs  []string            `json:"sample_job_ids"`
+}
+
+// Summary describes the group in one line, e.g. "82× email: SMTP timeout"
+func (g TriageGroup) Summary() string {
+	return fmt.Sprintf("%d× %s: %s", g.Count, g.JobType, g.Fingerprint)
+}
+
+// TriageReport groups dead letter jobs by type, reason and error fingerprint,
+// largest group first
+type TriageReport struct {
+	GeneratedAt time.Time     `json:"generated_at"`
+	Scanned     int           `json:"scanned"`
+	Truncated   bool          `json:"truncated"` // The DLQ holds more than the scanned entries
+	Groups      []TriageGroup `json:"groups"`
+}
+
+// TriageDLQ reads up to maxEntries dead letter jobs, newest first, and
+// groups them into a report
+func TriageDLQ(ctx context.Context, dlq DeadLetterQueue, maxEntries int) (*TriageReport, error) {
+	var entries []*types.FailedJobInfo
+	for len(entries) < maxEntries {
+		limit := triagePageSize
+		if remaining := maxEntries - len(entries); remaining < limit {
+			limit = remaining
+		}
+
+		page, err := dlq.List(ctx, len(entries), limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list dead letter jobs: %w", err)
+		}
+		entries = append(entries, page...)
+		if len(page) < limit {
+			break
+		}
+	}
+
+	report := Triage(entries)
+	if len(entries) >= maxEntries {
+		if size, err := dlq.Size(ctx); err == nil && size > len(entries) {
+			report.Truncated = true
+		}
+	}
+	return report, nil
+}
+
+// Triage groups dead letter entries by job type, reason and error fingerprint
+func Triage(entries []*types.FailedJobInfo) *TriageReport {
+	type groupKey struct {
+		jobType     string
+		reason      types.FailureReason
+		fingerprint string
+	}
+
+	groups := make(map[groupKey]*TriageGroup)
+	scanned := 0
+	for _, entry := range entries {
+		if entry == nil || entry.Job == nil {
+			continue
+		}
+		scanned++
+
+		key := groupKey{
+			jobType:     entry.Job.Type,
+			reason:      entry.ReasonOrUnknown(),
+			fingerprint: entry.FingerprintOrCompute(),
+		}
+		group, ok := groups[key]
+		if !ok {
+			group = &TriageGroup{
+				JobType:       key.jobType,
+				Reason:        key.reason,
+				Fingerprint:   key.fingerprint,
+				Example:       entry.Error,
+				FirstFailedAt: entry.FailedAt,
+				LastFailedAt:  entry.FailedAt,
+			}
+			groups[key] = group
+		}
+
+		group.Count++
+		if entry.FailedAt.Before(group.FirstFailedAt) {
+			group.FirstFailedAt = entry.FailedAt
+		}
+		if entry.FailedAt.After(group.LastFailedAt) {
+			group.LastFailedAt = entry.FailedAt
+			group.Example = entry.Error
+		}
+		if len(group.SampleJobIDs) < triageSampleSize {
+			group.SampleJobIDs = append(group.SampleJobIDs, entry.Job.ID)
+		}
+	}
+
+	report := &TriageReport{
+		GeneratedAt: time.Now().UTC(),
+		Scanned:     scanned,
+		Groups:      make([]TriageGroup, 0, len(groups)),
+	}
+	for _, group := range groups {
+		report.Groups = append(report.Groups, *group)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.LastFailedAt.After(b.LastFailedAt)
+	})
+	return report
+}
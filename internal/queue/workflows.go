@@ -0,0 +1,348 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+const (
+	workflowKeyPrefix = "workflow:"       // Redis hash per workflow: definition, status, and each node's job, state, output and error
+	workflowIndexKey  = "workflows:index" // Sorted set of workflow IDs scored by creation time
+)
+
+// Fields of a workflow's hash; node fields are suffixed with the node name
+const (
+	workflowDefinitionField = "definition"
+	workflowStatusField     = "status"
+	workflowCreatedField    = "created_at"
+	workflowFinishedField   = "finished_at"
+	workflowJobPrefix       = "job:"    // Sealed job JSON
+	workflowJobIDPrefix     = "job_id:" // Job ID, readable without opening the job
+	workflowStatePrefix     = "state:"
+	workflowOutputPrefix    = "output:" // Result of a completed node
+	workflowErrorPrefix     = "error:"  // Error of a failed node
+)
+
+// transitionScript moves a hash field from one value to another, so only
+// one of the workers finishing nodes at the same time releases a node.
+// KEYS[1] hash; ARGV[1] field, ARGV[2] expected value, ARGV[3] new value.
+// Returns 1 if the field was changed.
+var transitionScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+return 1
+`)
+
+// ErrWorkflowNotFound is returned for unknown or expired workflow IDs
+var ErrWorkflowNotFound = errors.New("workflow not found")
+
+// WorkflowEngine runs workflows: DAGs of jobs submitted together. Every
+// node's job is stored with the workflow and enqueued once the nodes it
+// depends on have finished; workers report finished nodes through Advance.
+// A workflow expires after the TTL without progress.
+type WorkflowEngine struct {
+	client  redis.Cmdable
+	layout  *Layout
+	queue   Queue // Where released node jobs are enqueued
+	keyring *encryption.Keyring
+	ttl     time.Duration
+}
+
+// NewWorkflowEngine creates a workflow engine; workflows are kept for ttl
+// after their last change
+func NewWorkflowEngine(client redis.Cmdable, layout *Layout, queue Queue, ttl time.Duration) *WorkflowEngine {
+	return &WorkflowEngine{
+		client: client,
+		layout: layout,
+		queue:  queue,
+		ttl:    ttl,
+	}
+}
+
+// SetKeyring enables payload encryption for stored node jobs
+func (e *WorkflowEngine) SetKeyring(keyring *encryption.Keyring) {
+	e.keyring = keyring
+}
+
+// Submit stores a workflow with the job of each node, keyed by node name,
+// and enqueues the nodes that depend on nothing
+func (e *WorkflowEngine) Submit(ctx context.Context, workflow *types.Workflow, jobs map[string]*types.Job) (*types.WorkflowInfo, error) {
+	if err := workflow.Validate(); err != nil {
+		return nil, err
+	}
+
+	id := "wf_" + uuid.NewString()
+	now := time.Now().UTC()
+
+	definition, err := json.Marshal(workflow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal workflow: %w", err)
+	}
+	fields := map[string]interface{}{
+		workflowDefinitionField: definition,
+		workflowStatusField:     string(types.WorkflowRunning),
+		workflowCreatedField:    now.Format(time.RFC3339Nano),
+	}
+	for _, node := range workflow.Nodes {
+		job, ok := jobs[node.Name]
+		if !ok {
+			return nil, fmt.Errorf("no job for workflow node %s", node.Name)
+		}
+		job.WorkflowID = id
+		job.WorkflowNode = node.Name
+		if err := job.Validate(); err != nil {
+			return nil, fmt.Errorf("node %s: job validation failed: %w", node.Name, err)
+		}
+
+		sealed, err := e.layout.sealJob(job, e.keyring)
+		if err != nil {
+			return nil, err
+		}
+		jobData, err := e.layout.encodeJob(sealed)
+		if err != nil {
+			return nil, err
+		}
+		fields[workflowJobPrefix+node.Name] = jobData
+		fields[workflowJobIDPrefix+node.Name] = job.ID
+		fields[workflowStatePrefix+node.Name] = string(types.NodePending)
+	}
+
+	key := e.layout.key(workflowKeyPrefix) + id
+	pipe := e.client.TxPipeline()
+	pipe.HSet(ctx, key, fields)
+	pipe.Expire(ctx, key, e.ttl)
+	pipe.ZAdd(ctx, e.layout.key(workflowIndexKey), &redis.Z{Score: float64(now.UnixNano()), Member: id})
+
+	// Index entries older than the TTL may point at expired workflows
+	cutoff := now.Add(-e.ttl).UnixNano()
+	pipe.ZRemRangeByScore(ctx, e.layout.key(workflowIndexKey), "-inf", "("+strconv.FormatInt(cutoff, 10))
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to save workflow: %w", err)
+	}
+
+	if err := e.release(ctx, id); err != nil {
+		return nil, err
+	}
+	return e.Get(ctx, id)
+}
+
+// Advance records how a node's job finished and enqueues or skips the
+// nodes that were waiting on it. Jobs that aren't workflow nodes are ignored.
+func (e *WorkflowEngine) Advance(ctx context.Context, finished *types.Job, result *types.JobResult) error {
+	if finished.WorkflowID == "" {
+		return nil
+	}
+
+	key := e.layout.key(workflowKeyPrefix) + finished.WorkflowID
+	state := types.NodeCompleted
+	if result.Status != types.StatusCompleted {
+		state = types.NodeFailed
+	}
+
+	pipe := e.client.TxPipeline()
+	if state == types.NodeCompleted && len(result.Output) > 0 {
+		pipe.HSet(ctx, key, workflowOutputPrefix+finished.WorkflowNode, []byte(result.Output))
+	}
+	if state == types.NodeFailed {
+		pipe.HSet(ctx, key, workflowErrorPrefix+finished.WorkflowNode, result.Error)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record workflow node: %w", err)
+	}
+
+	if _, err := e.transition(ctx, key, workflowStatePrefix+finished.WorkflowNode, string(types.NodeEnqueued), string(state)); err != nil {
+		return err
+	}
+	return e.release(ctx, finished.WorkflowID)
+}
+
+// release enqueues or skips every pending node whose dependencies have
+// finished, then marks the workflow finished once all nodes are
+func (e *WorkflowEngine) release(ctx context.Context, id string) error {
+	key := e.layout.key(workflowKeyPrefix) + id
+	fields, err := e.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("failed to load workflow: %w", err)
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("%w: %s", ErrWorkflowNotFound, id)
+	}
+
+	var workflow types.Workflow
+	if err := json.Unmarshal([]byte(fields[workflowDefinitionField]), &workflow); err != nil {
+		return fmt.Errorf("failed to unmarshal workflow: %w", err)
+	}
+
+	states := make(map[string]types.NodeState, len(workflow.Nodes))
+	outputs := make(map[string]json.RawMessage)
+	for _, node := range workflow.Nodes {
+		states[node.Name] = types.NodeState(fields[workflowStatePrefix+node.Name])
+		if output, ok := fields[workflowOutputPrefix+node.Name]; ok {
+			outputs[node.Name] = json.RawMessage(output)
+		}
+	}
+
+	// Skipping a node can settle its dependents, so repeat until nothing changes
+	var errs []error
+	for progress := true; progress; {
+		progress = false
+		for _, node := range workflow.Nodes {
+			if states[node.Name] != types.NodePending {
+				continue
+			}
+			finished, run := node.Ready(states, outputs)
+			if !finished {
+				continue
+			}
+
+			next := types.NodeSkipped
+			if run {
+				next = types.NodeEnqueued
+			}
+			won, err := e.transition(ctx, key, workflowStatePrefix+node.Name, string(types.NodePending), string(next))
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			states[node.Name] = next
+			progress = true
+			if !won || !run {
+				continue
+			}
+
+			if err := e.enqueueNode(ctx, fields[workflowJobPrefix+node.Name]); err != nil {
+				errs = append(errs, fmt.Errorf("failed to enqueue workflow node %s: %w", node.Name, err))
+				e.client.HSet(ctx, key, workflowErrorPrefix+node.Name, err.Error())
+				if _, err := e.transition(ctx, key, workflowStatePrefix+node.Name, string(types.NodeEnqueued), string(types.NodeFailed)); err != nil {
+					errs = append(errs, err)
+				}
+				states[node.Name] = types.NodeFailed
+			}
+		}
+	}
+
+	status := types.WorkflowCompleted
+	for _, state := range states {
+		if !state.Finished() {
+			status = types.WorkflowRunning
+			break
+		}
+		if state == types.NodeFailed {
+			status = types.WorkflowFailed
+		}
+	}
+	if status != types.WorkflowRunning {
+		won, err := e.transition(ctx, key, workflowStatusField, string(types.WorkflowRunning), string(status))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if won {
+			e.client.HSet(ctx, key, workflowFinishedField, time.Now().UTC().Format(time.RFC3339Nano))
+		}
+	}
+
+	if err := e.client.Expire(ctx, key, e.ttl).Err(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to refresh workflow TTL: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// enqueueNode opens a stored node job and enqueues it
+func (e *WorkflowEngine) enqueueNode(ctx context.Context, data string) error {
+	var job types.Job
+	if err := decodeJob([]byte(data), &job); err != nil {
+		return err
+	}
+	if err := openJob(&job, e.keyring); err != nil {
+		return err
+	}
+	return e.queue.Enqueue(ctx, &job)
+}
+
+// transition atomically changes a field from one value to another,
+// reporting whether this call changed it
+func (e *WorkflowEngine) transition(ctx context.Context, key, field, from, to string) (bool, error) {
+	changed, err := transitionScript.Run(ctx, e.client, []string{key}, field, from, to).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to update workflow: %w", err)
+	}
+	return changed == 1, nil
+}
+
+// Get returns a workflow with the state of its nodes, or nil when it
+// doesn't exist or has expired
+func (e *WorkflowEngine) Get(ctx context.Context, id string) (*types.WorkflowInfo, error) {
+	fields, err := e.client.HGetAll(ctx, e.layout.key(workflowKeyPrefix)+id).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow: %w", err)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return workflowInfo(id, fields)
+}
+
+// List returns up to limit workflows, newest first
+func (e *WorkflowEngine) List(ctx context.Context, limit int) ([]*types.WorkflowInfo, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	ids, err := e.client.ZRevRange(ctx, e.layout.key(workflowIndexKey), 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflows: %w", err)
+	}
+
+	infos := make([]*types.WorkflowInfo, 0, len(ids))
+	for _, id := range ids {
+		info, err := e.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if info != nil {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+// workflowInfo builds the API view of a workflow from its hash
+func workflowInfo(id string, fields map[string]string) (*types.WorkflowInfo, error) {
+	var workflow types.Workflow
+	if err := json.Unmarshal([]byte(fields[workflowDefinitionField]), &workflow); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal workflow: %w", err)
+	}
+
+	info := &types.WorkflowInfo{
+		ID:     id,
+		Name:   workflow.Name,
+		Status: types.WorkflowStatus(fields[workflowStatusField]),
+		Nodes:  make([]types.WorkflowNodeStatus, 0, len(workflow.Nodes)),
+	}
+	info.CreatedAt, _ = time.Parse(time.RFC3339Nano, fields[workflowCreatedField])
+	if finished, err := time.Parse(time.RFC3339Nano, fields[workflowFinishedField]); err == nil {
+		info.FinishedAt = &finished
+	}
+
+	for _, node := range workflow.Nodes {
+		info.Nodes = append(info.Nodes, types.WorkflowNodeStatus{
+			Name:  node.Name,
+			JobID: fields[workflowJobIDPrefix+node.Name],
+			State: types.NodeState(fields[workflowStatePrefix+node.Name]),
+			Error: fields[workflowErrorPrefix+node.Name],
+		})
+	}
+	return info, nil
+}
@@ -0,0 +1,216 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/aneeshsunganahalli/Gopher/internal/encryption"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+// nodeOutcome is how a node's job finishes when a worker runs it
+type nodeOutcome struct {
+	failed bool
+	output string
+}
+
+func TestWorkflowEngine(t *testing.T) {
+	node := func(name string, dependsOn ...string) types.WorkflowNode {
+		return types.WorkflowNode{Name: name, Type: "step", Payload: json.RawMessage(`{"step":"` + name + `"}`), DependsOn: dependsOn}
+	}
+	branch := func(name, parent, path, equals string) types.WorkflowNode {
+		n := node(name, parent)
+		n.When = &types.NodeCondition{Node: parent, Path: path, Equals: json.RawMessage(equals)}
+		return n
+	}
+	onFailure := func(name, parent string) types.WorkflowNode {
+		n := node(name, parent)
+		n.When = &types.NodeCondition{Node: parent, Status: types.NodeFailed}
+		return n
+	}
+
+	tests := []struct {
+		name       string
+		nodes      []types.WorkflowNode
+		outcomes   map[string]nodeOutcome // Nodes not listed complete with no output
+		wantOrder  []string               // Nodes in the order their jobs are enqueued
+		wantStates map[string]types.NodeState
+		wantStatus types.WorkflowStatus
+	}{
+		{
+			name:       "chain",
+			nodes:      []types.WorkflowNode{node("a"), node("b", "a"), node("c", "b")},
+			wantOrder:  []string{"a", "b", "c"},
+			wantStates: map[string]types.NodeState{"a": types.NodeCompleted, "b": types.NodeCompleted, "c": types.NodeCompleted},
+			wantStatus: types.WorkflowCompleted,
+		},
+		{
+			name:       "diamond waits for both parents",
+			nodes:      []types.WorkflowNode{node("fetch"), node("left", "fetch"), node("right", "fetch"), node("join", "left", "right")},
+			wantOrder:  []string{"fetch", "left", "right", "join"},
+			wantStates: map[string]types.NodeState{"fetch": types.NodeCompleted, "left": types.NodeCompleted, "right": types.NodeCompleted, "join": types.NodeCompleted},
+			wantStatus: types.WorkflowCompleted,
+		},
+		{
+			name:       "failure skips dependents",
+			nodes:      []types.WorkflowNode{node("a"), node("b", "a"), node("c", "b")},
+			outcomes:   map[string]nodeOutcome{"a": {failed: true}},
+			wantOrder:  []string{"a"},
+			wantStates: map[string]types.NodeState{"a": types.NodeFailed, "b": types.NodeSkipped, "c": types.NodeSkipped},
+			wantStatus: types.WorkflowFailed,
+		},
+		{
+			name:       "failure branch runs",
+			nodes:      []types.WorkflowNode{node("charge"), onFailure("refund", "charge"), node("ship", "charge")},
+			outcomes:   map[string]nodeOutcome{"charge": {failed: true}},
+			wantOrder:  []string{"charge", "refund"},
+			wantStates: map[string]types.NodeState{"charge": types.NodeFailed, "refund": types.NodeCompleted, "ship": types.NodeSkipped},
+			wantStatus: types.WorkflowFailed,
+		},
+		{
+			name: "branch on output",
+			nodes: []types.WorkflowNode{
+				node("review"),
+				branch("publish", "review", "review.approved", "true"),
+				branch("reject", "review", "review.approved", "false"),
+			},
+			outcomes:   map[string]nodeOutcome{"review": {output: `{"review":{"approved":true}}`}},
+			wantOrder:  []string{"review", "publish"},
+			wantStates: map[string]types.NodeState{"review": types.NodeCompleted, "publish": types.NodeCompleted, "reject": types.NodeSkipped},
+			wantStatus: types.WorkflowCompleted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server := miniredis.RunT(t)
+			q := newTestRedisQueue(t, server, RedisOptions{})
+			nodes := NewMemoryQueue(10 * time.Millisecond)
+			engine := NewWorkflowEngine(q.Client(), q.Layout(), nodes, time.Hour)
+
+			workflow := &types.Workflow{Name: tt.name, Nodes: tt.nodes}
+			jobs := make(map[string]*types.Job, len(tt.nodes))
+			for _, n := range tt.nodes {
+				jobs[n.Name] = types.NewJob(n.Type, n.Payload, 0)
+			}
+
+			info, err := engine.Submit(ctx, workflow, jobs)
+			if err != nil {
+				t.Fatalf("Submit: %v", err)
+			}
+
+			// Run node jobs as a worker would until none are left
+			var order []string
+			for {
+				job, err := nodes.Dequeue(ctx)
+				if err != nil {
+					t.Fatalf("Dequeue: %v", err)
+				}
+				if job == nil {
+					break
+				}
+				if job.WorkflowID != info.ID {
+					t.Fatalf("job of node %s belongs to workflow %q, want %q", job.WorkflowNode, job.WorkflowID, info.ID)
+				}
+				order = append(order, job.WorkflowNode)
+
+				outcome := tt.outcomes[job.WorkflowNode]
+				result := &types.JobResult{JobID: job.ID, Status: types.StatusCompleted, Output: json.RawMessage(outcome.output)}
+				if outcome.failed {
+					result.Status, result.Error = types.StatusFailed, "boom"
+				}
+				if err := engine.Advance(ctx, job, result); err != nil {
+					t.Fatalf("Advance %s: %v", job.WorkflowNode, err)
+				}
+			}
+
+			if len(order) != len(tt.wantOrder) {
+				t.Fatalf("nodes ran in order %v, want %v", order, tt.wantOrder)
+			}
+			for i := range order {
+				if order[i] != tt.wantOrder[i] {
+					t.Fatalf("nodes ran in order %v, want %v", order, tt.wantOrder)
+				}
+			}
+
+			got, err := engine.Get(ctx, info.ID)
+			if err != nil || got == nil {
+				t.Fatalf("Get = %v, %v; want the workflow", got, err)
+			}
+			if got.Status != tt.wantStatus || got.FinishedAt == nil {
+				t.Errorf("status = %s (finished at %v), want %s and a finish time", got.Status, got.FinishedAt, tt.wantStatus)
+			}
+			for _, n := range got.Nodes {
+				if n.State != tt.wantStates[n.Name] {
+					t.Errorf("node %s is %s, want %s", n.Name, n.State, tt.wantStates[n.Name])
+				}
+				if n.JobID != jobs[n.Name].ID {
+					t.Errorf("node %s has job %s, want %s", n.Name, n.JobID, jobs[n.Name].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestWorkflowEngineStorage(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	q := newTestRedisQueue(t, server, RedisOptions{KeyPrefix: "app:"})
+	nodes := NewMemoryQueue(10 * time.Millisecond)
+	engine := NewWorkflowEngine(q.Client(), q.Layout(), nodes, time.Hour)
+
+	keyring, err := encryption.NewKeyring(map[string][]byte{"v1": bytes.Repeat([]byte{1}, 32)}, "v1")
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	engine.SetKeyring(keyring)
+
+	workflow := &types.Workflow{Nodes: []types.WorkflowNode{
+		{Name: "a", Type: "step", Payload: json.RawMessage(`{}`)},
+		{Name: "b", Type: "step", Payload: json.RawMessage(`{"secret":"hunter2"}`), DependsOn: []string{"a"}},
+	}}
+	jobs := map[string]*types.Job{
+		"a": types.NewJob("step", workflow.Nodes[0].Payload, 0),
+		"b": types.NewJob("step", workflow.Nodes[1].Payload, 0),
+	}
+	info, err := engine.Submit(ctx, workflow, jobs)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	key := "app:workflow:" + info.ID
+	if stored := server.HGet(key, "job:b"); stored == "" || bytes.Contains([]byte(stored), []byte("hunter2")) {
+		t.Fatalf("node job stored as %q, want it sealed under the key prefix", stored)
+	}
+	if ttl := server.TTL(key); ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("workflow TTL = %v, want up to an hour", ttl)
+	}
+
+	// The released node's job is opened before it is enqueued
+	a, _ := nodes.Dequeue(ctx)
+	if err := engine.Advance(ctx, a, &types.JobResult{JobID: a.ID, Status: types.StatusCompleted}); err != nil {
+		t.Fatalf("Advance: %v", err)
+	}
+	b, _ := nodes.Dequeue(ctx)
+	if b == nil || b.KeyID != "" || !jsonEqual(t, b.Payload, jobs["b"].Payload) {
+		t.Fatalf("enqueued node job = %+v, want the plaintext job of b", b)
+	}
+
+	listed, err := engine.List(ctx, 10)
+	if err != nil || len(listed) != 1 || listed[0].ID != info.ID {
+		t.Fatalf("List = %v, %v; want the workflow", listed, err)
+	}
+
+	server.FastForward(time.Hour + time.Second)
+	if got, err := engine.Get(ctx, info.ID); err != nil || got != nil {
+		t.Fatalf("Get after the TTL = %v, %v; want nothing", got, err)
+	}
+	if err := engine.Advance(ctx, b, &types.JobResult{JobID: b.ID, Status: types.StatusCompleted}); err == nil {
+		t.Fatal("Advance of an expired workflow succeeded")
+	}
+}
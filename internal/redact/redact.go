@@ -0,0 +1,131 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+)
+
+// Mask replaces redacted values
+const Mask = "[REDACTED]"
+
+// AllTypes is the rule key that applies to every job type
+const AllTypes = "*"
+
+// Redactor masks sensitive payload fields before they reach logs and listings.
+// Rules map a job type to dotted JSON paths; "*" matches any key at that level
+// and arrays are traversed transparently.
+type Redactor struct {
+	rules map[string][][]string
+}
+
+// NewRedactor creates a redactor from job type → path rules
+func NewRedactor(rules map[string][]string) *Redactor {
+	parsed := make(map[string][][]string, len(rules))
+	for jobType, paths := range rules {
+		for _, path := range paths {
+			path = strings.TrimSpace(path)
+			if path == "" {
+				continue
+			}
+			parsed[jobType] = append(parsed[jobType], strings.Split(path, "."))
+		}
+	}
+
+	return &Redactor{rules: parsed}
+}
+
+// ParseRules parses a JSON object such as {"email":["to"],"*":["password"]}
+func ParseRules(spec string) (map[string][]string, error) {
+	rules := make(map[string][]string)
+	if strings.TrimSpace(spec) == "" {
+		return rules, nil
+	}
+
+	if err := json.Unmarshal([]byte(spec), &rules); err != nil {
+		return nil, fmt.Errorf("invalid redaction rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// Enabled reports whether any rules are configured
+func (r *Redactor) Enabled() bool {
+	return r != nil && len(r.rules) > 0
+}
+
+// Payload returns a copy of payload with all configured fields masked.
+// Payloads that are not JSON objects or arrays are returned unchanged.
+func (r *Redactor) Payload(jobType string, payload json.RawMessage) json.RawMessage {
+	if !r.Enabled() || len(payload) == 0 {
+		return payload
+	}
+
+	paths := make([][]string, 0, len(r.rules[AllTypes])+len(r.rules[jobType]))
+	paths = append(paths, r.rules[AllTypes]...)
+	paths = append(paths, r.rules[jobType]...)
+	if len(paths) == 0 {
+		return payload
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(payload, &doc); err != nil {
+		return payload
+	}
+
+	for _, path := range paths {
+		doc = redactPath(doc, path)
+	}
+
+	redacted, err := json.Marshal(doc)
+	if err != nil {
+		return payload
+	}
+
+	return redacted
+}
+
+// Job returns a shallow copy of the job with its payload redacted
+func (r *Redactor) Job(job *types.Job) *types.Job {
+	if !r.Enabled() || job == nil {
+		return job
+	}
+
+	redacted := *job
+	redacted.Payload = r.Payload(job.Type, job.Payload)
+	return &redacted
+}
+
+// redactPath masks the value at path inside doc
+func redactPath(doc interface{}, path []string) interface{} {
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		if len(path) == 0 {
+			return doc
+		}
+
+		head, rest := path[0], path[1:]
+		for key, value := range node {
+			if head != "*" && head != key {
+				continue
+			}
+			if len(rest) == 0 {
+				node[key] = Mask
+			} else {
+				node[key] = redactPath(value, rest)
+			}
+		}
+		return node
+
+	case []interface{}:
+		for i, item := range node {
+			node[i] = redactPath(item, path)
+		}
+		return node
+
+	default:
+		return doc
+	}
+}
@@ -0,0 +1,328 @@
+package selfcheck
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/job"
+	"github.com/aneeshsunganahalli/Gopher/internal/queue"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// Check outcomes, from harmless to blocking startup
+const (
+	StatusOK      = "ok"
+	StatusWarning = "warning"
+	StatusFatal   = "fatal"
+)
+
+// MinRedisVersion is the oldest Redis with the list commands the queue relies on (BLMOVE, LMOVE, LPOS)
+const MinRedisVersion = "6.2.0"
+
+// sampleSize bounds how many waiting jobs are inspected for unhandled types
+const sampleSize = 100
+
+// Result is the outcome of one startup check
+type Result struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// Report collects the results of a startup self-check
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// Fatal reports whether any check failed badly enough to refuse to start
+func (r *Report) Fatal() bool {
+	for _, result := range r.Results {
+		if result.Status == StatusFatal {
+			return true
+		}
+	}
+	return false
+}
+
+// Log writes one line per check, at a level matching its status
+func (r *Report) Log(logger *zap.Logger) {
+	for _, result := range r.Results {
+		fields := []zap.Field{zap.String("check", result.Name), zap.String("status", result.Status)}
+		switch result.Status {
+		case StatusFatal:
+			logger.Error("Startup check failed: "+result.Message, fields...)
+		case StatusWarning:
+			logger.Warn("Startup check warning: "+result.Message, fields...)
+		default:
+			logger.Info("Startup check passed: "+result.Message, fields...)
+		}
+	}
+}
+
+func (r *Report) add(name, status, message string, args ...interface{}) {
+	r.Results = append(r.Results, Result{Name: name, Status: status, Message: fmt.Sprintf(message, args...)})
+}
+
+// Options selects what the self-check verifies
+type Options struct {
+	Client       redis.Cmdable
+	Layout       *queue.Layout // The queue's, whose keys are checked
+	Registry     *job.Registry
+	MaxClockSkew time.Duration // Skew against Redis TIME that blocks startup; a tenth of it only warns
+
+	// Worker checks: the process must have handlers, and jobs waiting in
+	// QueueKeys are sampled for types it can't handle
+	Worker    bool
+	QueueKeys []string
+
+	// Server check: registered types are compared with those advertised by live worker pools
+	Heartbeats *queue.HeartbeatRegistry
+}
+
+// Run performs the startup checks; it never fails itself, problems are results
+func Run(ctx context.Context, opts Options) *Report {
+	report := &Report{}
+
+	checkRedisVersion(ctx, opts.Client, report)
+	checkEvictionPolicy(ctx, opts.Client, report)
+	checkKeyTypes(ctx, opts.Client, opts.Layout, report)
+	checkKeyPrefix(ctx, opts.Client, opts.Layout, report)
+	checkClockSkew(ctx, opts.Client, opts.MaxClockSkew, report)
+	if opts.Worker {
+		checkHandlers(ctx, opts, report)
+	} else if opts.Heartbeats != nil {
+		checkWorkerCoverage(ctx, opts, report)
+	}
+
+	return report
+}
+
+// checkRedisVersion requires a Redis new enough for the commands the queue uses
+func checkRedisVersion(ctx context.Context, client redis.Cmdable, report *Report) {
+	info, err := client.Info(ctx, "server").Result()
+	if err != nil {
+		report.add("redis_version", StatusFatal, "cannot read Redis server info: %v", err)
+		return
+	}
+
+	version := infoField(info, "redis_version")
+	if version == "" {
+		report.add("redis_version", StatusWarning, "Redis did not report its version; %s or later is required", MinRedisVersion)
+		return
+	}
+	if compareVersions(version, MinRedisVersion) < 0 {
+		report.add("redis_version", StatusFatal, "Redis %s is too old, %s or later is required", version, MinRedisVersion)
+		return
+	}
+	report.add("redis_version", StatusOK, "Redis %s", version)
+}
+
+// checkEvictionPolicy warns when Redis may evict keys, which silently loses jobs
+func checkEvictionPolicy(ctx context.Context, client redis.Cmdable, report *Report) {
+	info, err := client.Info(ctx, "memory").Result()
+	if err != nil {
+		report.add("eviction_policy", StatusWarning, "cannot read Redis memory info: %v", err)
+		return
+	}
+
+	policy := infoField(info, "maxmemory_policy")
+	if policy != "" && policy != "noeviction" {
+		report.add("eviction_policy", StatusWarning, "maxmemory-policy is %s, so Redis may evict queued jobs under memory pressure; use noeviction", policy)
+		return
+	}
+	report.add("eviction_policy", StatusOK, "keys are never evicted")
+}
+
+// checkKeyTypes refuses to share key names with data of another shape
+func checkKeyTypes(ctx context.Context, client redis.Cmdable, layout *queue.Layout, report *Report) {
+	expected := layout.KeyTypes()
+	keys := make([]string, 0, len(expected))
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pipe := client.Pipeline()
+	cmds := make([]*redis.StatusCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Type(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		report.add("key_collisions", StatusWarning, "cannot check key types: %v", err)
+		return
+	}
+
+	var conflicts []string
+	for i, key := range keys {
+		if kind := cmds[i].Val(); kind != "none" && kind != expected[key] {
+			conflicts = append(conflicts, fmt.Sprintf("%s is a %s, expected a %s", key, kind, expected[key]))
+		}
+	}
+	if len(conflicts) > 0 {
+		report.add("key_collisions", StatusFatal, "keys are used by something else, set REDIS_KEY_PREFIX or REDIS_DB: %s", strings.Join(conflicts, "; "))
+		return
+	}
+	report.add("key_collisions", StatusOK, "no foreign keys under prefix %q", layout.Prefix())
+}
+
+// checkKeyPrefix warns about jobs stranded under no prefix while a prefix is set
+func checkKeyPrefix(ctx context.Context, client redis.Cmdable, layout *queue.Layout, report *Report) {
+	prefix := layout.Prefix()
+	if prefix == "" {
+		return
+	}
+
+	unprefixed := strings.TrimPrefix(layout.QueueKey(""), prefix)
+	waiting, err := client.LLen(ctx, unprefixed).Result()
+	if err != nil && err != redis.Nil {
+		return
+	}
+	if waiting > 0 {
+		report.add("key_prefix", StatusWarning, "%d jobs wait in unprefixed %s, left by a deployment without REDIS_KEY_PREFIX; they are not served under %q", waiting, unprefixed, prefix)
+		return
+	}
+	report.add("key_prefix", StatusOK, "no jobs stranded outside prefix %q", prefix)
+}
+
+// checkClockSkew compares the local clock with Redis TIME, which schedules,
+// retries and heartbeats assume agree across processes
+func checkClockSkew(ctx context.Context, client redis.Cmdable, maxSkew time.Duration, report *Report) {
+	sent := time.Now()
+	redisTime, err := client.Time(ctx).Result()
+	if err != nil {
+		report.add("clock_skew", StatusWarning, "cannot read Redis time: %v", err)
+		return
+	}
+	received := time.Now()
+
+	// Redis read its clock roughly halfway through the round trip
+	local := sent.Add(received.Sub(sent) / 2)
+	skew := local.Sub(redisTime)
+	if skew < 0 {
+		skew = -skew
+	}
+	skew = skew.Round(time.Millisecond)
+
+	switch {
+	case maxSkew > 0 && skew > maxSkew:
+		report.add("clock_skew", StatusFatal, "local clock is %v off Redis time, more than %v; fix NTP", skew, maxSkew)
+	case maxSkew > 0 && skew > maxSkew/10:
+		report.add("clock_skew", StatusWarning, "local clock is %v off Redis time; schedules and retries will drift", skew)
+	default:
+		report.add("clock_skew", StatusOK, "local clock within %v of Redis time", skew)
+	}
+}
+
+// checkHandlers requires a worker to handle something, and samples waiting
+// jobs for types none of its handlers cover
+func checkHandlers(ctx context.Context, opts Options, report *Report) {
+	registered := opts.Registry.Type()
+	if len(registered) == 0 {
+		report.add("handlers", StatusFatal, "no job handlers are registered")
+		return
+	}
+
+	handled := make(map[string]bool, len(registered))
+	for _, jobType := range registered {
+		handled[jobType] = true
+	}
+
+	unhandled := map[string]bool{}
+	for _, key := range opts.QueueKeys {
+		entries, err := opts.Client.LRange(ctx, key, -sampleSize, -1).Result()
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			waiting, err := queue.DecodeJob([]byte(entry))
+			if err == nil && waiting.Type != "" && !handled[waiting.Type] {
+				unhandled[waiting.Type] = true
+			}
+		}
+	}
+
+	if len(unhandled) > 0 {
+		report.add("handlers", StatusWarning, "jobs waiting in the queue have types this worker has no handler for: %s", sortedKeys(unhandled))
+		return
+	}
+	report.add("handlers", StatusOK, "%d job handlers registered: %s", len(registered), sortedKeys(handled))
+}
+
+// checkWorkerCoverage warns about job types the server accepts but no live
+// worker pool has a handler for
+func checkWorkerCoverage(ctx context.Context, opts Options, report *Report) {
+	pools, err := opts.Heartbeats.List(ctx)
+	if err != nil {
+		report.add("handlers", StatusWarning, "cannot list worker pools: %v", err)
+		return
+	}
+	if len(pools) == 0 {
+		report.add("handlers", StatusWarning, "no worker pools are running, accepted jobs will wait")
+		return
+	}
+
+	covered := map[string]bool{}
+	for _, pool := range pools {
+		for _, jobType := range pool.JobTypes {
+			covered[jobType] = true
+		}
+	}
+
+	uncovered := map[string]bool{}
+	for _, jobType := range opts.Registry.Type() {
+		if !covered[jobType] {
+			uncovered[jobType] = true
+		}
+	}
+	if len(uncovered) > 0 {
+		report.add("handlers", StatusWarning, "job types accepted here have no handler in any of %d live worker pools: %s", len(pools), sortedKeys(uncovered))
+		return
+	}
+	report.add("handlers", StatusOK, "every accepted job type is handled by a live worker pool")
+}
+
+// infoField returns a field of an INFO reply
+func infoField(info, name string) string {
+	for _, line := range strings.Split(info, "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), name+":"); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// compareVersions compares dotted numeric versions like 7.2.4
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// sortedKeys joins a set's members in order
+func sortedKeys(set map[string]bool) string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
@@ -2,13 +2,28 @@ package server
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/aneeshsunganahalli/Gopher/internal/api"
+	"github.com/aneeshsunganahalli/Gopher/internal/auth"
 	"github.com/aneeshsunganahalli/Gopher/internal/config"
 	"github.com/aneeshsunganahalli/Gopher/internal/job"
+	"github.com/aneeshsunganahalli/Gopher/internal/limiter"
+	"github.com/aneeshsunganahalli/Gopher/internal/metrics"
 	"github.com/aneeshsunganahalli/Gopher/internal/queue"
+	"github.com/aneeshsunganahalli/Gopher/internal/redact"
+	"github.com/aneeshsunganahalli/Gopher/internal/trigger"
 	"github.com/aneeshsunganahalli/Gopher/pkg/types"
 
 	"github.com/gin-gonic/gin"
@@ -23,14 +38,52 @@ type Server struct {
 	logger   *zap.Logger
 	router   *gin.Engine
 	server   *http.Server
+
+	// Optional components
+	dlq        queue.DeadLetterQueue
+	redactor   *redact.Redactor
+	admin      *queue.Admin
+	history    *queue.History
+	chaos      *queue.ChaosStore
+	limiter    limiter.RateLimiter
+	schedule   queue.Schedules
+	priority   *queue.PriorityQueue
+	retries    *queue.RetryTracker
+	results    *queue.ResultStore
+	statuses   queue.StatusTracker
+	auth       auth.Provider
+	oidc       *auth.OIDC
+	sessions   *auth.SessionManager
+	triggers   *trigger.Manager
+	templates  *queue.TemplateStore
+	heartbeats *queue.HeartbeatRegistry
+	spool      *queue.Spool
+	readOnly   *queue.ReadOnlyStore
+	policies   *queue.PolicyStore
+	deps       *queue.DependencyTracker
+	workflows  *queue.WorkflowEngine
+	idempotent *queue.IdempotencyStore
+	quarantine *queue.Quarantine
+	metrics    *metrics.Metrics
+
+	// Default timezone for timestamps in responses
+	location *time.Location
 }
 
+// displayLocationKey is the gin context key holding the response timezone
+const displayLocationKey = "display_location"
+
 func NewServer(cfg *config.Config, queue queue.Queue, registry *job.Registry, logger *zap.Logger) *Server {
 	s := &Server{
 		config:   cfg,
 		queue:    queue,
 		registry: registry,
 		logger:   logger,
+		location: time.UTC,
+	}
+
+	if loc, err := cfg.Server.DisplayLocation(); err == nil {
+		s.location = loc
 	}
 
 	s.setupRouter()
@@ -39,6 +92,128 @@ func NewServer(cfg *config.Config, queue queue.Queue, registry *job.Registry, lo
 	return s
 }
 
+// SetDeadLetterQueue enables the dead letter queue endpoints
+func (s *Server) SetDeadLetterQueue(dlq queue.DeadLetterQueue) {
+	s.dlq = dlq
+}
+
+// SetMetrics records API requests and accepted jobs in Prometheus
+func (s *Server) SetMetrics(m *metrics.Metrics) {
+	s.metrics = m
+}
+
+// SetRedactor sets the redactor applied to payloads in logs and listings
+func (s *Server) SetRedactor(redactor *redact.Redactor) {
+	s.redactor = redactor
+}
+
+// SetAdmin enables the admin maintenance endpoints
+func (s *Server) SetAdmin(admin *queue.Admin) {
+	s.admin = admin
+}
+
+// SetRateLimiter enables the rate limit admin endpoints
+func (s *Server) SetRateLimiter(rateLimiter limiter.RateLimiter) {
+	s.limiter = rateLimiter
+}
+
+// SetPolicies applies per job type defaults for max retries and queue to
+// submitted jobs, serves them at /policies and enables runtime overrides
+func (s *Server) SetPolicies(policies *queue.PolicyStore) {
+	s.policies = policies
+}
+
+// SetScheduledQueue enables the recurring schedule endpoints
+func (s *Server) SetScheduledQueue(scheduled queue.Schedules) {
+	s.schedule = scheduled
+}
+
+// SetDependencyTracker enables submitting jobs with depends_on
+func (s *Server) SetDependencyTracker(deps *queue.DependencyTracker) {
+	s.deps = deps
+}
+
+// SetWorkflowEngine enables workflow submission and status
+func (s *Server) SetWorkflowEngine(workflows *queue.WorkflowEngine) {
+	s.workflows = workflows
+}
+
+// SetIdempotencyStore enables idempotency keys on job submission
+func (s *Server) SetIdempotencyStore(store *queue.IdempotencyStore) {
+	s.idempotent = store
+}
+
+// SetPriorityQueue enables the priority ratio admin endpoints and per-priority stats
+func (s *Server) SetPriorityQueue(priority *queue.PriorityQueue) {
+	s.priority = priority
+}
+
+// SetRetryTracker enables the pending retries endpoint
+func (s *Server) SetRetryTracker(retries *queue.RetryTracker) {
+	s.retries = retries
+}
+
+// SetResultStore enables the job results endpoints
+func (s *Server) SetResultStore(results *queue.ResultStore) {
+	s.results = results
+}
+
+// SetStatusTracker enables job status lookup by ID
+func (s *Server) SetStatusTracker(statuses queue.StatusTracker) {
+	s.statuses = statuses
+}
+
+// SetChaos enables the chaos mode admin endpoints
+func (s *Server) SetChaos(store *queue.ChaosStore) {
+	s.chaos = store
+}
+
+// SetAuthProvider requires authentication on the API; admin endpoints also need the admin role
+func (s *Server) SetAuthProvider(provider auth.Provider) {
+	s.auth = provider
+}
+
+// SetOIDC enables browser login through OpenID Connect with session cookies
+func (s *Server) SetOIDC(oidc *auth.OIDC, sessions *auth.SessionManager) {
+	s.oidc = oidc
+	s.sessions = sessions
+}
+
+// SetTriggers enables the webhook trigger endpoint
+func (s *Server) SetTriggers(triggers *trigger.Manager) {
+	s.triggers = triggers
+}
+
+// SetTemplates enables "template:<name>" job types and the template endpoints
+func (s *Server) SetTemplates(templates *queue.TemplateStore) {
+	s.templates = templates
+}
+
+// SetHistory enables the job history and archive endpoints
+func (s *Server) SetHistory(history *queue.History) {
+	s.history = history
+}
+
+// SetSpool holds enqueues that fail while the queue is unreachable, answering 202
+func (s *Server) SetSpool(spool *queue.Spool) {
+	s.spool = spool
+}
+
+// SetReadOnly enables the read-only maintenance toggle shared by all replicas
+func (s *Server) SetReadOnly(store *queue.ReadOnlyStore) {
+	s.readOnly = store
+}
+
+// SetHeartbeats enables the worker stats endpoint
+func (s *Server) SetHeartbeats(heartbeats *queue.HeartbeatRegistry) {
+	s.heartbeats = heartbeats
+}
+
+// SetQuarantine enables reviewing the jobs workers quarantined
+func (s *Server) SetQuarantine(quarantine *queue.Quarantine) {
+	s.quarantine = quarantine
+}
+
 func (s *Server) setupRouter() {
 
 	if s.config.Log.Level == "debug" {
@@ -52,15 +227,101 @@ func (s *Server) setupRouter() {
 	// Middleware
 	s.router.Use(gin.Recovery())
 	s.router.Use(s.loggingMiddleware())
+	s.router.Use(s.metricsMiddleware())
 	s.router.Use(s.corsMiddleware())
+	s.router.Use(s.timezoneMiddleware())
 
 	s.router.GET("/health", s.healthHandler)
+	s.router.GET("/readyz", s.healthHandler)
+
+	s.router.GET("/auth/login", s.loginHandler)
+	s.router.GET("/auth/callback", s.loginCallbackHandler)
+	s.router.POST("/auth/logout", s.logoutHandler)
 
-	v1 := s.router.Group("/api/v1")
+	// Webhook triggers authenticate with their own HMAC secret instead of API credentials
+	s.router.POST("/hooks/:name", s.rejectWhenReadOnly(), s.webhookTriggerHandler)
+
+	v1 := s.router.Group("/api/v1", s.authMiddleware())
 	{
-		v1.POST("/jobs", s.enqueueJobHandler)
+		v1.POST("/jobs", s.rejectWhenReadOnly(), s.enqueueJobHandler)
+		v1.POST("/chains", s.rejectWhenReadOnly(), s.enqueueChainHandler)
+		v1.GET("/workflows", s.listWorkflowsHandler)
+		v1.POST("/workflows", s.rejectWhenReadOnly(), s.submitWorkflowHandler)
+		v1.GET("/workflows/:id", s.getWorkflowHandler)
 		v1.GET("/jobs/types", s.listJobTypesHandler)
+		v1.GET("/jobs/:id", s.getJobStatusHandler)
+		v1.GET("/jobs/:id/result", s.getJobOutputHandler)
+		v1.GET("/jobs/:id/dependencies", s.getJobDependenciesHandler)
+		v1.GET("/templates", s.listTemplatesHandler)
+		v1.GET("/policies", s.listPoliciesHandler)
+		v1.GET("/templates/:name", s.getTemplateHandler)
+		v1.GET("/auth/me", s.currentUserHandler)
 		v1.GET("/queue/stats", s.queueStatsHandler)
+		v1.GET("/queue/retries", s.pendingRetriesHandler)
+		v1.GET("/queue/priority", s.priorityStatsHandler)
+		v1.GET("/queues", s.listQueuesHandler)
+		v1.GET("/workers/stats", s.workerStatsHandler)
+		v1.GET("/dlq", s.listFailedJobsHandler)
+		v1.GET("/dlq/stats", s.dlqStatsHandler)
+		v1.GET("/dlq/triage", s.dlqTriageHandler)
+
+		v1.GET("/schedules", s.listSchedulesHandler)
+		v1.POST("/schedules", s.rejectWhenReadOnly(), s.createScheduleHandler)
+		v1.POST("/schedules/:id/pause", s.rejectWhenReadOnly(), s.pauseScheduleHandler)
+		v1.POST("/schedules/:id/resume", s.rejectWhenReadOnly(), s.resumeScheduleHandler)
+		v1.GET("/schedules/:id/runs", s.listScheduleRunsHandler)
+
+		v1.GET("/results", s.listResultsHandler)
+		v1.GET("/results/:id", s.getResultHandler)
+
+		v1.GET("/history", s.listHistoryHandler)
+		v1.GET("/history/:id", s.getHistoryHandler)
+		v1.GET("/archive", s.listArchiveHandler)
+		v1.GET("/archive/:id", s.getArchiveHandler)
+		v1.POST("/archive/:id/restore", s.rejectWhenReadOnly(), s.restoreArchiveHandler)
+	}
+
+	admin := v1.Group("/admin", s.requireRole(auth.RoleAdmin))
+	{
+		admin.POST("/erasure", s.rejectWhenReadOnly(), s.eraseSubjectHandler)
+
+		admin.POST("/dlq/:id/retry", s.rejectWhenReadOnly(), s.retryFailedJobHandler)
+
+		admin.DELETE("/history/:id", s.rejectWhenReadOnly(), s.deleteHistoryHandler)
+
+		admin.GET("/ratelimits", s.listRateLimitsHandler)
+		admin.GET("/ratelimits/:jobType", s.getRateLimitHandler)
+		admin.PUT("/ratelimits/:jobType", s.rejectWhenReadOnly(), s.setRateLimitHandler)
+
+		admin.GET("/priority", s.getPriorityHandler)
+		admin.PUT("/priority", s.rejectWhenReadOnly(), s.setPriorityHandler)
+
+		admin.DELETE("/queues/:target", s.rejectWhenReadOnly(), s.purgeQueueHandler)
+		admin.GET("/queues/paused", s.listPausedQueuesHandler)
+		admin.POST("/queues/:target/pause", s.rejectWhenReadOnly(), s.pauseQueueHandler)
+		admin.POST("/queues/:target/resume", s.rejectWhenReadOnly(), s.resumeQueueHandler)
+
+		admin.GET("/aliases", s.listAliasesHandler)
+		admin.PUT("/aliases/:alias", s.rejectWhenReadOnly(), s.switchAliasHandler)
+		admin.DELETE("/aliases/:alias", s.rejectWhenReadOnly(), s.deleteAliasHandler)
+
+		admin.PUT("/policies/:jobType", s.rejectWhenReadOnly(), s.putPolicyHandler)
+		admin.DELETE("/policies/:jobType", s.rejectWhenReadOnly(), s.deletePolicyHandler)
+
+		admin.PUT("/templates/:name", s.rejectWhenReadOnly(), s.putTemplateHandler)
+		admin.DELETE("/templates/:name", s.rejectWhenReadOnly(), s.deleteTemplateHandler)
+
+		admin.GET("/read-only", s.getReadOnlyHandler)
+		admin.PUT("/read-only", s.setReadOnlyHandler)
+		admin.DELETE("/read-only", s.clearReadOnlyHandler)
+
+		admin.GET("/chaos", s.getChaosHandler)
+		admin.PUT("/chaos", s.rejectWhenReadOnly(), s.setChaosHandler)
+		admin.DELETE("/chaos", s.rejectWhenReadOnly(), s.clearChaosHandler)
+
+		admin.GET("/quarantine", s.listQuarantineHandler)
+		admin.POST("/quarantine/:id/approve", s.rejectWhenReadOnly(), s.approveQuarantinedHandler)
+		admin.POST("/quarantine/:id/reject", s.rejectWhenReadOnly(), s.rejectQuarantinedHandler)
 	}
 }
 
@@ -112,7 +373,7 @@ func (s *Server) healthHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"status":    "healthy",
-		"timestamp": time.Now().UTC(),
+		"timestamp": displayTime(c, time.Now()),
 		"version":   "1.0.0",
 	})
 }
@@ -129,31 +390,60 @@ func (s *Server) enqueueJobHandler(c *gin.Context) {
 		return
 	}
 
-	// Validate job type is supported
-	if _, err := s.registry.Get(request.Type); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Unsupported job type",
-			"details": fmt.Sprintf("Job type '%s' is not registered", request.Type),
-		})
+	key, ok := s.idempotencyKey(c, request)
+	if !ok {
 		return
 	}
 
-	// Set default max retries if not specified
-	maxRetries := s.config.Worker.MaxRetries
-	if request.MaxRetries != nil {
-		maxRetries = *request.MaxRetries
+	job, ok := s.newJob(c, request)
+	if !ok {
+		return
 	}
 
-	// Create job
-	job := types.NewJob(request.Type, request.Payload, maxRetries)
+	if key != "" {
+		if !s.claimIdempotencyKey(c, key, request, job) {
+			return
+		}
+		// A submission that fails frees the key for the client's retry
+		defer func() {
+			if c.Writer.Status() >= http.StatusBadRequest {
+				s.releaseIdempotencyKey(key, job.ID)
+			}
+		}()
+	}
+
+	// Jobs with dependencies wait in the tracker until their parents finish
+	if len(job.DependsOn) > 0 {
+		s.submitDependentJob(c, job)
+		return
+	}
 
 	// Enqueue job
 	if err := s.queue.Enqueue(c.Request.Context(), job); err != nil {
+		if s.spoolJob(job, err) {
+			s.observeEnqueued(job)
+			c.JSON(http.StatusAccepted, types.JobResponse{
+				JobID:     job.ID,
+				Status:    string(types.StatusPending),
+				CreatedAt: displayTime(c, job.CreatedAt),
+				Spooled:   true,
+			})
+			return
+		}
+
 		s.logger.Error("Failed to enqueue job",
 			zap.String("job_id", job.ID),
 			zap.String("job_type", job.Type),
 			zap.Error(err),
 		)
+		if errors.Is(err, queue.ErrCircuitOpen) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(s.config.Redis.BreakerCooldown.Seconds()))))
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "Queue temporarily unavailable",
+				"details": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to enqueue job",
 			"details": err.Error(),
@@ -161,102 +451,3289 @@ func (s *Server) enqueueJobHandler(c *gin.Context) {
 		return
 	}
 
+	s.observeEnqueued(job)
 	s.logger.Info("Job enqueued successfully",
 		zap.String("job_id", job.ID),
 		zap.String("job_type", job.Type),
+		zap.String("queue", job.Queue),
+	)
+	s.logger.Debug("Enqueued job payload",
+		zap.String("job_id", job.ID),
+		zap.ByteString("payload", s.redactor.Payload(job.Type, job.Payload)),
 	)
 
 	response := types.JobResponse{
 		JobID:     job.ID,
 		Status:    string(types.StatusPending),
-		CreatedAt: job.CreatedAt,
+		CreatedAt: displayTime(c, job.CreatedAt),
 	}
 
 	c.JSON(http.StatusCreated, response)
 }
 
-// List job types handler
-func (s *Server) listJobTypesHandler(c *gin.Context) {
-	handlers := s.registry.ListHandlers()
+// idempotencyKey returns the submission's idempotency key from the
+// Idempotency-Key header or the request, scoped to the authenticated caller
+// so callers can't collide. It responds with the error and returns false
+// when the key can't be used.
+func (s *Server) idempotencyKey(c *gin.Context, request types.JobRequest) (string, bool) {
+	key := c.GetHeader("Idempotency-Key")
+	if request.IdempotencyKey != "" {
+		if key != "" && key != request.IdempotencyKey {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Conflicting idempotency keys",
+				"details": "The Idempotency-Key header and idempotency_key field differ",
+			})
+			return "", false
+		}
+		key = request.IdempotencyKey
+	}
+	if key == "" {
+		return "", true
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"job_types": handlers,
+	if s.idempotent == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Idempotency keys are not enabled",
+		})
+		return "", false
+	}
+	if len(key) > queue.MaxIdempotencyKeyLength {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid idempotency key",
+			"details": fmt.Sprintf("Idempotency keys can be at most %d characters", queue.MaxIdempotencyKeyLength),
+		})
+		return "", false
+	}
+
+	if principal := auth.FromContext(c.Request.Context()); principal != nil {
+		key = fmt.Sprintf("%d:%s:%s", len(principal.Subject), principal.Subject, key)
+	}
+	return key, true
+}
+
+// claimIdempotencyKey records key for job. When the key was used before it
+// responds with the job first submitted under it, or a conflict if that was
+// a different request, and returns false.
+func (s *Server) claimIdempotencyKey(c *gin.Context, key string, request types.JobRequest, job *types.Job) bool {
+	request.IdempotencyKey = ""
+	data, err := json.Marshal(request)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to check idempotency key",
+			"details": err.Error(),
+		})
+		return false
+	}
+	sum := sha256.Sum256(data)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	previous, err := s.idempotent.Claim(c.Request.Context(), key, queue.IdempotencyRecord{
+		JobID:       job.ID,
+		Fingerprint: fingerprint,
+		CreatedAt:   job.CreatedAt,
+	})
+	if err != nil {
+		s.logger.Error("Failed to claim idempotency key", zap.String("job_id", job.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to check idempotency key",
+			"details": err.Error(),
+		})
+		return false
+	}
+	if previous == nil {
+		return true
+	}
+
+	if previous.Fingerprint != fingerprint {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "Idempotency key reused",
+			"details": "The idempotency key was already used for a different job request",
+			"job_id":  previous.JobID,
+		})
+		return false
+	}
+
+	s.countSuppressed(c.Request.Context(), job.Type)
+
+	status := string(types.StatusPending)
+	if s.statuses != nil {
+		if record, err := s.statuses.GetStatus(c.Request.Context(), previous.JobID); err == nil && record != nil {
+			status = string(record.Status)
+		}
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.JSON(http.StatusOK, types.JobResponse{
+		JobID:     previous.JobID,
+		Status:    status,
+		CreatedAt: displayTime(c, previous.CreatedAt),
 	})
+	return false
 }
 
-// Queue stats handler
-func (s *Server) queueStatsHandler(c *gin.Context) {
-	// Get queue stats if supported
-	if redisQueue, ok := s.queue.(*queue.RedisQueue); ok {
-		stats, err := redisQueue.GetStats(c.Request.Context())
+// countSuppressed records a repeated submission of jobType that enqueued
+// nothing, in the stats shared by every server and in this one's metrics
+func (s *Server) countSuppressed(ctx context.Context, jobType string) {
+	if s.metrics != nil {
+		s.metrics.ObserveSuppressed(jobType)
+	}
+	if err := s.idempotent.RecordSuppressed(ctx, jobType); err != nil {
+		s.logger.Warn("Failed to count suppressed submission", zap.String("job_type", jobType), zap.Error(err))
+	}
+}
+
+// releaseIdempotencyKey frees the key of a submission that failed. It
+// doesn't use the request's context, which is gone when the client hung up.
+func (s *Server) releaseIdempotencyKey(key, jobID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Redis.Timeout)
+	defer cancel()
+
+	if err := s.idempotent.Release(ctx, key, jobID); err != nil {
+		s.logger.Warn("Failed to release idempotency key", zap.String("job_id", jobID), zap.Error(err))
+	}
+}
+
+// newJob builds a job from a submission request, expanding templates and
+// applying policy defaults. It responds with the error and returns false
+// when the request can't be accepted.
+func (s *Server) newJob(c *gin.Context, request types.JobRequest) (*types.Job, bool) {
+	// Expand template references into the template's type and defaults
+	if name, ok := types.TemplateName(request.Type); ok {
+		if !s.requireTemplates(c) {
+			return nil, false
+		}
+		tpl, err := s.templates.Get(c.Request.Context(), name)
+		if errors.Is(err, queue.ErrTemplateNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Unknown job template",
+				"details": fmt.Sprintf("Job template '%s' is not defined", name),
+			})
+			return nil, false
+		}
 		if err != nil {
-			s.logger.Error("Failed to get queue stats", zap.Error(err))
 			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to get queue statistics",
+				"error":   "Failed to get job template",
+				"details": err.Error(),
 			})
-			return
+			return nil, false
+		}
+		if request, err = tpl.Apply(request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid payload for template",
+				"details": err.Error(),
+			})
+			return nil, false
 		}
+	}
 
-		c.JSON(http.StatusOK, stats)
-		return
+	// Checked before the registry so scoped keys can't probe for types
+	if !s.allowJobType(c, request.Type) {
+		return nil, false
 	}
 
-	// Fallback to basic queue size
-	size, err := s.queue.Size(c.Request.Context())
-	if err != nil {
-		s.logger.Error("Failed to get queue size", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get queue size",
+	// Validate job type is supported
+	if _, err := s.registry.Get(request.Type); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Unsupported job type",
+			"details": fmt.Sprintf("Job type '%s' is not registered", request.Type),
 		})
-		return
+		return nil, false
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"queue_size": size,
-	})
-}
-func (s *Server) loggingMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		start := time.Now()
-		path := c.Request.URL.Path
-		raw := c.Request.URL.RawQuery
+	// Set default max retries if not specified
+	maxRetries := s.defaultMaxRetries(request.Type)
+	if request.MaxRetries != nil {
+		maxRetries = *request.MaxRetries
+	}
 
-		// Process request
-		c.Next()
+	if request.Priority != "" && request.Priority != queue.PriorityHigh &&
+		request.Priority != queue.PriorityNormal && request.Priority != queue.PriorityLow {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid priority",
+			"details": fmt.Sprintf("Priority must be %s, %s or %s", queue.PriorityHigh, queue.PriorityNormal, queue.PriorityLow),
+		})
+		return nil, false
+	}
 
-		// Log request
-		duration := time.Since(start)
-		clientIP := c.ClientIP()
-		method := c.Request.Method
-		statusCode := c.Writer.Status()
+	if err := request.Metadata.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid metadata",
+			"details": err.Error(),
+		})
+		return nil, false
+	}
 
-		if raw != "" {
-			path = path + "?" + raw
+	if request.Queue != "" {
+		if err := types.ValidateQueueName(request.Queue); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid queue",
+				"details": err.Error(),
+			})
+			return nil, false
 		}
+	}
 
-		s.logger.Info("HTTP request",
-			zap.String("client_ip", clientIP),
-			zap.String("method", method),
-			zap.String("path", path),
-			zap.Int("status", statusCode),
-			zap.Duration("duration", duration),
-			zap.Int("size", c.Writer.Size()),
-		)
+	var timeout time.Duration
+	if request.Timeout != "" {
+		parsed, err := types.ParseJobTimeout(request.Timeout)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid timeout",
+				"details": err.Error(),
+			})
+			return nil, false
+		}
+		timeout = parsed
 	}
-}
 
-func (s *Server) corsMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+	if len(request.DependsOn) > 0 && !s.requireDependencies(c) {
+		return nil, false
+	}
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid expires_at",
+			"details": "expires_at must be in the future",
+		})
+		return nil, false
+	}
+
+	if request.PayloadVersion < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid payload_version",
+			"details": "payload_version cannot be negative",
+		})
+		return nil, false
+	}
+
+	if request.Backoff != nil {
+		if err := request.Backoff.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid backoff",
+				"details": err.Error(),
+			})
+			return nil, false
 		}
+	}
 
-		c.Next()
+	// Create job
+	job := types.NewJob(request.Type, request.Payload, maxRetries)
+	job.Metadata = request.Metadata.Clone()
+	job.Queue = request.Queue
+	job.Timeout = timeout
+	job.PayloadVersion = request.PayloadVersion
+	job.Backoff = request.Backoff
+	if request.ExpiresAt != nil {
+		expiresAt := request.ExpiresAt.UTC()
+		job.ExpiresAt = &expiresAt
+	}
+	// The type's policy routes jobs that pick neither a queue nor a priority
+	if job.Queue == "" && request.Priority == "" {
+		job.Queue = s.policies.Get(job.Type).Queue
+	}
+	if request.Priority != "" {
+		job.SetPriority(request.Priority)
+	}
+	job.DependsOn = request.DependsOn
+	job.OnParentFailure = request.OnParentFailure
+
+	// Hooks rewrite the payload, so the schema check sees what is persisted
+	if !s.applyEnqueueHooks(c, job) || !s.validatePayload(c, job.Type, job.Payload) {
+		return nil, false
 	}
+
+	return job, true
+}
+
+// validatePayload responds with 422 listing every schema violation unless
+// the payload fits the schema the job type's handler advertises
+func (s *Server) validatePayload(c *gin.Context, jobType string, payload json.RawMessage) bool {
+	violations := s.registry.ValidatePayload(jobType, payload)
+	if len(violations) == 0 {
+		return true
+	}
+
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":      "Payload does not match the job type's schema",
+		"details":    violations[0].Error(),
+		"job_type":   jobType,
+		"violations": violations,
+	})
+	return false
+}
+
+// applyEnqueueHooks runs the job type's enqueue hooks and responds with the
+// error when one fails
+func (s *Server) applyEnqueueHooks(c *gin.Context, submitted *types.Job) bool {
+	err := s.registry.ApplyEnqueueHooks(c.Request.Context(), submitted)
+	if err == nil {
+		return true
+	}
+
+	if errors.Is(err, job.ErrPayloadRejected) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Payload rejected by enqueue hook",
+			"details": err.Error(),
+		})
+		return false
+	}
+	s.logger.Error("Enqueue hook failed",
+		zap.String("job_type", submitted.Type),
+		zap.Error(err),
+	)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "Enqueue hook failed",
+		"details": err.Error(),
+	})
+	return false
+}
+
+// submitDependentJob holds a job until the jobs it depends on complete, or
+// enqueues it right away when they already have
+func (s *Server) submitDependentJob(c *gin.Context, job *types.Job) {
+	held, err := s.deps.Submit(c.Request.Context(), job)
+	if errors.Is(err, queue.ErrDependencyFailed) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Dependency failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		s.logger.Error("Failed to submit dependent job",
+			zap.String("job_id", job.ID),
+			zap.String("job_type", job.Type),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to submit job",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	status := types.StatusPending
+	if held {
+		status = types.StatusWaiting
+	}
+	s.observeEnqueued(job)
+	s.logger.Info("Dependent job submitted",
+		zap.String("job_id", job.ID),
+		zap.String("job_type", job.Type),
+		zap.Strings("depends_on", job.DependsOn),
+		zap.String("status", string(status)),
+	)
+
+	c.JSON(http.StatusCreated, types.JobResponse{
+		JobID:     job.ID,
+		Status:    string(status),
+		CreatedAt: displayTime(c, job.CreatedAt),
+	})
+}
+
+// Submit chain handler
+func (s *Server) enqueueChainHandler(c *gin.Context) {
+	var request types.ChainRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		s.logger.Error("Invalid chain request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !s.requireDependencies(c) {
+		return
+	}
+
+	jobs := make([]*types.Job, 0, len(request.Jobs))
+	for _, jobRequest := range request.Jobs {
+		job, ok := s.newJob(c, jobRequest)
+		if !ok {
+			return
+		}
+		jobs = append(jobs, job)
+	}
+
+	chainID, err := types.Chain(jobs...)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid chain",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Hold the jobs from the tail so none can be released before it waits
+	ctx := c.Request.Context()
+	for i := len(jobs) - 1; i > 0; i-- {
+		if _, err := s.deps.Submit(ctx, jobs[i]); err != nil {
+			s.abandonChain(jobs[i:])
+			s.logger.Error("Failed to submit chained job",
+				zap.String("chain_id", chainID),
+				zap.String("job_id", jobs[i].ID),
+				zap.Error(err),
+			)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to submit chain",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+	if err := s.queue.Enqueue(ctx, jobs[0]); err != nil {
+		s.abandonChain(jobs)
+		s.logger.Error("Failed to enqueue chain",
+			zap.String("chain_id", chainID),
+			zap.String("job_id", jobs[0].ID),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to enqueue chain",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	s.observeEnqueued(jobs...)
+	s.logger.Info("Chain submitted",
+		zap.String("chain_id", chainID),
+		zap.Int("jobs", len(jobs)),
+	)
+
+	response := types.ChainResponse{
+		ChainID:   chainID,
+		Jobs:      make([]types.JobResponse, 0, len(jobs)),
+		CreatedAt: displayTime(c, jobs[0].CreatedAt),
+	}
+	for i, job := range jobs {
+		status := types.StatusWaiting
+		if i == 0 {
+			status = types.StatusPending
+		}
+		response.Jobs = append(response.Jobs, types.JobResponse{
+			JobID:     job.ID,
+			Status:    string(status),
+			CreatedAt: displayTime(c, job.CreatedAt),
+		})
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// abandonChain drops the jobs already held for a chain that couldn't be
+// submitted. Resolving a job as failed cancels the one held behind it, so
+// every job but the last is resolved.
+func (s *Server) abandonChain(chain []*types.Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, job := range chain[:len(chain)-1] {
+		failed := &types.JobResult{JobID: job.ID, Status: types.StatusFailed}
+		if _, err := s.deps.Resolve(ctx, job, failed); err != nil {
+			s.logger.Warn("Failed to abandon chained job",
+				zap.String("job_id", job.ID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// Submit workflow handler, accepting the DAG as JSON or YAML
+func (s *Server) submitWorkflowHandler(c *gin.Context) {
+	if !s.requireWorkflows(c) {
+		return
+	}
+
+	var workflow types.Workflow
+	if err := bindWorkflow(c, &workflow); err != nil {
+		s.logger.Error("Invalid workflow request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := workflow.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid workflow",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	jobs := make(map[string]*types.Job, len(workflow.Nodes))
+	for _, node := range workflow.Nodes {
+		job, ok := s.newJob(c, node.JobRequest())
+		if !ok {
+			return
+		}
+		jobs[node.Name] = job
+	}
+
+	info, err := s.workflows.Submit(c.Request.Context(), &workflow, jobs)
+	if err != nil {
+		s.logger.Error("Failed to submit workflow",
+			zap.String("workflow", workflow.Name),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to submit workflow",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	for _, job := range jobs {
+		s.observeEnqueued(job)
+	}
+	s.logger.Info("Workflow submitted",
+		zap.String("workflow_id", info.ID),
+		zap.String("workflow", info.Name),
+		zap.Int("nodes", len(info.Nodes)),
+	)
+	c.JSON(http.StatusCreated, workflowResponse(c, info))
+}
+
+// bindWorkflow decodes a workflow sent as JSON, or as YAML with a YAML
+// content type. YAML is converted to JSON first so payloads stay raw JSON.
+func bindWorkflow(c *gin.Context, workflow *types.Workflow) error {
+	switch c.ContentType() {
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		var document map[string]interface{}
+		if err := c.ShouldBindYAML(&document); err != nil {
+			return err
+		}
+		data, err := json.Marshal(document)
+		if err != nil {
+			return fmt.Errorf("workflow YAML cannot be converted to JSON: %w", err)
+		}
+		return json.Unmarshal(data, workflow)
+	}
+	return c.ShouldBindJSON(workflow)
+}
+
+// Get workflow handler
+func (s *Server) getWorkflowHandler(c *gin.Context) {
+	if !s.requireWorkflows(c) {
+		return
+	}
+
+	id := c.Param("id")
+	info, err := s.workflows.Get(c.Request.Context(), id)
+	if err != nil {
+		s.logger.Error("Failed to get workflow", zap.String("workflow_id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get workflow",
+		})
+		return
+	}
+	if info == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":       "Workflow not found",
+			"workflow_id": id,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, workflowResponse(c, info))
+}
+
+// List workflows handler, newest first
+func (s *Server) listWorkflowsHandler(c *gin.Context) {
+	if !s.requireWorkflows(c) {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "limit must be a positive integer",
+		})
+		return
+	}
+
+	infos, err := s.workflows.List(c.Request.Context(), limit)
+	if err != nil {
+		s.logger.Error("Failed to list workflows", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list workflows",
+		})
+		return
+	}
+
+	workflows := make([]*types.WorkflowInfo, 0, len(infos))
+	for _, info := range infos {
+		workflows = append(workflows, workflowResponse(c, info))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"workflows": workflows,
+		"count":     len(workflows),
+	})
+}
+
+// workflowResponse renders a workflow's timestamps in the response timezone
+func workflowResponse(c *gin.Context, info *types.WorkflowInfo) *types.WorkflowInfo {
+	info.CreatedAt = displayTime(c, info.CreatedAt)
+	if info.FinishedAt != nil {
+		finishedAt := displayTime(c, *info.FinishedAt)
+		info.FinishedAt = &finishedAt
+	}
+	return info
+}
+
+// requireWorkflows responds with 501 unless the workflow engine is configured
+func (s *Server) requireWorkflows(c *gin.Context) bool {
+	if s.workflows == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Workflows are not enabled",
+		})
+		return false
+	}
+	return true
+}
+
+// List recurring schedules handler
+func (s *Server) listSchedulesHandler(c *gin.Context) {
+	if !s.requireSchedules(c) {
+		return
+	}
+
+	scheduled, err := s.schedule.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list schedules",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	response := api.ListSchedulesResponse{Schedules: []api.ScheduleInfo{}}
+	for i := range scheduled {
+		if scheduled[i].Recurring {
+			response.Schedules = append(response.Schedules, s.scheduleInfo(c, &scheduled[i]))
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Create recurring schedule handler
+func (s *Server) createScheduleHandler(c *gin.Context) {
+	if !s.requireSchedules(c) {
+		return
+	}
+
+	var request api.CreateScheduleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !s.allowJobType(c, request.Type) {
+		return
+	}
+	if _, err := s.registry.Get(request.Type); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Unsupported job type",
+			"details": fmt.Sprintf("Job type '%s' is not registered", request.Type),
+		})
+		return
+	}
+
+	maxRetries := s.defaultMaxRetries(request.Type)
+	if request.MaxRetries != nil {
+		maxRetries = *request.MaxRetries
+	}
+
+	job := types.NewJob(request.Type, request.Payload, maxRetries)
+	if !s.applyEnqueueHooks(c, job) || !s.validatePayload(c, job.Type, job.Payload) {
+		return
+	}
+	if err := s.schedule.ScheduleRecurring(c.Request.Context(), job, request.CronExpression); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create schedule",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Schedule created",
+		"job_id":  job.ID,
+	})
+}
+
+// Pause schedule handler
+func (s *Server) pauseScheduleHandler(c *gin.Context) {
+	s.setSchedulePaused(c, true)
+}
+
+// Resume schedule handler
+func (s *Server) resumeScheduleHandler(c *gin.Context) {
+	s.setSchedulePaused(c, false)
+}
+
+func (s *Server) setSchedulePaused(c *gin.Context, paused bool) {
+	if !s.requireSchedules(c) {
+		return
+	}
+
+	id := c.Param("id")
+	scheduled, err := s.schedule.SetPaused(c.Request.Context(), id, paused)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, queue.ErrScheduleNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to update schedule",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	s.logger.Info("Schedule pause state changed",
+		zap.String("schedule_id", id),
+		zap.Bool("paused", paused),
+	)
+
+	c.JSON(http.StatusOK, s.scheduleInfo(c, scheduled))
+}
+
+// List schedule runs handler
+func (s *Server) listScheduleRunsHandler(c *gin.Context) {
+	if !s.requireSchedules(c) {
+		return
+	}
+
+	limit := queryInt(c, "limit", 50)
+	if limit <= 0 || limit > 1000 {
+		limit = 50
+	}
+
+	id := c.Param("id")
+	runs, err := s.schedule.Runs(c.Request.Context(), id, limit)
+	if err != nil {
+		s.logger.Error("Failed to list schedule runs", zap.String("schedule_id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list schedule runs",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	response := api.ListScheduleRunsResponse{
+		ScheduleID: id,
+		Runs:       make([]api.ScheduleRunInfo, 0, len(runs)),
+	}
+	for _, run := range runs {
+		info := api.ScheduleRunInfo{
+			JobID:      run.JobID,
+			EnqueuedAt: displayTime(c, run.EnqueuedAt),
+			Status:     string(run.Status),
+			Attempts:   run.Attempts,
+			Duration:   run.Duration,
+			Error:      run.Error,
+		}
+		if run.FinishedAt != nil {
+			finishedAt := displayTime(c, *run.FinishedAt)
+			info.FinishedAt = &finishedAt
+		}
+		// Unfinished runs may have a retrying attempt in the result store
+		if info.Status == "" && s.results != nil {
+			if record, err := s.results.Get(c.Request.Context(), run.JobID); err == nil && record != nil {
+				info.Status = string(record.Status)
+			}
+		}
+		response.Runs = append(response.Runs, info)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// scheduleInfo converts a scheduled job for API responses, redacting its payload
+func (s *Server) scheduleInfo(c *gin.Context, scheduled *types.ScheduledJob) api.ScheduleInfo {
+	info := api.ScheduleInfo{
+		ID:             scheduled.ID,
+		Type:           scheduled.Job.Type,
+		Payload:        s.redactor.Payload(scheduled.Job.Type, scheduled.Job.Payload),
+		CronExpression: scheduled.CronExpression,
+		NextRunAt:      displayTime(c, scheduled.ExecuteAt),
+		Paused:         scheduled.Paused,
+	}
+	if scheduled.PausedAt != nil {
+		pausedAt := displayTime(c, *scheduled.PausedAt)
+		info.PausedAt = &pausedAt
+	}
+	return info
+}
+
+// requireSchedules responds with 501 unless the scheduled queue is configured
+func (s *Server) requireSchedules(c *gin.Context) bool {
+	if s.schedule == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Scheduled jobs are not enabled",
+		})
+		return false
+	}
+	return true
+}
+
+// requireDependencies responds with 501 unless the dependency tracker is configured
+func (s *Server) requireDependencies(c *gin.Context) bool {
+	if s.deps == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Job dependencies are not enabled",
+		})
+		return false
+	}
+	return true
+}
+
+// List job types handler
+func (s *Server) listJobTypesHandler(c *gin.Context) {
+	handlers := s.registry.ListHandlers()
+	capabilities := s.registry.Capabilities()
+
+	infos := make([]api.JobTypeInfo, 0, len(handlers))
+	for jobType, description := range handlers {
+		capability := capabilities[jobType]
+		info := api.JobTypeInfo{
+			Type:           jobType,
+			Description:    description,
+			Version:        capability.Version,
+			PayloadSchema:  capability.PayloadSchema,
+			ExamplePayload: capability.ExamplePayload,
+			DefaultPolicy:  capability.DefaultPolicy,
+		}
+		if len(info.ExamplePayload) == 0 && len(info.PayloadSchema) > 0 {
+			if example, err := types.ExampleFromSchema(info.PayloadSchema); err == nil {
+				info.ExamplePayload = example
+			}
+		}
+		if policy, ok := s.policies.Policies()[jobType]; ok {
+			info.Policy = &policy
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Type < infos[j].Type })
+
+	c.JSON(http.StatusOK, gin.H{
+		"job_types": handlers,
+		"handlers":  infos,
+	})
+}
+
+// Pending retries handler: jobs waiting out their backoff, per type and in due order
+func (s *Server) pendingRetriesHandler(c *gin.Context) {
+	if s.retries == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Retry tracking is not enabled",
+		})
+		return
+	}
+
+	retries, err := s.retries.List(c.Request.Context())
+	if err != nil {
+		s.logger.Error("Failed to list pending retries", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list pending retries",
+		})
+		return
+	}
+
+	summary, err := s.retries.Summary(c.Request.Context())
+	if err != nil {
+		s.logger.Error("Failed to summarize pending retries", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to summarize pending retries",
+		})
+		return
+	}
+
+	for jobType, typeSummary := range summary.ByType {
+		typeSummary.NextDueAt = displayTime(c, typeSummary.NextDueAt)
+		typeSummary.LastDueAt = displayTime(c, typeSummary.LastDueAt)
+		summary.ByType[jobType] = typeSummary
+	}
+
+	limit := queryInt(c, "limit", 100)
+	if limit < 0 || limit > len(retries) {
+		limit = len(retries)
+	}
+	retries = retries[:limit]
+	for i := range retries {
+		retries[i].DueAt = displayTime(c, retries[i].DueAt)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"summary": summary,
+		"retries": retries,
+	})
+}
+
+// defaultMaxRetries returns the job type's policy max retries, or WORKER_MAX_RETRIES
+func (s *Server) defaultMaxRetries(jobType string) int {
+	if retries := s.policies.Get(jobType).MaxRetries; retries != nil {
+		return *retries
+	}
+	return s.config.Worker.MaxRetries
+}
+
+// Queue stats handler
+func (s *Server) queueStatsHandler(c *gin.Context) {
+	// Get queue stats if supported
+	if provider, ok := s.queue.(queue.StatsProvider); ok {
+		stats, err := provider.GetStats(c.Request.Context())
+		if err != nil {
+			s.logger.Error("Failed to get queue stats", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to get queue statistics",
+			})
+			return
+		}
+
+		// A wrapped queue hides the priority breakdown
+		if stats.ByPriority == nil && s.priority != nil {
+			if byPriority, err := s.priority.StatsByPriority(c.Request.Context()); err == nil {
+				stats.ByPriority = byPriority
+			}
+		}
+		if s.idempotent != nil {
+			if suppressed, err := s.idempotent.Suppressed(c.Request.Context()); err == nil && len(suppressed) > 0 {
+				stats.Suppressed = suppressed
+			}
+		}
+
+		c.JSON(http.StatusOK, stats)
+		return
+	}
+
+	// Fallback to basic queue size
+	size, err := s.queue.Size(c.Request.Context())
+	if err != nil {
+		s.logger.Error("Failed to get queue size", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get queue size",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"queue_size": size,
+	})
+}
+
+// List failed jobs handler
+func (s *Server) listFailedJobsHandler(c *gin.Context) {
+	if s.dlq == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Dead letter queue is not configured",
+		})
+		return
+	}
+
+	offset := queryInt(c, "offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+	limit := queryInt(c, "limit", 50)
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	var (
+		jobs  []*types.FailedJobInfo
+		total int
+		err   error
+	)
+
+	if value := c.Query("reason"); value != "" {
+		reason, parseErr := types.ParseFailureReason(value)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid reason",
+				"details": parseErr.Error(),
+			})
+			return
+		}
+
+		jobs, total, err = s.dlq.ListByReason(c.Request.Context(), reason, offset, limit)
+		if err != nil {
+			s.logger.Error("Failed to list failed jobs", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to list failed jobs",
+			})
+			return
+		}
+	} else {
+		jobs, err = s.dlq.List(c.Request.Context(), offset, limit)
+		if err != nil {
+			s.logger.Error("Failed to list failed jobs", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to list failed jobs",
+			})
+			return
+		}
+
+		total, err = s.dlq.Size(c.Request.Context())
+		if err != nil {
+			s.logger.Error("Failed to get DLQ size", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to get DLQ size",
+			})
+			return
+		}
+	}
+
+	response := api.ListFailedJobsResponse{
+		Jobs:       make([]api.FailedJobInfo, 0, len(jobs)),
+		TotalCount: total,
+	}
+	for _, info := range jobs {
+		if info.Job == nil {
+			continue
+		}
+
+		response.Jobs = append(response.Jobs, api.FailedJobInfo{
+			JobID:       info.Job.ID,
+			Type:        info.Job.Type,
+			Payload:     string(s.redactor.Payload(info.Job.Type, info.Job.Payload)),
+			Error:       info.Error,
+			Fingerprint: info.FingerprintOrCompute(),
+			Reason:      string(info.ReasonOrUnknown()),
+			Attempts:    info.Job.Attempts,
+			MaxRetries:  info.Job.MaxRetries,
+			FailedAt:    displayTime(c, info.FailedAt),
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Retry failed job handler, moving a dead-lettered job back to its queue
+// with its attempts reset
+func (s *Server) retryFailedJobHandler(c *gin.Context) {
+	if s.dlq == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Dead letter queue is not configured",
+		})
+		return
+	}
+
+	// Callers may only retry jobs of the types they may enqueue
+	jobID := c.Param("id")
+	info, err := s.findFailedJob(c.Request.Context(), jobID)
+	if err != nil {
+		s.retryError(c, jobID, err)
+		return
+	}
+	if !s.allowJobType(c, info.Job.Type) {
+		return
+	}
+
+	if err := s.dlq.Reprocess(c.Request.Context(), jobID); err != nil {
+		s.retryError(c, jobID, err)
+		return
+	}
+
+	s.logger.Info("Failed job retried",
+		zap.String("job_id", jobID),
+		zap.String("job_type", info.Job.Type),
+		zap.String("reason", string(info.ReasonOrUnknown())),
+	)
+	c.JSON(http.StatusOK, gin.H{
+		"job_id": jobID,
+		"status": "requeued",
+	})
+}
+
+// retryError maps DLQ retry errors to HTTP responses
+func (s *Server) retryError(c *gin.Context, jobID string, err error) {
+	if errors.Is(err, queue.ErrNotInDLQ) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Job not in dead letter queue",
+		})
+		return
+	}
+
+	s.logger.Error("Failed to retry failed job",
+		zap.String("job_id", jobID),
+		zap.Error(err),
+	)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "Failed to retry job",
+		"details": err.Error(),
+	})
+}
+
+// findFailedJob pages through the DLQ for a job
+func (s *Server) findFailedJob(ctx context.Context, jobID string) (*types.FailedJobInfo, error) {
+	const page = 500
+	for offset := 0; ; offset += page {
+		infos, err := s.dlq.List(ctx, offset, page)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			if info.Job != nil && info.Job.ID == jobID {
+				return info, nil
+			}
+		}
+		if len(infos) < page {
+			return nil, fmt.Errorf("%w: %s", queue.ErrNotInDLQ, jobID)
+		}
+	}
+}
+
+// Data-subject erasure handler
+func (s *Server) eraseSubjectHandler(c *gin.Context) {
+	if !s.requireAdmin(c) {
+		return
+	}
+
+	var request queue.ErasureRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// The spool goes first so it can't flush a matching job behind the erasure
+	spooled := 0
+	if s.spool != nil {
+		n, err := s.spool.Erase(request)
+		if err != nil {
+			s.logger.Error("Failed to erase spooled jobs", zap.String("field", request.Field), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Erasure failed",
+				"details": err.Error(),
+			})
+			return
+		}
+		spooled = n
+	}
+
+	report, err := s.admin.EraseSubject(c.Request.Context(), request)
+	if report != nil {
+		report.Spool = spooled
+	}
+	if err != nil {
+		s.logger.Error("Data-subject erasure failed",
+			zap.String("field", request.Field),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Erasure failed",
+			"details": err.Error(),
+			"partial": report,
+		})
+		return
+	}
+
+	// The subject value itself is deliberately not logged
+	s.logger.Info("Data-subject erasure completed",
+		zap.String("field", request.Field),
+		zap.String("mode", request.Mode),
+		zap.Int("pending", report.Pending),
+		zap.Int("scheduled", report.Scheduled),
+		zap.Int("dlq", report.DLQ),
+		zap.Int("history", report.History),
+		zap.Int("recordings", report.Recordings),
+		zap.Int("dependencies", report.Dependencies),
+		zap.Int("workflows", report.Workflows),
+		zap.Int("quarantine", report.Quarantine),
+		zap.Int("results", report.Results),
+		zap.Int("statuses", report.Statuses),
+		zap.Int("spool", report.Spool),
+	)
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Rate limits handler, listing every known job type with its current token level
+func (s *Server) listRateLimitsHandler(c *gin.Context) {
+	if !s.requireRateLimiter(c) {
+		return
+	}
+
+	limits, err := s.limiter.Limits(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list rate limits",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	throttled := []string{}
+	for _, limit := range limits {
+		if limit.Limit >= 0 && limit.Tokens < 1 {
+			throttled = append(throttled, limit.JobType)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"limits":    limits,
+		"throttled": throttled,
+	})
+}
+
+// Rate limit handler
+func (s *Server) getRateLimitHandler(c *gin.Context) {
+	if !s.requireRateLimiter(c) {
+		return
+	}
+
+	limit, err := s.limiter.GetLimit(c.Request.Context(), c.Param("jobType"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get rate limit",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, limit)
+}
+
+// Rate limit update handler, applied by workers on their next dequeue
+func (s *Server) setRateLimitHandler(c *gin.Context) {
+	if !s.requireRateLimiter(c) {
+		return
+	}
+
+	var request struct {
+		Limit *float64 `json:"limit" binding:"required"` // Jobs per second, 0 stops the type, negative removes the limit
+		Burst int      `json:"burst" binding:"min=0"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	jobType := c.Param("jobType")
+	burst := request.Burst
+	if burst == 0 && *request.Limit > 0 {
+		burst = int(math.Ceil(*request.Limit))
+	}
+
+	if err := s.limiter.SetLimit(c.Request.Context(), jobType, *request.Limit, burst); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to set rate limit",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	s.logger.Info("Rate limit changed",
+		zap.String("job_type", jobType),
+		zap.Float64("limit", *request.Limit),
+		zap.Int("burst", burst),
+	)
+
+	limit, err := s.limiter.GetLimit(c.Request.Context(), jobType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get rate limit",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, limit)
+}
+
+// requireRateLimiter responds with 501 unless rate limiting is configured
+func (s *Server) requireRateLimiter(c *gin.Context) bool {
+	if s.limiter == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Rate limiting is not configured",
+		})
+		return false
+	}
+	return true
+}
+
+// Priority ratio handler, with the dequeue mix achieved so far
+func (s *Server) getPriorityHandler(c *gin.Context) {
+	if !s.requirePriority(c) {
+		return
+	}
+
+	mix, err := s.priority.Mix(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get priority mix",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, mix)
+}
+
+// Priority stats handler, showing depth and throughput per priority level
+func (s *Server) priorityStatsHandler(c *gin.Context) {
+	if !s.requirePriority(c) {
+		return
+	}
+
+	stats, err := s.priority.StatsByPriority(c.Request.Context())
+	if err != nil {
+		s.logger.Error("Failed to get priority stats", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get priority stats",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"priorities": stats,
+	})
+}
+
+// Priority ratio update handler, picked up by workers within a few seconds
+func (s *Server) setPriorityHandler(c *gin.Context) {
+	if !s.requirePriority(c) {
+		return
+	}
+
+	var ratio queue.PriorityRatio
+	if err := c.ShouldBindJSON(&ratio); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := ratio.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid priority ratio",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := s.priority.SavePriorityRatio(c.Request.Context(), ratio); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to set priority ratio",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	s.logger.Info("Priority ratio changed", zap.String("ratio", ratio.String()))
+
+	s.getPriorityHandler(c)
+}
+
+// requirePriority responds with 501 unless the priority queue is in use
+func (s *Server) requirePriority(c *gin.Context) bool {
+	if s.priority == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Priority queues are not enabled (set QUEUE_PRIORITY)",
+		})
+		return false
+	}
+	return true
+}
+
+// List job policies handler
+func (s *Server) listPoliciesHandler(c *gin.Context) {
+	policies := s.policies.Policies()
+	overridden := []string{}
+	for jobType := range policies {
+		if s.policies.Overridden(jobType) {
+			overridden = append(overridden, jobType)
+		}
+	}
+	sort.Strings(overridden)
+
+	c.JSON(http.StatusOK, gin.H{
+		"policies":   policies,
+		"overridden": overridden,
+		"count":      len(policies),
+	})
+}
+
+// Override job policy handler, replacing a job type's policy at runtime
+func (s *Server) putPolicyHandler(c *gin.Context) {
+	if !s.requirePolicies(c) {
+		return
+	}
+
+	var policy types.JobPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	jobType := c.Param("jobType")
+	if _, err := s.registry.Get(jobType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Unsupported job type",
+			"details": fmt.Sprintf("Job type '%s' is not registered", jobType),
+		})
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid job policy",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := s.policies.Put(c.Request.Context(), jobType, policy); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, queue.ErrPoliciesReadOnly) {
+			status = http.StatusNotImplemented
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to save job policy",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	s.logger.Info("Job policy overridden", zap.String("job_type", jobType))
+
+	c.JSON(http.StatusOK, gin.H{
+		"job_type": jobType,
+		"policy":   policy,
+	})
+}
+
+// Clear job policy override handler, reverting a job type to its configured policy
+func (s *Server) deletePolicyHandler(c *gin.Context) {
+	if !s.requirePolicies(c) {
+		return
+	}
+
+	jobType := c.Param("jobType")
+	removed, err := s.policies.Clear(c.Request.Context(), jobType)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, queue.ErrPoliciesReadOnly) {
+			status = http.StatusNotImplemented
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to clear job policy",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Job policy override not found",
+		})
+		return
+	}
+
+	s.logger.Info("Job policy override cleared", zap.String("job_type", jobType))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Job policy override cleared",
+		"job_type": jobType,
+		"policy":   s.policies.Get(jobType),
+	})
+}
+
+// requirePolicies responds with 501 unless job policies are configured
+func (s *Server) requirePolicies(c *gin.Context) bool {
+	if s.policies == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Job policies are not configured",
+		})
+		return false
+	}
+	return true
+}
+
+// List job templates handler
+func (s *Server) listTemplatesHandler(c *gin.Context) {
+	if !s.requireTemplates(c) {
+		return
+	}
+
+	templates, err := s.templates.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list job templates",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"templates": templates,
+	})
+}
+
+// Get job template handler
+func (s *Server) getTemplateHandler(c *gin.Context) {
+	if !s.requireTemplates(c) {
+		return
+	}
+
+	tpl, err := s.templates.Get(c.Request.Context(), c.Param("name"))
+	if errors.Is(err, queue.ErrTemplateNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Job template not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get job template",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tpl)
+}
+
+// Create or replace job template handler
+func (s *Server) putTemplateHandler(c *gin.Context) {
+	if !s.requireTemplates(c) {
+		return
+	}
+
+	var tpl types.JobTemplate
+	if err := c.ShouldBindJSON(&tpl); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	tpl.Name = c.Param("name")
+
+	if err := tpl.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid job template",
+			"details": err.Error(),
+		})
+		return
+	}
+	if _, err := s.registry.Get(tpl.Type); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Unsupported job type",
+			"details": fmt.Sprintf("Job type '%s' is not registered", tpl.Type),
+		})
+		return
+	}
+	if tpl.Priority != "" && tpl.Priority != queue.PriorityHigh &&
+		tpl.Priority != queue.PriorityNormal && tpl.Priority != queue.PriorityLow {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid priority",
+			"details": fmt.Sprintf("Priority must be %s, %s or %s", queue.PriorityHigh, queue.PriorityNormal, queue.PriorityLow),
+		})
+		return
+	}
+
+	if err := s.templates.Put(c.Request.Context(), tpl); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, queue.ErrTemplatesReadOnly) {
+			status = http.StatusNotImplemented
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to save job template",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	s.logger.Info("Job template saved",
+		zap.String("template", tpl.Name),
+		zap.String("job_type", tpl.Type),
+	)
+
+	c.JSON(http.StatusOK, tpl)
+}
+
+// Delete job template handler
+func (s *Server) deleteTemplateHandler(c *gin.Context) {
+	if !s.requireTemplates(c) {
+		return
+	}
+
+	err := s.templates.Delete(c.Request.Context(), c.Param("name"))
+	if errors.Is(err, queue.ErrTemplateNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Job template not found",
+		})
+		return
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, queue.ErrTemplatesReadOnly) {
+			status = http.StatusNotImplemented
+		} else if errors.Is(err, queue.ErrTemplateConfigured) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to delete job template",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	s.logger.Info("Job template deleted", zap.String("template", c.Param("name")))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Job template deleted",
+		"template": c.Param("name"),
+	})
+}
+
+// requireTemplates responds with 501 unless job templates are configured
+func (s *Server) requireTemplates(c *gin.Context) bool {
+	if s.templates == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Job templates are not configured",
+		})
+		return false
+	}
+	return true
+}
+
+// Queue aliases handler
+func (s *Server) listAliasesHandler(c *gin.Context) {
+	if !s.requireAdmin(c) {
+		return
+	}
+
+	aliases, err := s.admin.Aliases(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list queue aliases",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"aliases": aliases,
+	})
+}
+
+// Alias switch handler for blue/green cutovers
+func (s *Server) switchAliasHandler(c *gin.Context) {
+	if !s.requireAdmin(c) {
+		return
+	}
+
+	var request struct {
+		Queue string `json:"queue" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result, err := s.admin.SwitchAlias(c.Request.Context(), c.Param("alias"), request.Queue)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to switch queue alias",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	s.logger.Info("Queue alias switched",
+		zap.String("alias", result.Alias),
+		zap.String("from", result.From),
+		zap.String("to", result.To),
+		zap.Int("from_pending", result.FromPending),
+	)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Queue listing handler, the overview of every queue with depth and throughput
+func (s *Server) listQueuesHandler(c *gin.Context) {
+	if !s.requireAdmin(c) {
+		return
+	}
+
+	queues, err := s.admin.ListQueues(c.Request.Context())
+	if err != nil {
+		s.logger.Error("Failed to list queues", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list queues",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	for i := range queues {
+		if queues[i].OldestEnqueuedAt != nil {
+			oldest := displayTime(c, *queues[i].OldestEnqueuedAt)
+			queues[i].OldestEnqueuedAt = &oldest
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"queues": queues,
+		"count":  len(queues),
+	})
+}
+
+// Paused queues handler
+func (s *Server) listPausedQueuesHandler(c *gin.Context) {
+	if !s.requireAdmin(c) {
+		return
+	}
+
+	pauses, err := s.admin.PausedQueues(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list paused queues",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	for i := range pauses {
+		pauses[i].PausedAt = displayTime(c, pauses[i].PausedAt)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"paused": pauses,
+		"count":  len(pauses),
+	})
+}
+
+// Queue pause handler; workers stop dequeuing within seconds while enqueues continue
+func (s *Server) pauseQueueHandler(c *gin.Context) {
+	if !s.requireAdmin(c) {
+		return
+	}
+
+	name := c.Param("target")
+	if err := types.ValidateQueueName(name); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid queue name",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request format",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	pause, err := s.admin.PauseQueue(c.Request.Context(), name, request.Reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to pause queue",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	s.logger.Warn("Queue paused",
+		zap.String("queue", pause.Queue),
+		zap.String("reason", pause.Reason),
+	)
+
+	pause.PausedAt = displayTime(c, pause.PausedAt)
+	c.JSON(http.StatusOK, pause)
+}
+
+// Queue resume handler
+func (s *Server) resumeQueueHandler(c *gin.Context) {
+	if !s.requireAdmin(c) {
+		return
+	}
+
+	name := c.Param("target")
+	if err := types.ValidateQueueName(name); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid queue name",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	resumed, err := s.admin.ResumeQueue(c.Request.Context(), name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to resume queue",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !resumed {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Queue is not paused",
+		})
+		return
+	}
+
+	s.logger.Info("Queue resumed", zap.String("queue", name))
+	c.JSON(http.StatusOK, gin.H{
+		"queue":  name,
+		"paused": false,
+	})
+}
+
+// Alias delete handler
+func (s *Server) deleteAliasHandler(c *gin.Context) {
+	if !s.requireAdmin(c) {
+		return
+	}
+
+	if err := s.admin.DeleteAlias(c.Request.Context(), c.Param("alias")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete queue alias",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Queue alias deleted",
+		"alias":   c.Param("alias"),
+	})
+}
+
+// spoolJob holds a job whose enqueue failed because the queue is unreachable
+// and reports whether it was spooled
+func (s *Server) spoolJob(job *types.Job, enqueueErr error) bool {
+	if s.spool == nil || !(queue.IsTransient(enqueueErr) || errors.Is(enqueueErr, queue.ErrCircuitOpen)) {
+		return false
+	}
+
+	if err := s.spool.Add(job); err != nil {
+		s.logger.Error("Failed to spool job", zap.String("job_id", job.ID), zap.Error(err))
+		return false
+	}
+
+	s.logger.Warn("Queue unavailable, job spooled",
+		zap.String("job_id", job.ID),
+		zap.String("job_type", job.Type),
+		zap.Int("spooled", s.spool.Len()),
+		zap.Error(enqueueErr),
+	)
+	return true
+}
+
+// Queue purge handler; deletes jobs permanently, so it needs force=true
+func (s *Server) purgeQueueHandler(c *gin.Context) {
+	target := c.Param("target")
+
+	var purge func(ctx context.Context) (int, error)
+	switch target {
+	case "main":
+		name := c.Query("queue")
+		if name != "" {
+			if err := types.ValidateQueueName(name); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":   "Invalid queue",
+					"details": err.Error(),
+				})
+				return
+			}
+		}
+		if s.admin != nil {
+			purge = func(ctx context.Context) (int, error) {
+				return s.admin.PurgeQueue(ctx, name)
+			}
+		}
+	case "priority":
+		if s.priority != nil {
+			purge = s.priority.Purge
+		}
+	case "scheduled":
+		if purger, ok := s.schedule.(queue.Purger); ok {
+			purge = purger.Purge
+		}
+	case "failed":
+		if purger, ok := s.dlq.(queue.Purger); ok {
+			purge = purger.Purge
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid purge target",
+			"details": "Target must be main, priority, scheduled or failed",
+		})
+		return
+	}
+	if purge == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": fmt.Sprintf("Purging the %s queue is not supported", target),
+		})
+		return
+	}
+
+	if c.Query("force") != "true" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Purge not confirmed",
+			"details": fmt.Sprintf("Purging deletes every job in the %s queue permanently; repeat with force=true", target),
+		})
+		return
+	}
+
+	purged, err := purge(c.Request.Context())
+	if err != nil {
+		s.logger.Error("Failed to purge queue", zap.String("target", target), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to purge queue",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	s.logger.Warn("Queue purged",
+		zap.String("target", target),
+		zap.String("queue", c.Query("queue")),
+		zap.Int("purged", purged),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"target": target,
+		"purged": purged,
+	})
+}
+
+// requireAdmin responds with 501 unless admin operations are configured
+func (s *Server) requireAdmin(c *gin.Context) bool {
+	if s.admin == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Admin operations are not configured",
+		})
+		return false
+	}
+	return true
+}
+
+// Chaos settings handler
+func (s *Server) getChaosHandler(c *gin.Context) {
+	if !s.requireChaos(c) {
+		return
+	}
+
+	settings, overridden, err := s.chaos.Get(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get chaos settings",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !overridden {
+		settings = s.config.Chaos.Settings()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settings":   settings,
+		"overridden": overridden,
+	})
+}
+
+// Chaos override handler, picked up by every chaos-enabled process within seconds
+func (s *Server) setChaosHandler(c *gin.Context) {
+	if !s.requireChaos(c) {
+		return
+	}
+
+	var settings queue.ChaosSettings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := settings.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid chaos settings",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := s.chaos.Set(c.Request.Context(), settings); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to store chaos settings",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	s.logger.Warn("Chaos settings changed",
+		zap.Float64("failure_rate", settings.FailureRate),
+		zap.Int("latency_ms", settings.LatencyMs),
+		zap.Float64("drop_ack_rate", settings.DropAckRate),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"settings":   settings,
+		"overridden": true,
+	})
+}
+
+// Chaos reset handler, reverting to the configured defaults
+func (s *Server) clearChaosHandler(c *gin.Context) {
+	if !s.requireChaos(c) {
+		return
+	}
+
+	if err := s.chaos.Clear(c.Request.Context()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to clear chaos settings",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	s.logger.Info("Chaos settings reset to defaults")
+	c.JSON(http.StatusOK, gin.H{
+		"settings":   s.config.Chaos.Settings(),
+		"overridden": false,
+	})
+}
+
+// requireChaos responds with 501 unless chaos mode is enabled
+func (s *Server) requireChaos(c *gin.Context) bool {
+	if s.chaos == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Chaos mode is not enabled",
+		})
+		return false
+	}
+	return true
+}
+
+// Read-only mode handler
+func (s *Server) getReadOnlyHandler(c *gin.Context) {
+	if !s.requireReadOnly(c) {
+		return
+	}
+
+	mode, err := s.readOnly.Get(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get read-only mode",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, readOnlyResponse(c, mode))
+}
+
+// Read-only mode switch, honoured by every replica within seconds
+func (s *Server) setReadOnlyHandler(c *gin.Context) {
+	if !s.requireReadOnly(c) {
+		return
+	}
+
+	var mode queue.ReadOnlyMode
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&mode); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request format",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+	if mode.RetryAfter < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid read-only mode",
+			"details": "retry_after_seconds must not be negative",
+		})
+		return
+	}
+
+	enabled, err := s.readOnly.Enable(c.Request.Context(), mode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to enable read-only mode",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	s.logger.Warn("Read-only mode enabled, enqueues will be rejected",
+		zap.String("reason", enabled.Reason),
+		zap.Int("retry_after_seconds", enabled.RetryAfter),
+	)
+	c.JSON(http.StatusOK, readOnlyResponse(c, enabled))
+}
+
+// Read-only mode reset handler, accepting enqueues again
+func (s *Server) clearReadOnlyHandler(c *gin.Context) {
+	if !s.requireReadOnly(c) {
+		return
+	}
+
+	if err := s.readOnly.Disable(c.Request.Context()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to disable read-only mode",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	s.logger.Info("Read-only mode disabled")
+	c.JSON(http.StatusOK, readOnlyResponse(c, nil))
+}
+
+// readOnlyResponse describes the read-only mode, nil meaning writable
+func readOnlyResponse(c *gin.Context, mode *queue.ReadOnlyMode) gin.H {
+	if mode == nil {
+		return gin.H{"read_only": false}
+	}
+	return gin.H{
+		"read_only":           true,
+		"reason":              mode.Reason,
+		"retry_after_seconds": mode.RetryAfter,
+		"since":               displayTime(c, mode.Since),
+	}
+}
+
+// rejectWhenReadOnly answers 503 with Retry-After while read-only mode is on
+func (s *Server) rejectWhenReadOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.readOnly == nil {
+			c.Next()
+			return
+		}
+
+		mode := s.readOnly.Current(c.Request.Context())
+		if mode == nil {
+			c.Next()
+			return
+		}
+
+		details := "The API is in read-only mode for maintenance"
+		if mode.Reason != "" {
+			details = mode.Reason
+		}
+		c.Header("Retry-After", strconv.Itoa(mode.RetryAfter))
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "API is read-only",
+			"details": details,
+		})
+	}
+}
+
+// requireReadOnly responds with 501 unless the read-only toggle is available
+func (s *Server) requireReadOnly(c *gin.Context) bool {
+	if s.readOnly == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Read-only mode is not available",
+		})
+		return false
+	}
+	return true
+}
+
+// DLQ stats handler
+func (s *Server) dlqStatsHandler(c *gin.Context) {
+	if s.dlq == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Dead letter queue is not configured",
+		})
+		return
+	}
+
+	stats, err := s.dlq.GetStats(c.Request.Context())
+	if err != nil {
+		s.logger.Error("Failed to get DLQ stats", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get DLQ statistics",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// List quarantine handler, newest first
+func (s *Server) listQuarantineHandler(c *gin.Context) {
+	if !s.requireQuarantine(c) {
+		return
+	}
+
+	offset := queryInt(c, "offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+	limit := queryInt(c, "limit", 50)
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	entries, total, err := s.quarantine.List(c.Request.Context(), offset, limit)
+	if err != nil {
+		s.logger.Error("Failed to list quarantined jobs", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list quarantined jobs",
+		})
+		return
+	}
+
+	response := api.ListQuarantinedJobsResponse{
+		Jobs:       make([]api.QuarantinedJob, 0, len(entries)),
+		TotalCount: total,
+	}
+	for _, entry := range entries {
+		response.Jobs = append(response.Jobs, api.QuarantinedJob{
+			JobID:         entry.Job.ID,
+			Type:          entry.Job.Type,
+			Queue:         entry.Job.Queue,
+			Payload:       string(s.redactor.Payload(entry.Job.Type, entry.Job.Payload)),
+			Reason:        string(entry.Reason),
+			Error:         entry.Error,
+			Attempts:      entry.Job.Attempts,
+			QuarantinedAt: displayTime(c, entry.QuarantinedAt),
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Approve quarantined job handler, releasing the job to its queue
+func (s *Server) approveQuarantinedHandler(c *gin.Context) {
+	if !s.requireQuarantine(c) {
+		return
+	}
+
+	jobID := c.Param("id")
+	entry, err := s.quarantine.Approve(c.Request.Context(), jobID)
+	if err != nil {
+		s.quarantineError(c, jobID, err)
+		return
+	}
+
+	s.logger.Info("Quarantined job approved",
+		zap.String("job_id", jobID),
+		zap.String("job_type", entry.Job.Type),
+		zap.String("reason", string(entry.Reason)),
+	)
+	c.JSON(http.StatusOK, gin.H{
+		"job_id": jobID,
+		"status": "approved",
+	})
+}
+
+// Reject quarantined job handler, dropping the job
+func (s *Server) rejectQuarantinedHandler(c *gin.Context) {
+	if !s.requireQuarantine(c) {
+		return
+	}
+
+	jobID := c.Param("id")
+	entry, err := s.quarantine.Reject(c.Request.Context(), jobID)
+	if err != nil {
+		s.quarantineError(c, jobID, err)
+		return
+	}
+	s.settleRejected(entry)
+
+	s.logger.Info("Quarantined job rejected",
+		zap.String("job_id", jobID),
+		zap.String("job_type", entry.Job.Type),
+		zap.String("reason", string(entry.Reason)),
+	)
+	c.JSON(http.StatusOK, gin.H{
+		"job_id": jobID,
+		"status": "rejected",
+	})
+}
+
+// settleRejected fails the jobs and workflow nodes waiting on a job rejected
+// out of quarantine, as a worker does for a job that failed
+func (s *Server) settleRejected(entry *types.QuarantinedJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	failed := &types.JobResult{
+		JobID:            entry.Job.ID,
+		Status:           types.StatusFailed,
+		Error:            entry.Error,
+		FailureReason:    entry.Reason,
+		ErrorFingerprint: types.ErrorFingerprint(entry.Error),
+		CompletedAt:      time.Now().UTC(),
+	}
+	if s.workflows != nil && entry.Job.WorkflowID != "" {
+		if err := s.workflows.Advance(ctx, entry.Job, failed); err != nil {
+			s.logger.Error("Failed to advance workflow",
+				zap.String("job_id", entry.Job.ID),
+				zap.String("workflow_id", entry.Job.WorkflowID),
+				zap.Error(err),
+			)
+		}
+	}
+	s.failDependents(ctx, entry.Job, failed)
+}
+
+// failDependents dead-letters the jobs that can no longer run because
+// parent failed, and theirs in turn
+func (s *Server) failDependents(ctx context.Context, parent *types.Job, result *types.JobResult) {
+	if s.deps == nil {
+		return
+	}
+
+	cancelled, err := s.deps.Resolve(ctx, parent, result)
+	if err != nil {
+		s.logger.Error("Failed to release dependent jobs",
+			zap.String("job_id", parent.ID),
+			zap.Error(err),
+		)
+	}
+
+	for _, dependent := range cancelled {
+		errorMsg := fmt.Sprintf("dependency_failed: job %s it depends on failed", parent.ID)
+		if s.dlq != nil {
+			if err := s.dlq.Send(ctx, dependent, types.ReasonDependency, errorMsg); err != nil {
+				s.logger.Error("Failed to send job to DLQ",
+					zap.String("job_id", dependent.ID),
+					zap.Error(err),
+				)
+			}
+		}
+		s.failDependents(ctx, dependent, &types.JobResult{
+			JobID:         dependent.ID,
+			Status:        types.StatusFailed,
+			Error:         errorMsg,
+			FailureReason: types.ReasonDependency,
+			CompletedAt:   time.Now().UTC(),
+		})
+	}
+}
+
+// DLQ triage handler, grouping failed jobs by type and error fingerprint
+func (s *Server) dlqTriageHandler(c *gin.Context) {
+	if s.dlq == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Dead letter queue is not configured",
+		})
+		return
+	}
+
+	maxEntries := queryInt(c, "max", 10000)
+	if maxEntries <= 0 || maxEntries > 100000 {
+		maxEntries = 10000
+	}
+	top := queryInt(c, "top", 50)
+	if top <= 0 {
+		top = 50
+	}
+
+	report, err := queue.TriageDLQ(c.Request.Context(), s.dlq, maxEntries)
+	if err != nil {
+		s.logger.Error("Failed to triage DLQ", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to triage dead letter queue",
+			"details": err.Error(),
+		})
+		return
+	}
+	if len(report.Groups) > top {
+		report.Groups = report.Groups[:top]
+	}
+
+	response := api.DLQTriageResponse{
+		GeneratedAt: displayTime(c, report.GeneratedAt),
+		Scanned:     report.Scanned,
+		Truncated:   report.Truncated,
+		Groups:      make([]api.DLQTriageGroup, 0, len(report.Groups)),
+	}
+	for _, group := range report.Groups {
+		response.Groups = append(response.Groups, api.DLQTriageGroup{
+			Summary:       group.Summary(),
+			JobType:       group.JobType,
+			Reason:        string(group.Reason),
+			Fingerprint:   group.Fingerprint,
+			Count:         group.Count,
+			Example:       group.Example,
+			FirstFailedAt: displayTime(c, group.FirstFailedAt),
+			LastFailedAt:  displayTime(c, group.LastFailedAt),
+			SampleJobIDs:  group.SampleJobIDs,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// List job results handler, filtered by type, status, error fingerprint and completion time
+func (s *Server) listResultsHandler(c *gin.Context) {
+	if !s.requireResults(c) {
+		return
+	}
+
+	filter := queue.ResultFilter{
+		Type:        c.Query("type"),
+		Status:      types.JobStatus(c.Query("status")),
+		Fingerprint: c.Query("fingerprint"),
+		Limit:       queryInt(c, "limit", 100),
+	}
+	if filter.Limit <= 0 || filter.Limit > 1000 {
+		filter.Limit = 100
+	}
+
+	if since := c.Query("since"); since != "" {
+		sinceTime, err := parseSince(since)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid since parameter",
+				"details": err.Error(),
+			})
+			return
+		}
+		filter.Since = sinceTime
+	}
+
+	records, err := s.results.Query(c.Request.Context(), filter)
+	if err != nil {
+		s.logger.Error("Failed to query job results", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to query job results",
+		})
+		return
+	}
+
+	for _, record := range records {
+		localizeResult(c, record)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": records,
+	})
+}
+
+// Get job result handler
+func (s *Server) getResultHandler(c *gin.Context) {
+	if !s.requireResults(c) {
+		return
+	}
+
+	jobID := c.Param("id")
+	record, err := s.results.Get(c.Request.Context(), jobID)
+	if err != nil {
+		s.logger.Error("Failed to get job result", zap.String("job_id", jobID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get job result",
+		})
+		return
+	}
+	if record == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":  "Job result not found",
+			"job_id": jobID,
+		})
+		return
+	}
+
+	localizeResult(c, record)
+	c.JSON(http.StatusOK, record)
+}
+
+// Job output handler, returning the value the job's handler recorded
+func (s *Server) getJobOutputHandler(c *gin.Context) {
+	if !s.requireResults(c) {
+		return
+	}
+
+	jobID := c.Param("id")
+	record, err := s.results.Get(c.Request.Context(), jobID)
+	if err != nil {
+		s.logger.Error("Failed to get job result", zap.String("job_id", jobID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get job result",
+		})
+		return
+	}
+	if record == nil {
+		response := gin.H{
+			"error":  "Job result not found",
+			"job_id": jobID,
+		}
+		// A job that is still running has no result yet; say where it is
+		if s.statuses != nil {
+			if status, err := s.statuses.GetStatus(c.Request.Context(), jobID); err == nil && status != nil {
+				response["status"] = status.Status
+			}
+		}
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.JobResultResponse{
+		JobID:       record.JobID,
+		Type:        record.Type,
+		Status:      record.Status,
+		Result:      record.Output,
+		Error:       record.Error,
+		CompletedAt: displayTime(c, record.CompletedAt),
+		CachedFrom:  record.CachedFrom,
+	})
+}
+
+// Job dependencies handler, listing the jobs a waiting job still needs
+func (s *Server) getJobDependenciesHandler(c *gin.Context) {
+	if !s.requireDependencies(c) {
+		return
+	}
+
+	jobID := c.Param("id")
+	pending, err := s.deps.Pending(c.Request.Context(), jobID)
+	if err != nil {
+		s.logger.Error("Failed to get job dependencies", zap.String("job_id", jobID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get job dependencies",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"job_id":     jobID,
+		"waiting_on": pending,
+		"count":      len(pending),
+	})
+}
+
+// Job status handler, reporting where a job is in its lifecycle
+func (s *Server) getJobStatusHandler(c *gin.Context) {
+	if s.statuses == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Job status tracking is not enabled",
+		})
+		return
+	}
+
+	jobID := c.Param("id")
+	record, err := s.statuses.GetStatus(c.Request.Context(), jobID)
+	if err != nil {
+		s.logger.Error("Failed to get job status", zap.String("job_id", jobID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get job status",
+		})
+		return
+	}
+	if record == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":  "Job not found",
+			"job_id": jobID,
+		})
+		return
+	}
+
+	response := api.JobStatusResponse{
+		JobID:      record.JobID,
+		Type:       record.Type,
+		Status:     record.Status,
+		EnqueuedAt: displayTime(c, record.EnqueuedAt),
+		Attempts:   record.Attempts,
+		MaxRetries: record.MaxRetries,
+		Error:      record.Error,
+		Progress:   record.Progress,
+	}
+	if record.StartedAt != nil {
+		startedAt := displayTime(c, *record.StartedAt)
+		response.StartedAt = &startedAt
+	}
+	if record.CompletedAt != nil {
+		completedAt := displayTime(c, *record.CompletedAt)
+		response.CompletedAt = &completedAt
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// requireResults responds with 501 when result persistence is disabled
+func (s *Server) requireResults(c *gin.Context) bool {
+	if s.results == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Job results are not enabled",
+		})
+		return false
+	}
+	return true
+}
+
+// parseSince accepts an RFC 3339 timestamp or a duration relative to now, e.g. "2h"
+func parseSince(value string) (time.Time, error) {
+	if since, err := time.Parse(time.RFC3339, value); err == nil {
+		return since, nil
+	}
+
+	ago, err := time.ParseDuration(value)
+	if err != nil || ago < 0 {
+		return time.Time{}, fmt.Errorf("since must be an RFC 3339 timestamp or a duration like 2h")
+	}
+	return time.Now().Add(-ago), nil
+}
+
+// List job history handler
+func (s *Server) listHistoryHandler(c *gin.Context) {
+	s.listRecords(c, s.history.List)
+}
+
+// List archived job records handler
+func (s *Server) listArchiveHandler(c *gin.Context) {
+	s.listRecords(c, s.history.ListArchived)
+}
+
+// Get job history record handler
+func (s *Server) getHistoryHandler(c *gin.Context) {
+	s.getRecord(c, s.history.Get)
+}
+
+// Get archived job record handler
+func (s *Server) getArchiveHandler(c *gin.Context) {
+	s.getRecord(c, s.history.GetArchived)
+}
+
+// Soft delete job history record handler
+func (s *Server) deleteHistoryHandler(c *gin.Context) {
+	if !s.requireHistory(c) {
+		return
+	}
+
+	jobID := c.Param("id")
+	if err := s.history.Delete(c.Request.Context(), jobID); err != nil {
+		s.recordError(c, jobID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"job_id": jobID,
+		"status": "archived",
+	})
+}
+
+// Restore archived job record handler
+func (s *Server) restoreArchiveHandler(c *gin.Context) {
+	if !s.requireHistory(c) {
+		return
+	}
+
+	// Callers may only restore jobs of the types they may enqueue
+	jobID := c.Param("id")
+	record, err := s.history.GetArchived(c.Request.Context(), jobID)
+	if err != nil {
+		s.recordError(c, jobID, err)
+		return
+	}
+	if !s.allowJobType(c, record.Job.Type) {
+		return
+	}
+
+	if err := s.history.Restore(c.Request.Context(), jobID); err != nil {
+		s.recordError(c, jobID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"job_id": jobID,
+		"status": "restored",
+	})
+}
+
+func (s *Server) listRecords(c *gin.Context, list func(context.Context, int, int) ([]*types.JobRecord, error)) {
+	if !s.requireHistory(c) {
+		return
+	}
+
+	offset := queryInt(c, "offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+	limit := queryInt(c, "limit", 50)
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	records, err := list(c.Request.Context(), offset, limit)
+	if err != nil {
+		s.logger.Error("Failed to list job records", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list job records",
+		})
+		return
+	}
+
+	for _, record := range records {
+		record.Job = s.redactor.Job(record.Job)
+		localizeRecord(c, record)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"records": records,
+	})
+}
+
+func (s *Server) getRecord(c *gin.Context, get func(context.Context, string) (*types.JobRecord, error)) {
+	if !s.requireHistory(c) {
+		return
+	}
+
+	jobID := c.Param("id")
+	record, err := get(c.Request.Context(), jobID)
+	if err != nil {
+		s.recordError(c, jobID, err)
+		return
+	}
+
+	record.Job = s.redactor.Job(record.Job)
+	localizeRecord(c, record)
+	c.JSON(http.StatusOK, record)
+}
+
+// Worker stats handler, aggregated from the heartbeats of live worker pools
+func (s *Server) workerStatsHandler(c *gin.Context) {
+	if s.heartbeats == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Worker stats are not enabled",
+		})
+		return
+	}
+
+	heartbeats, err := s.heartbeats.List(c.Request.Context())
+	if err != nil {
+		s.logger.Error("Failed to list worker heartbeats", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get worker stats",
+		})
+		return
+	}
+
+	response := api.WorkerStatsResponse{
+		Pools:       len(heartbeats),
+		WorkersInfo: make([]api.WorkerInfo, 0, len(heartbeats)),
+	}
+	for _, hb := range heartbeats {
+		status := "idle"
+		switch {
+		case hb.Draining:
+			status = "draining"
+		case hb.ActiveJobs > 0:
+			status = "processing"
+		}
+
+		response.TotalWorkers += hb.Concurrency
+		response.ActiveWorkers += hb.ActiveJobs
+		response.JobsProcessed += int(hb.JobsProcessed)
+		response.JobsFailed += int(hb.JobsFailed)
+		response.JobsRetried += int(hb.JobsRetried)
+		response.WorkersInfo = append(response.WorkersInfo, api.WorkerInfo{
+			ID:            hb.ID,
+			Status:        status,
+			Hostname:      hb.Hostname,
+			PID:           hb.PID,
+			Concurrency:   hb.Concurrency,
+			ActiveJobs:    hb.ActiveJobs,
+			JobsProcessed: int(hb.JobsProcessed),
+			JobsFailed:    int(hb.JobsFailed),
+			JobsRetried:   int(hb.JobsRetried),
+			StartedAt:     displayTime(c, hb.StartedAt),
+			LastSeen:      displayTime(c, hb.LastSeen),
+		})
+	}
+	response.IdleWorkers = response.TotalWorkers - response.ActiveWorkers
+	if response.IdleWorkers < 0 {
+		response.IdleWorkers = 0
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// requireHistory responds with 501 when job history is disabled
+func (s *Server) requireHistory(c *gin.Context) bool {
+	if s.history == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Job history is not enabled",
+		})
+		return false
+	}
+	return true
+}
+
+// requireQuarantine responds with 501 unless the quarantine is available
+func (s *Server) requireQuarantine(c *gin.Context) bool {
+	if s.quarantine == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "Quarantine is not available",
+		})
+		return false
+	}
+	return true
+}
+
+// quarantineError maps quarantine errors to HTTP responses
+func (s *Server) quarantineError(c *gin.Context, jobID string, err error) {
+	if errors.Is(err, queue.ErrNotQuarantined) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Job not in quarantine",
+		})
+		return
+	}
+
+	s.logger.Error("Quarantine operation failed",
+		zap.String("job_id", jobID),
+		zap.Error(err),
+	)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "Quarantine operation failed",
+		"details": err.Error(),
+	})
+}
+
+// recordError maps history errors to HTTP responses
+func (s *Server) recordError(c *gin.Context, jobID string, err error) {
+	if errors.Is(err, queue.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Job record not found",
+		})
+		return
+	}
+
+	s.logger.Error("Job history operation failed",
+		zap.String("job_id", jobID),
+		zap.Error(err),
+	)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "Job history operation failed",
+		"details": err.Error(),
+	})
+}
+
+// queryInt reads an integer query parameter, falling back to def
+func queryInt(c *gin.Context, name string, def int) int {
+	value, err := strconv.Atoi(c.Query(name))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// metricsMiddleware records each request under its route pattern, so IDs in
+// paths don't add a series per job
+func (s *Server) metricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.metrics == nil {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		s.metrics.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// observeEnqueued counts jobs the API accepted, including spooled and held ones
+func (s *Server) observeEnqueued(jobs ...*types.Job) {
+	if s.metrics == nil {
+		return
+	}
+	for _, job := range jobs {
+		s.metrics.ObserveEnqueue(job.Type, job.GetPriority())
+	}
+}
+
+func (s *Server) loggingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		raw := c.Request.URL.RawQuery
+
+		// Process request
+		c.Next()
+
+		// Log request
+		duration := time.Since(start)
+		clientIP := c.ClientIP()
+		method := c.Request.Method
+		statusCode := c.Writer.Status()
+
+		if raw != "" {
+			path = path + "?" + raw
+		}
+
+		s.logger.Info("HTTP request",
+			zap.String("client_ip", clientIP),
+			zap.String("method", method),
+			zap.String("path", path),
+			zap.Int("status", statusCode),
+			zap.Duration("duration", duration),
+			zap.Int("size", c.Writer.Size()),
+		)
+	}
+}
+
+func (s *Server) corsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, Accept-Timezone, X-API-Key, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// authMiddleware authenticates API requests with the configured provider.
+// Requests pass through unauthenticated when no provider is set.
+func (s *Server) authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.auth == nil {
+			c.Next()
+			return
+		}
+
+		principal, err := s.auth.Authenticate(c.Request)
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrNoCredentials):
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					"error": "Authentication required",
+				})
+			case errors.Is(err, auth.ErrInvalidCredentials):
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					"error":   "Invalid credentials",
+					"details": err.Error(),
+				})
+			default:
+				s.logger.Error("Authentication failed", zap.String("provider", s.auth.Name()), zap.Error(err))
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+					"error": "Authentication is unavailable",
+				})
+			}
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// oidcLoginCookie holds the signed login state between /auth/login and /auth/callback
+const oidcLoginCookie = "gopher_oidc_login"
+
+// Login handler, redirects the browser to the identity provider
+func (s *Server) loginHandler(c *gin.Context) {
+	if !s.requireOIDC(c) {
+		return
+	}
+
+	login, redirectURL := s.oidc.Begin(safeReturnPath(c.Query("return_to")))
+	value, err := s.sessions.Seal(login)
+	if err != nil {
+		s.logger.Error("Failed to start login", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to start login",
+		})
+		return
+	}
+
+	s.sessions.SetCookie(c.Writer, oidcLoginCookie, value, 10*time.Minute)
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+// Login callback handler, exchanges the code and starts a session
+func (s *Server) loginCallbackHandler(c *gin.Context) {
+	if !s.requireOIDC(c) {
+		return
+	}
+
+	if errorCode := c.Query("error"); errorCode != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Login failed",
+			"details": fmt.Sprintf("%s: %s", errorCode, c.Query("error_description")),
+		})
+		return
+	}
+
+	var login auth.OIDCLogin
+	cookie, err := c.Cookie(oidcLoginCookie)
+	if err == nil {
+		err = s.sessions.Open(cookie, &login)
+	}
+	s.sessions.SetCookie(c.Writer, oidcLoginCookie, "", -1)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Login failed",
+			"details": "login state missing or invalid, start again at /auth/login",
+		})
+		return
+	}
+
+	principal, err := s.oidc.Finish(c.Request.Context(), &login, c.Query("state"), c.Query("code"))
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			status = http.StatusForbidden
+		}
+		s.logger.Warn("OIDC login failed", zap.Error(err))
+		c.JSON(status, gin.H{
+			"error":   "Login failed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := s.sessions.Issue(c.Writer, principal); err != nil {
+		s.logger.Error("Failed to issue session", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to issue session",
+		})
+		return
+	}
+
+	s.logger.Info("User logged in",
+		zap.String("subject", principal.Subject),
+		zap.Strings("roles", principal.Roles),
+	)
+
+	c.Redirect(http.StatusFound, login.ReturnTo)
+}
+
+// Logout handler, clears the session cookie
+func (s *Server) logoutHandler(c *gin.Context) {
+	if !s.requireOIDC(c) {
+		return
+	}
+
+	s.sessions.Clear(c.Writer)
+	c.Status(http.StatusNoContent)
+}
+
+// Current user handler
+func (s *Server) currentUserHandler(c *gin.Context) {
+	principal := auth.FromContext(c.Request.Context())
+	if principal == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Authentication is not enabled",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, principal)
+}
+
+// requireOIDC responds with 501 unless OIDC login is configured
+func (s *Server) requireOIDC(c *gin.Context) bool {
+	if s.oidc == nil || s.sessions == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": "OIDC login is not enabled",
+		})
+		return false
+	}
+	return true
+}
+
+// safeReturnPath only allows local paths after login, to avoid open redirects
+func safeReturnPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/"
+	}
+	return path
+}
+
+// requireRole rejects authenticated callers without the role
+func (s *Server) requireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := auth.FromContext(c.Request.Context())
+		if principal != nil && !principal.HasRole(role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   "Insufficient permissions",
+				"details": fmt.Sprintf("%s role required", role),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// allowJobType responds with 403 unless the caller may enqueue jobType
+func (s *Server) allowJobType(c *gin.Context, jobType string) bool {
+	principal := auth.FromContext(c.Request.Context())
+	if principal == nil || principal.CanEnqueue(jobType) {
+		return true
+	}
+
+	s.logger.Warn("Rejected job type not allowed for caller",
+		zap.String("subject", principal.Subject),
+		zap.String("job_type", jobType),
+	)
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":   "Job type not allowed",
+		"details": fmt.Sprintf("%s may not enqueue '%s' jobs", principal.Subject, jobType),
+	})
+	return false
+}
+
+// timezoneMiddleware picks the timezone for response timestamps from the
+// Accept-Timezone header, falling back to the configured display timezone
+func (s *Server) timezoneMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		loc := s.location
+		if name := c.GetHeader("Accept-Timezone"); name != "" {
+			requested, err := time.LoadLocation(name)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"error":   "Invalid Accept-Timezone header",
+					"details": err.Error(),
+				})
+				return
+			}
+			loc = requested
+		}
+
+		c.Set(displayLocationKey, loc)
+		c.Header("Content-Timezone", loc.String())
+		c.Next()
+	}
+}
+
+// displayTime converts t to the timezone chosen for this request
+func displayTime(c *gin.Context, t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	if value, exists := c.Get(displayLocationKey); exists {
+		if loc, ok := value.(*time.Location); ok {
+			return t.In(loc)
+		}
+	}
+	return t.UTC()
+}
+
+// localizeResult converts a stored result's timestamps for display
+func localizeResult(c *gin.Context, record *queue.ResultRecord) {
+	record.CompletedAt = displayTime(c, record.CompletedAt)
+	record.Timing.StartedAt = displayTime(c, record.Timing.StartedAt)
+	record.Timing.HandlerStartedAt = displayTime(c, record.Timing.HandlerStartedAt)
+	record.Timing.FinishedAt = displayTime(c, record.Timing.FinishedAt)
+}
+
+// localizeRecord converts a history record's timestamps for display.
+// The job is copied so cached or shared values are left untouched.
+func localizeRecord(c *gin.Context, record *types.JobRecord) {
+	record.FinishedAt = displayTime(c, record.FinishedAt)
+	if record.ArchivedAt != nil {
+		archivedAt := displayTime(c, *record.ArchivedAt)
+		record.ArchivedAt = &archivedAt
+	}
+
+	if record.Job != nil {
+		job := *record.Job
+		job.CreatedAt = displayTime(c, job.CreatedAt)
+		job.UpdatedAt = displayTime(c, job.UpdatedAt)
+		job.EnqueuedAt = displayTime(c, job.EnqueuedAt)
+		record.Job = &job
+	}
+}
+
+// maxWebhookBody caps webhook request bodies
+const maxWebhookBody = 1 << 20
+
+// Webhook trigger handler
+func (s *Server) webhookTriggerHandler(c *gin.Context) {
+	if s.triggers == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Unknown trigger",
+		})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBody+1))
+	if err != nil || len(body) > maxWebhookBody {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid webhook body",
+			"details": fmt.Sprintf("Body must be readable and at most %d bytes", maxWebhookBody),
+		})
+		return
+	}
+
+	timestamp := c.GetHeader("X-Gopher-Timestamp")
+	signature := c.GetHeader("X-Gopher-Signature")
+
+	query := make(map[string]string)
+	for key, values := range c.Request.URL.Query() {
+		query[key] = values[0]
+	}
+
+	job, err := s.triggers.HandleWebhook(c.Request.Context(), c.Param("name"), body, timestamp, signature, query)
+	switch {
+	case errors.Is(err, trigger.ErrUnknownTrigger):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Unknown trigger",
+		})
+		return
+	case errors.Is(err, trigger.ErrBadSignature):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid webhook signature",
+		})
+		return
+	case errors.Is(err, trigger.ErrReadOnly):
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "API is read-only",
+		})
+		return
+	case errors.Is(err, trigger.ErrInvalidEvent):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "Invalid webhook body",
+			"details": err.Error(),
+		})
+		return
+	case err != nil:
+		s.logger.Error("Failed to handle webhook trigger", zap.String("trigger", c.Param("name")), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to enqueue triggered job",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	s.observeEnqueued(job)
+	c.JSON(http.StatusAccepted, types.JobResponse{
+		JobID:     job.ID,
+		Status:    string(types.StatusPending),
+		CreatedAt: displayTime(c, job.CreatedAt),
+	})
 }
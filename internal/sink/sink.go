@@ -0,0 +1,178 @@
+package sink
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/metrics"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"go.uber.org/zap"
+)
+
+// Event types
+const (
+	EventCompleted = "job.completed"
+	EventFailed    = "job.failed"       // Failed permanently, after the last retry or at dequeue
+	EventAnomaly   = "anomaly.detected" // Enqueue rate, failure rate or latency spiked, see Event.Anomaly
+)
+
+// Event describes a job that reached a final state, or an anomaly a worker
+// detected
+type Event struct {
+	Event      string              `json:"event"`
+	JobID      string              `json:"job_id"`
+	JobType    string              `json:"job_type"`
+	Status     types.JobStatus     `json:"status"`
+	Attempts   int                 `json:"attempts"`
+	Error      string              `json:"error,omitempty"`
+	Reason     types.FailureReason `json:"reason,omitempty"`
+	Duration   string              `json:"duration,omitempty"`
+	Metadata   types.JobMetadata   `json:"metadata,omitempty"`
+	WorkerID   string              `json:"worker_id,omitempty"`
+	EnqueuedAt time.Time           `json:"enqueued_at"`
+	FinishedAt time.Time           `json:"finished_at"`
+	Anomaly    *metrics.Anomaly    `json:"anomaly,omitempty"`
+}
+
+// NewEvent builds the event for a finished job and its result
+func NewEvent(finished *types.Job, result *types.JobResult, workerID string) Event {
+	event := Event{
+		Event:      EventCompleted,
+		JobID:      finished.ID,
+		JobType:    finished.Type,
+		Status:     result.Status,
+		Attempts:   finished.Attempts,
+		Duration:   result.Duration,
+		Metadata:   finished.Metadata,
+		WorkerID:   workerID,
+		EnqueuedAt: finished.EnqueuedAt,
+		FinishedAt: result.CompletedAt,
+	}
+	if result.Status != types.StatusCompleted {
+		event.Event = EventFailed
+		event.Error = result.Error
+		event.Reason = result.FailureReason
+		if event.Reason == "" {
+			event.Reason = types.ReasonHandlerError
+		}
+	}
+	if event.FinishedAt.IsZero() {
+		event.FinishedAt = time.Now().UTC()
+	}
+	return event
+}
+
+// NewAnomalyEvent builds the alert event for a spike a worker detected. It
+// carries no job, and its finished_at is when the spike was detected.
+func NewAnomalyEvent(anomaly metrics.Anomaly, workerID string) Event {
+	return Event{
+		Event:      EventAnomaly,
+		Error:      anomaly.String(),
+		WorkerID:   workerID,
+		FinishedAt: anomaly.DetectedAt,
+		Anomaly:    &anomaly,
+	}
+}
+
+// Sink delivers job events to a downstream system
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, event Event) error
+	Close() error
+}
+
+// Forwarder fans events out to sinks in the background so a slow sink never
+// holds up workers. Events beyond the buffer are dropped and counted.
+type Forwarder struct {
+	sinks   []Sink
+	events  chan Event
+	logger  *zap.Logger
+	metrics *metrics.Metrics
+	timeout time.Duration
+
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewForwarder creates a forwarder holding up to buffer undelivered events
+func NewForwarder(sinks []Sink, buffer int, logger *zap.Logger) *Forwarder {
+	if buffer <= 0 {
+		buffer = 1000
+	}
+
+	f := &Forwarder{
+		sinks:   sinks,
+		events:  make(chan Event, buffer),
+		logger:  logger,
+		timeout: 10 * time.Second,
+	}
+
+	f.wg.Add(1)
+	go f.run()
+	return f
+}
+
+// SetMetrics counts forwarded events in gopher_sink_events_total
+func (f *Forwarder) SetMetrics(m *metrics.Metrics) {
+	f.metrics = m
+}
+
+// Publish queues an event for every sink without blocking
+func (f *Forwarder) Publish(event Event) {
+	select {
+	case f.events <- event:
+	default:
+		f.logger.Warn("Sink buffer full, dropping job event",
+			zap.String("job_id", event.JobID),
+			zap.String("event", event.Event),
+		)
+		for _, s := range f.sinks {
+			f.count(s, "dropped")
+		}
+	}
+}
+
+// Close delivers buffered events and closes the sinks
+func (f *Forwarder) Close() error {
+	f.closeOnce.Do(func() {
+		close(f.events)
+	})
+	f.wg.Wait()
+
+	for _, s := range f.sinks {
+		if err := s.Close(); err != nil {
+			f.logger.Warn("Failed to close sink", zap.String("sink", s.Name()), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (f *Forwarder) run() {
+	defer f.wg.Done()
+
+	for event := range f.events {
+		for _, s := range f.sinks {
+			ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+			err := s.Send(ctx, event)
+			cancel()
+
+			if err != nil {
+				f.logger.Warn("Failed to forward job event",
+					zap.String("sink", s.Name()),
+					zap.String("job_id", event.JobID),
+					zap.Error(err),
+				)
+				f.count(s, "failed")
+				continue
+			}
+			f.count(s, "sent")
+		}
+	}
+}
+
+func (f *Forwarder) count(s Sink, outcome string) {
+	if f.metrics != nil {
+		f.metrics.SinkEvents.WithLabelValues(s.Name(), outcome).Inc()
+	}
+}
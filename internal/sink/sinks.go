@@ -0,0 +1,151 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/queue"
+	"github.com/go-redis/redis/v8"
+)
+
+// KafkaSink produces events to a topic, keyed by job type
+type KafkaSink struct {
+	client queue.KafkaClient
+	topic  string
+}
+
+// NewKafkaSink creates a sink producing to topic
+func NewKafkaSink(client queue.KafkaClient, topic string) *KafkaSink {
+	return &KafkaSink{client: client, topic: topic}
+}
+
+func (k *KafkaSink) Name() string { return "kafka" }
+
+// Send produces the event as JSON
+func (k *KafkaSink) Send(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return k.client.Produce(ctx, queue.KafkaRecord{
+		Topic: k.topic,
+		Key:   []byte(event.JobType),
+		Value: data,
+	})
+}
+
+func (k *KafkaSink) Close() error {
+	return k.client.Close()
+}
+
+// WebhookSink POSTs events as JSON. With a secret, bodies are signed like
+// inbound trigger webhooks: X-Gopher-Signature: sha256=<hex HMAC> of
+// "<X-Gopher-Timestamp>.<body>".
+type WebhookSink struct {
+	url    string
+	secret string
+	http   *http.Client
+}
+
+// NewWebhookSink creates a sink posting to url
+func NewWebhookSink(url, secret string) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		secret: secret,
+		http:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (w *WebhookSink) Name() string { return "webhook" }
+
+// Send posts the event; any non-2xx response is an error
+func (w *WebhookSink) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gopher-Event", event.Event)
+	if w.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(w.secret))
+		mac.Write([]byte(timestamp + "."))
+		mac.Write(body)
+		req.Header.Set("X-Gopher-Timestamp", timestamp)
+		req.Header.Set("X-Gopher-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (w *WebhookSink) Close() error {
+	w.http.CloseIdleConnections()
+	return nil
+}
+
+// StreamSink appends events to a Redis stream trimmed to about maxLen entries
+type StreamSink struct {
+	client redis.Cmdable
+	stream string
+	maxLen int64
+}
+
+// NewStreamSink creates a sink appending to stream; maxLen 0 keeps every entry
+func NewStreamSink(client redis.Cmdable, stream string, maxLen int64) *StreamSink {
+	return &StreamSink{client: client, stream: stream, maxLen: maxLen}
+}
+
+func (s *StreamSink) Name() string { return "redis_stream" }
+
+// Send adds the event with its type, job ID and JSON body as fields
+func (s *StreamSink) Send(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	err = s.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.stream,
+		MaxLen: s.maxLen,
+		Approx: s.maxLen > 0,
+		Values: map[string]interface{}{
+			"event":    event.Event,
+			"job_id":   event.JobID,
+			"job_type": event.JobType,
+			"data":     string(data),
+		},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to add event to stream: %w", err)
+	}
+	return nil
+}
+
+func (s *StreamSink) Close() error {
+	return nil
+}
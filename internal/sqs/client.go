@@ -0,0 +1,175 @@
+package sqs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// Credentials are static AWS credentials
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Set for temporary credentials
+}
+
+// Options configures the SQS client
+type Options struct {
+	Region      string      // Defaults to the region the AWS SDK resolves from the environment and shared config
+	Endpoint    string      // Override for LocalStack or VPC endpoints
+	Credentials Credentials // Empty to use the AWS SDK's default credential chain
+}
+
+// Client runs the handful of SQS actions Gopher needs on aws-sdk-go-v2
+type Client struct {
+	api *sqs.Client
+}
+
+// Message is a received SQS message
+type Message struct {
+	MessageID         string
+	ReceiptHandle     string
+	Body              string
+	Attributes        map[string]string
+	MessageAttributes map[string]MessageAttribute
+}
+
+// MessageAttribute is a string message attribute
+type MessageAttribute struct {
+	DataType    string
+	StringValue string
+}
+
+// NewClient creates a client. Without static credentials it uses the AWS
+// SDK's default chain: environment, shared config and SSO, web identity,
+// ECS task roles and EC2 instance roles.
+func NewClient(ctx context.Context, opts Options) (*Client, error) {
+	var loadOpts []func(*config.LoadOptions) error
+	if opts.Region != "" {
+		loadOpts = append(loadOpts, config.WithRegion(opts.Region))
+	}
+	if opts.Credentials.AccessKeyID != "" || opts.Credentials.SecretAccessKey != "" {
+		if opts.Credentials.AccessKeyID == "" || opts.Credentials.SecretAccessKey == "" {
+			return nil, fmt.Errorf("AWS access key ID and secret access key must be set together")
+		}
+		loadOpts = append(loadOpts, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			opts.Credentials.AccessKeyID, opts.Credentials.SecretAccessKey, opts.Credentials.SessionToken)))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("AWS region is required")
+	}
+
+	return &Client{api: sqs.NewFromConfig(cfg, func(o *sqs.Options) {
+		if opts.Endpoint != "" {
+			o.BaseEndpoint = aws.String(opts.Endpoint)
+		}
+	})}, nil
+}
+
+// SendMessage sends a message with optional string attributes, delayed by up to 15 minutes
+func (c *Client) SendMessage(ctx context.Context, queueURL, body string, delay time.Duration, attributes map[string]string) (string, error) {
+	input := &sqs.SendMessageInput{
+		QueueUrl:     aws.String(queueURL),
+		MessageBody:  aws.String(body),
+		DelaySeconds: int32(delay / time.Second),
+	}
+	if len(attributes) > 0 {
+		input.MessageAttributes = make(map[string]types.MessageAttributeValue, len(attributes))
+		for name, value := range attributes {
+			input.MessageAttributes[name] = types.MessageAttributeValue{
+				DataType:    aws.String("String"),
+				StringValue: aws.String(value),
+			}
+		}
+	}
+
+	out, err := c.api.SendMessage(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.MessageId), nil
+}
+
+// ReceiveMessage long-polls for up to max messages, hiding them for visibility
+func (c *Client) ReceiveMessage(ctx context.Context, queueURL string, max int, wait, visibility time.Duration) ([]Message, error) {
+	input := &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(queueURL),
+		MaxNumberOfMessages: int32(max),
+		WaitTimeSeconds:     int32(wait / time.Second),
+		MessageSystemAttributeNames: []types.MessageSystemAttributeName{
+			types.MessageSystemAttributeNameApproximateReceiveCount,
+			types.MessageSystemAttributeNameSentTimestamp,
+		},
+		MessageAttributeNames: []string{"All"},
+	}
+	if visibility > 0 {
+		input.VisibilityTimeout = int32(visibility / time.Second)
+	}
+
+	out, err := c.api.ReceiveMessage(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]Message, len(out.Messages))
+	for i, m := range out.Messages {
+		messages[i] = messageOf(m)
+	}
+	return messages, nil
+}
+
+// DeleteMessage removes a handled message
+func (c *Client) DeleteMessage(ctx context.Context, queueURL, receiptHandle string) error {
+	_, err := c.api.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(queueURL),
+		ReceiptHandle: aws.String(receiptHandle),
+	})
+	return err
+}
+
+// GetQueueAttributes returns the named attributes, or all with "All"
+func (c *Client) GetQueueAttributes(ctx context.Context, queueURL string, names ...string) (map[string]string, error) {
+	attributeNames := make([]types.QueueAttributeName, len(names))
+	for i, name := range names {
+		attributeNames[i] = types.QueueAttributeName(name)
+	}
+
+	out, err := c.api.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(queueURL),
+		AttributeNames: attributeNames,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Attributes, nil
+}
+
+func messageOf(m types.Message) Message {
+	message := Message{
+		MessageID:     aws.ToString(m.MessageId),
+		ReceiptHandle: aws.ToString(m.ReceiptHandle),
+		Body:          aws.ToString(m.Body),
+		Attributes:    m.Attributes,
+	}
+	if len(m.MessageAttributes) > 0 {
+		message.MessageAttributes = make(map[string]MessageAttribute, len(m.MessageAttributes))
+		for name, value := range m.MessageAttributes {
+			message.MessageAttributes[name] = MessageAttribute{
+				DataType:    aws.ToString(value.DataType),
+				StringValue: aws.ToString(value.StringValue),
+			}
+		}
+	}
+	return message
+}
@@ -0,0 +1,224 @@
+package sqs
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// request is what the fake endpoint saw of a call
+type request struct {
+	target        string
+	authorization string
+	token         string
+	body          map[string]interface{}
+}
+
+// fakeEndpoint answers every call with response and records the requests
+func fakeEndpoint(t *testing.T, response string) (*Client, *[]request) {
+	t.Helper()
+
+	var seen []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		req := request{
+			target:        r.Header.Get("X-Amz-Target"),
+			authorization: r.Header.Get("Authorization"),
+			token:         r.Header.Get("X-Amz-Security-Token"),
+		}
+		json.Unmarshal(data, &req.body)
+		seen = append(seen, req)
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(context.Background(), Options{
+		Region:   "eu-west-1",
+		Endpoint: srv.URL,
+		Credentials: Credentials{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "secret",
+			SessionToken:    "session",
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client, &seen
+}
+
+func TestClientRequests(t *testing.T) {
+	const queueURL = "https://sqs.eu-west-1.amazonaws.com/123456789012/jobs"
+
+	tests := []struct {
+		name     string
+		response string
+		call     func(ctx context.Context, c *Client) error
+		target   string
+		want     map[string]interface{}
+	}{
+		{
+			name:     "send with delay and attributes",
+			response: `{"MessageId":"m-1"}`,
+			call: func(ctx context.Context, c *Client) error {
+				id, err := c.SendMessage(ctx, queueURL, "body", 90*time.Second, map[string]string{"gopher_run_at": "2030-01-01T00:00:00Z"})
+				if err == nil && id != "m-1" {
+					t.Errorf("MessageId = %q, want m-1", id)
+				}
+				return err
+			},
+			target: "AmazonSQS.SendMessage",
+			want: map[string]interface{}{
+				"QueueUrl":     queueURL,
+				"MessageBody":  "body",
+				"DelaySeconds": float64(90),
+				"MessageAttributes": map[string]interface{}{
+					"gopher_run_at": map[string]interface{}{"DataType": "String", "StringValue": "2030-01-01T00:00:00Z"},
+				},
+			},
+		},
+		{
+			name:     "receive",
+			response: `{"Messages":[{"MessageId":"m-1","ReceiptHandle":"r-1","Body":"{}","Attributes":{"ApproximateReceiveCount":"2"},"MessageAttributes":{"gopher_run_at":{"DataType":"String","StringValue":"x"}}}]}`,
+			call: func(ctx context.Context, c *Client) error {
+				messages, err := c.ReceiveMessage(ctx, queueURL, 1, 20*time.Second, 2*time.Minute)
+				if err != nil {
+					return err
+				}
+				if len(messages) != 1 {
+					t.Fatalf("got %d messages, want 1", len(messages))
+				}
+				m := messages[0]
+				if m.MessageID != "m-1" || m.ReceiptHandle != "r-1" || m.Body != "{}" ||
+					m.Attributes["ApproximateReceiveCount"] != "2" || m.MessageAttributes["gopher_run_at"].StringValue != "x" {
+					t.Errorf("message = %+v", m)
+				}
+				return nil
+			},
+			target: "AmazonSQS.ReceiveMessage",
+			want: map[string]interface{}{
+				"QueueUrl":            queueURL,
+				"MaxNumberOfMessages": float64(1),
+				"WaitTimeSeconds":     float64(20),
+				"VisibilityTimeout":   float64(120),
+			},
+		},
+		{
+			name:     "delete",
+			response: `{}`,
+			call: func(ctx context.Context, c *Client) error {
+				return c.DeleteMessage(ctx, queueURL, "r-1")
+			},
+			target: "AmazonSQS.DeleteMessage",
+			want:   map[string]interface{}{"QueueUrl": queueURL, "ReceiptHandle": "r-1"},
+		},
+		{
+			name:     "queue attributes",
+			response: `{"Attributes":{"ApproximateNumberOfMessages":"7"}}`,
+			call: func(ctx context.Context, c *Client) error {
+				attributes, err := c.GetQueueAttributes(ctx, queueURL, "ApproximateNumberOfMessages")
+				if err == nil && attributes["ApproximateNumberOfMessages"] != "7" {
+					t.Errorf("attributes = %v", attributes)
+				}
+				return err
+			},
+			target: "AmazonSQS.GetQueueAttributes",
+			want: map[string]interface{}{
+				"QueueUrl":       queueURL,
+				"AttributeNames": []interface{}{"ApproximateNumberOfMessages"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, seen := fakeEndpoint(t, tt.response)
+			if err := tt.call(context.Background(), client); err != nil {
+				t.Fatalf("call: %v", err)
+			}
+			if len(*seen) != 1 {
+				t.Fatalf("got %d requests, want 1", len(*seen))
+			}
+			req := (*seen)[0]
+
+			if req.target != tt.target {
+				t.Errorf("X-Amz-Target = %q, want %q", req.target, tt.target)
+			}
+			// Signed with SigV4 by the SDK, using the static credentials
+			if !strings.HasPrefix(req.authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+				!strings.Contains(req.authorization, "/eu-west-1/sqs/aws4_request") {
+				t.Errorf("Authorization = %q", req.authorization)
+			}
+			if req.token != "session" {
+				t.Errorf("X-Amz-Security-Token = %q, want session", req.token)
+			}
+			for field, want := range tt.want {
+				got, _ := json.Marshal(req.body[field])
+				wantJSON, _ := json.Marshal(want)
+				if string(got) != string(wantJSON) {
+					t.Errorf("%s = %s, want %s", field, got, wantJSON)
+				}
+			}
+		})
+	}
+}
+
+func TestClientAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"__type":"com.amazonaws.sqs#QueueDoesNotExist","message":"The specified queue does not exist."}`)
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(context.Background(), Options{
+		Region:      "eu-west-1",
+		Endpoint:    srv.URL,
+		Credentials: Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"},
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	_, err = client.GetQueueAttributes(context.Background(), srv.URL+"/123456789012/missing", "QueueArn")
+	if err == nil || !strings.Contains(err.Error(), "QueueDoesNotExist") {
+		t.Fatalf("error = %v, want QueueDoesNotExist", err)
+	}
+}
+
+func TestNewClientOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr string
+	}{
+		{
+			name: "static credentials",
+			opts: Options{Region: "us-east-1", Credentials: Credentials{AccessKeyID: "a", SecretAccessKey: "b"}},
+		},
+		{
+			name:    "half a key pair",
+			opts:    Options{Region: "us-east-1", Credentials: Credentials{AccessKeyID: "a"}},
+			wantErr: "must be set together",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(context.Background(), tt.opts)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("NewClient: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("NewClient error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
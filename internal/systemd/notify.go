@@ -0,0 +1,95 @@
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// sd_notify states understood by systemd
+const (
+	Ready    = "READY=1"
+	Stopping = "STOPPING=1"
+	Watchdog = "WATCHDOG=1"
+)
+
+// Notify sends a state to the systemd notify socket. It returns false with a
+// nil error when the process is not running under systemd with Type=notify.
+func Notify(state string) (bool, error) {
+	socketPath := os.Getenv("NOTIFY_SOCKET")
+	if socketPath == "" {
+		return false, nil
+	}
+
+	// Abstract namespace sockets are given with a leading @
+	if socketPath[0] == '@' {
+		socketPath = "\x00" + socketPath[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to notify socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, fmt.Errorf("failed to send notify state: %w", err)
+	}
+
+	return true, nil
+}
+
+// WatchdogInterval returns the watchdog timeout configured with WatchdogSec,
+// or false when the watchdog is disabled for this process
+func WatchdogInterval() (time.Duration, bool) {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, false
+	}
+
+	return time.Duration(usec) * time.Microsecond, true
+}
+
+// RunWatchdog pings the systemd watchdog at half the configured interval while
+// check passes, so systemd restarts the process once it stops being healthy.
+// It returns immediately when the watchdog is disabled.
+func RunWatchdog(ctx context.Context, check func(ctx context.Context) error, logger *zap.Logger) {
+	interval, ok := WatchdogInterval()
+	if !ok {
+		return
+	}
+
+	logger.Info("systemd watchdog enabled", zap.Duration("interval", interval))
+
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, interval/4)
+			err := check(checkCtx)
+			cancel()
+
+			if err != nil {
+				logger.Warn("Skipping watchdog ping, health check failed", zap.Error(err))
+				continue
+			}
+
+			if _, err := Notify(Watchdog); err != nil {
+				logger.Warn("Failed to ping systemd watchdog", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,557 @@
+package trigger
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/job"
+	"github.com/aneeshsunganahalli/Gopher/internal/queue"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// Trigger sources
+const (
+	SourceRedis   = "redis"   // Redis pub/sub channel or pattern, including keyspace notifications
+	SourceWebhook = "webhook" // POST /hooks/<name> signed with the trigger's secret
+	SourceStorage = "storage" // S3 or GCS object notifications read by the ingester
+)
+
+// ErrUnknownTrigger is returned for events addressed to a trigger that isn't configured
+var ErrUnknownTrigger = errors.New("unknown trigger")
+
+// ErrBadSignature is returned when a webhook body doesn't match its signature,
+// or was signed too long ago to be anything but a replay
+var ErrBadSignature = errors.New("bad webhook signature")
+
+// ErrReadOnly is returned for events that arrive while the API is in
+// read-only mode; they enqueue nothing
+var ErrReadOnly = errors.New("read-only mode")
+
+// ErrInvalidEvent is returned when an event lacks fields the payload template needs
+var ErrInvalidEvent = errors.New("event does not match the payload template")
+
+// Definition configures one trigger. Payload is a JSON document whose string
+// values are Go templates rendered against the event, e.g. "{{.data.url}}".
+type Definition struct {
+	Name       string          `json:"name"`
+	Source     string          `json:"source"`
+	Channel    string          `json:"channel,omitempty"` // Redis channel or glob pattern, e.g. __keyspace@0__:uploads:*
+	Secret     string          `json:"secret,omitempty"`  // HMAC-SHA256 key for webhook signatures
+	JobType    string          `json:"job_type"`
+	Payload    json.RawMessage `json:"payload"`
+	MaxRetries *int            `json:"max_retries,omitempty"`
+	Priority   string          `json:"priority,omitempty"`
+
+	// Storage triggers match object notifications; empty fields match anything
+	Bucket string   `json:"bucket,omitempty"`
+	Prefix string   `json:"prefix,omitempty"`
+	Suffix string   `json:"suffix,omitempty"`
+	Events []string `json:"events,omitempty"` // Event name prefixes, e.g. ObjectCreated or OBJECT_FINALIZE
+}
+
+// ObjectEvent is a storage notification for one object
+type ObjectEvent struct {
+	Provider    string                 `json:"provider"` // s3 or gcs
+	Event       string                 `json:"event"`    // e.g. ObjectCreated:Put or OBJECT_FINALIZE
+	Bucket      string                 `json:"bucket"`
+	Key         string                 `json:"key"`
+	Size        int64                  `json:"size"`
+	ETag        string                 `json:"etag,omitempty"`
+	ContentType string                 `json:"content_type,omitempty"`
+	Time        string                 `json:"time,omitempty"`
+	Raw         map[string]interface{} `json:"-"` // Provider record, exposed to templates as .data
+}
+
+// matches reports whether the storage trigger applies to the object event
+func (d Definition) matches(event ObjectEvent) bool {
+	if d.Bucket != "" && d.Bucket != event.Bucket {
+		return false
+	}
+	if !strings.HasPrefix(event.Key, d.Prefix) || !strings.HasSuffix(event.Key, d.Suffix) {
+		return false
+	}
+	if len(d.Events) == 0 {
+		return true
+	}
+	for _, prefix := range d.Events {
+		if strings.HasPrefix(event.Event, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse decodes and validates a JSON list of trigger definitions
+func Parse(value string) ([]Definition, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var defs []Definition
+	if err := json.Unmarshal([]byte(value), &defs); err != nil {
+		return nil, fmt.Errorf("invalid trigger definitions: %w", err)
+	}
+
+	seen := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		if def.Name == "" {
+			return nil, fmt.Errorf("trigger name is required")
+		}
+		if seen[def.Name] {
+			return nil, fmt.Errorf("duplicate trigger %q", def.Name)
+		}
+		seen[def.Name] = true
+
+		if def.JobType == "" {
+			return nil, fmt.Errorf("trigger %s: job_type is required", def.Name)
+		}
+		switch def.Source {
+		case SourceRedis:
+			if def.Channel == "" {
+				return nil, fmt.Errorf("trigger %s: channel is required for redis triggers", def.Name)
+			}
+		case SourceWebhook:
+			if def.Secret == "" {
+				return nil, fmt.Errorf("trigger %s: secret is required for webhook triggers", def.Name)
+			}
+		case SourceStorage:
+		default:
+			return nil, fmt.Errorf("trigger %s: invalid source %q (must be redis, webhook or storage)", def.Name, def.Source)
+		}
+		if def.Priority != "" && def.Priority != queue.PriorityHigh &&
+			def.Priority != queue.PriorityNormal && def.Priority != queue.PriorityLow {
+			return nil, fmt.Errorf("trigger %s: invalid priority %q", def.Name, def.Priority)
+		}
+		if _, err := compilePayload(def.Payload); err != nil {
+			return nil, fmt.Errorf("trigger %s: %w", def.Name, err)
+		}
+	}
+
+	return defs, nil
+}
+
+const (
+	// DefaultWebhookTolerance is how far a webhook's signed timestamp may be
+	// from the server's clock
+	DefaultWebhookTolerance = 5 * time.Minute
+
+	leaseTTL           = 15 * time.Second // How long Redis triggers stay with a server that stopped renewing
+	leaseRenewInterval = 5 * time.Second
+)
+
+// Subscriber is the part of a Redis client needed for pattern subscriptions
+type Subscriber interface {
+	PSubscribe(ctx context.Context, patterns ...string) *redis.PubSub
+}
+
+// trigger is a definition with its payload templates compiled
+type trigger struct {
+	def     Definition
+	payload interface{} // Decoded payload with *template.Template in place of strings
+}
+
+// Manager enqueues jobs for trigger events
+type Manager struct {
+	queue      queue.Queue
+	registry   *job.Registry
+	triggers   map[string]*trigger
+	maxRetries int
+	tolerance  time.Duration
+	readOnly   *queue.ReadOnlyStore
+	lease      *queue.LeaderLease
+	logger     *zap.Logger
+}
+
+// NewManager compiles the definitions; every job type must be registered
+func NewManager(defs []Definition, q queue.Queue, registry *job.Registry, maxRetries int, logger *zap.Logger) (*Manager, error) {
+	m := &Manager{
+		queue:      q,
+		registry:   registry,
+		triggers:   make(map[string]*trigger, len(defs)),
+		maxRetries: maxRetries,
+		tolerance:  DefaultWebhookTolerance,
+		logger:     logger,
+	}
+
+	for _, def := range defs {
+		if _, err := registry.Get(def.JobType); err != nil {
+			return nil, fmt.Errorf("trigger %s: job type %q is not registered", def.Name, def.JobType)
+		}
+		payload, err := compilePayload(def.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("trigger %s: %w", def.Name, err)
+		}
+		m.triggers[def.Name] = &trigger{def: def, payload: payload}
+	}
+
+	return m, nil
+}
+
+// SetWebhookTolerance sets how far a webhook's signed timestamp may be from
+// the server's clock
+func (m *Manager) SetWebhookTolerance(tolerance time.Duration) {
+	m.tolerance = tolerance
+}
+
+// SetReadOnly makes triggers enqueue nothing while read-only mode is on
+func (m *Manager) SetReadOnly(store *queue.ReadOnlyStore) {
+	m.readOnly = store
+}
+
+// SetLeaderLease makes Run subscribe only while this replica, identified by
+// holder, leads the others sharing client and layout, so a message on a trigger channel
+// enqueues one job however many servers run
+func (m *Manager) SetLeaderLease(client redis.Cmdable, layout *queue.Layout, holder string) {
+	m.lease = queue.NewLeaderLease(client, layout, "triggers", holder, leaseTTL)
+}
+
+// Names returns the configured trigger names
+func (m *Manager) Names() []string {
+	names := make([]string, 0, len(m.triggers))
+	for name := range m.triggers {
+		names = append(names, name)
+	}
+	return names
+}
+
+// HandleWebhook verifies a webhook body against signature ("sha256=<hex>"),
+// an HMAC of "<timestamp>.<body>" where timestamp is in Unix seconds, and
+// enqueues the trigger's job. Signatures older or newer than the tolerance
+// are rejected so a captured request can't be replayed.
+func (m *Manager) HandleWebhook(ctx context.Context, name string, body []byte, timestamp, signature string, query map[string]string) (*types.Job, error) {
+	t, ok := m.triggers[name]
+	if !ok || t.def.Source != SourceWebhook {
+		return nil, ErrUnknownTrigger
+	}
+
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing or invalid timestamp", ErrBadSignature)
+	}
+	if skew := time.Since(time.Unix(signedAt, 0)); skew > m.tolerance || skew < -m.tolerance {
+		return nil, fmt.Errorf("%w: timestamp outside the %s tolerance", ErrBadSignature, m.tolerance)
+	}
+
+	mac := hmac.New(sha256.New, []byte(t.def.Secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil || !hmac.Equal(got, mac.Sum(nil)) {
+		return nil, ErrBadSignature
+	}
+
+	event := map[string]interface{}{
+		"trigger": name,
+		"source":  SourceWebhook,
+		"data":    decodeData(body),
+		"query":   query,
+	}
+	return m.fire(ctx, t, event)
+}
+
+// HasSource reports whether any trigger uses source
+func (m *Manager) HasSource(source string) bool {
+	for _, t := range m.triggers {
+		if t.def.Source == source {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleObject enqueues a job for every storage trigger matching the event
+// and returns how many were enqueued. It stops at the first failure so the
+// notification can be redelivered.
+func (m *Manager) HandleObject(ctx context.Context, object ObjectEvent) (int, error) {
+	enqueued := 0
+	for _, t := range m.triggers {
+		if t.def.Source != SourceStorage || !t.def.matches(object) {
+			continue
+		}
+
+		event := map[string]interface{}{
+			"trigger":      t.def.Name,
+			"source":       SourceStorage,
+			"provider":     object.Provider,
+			"event":        object.Event,
+			"bucket":       object.Bucket,
+			"key":          object.Key,
+			"size":         object.Size,
+			"etag":         object.ETag,
+			"content_type": object.ContentType,
+			"time":         object.Time,
+			"data":         object.Raw,
+		}
+		if _, err := m.fire(ctx, t, event); err != nil {
+			return enqueued, fmt.Errorf("trigger %s: %w", t.def.Name, err)
+		}
+		enqueued++
+	}
+	return enqueued, nil
+}
+
+// Run subscribes to the redis triggers' channels and enqueues a job per
+// message until ctx is cancelled. With a leader lease only the replica
+// holding it subscribes; the others wait to take over.
+func (m *Manager) Run(ctx context.Context, client Subscriber) error {
+	patterns := make(map[string][]*trigger)
+	for _, t := range m.triggers {
+		if t.def.Source == SourceRedis {
+			patterns[t.def.Channel] = append(patterns[t.def.Channel], t)
+		}
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+	if m.lease == nil {
+		return m.subscribe(ctx, client, patterns, nil)
+	}
+
+	defer m.releaseLease()
+	ticker := time.NewTicker(leaseRenewInterval)
+	defer ticker.Stop()
+
+	for {
+		if m.leads(ctx) {
+			if err := m.subscribe(ctx, client, patterns, ticker.C); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// leads renews the leader lease and reports whether this replica holds it
+func (m *Manager) leads(ctx context.Context) bool {
+	held, err := m.lease.Acquire(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			m.logger.Warn("Failed to renew the Redis trigger lease", zap.Error(err))
+		}
+		return false
+	}
+	return held
+}
+
+func (m *Manager) releaseLease() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := m.lease.Release(ctx); err != nil {
+		m.logger.Warn("Failed to release the Redis trigger lease", zap.Error(err))
+	}
+}
+
+// subscribe enqueues a job per message on the patterns until ctx is
+// cancelled or, when renew ticks, the leader lease can't be renewed
+func (m *Manager) subscribe(ctx context.Context, client Subscriber, patterns map[string][]*trigger, renew <-chan time.Time) error {
+	channels := make([]string, 0, len(patterns))
+	for pattern := range patterns {
+		channels = append(channels, pattern)
+	}
+
+	pubsub := client.PSubscribe(ctx, channels...)
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return fmt.Errorf("failed to subscribe to trigger channels: %w", err)
+	}
+	m.logger.Info("Listening for trigger events", zap.Strings("channels", channels))
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-renew:
+			if !m.leads(ctx) {
+				m.logger.Info("Lost the Redis trigger lease, unsubscribing", zap.Strings("channels", channels))
+				return nil
+			}
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			for _, t := range patterns[msg.Pattern] {
+				event := map[string]interface{}{
+					"trigger": t.def.Name,
+					"source":  SourceRedis,
+					"channel": msg.Channel,
+					"message": msg.Payload,
+					"data":    decodeData([]byte(msg.Payload)),
+				}
+				_, err := m.fire(ctx, t, event)
+				if errors.Is(err, ErrReadOnly) {
+					m.logger.Warn("Dropping trigger event in read-only mode",
+						zap.String("trigger", t.def.Name),
+						zap.String("channel", msg.Channel),
+					)
+					continue
+				}
+				if err != nil {
+					m.logger.Error("Failed to enqueue triggered job",
+						zap.String("trigger", t.def.Name),
+						zap.String("channel", msg.Channel),
+						zap.Error(err),
+					)
+				}
+			}
+		}
+	}
+}
+
+// fire renders the trigger's job for event and enqueues it
+func (m *Manager) fire(ctx context.Context, t *trigger, event map[string]interface{}) (*types.Job, error) {
+	if m.readOnly != nil && m.readOnly.Current(ctx) != nil {
+		return nil, ErrReadOnly
+	}
+
+	rendered, err := render(t.payload, event)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	payload, err := json.Marshal(rendered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	maxRetries := m.maxRetries
+	if t.def.MaxRetries != nil {
+		maxRetries = *t.def.MaxRetries
+	}
+
+	triggered := types.NewJob(t.def.JobType, payload, maxRetries)
+	triggered.Metadata = types.JobMetadata{"trigger": t.def.Name}
+	if t.def.Priority != "" {
+		triggered.SetPriority(t.def.Priority)
+	}
+
+	if err := m.registry.ApplyEnqueueHooks(ctx, triggered); err != nil {
+		if errors.Is(err, job.ErrPayloadRejected) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+		}
+		return nil, err
+	}
+	if violations := m.registry.ValidatePayload(triggered.Type, triggered.Payload); len(violations) > 0 {
+		return nil, fmt.Errorf("%w: payload does not match the job type's schema: %s", ErrInvalidEvent, violations[0].Error())
+	}
+
+	if err := m.queue.Enqueue(ctx, triggered); err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	m.logger.Info("Triggered job enqueued",
+		zap.String("trigger", t.def.Name),
+		zap.String("job_id", triggered.ID),
+		zap.String("job_type", triggered.Type),
+	)
+	return triggered, nil
+}
+
+// decodeData parses body as JSON, falling back to the raw string
+func decodeData(body []byte) interface{} {
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return string(body)
+	}
+	return data
+}
+
+// compilePayload decodes the payload and parses its strings as templates
+func compilePayload(raw json.RawMessage) (interface{}, error) {
+	if len(raw) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	var payload interface{}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, fmt.Errorf("invalid payload template: %w", err)
+	}
+	return compileValue(payload)
+}
+
+func compileValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if !strings.Contains(v, "{{") {
+			return v, nil
+		}
+		tmpl, err := template.New("").Option("missingkey=error").Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload template %q: %w", v, err)
+		}
+		return tmpl, nil
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, entry := range v {
+			compiled, err := compileValue(entry)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = compiled
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, entry := range v {
+			compiled, err := compileValue(entry)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = compiled
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// render executes the compiled templates against event
+func render(value interface{}, event map[string]interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case *template.Template:
+		var buf bytes.Buffer
+		if err := v.Execute(&buf, event); err != nil {
+			return nil, err
+		}
+		return buf.String(), nil
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, entry := range v {
+			rendered, err := render(entry, event)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = rendered
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, entry := range v {
+			rendered, err := render(entry, event)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = rendered
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
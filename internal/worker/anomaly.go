@@ -0,0 +1,172 @@
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/metrics"
+	"github.com/aneeshsunganahalli/Gopher/internal/queue"
+	"github.com/aneeshsunganahalli/Gopher/internal/sink"
+	"go.uber.org/zap"
+)
+
+// anomalySample is a snapshot of the counters the anomaly signals are
+// computed from
+type anomalySample struct {
+	at           time.Time
+	enqueued     int  // Jobs ever enqueued on the queue, by any producer
+	haveEnqueued bool // The queue reported its stats
+	finished     int64
+	failed       int64
+	handlerNanos int64
+}
+
+// SetAnomalyDetector samples the queue's enqueue rate and the pool's
+// failure rate and handler latency every interval, and reports the spikes
+// detector flags as warnings, metrics and sink events
+func (p *Pool) SetAnomalyDetector(detector *metrics.AnomalyDetector, interval time.Duration) {
+	p.anomalies = detector
+	p.anomalyInterval = interval
+}
+
+// SetAnomalyLease makes the pool run its anomaly detector only while it
+// holds lease, so replicas sharing a queue report each spike once
+func (p *Pool) SetAnomalyLease(lease *queue.LeaderLease) {
+	p.anomalyLease = lease
+}
+
+// watchAnomalies feeds the anomaly detector until the pool stops
+func (p *Pool) watchAnomalies() {
+	ticker := time.NewTicker(p.anomalyInterval)
+	defer ticker.Stop()
+	defer p.releaseAnomalyLease()
+
+	var previous *anomalySample
+	if p.leadsAnomalyDetection() {
+		sample := p.sampleAnomalySignals()
+		previous = &sample
+	}
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			// A pool that lost the lease starts over from a fresh sample
+			// when it leads again
+			if !p.leadsAnomalyDetection() {
+				previous = nil
+				continue
+			}
+			current := p.sampleAnomalySignals()
+			if previous != nil {
+				p.detectAnomalies(*previous, current)
+			}
+			previous = &current
+		}
+	}
+}
+
+// leadsAnomalyDetection reports whether this pool should sample the signals
+// now. A pool that can't reach Redis to renew the lease stands down, as
+// another replica may have taken it over.
+func (p *Pool) leadsAnomalyDetection() bool {
+	if p.anomalyLease == nil {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(p.ctx, 5*time.Second)
+	defer cancel()
+
+	leads, err := p.anomalyLease.Acquire(ctx)
+	if err != nil {
+		p.logger.Warn("Failed to acquire anomaly detection lease", zap.Error(err))
+		return false
+	}
+	return leads
+}
+
+func (p *Pool) releaseAnomalyLease() {
+	if p.anomalyLease == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := p.anomalyLease.Release(ctx); err != nil {
+		p.logger.Warn("Failed to release anomaly detection lease", zap.Error(err))
+	}
+}
+
+func (p *Pool) sampleAnomalySignals() anomalySample {
+	sample := anomalySample{at: time.Now()}
+
+	p.mu.RLock()
+	processed, failed, _ := p.jobTotals()
+	for _, worker := range p.workers {
+		if worker != nil {
+			sample.handlerNanos += atomic.LoadInt64(&worker.handlerNanos)
+		}
+	}
+	p.mu.RUnlock()
+	sample.finished = processed + failed
+	sample.failed = failed
+
+	provider, ok := p.queue.(queue.StatsProvider)
+	if !ok {
+		return sample
+	}
+	ctx, cancel := context.WithTimeout(p.ctx, 5*time.Second)
+	defer cancel()
+
+	stats, err := provider.GetStats(ctx)
+	if err != nil {
+		p.logger.Warn("Failed to sample enqueue rate", zap.Error(err))
+		return sample
+	}
+	sample.enqueued = stats.TotalEnqueued
+	sample.haveEnqueued = true
+	return sample
+}
+
+// detectAnomalies turns the counters' change between two samples into the
+// signals' values
+func (p *Pool) detectAnomalies(previous, current anomalySample) {
+	elapsed := current.at.Sub(previous.at).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+
+	// A counter that went backwards was reset, e.g. by purging the queue
+	if previous.haveEnqueued && current.haveEnqueued && current.enqueued >= previous.enqueued {
+		p.checkAnomaly(metrics.SignalEnqueueRate, float64(current.enqueued-previous.enqueued)/elapsed, current.at)
+	}
+
+	// Intervals in which no job finished say nothing about failures or latency
+	finished := current.finished - previous.finished
+	if finished <= 0 {
+		return
+	}
+	p.checkAnomaly(metrics.SignalFailureRate, float64(current.failed-previous.failed)/float64(finished), current.at)
+	latency := time.Duration((current.handlerNanos - previous.handlerNanos) / finished)
+	p.checkAnomaly(metrics.SignalLatency, latency.Seconds(), current.at)
+}
+
+func (p *Pool) checkAnomaly(signal string, value float64, at time.Time) {
+	anomaly, detected := p.anomalies.Observe(signal, value, at)
+	if p.metrics != nil {
+		p.metrics.ObserveAnomaly(anomaly, detected)
+	}
+	if !detected {
+		return
+	}
+
+	p.logger.Warn("Anomaly detected",
+		zap.String("signal", signal),
+		zap.Float64("value", value),
+		zap.Float64("mean", anomaly.Mean),
+		zap.Float64("z_score", anomaly.ZScore),
+	)
+	if p.events != nil {
+		p.events.Publish(sink.NewAnomalyEvent(anomaly, p.id))
+	}
+}
@@ -0,0 +1,114 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/aneeshsunganahalli/Gopher/internal/queue"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+// HealthServer exposes liveness, readiness, metrics and stats for a worker process
+type HealthServer struct {
+	pool   *Pool
+	queue  queue.Queue
+	logger *zap.Logger
+	server *http.Server
+}
+
+// StatsResponse is returned by the /stats endpoint
+type StatsResponse struct {
+	Pool    PoolStats     `json:"pool"`
+	Workers []WorkerStats `json:"workers"`
+}
+
+// NewHealthServer creates a health server for the given pool
+func NewHealthServer(address string, pool *Pool, queue queue.Queue, logger *zap.Logger) *HealthServer {
+	h := &HealthServer{
+		pool:   pool,
+		queue:  queue,
+		logger: logger,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", h.healthHandler)
+	mux.HandleFunc("/readyz", h.readyHandler)
+	mux.HandleFunc("/stats", h.statsHandler)
+	mux.Handle("/metrics", promhttp.Handler())
+
+	h.server = &http.Server{
+		Addr:         address,
+		Handler:      mux,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	return h
+}
+
+// Start serves health endpoints until Stop is called
+func (h *HealthServer) Start() error {
+	h.logger.Info("Starting worker health server", zap.String("address", h.server.Addr))
+	if err := h.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Stop gracefully shuts down the health server
+func (h *HealthServer) Stop(ctx context.Context) error {
+	h.logger.Info("Stopping worker health server")
+	return h.server.Shutdown(ctx)
+}
+
+// healthHandler reports liveness: the process is up and serving
+func (h *HealthServer) healthHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":    "healthy",
+		"timestamp": time.Now().UTC(),
+	})
+}
+
+// readyHandler reports whether the pool is running and the queue is reachable
+func (h *HealthServer) readyHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.pool.IsRunning() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"status": "not ready",
+			"reason": "worker pool is not running",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	if err := h.queue.Health(ctx); err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"status": "not ready",
+			"reason": err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":    "ready",
+		"timestamp": time.Now().UTC(),
+	})
+}
+
+// statsHandler returns pool and per-worker statistics
+func (h *HealthServer) statsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, StatsResponse{
+		Pool:    h.pool.GetStats(),
+		Workers: h.pool.GetWorkerStats(),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -2,12 +2,21 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aneeshsunganahalli/Gopher/internal/job"
+	"github.com/aneeshsunganahalli/Gopher/internal/limiter"
+	"github.com/aneeshsunganahalli/Gopher/internal/metrics"
 	"github.com/aneeshsunganahalli/Gopher/internal/queue"
+	"github.com/aneeshsunganahalli/Gopher/internal/redact"
+	"github.com/aneeshsunganahalli/Gopher/internal/sink"
+	"github.com/aneeshsunganahalli/Gopher/pkg/types"
 	"go.uber.org/zap"
 )
 
@@ -19,18 +28,56 @@ type Pool struct {
 	registry    *job.Registry
 	queue       queue.Queue
 	logger      *zap.Logger
+	redactor    *redact.Redactor
+	history     *queue.History
+	dlq         queue.DeadLetterQueue
+	metrics     *metrics.Metrics
+	heartbeats  *queue.HeartbeatRegistry
+	limiter     limiter.RateLimiter
+	maxAges     map[string]time.Duration
+	timeouts    map[string]time.Duration
+	policies    types.JobPolicies
+	slots       *typeSlots
+	retries     *queue.RetryTracker
+	results     *queue.ResultStore
+	events      *sink.Forwarder
+	schedule    queue.Schedules
+	statuses    *queue.StatusStore
+	deps        *queue.DependencyTracker
+	workflows   *queue.WorkflowEngine
+	parking     string // Named queue for jobs of payload versions no handler here accepts
+	quarantine  *queue.Quarantine
+	signing     queue.Signing
+
+	// Anomaly detection
+	anomalies       *metrics.AnomalyDetector
+	anomalyInterval time.Duration
+	anomalyLease    *queue.LeaderLease // Held by the one pool that runs the detector, nil to run it in every pool
+
+	// Polling
+	pollInterval   time.Duration
+	pollTimeout    time.Duration
+	dequeueTimeout time.Duration
+	jobTimeout     time.Duration
 
 	// Runtime state
 	ctx     context.Context
 	cancel  context.CancelFunc
 	wg      sync.WaitGroup
 	workers []*Worker
+	running bool
+	paused  int32 // 1 while dequeuing is paused
+
+	// In-flight jobs run under jobsCtx so they survive the stop of dequeuing
+	jobsCtx    context.Context
+	abortJobs  context.CancelFunc
+	id         string
+	hostname   string
+	startedAt  time.Time
+	beatPeriod time.Duration
 
-	// Metrics
-	mu             sync.RWMutex
-	totalProcessed int64
-	totalFailed    int64
-	totalRetried   int64
+	mu         sync.RWMutex
+	policiesMu sync.RWMutex // Guards policies, which can change while running
 
 	// Shutdown
 	shutdownTimeout time.Duration
@@ -38,9 +85,13 @@ type Pool struct {
 
 // PoolConfig holds configuration for the worker pool
 type PoolConfig struct {
-	Concurrency     int
-	ShutdownTimeout time.Duration
-	PollInterval    time.Duration
+	Concurrency       int
+	ShutdownTimeout   time.Duration
+	PollInterval      time.Duration // Pause between polls that returned no job without blocking
+	PollTimeout       time.Duration // How long a single dequeue may block waiting for a job
+	DequeueTimeout    time.Duration // Allowance on top of PollTimeout before a dequeue is abandoned
+	JobTimeout        time.Duration // How long a job may execute
+	HeartbeatInterval time.Duration
 }
 
 // PoolStats holds statistics about the worker pool
@@ -50,11 +101,35 @@ type PoolStats struct {
 	TotalProcessed int64 `json:"total_processed"`
 	TotalFailed    int64 `json:"total_failed"`
 	TotalRetried   int64 `json:"total_retried"`
+	Paused         bool  `json:"paused"`
+}
+
+// ErrDrainTimeout is returned when in-flight jobs did not finish before the
+// shutdown timeout and had to be requeued
+var ErrDrainTimeout = errors.New("shutdown timeout exceeded")
+
+// DrainReport describes how a pool shutdown went
+type DrainReport struct {
+	InFlight int           `json:"in_flight"` // Jobs executing when the drain started
+	Requeued int           `json:"requeued"`  // Jobs aborted and returned to the queue
+	TimedOut bool          `json:"timed_out"`
+	Duration time.Duration `json:"duration"`
 }
 
 // NewPool creates a new worker pool
 func NewPool(config PoolConfig, queue queue.Queue, registry *job.Registry, logger *zap.Logger) *Pool {
 	ctx, cancel := context.WithCancel(context.Background())
+	jobsCtx, abortJobs := context.WithCancel(context.Background())
+
+	hostname, _ := os.Hostname()
+	beatPeriod := config.HeartbeatInterval
+	if beatPeriod <= 0 {
+		beatPeriod = 10 * time.Second
+	}
+	pollInterval := config.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
 
 	return &Pool{
 		concurrency:     config.Concurrency,
@@ -63,28 +138,165 @@ func NewPool(config PoolConfig, queue queue.Queue, registry *job.Registry, logge
 		logger:          logger,
 		ctx:             ctx,
 		cancel:          cancel,
+		jobsCtx:         jobsCtx,
+		abortJobs:       abortJobs,
+		id:              fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		hostname:        hostname,
+		beatPeriod:      beatPeriod,
 		workers:         make([]*Worker, config.Concurrency),
 		shutdownTimeout: config.ShutdownTimeout,
+		pollInterval:    pollInterval,
+		pollTimeout:     config.PollTimeout,
+		dequeueTimeout:  config.DequeueTimeout,
+		jobTimeout:      config.JobTimeout,
+		slots:           newTypeSlots(nil),
 	}
 }
 
+// ID returns the pool's identity in heartbeats and events
+func (p *Pool) ID() string {
+	return p.id
+}
+
+// SetRedactor sets the redactor applied to payloads before they are logged
+func (p *Pool) SetRedactor(redactor *redact.Redactor) {
+	p.redactor = redactor
+}
+
+// SetHistory enables recording of finished jobs
+func (p *Pool) SetHistory(history *queue.History) {
+	p.history = history
+}
+
+// SetDeadLetterQueue sets where permanently failed jobs are sent
+func (p *Pool) SetDeadLetterQueue(dlq queue.DeadLetterQueue) {
+	p.dlq = dlq
+}
+
+// SetHeartbeats registers the pool in the heartbeat registry while it runs
+func (p *Pool) SetHeartbeats(heartbeats *queue.HeartbeatRegistry) {
+	p.heartbeats = heartbeats
+}
+
+// SetRateLimiter throttles job execution per job type
+func (p *Pool) SetRateLimiter(rateLimiter limiter.RateLimiter) {
+	p.limiter = rateLimiter
+}
+
+// SetMaxAges rejects jobs older than the given age per job type to the DLQ
+func (p *Pool) SetMaxAges(maxAges map[string]time.Duration) {
+	p.maxAges = maxAges
+}
+
+// SetJobTimeouts overrides the job timeout per job type; a job's own Timeout still wins
+func (p *Pool) SetJobTimeouts(timeouts map[string]time.Duration) {
+	p.timeouts = timeouts
+}
+
+// SetPolicies applies per job type timeouts, retry backoff and concurrency
+// caps; the caps count jobs running in this pool. It may be called while the
+// pool runs, e.g. when a policy is overridden at runtime.
+func (p *Pool) SetPolicies(policies types.JobPolicies) {
+	limits := make(map[string]int)
+	for jobType, policy := range policies {
+		if policy.Concurrency > 0 {
+			limits[jobType] = policy.Concurrency
+		}
+	}
+
+	p.policiesMu.Lock()
+	p.policies = policies
+	p.policiesMu.Unlock()
+	p.slots.setLimits(limits)
+}
+
+// policy returns the policy currently in effect for a job type
+func (p *Pool) policy(jobType string) types.JobPolicy {
+	p.policiesMu.RLock()
+	defer p.policiesMu.RUnlock()
+	return p.policies.Get(jobType)
+}
+
+// SetRetryTracker publishes jobs waiting out a retry backoff
+func (p *Pool) SetRetryTracker(retries *queue.RetryTracker) {
+	p.retries = retries
+}
+
+// SetResultStore persists the result of every job attempt
+func (p *Pool) SetResultStore(results *queue.ResultStore) {
+	p.results = results
+}
+
+// SetEventSink forwards completed and permanently failed jobs to outbound sinks
+func (p *Pool) SetEventSink(events *sink.Forwarder) {
+	p.events = events
+}
+
+// SetScheduledQueue records the outcome of jobs spawned by recurring schedules
+func (p *Pool) SetScheduledQueue(scheduled queue.Schedules) {
+	p.schedule = scheduled
+}
+
+// SetStatusStore records each job's state transitions for lookup by ID
+func (p *Pool) SetStatusStore(statuses *queue.StatusStore) {
+	p.statuses = statuses
+}
+
+// SetDependencyTracker releases jobs waiting on the jobs this pool finishes
+func (p *Pool) SetDependencyTracker(deps *queue.DependencyTracker) {
+	p.deps = deps
+}
+
+// SetWorkflowEngine advances the workflows whose nodes this pool finishes
+func (p *Pool) SetWorkflowEngine(workflows *queue.WorkflowEngine) {
+	p.workflows = workflows
+}
+
+// SetParkingQueue moves jobs whose payload version the handler doesn't
+// accept to the named queue, for upgraded workers to pick up, instead of
+// dead-lettering them
+func (p *Pool) SetParkingQueue(name string) {
+	p.parking = name
+}
+
+// SetQuarantine holds untrusted jobs, jobs whose payload doesn't match the
+// handler's schema and poison jobs for review instead of dead-lettering them
+func (p *Pool) SetQuarantine(quarantine *queue.Quarantine) {
+	p.quarantine = quarantine
+}
+
+// SetSigning verifies the signature of every dequeued job
+func (p *Pool) SetSigning(signing queue.Signing) {
+	p.signing = signing
+}
+
+// SetMetrics enables Prometheus metrics for the pool and its workers
+func (p *Pool) SetMetrics(m *metrics.Metrics) {
+	p.metrics = m
+}
+
 func (p *Pool) Start() error {
 	p.logger.Info("Starting worker pool", zap.Int("concurrency", p.concurrency))
 
 	// Start workers
 	for i := 0; i < p.concurrency; i++ {
 		workerConfig := WorkerConfig{
-			ID:           fmt.Sprintf("worker-%d", i+1),
-			PollInterval: time.Second,
+			ID:             fmt.Sprintf("worker-%d", i+1),
+			PollInterval:   p.pollInterval,
+			PollTimeout:    p.pollTimeout,
+			DequeueTimeout: p.dequeueTimeout,
+			JobTimeout:     p.jobTimeout,
 		}
 
 		worker := NewWorker(workerConfig, p.queue, p.registry, p.logger)
+		p.configureWorker(worker)
 		p.workers[i] = worker
 
 		// Start worker in goroutine
 		p.wg.Add(1)
 		go func(w *Worker) {
 			defer p.wg.Done()
+			defer w.retries.Wait()
 
 			if err := w.Start(p.ctx); err != nil {
 				p.logger.Error("Worker stopped with error",
@@ -102,14 +314,80 @@ func (p *Pool) Start() error {
 		p.collectMetrics()
 	}()
 
+	p.mu.Lock()
+	p.running = true
+	p.startedAt = time.Now().UTC()
+	p.mu.Unlock()
+
+	if p.heartbeats != nil {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.sendHeartbeats()
+		}()
+	}
+
+	if p.anomalies != nil && p.anomalyInterval > 0 {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.watchAnomalies()
+		}()
+	}
+
 	p.logger.Info("Worker pool started successfully")
 	return nil
 
 }
 
+// configureWorker passes the pool's optional components on to a worker
+func (p *Pool) configureWorker(w *Worker) {
+	w.redactor = p.redactor
+	w.history = p.history
+	w.dlq = p.dlq
+	w.metrics = p.metrics
+	w.jobsCtx = p.jobsCtx
+	w.paused = p.IsPaused
+	w.limiter = p.limiter
+	w.maxAges = p.maxAges
+	w.timeouts = p.timeouts
+	w.policy = p.policy
+	w.slots = p.slots
+	w.tracker = p.retries
+	w.results = p.results
+	w.events = p.events
+	w.schedule = p.schedule
+	w.statuses = p.statuses
+	w.deps = p.deps
+	w.workflows = p.workflows
+	w.parking = p.parking
+	w.quarantine = p.quarantine
+	w.signing = p.signing
+}
+
+// Stop drains the pool; see Drain
 func (p *Pool) Stop() error {
-	p.logger.Info("Stopping worker pool", zap.Duration("timeout", p.shutdownTimeout))
+	_, err := p.Drain()
+	return err
+}
+
+// Drain stops dequeuing immediately and waits for in-flight jobs to finish.
+// Jobs still running at the shutdown timeout are aborted and requeued, and
+// ErrDrainTimeout is returned. The pool's heartbeat is removed either way.
+func (p *Pool) Drain() (*DrainReport, error) {
+	start := time.Now()
+	report := &DrainReport{InFlight: p.getBusyWorkerCount()}
 
+	p.logger.Info("Draining worker pool",
+		zap.Duration("timeout", p.shutdownTimeout),
+		zap.Int("in_flight", report.InFlight),
+	)
+
+	p.mu.Lock()
+	p.running = false
+	p.mu.Unlock()
+
+	p.beat(context.Background(), true)
 	p.cancel()
 
 	done := make(chan struct{})
@@ -120,11 +398,100 @@ func (p *Pool) Stop() error {
 
 	select {
 	case <-done:
-		p.logger.Info("Worker pool stopped gracefully")
-		return nil
 	case <-time.After(p.shutdownTimeout):
-		p.logger.Warn("Worker pool shutdown timeout exceeded")
-		return fmt.Errorf("shutdown timeout exceeded")
+		report.TimedOut = true
+		p.logger.Warn("Worker pool shutdown timeout exceeded, aborting in-flight jobs")
+		p.abortJobs()
+
+		// Give aborted jobs a moment to be requeued
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			p.logger.Error("Workers did not stop after aborting in-flight jobs")
+		}
+	}
+	p.abortJobs()
+
+	for _, worker := range p.workers {
+		if worker != nil {
+			report.Requeued += int(worker.GetStats().JobsRequeued)
+		}
+	}
+
+	p.deregister()
+	report.Duration = time.Since(start)
+
+	if report.TimedOut {
+		p.logger.Warn("Worker pool drain timed out",
+			zap.Int("requeued", report.Requeued),
+			zap.Duration("duration", report.Duration),
+		)
+		return report, fmt.Errorf("%w: %d jobs requeued", ErrDrainTimeout, report.Requeued)
+	}
+
+	p.logger.Info("Worker pool drained cleanly", zap.Duration("duration", report.Duration))
+	return report, nil
+}
+
+// sendHeartbeats refreshes the pool's heartbeat until the pool stops
+func (p *Pool) sendHeartbeats() {
+	ticker := time.NewTicker(p.beatPeriod)
+	defer ticker.Stop()
+
+	p.beat(p.ctx, false)
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.beat(p.ctx, false)
+		}
+	}
+}
+
+// beat records a single heartbeat for the pool
+func (p *Pool) beat(ctx context.Context, draining bool) {
+	if p.heartbeats == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	p.mu.RLock()
+	startedAt := p.startedAt
+	processed, failed, retried := p.jobTotals()
+	p.mu.RUnlock()
+
+	err := p.heartbeats.Beat(ctx, queue.WorkerHeartbeat{
+		ID:            p.id,
+		Hostname:      p.hostname,
+		PID:           os.Getpid(),
+		Concurrency:   p.concurrency,
+		ActiveJobs:    p.getBusyWorkerCount(),
+		Draining:      draining,
+		StartedAt:     startedAt,
+		JobsProcessed: processed,
+		JobsFailed:    failed,
+		JobsRetried:   retried,
+		JobTypes:      p.registry.Type(),
+	})
+	if err != nil && ctx.Err() == nil {
+		p.logger.Warn("Failed to send heartbeat", zap.Error(err))
+	}
+}
+
+// deregister removes the pool's heartbeat after shutdown
+func (p *Pool) deregister() {
+	if p.heartbeats == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := p.heartbeats.Deregister(ctx, p.id); err != nil {
+		p.logger.Warn("Failed to deregister heartbeat", zap.Error(err))
 	}
 }
 
@@ -139,13 +506,113 @@ func (p *Pool) GetStats() PoolStats {
 			activeWorkers++
 		}
 	}
+	processed, failed, retried := p.jobTotals()
 	return PoolStats{
 		TotalWorkers:   p.concurrency,
 		ActiveWorkers:  activeWorkers,
-		TotalProcessed: p.totalProcessed,
-		TotalFailed:    p.totalFailed,
-		TotalRetried:   p.totalRetried,
+		TotalProcessed: processed,
+		TotalFailed:    failed,
+		TotalRetried:   retried,
+		Paused:         p.IsPaused(),
+	}
+}
+
+// jobTotals sums the job counters of all workers; callers hold p.mu
+func (p *Pool) jobTotals() (processed, failed, retried int64) {
+	for _, worker := range p.workers {
+		if worker == nil {
+			continue
+		}
+		stats := worker.GetStats()
+		processed += stats.JobsProcessed
+		failed += stats.JobsFailed
+		retried += stats.JobsRetried
+	}
+	return processed, failed, retried
+}
+
+// Pause stops workers from dequeuing new jobs; in-flight jobs keep running
+func (p *Pool) Pause() {
+	if atomic.CompareAndSwapInt32(&p.paused, 0, 1) {
+		p.logger.Info("Worker pool paused")
+	}
+}
+
+// Resume lets workers dequeue again after Pause
+func (p *Pool) Resume() {
+	if atomic.CompareAndSwapInt32(&p.paused, 1, 0) {
+		p.logger.Info("Worker pool resumed")
+	}
+}
+
+// TogglePause pauses a running pool or resumes a paused one and returns the new state
+func (p *Pool) TogglePause() bool {
+	if p.IsPaused() {
+		p.Resume()
+		return false
+	}
+	p.Pause()
+	return true
+}
+
+// IsPaused reports whether dequeuing is paused
+func (p *Pool) IsPaused() bool {
+	return atomic.LoadInt32(&p.paused) == 1
+}
+
+// InFlightJobs returns the IDs of jobs currently executing
+func (p *Pool) InFlightJobs() []string {
+	var ids []string
+	for _, worker := range p.workers {
+		if worker == nil {
+			continue
+		}
+		if id := worker.CurrentJobID(); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// DumpState logs pool statistics, in-flight jobs and goroutine counts for debugging
+func (p *Pool) DumpState() {
+	p.updateMetrics()
+	stats := p.GetStats()
+
+	p.logger.Info("Worker pool state",
+		zap.String("pool_id", p.id),
+		zap.Int("total_workers", stats.TotalWorkers),
+		zap.Int("active_workers", stats.ActiveWorkers),
+		zap.Int("busy_workers", p.getBusyWorkerCount()),
+		zap.Bool("paused", stats.Paused),
+		zap.Int64("processed", stats.TotalProcessed),
+		zap.Int64("failed", stats.TotalFailed),
+		zap.Int64("retried", stats.TotalRetried),
+		zap.Strings("in_flight_jobs", p.InFlightJobs()),
+		zap.Int("goroutines", runtime.NumGoroutine()),
+	)
+}
+
+// GetWorkerStats returns statistics for each worker in the pool
+func (p *Pool) GetWorkerStats() []WorkerStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	stats := make([]WorkerStats, 0, len(p.workers))
+	for _, worker := range p.workers {
+		if worker != nil {
+			stats = append(stats, worker.GetStats())
+		}
 	}
+	return stats
+}
+
+// IsRunning reports whether the pool has started and is not shutting down
+func (p *Pool) IsRunning() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.running
 }
 
 // collectMetrics periodically collects metrics from workers
@@ -168,18 +635,7 @@ func (p *Pool) updateMetrics() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	
-	var totalProcessed, totalFailed, totalRetried int64
-	
-	for _, worker := range p.workers {
-		stats := worker.GetStats()
-		totalProcessed += stats.JobsProcessed
-		totalFailed += stats.JobsFailed
-		totalRetried += stats.JobsRetried
-	}
-	
-	p.totalProcessed = totalProcessed
-	p.totalFailed = totalFailed
-	p.totalRetried = totalRetried
+	totalProcessed, totalFailed, totalRetried := p.jobTotals()
 	
 	// Log metrics periodically
 	p.logger.Info("Worker pool metrics",
@@ -188,6 +644,30 @@ func (p *Pool) updateMetrics() {
 		zap.Int64("retried", totalRetried),
 		zap.Int("active_workers", p.getActiveWorkerCount()),
 	)
+
+	if p.metrics != nil {
+		p.metrics.ObservePool(p.concurrency, p.getBusyWorkerCount(), totalProcessed, totalFailed, totalRetried)
+	}
+
+	p.observeDLQ()
+}
+
+// observeDLQ publishes dead letter queue size, age and inflow gauges
+func (p *Pool) observeDLQ() {
+	if p.dlq == nil || p.metrics == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(p.ctx, 5*time.Second)
+	defer cancel()
+
+	stats, err := p.dlq.GetStats(ctx)
+	if err != nil {
+		p.logger.Warn("Failed to collect DLQ stats", zap.Error(err))
+		return
+	}
+
+	p.metrics.ObserveDLQ(stats.Size, stats.OldestAgeSecs, stats.InflowPerMinute)
 }
 
 func (p *Pool) getActiveWorkerCount() int {
@@ -198,4 +678,63 @@ func (p *Pool) getActiveWorkerCount() int {
 		}
 	}
 	return count
-}
\ No newline at end of file
+}
+
+func (p *Pool) getBusyWorkerCount() int {
+	count := 0
+	for _, worker := range p.workers {
+		if worker != nil && worker.IsBusy() {
+			count++
+		}
+	}
+	return count
+}
+// typeSlots caps how many jobs of each type a pool runs at once
+type typeSlots struct {
+	mu      sync.Mutex
+	limits  map[string]int
+	running map[string]int
+}
+
+func newTypeSlots(limits map[string]int) *typeSlots {
+	return &typeSlots{
+		limits:  limits,
+		running: make(map[string]int, len(limits)),
+	}
+}
+
+// setLimits replaces the caps; jobs already running keep their slots
+func (s *typeSlots) setLimits(limits map[string]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limits = limits
+}
+
+// acquire takes a slot for the job type, reporting false when it is at its cap
+func (s *typeSlots) acquire(jobType string) bool {
+	if s == nil {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Uncapped types are counted too, so a cap set later sees their jobs
+	if limit, ok := s.limits[jobType]; ok && s.running[jobType] >= limit {
+		return false
+	}
+	s.running[jobType]++
+	return true
+}
+
+// release frees a slot taken by acquire
+func (s *typeSlots) release(jobType string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running[jobType] > 0 {
+		s.running[jobType]--
+	}
+}
@@ -2,29 +2,69 @@ package worker
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/aneeshsunganahalli/Gopher/internal/job"
+	"github.com/aneeshsunganahalli/Gopher/internal/limiter"
+	"github.com/aneeshsunganahalli/Gopher/internal/metrics"
 	"github.com/aneeshsunganahalli/Gopher/internal/queue"
+	"github.com/aneeshsunganahalli/Gopher/internal/redact"
+	"github.com/aneeshsunganahalli/Gopher/internal/sink"
 	"github.com/aneeshsunganahalli/Gopher/pkg/types"
 	"go.uber.org/zap"
 )
 
-type contextKey string
-
-const startTimeKey contextKey = "start_time"
-
 type Worker struct {
-	config   WorkerConfig
-	queue    queue.Queue
-	registry *job.Registry
-	logger   *zap.Logger
+	config     WorkerConfig
+	queue      queue.Queue
+	registry   *job.Registry
+	logger     *zap.Logger
+	redactor   *redact.Redactor
+	history    *queue.History
+	dlq        queue.DeadLetterQueue
+	metrics    *metrics.Metrics
+	limiter    limiter.RateLimiter
+	maxAges    map[string]time.Duration // Per job type age limit checked at dequeue
+	timeouts   map[string]time.Duration // Per job type handler timeout, overriding JobTimeout
+	slots      *typeSlots               // Shared with the pool's other workers, nil outside a pool
+	tracker    *queue.RetryTracker      // Publishes jobs waiting out a retry backoff
+	results    *queue.ResultStore
+	events     *sink.Forwarder          // Forwards final job outcomes to outbound sinks
+	schedule   queue.Schedules          // Records outcomes of jobs spawned by recurring schedules
+	statuses   *queue.StatusStore       // Records each job's state transitions
+	deps       *queue.DependencyTracker // Releases jobs waiting on finished ones
+	workflows  *queue.WorkflowEngine    // Releases the next nodes of workflows
+	parking    string                   // Named queue for unsupported payload versions, empty to dead-letter them
+	quarantine *queue.Quarantine        // Holds untrusted, schema-violating and poison jobs for review, nil to dead-letter them
+	signing    queue.Signing            // Verifies dequeued jobs
 
 	jobsProcessed int64
 	jobsFailed    int64
 	jobsRetried   int64
+	jobsRequeued  int64
 	isActive      int32 // 0 = inactive, 1 = active
+	isBusy        int32 // 1 while a job is executing
+	handlerNanos  int64 // Time spent in handlers, for the pool's anomaly detector
+
+	// paused reports whether dequeuing is paused; set by the pool
+	paused func() bool
+
+	// policy returns a job type's current timeout, backoff and concurrency
+	// policy; set by the pool
+	policy func(jobType string) types.JobPolicy
+
+	currentJobMu sync.Mutex
+	currentJobID string
+
+	// Jobs run under jobsCtx rather than the loop context, so stopping the
+	// loop lets in-flight jobs finish; cancelling jobsCtx aborts them
+	jobsCtx context.Context
+	stopCtx context.Context
+	retries sync.WaitGroup
 
 	// Current job context (for cancellation)
 	currentJobCtx    context.Context
@@ -33,25 +73,40 @@ type Worker struct {
 
 // WorkerConfig holds configuration for a worker
 type WorkerConfig struct {
-	ID           string
-	PollInterval time.Duration
+	ID             string
+	PollInterval   time.Duration
+	PollTimeout    time.Duration // Long-poll timeout of the queue, bounds how long Dequeue may block
+	DequeueTimeout time.Duration // Allowance on top of PollTimeout before a stuck Dequeue is abandoned
+	JobTimeout     time.Duration // How long a handler may run before its context is cancelled
 }
 
+// defaultTimeout applies when a WorkerConfig leaves DequeueTimeout or JobTimeout unset
+const defaultTimeout = 30 * time.Second
+
 // WorkerStats holds statistics for a single worker
 type WorkerStats struct {
 	WorkerID       string `json:"worker_id"`
 	JobsProcessed  int64  `json:"jobs_processed"`
 	JobsFailed     int64  `json:"jobs_failed"`
 	JobsRetried    int64  `json:"jobs_retried"`
+	JobsRequeued   int64  `json:"jobs_requeued"`
 	IsActive       bool   `json:"is_active"`
 }
 
 func NewWorker(config WorkerConfig, queue queue.Queue, registry *job.Registry, logger *zap.Logger) *Worker {
+	if config.DequeueTimeout <= 0 {
+		config.DequeueTimeout = defaultTimeout
+	}
+	if config.JobTimeout <= 0 {
+		config.JobTimeout = defaultTimeout
+	}
+
 	return &Worker{
 		config:   config,
 		queue:    queue,
 		registry: registry,
 		logger:   logger.With(zap.String("worker_id", config.ID)),
+		jobsCtx:  context.Background(),
 	}
 }
 
@@ -62,14 +117,23 @@ func (w *Worker) Start(ctx context.Context) error {
 	atomic.StoreInt32(&w.isActive, 1)
 	defer atomic.StoreInt32(&w.isActive, 0)
 
+	w.stopCtx = ctx
+
 	for {
 		select {
 		case <-ctx.Done():
 			w.logger.Info("Worker stopping due to context cancellation")
-			w.cancelCurrentJob()
 			return ctx.Err()
 
 		default:
+			if w.paused != nil && w.paused() {
+				select {
+				case <-ctx.Done():
+				case <-time.After(w.config.PollInterval):
+				}
+				continue
+			}
+
 			// Process next job
 			if err := w.processNextJob(ctx); err != nil {
 				w.logger.Error("Error processing job", zap.Error(err))
@@ -81,33 +145,98 @@ func (w *Worker) Start(ctx context.Context) error {
 
 
 func (w *Worker) processNextJob(ctx context.Context) error {
-	jobCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
-
-	w.currentJobCtx = jobCtx
-	w.currentJobCancel = cancel
-	defer func() {
-		w.currentJobCtx = nil
-		w.currentJobCancel = nil
-	}()
-
-	// Fetch job from queue
-	job, err := w.queue.Dequeue(jobCtx)
+	// Fetch job from queue; cancelling ctx stops dequeuing immediately
+	dequeueStart := time.Now()
+	dequeueCtx, cancelDequeue := context.WithTimeout(ctx, w.config.PollTimeout+w.config.DequeueTimeout)
+	job, err := w.queue.Dequeue(dequeueCtx)
+	cancelDequeue()
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
 		return err
 	}
 
-	// No job available
+	// No job available. A long poll has already waited, so only queues that
+	// return immediately are held back to one poll per PollInterval.
 	if job == nil {
-		// Short sleep to prevent tight polling
+		wait := w.config.PollInterval - time.Since(dequeueStart)
+		if wait <= 0 {
+			return nil
+		}
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-time.After(w.config.PollInterval):
+		case <-time.After(wait):
 			return nil
 		}
 	}
 
+	// Jobs written by a newer release can't be trusted to decode correctly
+	if w.rejectIncompatible(job) {
+		w.ack(job)
+		return nil
+	}
+
+	// Jobs without a valid signature may have been written straight into
+	// the queue, so they never reach a handler
+	if w.rejectUntrusted(job) {
+		w.ack(job)
+		return nil
+	}
+
+	// Payloads in a version the handler doesn't know would be misparsed
+	if w.rejectUnsupportedVersion(job) {
+		w.ack(job)
+		return nil
+	}
+
+	// Payloads that don't match the handler's schema wait for review
+	if w.quarantineInvalidPayload(job) {
+		w.ack(job)
+		return nil
+	}
+
+	// Jobs past their own deadline are dropped rather than run late
+	if w.discardPastDeadline(job) {
+		w.ack(job)
+		return nil
+	}
+
+	// Jobs too old to still be useful go straight to the DLQ
+	if w.rejectExpired(job) {
+		w.ack(job)
+		return nil
+	}
+
+	// Job types at their concurrency cap go back to the queue untouched
+	if !w.slots.acquire(job.Type) {
+		return w.deferThrottledJob(ctx, job)
+	}
+	defer w.slots.release(job.Type)
+
+	// Throttled job types go back to the queue untouched
+	if !w.allowed(ctx, job) {
+		return w.deferThrottledJob(ctx, job)
+	}
+
+	// Execution is detached from ctx so a drain lets the job finish
+	jobCtx, cancel := context.WithTimeout(w.jobsCtx, w.jobTimeout(job))
+	defer cancel()
+
+	w.currentJobCtx = jobCtx
+	w.currentJobCancel = cancel
+	defer func() {
+		w.currentJobCtx = nil
+		w.currentJobCancel = nil
+	}()
+
+	atomic.StoreInt32(&w.isBusy, 1)
+	defer atomic.StoreInt32(&w.isBusy, 0)
+
+	w.setCurrentJobID(job.ID)
+	defer w.setCurrentJobID("")
+
 	// Process the job
 	return w.executeJob(jobCtx, job)
 }
@@ -117,7 +246,7 @@ func (w *Worker) executeJob(ctx context.Context, job *types.Job) error {
 	startTime := time.Now()
 
 	// Add start time to context for duration calculation
-	ctx = context.WithValue(ctx, startTimeKey, startTime.UnixNano())
+	ctx = withStartTime(ctx, startTime)
 
 	w.logger.Info("Starting job execution",
 		zap.String("job_id", job.ID),
@@ -125,12 +254,46 @@ func (w *Worker) executeJob(ctx context.Context, job *types.Job) error {
 		zap.Int("attempt", job.Attempts+1),
 		zap.Int("max_retries", job.MaxRetries),
 	)
+	w.logger.Debug("Job payload",
+		zap.String("job_id", job.ID),
+		zap.ByteString("payload", w.redactor.Payload(job.Type, job.Payload)),
+	)
+
+	w.observeQueueWait(job, startTime)
+
+	// Ack once the job is handled; a retry is acked after it is re-enqueued,
+	// and a job that couldn't be put back stays unacked for recovery
+	skipAck := false
+	defer func() {
+		if !skipAck {
+			w.ack(job)
+		}
+	}()
 
 	// Increment attempt counter
 	job.IncrementAttempts()
-	
+	w.recordStatus(job, types.StatusProcessing, "")
+
+	// Buffer follow-up jobs until the handler succeeds
+	ctx, deferred := w.deferredEnqueuer(ctx)
+	ctx = w.withProgressReporter(ctx, job)
+
 	// Process job using registry
 	result := w.registry.Process(ctx, job)
+	atomic.AddInt64(&w.handlerNanos, int64(result.Timing.HandlerDuration))
+	if w.metrics != nil && result.Variant != "" {
+		w.metrics.ObserveHandler(job.Type, result.Variant, string(result.Status), result.Timing.HandlerDuration)
+	}
+
+	// A drain that timed out aborted the job; put it back untouched
+	if w.jobsCtx.Err() != nil && result.Status != types.StatusCompleted {
+		deferred.Discard()
+		skipAck = !w.checkpointJob(job)
+		return nil
+	}
+
+	w.settleDeferredJobs(job, result, deferred)
+	w.saveResult(job, result)
 
 	switch result.Status {
 	case types.StatusCompleted:
@@ -139,9 +302,20 @@ func (w *Worker) executeJob(ctx context.Context, job *types.Job) error {
 			zap.String("job_id", job.ID),
 			zap.String("duration", result.Duration),
 		)
+		w.recordStatus(job, types.StatusCompleted, "")
+		w.recordHistory(job, result)
+		w.recordScheduleRun(job, result)
+		w.publishEvent(job, result)
+		w.settleWaiters(job, result)
 		
 	case types.StatusFailed:
 		atomic.AddInt64(&w.jobsFailed, 1)
+		w.observeFailure(job, result)
+
+		// Poison jobs can never succeed, so they skip their retries and wait for review
+		if result.FailureReason == types.ReasonPoison && w.quarantineJob(job, types.ReasonPoison, result.Error) {
+			return nil
+		}
 		
 		// Check if we should retry
 		if job.ShouldRetry() {
@@ -154,6 +328,8 @@ func (w *Worker) executeJob(ctx context.Context, job *types.Job) error {
 			)
 			
 			// Re-enqueue job for retry with exponential backoff
+			skipAck = true
+			w.recordStatus(job, types.StatusRetrying, result.Error)
 			if err := w.requeueJobWithDelay(ctx, job); err != nil {
 				w.logger.Error("Failed to requeue job for retry",
 					zap.String("job_id", job.ID),
@@ -166,28 +342,236 @@ func (w *Worker) executeJob(ctx context.Context, job *types.Job) error {
 				zap.String("error", result.Error),
 				zap.Int("attempts", job.Attempts),
 			)
-			
+
+			w.recordStatus(job, types.StatusFailed, result.Error)
+			w.recordHistory(job, result)
+			w.recordScheduleRun(job, result)
+			w.publishEvent(job, result)
+			w.settleWaiters(job, result)
+			reason := result.FailureReason
+			if reason == "" {
+				reason = types.ReasonHandlerError
+			}
+			w.sendToDLQ(job, reason, result.Error)
 		}
 	}
 	
 	return nil
 }
 
-func (w *Worker) requeueJobWithDelay(ctx context.Context, job *types.Job) error {
+// withStartTime records the pickup time for the registry's timing breakdown
+func withStartTime(ctx context.Context, startTime time.Time) context.Context {
+	return job.WithStartTime(ctx, startTime)
+}
+
+// deferredEnqueuer attaches a buffering enqueuer to the handler context
+func (w *Worker) deferredEnqueuer(ctx context.Context) (context.Context, *job.DeferredEnqueuer) {
+	enqueuer := job.NewDeferredEnqueuer(w.queue)
+	return job.WithEnqueuer(ctx, enqueuer), enqueuer
+}
 
-	delay := time.Duration(1<<uint(job.Attempts-1)) * time.Second
+// settleDeferredJobs flushes follow-up jobs on success and drops them otherwise
+func (w *Worker) settleDeferredJobs(current *types.Job, result *types.JobResult, deferred *job.DeferredEnqueuer) {
+	if deferred.Pending() == 0 {
+		return
+	}
 
-	// Delay cap at 5 min
-	if delay > 5*time.Minute {
-		delay = 5*time.Minute
+	if result.Status != types.StatusCompleted {
+		dropped := deferred.Discard()
+		w.logger.Info("Discarded deferred jobs from failed handler",
+			zap.String("job_id", current.ID),
+			zap.Int("discarded", dropped),
+		)
+		return
 	}
 
+	// Use a fresh context so a job that used its whole timeout can still flush
+	flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := deferred.Flush(flushCtx); err != nil {
+		w.logger.Error("Failed to flush deferred jobs",
+			zap.String("job_id", current.ID),
+			zap.Int("remaining", deferred.Pending()),
+			zap.Error(err),
+		)
+	}
+}
+
+// observeFailure counts a failed attempt by reason and error fingerprint
+func (w *Worker) observeFailure(failed *types.Job, result *types.JobResult) {
+	if w.metrics == nil {
+		return
+	}
+	reason := result.FailureReason
+	if reason == "" {
+		reason = types.ReasonHandlerError
+	}
+	w.metrics.ObserveFailure(failed.Type, string(reason), result.ErrorFingerprint)
+}
+
+// sendToDLQ moves a permanently failed job into the dead letter queue
+func (w *Worker) sendToDLQ(failed *types.Job, reason types.FailureReason, errorMsg string) {
+	if w.dlq == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.dlq.Send(ctx, failed, reason, errorMsg); err != nil {
+		w.logger.Error("Failed to send job to DLQ",
+			zap.String("job_id", failed.ID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	if w.metrics != nil {
+		w.metrics.JobsDeadLettered.WithLabelValues(failed.Type, string(reason)).Inc()
+	}
+}
+
+// saveResult persists the attempt's result; failures that will be retried are stored as retrying
+func (w *Worker) saveResult(finished *types.Job, result *types.JobResult) {
+	if w.results == nil {
+		return
+	}
+
+	stored := *result
+	if stored.Status == types.StatusFailed && finished.ShouldRetry() {
+		stored.Status = types.StatusRetrying
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.results.Save(ctx, finished, &stored); err != nil {
+		w.logger.Warn("Failed to save job result",
+			zap.String("job_id", finished.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+// withProgressReporter lets the handler report the job's progress
+func (w *Worker) withProgressReporter(ctx context.Context, current *types.Job) context.Context {
+	return job.WithProgressReporter(ctx, &progressReporter{statuses: w.statuses, jobID: current.ID})
+}
+
+// progressReporter stores the progress a handler reports in its job's
+// status record; reports are dropped when status tracking is disabled
+type progressReporter struct {
+	statuses *queue.StatusStore
+	jobID    string
+}
+
+// ReportProgress implements job.ProgressReporter
+func (r *progressReporter) ReportProgress(ctx context.Context, progress types.JobProgress) error {
+	if r.statuses == nil {
+		return nil
+	}
+	return r.statuses.SetProgress(ctx, r.jobID, progress)
+}
+
+// recordStatus stores the state a job has reached
+func (w *Worker) recordStatus(tracked *types.Job, status types.JobStatus, errMsg string) {
+	if w.statuses == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.statuses.Set(ctx, tracked, status, errMsg); err != nil {
+		w.logger.Warn("Failed to record job status",
+			zap.String("job_id", tracked.ID),
+			zap.String("status", string(status)),
+			zap.Error(err),
+		)
+	}
+}
+
+// recordHistory stores the outcome of a finished job
+func (w *Worker) recordHistory(finished *types.Job, result *types.JobResult) {
+	if w.history == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.history.Record(ctx, finished, result); err != nil {
+		w.logger.Warn("Failed to record job history",
+			zap.String("job_id", finished.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+// recordScheduleRun stores the outcome of a job spawned by a recurring schedule
+func (w *Worker) recordScheduleRun(finished *types.Job, result *types.JobResult) {
+	if w.schedule == nil || finished.ScheduleID == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.schedule.RecordOutcome(ctx, finished, result); err != nil {
+		w.logger.Warn("Failed to record schedule run outcome",
+			zap.String("job_id", finished.ID),
+			zap.String("schedule_id", finished.ScheduleID),
+			zap.Error(err),
+		)
+	}
+}
+
+// publishEvent forwards the final outcome of a job to the outbound sinks
+func (w *Worker) publishEvent(finished *types.Job, result *types.JobResult) {
+	if w.events == nil {
+		return
+	}
+	w.events.Publish(sink.NewEvent(finished, result, w.config.ID))
+}
+
+func (w *Worker) requeueJobWithDelay(ctx context.Context, job *types.Job) error {
+
+	// Backoff set on the job at enqueue wins over the job type's policy
+	policy := w.policyFor(job.Type)
+	if job.Backoff != nil {
+		policy.Backoff = job.Backoff
+	}
+	delay := policy.RetryDelay(job.Attempts)
+
 	w.logger.Info("Scheduling job retry",
 	zap.String("job_id", job.ID),
 	zap.Duration("delay", delay),)
 
+	// Backends that can delay jobs themselves keep the retry across worker restarts
+	if scheduler, ok := w.queue.(queue.Scheduler); ok {
+		scheduleCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := scheduler.Schedule(scheduleCtx, job, time.Now().Add(delay)); err != nil {
+			return fmt.Errorf("failed to schedule retry: %w", err)
+		}
+		w.ack(job)
+		return nil
+	}
+
+	w.trackRetry(job, time.Now().Add(delay))
+
+	w.retries.Add(1)
 	go func(){
-		time.Sleep(delay)
+		defer w.retries.Done()
+		defer w.untrackRetry(job.ID)
+
+		// Stopping the worker enqueues the retry right away instead of losing it
+		select {
+		case <-time.After(delay):
+		case <-w.stopCtx.Done():
+		}
 
 		retryCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -197,12 +581,60 @@ func (w *Worker) requeueJobWithDelay(ctx context.Context, job *types.Job) error
 				zap.String("job_id", job.ID),
 				zap.Error(err),
 			)
+			return
 		}
+		w.ack(job)
 	}()
 
 	return nil
 }
 
+// ack tells queues that track deliveries that the job has been handled
+func (w *Worker) ack(handled *types.Job) {
+	acker, ok := w.queue.(queue.Acker)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := acker.Ack(ctx, handled); err != nil {
+		w.logger.Warn("Failed to ack job",
+			zap.String("job_id", handled.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+// trackRetry publishes the pending retry so stats can show jobs in backoff
+func (w *Worker) trackRetry(retry *types.Job, dueAt time.Time) {
+	if w.tracker == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.tracker.Track(ctx, retry, dueAt, w.config.ID); err != nil {
+		w.logger.Warn("Failed to track pending retry", zap.String("job_id", retry.ID), zap.Error(err))
+	}
+}
+
+// untrackRetry clears the pending retry once the job is back on the queue
+func (w *Worker) untrackRetry(jobID string) {
+	if w.tracker == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.tracker.Untrack(ctx, jobID); err != nil {
+		w.logger.Warn("Failed to untrack pending retry", zap.String("job_id", jobID), zap.Error(err))
+	}
+}
+
 // GetStats returns current worker statistics
 func (w *Worker) GetStats() WorkerStats {
 	return WorkerStats{
@@ -210,6 +642,7 @@ func (w *Worker) GetStats() WorkerStats {
 		JobsProcessed:  atomic.LoadInt64(&w.jobsProcessed),
 		JobsFailed:     atomic.LoadInt64(&w.jobsFailed),
 		JobsRetried:    atomic.LoadInt64(&w.jobsRetried),
+		JobsRequeued:   atomic.LoadInt64(&w.jobsRequeued),
 		IsActive:       w.IsActive(),
 	}
 }
@@ -219,10 +652,475 @@ func (w *Worker) IsActive() bool {
 	return atomic.LoadInt32(&w.isActive) == 1
 }
 
+// IsBusy returns true while the worker is executing a job
+func (w *Worker) IsBusy() bool {
+	return atomic.LoadInt32(&w.isBusy) == 1
+}
+
+// CurrentJobID returns the ID of the job being executed, or "" when idle
+func (w *Worker) CurrentJobID() string {
+	w.currentJobMu.Lock()
+	defer w.currentJobMu.Unlock()
+
+	return w.currentJobID
+}
+
+func (w *Worker) setCurrentJobID(id string) {
+	w.currentJobMu.Lock()
+	w.currentJobID = id
+	w.currentJobMu.Unlock()
+}
+
 // cancelCurrentJob cancels the currently running job
 func (w *Worker) cancelCurrentJob() {
 	if w.currentJobCancel != nil {
 		w.logger.Info("Cancelling current job")
 		w.currentJobCancel()
 	}
-}
\ No newline at end of file
+}
+
+// observeQueueWait records the job's priority and how long it waited in its queue before starting
+func (w *Worker) observeQueueWait(job *types.Job, startTime time.Time) {
+	if w.metrics == nil {
+		return
+	}
+	w.metrics.ObservePriority(job.GetPriority())
+
+	if job.EnqueuedAt.IsZero() {
+		return
+	}
+	w.metrics.ObserveQueueWait(job.GetPriority(), startTime.Sub(job.EnqueuedAt))
+}
+
+// checkpointJob returns an aborted job to the queue without using up an
+// attempt. It reports whether the job is back on the queue.
+func (w *Worker) checkpointJob(aborted *types.Job) bool {
+	aborted.Attempts--
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.queue.Enqueue(ctx, aborted); err != nil {
+		w.logger.Error("Failed to requeue aborted job",
+			zap.String("job_id", aborted.ID),
+			zap.Error(err),
+		)
+		return false
+	}
+
+	atomic.AddInt64(&w.jobsRequeued, 1)
+	w.logger.Warn("Requeued job aborted by shutdown", zap.String("job_id", aborted.ID))
+	return true
+}
+
+// settleWaiters lets the jobs and workflow nodes waiting on a job move on
+// once it reaches a final outcome. Every terminal path goes through it, so
+// nothing is left waiting on a job that will never run.
+func (w *Worker) settleWaiters(finished *types.Job, result *types.JobResult) {
+	w.resolveDependents(finished, result)
+	w.advanceWorkflow(finished, result)
+}
+
+// resolveDependents releases the jobs waiting on a finished job. Waiting
+// jobs that can no longer run because it failed are failed in turn, which
+// resolves their own dependents.
+func (w *Worker) resolveDependents(finished *types.Job, result *types.JobResult) {
+	if w.deps == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cancelled, err := w.deps.Resolve(ctx, finished, result)
+	if err != nil {
+		w.logger.Error("Failed to release dependent jobs",
+			zap.String("job_id", finished.ID),
+			zap.Error(err),
+		)
+	}
+
+	for _, dependent := range cancelled {
+		errorMsg := fmt.Sprintf("dependency_failed: job %s it depends on failed", finished.ID)
+
+		w.logger.Warn("Dependent job failed with its parent",
+			zap.String("job_id", dependent.ID),
+			zap.String("job_type", dependent.Type),
+			zap.String("parent_id", finished.ID),
+		)
+
+		failed := &types.JobResult{
+			JobID:            dependent.ID,
+			Status:           types.StatusFailed,
+			Error:            errorMsg,
+			FailureReason:    types.ReasonDependency,
+			ErrorFingerprint: types.ErrorFingerprint(errorMsg),
+			CompletedAt:      time.Now().UTC(),
+		}
+		w.saveResult(dependent, failed)
+		w.recordStatus(dependent, types.StatusFailed, errorMsg)
+		w.observeFailure(dependent, failed)
+		w.recordHistory(dependent, failed)
+		w.publishEvent(dependent, failed)
+		w.sendToDLQ(dependent, types.ReasonDependency, errorMsg)
+		w.settleWaiters(dependent, failed)
+	}
+}
+
+// advanceWorkflow records a finished workflow node and releases the nodes
+// waiting on it
+func (w *Worker) advanceWorkflow(finished *types.Job, result *types.JobResult) {
+	if w.workflows == nil || finished.WorkflowID == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.workflows.Advance(ctx, finished, result); err != nil {
+		w.logger.Error("Failed to advance workflow",
+			zap.String("job_id", finished.ID),
+			zap.String("workflow_id", finished.WorkflowID),
+			zap.String("node", finished.WorkflowNode),
+			zap.Error(err),
+		)
+	}
+}
+
+// rejectIncompatible dead-letters a job written in a newer envelope format,
+// where it waits to be retried once this worker is upgraded
+func (w *Worker) rejectIncompatible(incompatible *types.Job) bool {
+	envelopeErr := incompatible.CheckEnvelope()
+	if envelopeErr == nil {
+		return false
+	}
+
+	errorMsg := fmt.Sprintf("incompatible: %v", envelopeErr)
+
+	w.logger.Warn("Job written in a newer envelope format",
+		zap.String("job_id", incompatible.ID),
+		zap.String("job_type", incompatible.Type),
+		zap.Int("envelope_version", incompatible.EnvelopeVersion),
+		zap.Int("supported_version", types.CurrentEnvelopeVersion),
+	)
+
+	result := &types.JobResult{
+		JobID:            incompatible.ID,
+		Status:           types.StatusFailed,
+		Error:            errorMsg,
+		FailureReason:    types.ReasonIncompatible,
+		ErrorFingerprint: types.ErrorFingerprint(errorMsg),
+		CompletedAt:      time.Now().UTC(),
+	}
+	w.saveResult(incompatible, result)
+	w.recordStatus(incompatible, types.StatusFailed, errorMsg)
+	w.observeFailure(incompatible, result)
+	w.recordHistory(incompatible, result)
+	w.recordScheduleRun(incompatible, result)
+	w.publishEvent(incompatible, result)
+	w.settleWaiters(incompatible, result)
+	w.sendToDLQ(incompatible, types.ReasonIncompatible, errorMsg)
+	return true
+}
+
+// rejectUntrusted quarantines or dead-letters a job whose signature is
+// missing or doesn't verify. Its status, history and result are left alone,
+// since its ID and type are whatever was written into the queue and may
+// belong to a real job, but whatever waits on it is settled as failed.
+func (w *Worker) rejectUntrusted(untrusted *types.Job) bool {
+	verifyErr := w.signing.Verify(untrusted)
+	if verifyErr == nil {
+		return false
+	}
+
+	w.logger.Error("Rejected job with an invalid signature",
+		zap.String("job_id", untrusted.ID),
+		zap.String("job_type", untrusted.Type),
+		zap.Error(verifyErr),
+	)
+	if w.quarantineJob(untrusted, types.ReasonUntrusted, verifyErr.Error()) {
+		return true
+	}
+
+	errorMsg := verifyErr.Error()
+	w.settleWaiters(untrusted, &types.JobResult{
+		JobID:            untrusted.ID,
+		Status:           types.StatusFailed,
+		Error:            errorMsg,
+		FailureReason:    types.ReasonUntrusted,
+		ErrorFingerprint: types.ErrorFingerprint(errorMsg),
+		CompletedAt:      time.Now().UTC(),
+	})
+	w.sendToDLQ(untrusted, types.ReasonUntrusted, errorMsg)
+	return true
+}
+
+// quarantineInvalidPayload holds a job whose payload doesn't match the
+// schema its handler advertises, e.g. one written straight into the queue.
+// Payloads are only checked with a quarantine to hold them, and jobs
+// approved out of it run as they are.
+func (w *Worker) quarantineInvalidPayload(suspect *types.Job) bool {
+	if w.quarantine == nil {
+		return false
+	}
+	if approved, _ := suspect.GetMetadataBool(queue.QuarantineApprovedKey); approved {
+		return false
+	}
+
+	violations := w.registry.ValidatePayload(suspect.Type, suspect.Payload)
+	if len(violations) == 0 {
+		return false
+	}
+
+	messages := make([]string, len(violations))
+	for i, violation := range violations {
+		messages[i] = violation.Error()
+	}
+	errorMsg := "payload does not match schema: " + strings.Join(messages, "; ")
+
+	w.logger.Warn("Job payload does not match its schema",
+		zap.String("job_id", suspect.ID),
+		zap.String("job_type", suspect.Type),
+		zap.Int("violations", len(violations)),
+	)
+	return w.quarantineJob(suspect, types.ReasonSchema, errorMsg)
+}
+
+// quarantineJob holds a job for manual review. It returns false when the
+// worker has no quarantine or the job couldn't be added, leaving the caller
+// to deal with the job as it would without one.
+func (w *Worker) quarantineJob(suspect *types.Job, reason types.FailureReason, errorMsg string) bool {
+	if w.quarantine == nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.quarantine.Add(ctx, suspect, reason, errorMsg); err != nil {
+		w.logger.Error("Failed to quarantine job",
+			zap.String("job_id", suspect.ID),
+			zap.Error(err),
+		)
+		return false
+	}
+
+	w.logger.Warn("Quarantined job for review",
+		zap.String("job_id", suspect.ID),
+		zap.String("job_type", suspect.Type),
+		zap.String("reason", string(reason)),
+	)
+	return true
+}
+
+// rejectUnsupportedVersion parks or dead-letters a job whose payload version
+// its handler doesn't accept, typically a new-format job reaching an old
+// worker mid-deploy. Parked jobs keep their attempts; a job already in the
+// parking queue is dead-lettered so it can't circle back to itself.
+func (w *Worker) rejectUnsupportedVersion(unsupported *types.Job) bool {
+	versionErr := w.registry.CheckPayloadVersion(unsupported)
+	if versionErr == nil {
+		return false
+	}
+
+	if w.parking != "" && unsupported.Queue != w.parking {
+		err := w.parkJob(unsupported)
+		if err == nil {
+			w.logger.Warn("Parked job with an unsupported payload version",
+				zap.String("job_id", unsupported.ID),
+				zap.String("job_type", unsupported.Type),
+				zap.Int("payload_version", unsupported.PayloadVersion),
+				zap.String("parking_queue", w.parking),
+			)
+			return true
+		}
+		w.logger.Error("Failed to park job, dead-lettering it",
+			zap.String("job_id", unsupported.ID),
+			zap.Error(err),
+		)
+	}
+
+	errorMsg := versionErr.Error()
+	w.logger.Warn("Job payload version not supported",
+		zap.String("job_id", unsupported.ID),
+		zap.String("job_type", unsupported.Type),
+		zap.Int("payload_version", unsupported.PayloadVersion),
+	)
+
+	result := &types.JobResult{
+		JobID:            unsupported.ID,
+		Status:           types.StatusFailed,
+		Error:            errorMsg,
+		FailureReason:    types.ReasonVersion,
+		ErrorFingerprint: types.ErrorFingerprint(errorMsg),
+		CompletedAt:      time.Now().UTC(),
+	}
+	w.saveResult(unsupported, result)
+	w.recordStatus(unsupported, types.StatusFailed, errorMsg)
+	w.observeFailure(unsupported, result)
+	w.recordHistory(unsupported, result)
+	w.recordScheduleRun(unsupported, result)
+	w.publishEvent(unsupported, result)
+	w.settleWaiters(unsupported, result)
+	w.sendToDLQ(unsupported, types.ReasonVersion, errorMsg)
+	return true
+}
+
+// parkJob re-enqueues a copy of the job on the parking queue
+func (w *Worker) parkJob(unsupported *types.Job) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	parked := *unsupported
+	parked.Queue = w.parking
+	return w.queue.Enqueue(ctx, &parked)
+}
+
+// discardPastDeadline drops a job whose expires_at passed before a worker
+// started it. Unlike max-age expiry the job is not dead-lettered, since
+// replaying it would be just as late.
+func (w *Worker) discardPastDeadline(stale *types.Job) bool {
+	now := time.Now().UTC()
+	if !stale.PastDeadline(now) {
+		return false
+	}
+
+	late := now.Sub(*stale.ExpiresAt)
+	errorMsg := fmt.Sprintf("expired: deadline %s passed %s before execution",
+		stale.ExpiresAt.UTC().Format(time.RFC3339), late.Round(time.Second))
+
+	w.logger.Warn("Job discarded past its deadline",
+		zap.String("job_id", stale.ID),
+		zap.String("job_type", stale.Type),
+		zap.Time("expires_at", *stale.ExpiresAt),
+		zap.Duration("late_by", late),
+	)
+
+	if w.metrics != nil {
+		w.metrics.JobsPastDeadline.WithLabelValues(stale.Type).Inc()
+	}
+
+	result := &types.JobResult{
+		JobID:            stale.ID,
+		Status:           types.StatusFailed,
+		Error:            errorMsg,
+		FailureReason:    types.ReasonExpired,
+		ErrorFingerprint: types.ErrorFingerprint(errorMsg),
+		CompletedAt:      now,
+	}
+	w.saveResult(stale, result)
+	w.recordStatus(stale, types.StatusFailed, errorMsg)
+	w.observeFailure(stale, result)
+	w.recordHistory(stale, result)
+	w.recordScheduleRun(stale, result)
+	w.publishEvent(stale, result)
+	w.settleWaiters(stale, result)
+	return true
+}
+
+// rejectExpired dead-letters a job that is older than its type's max age.
+// Age counts from creation, so time spent in retries counts too.
+func (w *Worker) rejectExpired(expired *types.Job) bool {
+	maxAge, ok := w.maxAges[expired.Type]
+	if !ok || maxAge <= 0 {
+		return false
+	}
+
+	age := time.Since(expired.CreatedAt)
+	if age <= maxAge {
+		return false
+	}
+
+	errorMsg := fmt.Sprintf("expired: job is %s old, max age for %s is %s",
+		age.Round(time.Second), expired.Type, maxAge)
+
+	w.logger.Warn("Job expired before execution",
+		zap.String("job_id", expired.ID),
+		zap.String("job_type", expired.Type),
+		zap.Duration("age", age),
+		zap.Duration("max_age", maxAge),
+	)
+
+	if w.metrics != nil {
+		w.metrics.JobsExpired.WithLabelValues(expired.Type).Inc()
+	}
+
+	result := &types.JobResult{
+		JobID:            expired.ID,
+		Status:           types.StatusFailed,
+		Error:            errorMsg,
+		FailureReason:    types.ReasonExpired,
+		ErrorFingerprint: types.ErrorFingerprint(errorMsg),
+		CompletedAt:      time.Now().UTC(),
+	}
+	w.saveResult(expired, result)
+	w.recordStatus(expired, types.StatusFailed, errorMsg)
+	w.observeFailure(expired, result)
+	w.recordHistory(expired, result)
+	w.recordScheduleRun(expired, result)
+	w.publishEvent(expired, result)
+	w.settleWaiters(expired, result)
+	w.sendToDLQ(expired, types.ReasonExpired, errorMsg)
+	return true
+}
+
+// jobTimeout picks how long the job's handler may run: the job's own
+// timeout, then its type's policy, WORKER_JOB_TIMEOUTS and the worker default
+func (w *Worker) jobTimeout(job *types.Job) time.Duration {
+	if job.Timeout > 0 {
+		return job.Timeout
+	}
+	if timeout := w.policyFor(job.Type).TimeoutDuration(); timeout > 0 {
+		return timeout
+	}
+	if timeout, ok := w.timeouts[job.Type]; ok && timeout > 0 {
+		return timeout
+	}
+	return w.config.JobTimeout
+}
+
+// policyFor returns the job type's policy, the zero policy outside a pool
+func (w *Worker) policyFor(jobType string) types.JobPolicy {
+	if w.policy == nil {
+		return types.JobPolicy{}
+	}
+	return w.policy(jobType)
+}
+
+// allowed checks the job type's rate limit; limiter errors let the job run
+func (w *Worker) allowed(ctx context.Context, job *types.Job) bool {
+	if w.limiter == nil {
+		return true
+	}
+
+	allowed, err := w.limiter.Allow(ctx, job.Type)
+	if err != nil {
+		w.logger.Warn("Rate limiter check failed", zap.String("job_type", job.Type), zap.Error(err))
+		return true
+	}
+	return allowed
+}
+
+// deferThrottledJob returns a rate limited job to the queue and backs off.
+// The delivery is acked only once the copy is back on the queue; until then
+// it stays where recovery can find it.
+func (w *Worker) deferThrottledJob(ctx context.Context, throttled *types.Job) error {
+	w.logger.Debug("Job type throttled, requeueing",
+		zap.String("job_id", throttled.ID),
+		zap.String("job_type", throttled.Type),
+	)
+
+	requeueCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.queue.Enqueue(requeueCtx, throttled); err != nil {
+		return fmt.Errorf("failed to requeue rate limited job %s: %w", throttled.ID, err)
+	}
+	w.ack(throttled)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(w.config.PollInterval):
+	}
+	return nil
+}
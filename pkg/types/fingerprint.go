@@ -0,0 +1,42 @@
+package types
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxFingerprintLength bounds fingerprints so they stay usable as group keys
+const maxFingerprintLength = 200
+
+// fingerprintRules replace the variable parts of error messages, most
+// specific first, so errors that differ only by IDs or values group together
+var fingerprintRules = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"']+`), "<url>"},
+	{regexp.MustCompile(`[^\s@"'<>]+@[^\s@"'<>]+\.[a-zA-Z]{2,}`), "<email>"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}(:\d+)?\b`), "<ip>"},
+	{regexp.MustCompile(`(?i)\b(0x)?[0-9a-f]*[0-9][0-9a-f]*[a-f][0-9a-f]*\b|\b(0x)?[0-9a-f]*[a-f][0-9a-f]*[0-9][0-9a-f]*\b`), "<hex>"},
+	{regexp.MustCompile(`"[^"]*"|'[^']*'`), "<str>"},
+	{regexp.MustCompile(`\b[a-zA-Z]+[-_]\d[\w-]*\b`), "<id>"}, // job-1700000000, order_42
+	{regexp.MustCompile(`\d+(\.\d+)?`), "<n>"},
+	{regexp.MustCompile(`\s+`), " "},
+}
+
+// ErrorFingerprint normalizes an error message into a stable grouping key by
+// replacing URLs, emails, UUIDs, IPs, hex strings, quoted values, IDs and
+// numbers with placeholders
+func ErrorFingerprint(message string) string {
+	fingerprint := strings.TrimSpace(message)
+	for _, rule := range fingerprintRules {
+		fingerprint = rule.pattern.ReplaceAllString(fingerprint, rule.replacement)
+	}
+	fingerprint = strings.TrimSpace(fingerprint)
+
+	if len(fingerprint) > maxFingerprintLength {
+		fingerprint = strings.ToValidUTF8(fingerprint[:maxFingerprintLength], "")
+	}
+	return fingerprint
+}
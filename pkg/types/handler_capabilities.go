@@ -0,0 +1,75 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DescribedHandler is implemented by handlers that advertise how to submit
+// their jobs, so API consumers can discover it from GET /jobs/types
+type DescribedHandler interface {
+	JobHandler
+
+	// Capabilities describes the handler's payload, version and defaults
+	Capabilities() HandlerCapabilities
+}
+
+// HandlerCapabilities tells API consumers how to submit one job type
+type HandlerCapabilities struct {
+	Version        string          `json:"version,omitempty"`
+	PayloadSchema  json.RawMessage `json:"payload_schema,omitempty"`  // JSON Schema of the payload, enforced at enqueue time
+	ExamplePayload json.RawMessage `json:"example_payload,omitempty"` // A payload the handler accepts
+	DefaultPolicy  *JobPolicy      `json:"default_policy,omitempty"`  // Used unless a policy for the type is configured
+
+	// PayloadVersions lists the payload versions the handler accepts, 0 for
+	// unversioned payloads; empty accepts any. Workers dead-letter or park
+	// jobs of other versions instead of running them.
+	PayloadVersions []int `json:"payload_versions,omitempty"`
+}
+
+// AcceptsPayloadVersion reports whether the handler takes payloads of version
+func (c HandlerCapabilities) AcceptsPayloadVersion(version int) bool {
+	if len(c.PayloadVersions) == 0 {
+		return true
+	}
+	for _, accepted := range c.PayloadVersions {
+		if accepted == version {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the schema compiles, the example is JSON and fits
+// the schema, the default policy is valid and payload versions aren't negative
+func (c HandlerCapabilities) Validate() error {
+	for _, version := range c.PayloadVersions {
+		if version < 0 {
+			return fmt.Errorf("payload version %d is negative", version)
+		}
+	}
+
+	var schema *PayloadSchema
+	if len(c.PayloadSchema) > 0 {
+		var err error
+		if schema, err = CompileSchema(c.PayloadSchema); err != nil {
+			return err
+		}
+	}
+	if len(c.ExamplePayload) > 0 {
+		if !json.Valid(c.ExamplePayload) {
+			return fmt.Errorf("example payload is not valid JSON")
+		}
+		if schema != nil {
+			if violations := schema.Validate(c.ExamplePayload); len(violations) > 0 {
+				return fmt.Errorf("example payload does not match the payload schema: %s", violations[0].Error())
+			}
+		}
+	}
+	if c.DefaultPolicy != nil {
+		if err := c.DefaultPolicy.Validate(); err != nil {
+			return fmt.Errorf("invalid default policy: %w", err)
+		}
+	}
+	return nil
+}
@@ -3,12 +3,19 @@ package types
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// CurrentEnvelopeVersion is the job serialization format this build writes.
+// Fields added with safe zero values don't bump it, since older readers
+// ignore fields they don't know; it only changes when an older reader would
+// misinterpret a job.
+const CurrentEnvelopeVersion = 1
+
 // JobMetadata holds additional information about a job
 type JobMetadata map[string]interface{}
 
@@ -21,7 +28,30 @@ type Job struct {
 	MaxRetries int             `json:"max_retries"`
 	CreatedAt  time.Time       `json:"created_at"`
 	UpdatedAt  time.Time       `json:"updated_at"`
+	EnqueuedAt time.Time       `json:"enqueued_at,omitempty"` // Last time the job entered a queue
 	Metadata   JobMetadata     `json:"metadata,omitempty"`
+	KeyID      string          `json:"key_id,omitempty"`      // Encryption key version, empty for plaintext payloads
+	ScheduleID string          `json:"schedule_id,omitempty"` // Recurring schedule that spawned this job
+	Queue      string          `json:"queue,omitempty"`       // Named queue the job targets, empty for the producer's queue
+	Encoding   string          `json:"encoding,omitempty"`    // Stored payload compression, e.g. gzip; empty for plain JSON
+
+	PayloadVersion int            `json:"payload_version,omitempty"` // Format of the payload, checked against the versions the handler accepts; 0 when unversioned
+	Backoff        *BackoffPolicy `json:"backoff,omitempty"`         // Retry delays for this job, overriding the job type's policy
+
+	EnvelopeVersion int           `json:"envelope_version,omitempty"` // Serialization format, 0 for jobs written before versioning
+	Timeout         time.Duration `json:"timeout_ns,omitempty"`       // How long the handler may run, zero for the worker's default for the type
+	ExpiresAt       *time.Time    `json:"expires_at,omitempty"`       // Deadline for starting the job; later it is discarded instead of run
+
+	DependsOn       []string `json:"depends_on,omitempty"`        // Jobs that must complete before this one is enqueued
+	OnParentFailure string   `json:"on_parent_failure,omitempty"` // fail (default) or ignore, when a job in DependsOn fails
+
+	ChainID        string          `json:"chain_id,omitempty"`        // Chain the job belongs to, see Chain
+	PreviousOutput json.RawMessage `json:"previous_output,omitempty"` // Result of the previous job in the chain, set when it releases this one
+
+	WorkflowID   string `json:"workflow_id,omitempty"`   // Workflow the job is a node of
+	WorkflowNode string `json:"workflow_node,omitempty"` // Name of that node
+
+	Signature string `json:"signature,omitempty"` // keyID:signature over the job's fixed fields, set at enqueue when signing is on
 }
 
 // Job Submission Request
@@ -29,6 +59,18 @@ type JobRequest struct {
 	Type       string          `json:"type" binding:"required"`
 	Payload    json.RawMessage `json:"payload" binding:"required"`
 	MaxRetries *int            `json:"max_retries,omitempty"`
+	Priority   string          `json:"priority,omitempty"` // high, normal or low; needs QUEUE_PRIORITY
+	Queue      string          `json:"queue,omitempty"`    // Named queue, e.g. emails; empty for the server's queue
+	Metadata   JobMetadata     `json:"metadata,omitempty"`
+	Timeout    string          `json:"timeout,omitempty"`    // Handler time limit, e.g. 2h; empty for the worker's default
+	ExpiresAt  *time.Time      `json:"expires_at,omitempty"` // Discard the job if no worker has started it by then
+
+	DependsOn       []string `json:"depends_on,omitempty"`        // Job IDs that must complete first
+	OnParentFailure string   `json:"on_parent_failure,omitempty"` // fail (default) or ignore
+
+	IdempotencyKey string         `json:"idempotency_key,omitempty"` // Repeats return the job first submitted with the key; also the Idempotency-Key header
+	PayloadVersion int            `json:"payload_version,omitempty"` // Format of the payload, for handlers that declare the versions they accept
+	Backoff        *BackoffPolicy `json:"backoff,omitempty"`         // Retry delays, overriding the job type's policy
 }
 
 // Job Response Struct
@@ -36,17 +78,20 @@ type JobResponse struct {
 	JobID     string    `json:"job_id"`
 	Status    string    `json:"status"`
 	CreatedAt time.Time `json:"created_at"`
+	Spooled   bool      `json:"spooled,omitempty"` // Held by the server until the queue is reachable again
 }
 
 // Enum to represent the stage of the job
 type JobStatus string
 
 const (
-	StatusPending    JobStatus = "pending"
-	StatusProcessing JobStatus = "processing"
-	StatusCompleted  JobStatus = "completed"
-	StatusFailed     JobStatus = "failed"
-	StatusRetrying   JobStatus = "retrying"
+	StatusPending     JobStatus = "pending"
+	StatusProcessing  JobStatus = "processing"
+	StatusCompleted   JobStatus = "completed"
+	StatusFailed      JobStatus = "failed"
+	StatusRetrying    JobStatus = "retrying"
+	StatusWaiting     JobStatus = "waiting"     // Held until the jobs it depends on finish
+	StatusQuarantined JobStatus = "quarantined" // Held for manual review, see the quarantine API
 )
 
 type JobHandler interface {
@@ -61,12 +106,47 @@ type JobHandler interface {
 	Description() string
 }
 
+// IdempotentHandler is implemented by handlers whose outcome depends only on
+// the job's input. When workers have a result cache, a job whose cache key
+// completed within the TTL reuses that result instead of running again.
+type IdempotentHandler interface {
+	JobHandler
+
+	// CacheKey identifies the job's input, e.g. PayloadCacheKey(job); an
+	// empty key runs the job without caching
+	CacheKey(job *Job) string
+
+	// CacheTTL is how long a completed result is reused
+	CacheTTL() time.Duration
+}
+
 type JobResult struct {
 	JobID       string    `json:"job_id"`
 	Status      JobStatus `json:"status"`
 	Error       string    `json:"error,omitempty"`
-	Duration    string    `json:"duration"`
+	Duration    string    `json:"duration"` // Handler execution time
 	CompletedAt time.Time `json:"completed_at"`
+	Variant     string    `json:"variant,omitempty"` // Handler variant that ran the job: stable or canary
+	Timing      JobTiming `json:"timing"`
+
+	FailureReason    FailureReason `json:"failure_reason,omitempty"`    // Set when Status is failed
+	ErrorFingerprint string        `json:"error_fingerprint,omitempty"` // Error with IDs and numbers stripped, for grouping
+	CachedFrom       string        `json:"cached_from,omitempty"`       // Job whose cached result was reused instead of running the handler
+
+	Output json.RawMessage `json:"output,omitempty"` // Value the handler recorded with SetResult, only kept on success
+}
+
+// ErrPoisonJob marks a job that can never succeed; handlers wrap it, e.g.
+// fmt.Errorf("%w: invalid payload", types.ErrPoisonJob)
+var ErrPoisonJob = errors.New("poison job")
+
+// JobTiming breaks down where the time spent processing a job went
+type JobTiming struct {
+	StartedAt        time.Time     `json:"started_at"`                   // Worker picked the job up
+	HandlerStartedAt time.Time     `json:"handler_started_at,omitempty"` // Zero when no handler ran
+	FinishedAt       time.Time     `json:"finished_at"`
+	HandlerDuration  time.Duration `json:"handler_duration_ns"`
+	TotalDuration    time.Duration `json:"total_duration_ns"` // Includes routing and setup overhead
 }
 
 func NewJob(jobType string, payload json.RawMessage, maxRetries int) *Job {
@@ -90,6 +170,19 @@ func (j *Job) IncrementAttempts() {
 	j.UpdatedAt = time.Now().UTC()
 }
 
+// PastDeadline reports whether the job's expires_at has passed
+func (j *Job) PastDeadline(now time.Time) bool {
+	return j.ExpiresAt != nil && now.After(*j.ExpiresAt)
+}
+
+// CheckEnvelope rejects jobs written in a newer format than this build understands
+func (j *Job) CheckEnvelope() error {
+	if j.EnvelopeVersion > CurrentEnvelopeVersion {
+		return fmt.Errorf("job envelope version %d is newer than supported version %d", j.EnvelopeVersion, CurrentEnvelopeVersion)
+	}
+	return nil
+}
+
 func (j *Job) Validate() error {
 	if j.ID == "" {
 		return fmt.Errorf("job ID cannot be empty")
@@ -103,6 +196,54 @@ func (j *Job) Validate() error {
 	if j.MaxRetries < 0 {
 		return fmt.Errorf("max retries cannot be empty")
 	}
+	if err := j.Metadata.Validate(); err != nil {
+		return fmt.Errorf("invalid job metadata: %w", err)
+	}
+	if j.Queue != "" {
+		if err := ValidateQueueName(j.Queue); err != nil {
+			return err
+		}
+	}
+	if j.Timeout < 0 {
+		return fmt.Errorf("job timeout cannot be negative")
+	}
+	if err := j.validateDependencies(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ParseJobTimeout parses a requested handler time limit such as 90s or 2h
+func ParseJobTimeout(value string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: %w", value, err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("timeout must be positive, got %s", value)
+	}
+	return timeout, nil
+}
+
+// MaxQueueNameLength bounds named queues, which end up in storage keys
+const MaxQueueNameLength = 64
+
+// ValidateQueueName checks that a named queue is made of letters, digits,
+// '-', '_' and '.'
+func ValidateQueueName(name string) error {
+	if name == "" {
+		return fmt.Errorf("queue name cannot be empty")
+	}
+	if len(name) > MaxQueueNameLength {
+		return fmt.Errorf("queue name %q is longer than %d characters", name, MaxQueueNameLength)
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return fmt.Errorf("queue name %q can only contain letters, digits, '-', '_' and '.'", name)
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,65 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxChainLength caps how many jobs a single chain may hold
+const MaxChainLength = 100
+
+// ChainRequest submits jobs that run one after another, each only once the
+// previous one completed
+type ChainRequest struct {
+	Jobs []JobRequest `json:"jobs" binding:"required"`
+}
+
+// ChainResponse lists the jobs of a submitted chain in order
+type ChainResponse struct {
+	ChainID   string        `json:"chain_id"`
+	Jobs      []JobResponse `json:"jobs"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// Chain links jobs in order: each depends on the one before it and fails
+// with it, and receives its result in PreviousOutput. It returns the ID
+// shared by the chain's jobs.
+func Chain(jobs ...*Job) (string, error) {
+	if len(jobs) == 0 {
+		return "", fmt.Errorf("chain needs at least one job")
+	}
+	if len(jobs) > MaxChainLength {
+		return "", fmt.Errorf("chain can hold at most %d jobs, got %d", MaxChainLength, len(jobs))
+	}
+	for i, job := range jobs {
+		if len(job.DependsOn) > 0 {
+			return "", fmt.Errorf("job %d of the chain cannot set depends_on", i+1)
+		}
+	}
+
+	chainID := "chain_" + uuid.NewString()
+	for i, job := range jobs {
+		job.ChainID = chainID
+		if i > 0 {
+			job.DependsOn = []string{jobs[i-1].ID}
+			job.OnParentFailure = OnParentFailureFail
+		}
+	}
+	return chainID, nil
+}
+
+// PreviousResult decodes the result the previous job of the chain recorded
+// with SetResult into v. It reports false when there is none, e.g. for the
+// first job or when the previous handler didn't set a result.
+func (j *Job) PreviousResult(v interface{}) (bool, error) {
+	if len(j.PreviousOutput) == 0 {
+		return false, nil
+	}
+	if err := json.Unmarshal(j.PreviousOutput, v); err != nil {
+		return false, fmt.Errorf("failed to unmarshal previous job result: %w", err)
+	}
+	return true, nil
+}
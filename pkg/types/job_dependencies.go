@@ -0,0 +1,42 @@
+package types
+
+import "fmt"
+
+// What happens to a job when one of the jobs it depends on fails
+const (
+	OnParentFailureFail   = "fail"   // The job fails with reason dependency_failed, as do its own dependents
+	OnParentFailureIgnore = "ignore" // The failed parent counts as finished
+)
+
+// MaxDependencies caps how many jobs a single job may depend on
+const MaxDependencies = 100
+
+// IgnoresParentFailure reports whether the job still runs after a parent fails
+func (j *Job) IgnoresParentFailure() bool {
+	return j.OnParentFailure == OnParentFailureIgnore
+}
+
+func (j *Job) validateDependencies() error {
+	if len(j.DependsOn) > MaxDependencies {
+		return fmt.Errorf("job can depend on at most %d jobs, got %d", MaxDependencies, len(j.DependsOn))
+	}
+	seen := make(map[string]bool, len(j.DependsOn))
+	for _, parentID := range j.DependsOn {
+		if parentID == "" {
+			return fmt.Errorf("depends_on cannot contain an empty job ID")
+		}
+		if parentID == j.ID {
+			return fmt.Errorf("job cannot depend on itself")
+		}
+		if seen[parentID] {
+			return fmt.Errorf("depends_on lists job %s twice", parentID)
+		}
+		seen[parentID] = true
+	}
+	switch j.OnParentFailure {
+	case "", OnParentFailureFail, OnParentFailureIgnore:
+	default:
+		return fmt.Errorf("on_parent_failure must be %s or %s, got %q", OnParentFailureFail, OnParentFailureIgnore, j.OnParentFailure)
+	}
+	return nil
+}
@@ -1,5 +1,13 @@
 package types
 
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
 // AddMetadata adds a key-value pair to job metadata
 func (j *Job) AddMetadata(key string, value interface{}) {
 	if j.Metadata == nil {
@@ -17,6 +25,32 @@ func (j *Job) GetMetadata(key string) (interface{}, bool) {
 	return val, ok
 }
 
+// RemoveMetadata deletes a key from job metadata
+func (j *Job) RemoveMetadata(key string) {
+	delete(j.Metadata, key)
+}
+
+// GetMetadataString retrieves a string value from job metadata
+func (j *Job) GetMetadataString(key string) (string, bool) {
+	return j.Metadata.GetString(key)
+}
+
+// GetMetadataInt retrieves an integer value from job metadata
+func (j *Job) GetMetadataInt(key string) (int, bool) {
+	return j.Metadata.GetInt(key)
+}
+
+// GetMetadataBool retrieves a bool value from job metadata
+func (j *Job) GetMetadataBool(key string) (bool, bool) {
+	return j.Metadata.GetBool(key)
+}
+
+// GetMetadataTime retrieves a time value from job metadata, which may have
+// been set as a time.Time or arrived as an RFC 3339 string
+func (j *Job) GetMetadataTime(key string) (time.Time, bool) {
+	return j.Metadata.GetTime(key)
+}
+
 // SetPriority sets the job priority
 func (j *Job) SetPriority(priority string) {
 	j.AddMetadata("priority", priority)
@@ -24,15 +58,28 @@ func (j *Job) SetPriority(priority string) {
 
 // GetPriority gets the job priority, defaulting to "normal" if not set
 func (j *Job) GetPriority() string {
-	val, ok := j.GetMetadata("priority")
+	priority, ok := j.GetMetadataString("priority")
 	if !ok {
 		return "normal"
 	}
 
-	priority, ok := val.(string)
-	if !ok {
-		return "normal"
+	return priority
+}
+
+// PayloadCacheKey returns a hash of the job's payload for IdempotentHandler.
+// Payloads that differ only in key order or whitespace hash the same.
+func PayloadCacheKey(job *Job) string {
+	payload := []byte(job.Payload)
+
+	decoder := json.NewDecoder(bytes.NewReader(job.Payload))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err == nil {
+		if canonical, err := json.Marshal(value); err == nil {
+			payload = canonical
+		}
 	}
 
-	return priority
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
 }
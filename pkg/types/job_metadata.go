@@ -0,0 +1,105 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Metadata limits keep queue entries small; metadata travels with every
+// enqueue, retry and history record
+const (
+	MaxMetadataKeys      = 64
+	MaxMetadataKeyLength = 128
+	MaxMetadataSize      = 16 * 1024 // Encoded JSON bytes
+)
+
+// Validate checks the metadata against the key count, key length and size limits
+func (m JobMetadata) Validate() error {
+	if len(m) > MaxMetadataKeys {
+		return fmt.Errorf("metadata has %d keys, limit is %d", len(m), MaxMetadataKeys)
+	}
+
+	for key := range m {
+		if key == "" {
+			return fmt.Errorf("metadata keys cannot be empty")
+		}
+		if len(key) > MaxMetadataKeyLength {
+			return fmt.Errorf("metadata key exceeds %d characters", MaxMetadataKeyLength)
+		}
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("metadata is not JSON encodable: %w", err)
+	}
+	if len(data) > MaxMetadataSize {
+		return fmt.Errorf("metadata is %d bytes encoded, limit is %d", len(data), MaxMetadataSize)
+	}
+
+	return nil
+}
+
+// Clone returns a shallow copy so callers can modify keys without touching the original
+func (m JobMetadata) Clone() JobMetadata {
+	if m == nil {
+		return nil
+	}
+	clone := make(JobMetadata, len(m))
+	for key, value := range m {
+		clone[key] = value
+	}
+	return clone
+}
+
+// GetString returns the value for key when it is a string
+func (m JobMetadata) GetString(key string) (string, bool) {
+	value, ok := m[key].(string)
+	return value, ok
+}
+
+// GetInt returns the value for key as an int. JSON decoding turns numbers into
+// float64, so whole floats are accepted as well.
+func (m JobMetadata) GetInt(key string) (int, bool) {
+	switch value := m[key].(type) {
+	case int:
+		return value, true
+	case int32:
+		return int(value), true
+	case int64:
+		return int(value), true
+	case float64:
+		if value == float64(int(value)) {
+			return int(value), true
+		}
+	case json.Number:
+		if parsed, err := value.Int64(); err == nil {
+			return int(parsed), true
+		}
+	}
+	return 0, false
+}
+
+// GetTime returns the value for key as a time. Times are stored as RFC 3339
+// strings once the job has been serialized, so those are parsed as well.
+func (m JobMetadata) GetTime(key string) (time.Time, bool) {
+	switch value := m[key].(type) {
+	case time.Time:
+		return value, true
+	case *time.Time:
+		if value != nil {
+			return *value, true
+		}
+	case string:
+		if parsed, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// GetBool returns the value for key when it is a bool
+func (m JobMetadata) GetBool(key string) (bool, bool) {
+	value, ok := m[key].(bool)
+	return value, ok
+}
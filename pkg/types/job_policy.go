@@ -0,0 +1,284 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// Retry backoff used when a job type's policy doesn't set one
+const (
+	DefaultBackoffInitial    = time.Second
+	DefaultBackoffMax        = 5 * time.Minute
+	DefaultBackoffMultiplier = 2.0
+)
+
+// JobPolicy holds the execution settings of one job type. Zero fields fall
+// back to the process-wide settings.
+type JobPolicy struct {
+	Timeout     string           `json:"timeout,omitempty"`     // Handler time limit, e.g. 2h; a job's own timeout still wins
+	MaxRetries  *int             `json:"max_retries,omitempty"` // Used when a request doesn't set max_retries
+	Backoff     *BackoffPolicy   `json:"backoff,omitempty"`
+	RateLimit   *RateLimitPolicy `json:"rate_limit,omitempty"`  // Default limit, overridable at runtime via the rate limit API
+	Concurrency int              `json:"concurrency,omitempty"` // Jobs of the type each worker pool runs at once, 0 for no cap
+	Queue       string           `json:"queue,omitempty"`       // Named queue used when a request names none
+}
+
+// Backoff strategies for BackoffPolicy.Strategy
+const (
+	BackoffExponential = "exponential" // initial, times multiplier per attempt (the default)
+	BackoffLinear      = "linear"      // initial, plus step per attempt
+	BackoffConstant    = "constant"    // initial before every retry
+	BackoffSchedule    = "schedule"    // schedule[attempt-1], repeating the last entry
+)
+
+// BackoffPolicy shapes the delay before each retry. Delays are capped at
+// max, which for the schedule strategy applies only when set.
+type BackoffPolicy struct {
+	Strategy   string   `json:"strategy,omitempty"` // exponential (default), linear, constant or schedule
+	Initial    string   `json:"initial,omitempty"`  // e.g. 1s
+	Max        string   `json:"max,omitempty"`      // e.g. 5m
+	Multiplier float64  `json:"multiplier,omitempty"`
+	Step       string   `json:"step,omitempty"`     // Linear increment, defaults to initial
+	Schedule   []string `json:"schedule,omitempty"` // Delays for the schedule strategy, e.g. ["10s","1m","10m"]
+}
+
+// RetryPolicy computes the delay before each retry of a job
+type RetryPolicy interface {
+	// Delay returns how long to wait before retrying after the given
+	// attempt, counting from 1
+	Delay(attempt int) time.Duration
+}
+
+// ExponentialBackoff multiplies the delay by Multiplier per attempt
+type ExponentialBackoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+}
+
+func (b ExponentialBackoff) Delay(attempt int) time.Duration {
+	delay := float64(b.Initial)
+	for i := 1; i < attempt && delay < float64(b.Max); i++ {
+		delay *= b.Multiplier
+	}
+	if delay > float64(b.Max) {
+		return b.Max
+	}
+	return time.Duration(delay)
+}
+
+// LinearBackoff adds Step to the delay per attempt
+type LinearBackoff struct {
+	Initial time.Duration
+	Step    time.Duration
+	Max     time.Duration
+}
+
+func (b LinearBackoff) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	steps := time.Duration(attempt - 1)
+	if b.Step > 0 && steps > (b.Max-b.Initial)/b.Step {
+		return b.Max
+	}
+	if delay := b.Initial + steps*b.Step; delay < b.Max {
+		return delay
+	}
+	return b.Max
+}
+
+// ConstantBackoff waits the same delay before every retry
+type ConstantBackoff struct {
+	Wait time.Duration
+}
+
+func (b ConstantBackoff) Delay(int) time.Duration {
+	return b.Wait
+}
+
+// ScheduleBackoff takes each delay from a list, repeating the last one
+type ScheduleBackoff struct {
+	Delays []time.Duration
+	Max    time.Duration // Zero for no cap
+}
+
+func (b ScheduleBackoff) Delay(attempt int) time.Duration {
+	if len(b.Delays) == 0 {
+		return 0
+	}
+	index := attempt - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(b.Delays) {
+		index = len(b.Delays) - 1
+	}
+	if delay := b.Delays[index]; b.Max == 0 || delay < b.Max {
+		return delay
+	}
+	return b.Max
+}
+
+// RetryPolicy builds the policy's retry delays; a nil policy is the default
+// exponential backoff. Invalid durations fall back to the defaults, so
+// policies are expected to be validated beforehand.
+func (b *BackoffPolicy) RetryPolicy() RetryPolicy {
+	initial, max, multiplier := DefaultBackoffInitial, DefaultBackoffMax, DefaultBackoffMultiplier
+	if b == nil {
+		return ExponentialBackoff{Initial: initial, Max: max, Multiplier: multiplier}
+	}
+
+	if d, err := time.ParseDuration(b.Initial); err == nil {
+		initial = d
+	}
+	if d, err := time.ParseDuration(b.Max); err == nil {
+		max = d
+	}
+
+	switch b.Strategy {
+	case BackoffLinear:
+		step := initial
+		if d, err := time.ParseDuration(b.Step); err == nil {
+			step = d
+		}
+		return LinearBackoff{Initial: initial, Step: step, Max: max}
+	case BackoffConstant:
+		if initial > max {
+			initial = max
+		}
+		return ConstantBackoff{Wait: initial}
+	case BackoffSchedule:
+		if b.Max == "" {
+			max = 0
+		}
+		delays := make([]time.Duration, 0, len(b.Schedule))
+		for _, value := range b.Schedule {
+			if d, err := time.ParseDuration(value); err == nil {
+				delays = append(delays, d)
+			}
+		}
+		return ScheduleBackoff{Delays: delays, Max: max}
+	default:
+		if b.Multiplier > 0 {
+			multiplier = b.Multiplier
+		}
+		return ExponentialBackoff{Initial: initial, Max: max, Multiplier: multiplier}
+	}
+}
+
+// RateLimitPolicy is a token bucket refilled at Limit jobs per second; a
+// limit of 0 stops the type and a negative one leaves it unlimited
+type RateLimitPolicy struct {
+	Limit float64 `json:"limit"`
+	Burst int     `json:"burst"`
+}
+
+// JobPolicies maps job types to their policies
+type JobPolicies map[string]JobPolicy
+
+// Get returns the policy for a job type; the zero policy when none is set
+func (p JobPolicies) Get(jobType string) JobPolicy {
+	return p[jobType]
+}
+
+// WithDefaults returns the policies plus the defaults of job types that have none
+func (p JobPolicies) WithDefaults(defaults JobPolicies) JobPolicies {
+	merged := make(JobPolicies, len(p)+len(defaults))
+	for jobType, policy := range defaults {
+		merged[jobType] = policy
+	}
+	for jobType, policy := range p {
+		merged[jobType] = policy
+	}
+	return merged
+}
+
+// Validate checks every policy's durations and limits
+func (p JobPolicies) Validate() error {
+	for jobType, policy := range p {
+		if jobType == "" {
+			return fmt.Errorf("policy job type cannot be empty")
+		}
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("policy %s: %w", jobType, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the policy's durations and limits
+func (p JobPolicy) Validate() error {
+	if p.Timeout != "" {
+		if _, err := ParseJobTimeout(p.Timeout); err != nil {
+			return err
+		}
+	}
+	if p.MaxRetries != nil && *p.MaxRetries < 0 {
+		return fmt.Errorf("max_retries cannot be negative")
+	}
+	if p.Backoff != nil {
+		if err := p.Backoff.Validate(); err != nil {
+			return err
+		}
+	}
+	if p.RateLimit != nil && p.RateLimit.Limit > 0 && p.RateLimit.Burst < 1 {
+		return fmt.Errorf("rate_limit burst must be at least 1")
+	}
+	if p.Concurrency < 0 {
+		return fmt.Errorf("concurrency cannot be negative")
+	}
+	if p.Queue != "" {
+		if err := ValidateQueueName(p.Queue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TimeoutDuration returns the policy's handler time limit, zero when unset
+func (p JobPolicy) TimeoutDuration() time.Duration {
+	timeout, _ := time.ParseDuration(p.Timeout)
+	return timeout
+}
+
+// RetryDelay returns how long to wait before retrying after the given
+// attempt, counting from 1
+func (p JobPolicy) RetryDelay(attempt int) time.Duration {
+	return p.Backoff.RetryPolicy().Delay(attempt)
+}
+
+// Validate checks the strategy and its durations
+func (b *BackoffPolicy) Validate() error {
+	switch b.Strategy {
+	case "", BackoffExponential, BackoffLinear, BackoffConstant, BackoffSchedule:
+	default:
+		return fmt.Errorf("unknown backoff strategy %q, expected %s, %s, %s or %s",
+			b.Strategy, BackoffExponential, BackoffLinear, BackoffConstant, BackoffSchedule)
+	}
+
+	for name, value := range map[string]string{"initial": b.Initial, "max": b.Max, "step": b.Step} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("invalid backoff %s %q", name, value)
+		}
+	}
+	if b.Multiplier < 0 || (b.Multiplier > 0 && b.Multiplier < 1) {
+		return fmt.Errorf("backoff multiplier must be at least 1, got %v", b.Multiplier)
+	}
+
+	if b.Strategy == BackoffSchedule && len(b.Schedule) == 0 {
+		return fmt.Errorf("backoff schedule needs at least one delay")
+	}
+	if b.Strategy != BackoffSchedule && len(b.Schedule) > 0 {
+		return fmt.Errorf("backoff schedule needs the %s strategy", BackoffSchedule)
+	}
+	for _, value := range b.Schedule {
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			return fmt.Errorf("invalid backoff schedule delay %q", value)
+		}
+	}
+	return nil
+}
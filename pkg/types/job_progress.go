@@ -0,0 +1,30 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// MaxProgressStepLength caps the step name a handler reports
+const MaxProgressStepLength = 256
+
+// JobProgress is how far a running job has got, as reported by its handler
+type JobProgress struct {
+	Percent   float64   `json:"percent"`         // 0 to 100
+	Step      string    `json:"step,omitempty"`  // What the handler is doing, e.g. "resizing"
+	Done      int       `json:"done,omitempty"`  // Steps finished, when reported in steps
+	Total     int       `json:"total,omitempty"` // Steps overall, when reported in steps
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate checks the percentage and step name
+func (p JobProgress) Validate() error {
+	if math.IsNaN(p.Percent) || p.Percent < 0 || p.Percent > 100 {
+		return fmt.Errorf("progress percent must be between 0 and 100, got %v", p.Percent)
+	}
+	if len(p.Step) > MaxProgressStepLength {
+		return fmt.Errorf("progress step is %d characters, limit is %d", len(p.Step), MaxProgressStepLength)
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package types
+
+import (
+	"time"
+)
+
+// JobRecord is the history entry kept for a finished job
+type JobRecord struct {
+	Job        *Job       `json:"job"`
+	Status     JobStatus  `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	Duration   string     `json:"duration"`
+	FinishedAt time.Time  `json:"finished_at"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+}
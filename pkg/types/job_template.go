@@ -0,0 +1,158 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// TemplatePrefix marks a job request type as a template reference, e.g. "template:welcome-email"
+const TemplatePrefix = "template:"
+
+// JobTemplate holds the defaults for a named kind of job. Requests that
+// reference it only send the fields that differ.
+type JobTemplate struct {
+	Name       string          `json:"name"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload,omitempty"` // JSON object of default payload fields
+	Priority   string          `json:"priority,omitempty"`
+	Queue      string          `json:"queue,omitempty"`
+	MaxRetries *int            `json:"max_retries,omitempty"`
+	Metadata   JobMetadata     `json:"metadata,omitempty"`
+}
+
+// TemplateName returns the template a job type refers to, if any
+func TemplateName(jobType string) (string, bool) {
+	name, ok := strings.CutPrefix(jobType, TemplatePrefix)
+	return name, ok && name != ""
+}
+
+// Validate checks the template's name, type and default fields
+func (t *JobTemplate) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("template name is required")
+	}
+	if strings.Contains(t.Name, ":") {
+		return fmt.Errorf("template %s: name cannot contain ':'", t.Name)
+	}
+	if t.Type == "" {
+		return fmt.Errorf("template %s: type is required", t.Name)
+	}
+	if _, ok := TemplateName(t.Type); ok {
+		return fmt.Errorf("template %s: type cannot refer to another template", t.Name)
+	}
+	if len(t.Payload) > 0 {
+		if _, err := payloadObject(t.Payload); err != nil {
+			return fmt.Errorf("template %s: payload %w", t.Name, err)
+		}
+	}
+	if t.Queue != "" {
+		if err := ValidateQueueName(t.Queue); err != nil {
+			return fmt.Errorf("template %s: %w", t.Name, err)
+		}
+	}
+	if t.MaxRetries != nil && *t.MaxRetries < 0 {
+		return fmt.Errorf("template %s: max_retries cannot be negative", t.Name)
+	}
+	if err := t.Metadata.Validate(); err != nil {
+		return fmt.Errorf("template %s: %w", t.Name, err)
+	}
+	return nil
+}
+
+// Apply expands a request that references the template. Payload objects are
+// merged recursively with the request's fields winning; priority, queue,
+// retries and metadata keys from the request override the template's.
+func (t *JobTemplate) Apply(request JobRequest) (JobRequest, error) {
+	payload, err := mergePayload(t.Payload, request.Payload)
+	if err != nil {
+		return request, err
+	}
+
+	expanded := JobRequest{
+		Type:       t.Type,
+		Payload:    payload,
+		MaxRetries: request.MaxRetries,
+		Priority:   request.Priority,
+		Queue:      request.Queue,
+		Metadata:   t.Metadata.Clone(),
+		Timeout:    request.Timeout,
+		ExpiresAt:  request.ExpiresAt,
+
+		DependsOn:       request.DependsOn,
+		OnParentFailure: request.OnParentFailure,
+		PayloadVersion:  request.PayloadVersion,
+		Backoff:         request.Backoff,
+	}
+	if expanded.MaxRetries == nil && t.MaxRetries != nil {
+		retries := *t.MaxRetries
+		expanded.MaxRetries = &retries
+	}
+	if expanded.Priority == "" {
+		expanded.Priority = t.Priority
+	}
+	if expanded.Queue == "" {
+		expanded.Queue = t.Queue
+	}
+
+	if expanded.Metadata == nil {
+		expanded.Metadata = make(JobMetadata, len(request.Metadata)+1)
+	}
+	for key, value := range request.Metadata {
+		expanded.Metadata[key] = value
+	}
+	expanded.Metadata["template"] = t.Name
+
+	return expanded, nil
+}
+
+// mergePayload overlays the overrides object on the defaults object
+func mergePayload(defaults, overrides json.RawMessage) (json.RawMessage, error) {
+	if len(bytes.TrimSpace(defaults)) == 0 {
+		return overrides, nil
+	}
+	base, err := payloadObject(defaults)
+	if err != nil {
+		return nil, fmt.Errorf("template payload %w", err)
+	}
+
+	trimmed := bytes.TrimSpace(overrides)
+	if len(trimmed) > 0 && !bytes.Equal(trimmed, []byte("null")) {
+		patch, err := payloadObject(overrides)
+		if err != nil {
+			return nil, fmt.Errorf("payload overrides %w", err)
+		}
+		mergeObjects(base, patch)
+	}
+
+	merged, err := json.Marshal(base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal merged payload: %w", err)
+	}
+	return merged, nil
+}
+
+func mergeObjects(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if nested, ok := value.(map[string]interface{}); ok {
+			if existing, ok := dst[key].(map[string]interface{}); ok {
+				mergeObjects(existing, nested)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+}
+
+// payloadObject decodes a JSON object, keeping numbers exact
+func payloadObject(data json.RawMessage) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil || object == nil {
+		return nil, fmt.Errorf("must be a JSON object")
+	}
+	return object, nil
+}
@@ -0,0 +1,302 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TransformAllTypes is the job type whose transforms apply to every type,
+// before the type's own
+const TransformAllTypes = "*"
+
+// Transform operations
+const (
+	TransformSet       = "set"       // Write Value or the From source to Path
+	TransformDefault   = "default"   // Like set, but only when Path is missing
+	TransformRemove    = "remove"    // Delete Path
+	TransformRename    = "rename"    // Move the field at From to Path
+	TransformLowercase = "lowercase" // Lowercase the string at Path
+	TransformUppercase = "uppercase" // Uppercase the string at Path
+	TransformTrim      = "trim"      // Trim surrounding whitespace from the string at Path
+)
+
+// PayloadTransform is one declarative rewrite of a job's payload, e.g.
+// stamping a schema version or normalizing an email address
+type PayloadTransform struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`            // Dotted field path into the payload, e.g. customer.email
+	Value json.RawMessage `json:"value,omitempty"` // Literal written by set and default
+	From  string          `json:"from,omitempty"`  // Source for set and default instead of Value, or the payload path rename moves
+}
+
+// PayloadTransforms maps job types, or TransformAllTypes, to the transforms
+// applied in order to their payloads
+type PayloadTransforms map[string][]PayloadTransform
+
+// TransformInput is what transform sources can read besides the job itself
+type TransformInput struct {
+	Caller string    // Subject of the authenticated caller, empty without auth
+	Now    time.Time // Zero means the current time
+}
+
+// Transform sources usable in From by set and default
+const (
+	sourceMetadataPrefix = "metadata." // metadata.<key>: a metadata value of the job
+	sourcePayloadPrefix  = "payload."  // payload.<path>: another field of the payload
+)
+
+var transformSources = map[string]bool{
+	"job.id":    true,
+	"job.type":  true,
+	"job.queue": true,
+	"caller":    true,
+	"now":       true,
+}
+
+// Validate checks every job type's transforms
+func (t PayloadTransforms) Validate() error {
+	for jobType, transforms := range t {
+		if jobType == "" {
+			return fmt.Errorf("transform job type cannot be empty")
+		}
+		for i, transform := range transforms {
+			if err := transform.Validate(); err != nil {
+				return fmt.Errorf("transform %d of %s: %w", i+1, jobType, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Types returns the job types with transforms, sorted
+func (t PayloadTransforms) Types() []string {
+	jobTypes := make([]string, 0, len(t))
+	for jobType := range t {
+		jobTypes = append(jobTypes, jobType)
+	}
+	sort.Strings(jobTypes)
+	return jobTypes
+}
+
+// Validate checks the operation and the fields it needs
+func (t PayloadTransform) Validate() error {
+	if err := validateTransformPath(t.Path); err != nil {
+		return err
+	}
+
+	switch t.Op {
+	case TransformSet, TransformDefault:
+		if (len(t.Value) == 0) == (t.From == "") {
+			return fmt.Errorf("%s needs either value or from", t.Op)
+		}
+		if len(t.Value) > 0 && !json.Valid(t.Value) {
+			return fmt.Errorf("%s value is not valid JSON", t.Op)
+		}
+		if t.From != "" {
+			return validateTransformSource(t.From)
+		}
+	case TransformRename:
+		if len(t.Value) > 0 {
+			return fmt.Errorf("rename takes from, not value")
+		}
+		if err := validateTransformPath(t.From); err != nil {
+			return fmt.Errorf("rename from: %w", err)
+		}
+	case TransformRemove, TransformLowercase, TransformUppercase, TransformTrim:
+		if len(t.Value) > 0 || t.From != "" {
+			return fmt.Errorf("%s takes only a path", t.Op)
+		}
+	default:
+		return fmt.Errorf("unknown transform op %q", t.Op)
+	}
+	return nil
+}
+
+func validateTransformPath(path string) error {
+	if path == "" {
+		return fmt.Errorf("path is required")
+	}
+	for _, field := range strings.Split(path, ".") {
+		if field == "" {
+			return fmt.Errorf("path %q has an empty field", path)
+		}
+	}
+	return nil
+}
+
+func validateTransformSource(source string) error {
+	if transformSources[source] {
+		return nil
+	}
+	if key, ok := strings.CutPrefix(source, sourceMetadataPrefix); ok && key != "" {
+		return nil
+	}
+	if path, ok := strings.CutPrefix(source, sourcePayloadPrefix); ok {
+		return validateTransformPath(path)
+	}
+	return fmt.Errorf("unknown transform source %q, expected metadata.<key>, payload.<path>, job.id, job.type, job.queue, caller or now", source)
+}
+
+// Apply runs the transforms of TransformAllTypes and then those of the job's
+// type against its payload, which must be a JSON object
+func (t PayloadTransforms) Apply(job *Job, input TransformInput) error {
+	transforms := append(append([]PayloadTransform(nil), t[TransformAllTypes]...), t[job.Type]...)
+	return ApplyTransforms(job, transforms, input)
+}
+
+// ApplyTransforms runs transforms in order against the job's payload and
+// replaces it with the result. The payload is left untouched on error.
+func ApplyTransforms(job *Job, transforms []PayloadTransform, input TransformInput) error {
+	if len(transforms) == 0 {
+		return nil
+	}
+
+	payload, err := payloadObject(job.Payload)
+	if err != nil {
+		return fmt.Errorf("payload %w to be transformed", err)
+	}
+	if input.Now.IsZero() {
+		input.Now = time.Now()
+	}
+
+	for i, transform := range transforms {
+		if err := transform.apply(payload, job, input); err != nil {
+			return fmt.Errorf("transform %d (%s %s): %w", i+1, transform.Op, transform.Path, err)
+		}
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal transformed payload: %w", err)
+	}
+	job.Payload = data
+	return nil
+}
+
+func (t PayloadTransform) apply(payload map[string]interface{}, job *Job, input TransformInput) error {
+	parent, field, err := transformParent(payload, t.Path, t.Op == TransformSet || t.Op == TransformDefault || t.Op == TransformRename)
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		return nil // Nothing to remove or normalize
+	}
+
+	switch t.Op {
+	case TransformSet, TransformDefault:
+		if _, exists := parent[field]; exists && t.Op == TransformDefault {
+			return nil
+		}
+		value, ok, err := t.source(payload, job, input)
+		if err != nil || !ok {
+			return err
+		}
+		parent[field] = value
+	case TransformRename:
+		fromParent, fromField, err := transformParent(payload, t.From, false)
+		if err != nil || fromParent == nil {
+			return err
+		}
+		value, ok := fromParent[fromField]
+		if !ok {
+			return nil
+		}
+		delete(fromParent, fromField)
+		parent[field] = value
+	case TransformRemove:
+		delete(parent, field)
+	case TransformLowercase, TransformUppercase, TransformTrim:
+		value, ok := parent[field]
+		if !ok || value == nil {
+			return nil
+		}
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("field is %s, not a string", jsonType(value))
+		}
+		switch t.Op {
+		case TransformLowercase:
+			parent[field] = strings.ToLower(text)
+		case TransformUppercase:
+			parent[field] = strings.ToUpper(text)
+		default:
+			parent[field] = strings.TrimSpace(text)
+		}
+	}
+	return nil
+}
+
+// source resolves the value set and default write; ok is false when the
+// source has no value, which leaves the field alone
+func (t PayloadTransform) source(payload map[string]interface{}, job *Job, input TransformInput) (interface{}, bool, error) {
+	if len(t.Value) > 0 {
+		value, err := payloadValue(t.Value)
+		return value, err == nil, err
+	}
+
+	switch t.From {
+	case "job.id":
+		return job.ID, true, nil
+	case "job.type":
+		return job.Type, true, nil
+	case "job.queue":
+		return job.Queue, job.Queue != "", nil
+	case "caller":
+		return input.Caller, input.Caller != "", nil
+	case "now":
+		return input.Now.UTC().Format(time.RFC3339), true, nil
+	}
+
+	if key, ok := strings.CutPrefix(t.From, sourceMetadataPrefix); ok {
+		value, ok := job.Metadata[key]
+		return value, ok, nil
+	}
+
+	path, _ := strings.CutPrefix(t.From, sourcePayloadPrefix)
+	parent, field, err := transformParent(payload, path, false)
+	if err != nil || parent == nil {
+		return nil, false, err
+	}
+	value, ok := parent[field]
+	return value, ok, nil
+}
+
+// transformParent returns the object holding the last field of path. With
+// create, missing objects along the way are added; without it a missing
+// object returns nil.
+func transformParent(payload map[string]interface{}, path string, create bool) (map[string]interface{}, string, error) {
+	fields := strings.Split(path, ".")
+	object := payload
+	for _, field := range fields[:len(fields)-1] {
+		next, exists := object[field]
+		if !exists || next == nil {
+			if !create {
+				return nil, "", nil
+			}
+			child := map[string]interface{}{}
+			object[field] = child
+			object = child
+			continue
+		}
+		child, ok := next.(map[string]interface{})
+		if !ok {
+			return nil, "", fmt.Errorf("field %s is %s, not an object", field, jsonType(next))
+		}
+		object = child
+	}
+	return object, fields[len(fields)-1], nil
+}
+
+// payloadValue decodes a JSON value keeping numbers exact
+func payloadValue(data json.RawMessage) (interface{}, error) {
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid value: %w", err)
+	}
+	return value, nil
+}
@@ -0,0 +1,70 @@
+package types
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// MaxResultSize bounds a handler's encoded result, which is stored in Redis with the job's result
+const MaxResultSize = 1 << 20
+
+// ResultWriter records the value a handler produced
+type ResultWriter interface {
+	WriteResult(value interface{}) error
+}
+
+type resultWriterContextKey struct{}
+
+// WithResultWriter returns a context carrying the given result writer
+func WithResultWriter(ctx context.Context, writer ResultWriter) context.Context {
+	return context.WithValue(ctx, resultWriterContextKey{}, writer)
+}
+
+// ResultWriterFromContext returns the result writer attached to the handler context, if any
+func ResultWriterFromContext(ctx context.Context) (ResultWriter, bool) {
+	writer, ok := ctx.Value(resultWriterContextKey{}).(ResultWriter)
+	return writer, ok
+}
+
+// SetResult records value as the job's result through the writer in ctx.
+// The result is kept only if the handler returns nil; a later call replaces it.
+func SetResult(ctx context.Context, value interface{}) error {
+	writer, ok := ResultWriterFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("no result writer available in context")
+	}
+	return writer.WriteResult(value)
+}
+
+// ResultBuffer holds the latest result written by a handler as JSON
+type ResultBuffer struct {
+	mu     sync.Mutex
+	output json.RawMessage
+}
+
+// WriteResult encodes the value, rejecting results over MaxResultSize
+func (b *ResultBuffer) WriteResult(value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job result: %w", err)
+	}
+	if len(data) > MaxResultSize {
+		return fmt.Errorf("job result is %d bytes, more than the %d allowed", len(data), MaxResultSize)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.output = data
+	return nil
+}
+
+// Output returns the encoded result, or nil when none was written
+func (b *ResultBuffer) Output() json.RawMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.output
+}
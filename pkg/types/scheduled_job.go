@@ -1,20 +1,97 @@
 package types
 
 import (
+	"fmt"
 	"time"
 )
 
 // ScheduledJob represents a job that will be executed at a future time
 type ScheduledJob struct {
-	Job            *Job      `json:"job"`
-	ExecuteAt      time.Time `json:"execute_at"`
-	Recurring      bool      `json:"recurring"`
-	CronExpression string    `json:"cron_expression,omitempty"`
+	ID             string     `json:"id,omitempty"` // Stable identifier of a recurring schedule
+	Job            *Job       `json:"job"`
+	ExecuteAt      time.Time  `json:"execute_at"`
+	Recurring      bool       `json:"recurring"`
+	CronExpression string     `json:"cron_expression,omitempty"`
+	Paused         bool       `json:"paused,omitempty"` // Paused schedules skip their runs until resumed
+	PausedAt       *time.Time `json:"paused_at,omitempty"`
+}
+
+// ScheduleRun records one job spawned by a recurring schedule and, once it
+// has finished, its outcome
+type ScheduleRun struct {
+	JobID      string     `json:"job_id"`
+	EnqueuedAt time.Time  `json:"enqueued_at"`
+	Status     JobStatus  `json:"status,omitempty"` // Empty until the job has finished
+	Attempts   int        `json:"attempts,omitempty"`
+	Duration   string     `json:"duration,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// FailureReason classifies why a job ended up in the DLQ
+type FailureReason string
+
+const (
+	ReasonHandlerError FailureReason = "handler_error"     // Handler returned an error
+	ReasonTimeout      FailureReason = "timeout"           // Handler ran past its deadline
+	ReasonPanic        FailureReason = "panic"             // Handler panicked
+	ReasonExpired      FailureReason = "expired"           // Job was too old when dequeued
+	ReasonPoison       FailureReason = "poison"            // Job can never succeed, e.g. unknown type or bad payload
+	ReasonCancelled    FailureReason = "cancelled"         // Handler context was cancelled
+	ReasonIncompatible FailureReason = "incompatible"      // Job was written in a newer envelope format than the worker understands
+	ReasonDependency   FailureReason = "dependency_failed" // A job it depends on failed, so it never ran
+	ReasonUntrusted    FailureReason = "untrusted"         // Job signature was missing or invalid, so it never ran
+	ReasonVersion      FailureReason = "version_mismatch"  // Handler doesn't accept the job's payload version
+	ReasonSchema       FailureReason = "schema_violation"  // Payload didn't match the handler's schema when dequeued
+	ReasonUnknown      FailureReason = "unknown"           // Entries written before reasons were recorded
+)
+
+// FailureReasons lists every known reason, for validating filters
+var FailureReasons = []FailureReason{
+	ReasonHandlerError, ReasonTimeout, ReasonPanic, ReasonExpired, ReasonPoison, ReasonCancelled, ReasonIncompatible, ReasonDependency, ReasonUntrusted, ReasonVersion, ReasonSchema, ReasonUnknown,
+}
+
+// ParseFailureReason validates a reason given by a user
+func ParseFailureReason(value string) (FailureReason, error) {
+	for _, reason := range FailureReasons {
+		if string(reason) == value {
+			return reason, nil
+		}
+	}
+	return "", fmt.Errorf("unknown failure reason %q", value)
 }
 
 // FailedJobInfo contains information about a failed job in the DLQ
 type FailedJobInfo struct {
-	Job      *Job      `json:"job"`
-	Error    string    `json:"error"`
-	FailedAt time.Time `json:"failed_at"`
+	Job         *Job          `json:"job"`
+	Error       string        `json:"error"`
+	Reason      FailureReason `json:"reason,omitempty"`
+	Fingerprint string        `json:"fingerprint,omitempty"` // Normalized error, see ErrorFingerprint
+	FailedAt    time.Time     `json:"failed_at"`
+}
+
+// QuarantinedJob is a job held for manual review instead of being run or
+// dead-lettered
+type QuarantinedJob struct {
+	Job           *Job          `json:"job"`
+	Reason        FailureReason `json:"reason"` // untrusted, schema_violation or poison
+	Error         string        `json:"error"`
+	QuarantinedAt time.Time     `json:"quarantined_at"`
+}
+
+// ReasonOrUnknown returns the recorded reason, or ReasonUnknown for older entries
+func (f *FailedJobInfo) ReasonOrUnknown() FailureReason {
+	if f.Reason == "" {
+		return ReasonUnknown
+	}
+	return f.Reason
+}
+
+// FingerprintOrCompute returns the stored fingerprint, or computes it for
+// entries stored without one
+func (f *FailedJobInfo) FingerprintOrCompute() string {
+	if f.Fingerprint == "" {
+		return ErrorFingerprint(f.Error)
+	}
+	return f.Fingerprint
 }
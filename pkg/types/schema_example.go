@@ -0,0 +1,106 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// maxExampleDepth stops example generation on deeply nested or recursive schemas
+const maxExampleDepth = 8
+
+// ExampleFromSchema builds a payload that fits a JSON Schema, for handlers
+// that advertise a schema but no example. It uses the schema's own
+// examples, defaults, consts and enums where present and placeholder values
+// of the declared type elsewhere.
+func ExampleFromSchema(schema json.RawMessage) (json.RawMessage, error) {
+	var root interface{}
+	if err := json.Unmarshal(schema, &root); err != nil {
+		return nil, fmt.Errorf("invalid payload schema: %w", err)
+	}
+
+	example, err := json.Marshal(schemaExample(root, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal example payload: %w", err)
+	}
+	return example, nil
+}
+
+func schemaExample(node interface{}, depth int) interface{} {
+	schema, ok := node.(map[string]interface{})
+	if !ok || depth > maxExampleDepth {
+		return nil
+	}
+
+	if examples, ok := schema["examples"].([]interface{}); ok && len(examples) > 0 {
+		return examples[0]
+	}
+	for _, key := range []string{"example", "default", "const"} {
+		if value, ok := schema[key]; ok {
+			return value
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	for _, key := range []string{"oneOf", "anyOf", "allOf"} {
+		if options, ok := schema[key].([]interface{}); ok && len(options) > 0 {
+			return schemaExample(options[0], depth+1)
+		}
+	}
+
+	schemaType, _ := schema["type"].(string)
+	if typeList, ok := schema["type"].([]interface{}); ok && len(typeList) > 0 {
+		schemaType, _ = typeList[0].(string)
+	}
+	if schemaType == "" {
+		if _, ok := schema["properties"]; ok {
+			schemaType = "object"
+		}
+	}
+
+	switch schemaType {
+	case "object":
+		properties, _ := schema["properties"].(map[string]interface{})
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		object := make(map[string]interface{}, len(names))
+		for _, name := range names {
+			object[name] = schemaExample(properties[name], depth+1)
+		}
+		return object
+	case "array":
+		return []interface{}{schemaExample(schema["items"], depth+1)}
+	case "string":
+		return stringExample(schema)
+	case "integer", "number":
+		if minimum, ok := schema["minimum"].(float64); ok {
+			return minimum
+		}
+		return 0
+	case "boolean":
+		return false
+	}
+	return nil
+}
+
+// stringExample returns a placeholder that satisfies common string formats
+func stringExample(schema map[string]interface{}) string {
+	switch schema["format"] {
+	case "email":
+		return "user@example.com"
+	case "uri", "url":
+		return "https://example.com"
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	}
+	return "string"
+}
@@ -0,0 +1,511 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxSchemaViolations = 20 // Violations reported per payload
+	maxSchemaDepth      = 64 // Stops validation on recursive $refs
+)
+
+// uuidPattern matches the textual form of a UUID
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// SchemaViolation is one way a payload breaks its job type's JSON Schema
+type SchemaViolation struct {
+	Path    string `json:"path"`    // JSON Pointer to the offending value, empty for the payload itself
+	Keyword string `json:"keyword"` // Schema keyword the value fails, e.g. required or maxLength
+	Message string `json:"message"`
+}
+
+func (v SchemaViolation) Error() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+// PayloadSchema is a compiled JSON Schema that payloads are checked against
+// at enqueue time. It covers the keywords handlers commonly use: type, enum,
+// const, the object, array, string and number constraints, format, $ref to
+// the same document and the allOf, anyOf, oneOf and not combinators. Other
+// keywords are ignored.
+type PayloadSchema struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// CompileSchema parses a JSON Schema and its regular expressions
+func CompileSchema(schema json.RawMessage) (*PayloadSchema, error) {
+	var root interface{}
+	if err := json.Unmarshal(schema, &root); err != nil {
+		return nil, fmt.Errorf("invalid payload schema: %w", err)
+	}
+
+	s := &PayloadSchema{
+		root:     root,
+		patterns: make(map[string]*regexp.Regexp),
+	}
+	if err := s.compile(root, ""); err != nil {
+		return nil, fmt.Errorf("invalid payload schema: %w", err)
+	}
+	return s, nil
+}
+
+// compile checks the patterns and references of a schema node and the
+// nodes below it
+func (s *PayloadSchema) compile(node interface{}, path string) error {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		if pattern, ok := value["pattern"].(string); ok {
+			if err := s.addPattern(pattern); err != nil {
+				return fmt.Errorf("%s/pattern: %w", path, err)
+			}
+		}
+		if properties, ok := value["patternProperties"].(map[string]interface{}); ok {
+			for pattern := range properties {
+				if err := s.addPattern(pattern); err != nil {
+					return fmt.Errorf("%s/patternProperties: %w", path, err)
+				}
+			}
+		}
+		if ref, ok := value["$ref"].(string); ok {
+			if _, err := s.resolve(ref); err != nil {
+				return fmt.Errorf("%s/$ref: %w", path, err)
+			}
+		}
+		for key, child := range value {
+			switch key {
+			case "enum", "const", "default", "example", "examples":
+				continue // Values, not schemas
+			}
+			if err := s.compile(child, path+"/"+pointerEscape(key)); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, child := range value {
+			if err := s.compile(child, fmt.Sprintf("%s/%d", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *PayloadSchema) addPattern(pattern string) error {
+	if _, ok := s.patterns[pattern]; ok {
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	s.patterns[pattern] = re
+	return nil
+}
+
+// resolve follows a reference within the schema, e.g. #/$defs/address
+func (s *PayloadSchema) resolve(ref string) (interface{}, error) {
+	if ref != "#" && !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("only references within the schema are supported, got %q", ref)
+	}
+
+	node := s.root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch value := node.(type) {
+		case map[string]interface{}:
+			child, ok := value[token]
+			if !ok {
+				return nil, fmt.Errorf("reference %q not found", ref)
+			}
+			node = child
+		case []interface{}:
+			var i int
+			if _, err := fmt.Sscan(token, &i); err != nil || i < 0 || i >= len(value) {
+				return nil, fmt.Errorf("reference %q not found", ref)
+			}
+			node = value[i]
+		default:
+			return nil, fmt.Errorf("reference %q not found", ref)
+		}
+	}
+	return node, nil
+}
+
+// Validate returns the ways payload breaks the schema, up to a limit, or
+// nil when it fits
+func (s *PayloadSchema) Validate(payload json.RawMessage) []SchemaViolation {
+	var value interface{}
+	if err := json.Unmarshal(payload, &value); err != nil {
+		return []SchemaViolation{{Keyword: "type", Message: "payload is not valid JSON"}}
+	}
+
+	violations := s.check(s.root, value, "", 0)
+	if len(violations) > maxSchemaViolations {
+		violations = violations[:maxSchemaViolations]
+	}
+	return violations
+}
+
+// check validates value at path against one schema node
+func (s *PayloadSchema) check(node, value interface{}, path string, depth int) []SchemaViolation {
+	if depth > maxSchemaDepth {
+		return nil
+	}
+
+	var schema map[string]interface{}
+	switch typed := node.(type) {
+	case bool:
+		if !typed {
+			return []SchemaViolation{{Path: path, Keyword: "false", Message: "no value is allowed here"}}
+		}
+		return nil
+	case map[string]interface{}:
+		schema = typed
+	default:
+		return nil
+	}
+
+	var violations []SchemaViolation
+	fail := func(keyword, format string, args ...interface{}) {
+		violations = append(violations, SchemaViolation{Path: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		if target, err := s.resolve(ref); err == nil {
+			violations = append(violations, s.check(target, value, path, depth+1)...)
+		}
+	}
+
+	if allowed := schemaTypes(schema["type"]); len(allowed) > 0 && !matchesType(value, allowed) {
+		// The remaining keywords would only restate the mismatch
+		fail("type", "must be %s, got %s", strings.Join(allowed, " or "), jsonType(value))
+		return violations
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsValue(enum, value) {
+		fail("enum", "must be one of %s", compactJSON(enum))
+	}
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(constant, value) {
+		fail("const", "must be %s", compactJSON(constant))
+	}
+
+	switch typed := value.(type) {
+	case string:
+		violations = append(violations, s.checkString(schema, typed, path)...)
+	case float64:
+		violations = append(violations, checkNumber(schema, typed, path)...)
+	case []interface{}:
+		violations = append(violations, s.checkArray(schema, typed, path, depth)...)
+	case map[string]interface{}:
+		violations = append(violations, s.checkObject(schema, typed, path, depth)...)
+	}
+
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, option := range all {
+			violations = append(violations, s.check(option, value, path, depth+1)...)
+		}
+	}
+	if options, ok := schema["anyOf"].([]interface{}); ok && s.countMatches(options, value, path, depth) == 0 {
+		fail("anyOf", "must match at least one of the allowed schemas")
+	}
+	if options, ok := schema["oneOf"].([]interface{}); ok {
+		if matches := s.countMatches(options, value, path, depth); matches != 1 {
+			fail("oneOf", "must match exactly one of the allowed schemas, matches %d", matches)
+		}
+	}
+	if not, ok := schema["not"]; ok && len(s.check(not, value, path, depth+1)) == 0 {
+		fail("not", "must not match the disallowed schema")
+	}
+	return violations
+}
+
+// countMatches returns how many of options value fits
+func (s *PayloadSchema) countMatches(options []interface{}, value interface{}, path string, depth int) int {
+	matches := 0
+	for _, option := range options {
+		if len(s.check(option, value, path, depth+1)) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+func (s *PayloadSchema) checkString(schema map[string]interface{}, value, path string) []SchemaViolation {
+	var violations []SchemaViolation
+	fail := func(keyword, format string, args ...interface{}) {
+		violations = append(violations, SchemaViolation{Path: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(value)
+	if minLength, ok := schema["minLength"].(float64); ok && float64(length) < minLength {
+		fail("minLength", "must be at least %v characters, got %d", minLength, length)
+	}
+	if maxLength, ok := schema["maxLength"].(float64); ok && float64(length) > maxLength {
+		fail("maxLength", "must be at most %v characters, got %d", maxLength, length)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		if re := s.patterns[pattern]; re != nil && !re.MatchString(value) {
+			fail("pattern", "must match %s", pattern)
+		}
+	}
+	if format, ok := schema["format"].(string); ok && !matchesFormat(format, value) {
+		fail("format", "must be a valid %s", format)
+	}
+	return violations
+}
+
+func checkNumber(schema map[string]interface{}, value float64, path string) []SchemaViolation {
+	var violations []SchemaViolation
+	fail := func(keyword, format string, args ...interface{}) {
+		violations = append(violations, SchemaViolation{Path: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Draft 4 spells exclusive bounds as booleans next to minimum and maximum
+	exclusiveMin, _ := schema["exclusiveMinimum"].(bool)
+	exclusiveMax, _ := schema["exclusiveMaximum"].(bool)
+	if minimum, ok := schema["minimum"].(float64); ok {
+		if exclusiveMin && value <= minimum {
+			fail("minimum", "must be greater than %v", minimum)
+		} else if value < minimum {
+			fail("minimum", "must be at least %v", minimum)
+		}
+	}
+	if maximum, ok := schema["maximum"].(float64); ok {
+		if exclusiveMax && value >= maximum {
+			fail("maximum", "must be less than %v", maximum)
+		} else if value > maximum {
+			fail("maximum", "must be at most %v", maximum)
+		}
+	}
+	if bound, ok := schema["exclusiveMinimum"].(float64); ok && value <= bound {
+		fail("exclusiveMinimum", "must be greater than %v", bound)
+	}
+	if bound, ok := schema["exclusiveMaximum"].(float64); ok && value >= bound {
+		fail("exclusiveMaximum", "must be less than %v", bound)
+	}
+	if multipleOf, ok := schema["multipleOf"].(float64); ok && multipleOf > 0 {
+		quotient := value / multipleOf
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			fail("multipleOf", "must be a multiple of %v", multipleOf)
+		}
+	}
+	return violations
+}
+
+func (s *PayloadSchema) checkArray(schema map[string]interface{}, value []interface{}, path string, depth int) []SchemaViolation {
+	var violations []SchemaViolation
+	fail := func(keyword, format string, args ...interface{}) {
+		violations = append(violations, SchemaViolation{Path: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if minItems, ok := schema["minItems"].(float64); ok && float64(len(value)) < minItems {
+		fail("minItems", "must have at least %v items, got %d", minItems, len(value))
+	}
+	if maxItems, ok := schema["maxItems"].(float64); ok && float64(len(value)) > maxItems {
+		fail("maxItems", "must have at most %v items, got %d", maxItems, len(value))
+	}
+	if unique, _ := schema["uniqueItems"].(bool); unique {
+	duplicates:
+		for i := range value {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(value[i], value[j]) {
+					fail("uniqueItems", "must not repeat items, item %d equals item %d", i, j)
+					break duplicates
+				}
+			}
+		}
+	}
+
+	// Leading items follow prefixItems (or an items array in older drafts),
+	// the rest follow items (or additionalItems)
+	prefix, _ := schema["prefixItems"].([]interface{})
+	rest, hasRest := schema["items"]
+	if tuple, ok := rest.([]interface{}); ok {
+		prefix = tuple
+		rest, hasRest = schema["additionalItems"]
+	}
+	for i, item := range value {
+		itemPath := fmt.Sprintf("%s/%d", path, i)
+		switch {
+		case i < len(prefix):
+			violations = append(violations, s.check(prefix[i], item, itemPath, depth+1)...)
+		case hasRest:
+			violations = append(violations, s.check(rest, item, itemPath, depth+1)...)
+		}
+	}
+	return violations
+}
+
+func (s *PayloadSchema) checkObject(schema map[string]interface{}, value map[string]interface{}, path string, depth int) []SchemaViolation {
+	var violations []SchemaViolation
+	fail := func(keyword, format string, args ...interface{}) {
+		violations = append(violations, SchemaViolation{Path: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, field := range required {
+			name, _ := field.(string)
+			if _, present := value[name]; !present {
+				violations = append(violations, SchemaViolation{
+					Path:    path + "/" + pointerEscape(name),
+					Keyword: "required",
+					Message: "is required",
+				})
+			}
+		}
+	}
+	if minProperties, ok := schema["minProperties"].(float64); ok && float64(len(value)) < minProperties {
+		fail("minProperties", "must have at least %v fields, got %d", minProperties, len(value))
+	}
+	if maxProperties, ok := schema["maxProperties"].(float64); ok && float64(len(value)) > maxProperties {
+		fail("maxProperties", "must have at most %v fields, got %d", maxProperties, len(value))
+	}
+
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	patternProperties, _ := schema["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := schema["additionalProperties"]
+	for _, name := range names {
+		fieldPath := path + "/" + pointerEscape(name)
+		matched := false
+		if property, ok := properties[name]; ok {
+			violations = append(violations, s.check(property, value[name], fieldPath, depth+1)...)
+			matched = true
+		}
+		for pattern, property := range patternProperties {
+			if re := s.patterns[pattern]; re != nil && re.MatchString(name) {
+				violations = append(violations, s.check(property, value[name], fieldPath, depth+1)...)
+				matched = true
+			}
+		}
+		if matched || !hasAdditional {
+			continue
+		}
+		if allowed, ok := additional.(bool); ok && !allowed {
+			violations = append(violations, SchemaViolation{Path: fieldPath, Keyword: "additionalProperties", Message: "is not an allowed field"})
+			continue
+		}
+		violations = append(violations, s.check(additional, value[name], fieldPath, depth+1)...)
+	}
+	return violations
+}
+
+// schemaTypes returns the types a schema's type keyword allows
+func schemaTypes(value interface{}) []string {
+	switch typed := value.(type) {
+	case string:
+		return []string{typed}
+	case []interface{}:
+		allowed := make([]string, 0, len(typed))
+		for _, entry := range typed {
+			if name, ok := entry.(string); ok {
+				allowed = append(allowed, name)
+			}
+		}
+		return allowed
+	}
+	return nil
+}
+
+func matchesType(value interface{}, allowed []string) bool {
+	actual := jsonType(value)
+	for _, name := range allowed {
+		if name == actual || (name == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType names the JSON type of a decoded value, telling integers apart
+func jsonType(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if typed == math.Trunc(typed) && !math.IsInf(typed, 0) {
+			return "integer"
+		}
+		return "number"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if reflect.DeepEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesFormat checks the string formats handlers commonly declare;
+// unknown formats always match
+func matchesFormat(format, value string) bool {
+	switch format {
+	case "email":
+		address, err := mail.ParseAddress(value)
+		return err == nil && address.Address == value
+	case "uri", "url":
+		parsed, err := url.Parse(value)
+		return err == nil && parsed.Scheme != ""
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case "uuid":
+		return uuidPattern.MatchString(value)
+	case "ipv4":
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil && !strings.Contains(value, ":")
+	case "ipv6":
+		ip := net.ParseIP(value)
+		return ip != nil && strings.Contains(value, ":")
+	}
+	return true
+}
+
+// pointerEscape escapes a field name for use in a JSON Pointer
+func pointerEscape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+func compactJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
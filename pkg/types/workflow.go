@@ -0,0 +1,246 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxWorkflowNodes caps how many nodes a single workflow may hold
+const MaxWorkflowNodes = 500
+
+// WorkflowStatus is the overall state of a workflow
+type WorkflowStatus string
+
+const (
+	WorkflowRunning   WorkflowStatus = "running"
+	WorkflowCompleted WorkflowStatus = "completed" // Every node finished and none failed
+	WorkflowFailed    WorkflowStatus = "failed"    // Every node finished and at least one failed
+)
+
+// NodeState is the state of one node of a workflow
+type NodeState string
+
+const (
+	NodePending   NodeState = "pending"  // Waiting on the nodes it depends on
+	NodeEnqueued  NodeState = "enqueued" // Its job is queued, running or retrying
+	NodeCompleted NodeState = "completed"
+	NodeFailed    NodeState = "failed"  // Its job failed permanently
+	NodeSkipped   NodeState = "skipped" // Its condition didn't hold or a dependency didn't complete
+)
+
+// Finished reports whether the node will not change state again
+func (s NodeState) Finished() bool {
+	return s == NodeCompleted || s == NodeFailed || s == NodeSkipped
+}
+
+// Workflow is a DAG of jobs. A node runs once every node it depends on has
+// finished: by default only if they all completed, otherwise as its When
+// condition says. Nodes that don't run are skipped, as are their dependents.
+type Workflow struct {
+	Name  string         `json:"name,omitempty"`
+	Nodes []WorkflowNode `json:"nodes" binding:"required"`
+}
+
+// WorkflowNode is one job of a workflow
+type WorkflowNode struct {
+	Name       string          `json:"name"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	MaxRetries *int            `json:"max_retries,omitempty"` // Retries of this node's job, the type's default if unset
+	Priority   string          `json:"priority,omitempty"`
+	Queue      string          `json:"queue,omitempty"`
+	Timeout    string          `json:"timeout,omitempty"`
+	Metadata   JobMetadata     `json:"metadata,omitempty"`
+	DependsOn  []string        `json:"depends_on,omitempty"` // Names of the nodes that must finish first
+	When       *NodeCondition  `json:"when,omitempty"`       // Branch condition on one of DependsOn
+}
+
+// NodeCondition makes a node a conditional branch. It holds when the named
+// node finished with Status (completed by default) and, if Equals is set,
+// the value at Path in that node's result equals it.
+type NodeCondition struct {
+	Node   string          `json:"node"`
+	Status NodeState       `json:"status,omitempty"` // completed or failed
+	Path   string          `json:"path,omitempty"`   // Dotted field path into the result, e.g. review.approved; empty for the whole result
+	Equals json.RawMessage `json:"equals,omitempty"`
+}
+
+// WorkflowNodeStatus reports the progress of one node
+type WorkflowNodeStatus struct {
+	Name  string    `json:"name"`
+	JobID string    `json:"job_id"`
+	State NodeState `json:"state"`
+	Error string    `json:"error,omitempty"`
+}
+
+// WorkflowInfo is a submitted workflow and the state of its nodes
+type WorkflowInfo struct {
+	ID         string               `json:"id"`
+	Name       string               `json:"name,omitempty"`
+	Status     WorkflowStatus       `json:"status"`
+	Nodes      []WorkflowNodeStatus `json:"nodes"`
+	CreatedAt  time.Time            `json:"created_at"`
+	FinishedAt *time.Time           `json:"finished_at,omitempty"`
+}
+
+// JobRequest returns the job submission for the node
+func (n WorkflowNode) JobRequest() JobRequest {
+	return JobRequest{
+		Type:       n.Type,
+		Payload:    n.Payload,
+		MaxRetries: n.MaxRetries,
+		Priority:   n.Priority,
+		Queue:      n.Queue,
+		Metadata:   n.Metadata,
+		Timeout:    n.Timeout,
+	}
+}
+
+// Validate checks node names, dependencies and conditions and that the
+// nodes form a DAG
+func (w *Workflow) Validate() error {
+	if len(w.Nodes) == 0 {
+		return fmt.Errorf("workflow needs at least one node")
+	}
+	if len(w.Nodes) > MaxWorkflowNodes {
+		return fmt.Errorf("workflow can hold at most %d nodes, got %d", MaxWorkflowNodes, len(w.Nodes))
+	}
+
+	nodes := make(map[string]*WorkflowNode, len(w.Nodes))
+	for i := range w.Nodes {
+		node := &w.Nodes[i]
+		if node.Name == "" {
+			return fmt.Errorf("node %d: name is required", i+1)
+		}
+		if _, exists := nodes[node.Name]; exists {
+			return fmt.Errorf("node %s is defined twice", node.Name)
+		}
+		if node.Type == "" {
+			return fmt.Errorf("node %s: type is required", node.Name)
+		}
+		if len(node.Payload) == 0 {
+			return fmt.Errorf("node %s: payload is required", node.Name)
+		}
+		nodes[node.Name] = node
+	}
+
+	for _, node := range w.Nodes {
+		seen := make(map[string]bool, len(node.DependsOn))
+		for _, parent := range node.DependsOn {
+			if _, ok := nodes[parent]; !ok {
+				return fmt.Errorf("node %s depends on unknown node %s", node.Name, parent)
+			}
+			if seen[parent] {
+				return fmt.Errorf("node %s lists dependency %s twice", node.Name, parent)
+			}
+			seen[parent] = true
+		}
+		if node.When != nil {
+			if !seen[node.When.Node] {
+				return fmt.Errorf("node %s: condition refers to %s, which is not in its depends_on", node.Name, node.When.Node)
+			}
+			switch node.When.Status {
+			case "", NodeCompleted, NodeFailed:
+			default:
+				return fmt.Errorf("node %s: condition status must be %s or %s, got %q", node.Name, NodeCompleted, NodeFailed, node.When.Status)
+			}
+			if len(node.When.Equals) > 0 && !json.Valid(node.When.Equals) {
+				return fmt.Errorf("node %s: condition equals is not valid JSON", node.Name)
+			}
+		}
+	}
+
+	// Depth-first search for cycles: 1 while on the stack, 2 once done
+	visit := make(map[string]int, len(nodes))
+	var walk func(name string) error
+	walk = func(name string) error {
+		switch visit[name] {
+		case 1:
+			return fmt.Errorf("workflow has a cycle through node %s", name)
+		case 2:
+			return nil
+		}
+		visit[name] = 1
+		for _, parent := range nodes[name].DependsOn {
+			if err := walk(parent); err != nil {
+				return err
+			}
+		}
+		visit[name] = 2
+		return nil
+	}
+	for _, node := range w.Nodes {
+		if err := walk(node.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ready decides what happens to a pending node given the state of the
+// others and the results of completed ones. It reports whether the node's
+// dependencies have all finished and, if so, whether it should run.
+func (n WorkflowNode) Ready(states map[string]NodeState, outputs map[string]json.RawMessage) (finished, run bool) {
+	for _, parent := range n.DependsOn {
+		if !states[parent].Finished() {
+			return false, false
+		}
+	}
+
+	if n.When == nil {
+		for _, parent := range n.DependsOn {
+			if states[parent] != NodeCompleted {
+				return true, false
+			}
+		}
+		return true, true
+	}
+
+	want := n.When.Status
+	if want == "" {
+		want = NodeCompleted
+	}
+	if states[n.When.Node] != want {
+		return true, false
+	}
+	if len(n.When.Equals) == 0 {
+		return true, true
+	}
+	return true, n.When.matches(outputs[n.When.Node])
+}
+
+// matches reports whether the value at the condition's path equals Equals
+func (c *NodeCondition) matches(output json.RawMessage) bool {
+	var value interface{}
+	if err := json.Unmarshal(output, &value); err != nil {
+		return false
+	}
+	if c.Path != "" {
+		for _, field := range strings.Split(c.Path, ".") {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return false
+			}
+			if value, ok = object[field]; !ok {
+				return false
+			}
+		}
+	}
+
+	var expected interface{}
+	if err := json.Unmarshal(c.Equals, &expected); err != nil {
+		return false
+	}
+	got, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	want, err := json.Marshal(expected)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(got, want)
+}
@@ -0,0 +1,150 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWorkflowValidate(t *testing.T) {
+	node := func(name string, dependsOn ...string) WorkflowNode {
+		return WorkflowNode{Name: name, Type: "step", Payload: json.RawMessage(`{}`), DependsOn: dependsOn}
+	}
+	withWhen := func(n WorkflowNode, when NodeCondition) WorkflowNode {
+		n.When = &when
+		return n
+	}
+
+	tests := []struct {
+		name    string
+		nodes   []WorkflowNode
+		wantErr string
+	}{
+		{name: "single node", nodes: []WorkflowNode{node("a")}},
+		{name: "diamond", nodes: []WorkflowNode{node("a"), node("b", "a"), node("c", "a"), node("d", "b", "c")}},
+		{name: "condition on a parent", nodes: []WorkflowNode{node("a"), withWhen(node("b", "a"), NodeCondition{Node: "a", Status: NodeFailed})}},
+		{name: "no nodes", nodes: nil, wantErr: "at least one node"},
+		{name: "unnamed node", nodes: []WorkflowNode{node("")}, wantErr: "name is required"},
+		{name: "duplicate name", nodes: []WorkflowNode{node("a"), node("a")}, wantErr: "defined twice"},
+		{name: "missing type", nodes: []WorkflowNode{{Name: "a", Payload: json.RawMessage(`{}`)}}, wantErr: "type is required"},
+		{name: "missing payload", nodes: []WorkflowNode{{Name: "a", Type: "step"}}, wantErr: "payload is required"},
+		{name: "unknown dependency", nodes: []WorkflowNode{node("a", "ghost")}, wantErr: "unknown node ghost"},
+		{name: "repeated dependency", nodes: []WorkflowNode{node("a"), node("b", "a", "a")}, wantErr: "twice"},
+		{name: "self cycle", nodes: []WorkflowNode{node("a", "a")}, wantErr: "cycle"},
+		{name: "longer cycle", nodes: []WorkflowNode{node("a", "c"), node("b", "a"), node("c", "b")}, wantErr: "cycle"},
+		{
+			name:    "condition on a non-parent",
+			nodes:   []WorkflowNode{node("a"), node("x"), withWhen(node("b", "a"), NodeCondition{Node: "x"})},
+			wantErr: "not in its depends_on",
+		},
+		{
+			name:    "condition on a pending state",
+			nodes:   []WorkflowNode{node("a"), withWhen(node("b", "a"), NodeCondition{Node: "a", Status: NodePending})},
+			wantErr: "condition status",
+		},
+		{
+			name:    "condition equals not JSON",
+			nodes:   []WorkflowNode{node("a"), withWhen(node("b", "a"), NodeCondition{Node: "a", Equals: json.RawMessage(`{`)})},
+			wantErr: "not valid JSON",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Workflow{Nodes: tt.nodes}).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWorkflowNodeReady(t *testing.T) {
+	tests := []struct {
+		name         string
+		node         WorkflowNode
+		states       map[string]NodeState
+		outputs      map[string]json.RawMessage
+		wantFinished bool
+		wantRun      bool
+	}{
+		{
+			name:         "no dependencies",
+			node:         WorkflowNode{Name: "a"},
+			wantFinished: true, wantRun: true,
+		},
+		{
+			name:         "parent still running",
+			node:         WorkflowNode{Name: "b", DependsOn: []string{"a"}},
+			states:       map[string]NodeState{"a": NodeEnqueued},
+			wantFinished: false, wantRun: false,
+		},
+		{
+			name:         "all parents completed",
+			node:         WorkflowNode{Name: "c", DependsOn: []string{"a", "b"}},
+			states:       map[string]NodeState{"a": NodeCompleted, "b": NodeCompleted},
+			wantFinished: true, wantRun: true,
+		},
+		{
+			name:         "a parent skipped",
+			node:         WorkflowNode{Name: "c", DependsOn: []string{"a", "b"}},
+			states:       map[string]NodeState{"a": NodeCompleted, "b": NodeSkipped},
+			wantFinished: true, wantRun: false,
+		},
+		{
+			name:         "failure condition holds",
+			node:         WorkflowNode{Name: "b", DependsOn: []string{"a"}, When: &NodeCondition{Node: "a", Status: NodeFailed}},
+			states:       map[string]NodeState{"a": NodeFailed},
+			wantFinished: true, wantRun: true,
+		},
+		{
+			name:         "failure condition doesn't hold",
+			node:         WorkflowNode{Name: "b", DependsOn: []string{"a"}, When: &NodeCondition{Node: "a", Status: NodeFailed}},
+			states:       map[string]NodeState{"a": NodeCompleted},
+			wantFinished: true, wantRun: false,
+		},
+		{
+			name:         "output path equals",
+			node:         WorkflowNode{Name: "b", DependsOn: []string{"a"}, When: &NodeCondition{Node: "a", Path: "review.score", Equals: json.RawMessage(`5`)}},
+			states:       map[string]NodeState{"a": NodeCompleted},
+			outputs:      map[string]json.RawMessage{"a": json.RawMessage(`{"review":{"score":5}}`)},
+			wantFinished: true, wantRun: true,
+		},
+		{
+			name:         "output path differs",
+			node:         WorkflowNode{Name: "b", DependsOn: []string{"a"}, When: &NodeCondition{Node: "a", Path: "review.score", Equals: json.RawMessage(`5`)}},
+			states:       map[string]NodeState{"a": NodeCompleted},
+			outputs:      map[string]json.RawMessage{"a": json.RawMessage(`{"review":{"score":3}}`)},
+			wantFinished: true, wantRun: false,
+		},
+		{
+			name:         "output path missing",
+			node:         WorkflowNode{Name: "b", DependsOn: []string{"a"}, When: &NodeCondition{Node: "a", Path: "review.score", Equals: json.RawMessage(`5`)}},
+			states:       map[string]NodeState{"a": NodeCompleted},
+			outputs:      map[string]json.RawMessage{"a": json.RawMessage(`{"review":"pending"}`)},
+			wantFinished: true, wantRun: false,
+		},
+		{
+			name:         "whole output equals",
+			node:         WorkflowNode{Name: "b", DependsOn: []string{"a"}, When: &NodeCondition{Node: "a", Equals: json.RawMessage(`{"ok": true}`)}},
+			states:       map[string]NodeState{"a": NodeCompleted},
+			outputs:      map[string]json.RawMessage{"a": json.RawMessage(`{"ok":true}`)},
+			wantFinished: true, wantRun: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finished, run := tt.node.Ready(tt.states, tt.outputs)
+			if finished != tt.wantFinished || run != tt.wantRun {
+				t.Fatalf("Ready = %v, %v; want %v, %v", finished, run, tt.wantFinished, tt.wantRun)
+			}
+		})
+	}
+}